@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/services/auditlog"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+	"github.com/grafana/grafana/pkg/services/team"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// auditStateReaders returns the readers of the resources changed through the HTTP API, keyed by the kind
+// the audit log records them as. The UIDs are the identifiers used in the paths of the API.
+func (hs *HTTPServer) auditStateReaders() map[string]auditlog.StateReader {
+	return map[string]auditlog.StateReader{
+		"dashboard": func(ctx context.Context, orgID int64, uid string) (any, error) {
+			dash, err := hs.DashboardService.GetDashboard(ctx, &dashboards.GetDashboardQuery{UID: uid, OrgID: orgID})
+			if err != nil {
+				return nil, err
+			}
+			return dash.Data, nil
+		},
+		"dashboard-permissions": func(ctx context.Context, orgID int64, uid string) (any, error) {
+			requester, err := identity.GetRequester(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return hs.dashboardPermissionsService.GetPermissions(ctx, requester, uid)
+		},
+		"datasource": func(ctx context.Context, orgID int64, uid string) (any, error) {
+			// Data sources are identified by their UID, their ID or their name depending on the route.
+			ds, err := hs.DataSourcesService.GetDataSource(ctx, &datasources.GetDataSourceQuery{UID: uid, OrgID: orgID})
+			if err == nil {
+				return ds, nil
+			}
+			if id, parseErr := strconv.ParseInt(uid, 10, 64); parseErr == nil {
+				if ds, err = hs.DataSourcesService.GetDataSource(ctx, &datasources.GetDataSourceQuery{ID: id, OrgID: orgID}); err == nil {
+					return ds, nil
+				}
+			}
+			return hs.DataSourcesService.GetDataSource(ctx, &datasources.GetDataSourceQuery{Name: uid, OrgID: orgID})
+		},
+		"folder": func(ctx context.Context, orgID int64, uid string) (any, error) {
+			requester, err := identity.GetRequester(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return hs.folderService.Get(ctx, &folder.GetFolderQuery{UID: &uid, OrgID: orgID, SignedInUser: requester})
+		},
+		"folder-permissions": func(ctx context.Context, orgID int64, uid string) (any, error) {
+			requester, err := identity.GetRequester(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return hs.folderPermissionsService.GetPermissions(ctx, requester, uid)
+		},
+		"team": func(ctx context.Context, orgID int64, uid string) (any, error) {
+			requester, err := identity.GetRequester(ctx)
+			if err != nil {
+				return nil, err
+			}
+			query := &team.GetTeamByIDQuery{OrgID: orgID, UID: uid, SignedInUser: requester}
+			if id, err := strconv.ParseInt(uid, 10, 64); err == nil {
+				query.ID = id
+			}
+			return hs.TeamService.GetTeamByID(ctx, query)
+		},
+		"user": func(ctx context.Context, orgID int64, uid string) (any, error) {
+			id, err := strconv.ParseInt(uid, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			return hs.userService.GetProfile(ctx, &user.GetUserProfileQuery{UserID: id})
+		},
+		"org": func(ctx context.Context, orgID int64, uid string) (any, error) {
+			id, err := strconv.ParseInt(uid, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			return hs.orgService.GetByID(ctx, &org.GetOrgByIDQuery{ID: id})
+		},
+		"service-account": func(ctx context.Context, orgID int64, uid string) (any, error) {
+			id, err := strconv.ParseInt(uid, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			return hs.serviceAccountsService.RetrieveServiceAccount(ctx, &serviceaccounts.GetServiceAccountQuery{OrgID: orgID, ID: id})
+		},
+	}
+}
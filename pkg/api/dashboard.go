@@ -1035,6 +1035,121 @@ func (hs *HTTPServer) RestoreDashboardVersion(c *contextmodel.ReqContext) respon
 	return hs.postDashboard(c, saveCmd)
 }
 
+// swagger:route GET /dashboards/uid/{uid}/versions/{DashboardVersionID}/diff dashboard_versions getDashboardVersionDiffByUID
+//
+// Get a structured diff between two versions of a dashboard.
+//
+// Returns the JSON patch (RFC 6902) which transforms the given version into the
+// version of the `new` query parameter, or into the current dashboard when it is
+// omitted, along with the changed panels and queries.
+//
+// Responses:
+// 200: dashboardVersionDiffResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) GetDashboardVersionDiff(c *contextmodel.ReqContext) response.Response {
+	ctx, span := tracer.Start(c.Req.Context(), "api.GetDashboardVersionDiff")
+	defer span.End()
+	c.Req = c.Req.WithContext(ctx)
+
+	dash, rsp := hs.getDashboardHelper(c.Req.Context(), c.GetOrgID(), 0, web.Params(c.Req)[":uid"])
+	if rsp != nil {
+		return rsp
+	}
+
+	baseVersion, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "version is invalid", err)
+	}
+	newVersion := c.QueryInt64("new")
+	if newVersion < 0 {
+		return response.Error(http.StatusBadRequest, "new version is invalid", nil)
+	}
+
+	diff, err := hs.dashboardVersionService.Diff(c.Req.Context(), &dashver.DiffDashboardVersionsQuery{
+		DashboardID:  dash.ID,
+		DashboardUID: dash.UID,
+		OrgID:        c.GetOrgID(),
+		BaseVersion:  baseVersion,
+		NewVersion:   newVersion,
+	})
+	if err != nil {
+		if errors.Is(err, dashver.ErrDashboardVersionNotFound) {
+			return response.Error(http.StatusNotFound, "Dashboard version not found", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Unable to compute diff", err)
+	}
+
+	return response.JSON(http.StatusOK, diff)
+}
+
+// swagger:route POST /dashboards/uid/{uid}/restore-panels dashboard_versions restoreDashboardPanelsByUID
+//
+// Restore panels of a dashboard from a given dashboard version.
+//
+// The panels are copied from the version into the current dashboard, which is
+// saved as a new version. The other panels and settings are left untouched.
+//
+// Responses:
+// 200: postDashboardResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) RestoreDashboardPanels(c *contextmodel.ReqContext) response.Response {
+	ctx, span := tracer.Start(c.Req.Context(), "api.RestoreDashboardPanels")
+	defer span.End()
+	c.Req = c.Req.WithContext(ctx)
+
+	apiCmd := dtos.RestoreDashboardPanelsCommand{}
+	if err := web.Bind(c.Req, &apiCmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	dash, rsp := hs.getDashboardHelper(c.Req.Context(), c.GetOrgID(), 0, web.Params(c.Req)[":uid"])
+	if rsp != nil {
+		return rsp
+	}
+
+	versionQuery := dashver.GetDashboardVersionQuery{DashboardID: dash.ID, DashboardUID: dash.UID, Version: apiCmd.Version, OrgID: c.GetOrgID()}
+	version, err := hs.dashboardVersionService.Get(c.Req.Context(), &versionQuery)
+	if err != nil {
+		return response.Error(http.StatusNotFound, "Dashboard version not found", nil)
+	}
+
+	data, err := dashdiffs.RestorePanels(dash.Data, version.Data, apiCmd.PanelIDs)
+	if err != nil {
+		if errors.Is(err, dashdiffs.ErrPanelNotFound) {
+			return response.Error(http.StatusNotFound, "Panel not found in dashboard version", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to restore panels", err)
+	}
+
+	if compareDashboardData(data.MustMap(), dash.Data.MustMap()) {
+		return response.Error(http.StatusBadRequest, "Current panels are identical to the specified version", nil)
+	}
+
+	var userID int64
+	if id, err := identity.UserIdentifier(c.GetID()); err == nil {
+		userID = id
+	}
+
+	saveCmd := dashboards.SaveDashboardCommand{}
+	saveCmd.OrgID = c.GetOrgID()
+	saveCmd.UserID = userID
+	saveCmd.Dashboard = data
+	saveCmd.Dashboard.Set("version", dash.Version)
+	saveCmd.Dashboard.Set("uid", dash.UID)
+	saveCmd.Message = dashverimpl.PanelsRestoreMessage(version.Version, apiCmd.PanelIDs)
+	saveCmd.FolderUID = dash.FolderUID
+
+	return hs.postDashboard(c, saveCmd)
+}
+
 func compareDashboardData(versionData, dashData map[string]any) bool {
 	// these can be different but the actual data is the same
 	delete(versionData, "version")
@@ -1127,6 +1242,29 @@ type RestoreDashboardVersionByUIDParams struct {
 	UID string `json:"uid"`
 }
 
+// swagger:parameters getDashboardVersionDiffByUID
+type GetDashboardVersionDiffByUIDParams struct {
+	// in:path
+	DashboardVersionID int64
+	// in:path
+	// required:true
+	UID string `json:"uid"`
+	// The version to compare with, the current dashboard by default
+	// in:query
+	// required:false
+	New int64 `json:"new"`
+}
+
+// swagger:parameters restoreDashboardPanelsByUID
+type RestoreDashboardPanelsByUIDParams struct {
+	// in:body
+	// required:true
+	Body dtos.RestoreDashboardPanelsCommand
+	// in:path
+	// required:true
+	UID string `json:"uid"`
+}
+
 // swagger:parameters getDashboardVersionByID
 type GetDashboardVersionByIDParams struct {
 	// in:path
@@ -1314,6 +1452,12 @@ type DashboardVersionResponse struct {
 	Body *dashver.DashboardVersionMeta `json:"body"`
 }
 
+// swagger:response dashboardVersionDiffResponse
+type DashboardVersionDiffResponse struct {
+	// in: body
+	Body *dashver.DashboardVersionDiff `json:"body"`
+}
+
 // swagger:parameters restoreDeletedDashboardByUID
 type RestoreDeletedDashboardByUID struct {
 	// in:path
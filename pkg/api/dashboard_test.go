@@ -343,6 +343,7 @@ func TestHTTPServer_GetDashboardVersions_AccessControl(t *testing.T) {
 				ExpectedListDashboarVersions: []*dashver.DashboardVersionDTO{},
 				ExpectedDashboardVersions:    expectedDashVersions,
 				ExpectedDashboardVersion:     &dashver.DashboardVersionDTO{},
+				ExpectedDiff:                 &dashver.DashboardVersionDiff{BaseVersion: 1, NewVersion: 2},
 			}
 		})
 	}
@@ -421,6 +422,39 @@ func TestHTTPServer_GetDashboardVersions_AccessControl(t *testing.T) {
 		assert.Equal(t, http.StatusForbidden, res.StatusCode)
 		require.NoError(t, res.Body.Close())
 	})
+
+	getVersionDiff := func(server *webtest.Server, permissions []accesscontrol.Permission) (*http.Response, error) {
+		return server.Send(webtest.RequestWithSignedInUser(server.NewGetRequest("/api/dashboards/uid/1/versions/1/diff?new=2"), userWithPermissions(1, permissions)))
+	}
+
+	t.Run("Should be able to get a structured diff with correct permission", func(t *testing.T) {
+		server := setup()
+
+		res, err := getVersionDiff(server, []accesscontrol.Permission{{Action: dashboards.ActionDashboardsWrite, Scope: "dashboards:uid:1"}})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		var diff dashver.DashboardVersionDiff
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&diff))
+		assert.Equal(t, 2, diff.NewVersion)
+		require.NoError(t, res.Body.Close())
+
+		res, err = getVersionDiff(server, []accesscontrol.Permission{})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+		require.NoError(t, res.Body.Close())
+	})
+
+	t.Run("Should not restore panels which are not in the version", func(t *testing.T) {
+		server := setup()
+
+		jsonBytes, err := json.Marshal(&dtos.RestoreDashboardPanelsCommand{Version: 1, PanelIDs: []int64{3}})
+		require.NoError(t, err)
+		req := server.NewPostRequest("/api/dashboards/uid/1/restore-panels", bytes.NewReader(jsonBytes))
+		res, err := server.SendJSON(webtest.RequestWithSignedInUser(req, userWithPermissions(1, []accesscontrol.Permission{{Action: dashboards.ActionDashboardsWrite, Scope: "dashboards:uid:1"}})))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		require.NoError(t, res.Body.Close())
+	})
 }
 
 func TestDashboardAPIEndpoint(t *testing.T) {
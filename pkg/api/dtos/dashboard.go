@@ -62,3 +62,8 @@ type CalculateDiffTarget struct {
 type RestoreDashboardVersionCommand struct {
 	Version int64 `json:"version" binding:"Required"`
 }
+
+type RestoreDashboardPanelsCommand struct {
+	Version  int64   `json:"version" binding:"Required"`
+	PanelIDs []int64 `json:"panelIds" binding:"Required"`
+}
@@ -59,6 +59,58 @@ type GitHubRepositoryConfig struct {
 	Path string `json:"path,omitempty"`
 }
 
+type GitRepositoryConfig struct {
+	// The URL of the git remote. HTTPS (e.g. `https://git.example.com/example/test.git`),
+	// SSH (e.g. `ssh://git@git.example.com/example/test.git` or `git@git.example.com:example/test.git`)
+	// and `file://` URLs are supported.
+	URL string `json:"url,omitempty"`
+
+	// The branch to use in the repository.
+	Branch string `json:"branch"`
+
+	// Username for HTTPS authentication. When empty, `git` is used.
+	// SSH remotes use the user of the URL instead.
+	Username string `json:"username,omitempty"`
+	// Token or password for HTTPS authentication. If set, it will be encrypted into encryptedToken, then set to an empty string again.
+	Token string `json:"token,omitempty"`
+	// Token for HTTPS authentication, but encrypted. This is not possible to read back to a user decrypted.
+	// +listType=atomic
+	EncryptedToken []byte `json:"encryptedToken,omitempty"`
+
+	// PEM encoded private key for SSH authentication. If set, it will be encrypted into encryptedSSHKey, then set to an empty string again.
+	SSHKey string `json:"sshKey,omitempty"`
+	// Private key for SSH authentication, but encrypted. This is not possible to read back to a user decrypted.
+	// +listType=atomic
+	EncryptedSSHKey []byte `json:"encryptedSSHKey,omitempty"`
+	// Public keys of the SSH server, in the format of an OpenSSH known_hosts file.
+	// When empty, the known_hosts files of the system are used to verify the server.
+	KnownHosts string `json:"knownHosts,omitempty"`
+
+	// The server that hosts the repository. When set, its API is used to register the webhook and to comment on merge requests.
+	// The API is expected on the host of the URL, using a token with API access.
+	Provider GitProvider `json:"provider,omitempty"`
+
+	// Whether we should show dashboard previews for merge requests.
+	// By default, this is false (i.e. we will not create previews).
+	GenerateDashboardPreviews bool `json:"generateDashboardPreviews,omitempty"`
+
+	// Path is the subdirectory for the Grafana data. If specified, Grafana will ignore anything that is outside this directory in the repository.
+	// This is usually something like `grafana/`. Trailing and leading slash are not required. They are always added when needed.
+	// The path is relative to the root of the repository, regardless of the leading slash.
+	Path string `json:"path,omitempty"`
+}
+
+// GitProvider defines the servers whose API can be used by a git repository
+// +enum
+type GitProvider string
+
+// GitProvider values
+const (
+	GitLabProvider GitProvider = "gitlab"
+	// Gitea and Forgejo share the same API
+	GiteaProvider GitProvider = "gitea"
+)
+
 // RepositoryType defines the types of Repository
 // +enum
 type RepositoryType string
@@ -67,6 +119,7 @@ type RepositoryType string
 const (
 	LocalRepositoryType  RepositoryType = "local"
 	GitHubRepositoryType RepositoryType = "github"
+	GitRepositoryType    RepositoryType = "git"
 )
 
 type RepositorySpec struct {
@@ -88,13 +141,16 @@ type RepositorySpec struct {
 	Type RepositoryType `json:"type"`
 
 	// The repository on the local file system.
-	// Mutually exclusive with local | github.
+	// Mutually exclusive with local | github | git.
 	Local *LocalRepositoryConfig `json:"local,omitempty"`
 
 	// The repository on GitHub.
-	// Mutually exclusive with local | github.
-	// TODO: github or just 'git'??
+	// Mutually exclusive with local | github | git.
 	GitHub *GitHubRepositoryConfig `json:"github,omitempty"`
+
+	// The repository on any git server.
+	// Mutually exclusive with local | github | git.
+	Git *GitRepositoryConfig `json:"git,omitempty"`
 }
 
 // SyncTargetType defines where we want all values to resolve
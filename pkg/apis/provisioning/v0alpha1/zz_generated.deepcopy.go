@@ -127,6 +127,32 @@ func (in *GitHubRepositoryConfig) DeepCopy() *GitHubRepositoryConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitRepositoryConfig) DeepCopyInto(out *GitRepositoryConfig) {
+	*out = *in
+	if in.EncryptedToken != nil {
+		in, out := &in.EncryptedToken, &out.EncryptedToken
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	if in.EncryptedSSHKey != nil {
+		in, out := &in.EncryptedSSHKey, &out.EncryptedSSHKey
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepositoryConfig.
+func (in *GitRepositoryConfig) DeepCopy() *GitRepositoryConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(GitRepositoryConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HealthStatus) DeepCopyInto(out *HealthStatus) {
 	*out = *in
@@ -501,6 +527,11 @@ func (in *RepositorySpec) DeepCopyInto(out *RepositorySpec) {
 		*out = new(GitHubRepositoryConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Git != nil {
+		in, out := &in.Git, &out.Git
+		*out = new(GitRepositoryConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -44,7 +44,7 @@ func CLICommand(version string) *cli.Command {
 			},
 			&cli.StringFlag{
 				Name:    "repo",
-				Usage:   "URL to the plugin repository",
+				Usage:   "URL to the plugin repository: a catalog with the grafana.com API, a file:// directory of plugin archives or an oci:// registry namespace",
 				Value:   "https://grafana.com/api/plugins",
 				EnvVars: []string{"GF_PLUGIN_REPO"},
 			},
@@ -103,6 +103,17 @@ func newInstallPluginOpts(c utils.CommandLine) pluginInstallOpts {
 	}
 }
 
+// newRepository returns the plugin repository of the options, which is either grafana.com or an alternative
+// repository like a self-hosted catalog, a directory of plugin archives or an OCI registry.
+func newRepository(o pluginInstallOpts) *repo.Manager {
+	return repo.NewManager(repo.ManagerCfg{
+		SkipTLSVerify:      o.insecure,
+		BaseURL:            o.repoURL,
+		Logger:             services.Logger,
+		GrafanaComAPIToken: o.gcomToken,
+	})
+}
+
 // installPlugin downloads the plugin code as a zip file from the Grafana.com API
 // and then extracts the zip into the plugin's directory.
 func installPlugin(ctx context.Context, pluginID, version string, o pluginInstallOpts) error {
@@ -133,12 +144,7 @@ func doInstallPlugin(ctx context.Context, pluginID, version string, o pluginInst
 		}
 	}
 
-	repository := repo.NewManager(repo.ManagerCfg{
-		SkipTLSVerify:      o.insecure,
-		BaseURL:            o.repoURL,
-		Logger:             services.Logger,
-		GrafanaComAPIToken: o.gcomToken,
-	})
+	repository := newRepository(o)
 
 	compatOpts := repo.NewCompatOpts(services.GrafanaVersion, runtime.GOOS, runtime.GOARCH)
 
@@ -166,7 +172,8 @@ func doInstallPlugin(ctx context.Context, pluginID, version string, o pluginInst
 			return nil
 		}
 
-		if archive, err = repository.GetPluginArchiveByURL(ctx, archiveInfo.URL, compatOpts); err != nil {
+		// Fetch the resolved version again to verify the checksum of its archive.
+		if archive, err = repository.GetPluginArchive(ctx, pluginID, archiveInfo.Version, compatOpts); err != nil {
 			return err
 		}
 	}
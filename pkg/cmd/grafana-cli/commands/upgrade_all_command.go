@@ -2,17 +2,21 @@ package commands
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"runtime"
 
 	"github.com/hashicorp/go-version"
 
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
-	"github.com/grafana/grafana/pkg/cmd/grafana-cli/models"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/services"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
 	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/repo"
 )
 
-func shouldUpgrade(installed plugins.FoundPlugin, remote models.Plugin) bool {
+func shouldUpgrade(installed plugins.FoundPlugin, latest string) bool {
 	installedVer := installed.JSONData.Info.Version
 	if installedVer == "" {
 		installedVer = "0.0.0"
@@ -22,43 +26,64 @@ func shouldUpgrade(installed plugins.FoundPlugin, remote models.Plugin) bool {
 		return false
 	}
 
-	latest := latestSupportedVersion(remote)
-	latestVersion, err := version.NewVersion(latest.Version)
+	latestVersion, err := version.NewVersion(latest)
 	if err != nil {
 		return false
 	}
 	return installedVersion.LessThan(latestVersion)
 }
 
+// latestPluginVersion returns the latest version of the plugin in the repository that is compatible with Grafana
+// and the system, or false if there is none.
+func latestPluginVersion(ctx context.Context, repository repo.Service, pluginID string) (string, bool, error) {
+	compatOpts := repo.NewCompatOpts(services.GrafanaVersion, runtime.GOOS, runtime.GOARCH)
+	v, err := repository.PluginVersion(ctx, pluginID, "", compatOpts)
+	if err != nil {
+		if isPluginNotFound(err) {
+			logger.Debugf("No compatible version of %s found in the plugin repository: %v\n", pluginID, err)
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return v.Version, true, nil
+}
+
+// isPluginNotFound returns whether the plugin, or a version of it compatible with Grafana and the system, is not
+// found in the repository.
+func isPluginNotFound(err error) bool {
+	var errResp repo.ErrResponse4xx
+	if errors.As(err, &errResp) {
+		return errResp.StatusCode() == http.StatusNotFound
+	}
+	var gfErr errutil.Error
+	return errors.As(err, &gfErr) && gfErr.Reason.Status() == errutil.StatusNotFound
+}
+
 func upgradeAllCommand(c utils.CommandLine) error {
 	pluginsDir := c.PluginDirectory()
 
 	localPlugins := services.GetLocalPlugins(pluginsDir)
 
-	remotePlugins, err := services.ListAllPlugins(c.String("repo"))
-	if err != nil {
-		return err
-	}
+	opts := newInstallPluginOpts(c)
+	repository := newRepository(opts)
+	ctx := repo.WithRequestOrigin(context.Background(), "cli")
 
 	pluginsToUpgrade := make([]plugins.FoundPlugin, 0)
 
 	for _, localPlugin := range localPlugins {
-		for _, p := range remotePlugins.Plugins {
-			remotePlugin := p
-			if localPlugin.Primary.JSONData.ID != remotePlugin.ID {
-				continue
-			}
-			if shouldUpgrade(localPlugin.Primary, remotePlugin) {
-				pluginsToUpgrade = append(pluginsToUpgrade, localPlugin.Primary)
-			}
+		latest, ok, err := latestPluginVersion(ctx, repository, localPlugin.Primary.JSONData.ID)
+		if err != nil {
+			return err
+		}
+		if ok && shouldUpgrade(localPlugin.Primary, latest) {
+			pluginsToUpgrade = append(pluginsToUpgrade, localPlugin.Primary)
 		}
 	}
 
-	ctx := context.Background()
 	for _, p := range pluginsToUpgrade {
 		logger.Infof("Updating %v \n", p.JSONData.ID)
 
-		err = installPlugin(ctx, p.JSONData.ID, "", newInstallPluginOpts(c))
+		err := installPlugin(ctx, p.JSONData.ID, "", opts)
 		if err != nil {
 			return err
 		}
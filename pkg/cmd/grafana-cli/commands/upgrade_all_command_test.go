@@ -6,28 +6,22 @@ import (
 
 	"github.com/stretchr/testify/require"
 
-	"github.com/grafana/grafana/pkg/cmd/grafana-cli/models"
 	"github.com/grafana/grafana/pkg/plugins"
 )
 
 func TestVersionComparison(t *testing.T) {
 	t.Run("Validate that version is outdated", func(t *testing.T) {
-		versions := []models.Version{
-			{Version: "1.1.1"},
-			{Version: "2.0.0"},
-		}
-
 		upgradeablePlugins := []struct {
 			have      plugins.FoundPlugin
-			requested models.Plugin
+			requested string
 		}{
 			{
 				have:      plugins.FoundPlugin{JSONData: plugins.JSONData{Info: plugins.Info{Version: "0.0.0"}}},
-				requested: models.Plugin{Versions: versions},
+				requested: "2.0.0",
 			},
 			{
 				have:      plugins.FoundPlugin{JSONData: plugins.JSONData{Info: plugins.Info{Version: "1.0.0"}}},
-				requested: models.Plugin{Versions: versions},
+				requested: "2.0.0",
 			},
 		}
 
@@ -39,22 +33,17 @@ func TestVersionComparison(t *testing.T) {
 	})
 
 	t.Run("Validate that version is ok", func(t *testing.T) {
-		versions := []models.Version{
-			{Version: "1.1.1"},
-			{Version: "2.0.0"},
-		}
-
 		shouldNotUpgrade := []struct {
 			have      plugins.FoundPlugin
-			requested models.Plugin
+			requested string
 		}{
 			{
 				have:      plugins.FoundPlugin{JSONData: plugins.JSONData{Info: plugins.Info{Version: "2.0.0"}}},
-				requested: models.Plugin{Versions: versions},
+				requested: "2.0.0",
 			},
 			{
 				have:      plugins.FoundPlugin{JSONData: plugins.JSONData{Info: plugins.Info{Version: "6.0.0"}}},
-				requested: models.Plugin{Versions: versions},
+				requested: "2.0.0",
 			},
 		}
 
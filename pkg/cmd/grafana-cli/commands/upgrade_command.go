@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/services"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/plugins/repo"
 )
 
 func upgradeCommand(c utils.CommandLine) error {
@@ -25,17 +26,21 @@ func upgradeCommand(c utils.CommandLine) error {
 		return err
 	}
 
-	plugin, err := services.GetPluginInfoFromRepo(pluginID, c.PluginRepoURL())
+	opts := newInstallPluginOpts(c)
+	latest, ok, err := latestPluginVersion(repo.WithRequestOrigin(ctx, "cli"), newRepository(opts), pluginID)
 	if err != nil {
 		return err
 	}
+	if !ok {
+		return fmt.Errorf("failed to find a version of plugin '%s' compatible with your system in the plugin repository", pluginID)
+	}
 
-	if shouldUpgrade(localPlugin, plugin) {
+	if shouldUpgrade(localPlugin, latest) {
 		if err = uninstallPlugin(ctx, pluginID, c); err != nil {
 			return fmt.Errorf("failed to remove plugin '%s': %w", pluginID, err)
 		}
 
-		err = installPlugin(ctx, pluginID, "", newInstallPluginOpts(c))
+		err = installPlugin(ctx, pluginID, "", opts)
 		if err == nil {
 			logRestartNotice()
 		}
@@ -63,7 +63,7 @@ The plugin repository URL is determined in the following order:
 1. --repo flag value if it is specified
 2. --repo flag value if set via the environment variable called "GF_PLUGIN_REPO"
 3. --configOverrides parameter (only if --config is set too)
-4. --config parameter, from which we are looking at GrafanaComAPIURL setting
+4. --config parameter, from which we are looking at PluginRepositoryURL and then GrafanaComAPIURL settings
 5. fallback to default value which is https://grafana.com/api/plugins
 **/
 
@@ -80,6 +80,8 @@ func (c *ContextCommandLine) PluginRepoURL() string {
 
 		if err != nil {
 			logger.Debug("Could not parse config file", err)
+		} else if cfg.PluginRepositoryURL != "" {
+			return cfg.PluginRepositoryURL
 		} else if cfg.GrafanaComAPIURL != "" {
 			return cfg.GrafanaComAPIURL + "/plugins"
 		}
@@ -0,0 +1,376 @@
+package dashdiffs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// ErrPanelNotFound occurs when a panel to restore does not exist in the source dashboard.
+var ErrPanelNotFound = errors.New("dashdiff: panel not found")
+
+// Operation is a JSON patch operation, as defined in RFC 6902.
+type Operation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value,omitempty"`
+}
+
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+)
+
+// MarshalJSON keeps the value of add and replace operations, even when it is null.
+func (o Operation) MarshalJSON() ([]byte, error) {
+	if o.Op == OpRemove {
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{o.Op, o.Path})
+	}
+	return json.Marshal(struct {
+		Op    string `json:"op"`
+		Path  string `json:"path"`
+		Value any    `json:"value"`
+	}{o.Op, o.Path, o.Value})
+}
+
+// ElementChange tells whether a panel or a query was added, removed or modified.
+type ElementChange string
+
+const (
+	ElementAdded    ElementChange = "added"
+	ElementRemoved  ElementChange = "removed"
+	ElementModified ElementChange = "modified"
+)
+
+// PanelChange describes the changes of a single panel, matched by ID between both dashboards.
+type PanelChange struct {
+	ID     int64         `json:"id"`
+	Title  string        `json:"title"`
+	Change ElementChange `json:"change"`
+	// Patch applies to the panel itself, so its paths are relative to the panel.
+	Patch   []Operation   `json:"patch"`
+	Queries []QueryChange `json:"queries,omitempty"`
+}
+
+// QueryChange describes the changes of a single query of a panel, matched by refId.
+type QueryChange struct {
+	RefID  string        `json:"refId"`
+	Change ElementChange `json:"change"`
+	Patch  []Operation   `json:"patch"`
+}
+
+// StructuredDiff is a machine readable diff of two dashboards.
+type StructuredDiff struct {
+	Patch  []Operation   `json:"patch"`
+	Panels []PanelChange `json:"panels"`
+}
+
+// CalculateStructuredDiff returns the JSON patch which transforms the base dashboard into the new one,
+// along with a summary of the changed panels and queries.
+func CalculateStructuredDiff(baseData, newData *simplejson.Json) (*StructuredDiff, error) {
+	base, err := toGeneric(baseData)
+	if err != nil {
+		return nil, err
+	}
+	next, err := toGeneric(newData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StructuredDiff{
+		Patch:  CalculatePatch(base, next),
+		Panels: panelChanges(base, next),
+	}, nil
+}
+
+// CalculatePatch returns the JSON patch which transforms base into next. Both values must be generic JSON values,
+// as returned by json.Unmarshal into an any.
+func CalculatePatch(base, next any) []Operation {
+	ops := []Operation{}
+	return diffValues(ops, "", base, next)
+}
+
+func diffValues(ops []Operation, path string, base, next any) []Operation {
+	switch b := base.(type) {
+	case map[string]any:
+		if n, ok := next.(map[string]any); ok {
+			return diffObjects(ops, path, b, n)
+		}
+	case []any:
+		if n, ok := next.([]any); ok {
+			return diffArrays(ops, path, b, n)
+		}
+	}
+	if !reflect.DeepEqual(base, next) {
+		ops = append(ops, Operation{Op: OpReplace, Path: path, Value: next})
+	}
+	return ops
+}
+
+func diffObjects(ops []Operation, path string, base, next map[string]any) []Operation {
+	for _, key := range sortedKeys(base) {
+		n, ok := next[key]
+		if !ok {
+			ops = append(ops, Operation{Op: OpRemove, Path: path + "/" + escapePointer(key)})
+			continue
+		}
+		ops = diffValues(ops, path+"/"+escapePointer(key), base[key], n)
+	}
+	for _, key := range sortedKeys(next) {
+		if _, ok := base[key]; !ok {
+			ops = append(ops, Operation{Op: OpAdd, Path: path + "/" + escapePointer(key), Value: next[key]})
+		}
+	}
+	return ops
+}
+
+func diffArrays(ops []Operation, path string, base, next []any) []Operation {
+	common := min(len(base), len(next))
+	for i := 0; i < common; i++ {
+		ops = diffValues(ops, path+"/"+strconv.Itoa(i), base[i], next[i])
+	}
+	// Remove from the end, so that the indexes of the patch stay valid while it is applied.
+	for i := len(base) - 1; i >= common; i-- {
+		ops = append(ops, Operation{Op: OpRemove, Path: path + "/" + strconv.Itoa(i)})
+	}
+	for i := common; i < len(next); i++ {
+		ops = append(ops, Operation{Op: OpAdd, Path: path + "/" + strconv.Itoa(i), Value: next[i]})
+	}
+	return ops
+}
+
+func panelChanges(base, next any) []PanelChange {
+	basePanels, baseOrder := panelsByID(base)
+	nextPanels, nextOrder := panelsByID(next)
+
+	changes := []PanelChange{}
+	for _, id := range baseOrder {
+		b := basePanels[id]
+		n, ok := nextPanels[id]
+		if !ok {
+			changes = append(changes, PanelChange{ID: id, Title: panelTitle(b), Change: ElementRemoved, Patch: []Operation{}})
+			continue
+		}
+		patch := CalculatePatch(b, n)
+		if len(patch) == 0 {
+			continue
+		}
+		changes = append(changes, PanelChange{
+			ID:      id,
+			Title:   panelTitle(n),
+			Change:  ElementModified,
+			Patch:   patch,
+			Queries: queryChanges(b, n),
+		})
+	}
+	for _, id := range nextOrder {
+		n := nextPanels[id]
+		if _, ok := basePanels[id]; !ok {
+			changes = append(changes, PanelChange{
+				ID:      id,
+				Title:   panelTitle(n),
+				Change:  ElementAdded,
+				Patch:   CalculatePatch(map[string]any{}, n),
+				Queries: queryChanges(map[string]any{}, n),
+			})
+		}
+	}
+	return changes
+}
+
+func queryChanges(basePanel, nextPanel map[string]any) []QueryChange {
+	baseQueries, baseOrder := queriesByRefID(basePanel)
+	nextQueries, nextOrder := queriesByRefID(nextPanel)
+
+	var changes []QueryChange
+	for _, refID := range baseOrder {
+		n, ok := nextQueries[refID]
+		if !ok {
+			changes = append(changes, QueryChange{RefID: refID, Change: ElementRemoved, Patch: []Operation{}})
+			continue
+		}
+		if patch := CalculatePatch(baseQueries[refID], n); len(patch) > 0 {
+			changes = append(changes, QueryChange{RefID: refID, Change: ElementModified, Patch: patch})
+		}
+	}
+	for _, refID := range nextOrder {
+		if _, ok := baseQueries[refID]; !ok {
+			changes = append(changes, QueryChange{RefID: refID, Change: ElementAdded, Patch: CalculatePatch(map[string]any{}, nextQueries[refID])})
+		}
+	}
+	return changes
+}
+
+// panelsByID indexes the panels of a dashboard, including the panels of collapsed rows. The panels of a row
+// are not kept in the row itself, so that they are only reported once.
+func panelsByID(dashboard any) (map[int64]map[string]any, []int64) {
+	panels := map[int64]map[string]any{}
+	var order []int64
+	var walk func(list any)
+	walk = func(list any) {
+		items, _ := list.([]any)
+		for _, item := range items {
+			panel, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			id, ok := panelID(panel)
+			if !ok {
+				continue
+			}
+			if nested, ok := panel["panels"]; ok {
+				panel = shallowCopy(panel)
+				delete(panel, "panels")
+				walk(nested)
+			}
+			if _, exists := panels[id]; !exists {
+				order = append(order, id)
+			}
+			panels[id] = panel
+		}
+	}
+	if d, ok := dashboard.(map[string]any); ok {
+		walk(d["panels"])
+	}
+	return panels, order
+}
+
+// queriesByRefID indexes the queries of a panel. Queries without refId are keyed by their position.
+func queriesByRefID(panel map[string]any) (map[string]any, []string) {
+	queries := map[string]any{}
+	var order []string
+	targets, _ := panel["targets"].([]any)
+	for i, target := range targets {
+		refID := fmt.Sprintf("#%d", i)
+		if t, ok := target.(map[string]any); ok {
+			if r, ok := t["refId"].(string); ok && r != "" {
+				refID = r
+			}
+		}
+		if _, exists := queries[refID]; !exists {
+			order = append(order, refID)
+		}
+		queries[refID] = target
+	}
+	return queries, order
+}
+
+// RestorePanels copies the panels with the given IDs from the source dashboard into the target one.
+// A panel which still exists in the target dashboard is replaced in place, otherwise it is appended.
+// The target dashboard is not modified, a copy is returned instead.
+func RestorePanels(targetData, sourceData *simplejson.Json, panelIDs []int64) (*simplejson.Json, error) {
+	target, err := toGeneric(targetData)
+	if err != nil {
+		return nil, err
+	}
+	source, err := toGeneric(sourceData)
+	if err != nil {
+		return nil, err
+	}
+	targetDash, ok := target.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("dashdiff: invalid dashboard")
+	}
+	sourceDash, _ := source.(map[string]any)
+
+	for _, id := range panelIDs {
+		sourcePanels, _ := sourceDash["panels"].([]any)
+		panels, i := findPanel(sourcePanels, id)
+		if panels == nil {
+			return nil, fmt.Errorf("%w: %d", ErrPanelNotFound, id)
+		}
+		panel := panels[i]
+
+		targetPanels, _ := targetDash["panels"].([]any)
+		if panels, i := findPanel(targetPanels, id); panels != nil {
+			panels[i] = panel
+			continue
+		}
+		targetDash["panels"] = append(targetPanels, panel)
+	}
+
+	return simplejson.NewFromAny(targetDash), nil
+}
+
+// findPanel returns the list which holds the panel with the given ID, looking into collapsed rows as well,
+// and the index of the panel in that list.
+func findPanel(panels []any, id int64) ([]any, int) {
+	for i, item := range panels {
+		panel, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		if panelID, ok := panelID(panel); ok && panelID == id {
+			return panels, i
+		}
+		if nested, ok := panel["panels"].([]any); ok {
+			if list, j := findPanel(nested, id); list != nil {
+				return list, j
+			}
+		}
+	}
+	return nil, -1
+}
+
+func panelID(panel map[string]any) (int64, bool) {
+	switch id := panel["id"].(type) {
+	case float64:
+		return int64(id), true
+	case json.Number:
+		v, err := id.Int64()
+		return v, err == nil
+	}
+	return 0, false
+}
+
+func panelTitle(panel map[string]any) string {
+	title, _ := panel["title"].(string)
+	return title
+}
+
+func shallowCopy(m map[string]any) map[string]any {
+	c := make(map[string]any, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func toGeneric(data *simplejson.Json) (any, error) {
+	if data == nil {
+		return map[string]any{}, nil
+	}
+	raw, err := data.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// escapePointer escapes a reference token of a JSON pointer, as defined in RFC 6901.
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
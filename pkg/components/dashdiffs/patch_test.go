@@ -0,0 +1,136 @@
+package dashdiffs
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+const (
+	baseDashboard = `{
+		"title": "Dashboard",
+		"tags": ["a", "b", "c"],
+		"panels": [
+			{"id": 1, "title": "CPU", "type": "timeseries", "targets": [{"refId": "A", "expr": "cpu"}, {"refId": "B", "expr": "load"}]},
+			{"id": 2, "title": "Removed", "type": "stat"},
+			{"id": 3, "title": "Row", "type": "row", "collapsed": true, "panels": [
+				{"id": 4, "title": "Memory", "type": "timeseries", "targets": [{"refId": "A", "expr": "mem"}]}
+			]}
+		]
+	}`
+
+	newDashboard = `{
+		"title": "Dashboard",
+		"tags": ["a"],
+		"description": null,
+		"panels": [
+			{"id": 1, "title": "CPU usage", "type": "timeseries", "targets": [{"refId": "A", "expr": "rate(cpu[5m])"}, {"refId": "C", "expr": "up"}]},
+			{"id": 3, "title": "Row", "type": "row", "collapsed": true, "panels": [
+				{"id": 4, "title": "Memory", "type": "timeseries", "targets": [{"refId": "A", "expr": "mem"}]}
+			]},
+			{"id": 5, "title": "a/b~c", "type": "text"}
+		]
+	}`
+)
+
+func TestCalculatePatch(t *testing.T) {
+	var base, next any
+	require.NoError(t, json.Unmarshal([]byte(`{"a": 1, "b": [1, 2, 3], "c": {"d/e": true}, "f": "x"}`), &base))
+	require.NoError(t, json.Unmarshal([]byte(`{"a": 2, "b": [1], "c": {"d/e": false}, "g": null}`), &next))
+
+	assert.Equal(t, []Operation{
+		{Op: OpReplace, Path: "/a", Value: float64(2)},
+		{Op: OpRemove, Path: "/b/2"},
+		{Op: OpRemove, Path: "/b/1"},
+		{Op: OpReplace, Path: "/c/d~1e", Value: false},
+		{Op: OpRemove, Path: "/f"},
+		{Op: OpAdd, Path: "/g", Value: nil},
+	}, CalculatePatch(base, next))
+
+	assert.Empty(t, CalculatePatch(base, base))
+
+	raw, err := json.Marshal(CalculatePatch(base, next))
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"op": "replace", "path": "/a", "value": 2},
+		{"op": "remove", "path": "/b/2"},
+		{"op": "remove", "path": "/b/1"},
+		{"op": "replace", "path": "/c/d~1e", "value": false},
+		{"op": "remove", "path": "/f"},
+		{"op": "add", "path": "/g", "value": null}
+	]`, string(raw))
+}
+
+func TestCalculateStructuredDiff(t *testing.T) {
+	baseData, err := simplejson.NewJson([]byte(baseDashboard))
+	require.NoError(t, err)
+	newData, err := simplejson.NewJson([]byte(newDashboard))
+	require.NoError(t, err)
+
+	diff, err := CalculateStructuredDiff(baseData, newData)
+	require.NoError(t, err)
+	assert.NotEmpty(t, diff.Patch)
+
+	require.Len(t, diff.Panels, 3)
+
+	cpu := diff.Panels[0]
+	assert.Equal(t, int64(1), cpu.ID)
+	assert.Equal(t, "CPU usage", cpu.Title)
+	assert.Equal(t, ElementModified, cpu.Change)
+	assert.Contains(t, cpu.Patch, Operation{Op: OpReplace, Path: "/title", Value: "CPU usage"})
+	require.Len(t, cpu.Queries, 3)
+	assert.Equal(t, QueryChange{RefID: "A", Change: ElementModified, Patch: []Operation{{Op: OpReplace, Path: "/expr", Value: "rate(cpu[5m])"}}}, cpu.Queries[0])
+	assert.Equal(t, "B", cpu.Queries[1].RefID)
+	assert.Equal(t, ElementRemoved, cpu.Queries[1].Change)
+	assert.Equal(t, "C", cpu.Queries[2].RefID)
+	assert.Equal(t, ElementAdded, cpu.Queries[2].Change)
+
+	assert.Equal(t, int64(2), diff.Panels[1].ID)
+	assert.Equal(t, ElementRemoved, diff.Panels[1].Change)
+
+	assert.Equal(t, int64(5), diff.Panels[2].ID)
+	assert.Equal(t, ElementAdded, diff.Panels[2].Change)
+	assert.Contains(t, diff.Panels[2].Patch, Operation{Op: OpAdd, Path: "/title", Value: "a/b~c"})
+}
+
+func TestRestorePanels(t *testing.T) {
+	baseData, err := simplejson.NewJson([]byte(baseDashboard))
+	require.NoError(t, err)
+	newData, err := simplejson.NewJson([]byte(newDashboard))
+	require.NoError(t, err)
+
+	t.Run("restores modified and removed panels", func(t *testing.T) {
+		restored, err := RestorePanels(newData, baseData, []int64{1, 2})
+		require.NoError(t, err)
+
+		panels := restored.Get("panels").MustArray()
+		require.Len(t, panels, 4)
+		assert.Equal(t, "CPU", restored.Get("panels").GetIndex(0).Get("title").MustString())
+		assert.Equal(t, "Row", restored.Get("panels").GetIndex(1).Get("title").MustString())
+		assert.Equal(t, "a/b~c", restored.Get("panels").GetIndex(2).Get("title").MustString())
+		assert.Equal(t, "Removed", restored.Get("panels").GetIndex(3).Get("title").MustString())
+		assert.Equal(t, []any{"a"}, restored.Get("tags").MustArray(), "other fields must be kept")
+
+		assert.Equal(t, "CPU usage", newData.Get("panels").GetIndex(0).Get("title").MustString(), "the target must not be modified")
+	})
+
+	t.Run("restores panels of collapsed rows in place", func(t *testing.T) {
+		modified, err := simplejson.NewJson([]byte(newDashboard))
+		require.NoError(t, err)
+		modified.Get("panels").GetIndex(1).Get("panels").GetIndex(0).Set("title", "Changed")
+
+		restored, err := RestorePanels(modified, baseData, []int64{4})
+		require.NoError(t, err)
+		require.Len(t, restored.Get("panels").MustArray(), 3)
+		assert.Equal(t, "Memory", restored.Get("panels").GetIndex(1).Get("panels").GetIndex(0).Get("title").MustString())
+	})
+
+	t.Run("fails when the panel does not exist in the source", func(t *testing.T) {
+		_, err := RestorePanels(newData, baseData, []int64{5})
+		require.ErrorIs(t, err, ErrPanelNotFound)
+	})
+}
@@ -2,8 +2,10 @@ package expr
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -92,7 +94,7 @@ type ReduceCommand struct {
 
 // NewReduceCommand creates a new ReduceCMD.
 func NewReduceCommand(refID string, reducer mathexp.ReducerID, varToReduce string, mapper mathexp.ReduceMapper) (*ReduceCommand, error) {
-	_, err := mathexp.GetReduceFunc(reducer)
+	_, err := mathexp.GetSeriesReduceFunc(reducer)
 	if err != nil {
 		return nil, err
 	}
@@ -224,11 +226,13 @@ type ResampleCommand struct {
 
 // NewResampleCommand creates a new ResampleCMD.
 func NewResampleCommand(refID, rawWindow, varToResample string, downsampler mathexp.ReducerID, upsampler mathexp.Upsampler, tr TimeRange) (*ResampleCommand, error) {
-	// TODO: validate reducer here, before execution
 	window, err := gtime.ParseDuration(rawWindow)
 	if err != nil {
 		return nil, fmt.Errorf(`failed to parse resample "window" duration field %q: %w`, window, err)
 	}
+	if _, err := mathexp.GetSeriesReduceFunc(downsampler); err != nil {
+		return nil, fmt.Errorf("invalid resample downsampler: %w", err)
+	}
 	return &ResampleCommand{
 		Window:        window,
 		VarToResample: varToResample,
@@ -327,6 +331,262 @@ func (gr *ResampleCommand) Type() string {
 	return TypeResample.String()
 }
 
+// AbsentCommand is an expression command that detects dimensions missing from the result of a query or expression.
+// It returns a number for each expected label set, and for each series that has values in the time range but not
+// in the lookback window. The number is 1 when the dimension is missing and 0 when it is present.
+// With a lookback window, the dimensions of the previous results of the alert rule, LoadedDimensions, are missing
+// too when they have no values in the time range at all.
+type AbsentCommand struct {
+	VarToCheck       string
+	Expected         []data.Labels
+	Lookback         time.Duration
+	TimeRange        TimeRange
+	LoadedDimensions Dimensions
+	refID            string
+}
+
+// NewAbsentCommand creates a new AbsentCommand. At least one expected label set or a lookback window is required.
+func NewAbsentCommand(refID, varToCheck string, expected []data.Labels, rawLookback string, tr TimeRange, loaded Dimensions) (*AbsentCommand, error) {
+	var lookback time.Duration
+	if rawLookback != "" {
+		var err error
+		lookback, err = gtime.ParseDuration(rawLookback)
+		if err != nil {
+			return nil, fmt.Errorf(`failed to parse absent "lookback" duration field %q: %w`, rawLookback, err)
+		}
+		if lookback <= 0 {
+			return nil, fmt.Errorf(`absent "lookback" duration must be positive, got %q`, rawLookback)
+		}
+	}
+	if len(expected) == 0 && lookback == 0 {
+		return nil, errors.New("absent command requires expected label sets or a lookback window")
+	}
+	for i, l := range expected {
+		if len(l) == 0 {
+			return nil, fmt.Errorf("expected label set %d of absent command is empty", i)
+		}
+	}
+	return &AbsentCommand{
+		VarToCheck:       varToCheck,
+		Expected:         expected,
+		Lookback:         lookback,
+		TimeRange:        tr,
+		LoadedDimensions: loaded,
+		refID:            refID,
+	}, nil
+}
+
+// UnmarshalAbsentCommand creates an AbsentCommand from Grafana's frontend query.
+func UnmarshalAbsentCommand(rn *rawNode) (*AbsentCommand, error) {
+	q := AbsentQuery{}
+	if err := json.Unmarshal(rn.QueryRaw, &q); err != nil {
+		return nil, fmt.Errorf("failed to parse the absent command: %w", err)
+	}
+	varToCheck, err := getReferenceVar(q.Expression, rn.RefID)
+	if err != nil {
+		return nil, err
+	}
+	var loaded Dimensions
+	if q.LoadedDimensions != nil {
+		loaded, err = DimensionsFromFrame(q.LoadedDimensions)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return NewAbsentCommand(rn.RefID, varToCheck, q.ExpectedLabels(), q.Lookback, rn.TimeRange, loaded)
+}
+
+// NeedsVars returns the variable names (refIds) that are dependencies
+// to execute the command and allows the command to fulfill the Command interface.
+func (ac *AbsentCommand) NeedsVars() []string {
+	return []string{ac.VarToCheck}
+}
+
+// Execute runs the command and returns the results or an error if the command
+// failed to execute.
+func (ac *AbsentCommand) Execute(ctx context.Context, now time.Time, vars mathexp.Vars, tracer tracing.Tracer, _ *metrics.ExprMetrics) (mathexp.Results, error) {
+	_, span := tracer.Start(ctx, "SSE.ExecuteAbsent")
+	defer span.End()
+
+	end := now
+	if ac.TimeRange != nil {
+		end = ac.TimeRange.AbsoluteTime(now).To
+	}
+
+	// present holds the dimensions that have a value, seen the dimensions that only had values before the lookback window.
+	present := map[data.Fingerprint]data.Labels{}
+	seen := map[data.Fingerprint]data.Labels{}
+	for _, val := range vars[ac.VarToCheck].Values {
+		if val == nil {
+			continue
+		}
+		switch v := val.(type) {
+		case mathexp.Series:
+			hasValues, recent := false, false
+			for i := 0; i < v.Len(); i++ {
+				t, f := v.GetPoint(i)
+				if f == nil {
+					continue
+				}
+				hasValues = true
+				if ac.Lookback == 0 || end.Sub(t) < ac.Lookback {
+					recent = true
+					break
+				}
+			}
+			if recent {
+				present[v.GetLabels().Fingerprint()] = v.GetLabels()
+			} else if hasValues {
+				seen[v.GetLabels().Fingerprint()] = v.GetLabels()
+			}
+		case mathexp.Number:
+			if v.GetFloat64Value() != nil {
+				present[v.GetLabels().Fingerprint()] = v.GetLabels()
+			}
+		case mathexp.NoData:
+		default:
+			return mathexp.Results{}, fmt.Errorf("can only check type series or number for absence, got type %v", val.Type())
+		}
+	}
+
+	newRes := mathexp.Results{}
+	emitted := map[data.Fingerprint]struct{}{}
+	emit := func(labels data.Labels, absent bool) {
+		fp := labels.Fingerprint()
+		if _, ok := emitted[fp]; ok {
+			return
+		}
+		emitted[fp] = struct{}{}
+		value := 0.0
+		if absent {
+			value = 1
+		}
+		n := mathexp.NewNumber(ac.refID, labels.Copy())
+		n.SetValue(&value)
+		newRes.Values = append(newRes.Values, n)
+	}
+
+	for _, expected := range ac.Expected {
+		absent := true
+		for _, labels := range present {
+			if labels.Contains(expected) {
+				absent = false
+				break
+			}
+		}
+		emit(expected, absent)
+	}
+	if ac.Lookback > 0 {
+		for _, labels := range sortedDimensions(present) {
+			emit(labels, false)
+		}
+		for _, labels := range sortedDimensions(seen) {
+			emit(labels, true)
+		}
+		// The previous dimensions that are neither present nor seen have vanished from the whole time range.
+		for _, labels := range sortedDimensions(ac.LoadedDimensions) {
+			emit(labels, true)
+		}
+	}
+
+	if len(newRes.Values) == 0 {
+		newRes.Values = append(newRes.Values, mathexp.NewNoData())
+	}
+	return newRes, nil
+}
+
+func (ac *AbsentCommand) Type() string {
+	return TypeAbsent.String()
+}
+
+// Dimensions are label sets by fingerprint.
+type Dimensions map[data.Fingerprint]data.Labels
+
+// DimensionsFromFrame converts data.Frame to Dimensions.
+// The input data frame must have a single field of string type, with the labels of each dimension as JSON.
+// Returns error if the input data frame has invalid format
+func DimensionsFromFrame(frame *data.Frame) (Dimensions, error) {
+	frameType, frameVersion := frame.TypeInfo("")
+	if frameType != "dimensions" {
+		return nil, fmt.Errorf("invalid format of loaded dimensions frame: expected frame type 'dimensions'")
+	}
+	if frameVersion.Greater(data.FrameTypeVersion{1, 0}) {
+		return nil, fmt.Errorf("invalid format of loaded dimensions frame: expected frame type 'dimensions' of version 1.0 or lower")
+	}
+	if len(frame.Fields) != 1 {
+		return nil, fmt.Errorf("invalid format of loaded dimensions frame: expected a single field but got %d", len(frame.Fields))
+	}
+	fld := frame.Fields[0]
+	if fld.Type() != data.FieldTypeString {
+		return nil, fmt.Errorf("invalid format of loaded dimensions frame: the field type must be string but got %s", fld.Type().String())
+	}
+	result := make(Dimensions, fld.Len())
+	for i := 0; i < fld.Len(); i++ {
+		var labels data.Labels
+		if err := json.Unmarshal([]byte(fld.At(i).(string)), &labels); err != nil {
+			return nil, fmt.Errorf("cannot read the labels at index [%d]: %w", i, err)
+		}
+		result[labels.Fingerprint()] = labels
+	}
+	return result, nil
+}
+
+// DimensionsToFrame converts Dimensions to data.Frame.
+func DimensionsToFrame(dims Dimensions) (*data.Frame, error) {
+	values := make([]string, 0, len(dims))
+	for _, labels := range sortedDimensions(dims) {
+		b, err := json.Marshal(labels)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, string(b))
+	}
+	frame := data.NewFrame("", data.NewField("dimensions", nil, values))
+	frame.SetMeta(&data.FrameMeta{
+		Type:        "dimensions",
+		TypeVersion: data.FrameTypeVersion{1, 0},
+	})
+	return frame, nil
+}
+
+// IsAbsentExpression returns true if the raw model describes an absent command with a lookback window,
+// which reports the dimensions of the previous results that are missing.
+func IsAbsentExpression(query map[string]any) bool {
+	t, err := GetExpressionCommandType(query)
+	if err != nil || t != TypeAbsent {
+		return false
+	}
+	lookback, _ := query["lookback"].(string)
+	return lookback != ""
+}
+
+// SetLoadedDimensionsToAbsentCommand mutates the input map and sets field "loadedDimensions" with the data frame created from the provided dimensions.
+func SetLoadedDimensionsToAbsentCommand(query map[string]any, dims Dimensions) error {
+	if !IsAbsentExpression(query) {
+		return errors.New("not an absent command with a lookback window")
+	}
+	fr, err := DimensionsToFrame(dims)
+	if err != nil {
+		return err
+	}
+	query["loadedDimensions"] = fr
+	return nil
+}
+
+// sortedDimensions returns the label sets ordered by fingerprint so that results are stable between evaluations.
+func sortedDimensions(dims map[data.Fingerprint]data.Labels) []data.Labels {
+	fps := make([]data.Fingerprint, 0, len(dims))
+	for fp := range dims {
+		fps = append(fps, fp)
+	}
+	slices.Sort(fps)
+	result := make([]data.Labels, 0, len(fps))
+	for _, fp := range fps {
+		result = append(result, dims[fp])
+	}
+	return result
+}
+
 // CommandType is the type of the expression command.
 type CommandType int
 
@@ -345,6 +605,10 @@ const (
 	TypeThreshold
 	// TypeSQL is the CMDType for running SQL expressions
 	TypeSQL
+	// TypeForecast is the CMDType for forecasting baselines and anomaly bands
+	TypeForecast
+	// TypeAbsent is the CMDType for detecting missing dimensions
+	TypeAbsent
 )
 
 func (gt CommandType) String() string {
@@ -361,6 +625,10 @@ func (gt CommandType) String() string {
 		return "threshold"
 	case TypeSQL:
 		return "sql"
+	case TypeForecast:
+		return "forecast"
+	case TypeAbsent:
+		return "absent"
 	default:
 		return "unknown"
 	}
@@ -381,6 +649,10 @@ func ParseCommandType(s string) (CommandType, error) {
 		return TypeThreshold, nil
 	case "sql":
 		return TypeSQL, nil
+	case "forecast":
+		return TypeForecast, nil
+	case "absent":
+		return TypeAbsent, nil
 	default:
 		return TypeUnknown, fmt.Errorf("'%v' is not a recognized expression type", s)
 	}
@@ -273,3 +273,168 @@ func TestResampleCommand_Execute(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestNewAbsentCommand(t *testing.T) {
+	_, err := NewAbsentCommand("B", "A", nil, "", nil, nil)
+	require.ErrorContains(t, err, "requires expected label sets or a lookback window")
+
+	_, err = NewAbsentCommand("B", "A", []data.Labels{{}}, "", nil, nil)
+	require.ErrorContains(t, err, "is empty")
+
+	_, err = NewAbsentCommand("B", "A", nil, "-5m", nil, nil)
+	require.ErrorContains(t, err, "must be positive")
+
+	cmd, err := NewAbsentCommand("B", "A", nil, "5m", nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, 5*time.Minute, cmd.Lookback)
+	require.Equal(t, []string{"A"}, cmd.NeedsVars())
+}
+
+func TestAbsentCommand_Execute(t *testing.T) {
+	now := time.Unix(3600, 0)
+	tr := AbsoluteTimeRange{From: now.Add(-time.Hour), To: now}
+
+	series := func(labels data.Labels, points ...time.Time) mathexp.Series {
+		s := mathexp.NewSeries("A", labels, len(points))
+		for i, p := range points {
+			s.SetPoint(i, p, util.Pointer(1.0))
+		}
+		return s
+	}
+	number := func(labels data.Labels, value *float64) mathexp.Number {
+		n := mathexp.NewNumber("A", labels)
+		n.SetValue(value)
+		return n
+	}
+	absent := func(labels data.Labels, value float64) mathexp.Value {
+		return number(labels, &value)
+	}
+
+	testCases := []struct {
+		name     string
+		expected []data.Labels
+		lookback string
+		loaded   Dimensions
+		input    mathexp.Values
+		result   mathexp.Values
+		err      string
+	}{
+		{
+			name:     "expected label sets are matched as a subset of the result labels",
+			expected: []data.Labels{{"host": "a"}, {"host": "b"}},
+			input: mathexp.Values{
+				number(data.Labels{"host": "a", "dc": "1"}, util.Pointer(3.0)),
+				number(data.Labels{"host": "b"}, nil),
+			},
+			result: mathexp.Values{
+				absent(data.Labels{"host": "a"}, 0),
+				absent(data.Labels{"host": "b"}, 1),
+			},
+		},
+		{
+			name:     "every expected label set is missing when there is no data",
+			expected: []data.Labels{{"host": "a"}},
+			input:    mathexp.Values{mathexp.NewNoData()},
+			result:   mathexp.Values{absent(data.Labels{"host": "a"}, 1)},
+		},
+		{
+			name:     "series without values in the lookback window are missing",
+			lookback: "5m",
+			input: mathexp.Values{
+				series(data.Labels{"host": "a"}, now.Add(-30*time.Minute), now.Add(-time.Minute)),
+				series(data.Labels{"host": "b"}, now.Add(-30*time.Minute), now.Add(-10*time.Minute)),
+				series(data.Labels{"host": "c"}),
+			},
+			result: mathexp.Values{
+				absent(data.Labels{"host": "a"}, 0),
+				absent(data.Labels{"host": "b"}, 1),
+			},
+		},
+		{
+			name:     "loaded dimensions without values in the time range are missing",
+			lookback: "5m",
+			loaded: Dimensions{
+				data.Labels{"host": "a"}.Fingerprint(): {"host": "a"},
+				data.Labels{"host": "b"}.Fingerprint(): {"host": "b"},
+				data.Labels{"host": "c"}.Fingerprint(): {"host": "c"},
+			},
+			input: mathexp.Values{
+				series(data.Labels{"host": "a"}, now.Add(-time.Minute)),
+				series(data.Labels{"host": "b"}, now.Add(-10*time.Minute)),
+			},
+			result: mathexp.Values{
+				absent(data.Labels{"host": "a"}, 0),
+				absent(data.Labels{"host": "b"}, 1),
+				absent(data.Labels{"host": "c"}, 1),
+			},
+		},
+		{
+			name:     "loaded dimensions are missing when the series are completely gone",
+			lookback: "5m",
+			loaded: Dimensions{
+				data.Labels{"host": "a"}.Fingerprint(): {"host": "a"},
+			},
+			input:  mathexp.Values{mathexp.NewNoData()},
+			result: mathexp.Values{absent(data.Labels{"host": "a"}, 1)},
+		},
+		{
+			name:     "no dimensions returns no data",
+			lookback: "5m",
+			input:    mathexp.Values{mathexp.NewNoData()},
+			result:   mathexp.Values{mathexp.NewNoData()},
+		},
+		{
+			name:     "scalars are not supported",
+			expected: []data.Labels{{"host": "a"}},
+			input:    mathexp.Values{mathexp.NewScalar("A", util.Pointer(1.0))},
+			err:      "can only check type series or number for absence",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd, err := NewAbsentCommand("A", "B", tc.expected, tc.lookback, tr, tc.loaded)
+			require.NoError(t, err)
+			res, err := cmd.Execute(context.Background(), now, mathexp.Vars{"B": mathexp.Results{Values: tc.input}}, tracing.InitializeTracerForTest(), nil)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.result, res.Values)
+		})
+	}
+}
+
+func TestDimensionsFrame(t *testing.T) {
+	dims := Dimensions{
+		data.Labels{"host": "a"}.Fingerprint():            {"host": "a"},
+		data.Labels{"host": "b", "dc": "1"}.Fingerprint(): {"host": "b", "dc": "1"},
+		data.Labels{}.Fingerprint():                       {},
+	}
+	frame, err := DimensionsToFrame(dims)
+	require.NoError(t, err)
+	actual, err := DimensionsFromFrame(frame)
+	require.NoError(t, err)
+	require.Equal(t, dims, actual)
+
+	_, err = DimensionsFromFrame(data.NewFrame("", data.NewField("dimensions", nil, []string{})))
+	require.ErrorContains(t, err, "expected frame type 'dimensions'")
+
+	frame = data.NewFrame("", data.NewField("dimensions", nil, []string{"not json"}))
+	frame.SetMeta(&data.FrameMeta{Type: "dimensions", TypeVersion: data.FrameTypeVersion{1, 0}})
+	_, err = DimensionsFromFrame(frame)
+	require.ErrorContains(t, err, "cannot read the labels")
+}
+
+func TestSetLoadedDimensionsToAbsentCommand(t *testing.T) {
+	query := map[string]any{"type": "absent", "expression": "$A", "lookback": "5m"}
+	require.True(t, IsAbsentExpression(query))
+	require.False(t, IsAbsentExpression(map[string]any{"type": "absent", "expression": "$A"}))
+	require.False(t, IsAbsentExpression(map[string]any{"type": "math", "expression": "$A"}))
+
+	dims := Dimensions{data.Labels{"host": "a"}.Fingerprint(): {"host": "a"}}
+	require.NoError(t, SetLoadedDimensionsToAbsentCommand(query, dims))
+	require.IsType(t, &data.Frame{}, query["loadedDimensions"])
+
+	require.Error(t, SetLoadedDimensionsToAbsentCommand(map[string]any{"type": "math", "expression": "$A"}, dims))
+}
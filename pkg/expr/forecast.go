@@ -0,0 +1,538 @@
+package expr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/expr/mathexp"
+	"github.com/grafana/grafana/pkg/expr/metrics"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+// ForecastMethod is the method used by the forecast command to compute the expected value of a series.
+// +enum
+type ForecastMethod string
+
+const (
+	// Additive Holt-Winters (triple exponential smoothing)
+	ForecastMethodHoltWinters ForecastMethod = "holt_winters"
+	// Mean and standard deviation
+	ForecastMethodZScore ForecastMethod = "zscore"
+	// Median and median absolute deviation
+	ForecastMethodMAD ForecastMethod = "mad"
+	// Least squares linear regression
+	ForecastMethodPredictLinear ForecastMethod = "predict_linear"
+)
+
+// ForecastOutput is a series returned by the forecast command for each input series.
+// +enum
+type ForecastOutput string
+
+const (
+	// The expected value
+	ForecastOutputBaseline ForecastOutput = "baseline"
+	// The upper band
+	ForecastOutputUpper ForecastOutput = "upper"
+	// The lower band
+	ForecastOutputLower ForecastOutput = "lower"
+	// The deviation from the baseline relative to the band width, outside of the bands when above 1 or below -1
+	ForecastOutputScore ForecastOutput = "score"
+)
+
+// ForecastLabel is the label added to every series returned by the forecast command. Its value is the ForecastOutput.
+const ForecastLabel = "forecast"
+
+const (
+	defaultForecastSensitivity = 3
+	defaultHoltWintersAlpha    = 0.5
+	defaultHoltWintersBeta     = 0.1
+	defaultHoltWintersGamma    = 0.1
+
+	// madScale makes the median absolute deviation comparable to the standard deviation of normally distributed data.
+	madScale = 1.4826
+
+	// maxForecastHorizonPoints is the maximum number of points of the series interval the horizon can span.
+	maxForecastHorizonPoints = 10000
+)
+
+var allForecastOutputs = []ForecastOutput{ForecastOutputBaseline, ForecastOutputUpper, ForecastOutputLower, ForecastOutputScore}
+
+// ForecastCommand computes a baseline with bands for each input series and scores how far each point deviates from it.
+// It runs in process and does not depend on the machine learning API.
+type ForecastCommand struct {
+	ReferenceVar string
+	Method       ForecastMethod
+	Sensitivity  float64
+	Window       time.Duration
+	Season       time.Duration
+	Horizon      time.Duration
+	Alpha        float64
+	Beta         float64
+	Gamma        float64
+	Outputs      []ForecastOutput
+	refID        string
+}
+
+// NewForecastCommand creates a new ForecastCommand from its query model.
+func NewForecastCommand(refID, referenceVar string, q ForecastQuery) (*ForecastCommand, error) {
+	cmd := &ForecastCommand{
+		ReferenceVar: referenceVar,
+		Method:       q.Method,
+		Sensitivity:  q.Sensitivity,
+		Alpha:        defaultHoltWintersAlpha,
+		Beta:         defaultHoltWintersBeta,
+		Gamma:        defaultHoltWintersGamma,
+		Outputs:      q.Outputs,
+		refID:        refID,
+	}
+
+	switch q.Method {
+	case ForecastMethodHoltWinters, ForecastMethodPredictLinear, ForecastMethodZScore, ForecastMethodMAD:
+	default:
+		return nil, fmt.Errorf("unsupported forecast method '%s'", q.Method)
+	}
+
+	if cmd.Sensitivity == 0 {
+		cmd.Sensitivity = defaultForecastSensitivity
+	}
+	if cmd.Sensitivity < 0 {
+		return nil, fmt.Errorf("forecast sensitivity must be positive, got %v", cmd.Sensitivity)
+	}
+
+	if len(cmd.Outputs) == 0 {
+		cmd.Outputs = allForecastOutputs
+	}
+	for _, o := range cmd.Outputs {
+		if !slices.Contains(allForecastOutputs, o) {
+			return nil, fmt.Errorf("unsupported forecast output '%s'", o)
+		}
+	}
+
+	var err error
+	if cmd.Window, err = parseForecastDuration("window", q.Window); err != nil {
+		return nil, err
+	}
+	if cmd.Season, err = parseForecastDuration("season", q.Season); err != nil {
+		return nil, err
+	}
+	if cmd.Horizon, err = parseForecastDuration("horizon", q.Horizon); err != nil {
+		return nil, err
+	}
+
+	if cmd.Window > 0 && q.Method == ForecastMethodHoltWinters {
+		return nil, fmt.Errorf("forecast method '%s' does not support a window", q.Method)
+	}
+	if cmd.Season > 0 && q.Method != ForecastMethodHoltWinters {
+		return nil, fmt.Errorf("forecast method '%s' does not support a season", q.Method)
+	}
+	if cmd.Horizon > 0 && q.Method != ForecastMethodHoltWinters && q.Method != ForecastMethodPredictLinear {
+		return nil, fmt.Errorf("forecast method '%s' does not support a horizon", q.Method)
+	}
+
+	for _, f := range []struct {
+		name  string
+		value *float64
+		dst   *float64
+	}{{"alpha", q.Alpha, &cmd.Alpha}, {"beta", q.Beta, &cmd.Beta}, {"gamma", q.Gamma, &cmd.Gamma}} {
+		if f.value == nil {
+			continue
+		}
+		if *f.value < 0 || *f.value > 1 {
+			return nil, fmt.Errorf("forecast %s must be between 0 and 1, got %v", f.name, *f.value)
+		}
+		*f.dst = *f.value
+	}
+
+	return cmd, nil
+}
+
+func parseForecastDuration(name, raw string) (time.Duration, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	d, err := gtime.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse forecast %s %q: %w", name, raw, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("forecast %s must be positive, got %s", name, raw)
+	}
+	return d, nil
+}
+
+// UnmarshalForecastCommand creates a ForecastCommand from Grafana's frontend query.
+func UnmarshalForecastCommand(rn *rawNode) (*ForecastCommand, error) {
+	q := ForecastQuery{}
+	if err := json.Unmarshal(rn.QueryRaw, &q); err != nil {
+		return nil, fmt.Errorf("failed to parse the forecast command: %w", err)
+	}
+	referenceVar, err := getReferenceVar(q.Expression, rn.RefID)
+	if err != nil {
+		return nil, err
+	}
+	return NewForecastCommand(rn.RefID, referenceVar, q)
+}
+
+// NeedsVars returns the variable names (refIds) that are dependencies
+// to execute the command and allows the command to fulfill the Command interface.
+func (fc *ForecastCommand) NeedsVars() []string {
+	return []string{fc.ReferenceVar}
+}
+
+// Execute runs the command and returns the results or an error if the command
+// failed to execute.
+func (fc *ForecastCommand) Execute(ctx context.Context, _ time.Time, vars mathexp.Vars, tracer tracing.Tracer, _ *metrics.ExprMetrics) (mathexp.Results, error) {
+	_, span := tracer.Start(ctx, "SSE.ExecuteForecast")
+	defer span.End()
+
+	newRes := mathexp.Results{}
+	for _, val := range vars[fc.ReferenceVar].Values {
+		switch v := val.(type) {
+		case mathexp.Series:
+			newRes.Values = append(newRes.Values, fc.forecastSeries(v)...)
+		case mathexp.NoData:
+			newRes.Values = append(newRes.Values, v.New())
+		default:
+			return newRes, fmt.Errorf("can only forecast type series, got type %v", val.Type())
+		}
+	}
+	return newRes, nil
+}
+
+func (fc *ForecastCommand) Type() string {
+	return TypeForecast.String()
+}
+
+// forecast holds the baseline and the band half width for a sensitivity of 1 for every point of a series
+// followed by the points of the horizon.
+type forecast struct {
+	times    []time.Time
+	baseline []*float64
+	scale    []*float64
+}
+
+func (fc *ForecastCommand) forecastSeries(s mathexp.Series) []mathexp.Value {
+	times := make([]time.Time, s.Len())
+	values := make([]*float64, s.Len())
+	for i := range times {
+		times[i], values[i] = s.GetPoint(i)
+	}
+
+	var f forecast
+	var err error
+	switch fc.Method {
+	case ForecastMethodHoltWinters:
+		f, err = fc.holtWinters(times, values)
+	case ForecastMethodPredictLinear:
+		f, err = fc.predictLinear(times, values)
+	case ForecastMethodZScore:
+		f = fc.rollingBaseline(times, values, meanStdDev)
+	case ForecastMethodMAD:
+		f = fc.rollingBaseline(times, values, medianMAD)
+	}
+
+	var notice *data.Notice
+	if err != nil {
+		// Series that are too short to forecast are returned without values rather than failing the whole expression.
+		notice = &data.Notice{Severity: data.NoticeSeverityWarning, Text: err.Error()}
+		f = forecast{times: times, baseline: make([]*float64, len(times)), scale: make([]*float64, len(times))}
+	}
+
+	result := make([]mathexp.Value, 0, len(fc.Outputs))
+	for _, output := range fc.Outputs {
+		labels := s.GetLabels().Copy()
+		if labels == nil {
+			labels = data.Labels{}
+		}
+		labels[ForecastLabel] = string(output)
+
+		out := mathexp.NewSeries(fc.refID, labels, len(f.times))
+		for i, t := range f.times {
+			var value *float64
+			if i < len(values) {
+				value = values[i]
+			}
+			out.SetPoint(i, t, fc.outputValue(output, value, f.baseline[i], f.scale[i]))
+		}
+		if notice != nil {
+			out.AddNotice(*notice)
+		}
+		result = append(result, out)
+	}
+	return result
+}
+
+func (fc *ForecastCommand) outputValue(output ForecastOutput, value, baseline, scale *float64) *float64 {
+	if baseline == nil || scale == nil {
+		return nil
+	}
+	width := fc.Sensitivity * *scale
+	var r float64
+	switch output {
+	case ForecastOutputBaseline:
+		r = *baseline
+	case ForecastOutputUpper:
+		r = *baseline + width
+	case ForecastOutputLower:
+		r = *baseline - width
+	case ForecastOutputScore:
+		if value == nil {
+			return nil
+		}
+		deviation := *value - *baseline
+		switch {
+		case width > 0:
+			r = deviation / width
+		case deviation == 0:
+			r = 0
+		default:
+			r = math.Copysign(math.Inf(1), deviation)
+		}
+	}
+	return &r
+}
+
+// rollingBaseline computes the baseline and scale of each point from the values in the trailing window,
+// or from the whole series when there is no window.
+func (fc *ForecastCommand) rollingBaseline(times []time.Time, values []*float64, stats func([]float64) (float64, float64)) forecast {
+	f := forecast{times: times, baseline: make([]*float64, len(times)), scale: make([]*float64, len(times))}
+	if fc.Window == 0 {
+		window := nonNullValues(values)
+		if len(window) == 0 {
+			return f
+		}
+		baseline, scale := stats(window)
+		for i := range times {
+			f.baseline[i], f.scale[i] = &baseline, &scale
+		}
+		return f
+	}
+
+	for i, t := range times {
+		// The window only holds past points, so that an anomaly does not widen its own bands.
+		var window []float64
+		for j := i - 1; j >= 0 && t.Sub(times[j]) <= fc.Window; j-- {
+			if values[j] != nil {
+				window = append(window, *values[j])
+			}
+		}
+		if len(window) < 2 {
+			continue
+		}
+		baseline, scale := stats(window)
+		f.baseline[i], f.scale[i] = &baseline, &scale
+	}
+	return f
+}
+
+// predictLinear fits a line to the values in the trailing window before the last point, or to the whole series
+// when there is no window, and extends it over the horizon.
+func (fc *ForecastCommand) predictLinear(times []time.Time, values []*float64) (forecast, error) {
+	var xs, ys []float64
+	for i, t := range times {
+		if values[i] == nil || (fc.Window > 0 && times[len(times)-1].Sub(t) > fc.Window) {
+			continue
+		}
+		xs = append(xs, float64(t.Unix()))
+		ys = append(ys, *values[i])
+	}
+	if len(xs) < 2 {
+		return forecast{}, fmt.Errorf("predict_linear needs at least 2 values, got %d", len(xs))
+	}
+
+	slope, intercept := linearRegression(xs, ys)
+	var residuals []float64
+	for i := range xs {
+		residuals = append(residuals, ys[i]-(intercept+slope*xs[i]))
+	}
+	_, scale := meanStdDev(residuals)
+
+	times, err := fc.extendTimes(times)
+	if err != nil {
+		return forecast{}, err
+	}
+	f := forecast{times: times, baseline: make([]*float64, len(times)), scale: make([]*float64, len(times))}
+	for i, t := range times {
+		baseline := intercept + slope*float64(t.Unix())
+		f.baseline[i], f.scale[i] = &baseline, &scale
+	}
+	return f, nil
+}
+
+// holtWinters runs additive triple exponential smoothing over the series. The baseline of a point is the
+// forecast made at the previous point. Without a season it is double exponential smoothing.
+func (fc *ForecastCommand) holtWinters(times []time.Time, values []*float64) (forecast, error) {
+	step, err := seriesStep(times)
+	if err != nil {
+		return forecast{}, err
+	}
+	period, gamma := 1, 0.0
+	if fc.Season > 0 {
+		period = int(math.Round(float64(fc.Season) / float64(step)))
+		gamma = fc.Gamma
+		if period < 2 {
+			return forecast{}, fmt.Errorf("holt_winters season %s must span at least 2 points of %s", fc.Season, step)
+		}
+	}
+	if len(values) < 2*period {
+		return forecast{}, fmt.Errorf("holt_winters needs at least %d points, got %d", 2*period, len(values))
+	}
+
+	// The first two seasons give the initial level, trend and seasonal components.
+	first, second := nonNullValues(values[:period]), nonNullValues(values[period:2*period])
+	if len(first) == 0 || len(second) == 0 {
+		return forecast{}, fmt.Errorf("holt_winters needs values in each of the first two seasons")
+	}
+	level, _ := meanStdDev(first)
+	secondLevel, _ := meanStdDev(second)
+	trend := (secondLevel - level) / float64(period)
+	seasonal := make([]float64, period)
+	for i := range seasonal {
+		if values[i] != nil && fc.Season > 0 {
+			seasonal[i] = *values[i] - level
+		}
+	}
+
+	allTimes, err := fc.extendTimes(times)
+	if err != nil {
+		return forecast{}, err
+	}
+	f := forecast{times: allTimes, baseline: make([]*float64, len(allTimes)), scale: make([]*float64, len(allTimes))}
+
+	var residuals []float64
+	for i := period; i < len(values); i++ {
+		expected := level + trend + seasonal[i%period]
+		f.baseline[i] = &expected
+		if values[i] == nil {
+			level += trend
+			continue
+		}
+		x := *values[i]
+		residuals = append(residuals, x-expected)
+		lastLevel := level
+		level = fc.Alpha*(x-seasonal[i%period]) + (1-fc.Alpha)*(level+trend)
+		trend = fc.Beta*(level-lastLevel) + (1-fc.Beta)*trend
+		seasonal[i%period] = gamma*(x-level) + (1-gamma)*seasonal[i%period]
+	}
+	for k, i := 1, len(values); i < len(allTimes); k, i = k+1, i+1 {
+		expected := level + float64(k)*trend + seasonal[i%period]
+		f.baseline[i] = &expected
+	}
+
+	_, scale := meanStdDev(residuals)
+	for i := period; i < len(allTimes); i++ {
+		f.scale[i] = &scale
+	}
+	return f, nil
+}
+
+// extendTimes appends the points of the horizon to times, using the step of the series.
+func (fc *ForecastCommand) extendTimes(times []time.Time) ([]time.Time, error) {
+	if fc.Horizon == 0 {
+		return times, nil
+	}
+	step, err := seriesStep(times)
+	if err != nil {
+		return nil, err
+	}
+	points := int64(fc.Horizon / step)
+	if points > maxForecastHorizonPoints {
+		return nil, fmt.Errorf("forecast horizon %s spans %d points of the series interval %s, the maximum is %d", fc.Horizon, points, step, maxForecastHorizonPoints)
+	}
+	last := times[len(times)-1]
+	extended := make([]time.Time, len(times), len(times)+int(points))
+	copy(extended, times)
+	for t := last.Add(step); t.Sub(last) <= fc.Horizon; t = t.Add(step) {
+		extended = append(extended, t)
+	}
+	return extended, nil
+}
+
+// seriesStep returns the median interval between the points of a series.
+func seriesStep(times []time.Time) (time.Duration, error) {
+	if len(times) < 2 {
+		return 0, fmt.Errorf("forecast needs at least 2 points to find the series interval, got %d", len(times))
+	}
+	steps := make([]float64, 0, len(times)-1)
+	for i := 1; i < len(times); i++ {
+		steps = append(steps, float64(times[i].Sub(times[i-1])))
+	}
+	step := time.Duration(medianOf(steps))
+	if step <= 0 {
+		return 0, fmt.Errorf("forecast needs a series sorted by time")
+	}
+	return step, nil
+}
+
+func nonNullValues(values []*float64) []float64 {
+	r := make([]float64, 0, len(values))
+	for _, v := range values {
+		if v != nil {
+			r = append(r, *v)
+		}
+	}
+	return r
+}
+
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return math.NaN(), math.NaN()
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(values)))
+}
+
+func medianMAD(values []float64) (float64, float64) {
+	median := medianOf(values)
+	deviations := make([]float64, len(values))
+	for i, v := range values {
+		deviations[i] = math.Abs(v - median)
+	}
+	return median, madScale * medianOf(deviations)
+}
+
+func medianOf(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	sorted := slices.Clone(values)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// linearRegression returns the slope and intercept of the least squares line through the points.
+func linearRegression(xs, ys []float64) (float64, float64) {
+	// x values are unix timestamps, they are centered to keep the sums precise.
+	xMean, _ := meanStdDev(xs)
+	yMean, _ := meanStdDev(ys)
+	var covariance, variance float64
+	for i := range xs {
+		covariance += (xs[i] - xMean) * (ys[i] - yMean)
+		variance += (xs[i] - xMean) * (xs[i] - xMean)
+	}
+	if variance == 0 {
+		return 0, yMean
+	}
+	slope := covariance / variance
+	return slope, yMean - slope*xMean
+}
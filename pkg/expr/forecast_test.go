@@ -0,0 +1,180 @@
+package expr
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/expr/mathexp"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func TestNewForecastCommand(t *testing.T) {
+	testCases := []struct {
+		name          string
+		query         ForecastQuery
+		expectedError string
+	}{
+		{
+			name:  "defaults",
+			query: ForecastQuery{Method: ForecastMethodZScore},
+		},
+		{
+			name:          "unknown method",
+			query:         ForecastQuery{Method: "prophet"},
+			expectedError: "unsupported forecast method 'prophet'",
+		},
+		{
+			name:          "unknown output",
+			query:         ForecastQuery{Method: ForecastMethodMAD, Outputs: []ForecastOutput{"median"}},
+			expectedError: "unsupported forecast output 'median'",
+		},
+		{
+			name:          "negative sensitivity",
+			query:         ForecastQuery{Method: ForecastMethodMAD, Sensitivity: -1},
+			expectedError: "forecast sensitivity must be positive",
+		},
+		{
+			name:          "invalid window",
+			query:         ForecastQuery{Method: ForecastMethodZScore, Window: "soon"},
+			expectedError: "failed to parse forecast window",
+		},
+		{
+			name:          "season with a method without seasonality",
+			query:         ForecastQuery{Method: ForecastMethodZScore, Season: "1d"},
+			expectedError: "does not support a season",
+		},
+		{
+			name:          "horizon with a method that does not forecast",
+			query:         ForecastQuery{Method: ForecastMethodMAD, Horizon: "1h"},
+			expectedError: "does not support a horizon",
+		},
+		{
+			name:          "smoothing factor out of range",
+			query:         ForecastQuery{Method: ForecastMethodHoltWinters, Alpha: util.Pointer(1.5)},
+			expectedError: "forecast alpha must be between 0 and 1",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd, err := NewForecastCommand("B", "A", tc.query)
+			if tc.expectedError != "" {
+				require.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, float64(defaultForecastSensitivity), cmd.Sensitivity)
+			require.Equal(t, allForecastOutputs, cmd.Outputs)
+			require.Equal(t, []string{"A"}, cmd.NeedsVars())
+		})
+	}
+}
+
+func TestForecastExecute(t *testing.T) {
+	start := time.Unix(0, 0)
+	series := func(values ...*float64) mathexp.Series {
+		s := mathexp.NewSeries("A", data.Labels{"host": "a"}, len(values))
+		for i, v := range values {
+			s.SetPoint(i, start.Add(time.Duration(i)*time.Minute), v)
+		}
+		return s
+	}
+	execute := func(t *testing.T, q ForecastQuery, input mathexp.Value) map[ForecastOutput]mathexp.Series {
+		t.Helper()
+		q.Expression = "A"
+		cmd, err := NewForecastCommand("B", "A", q)
+		require.NoError(t, err)
+		res, err := cmd.Execute(context.Background(), time.Now(), mathexp.Vars{"A": mathexp.Results{Values: mathexp.Values{input}}}, tracing.InitializeTracerForTest(), nil)
+		require.NoError(t, err)
+		outputs := map[ForecastOutput]mathexp.Series{}
+		for _, v := range res.Values {
+			s, ok := v.(mathexp.Series)
+			require.True(t, ok)
+			require.Equal(t, "a", s.GetLabels()["host"])
+			outputs[ForecastOutput(s.GetLabels()[ForecastLabel])] = s
+		}
+		return outputs
+	}
+	values := func(s mathexp.Series) []*float64 {
+		r := make([]*float64, s.Len())
+		for i := range r {
+			r[i] = s.GetValue(i)
+		}
+		return r
+	}
+
+	t.Run("zscore scores the outlier outside of the bands", func(t *testing.T) {
+		out := execute(t, ForecastQuery{Method: ForecastMethodZScore, Sensitivity: 1}, series(fp(1), fp(2), fp(1), fp(2), fp(10)))
+		require.Len(t, out, 4)
+		score := values(out[ForecastOutputScore])
+		for _, s := range score[:4] {
+			assert.Less(t, *s, 1.0)
+		}
+		assert.Greater(t, *score[4], 1.0)
+		assert.InDelta(t, 3.2, *out[ForecastOutputBaseline].GetValue(0), 1e-9)
+		assert.InDelta(t, *out[ForecastOutputUpper].GetValue(0)-3.2, 3.2-*out[ForecastOutputLower].GetValue(0), 1e-9)
+	})
+
+	t.Run("mad with a window only uses past points", func(t *testing.T) {
+		out := execute(t, ForecastQuery{Method: ForecastMethodMAD, Window: "2m", Outputs: []ForecastOutput{ForecastOutputBaseline}},
+			series(fp(1), fp(3), fp(5), fp(100)))
+		require.Len(t, out, 1)
+		assert.Equal(t, []*float64{nil, nil, fp(2), fp(4)}, values(out[ForecastOutputBaseline]))
+	})
+
+	t.Run("predict_linear extends the baseline over the horizon", func(t *testing.T) {
+		out := execute(t, ForecastQuery{Method: ForecastMethodPredictLinear, Horizon: "2m"}, series(fp(0), fp(60), fp(120)))
+		baseline := out[ForecastOutputBaseline]
+		require.Equal(t, 5, baseline.Len())
+		assert.Equal(t, start.Add(4*time.Minute), baseline.GetTime(4))
+		assert.InDelta(t, 240, *baseline.GetValue(4), 1e-9)
+		assert.Equal(t, []*float64{fp(0), fp(0), fp(0), nil, nil}, values(out[ForecastOutputScore]))
+	})
+
+	t.Run("holt_winters follows the season", func(t *testing.T) {
+		out := execute(t, ForecastQuery{Method: ForecastMethodHoltWinters, Season: "4m", Horizon: "2m"},
+			series(fp(1), fp(3), fp(5), fp(3), fp(1), fp(3), fp(5), fp(3), fp(1), fp(3), fp(5), fp(3)))
+		baseline := values(out[ForecastOutputBaseline])
+		require.Len(t, baseline, 14)
+		assert.Equal(t, []*float64{nil, nil, nil, nil}, baseline[:4])
+		assert.Equal(t, []*float64{fp(1), fp(3), fp(5), fp(3), fp(1), fp(3), fp(5), fp(3), fp(1), fp(3)}, baseline[4:])
+		assert.Equal(t, fp(0), out[ForecastOutputScore].GetValue(11))
+	})
+
+	t.Run("series too short for holt_winters returns a notice", func(t *testing.T) {
+		out := execute(t, ForecastQuery{Method: ForecastMethodHoltWinters, Season: "4m"}, series(fp(1), fp(2), fp(3)))
+		baseline := out[ForecastOutputBaseline]
+		assert.Equal(t, []*float64{nil, nil, nil}, values(baseline))
+		require.Len(t, baseline.Frame.Meta.Notices, 1)
+		assert.Contains(t, baseline.Frame.Meta.Notices[0].Text, "holt_winters needs at least 8 points")
+	})
+
+	t.Run("horizon longer than the maximum number of points returns a notice", func(t *testing.T) {
+		out := execute(t, ForecastQuery{Method: ForecastMethodPredictLinear, Horizon: "1y"}, series(fp(0), fp(60), fp(120)))
+		baseline := out[ForecastOutputBaseline]
+		assert.Equal(t, []*float64{nil, nil, nil}, values(baseline))
+		require.Len(t, baseline.Frame.Meta.Notices, 1)
+		assert.Contains(t, baseline.Frame.Meta.Notices[0].Text, "the maximum is 10000")
+	})
+
+	t.Run("NoData is passed through", func(t *testing.T) {
+		cmd, err := NewForecastCommand("B", "A", ForecastQuery{Method: ForecastMethodZScore})
+		require.NoError(t, err)
+		res, err := cmd.Execute(context.Background(), time.Now(), mathexp.Vars{"A": mathexp.Results{Values: mathexp.Values{mathexp.NewNoData()}}}, tracing.InitializeTracerForTest(), nil)
+		require.NoError(t, err)
+		require.Len(t, res.Values, 1)
+		require.Equal(t, mathexp.NewNoData().Type(), res.Values[0].Type())
+	})
+
+	t.Run("numbers cannot be forecast", func(t *testing.T) {
+		cmd, err := NewForecastCommand("B", "A", ForecastQuery{Method: ForecastMethodZScore})
+		require.NoError(t, err)
+		_, err = cmd.Execute(context.Background(), time.Now(), mathexp.Vars{"A": mathexp.Results{Values: mathexp.Values{mathexp.NewNumber("A", nil)}}}, tracing.InitializeTracerForTest(), nil)
+		require.ErrorContains(t, err, "can only forecast type series")
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"math"
 	"reflect"
 	"runtime"
+	"slices"
 	"strings"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
@@ -202,15 +203,19 @@ type Union struct {
 // within a collection of Series or Numbers. The Unions are used with binary
 // operations. The labels of the Union will the taken from result with a greater
 // number of tags.
-func (e *State) union(aResults, bResults Results, biNode *parse.BinaryNode) []*Union {
+// When the operator has matching modifiers, items are matched as described in matchUnions instead.
+func (e *State) union(aResults, bResults Results, biNode *parse.BinaryNode) ([]*Union, error) {
+	return e.unionResults(aResults, bResults, biNode.Matching, biNode.Args[0].String(), biNode.Args[1].String(), biNode.String())
+}
+
+// unionResults is union for operands that are not necessarily the arguments of a binary node, such as
+// the arguments of a function. The names are only used to report dropped items.
+func (e *State) unionResults(aResults, bResults Results, matching *parse.VectorMatching, aVar, bVar, nodeText string) ([]*Union, error) {
 	unions := []*Union{}
 	appendUnions := func(u *Union) {
 		unions = append(unions, u)
 	}
 
-	aVar := biNode.Args[0].String()
-	bVar := biNode.Args[1].String()
-
 	aMatched := make([]bool, len(aResults.Values))
 	bMatched := make([]bool, len(bResults.Values))
 	collectDrops := func() {
@@ -222,8 +227,8 @@ func (e *State) union(aResults, bResults Results, biNode *parse.BinaryNode) []*U
 				if e.Drops == nil {
 					e.Drops = make(map[string]map[string][]data.Labels)
 				}
-				if e.Drops[biNode.String()] == nil {
-					e.Drops[biNode.String()] = make(map[string][]data.Labels)
+				if e.Drops[nodeText] == nil {
+					e.Drops[nodeText] = make(map[string][]data.Labels)
 				}
 
 				if r.Values[i].Type() == parse.TypeNoData {
@@ -231,7 +236,7 @@ func (e *State) union(aResults, bResults Results, biNode *parse.BinaryNode) []*U
 				}
 
 				e.DropCount++
-				e.Drops[biNode.String()][v] = append(e.Drops[biNode.String()][v], r.Values[i].GetLabels())
+				e.Drops[nodeText][v] = append(e.Drops[nodeText][v], r.Values[i].GetLabels())
 			}
 		}
 		check(aVar, aMatched, &aResults)
@@ -241,7 +246,7 @@ func (e *State) union(aResults, bResults Results, biNode *parse.BinaryNode) []*U
 	aValueLen := len(aResults.Values)
 	bValueLen := len(bResults.Values)
 	if aValueLen == 0 || bValueLen == 0 {
-		return unions
+		return unions, nil
 	}
 
 	if aValueLen == 1 || bValueLen == 1 {
@@ -254,10 +259,20 @@ func (e *State) union(aResults, bResults Results, biNode *parse.BinaryNode) []*U
 				B:      bResults.Values[0],
 			})
 			collectDrops()
-			return unions
+			return unions, nil
 		}
 	}
 
+	if matching != nil && !hasScalar(aResults) && !hasScalar(bResults) {
+		var err error
+		unions, err = matchUnions(aResults, bResults, matching, aMatched, bMatched)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", nodeText, err)
+		}
+		collectDrops()
+		return unions, nil
+	}
+
 	for iA, a := range aResults.Values {
 		for iB, b := range bResults.Values {
 			var labels data.Labels
@@ -303,7 +318,89 @@ func (e *State) union(aResults, bResults Results, biNode *parse.BinaryNode) []*U
 	}
 
 	collectDrops()
-	return unions
+	return unions, nil
+}
+
+// matchUnions matches items by the labels selected by on or ignoring. In a one-to-one match the result has the
+// matched labels only. With group_left, each item on the left side matches the item on the right side with the
+// same matched labels, and the result has the labels of the left item plus the included labels of the right item.
+// group_right is the opposite. aMatched and bMatched are set for the items that are part of a union.
+func matchUnions(aResults, bResults Results, matching *parse.VectorMatching, aMatched, bMatched []bool) ([]*Union, error) {
+	signature := func(labels data.Labels) data.Labels {
+		sig := data.Labels{}
+		for k, v := range labels {
+			if slices.Contains(matching.MatchingLabels, k) == matching.On {
+				sig[k] = v
+			}
+		}
+		return sig
+	}
+
+	// The "one" side of the match, indexed by signature, must have unique signatures.
+	one, many := bResults, aResults
+	oneMatched, manyMatched := bMatched, aMatched
+	if matching.Card == parse.CardOneToMany {
+		one, many = aResults, bResults
+		oneMatched, manyMatched = aMatched, bMatched
+	}
+	oneBySig := make(map[data.Fingerprint]int, len(one.Values))
+	for i, v := range one.Values {
+		fp := signature(v.GetLabels()).Fingerprint()
+		if _, ok := oneBySig[fp]; ok {
+			return nil, fmt.Errorf("found duplicate series for the match group %s on the side that must have one series per group", signature(v.GetLabels()))
+		}
+		oneBySig[fp] = i
+	}
+
+	unions := []*Union{}
+	manyBySig := map[data.Fingerprint]int{}
+	for iMany, m := range many.Values {
+		sig := signature(m.GetLabels())
+		fp := sig.Fingerprint()
+		iOne, ok := oneBySig[fp]
+		if !ok {
+			continue
+		}
+		var labels data.Labels
+		if matching.Card == parse.CardOneToOne {
+			if prev, ok := manyBySig[fp]; ok {
+				return nil, fmt.Errorf("found duplicate series for the match group %s, items %s and %s, use group_left or group_right for many-to-one matching", sig, many.Values[prev].GetLabels(), m.GetLabels())
+			}
+			manyBySig[fp] = iMany
+			labels = sig
+		} else {
+			labels = m.GetLabels().Copy()
+			if labels == nil {
+				labels = data.Labels{}
+			}
+			oneLabels := one.Values[iOne].GetLabels()
+			for _, l := range matching.Include {
+				if v, ok := oneLabels[l]; ok {
+					labels[l] = v
+				} else {
+					delete(labels, l)
+				}
+			}
+		}
+
+		u := &Union{Labels: labels, A: m, B: one.Values[iOne]}
+		if matching.Card == parse.CardOneToMany {
+			u.A, u.B = u.B, u.A
+		}
+		unions = append(unions, u)
+		manyMatched[iMany] = true
+		oneMatched[iOne] = true
+	}
+	return unions, nil
+}
+
+func hasScalar(r Results) bool {
+	for _, v := range r.Values {
+		if v.Type() == parse.TypeScalar {
+			return true
+		}
+	}
+	return false
 }
 
 func (e *State) walkBinary(node *parse.BinaryNode) (Results, error) {
@@ -316,7 +413,17 @@ func (e *State) walkBinary(node *parse.BinaryNode) (Results, error) {
 	if err != nil {
 		return res, err
 	}
-	unions := e.union(ar, br, node)
+	unions, err := e.union(ar, br, node)
+	if err != nil {
+		return res, err
+	}
+	return e.binaryUnions(node.OpStr, unions)
+}
+
+// binaryUnions applies the binary operator op to each union.
+func (e *State) binaryUnions(op string, unions []*Union) (Results, error) {
+	var err error
+	res := Results{Values: Values{}}
 	for _, uni := range unions {
 		var value Value
 		switch at := uni.A.(type) {
@@ -332,7 +439,7 @@ func (e *State) walkBinary(node *parse.BinaryNode) (Results, error) {
 				}
 				f := math.NaN()
 				if aFloat != nil && bFloat != nil {
-					f, err = binaryOp(node.OpStr, *aFloat, *bFloat)
+					f, err = binaryOp(op, *aFloat, *bFloat)
 					if err != nil {
 						return res, err
 					}
@@ -340,53 +447,53 @@ func (e *State) walkBinary(node *parse.BinaryNode) (Results, error) {
 				value = NewScalar(e.RefID, &f)
 			// Scalar op Scalar
 			case Number:
-				value, err = e.biScalarNumber(uni.Labels, node.OpStr, bt, aFloat, false)
+				value, err = e.biScalarNumber(uni.Labels, op, bt, aFloat, false)
 			// Scalar op Series
 			case Series:
-				value, err = e.biSeriesNumber(uni.Labels, node.OpStr, bt, aFloat, false)
+				value, err = e.biSeriesNumber(uni.Labels, op, bt, aFloat, false)
 			case NoData:
 				value = uni.B
 			default:
-				return res, fmt.Errorf("not implemented: binary %v on %T and %T", node.OpStr, uni.A, uni.B)
+				return res, fmt.Errorf("not implemented: binary %v on %T and %T", op, uni.A, uni.B)
 			}
 		case Series:
 			switch bt := uni.B.(type) {
 			// Series Op Scalar
 			case Scalar:
 				bFloat := bt.GetFloat64Value()
-				value, err = e.biSeriesNumber(uni.Labels, node.OpStr, at, bFloat, true)
+				value, err = e.biSeriesNumber(uni.Labels, op, at, bFloat, true)
 			// case Series Op Number
 			case Number:
 				bFloat := bt.GetFloat64Value()
-				value, err = e.biSeriesNumber(uni.Labels, node.OpStr, at, bFloat, true)
+				value, err = e.biSeriesNumber(uni.Labels, op, at, bFloat, true)
 			// case Series op Series
 			case Series:
-				value, err = e.biSeriesSeries(uni.Labels, node.OpStr, at, bt)
+				value, err = e.biSeriesSeries(uni.Labels, op, at, bt)
 			case NoData:
 				value = uni.B
 			default:
-				return res, fmt.Errorf("not implemented: binary %v on %T and %T", node.OpStr, uni.A, uni.B)
+				return res, fmt.Errorf("not implemented: binary %v on %T and %T", op, uni.A, uni.B)
 			}
 		case Number:
 			aFloat := at.GetFloat64Value()
 			switch bt := uni.B.(type) {
 			case Scalar:
 				bFloat := bt.GetFloat64Value()
-				value, err = e.biScalarNumber(uni.Labels, node.OpStr, at, bFloat, true)
+				value, err = e.biScalarNumber(uni.Labels, op, at, bFloat, true)
 			case Number:
 				bFloat := bt.GetFloat64Value()
-				value, err = e.biScalarNumber(uni.Labels, node.OpStr, at, bFloat, true)
+				value, err = e.biScalarNumber(uni.Labels, op, at, bFloat, true)
 			case Series:
-				value, err = e.biSeriesNumber(uni.Labels, node.OpStr, bt, aFloat, false)
+				value, err = e.biSeriesNumber(uni.Labels, op, bt, aFloat, false)
 			case NoData:
 				value = uni.B
 			default:
-				return res, fmt.Errorf("not implemented: binary %v on %T and %T", node.OpStr, uni.A, uni.B)
+				return res, fmt.Errorf("not implemented: binary %v on %T and %T", op, uni.A, uni.B)
 			}
 		case NoData:
 			value = uni.A
 		default:
-			return res, fmt.Errorf("not implemented: binary %v on %T and %T", node.OpStr, uni.A, uni.B)
+			return res, fmt.Errorf("not implemented: binary %v on %T and %T", op, uni.A, uni.B)
 		}
 		if err != nil {
 			return res, err
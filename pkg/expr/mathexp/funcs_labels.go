@@ -0,0 +1,120 @@
+package mathexp
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// labelReplace sets the label dst to replacement for each item whose src label value matches regex.
+// The regex is anchored and replacement may refer to its capture groups, e.g. "$1". The dst label is
+// removed when the replacement is empty.
+func labelReplace(e *State, varSet Results, dst, replacement, src, regex string) (Results, error) {
+	re, err := regexp.Compile("^(?:" + regex + ")$")
+	if err != nil {
+		return Results{}, fmt.Errorf("label_replace: invalid regular expression %q: %w", regex, err)
+	}
+	return relabel(e, "label_replace", varSet, func(labels data.Labels) data.Labels {
+		srcVal := labels[src]
+		idx := re.FindStringSubmatchIndex(srcVal)
+		if idx == nil {
+			return labels
+		}
+		v := string(re.ExpandString(nil, replacement, srcVal, idx))
+		if v == "" {
+			delete(labels, dst)
+		} else {
+			labels[dst] = v
+		}
+		return labels
+	})
+}
+
+// labelJoin sets the label dst to the values of the src labels joined with sep.
+func labelJoin(e *State, varSet Results, dst, sep string, src ...string) (Results, error) {
+	return relabel(e, "label_join", varSet, func(labels data.Labels) data.Labels {
+		values := make([]string, 0, len(src))
+		for _, s := range src {
+			values = append(values, labels[s])
+		}
+		if v := strings.Join(values, sep); v != "" {
+			labels[dst] = v
+		} else {
+			delete(labels, dst)
+		}
+		return labels
+	})
+}
+
+// dropLabels removes the given labels from each item.
+func dropLabels(e *State, varSet Results, names ...string) (Results, error) {
+	return relabel(e, "drop_labels", varSet, func(labels data.Labels) data.Labels {
+		for _, n := range names {
+			delete(labels, n)
+		}
+		return labels
+	})
+}
+
+// keepLabels removes all labels but the given ones from each item.
+func keepLabels(e *State, varSet Results, names ...string) (Results, error) {
+	return relabel(e, "keep_labels", varSet, func(labels data.Labels) data.Labels {
+		for k := range labels {
+			if !slices.Contains(names, k) {
+				delete(labels, k)
+			}
+		}
+		return labels
+	})
+}
+
+// relabel returns a copy of each Number and Series in varSet with the labels returned by labelsF, which is
+// given a copy of the labels of the item. Items must still have distinct labels after relabeling.
+// Scalars and NoData have no labels and are returned as they are.
+func relabel(e *State, name string, varSet Results, labelsF func(data.Labels) data.Labels) (Results, error) {
+	newRes := Results{}
+	seen := map[data.Fingerprint]struct{}{}
+	for _, res := range varSet.Values {
+		var newVal Value
+		switch v := res.(type) {
+		case Number:
+			n := NewNumber(e.RefID, relabelCopy(v.GetLabels(), labelsF))
+			n.SetValue(v.GetFloat64Value())
+			newVal = n
+		case Series:
+			s := NewSeries(e.RefID, relabelCopy(v.GetLabels(), labelsF), v.Len())
+			for i := 0; i < v.Len(); i++ {
+				t, f := v.GetPoint(i)
+				s.SetPoint(i, t, f)
+			}
+			newVal = s
+		case Scalar, NoData:
+			newRes.Values = append(newRes.Values, res)
+			continue
+		default:
+			return newRes, fmt.Errorf("%s: unsupported type %s", name, res.Type())
+		}
+		fp := newVal.GetLabels().Fingerprint()
+		if _, ok := seen[fp]; ok {
+			return newRes, fmt.Errorf("%s: more than one item has the labels %s after relabeling", name, newVal.GetLabels())
+		}
+		seen[fp] = struct{}{}
+		newRes.Values = append(newRes.Values, newVal)
+	}
+	return newRes, nil
+}
+
+func relabelCopy(labels data.Labels, labelsF func(data.Labels) data.Labels) data.Labels {
+	c := labels.Copy()
+	if c == nil {
+		c = data.Labels{}
+	}
+	c = labelsF(c)
+	if len(c) == 0 {
+		return nil
+	}
+	return c
+}
@@ -0,0 +1,116 @@
+package mathexp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+func TestLabelFuncs(t *testing.T) {
+	var tests = []struct {
+		name      string
+		expr      string
+		vars      Vars
+		newErrIs  require.ErrorAssertionFunc
+		execErrIs require.ErrorAssertionFunc
+		results   Results
+	}{
+		{
+			name: "label_replace with a capture group",
+			expr: `label_replace($A, "host", "$1", "instance", "(.*):\\d+")`,
+			vars: Vars{"A": resultValuesNoErr(
+				makeNumber("", data.Labels{"instance": "web-1:9100"}, float64Pointer(1)),
+				makeNumber("", data.Labels{"instance": "web-2"}, float64Pointer(2)),
+			)},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeNumber("", data.Labels{"instance": "web-1:9100", "host": "web-1"}, float64Pointer(1)),
+				makeNumber("", data.Labels{"instance": "web-2"}, float64Pointer(2)),
+			),
+		},
+		{
+			name:      "label_replace with an invalid regular expression",
+			expr:      `label_replace($A, "host", "$1", "instance", "(")`,
+			vars:      Vars{"A": resultValuesNoErr(makeNumber("", nil, float64Pointer(1)))},
+			newErrIs:  require.NoError,
+			execErrIs: require.Error,
+		},
+		{
+			name: "label_join on series",
+			expr: `label_join($A, "id", "/", "dc", "host")`,
+			vars: Vars{"A": resultValuesNoErr(
+				makeSeries("", data.Labels{"dc": "eu", "host": "a"}, tp{time.Unix(5, 0), float64Pointer(1)}),
+			)},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", data.Labels{"dc": "eu", "host": "a", "id": "eu/a"}, tp{time.Unix(5, 0), float64Pointer(1)}),
+			),
+		},
+		{
+			name:     "label_join without source labels",
+			expr:     `label_join($A, "id", "/")`,
+			vars:     Vars{},
+			newErrIs: require.Error,
+		},
+		{
+			name: "drop_labels",
+			expr: `drop_labels($A, "pod", "container")`,
+			vars: Vars{"A": resultValuesNoErr(
+				makeNumber("", data.Labels{"host": "a", "pod": "p", "container": "c"}, float64Pointer(1)),
+			)},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results:   resultValuesNoErr(makeNumber("", data.Labels{"host": "a"}, float64Pointer(1))),
+		},
+		{
+			name: "keep_labels",
+			expr: `keep_labels($A, "host")`,
+			vars: Vars{"A": resultValuesNoErr(
+				makeNumber("", data.Labels{"host": "a", "pod": "p"}, float64Pointer(1)),
+			)},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results:   resultValuesNoErr(makeNumber("", data.Labels{"host": "a"}, float64Pointer(1))),
+		},
+		{
+			name: "keep_labels that makes items indistinguishable - should error",
+			expr: `keep_labels($A, "host")`,
+			vars: Vars{"A": resultValuesNoErr(
+				makeNumber("", data.Labels{"host": "a", "pod": "p1"}, float64Pointer(1)),
+				makeNumber("", data.Labels{"host": "a", "pod": "p2"}, float64Pointer(2)),
+			)},
+			newErrIs:  require.NoError,
+			execErrIs: require.Error,
+		},
+		{
+			name: "label functions combine data sources with different label names",
+			expr: `$A - on(host) label_replace($B, "host", "$1", "instance", "(.*):\\d+")`,
+			vars: Vars{
+				"A": resultValuesNoErr(makeNumber("", data.Labels{"host": "web-1", "source": "influx"}, float64Pointer(10))),
+				"B": resultValuesNoErr(makeNumber("", data.Labels{"instance": "web-1:9100", "job": "node"}, float64Pointer(4))),
+			},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results:   resultValuesNoErr(makeNumber("", data.Labels{"host": "web-1"}, float64Pointer(6))),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := New(tt.expr)
+			tt.newErrIs(t, err)
+			if e != nil {
+				res, err := e.Execute("", tt.vars, tracing.InitializeTracerForTest())
+				tt.execErrIs(t, err)
+				if err == nil {
+					require.Equal(t, tt.results, res)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package mathexp
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+)
+
+// clamp limits each value in NumberSet, SeriesSet, or Scalar to the range [min, max].
+func clamp(e *State, varSet Results, minSet Results, maxSet Results) (Results, error) {
+	newRes := Results{}
+	minV, err := scalarArg("clamp", minSet)
+	if err != nil {
+		return newRes, err
+	}
+	maxV, err := scalarArg("clamp", maxSet)
+	if err != nil {
+		return newRes, err
+	}
+	if minV > maxV {
+		return newRes, fmt.Errorf("clamp: min %v is greater than max %v", minV, maxV)
+	}
+	for _, res := range varSet.Values {
+		newVal, err := perFloat(e, res, func(f float64) float64 {
+			return math.Max(minV, math.Min(maxV, f))
+		})
+		if err != nil {
+			return newRes, err
+		}
+		newRes.Values = append(newRes.Values, newVal)
+	}
+	return newRes, nil
+}
+
+// absDiff returns the absolute difference between the two arguments. The arguments are joined
+// on their labels and series are aligned on time, the same way as for binary operations.
+func absDiff(e *State, aSet Results, bSet Results) (Results, error) {
+	unions, err := e.unionResults(aSet, bSet, nil, "a", "b", "abs_diff(a, b)")
+	if err != nil {
+		return Results{}, err
+	}
+	diff, err := e.binaryUnions("-", unions)
+	if err != nil {
+		return diff, err
+	}
+	newRes := Results{}
+	for _, res := range diff.Values {
+		newVal, err := perNullableFloat(e, res, func(f *float64) *float64 {
+			if f == nil {
+				return nil
+			}
+			nF := math.Abs(*f)
+			return &nF
+		})
+		if err != nil {
+			return newRes, err
+		}
+		newRes.Values = append(newRes.Values, newVal)
+	}
+	return newRes, nil
+}
+
+// rate returns the per-second rate of change between each point of a series and the previous one.
+// The result has one point less than the input. Points that share a timestamp with the previous point are NaN.
+func rate(e *State, varSet Results) (Results, error) {
+	return perSeries(e, "rate", varSet, func(s Series) (Series, error) {
+		return pointDiff(e, s, func(prev, cur float64, dt time.Duration) float64 {
+			if dt == 0 {
+				return math.NaN()
+			}
+			return (cur - prev) / dt.Seconds()
+		}), nil
+	})
+}
+
+// delta returns the difference between each point of a series and the previous one.
+// The result has one point less than the input.
+func delta(e *State, varSet Results) (Results, error) {
+	return perSeries(e, "delta", varSet, func(s Series) (Series, error) {
+		return pointDiff(e, s, func(prev, cur float64, _ time.Duration) float64 {
+			return cur - prev
+		}), nil
+	})
+}
+
+// cumsum returns the running total of a series. Null points stay null and do not change the total.
+func cumsum(e *State, varSet Results) (Results, error) {
+	return perSeries(e, "cumsum", varSet, func(s Series) (Series, error) {
+		newSeries := NewSeries(e.RefID, s.GetLabels(), s.Len())
+		var sum float64
+		for i := 0; i < s.Len(); i++ {
+			t, f := s.GetPoint(i)
+			if f == nil {
+				newSeries.SetPoint(i, t, nil)
+				continue
+			}
+			sum += *f
+			nF := sum
+			newSeries.SetPoint(i, t, &nF)
+		}
+		return newSeries, nil
+	})
+}
+
+// movingAvg returns the average of each point and up to n-1 previous points of a series, ignoring nulls.
+func movingAvg(e *State, varSet Results, nSet Results) (Results, error) {
+	n, err := scalarArg("moving_avg", nSet)
+	if err != nil {
+		return Results{}, err
+	}
+	if n < 1 || n != math.Trunc(n) {
+		return Results{}, fmt.Errorf("moving_avg: window must be a positive integer, got %v", n)
+	}
+	window := int(n)
+	return perSeries(e, "moving_avg", varSet, func(s Series) (Series, error) {
+		newSeries := NewSeries(e.RefID, s.GetLabels(), s.Len())
+		for i := 0; i < s.Len(); i++ {
+			var sum float64
+			var count int
+			for j := max(0, i-window+1); j <= i; j++ {
+				if f := s.GetValue(j); f != nil {
+					sum += *f
+					count++
+				}
+			}
+			if count == 0 {
+				newSeries.SetPoint(i, s.GetTime(i), nil)
+				continue
+			}
+			nF := sum / float64(count)
+			newSeries.SetPoint(i, s.GetTime(i), &nF)
+		}
+		return newSeries, nil
+	})
+}
+
+// ewma returns the exponentially weighted moving average of a series with the smoothing factor alpha,
+// where 0 < alpha <= 1. Null points stay null and do not change the average.
+func ewma(e *State, varSet Results, alphaSet Results) (Results, error) {
+	alpha, err := scalarArg("ewma", alphaSet)
+	if err != nil {
+		return Results{}, err
+	}
+	if alpha <= 0 || alpha > 1 {
+		return Results{}, fmt.Errorf("ewma: alpha must be in the range (0, 1], got %v", alpha)
+	}
+	return perSeries(e, "ewma", varSet, func(s Series) (Series, error) {
+		newSeries := NewSeries(e.RefID, s.GetLabels(), s.Len())
+		var avg *float64
+		for i := 0; i < s.Len(); i++ {
+			t, f := s.GetPoint(i)
+			if f == nil {
+				newSeries.SetPoint(i, t, nil)
+				continue
+			}
+			nF := *f
+			if avg != nil {
+				nF = alpha*(*f) + (1-alpha)*(*avg)
+			}
+			avg = &nF
+			newSeries.SetPoint(i, t, &nF)
+		}
+		return newSeries, nil
+	})
+}
+
+// timeshift moves every point of a series forward in time by the given duration, e.g. "1d" to compare
+// with the previous day. A negative duration moves the points backward.
+func timeshift(e *State, varSet Results, rawDuration string) (Results, error) {
+	d, err := gtime.ParseDuration(rawDuration)
+	if err != nil {
+		return Results{}, fmt.Errorf("timeshift: failed to parse duration %q: %w", rawDuration, err)
+	}
+	return perSeries(e, "timeshift", varSet, func(s Series) (Series, error) {
+		newSeries := NewSeries(e.RefID, s.GetLabels(), s.Len())
+		for i := 0; i < s.Len(); i++ {
+			t, f := s.GetPoint(i)
+			newSeries.SetPoint(i, t.Add(d), f)
+		}
+		return newSeries, nil
+	})
+}
+
+// perSeries passes each Series in varSet to seriesF. NoData is passed through, any other type is an error.
+func perSeries(e *State, name string, varSet Results, seriesF func(s Series) (Series, error)) (Results, error) {
+	newRes := Results{}
+	for _, res := range varSet.Values {
+		switch v := res.(type) {
+		case Series:
+			newSeries, err := seriesF(v)
+			if err != nil {
+				return newRes, err
+			}
+			newRes.Values = append(newRes.Values, newSeries)
+		case NoData:
+			newRes.Values = append(newRes.Values, NewNoData())
+		default:
+			return newRes, fmt.Errorf("%s: expected series, got %s", name, res.Type())
+		}
+	}
+	return newRes, nil
+}
+
+// pointDiff computes diffF for each pair of consecutive points. The value is null when either point is null.
+func pointDiff(e *State, s Series, diffF func(prev, cur float64, dt time.Duration) float64) Series {
+	newSeries := NewSeries(e.RefID, s.GetLabels(), 0)
+	for i := 1; i < s.Len(); i++ {
+		prevT, prev := s.GetPoint(i - 1)
+		t, cur := s.GetPoint(i)
+		if prev == nil || cur == nil {
+			newSeries.AppendPoint(t, nil)
+			continue
+		}
+		nF := diffF(*prev, *cur, t.Sub(prevT))
+		newSeries.AppendPoint(t, &nF)
+	}
+	return newSeries
+}
+
+// scalarArg returns the value of a scalar function argument.
+func scalarArg(name string, r Results) (float64, error) {
+	if len(r.Values) != 1 {
+		return 0, fmt.Errorf("%s: expected a single scalar argument, got %d values", name, len(r.Values))
+	}
+	sc, ok := r.Values[0].(Scalar)
+	if !ok {
+		return 0, fmt.Errorf("%s: expected a scalar argument, got %s", name, r.Values[0].Type())
+	}
+	f := sc.GetFloat64Value()
+	if f == nil || math.IsNaN(*f) {
+		return 0, fmt.Errorf("%s: scalar argument must be a number", name)
+	}
+	return *f, nil
+}
@@ -0,0 +1,240 @@
+package mathexp
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+func TestSeriesFuncs(t *testing.T) {
+	series := resultValuesNoErr(
+		makeSeries("", nil,
+			tp{time.Unix(0, 0), float64Pointer(2)},
+			tp{time.Unix(10, 0), float64Pointer(6)},
+			tp{time.Unix(20, 0), nil},
+			tp{time.Unix(30, 0), float64Pointer(3)},
+			tp{time.Unix(40, 0), float64Pointer(5)},
+		),
+	)
+	var tests = []struct {
+		name      string
+		expr      string
+		vars      Vars
+		newErrIs  require.ErrorAssertionFunc
+		execErrIs require.ErrorAssertionFunc
+		results   Results
+	}{
+		{
+			name:      "rate",
+			expr:      "rate($A)",
+			vars:      Vars{"A": series},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", nil,
+					tp{time.Unix(10, 0), float64Pointer(0.4)},
+					tp{time.Unix(20, 0), nil},
+					tp{time.Unix(30, 0), nil},
+					tp{time.Unix(40, 0), float64Pointer(0.2)},
+				),
+			),
+		},
+		{
+			name:      "delta",
+			expr:      "delta($A)",
+			vars:      Vars{"A": series},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", nil,
+					tp{time.Unix(10, 0), float64Pointer(4)},
+					tp{time.Unix(20, 0), nil},
+					tp{time.Unix(30, 0), nil},
+					tp{time.Unix(40, 0), float64Pointer(2)},
+				),
+			),
+		},
+		{
+			name:      "cumsum",
+			expr:      "cumsum($A)",
+			vars:      Vars{"A": series},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", nil,
+					tp{time.Unix(0, 0), float64Pointer(2)},
+					tp{time.Unix(10, 0), float64Pointer(8)},
+					tp{time.Unix(20, 0), nil},
+					tp{time.Unix(30, 0), float64Pointer(11)},
+					tp{time.Unix(40, 0), float64Pointer(16)},
+				),
+			),
+		},
+		{
+			name:      "moving_avg skips nulls",
+			expr:      "moving_avg($A, 2)",
+			vars:      Vars{"A": series},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", nil,
+					tp{time.Unix(0, 0), float64Pointer(2)},
+					tp{time.Unix(10, 0), float64Pointer(4)},
+					tp{time.Unix(20, 0), float64Pointer(6)},
+					tp{time.Unix(30, 0), float64Pointer(3)},
+					tp{time.Unix(40, 0), float64Pointer(4)},
+				),
+			),
+		},
+		{
+			name:      "moving_avg with invalid window",
+			expr:      "moving_avg($A, 1.5)",
+			vars:      Vars{"A": series},
+			newErrIs:  require.NoError,
+			execErrIs: require.Error,
+		},
+		{
+			name:      "ewma",
+			expr:      "ewma($A, 0.5)",
+			vars:      Vars{"A": series},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", nil,
+					tp{time.Unix(0, 0), float64Pointer(2)},
+					tp{time.Unix(10, 0), float64Pointer(4)},
+					tp{time.Unix(20, 0), nil},
+					tp{time.Unix(30, 0), float64Pointer(3.5)},
+					tp{time.Unix(40, 0), float64Pointer(4.25)},
+				),
+			),
+		},
+		{
+			name:      "ewma with alpha out of range",
+			expr:      "ewma($A, 0)",
+			vars:      Vars{"A": series},
+			newErrIs:  require.NoError,
+			execErrIs: require.Error,
+		},
+		{
+			name: "timeshift",
+			expr: `timeshift($A, "1m")`,
+			vars: Vars{"A": resultValuesNoErr(
+				makeSeries("", nil, tp{time.Unix(0, 0), float64Pointer(1)}),
+			)},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", nil, tp{time.Unix(60, 0), float64Pointer(1)}),
+			),
+		},
+		{
+			name:      "timeshift with invalid duration",
+			expr:      `timeshift($A, "soon")`,
+			vars:      Vars{"A": series},
+			newErrIs:  require.NoError,
+			execErrIs: require.Error,
+		},
+		{
+			name:      "series function on number - should error",
+			expr:      "rate($A)",
+			vars:      Vars{"A": resultValuesNoErr(makeNumber("", nil, float64Pointer(1)))},
+			newErrIs:  require.NoError,
+			execErrIs: require.Error,
+		},
+		{
+			name:     "series function on scalar - should error",
+			expr:     "rate(1)",
+			vars:     Vars{},
+			newErrIs: require.Error,
+		},
+		{
+			name:      "clamp on number",
+			expr:      "clamp($A, 0, 5)",
+			vars:      Vars{"A": resultValuesNoErr(makeNumber("", nil, float64Pointer(7)))},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results:   resultValuesNoErr(makeNumber("", nil, float64Pointer(5))),
+		},
+		{
+			name:      "clamp with min greater than max",
+			expr:      "clamp($A, 5, 0)",
+			vars:      Vars{"A": resultValuesNoErr(makeNumber("", nil, float64Pointer(7)))},
+			newErrIs:  require.NoError,
+			execErrIs: require.Error,
+		},
+		{
+			name: "abs_diff on series and number",
+			expr: "abs_diff($A, $B)",
+			vars: Vars{
+				"A": series,
+				"B": resultValuesNoErr(makeNumber("", nil, float64Pointer(4))),
+			},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", nil,
+					tp{time.Unix(0, 0), float64Pointer(2)},
+					tp{time.Unix(10, 0), float64Pointer(2)},
+					tp{time.Unix(20, 0), nil},
+					tp{time.Unix(30, 0), float64Pointer(1)},
+					tp{time.Unix(40, 0), float64Pointer(1)},
+				),
+			),
+		},
+		{
+			name:     "missing comma - should error",
+			expr:     "clamp($A 0, 5)",
+			vars:     Vars{},
+			newErrIs: require.Error,
+		},
+		{
+			name:     "trailing comma - should error",
+			expr:     "clamp($A, 0, 5,)",
+			vars:     Vars{},
+			newErrIs: require.Error,
+		},
+		{
+			name:     "wrong number of arguments - should error",
+			expr:     "moving_avg($A)",
+			vars:     Vars{},
+			newErrIs: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := New(tt.expr)
+			tt.newErrIs(t, err)
+			if e != nil {
+				res, err := e.Execute("", tt.vars, tracing.InitializeTracerForTest())
+				tt.execErrIs(t, err)
+				if err == nil {
+					require.Equal(t, tt.results, res)
+				}
+			}
+		})
+	}
+}
+
+func TestRateDuplicateTimestamps(t *testing.T) {
+	e, err := New("rate($A)")
+	require.NoError(t, err)
+	vars := Vars{"A": resultValuesNoErr(
+		makeSeries("", nil,
+			tp{time.Unix(0, 0), float64Pointer(2)},
+			tp{time.Unix(0, 0), float64Pointer(4)},
+			tp{time.Unix(10, 0), float64Pointer(8)},
+		),
+	)}
+	res, err := e.Execute("", vars, tracing.InitializeTracerForTest())
+	require.NoError(t, err)
+	require.Len(t, res.Values, 1)
+	s := res.Values[0].(Series)
+	require.Equal(t, 2, s.Len())
+	require.True(t, math.IsNaN(*s.GetValue(0)), "expected NaN, got %v", *s.GetValue(0))
+	require.InDelta(t, 0.4, *s.GetValue(1), 1e-9)
+}
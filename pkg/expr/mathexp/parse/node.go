@@ -9,6 +9,7 @@ package parse
 import (
 	"fmt"
 	"strconv"
+	"strings"
 )
 
 // A Node is an element in the parse tree. The interface is trivial.
@@ -165,12 +166,12 @@ func (f *FuncNode) StringAST() string {
 func (f *FuncNode) Check(t *Tree) error {
 	if len(f.Args) < len(f.F.Args) {
 		return fmt.Errorf("parse: not enough arguments for %s", f.Name)
-	} else if len(f.Args) > len(f.F.Args) {
+	} else if len(f.Args) > len(f.F.Args) && !f.F.Variadic {
 		return fmt.Errorf("parse: too many arguments for %s", f.Name)
 	}
 
 	for i, arg := range f.Args {
-		funcType := f.F.Args[i]
+		funcType := f.F.Args[min(i, len(f.F.Args)-1)]
 		argType := arg.Return()
 		// if funcType == TypeNumberSet && argType == TypeScalar {
 		// 	argType = TypeNumberSet
@@ -300,6 +301,8 @@ type BinaryNode struct {
 	Args     [2]Node
 	Operator item
 	OpStr    string
+	// Matching is set when the operator is followed by matching modifiers, e.g. $A + on(host) $B.
+	Matching *VectorMatching
 }
 
 func newBinary(operator item, arg1, arg2 Node) *BinaryNode {
@@ -308,6 +311,9 @@ func newBinary(operator item, arg1, arg2 Node) *BinaryNode {
 
 // String returns the string representation of the BinaryNode so it fulfills the Node interface.
 func (b *BinaryNode) String() string {
+	if b.Matching != nil {
+		return fmt.Sprintf("%s %s %s %s", b.Args[0], b.Operator.val, b.Matching, b.Args[1])
+	}
 	return fmt.Sprintf("%s %s %s", b.Args[0], b.Operator.val, b.Args[1])
 }
 
@@ -331,6 +337,50 @@ func (b *BinaryNode) Return() ReturnType {
 	return t0
 }
 
+// MatchCardinality is the cardinality of the match between the two sides of a binary operation.
+type MatchCardinality int
+
+const (
+	// CardOneToOne matches each item on one side with at most one item on the other side.
+	CardOneToOne MatchCardinality = iota
+	// CardManyToOne matches many items on the left side with one item on the right side (group_left).
+	CardManyToOne
+	// CardOneToMany matches one item on the left side with many items on the right side (group_right).
+	CardOneToMany
+)
+
+// VectorMatching describes how the items of the two sides of a binary operation are matched
+// when the operator is followed by on, ignoring, group_left or group_right.
+type VectorMatching struct {
+	Card MatchCardinality
+	// On is true when only MatchingLabels are compared, and false when all labels except MatchingLabels are compared.
+	On             bool
+	MatchingLabels []string
+	// Include are the labels copied from the "one" side to the result of a group_left or group_right match.
+	Include []string
+}
+
+// String returns the modifiers that describe the VectorMatching.
+func (m *VectorMatching) String() string {
+	var parts []string
+	switch {
+	case m.On:
+		parts = append(parts, fmt.Sprintf("on(%s)", strings.Join(m.MatchingLabels, ", ")))
+	case len(m.MatchingLabels) > 0:
+		parts = append(parts, fmt.Sprintf("ignoring(%s)", strings.Join(m.MatchingLabels, ", ")))
+	}
+	switch m.Card {
+	case CardManyToOne:
+		parts = append(parts, fmt.Sprintf("group_left(%s)", strings.Join(m.Include, ", ")))
+	case CardOneToMany:
+		parts = append(parts, fmt.Sprintf("group_right(%s)", strings.Join(m.Include, ", ")))
+	}
+	if len(parts) == 0 {
+		return "ignoring()"
+	}
+	return strings.Join(parts, " ")
+}
+
 // UnaryNode holds one argument and an operator.
 type UnaryNode struct {
 	NodeType
@@ -34,7 +34,9 @@ type Func struct {
 	Return        ReturnType
 	F             interface{}
 	VariantReturn bool
-	Check         func(*Tree, *FuncNode) error
+	// Variadic functions accept more arguments of the type of their last argument.
+	Variadic bool
+	Check    func(*Tree, *FuncNode) error
 }
 
 // Parse returns a Tree, created by parsing the expression described in the
@@ -174,10 +176,12 @@ C -> P {( "==" | "!=" | ">" | ">=" | "<" | "<=") P}
 P -> M {( "+" | "-" ) M}
 M -> E {( "*" | "/" ) F}
 E -> F {( "**" ) F}
+each binary operator may be followed by [("on" | "ignoring") labels] [("group_left" | "group_right") [labels]]
+labels -> "(" [label {"," label}] ")"
 F -> v | "(" O ")" | "!" O | "-" O
 v -> number | func(..) | queryVar
-Func -> name "(" param {"," param} ")"
-param -> number | "string" | queryVar
+Func -> name "(" [param {"," param}] ")"
+param -> O | "string"
 */
 
 // expr:
@@ -188,7 +192,7 @@ func (t *Tree) O() Node {
 	for {
 		switch t.peek().typ {
 		case itemOr:
-			n = newBinary(t.next(), n, t.A())
+			n = t.binary(t.next(), n, t.A)
 		default:
 			return n
 		}
@@ -201,7 +205,7 @@ func (t *Tree) A() Node {
 	for {
 		switch t.peek().typ {
 		case itemAnd:
-			n = newBinary(t.next(), n, t.C())
+			n = t.binary(t.next(), n, t.C)
 		default:
 			return n
 		}
@@ -214,7 +218,7 @@ func (t *Tree) C() Node {
 	for {
 		switch t.peek().typ {
 		case itemEq, itemNotEq, itemGreater, itemGreaterEq, itemLess, itemLessEq:
-			n = newBinary(t.next(), n, t.P())
+			n = t.binary(t.next(), n, t.P)
 		default:
 			return n
 		}
@@ -227,7 +231,7 @@ func (t *Tree) P() Node {
 	for {
 		switch t.peek().typ {
 		case itemPlus, itemMinus:
-			n = newBinary(t.next(), n, t.M())
+			n = t.binary(t.next(), n, t.M)
 		default:
 			return n
 		}
@@ -240,7 +244,7 @@ func (t *Tree) M() Node {
 	for {
 		switch t.peek().typ {
 		case itemMult, itemDiv, itemMod:
-			n = newBinary(t.next(), n, t.E())
+			n = t.binary(t.next(), n, t.E)
 		default:
 			return n
 		}
@@ -253,13 +257,78 @@ func (t *Tree) E() Node {
 	for {
 		switch t.peek().typ {
 		case itemPow:
-			n = newBinary(t.next(), n, t.F())
+			n = t.binary(t.next(), n, t.F)
 		default:
 			return n
 		}
 	}
 }
 
+// binary creates a BinaryNode for operator, parsing the matching modifiers that follow the operator
+// and then the right operand with operand.
+func (t *Tree) binary(operator item, left Node, operand func() Node) Node {
+	matching := t.matching()
+	n := newBinary(operator, left, operand())
+	n.Matching = matching
+	return n
+}
+
+// matching parses the optional on, ignoring, group_left and group_right modifiers of a binary operator.
+func (t *Tree) matching() *VectorMatching {
+	var m *VectorMatching
+	if token := t.peek(); token.typ == itemFunc && (token.val == "on" || token.val == "ignoring") {
+		t.next()
+		m = &VectorMatching{On: token.val == "on", MatchingLabels: t.labels(token.val)}
+	}
+	if token := t.peek(); token.typ == itemFunc && (token.val == "group_left" || token.val == "group_right") {
+		t.next()
+		if m == nil {
+			m = &VectorMatching{}
+		}
+		m.Card = CardManyToOne
+		if token.val == "group_right" {
+			m.Card = CardOneToMany
+		}
+		if t.peek().typ == itemLeftParen {
+			m.Include = t.labels(token.val)
+		}
+	}
+	return m
+}
+
+// labels parses a list of label names in parentheses. Names that are not valid identifiers can be quoted.
+func (t *Tree) labels(context string) []string {
+	t.expect(itemLeftParen, context)
+	labels := []string{}
+	for {
+		token := t.next()
+		switch token.typ {
+		case itemRightParen:
+			if len(labels) > 0 {
+				t.unexpected(token, context)
+			}
+			return labels
+		case itemFunc:
+			labels = append(labels, token.val)
+		case itemString:
+			s, err := strconv.Unquote(token.val)
+			if err != nil {
+				t.errorf("Unquoting error: %s", err)
+			}
+			labels = append(labels, s)
+		default:
+			t.unexpected(token, context)
+		}
+		switch token = t.next(); token.typ {
+		case itemComma:
+		case itemRightParen:
+			return labels
+		default:
+			t.unexpected(token, context)
+		}
+	}
+}
+
 // F is v | "(" O ")" | "!" O | "-" O in the grammar.
 func (t *Tree) F() Node {
 	switch token := t.peek(); token.typ {
@@ -317,22 +386,40 @@ func (t *Tree) Func() (f *FuncNode) {
 	}
 	f = newFunc(token.pos, token.val, funcv)
 	t.expect(itemLeftParen, "func")
+	expectArg := true
 	for {
 		switch token = t.next(); token.typ {
 		default:
+			if !expectArg {
+				t.unexpected(token, "func")
+			}
 			t.backup()
 			node := t.O()
 			f.append(node)
-			if len(f.Args) == 1 && f.F.VariantReturn {
+			// A variant function returns the widest type of its arguments, e.g. a series when any argument is a series.
+			if f.F.VariantReturn && (len(f.Args) == 1 || node.Return() > f.F.Return) {
 				f.F.Return = node.Return()
 			}
+			expectArg = false
 		case itemString:
+			if !expectArg {
+				t.unexpected(token, "func")
+			}
 			s, err := strconv.Unquote(token.val)
 			if err != nil {
 				t.errorf("Unquoting error: %s", err)
 			}
 			f.append(newString(token.pos, token.val, s))
+			expectArg = false
+		case itemComma:
+			if expectArg {
+				t.unexpected(token, "func")
+			}
+			expectArg = true
 		case itemRightParen:
+			if expectArg && len(f.Args) > 0 {
+				t.unexpected(token, "func")
+			}
 			return
 		}
 	}
@@ -4,12 +4,17 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
 
 type ReducerFunc = func(fv *Float64Field) *float64
 
+// SeriesReducerFunc reduces a whole series, which gives access to the timestamps of the values.
+type SeriesReducerFunc = func(s Series) *float64
+
 // The reducer function
 // +enum
 type ReducerID string
@@ -22,11 +27,39 @@ const (
 	ReducerCount  ReducerID = "count"
 	ReducerLast   ReducerID = "last"
 	ReducerMedian ReducerID = "median"
+	// The first value
+	ReducerFirst ReducerID = "first"
+	// Population standard deviation
+	ReducerStdDev ReducerID = "stddev"
+	// Population variance
+	ReducerVariance ReducerID = "variance"
+	// Difference between the maximum and the minimum value
+	ReducerRange ReducerID = "range"
+	// Number of values that are neither null nor NaN
+	ReducerCountNonNull ReducerID = "count_non_null"
+	// Difference between the last and the first value
+	ReducerDiff ReducerID = "diff"
+	// Total increase, where a decrease is treated as a counter reset
+	ReducerDelta ReducerID = "delta"
+	// Per-second rate of change between the first and the last value
+	ReducerRate ReducerID = "rate"
+	// 50th percentile. Any percentile can be requested as "p" followed by a number between 0 and 100, e.g. "p99.9"
+	ReducerP50 ReducerID = "p50"
+	// 90th percentile
+	ReducerP90 ReducerID = "p90"
+	// 95th percentile
+	ReducerP95 ReducerID = "p95"
+	// 99th percentile
+	ReducerP99 ReducerID = "p99"
 )
 
 // GetSupportedReduceFuncs returns collection of supported function names
 func GetSupportedReduceFuncs() []ReducerID {
-	return []ReducerID{ReducerSum, ReducerMean, ReducerMin, ReducerMax, ReducerCount, ReducerLast, ReducerMedian}
+	return []ReducerID{
+		ReducerSum, ReducerMean, ReducerMin, ReducerMax, ReducerCount, ReducerLast, ReducerMedian,
+		ReducerFirst, ReducerStdDev, ReducerVariance, ReducerRange, ReducerCountNonNull, ReducerDiff, ReducerDelta, ReducerRate,
+		ReducerP50, ReducerP90, ReducerP95, ReducerP99,
+	}
 }
 
 func Sum(fv *Float64Field) *float64 {
@@ -126,6 +159,135 @@ func Median(fv *Float64Field) *float64 {
 	}
 }
 
+func First(fv *Float64Field) *float64 {
+	var f float64
+	if fv.Len() == 0 {
+		f = math.NaN()
+		return &f
+	}
+	return fv.GetValue(0)
+}
+
+// values returns all values of the field, or false if the field is empty or holds a null or NaN value.
+func values(fv *Float64Field) ([]float64, bool) {
+	vals := make([]float64, 0, fv.Len())
+	for i := 0; i < fv.Len(); i++ {
+		v := fv.GetValue(i)
+		if v == nil || math.IsNaN(*v) {
+			return nil, false
+		}
+		vals = append(vals, *v)
+	}
+	return vals, len(vals) > 0
+}
+
+func Variance(fv *Float64Field) *float64 {
+	vals, ok := values(fv)
+	if !ok {
+		nan := math.NaN()
+		return &nan
+	}
+	var sum float64
+	for _, v := range vals {
+		sum += v
+	}
+	mean := sum / float64(len(vals))
+	var squares float64
+	for _, v := range vals {
+		squares += (v - mean) * (v - mean)
+	}
+	f := squares / float64(len(vals))
+	return &f
+}
+
+func StdDev(fv *Float64Field) *float64 {
+	f := math.Sqrt(*Variance(fv))
+	return &f
+}
+
+func Range(fv *Float64Field) *float64 {
+	minV, maxV := Min(fv), Max(fv)
+	f := *maxV - *minV
+	return &f
+}
+
+func CountNonNull(fv *Float64Field) *float64 {
+	var f float64
+	for i := 0; i < fv.Len(); i++ {
+		if v := fv.GetValue(i); v != nil && !math.IsNaN(*v) {
+			f++
+		}
+	}
+	return &f
+}
+
+func Diff(fv *Float64Field) *float64 {
+	vals, ok := values(fv)
+	if !ok {
+		nan := math.NaN()
+		return &nan
+	}
+	f := vals[len(vals)-1] - vals[0]
+	return &f
+}
+
+// Delta returns the total increase of the values. A value lower than the previous one is treated
+// as a counter reset, so the value itself counts as the increase since the reset.
+func Delta(fv *Float64Field) *float64 {
+	vals, ok := values(fv)
+	if !ok {
+		nan := math.NaN()
+		return &nan
+	}
+	var f float64
+	for i := 1; i < len(vals); i++ {
+		if vals[i] < vals[i-1] {
+			f += vals[i]
+		} else {
+			f += vals[i] - vals[i-1]
+		}
+	}
+	return &f
+}
+
+// Percentile returns a reducer that computes the p-th percentile (0-100) using linear interpolation
+// between the closest ranks, so that Percentile(50) is equal to Median.
+func Percentile(p float64) ReducerFunc {
+	return func(fv *Float64Field) *float64 {
+		vals, ok := values(fv)
+		if !ok {
+			nan := math.NaN()
+			return &nan
+		}
+		sort.Float64s(vals)
+		rank := p / 100 * float64(len(vals)-1)
+		lower := int(math.Floor(rank))
+		upper := int(math.Ceil(rank))
+		f := vals[lower] + (vals[upper]-vals[lower])*(rank-float64(lower))
+		return &f
+	}
+}
+
+// Rate returns the per-second rate of change between the first and the last point of the series.
+func Rate(s Series) *float64 {
+	nan := math.NaN()
+	if s.Len() < 2 {
+		return &nan
+	}
+	first, last := s.GetValue(0), s.GetValue(s.Len()-1)
+	for i := 0; i < s.Len(); i++ {
+		if v := s.GetValue(i); v == nil || math.IsNaN(*v) {
+			return &nan
+		}
+	}
+	seconds := s.GetTime(s.Len() - 1).Sub(s.GetTime(0)).Seconds()
+	if seconds == 0 {
+		return &nan
+	}
+	f := (*last - *first) / seconds
+	return &f
+}
+
 func GetReduceFunc(rFunc ReducerID) (ReducerFunc, error) {
 	switch rFunc {
 	case ReducerSum:
@@ -142,9 +304,54 @@ func GetReduceFunc(rFunc ReducerID) (ReducerFunc, error) {
 		return Last, nil
 	case ReducerMedian:
 		return Median, nil
-	default:
-		return nil, fmt.Errorf("reduction %v not implemented", rFunc)
+	case ReducerFirst:
+		return First, nil
+	case ReducerStdDev:
+		return StdDev, nil
+	case ReducerVariance:
+		return Variance, nil
+	case ReducerRange:
+		return Range, nil
+	case ReducerCountNonNull:
+		return CountNonNull, nil
+	case ReducerDiff:
+		return Diff, nil
+	case ReducerDelta:
+		return Delta, nil
+	}
+	if p, ok := parsePercentile(rFunc); ok {
+		return Percentile(p), nil
+	}
+	return nil, fmt.Errorf("reduction %v not implemented", rFunc)
+}
+
+// GetSeriesReduceFunc returns the reduction function for the given reducer as a function of a whole series.
+// Unlike GetReduceFunc it supports reducers that depend on timestamps, such as rate.
+func GetSeriesReduceFunc(rFunc ReducerID) (SeriesReducerFunc, error) {
+	if rFunc == ReducerRate {
+		return Rate, nil
+	}
+	reduceFunc, err := GetReduceFunc(rFunc)
+	if err != nil {
+		return nil, err
 	}
+	return func(s Series) *float64 {
+		floatField := Float64Field(*s.Frame.Fields[seriesTypeValIdx])
+		return reduceFunc(&floatField)
+	}, nil
+}
+
+// parsePercentile parses reducers such as p95 or p99.9 and returns the requested percentile.
+func parsePercentile(rFunc ReducerID) (float64, bool) {
+	raw, ok := strings.CutPrefix(string(rFunc), "p")
+	if !ok {
+		return 0, false
+	}
+	p, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(p) || p < 0 || p > 100 {
+		return 0, false
+	}
+	return p, true
 }
 
 // Reduce turns the Series into a Number based on the given reduction function
@@ -161,13 +368,11 @@ func (s Series) Reduce(refID string, rFunc ReducerID, mapper ReduceMapper) (Numb
 	if mapper != nil {
 		series = mapSeries(s, mapper)
 	}
-	fVec := series.Frame.Fields[seriesTypeValIdx]
-	floatField := Float64Field(*fVec)
-	reduceFunc, err := GetReduceFunc(rFunc)
+	reduceFunc, err := GetSeriesReduceFunc(rFunc)
 	if err != nil {
 		return number, fmt.Errorf("invalid expression '%s': %w", refID, err)
 	}
-	f = reduceFunc(&floatField)
+	f = reduceFunc(series)
 	if f != nil && mapper != nil {
 		f = mapper.MapOutput(f)
 	}
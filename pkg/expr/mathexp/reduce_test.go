@@ -299,6 +299,91 @@ func TestSeriesReduce(t *testing.T) {
 	}
 }
 
+func TestSeriesReduceStatistics(t *testing.T) {
+	series := makeSeries("temp", nil,
+		tp{time.Unix(0, 0), float64Pointer(4)},
+		tp{time.Unix(10, 0), float64Pointer(2)},
+		tp{time.Unix(20, 0), float64Pointer(8)},
+		tp{time.Unix(30, 0), float64Pointer(6)},
+		tp{time.Unix(40, 0), float64Pointer(10)},
+	)
+	withNil := seriesWithNil["A"].Values[0].(Series)
+	empty := seriesEmpty["A"].Values[0].(Series)
+
+	var tests = []struct {
+		red      ReducerID
+		series   Series
+		expected *float64
+	}{
+		{red: ReducerFirst, series: series, expected: float64Pointer(4)},
+		{red: ReducerFirst, series: empty, expected: NaN},
+		{red: ReducerVariance, series: series, expected: float64Pointer(8)},
+		{red: ReducerVariance, series: withNil, expected: NaN},
+		{red: ReducerStdDev, series: series, expected: float64Pointer(math.Sqrt(8))},
+		{red: ReducerStdDev, series: empty, expected: NaN},
+		{red: ReducerRange, series: series, expected: float64Pointer(8)},
+		{red: ReducerRange, series: withNil, expected: NaN},
+		{red: ReducerCountNonNull, series: series, expected: float64Pointer(5)},
+		{red: ReducerCountNonNull, series: withNil, expected: float64Pointer(1)},
+		{red: ReducerCountNonNull, series: empty, expected: float64Pointer(0)},
+		{red: ReducerDiff, series: series, expected: float64Pointer(6)},
+		{red: ReducerDiff, series: withNil, expected: NaN},
+		{red: ReducerDelta, series: series, expected: float64Pointer(18)},
+		{red: ReducerDelta, series: empty, expected: NaN},
+		{red: ReducerRate, series: series, expected: float64Pointer(0.15)},
+		{red: ReducerRate, series: withNil, expected: NaN},
+		{red: ReducerRate, series: empty, expected: NaN},
+		{red: ReducerP50, series: series, expected: float64Pointer(6)},
+		{red: ReducerP90, series: series, expected: float64Pointer(9.2)},
+		{red: ReducerP99, series: withNil, expected: NaN},
+		{red: "p0", series: series, expected: float64Pointer(2)},
+		{red: "p100", series: series, expected: float64Pointer(10)},
+		{red: "p12.5", series: series, expected: float64Pointer(3)},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.red), func(t *testing.T) {
+			num, err := tt.series.Reduce("", tt.red, nil)
+			require.NoError(t, err)
+			actual := num.GetFloat64Value()
+			require.NotNil(t, actual)
+			if math.IsNaN(*tt.expected) {
+				require.True(t, math.IsNaN(*actual), "expected NaN, got %v", *actual)
+				return
+			}
+			require.InDelta(t, *tt.expected, *actual, 1e-9)
+		})
+	}
+
+	t.Run("invalid percentiles", func(t *testing.T) {
+		for _, red := range []ReducerID{"p101", "p-1", "pfoo", "p"} {
+			_, err := series.Reduce("", red, nil)
+			require.Error(t, err, red)
+		}
+	})
+
+	t.Run("p50 equals median", func(t *testing.T) {
+		median, err := GetReduceFunc(ReducerMedian)
+		require.NoError(t, err)
+		p50, err := GetReduceFunc(ReducerP50)
+		require.NoError(t, err)
+		for i := 0; i < 10; i++ {
+			vals := make([]*float64, rand.Intn(20)+1)
+			for j := range vals {
+				vals[j] = float64Pointer(rand.Float64())
+			}
+			ff := Float64Field(*data.NewField("", nil, vals))
+			require.InDelta(t, *median(&ff), *p50(&ff), 1e-9)
+		}
+	})
+
+	t.Run("dropNN ignores non numbers", func(t *testing.T) {
+		num, err := withNil.Reduce("", ReducerStdDev, DropNonNumber{})
+		require.NoError(t, err)
+		require.Equal(t, float64Pointer(0), num.GetFloat64Value())
+	})
+}
+
 var seriesNonNumbers = Vars{
 	"A": resultValuesNoErr(
 		makeSeries("temp", nil,
@@ -3,8 +3,6 @@ package mathexp
 import (
 	"fmt"
 	"time"
-
-	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
 
 // The upsample function
@@ -28,13 +26,17 @@ func (s Series) Resample(refID string, interval time.Duration, downsampler Reduc
 	if newSeriesLength <= 0 {
 		return s, fmt.Errorf("the series cannot be sampled further; the time range is shorter than the interval")
 	}
+	downsample, err := GetSeriesReduceFunc(downsampler)
+	if err != nil {
+		return s, fmt.Errorf("downsampling %v not implemented", downsampler)
+	}
 	resampled := NewSeries(refID, s.GetLabels(), newSeriesLength+1)
 	bookmark := 0
 	var lastSeen *float64
 	idx := 0
 	t := from
 	for !t.After(to) && idx <= newSeriesLength {
-		vals := make([]*float64, 0)
+		window := NewSeries("", s.GetLabels(), 0)
 		sIdx := bookmark
 		for sIdx != s.Len() {
 			st, v := s.GetPoint(sIdx)
@@ -44,10 +46,10 @@ func (s Series) Resample(refID string, interval time.Duration, downsampler Reduc
 			bookmark++
 			sIdx++
 			lastSeen = v
-			vals = append(vals, v)
+			window.AppendPoint(st, v)
 		}
 		var value *float64
-		if len(vals) == 0 { // upsampling
+		if window.Len() == 0 { // upsampling
 			switch upsampler {
 			case UpsamplerPad:
 				if lastSeen != nil {
@@ -66,27 +68,8 @@ func (s Series) Resample(refID string, interval time.Duration, downsampler Reduc
 			default:
 				return s, fmt.Errorf("upsampling %v not implemented", upsampler)
 			}
-		} else if len(vals) == 1 {
-			value = vals[0]
 		} else { // downsampling
-			fVec := data.NewField("", s.GetLabels(), vals)
-			ff := Float64Field(*fVec)
-			var tmp *float64
-			switch downsampler {
-			case ReducerSum:
-				tmp = Sum(&ff)
-			case ReducerMean:
-				tmp = Avg(&ff)
-			case ReducerMin:
-				tmp = Min(&ff)
-			case ReducerMax:
-				tmp = Max(&ff)
-			case ReducerLast:
-				tmp = Last(&ff)
-			default:
-				return s, fmt.Errorf("downsampling %v not implemented", downsampler)
-			}
-			value = tmp
+			value = downsample(window)
 		}
 		resampled.SetPoint(idx, t, value)
 		t = t.Add(interval)
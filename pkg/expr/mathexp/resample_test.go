@@ -1,11 +1,13 @@
 package mathexp
 
 import (
+	"math"
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
-	"github.com/stretchr/testify/assert"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/stretchr/testify/require"
 )
 
@@ -277,6 +279,49 @@ func TestResampleSeries(t *testing.T) {
 				time.Unix(9, 0), float64Pointer(0),
 			}),
 		},
+		{
+			name:        "resample series: downsampling (rate / pad)",
+			interval:    time.Second * 3,
+			downsampler: "rate",
+			upsampler:   "pad",
+			timeRange: backend.TimeRange{
+				From: time.Unix(0, 0),
+				To:   time.Unix(11, 0),
+			},
+			seriesToResample: makeSeries("", nil, tp{
+				time.Unix(0, 0), float64Pointer(0),
+			}, tp{
+				time.Unix(2, 0), float64Pointer(2),
+			}, tp{
+				time.Unix(4, 0), float64Pointer(3),
+			}, tp{
+				time.Unix(6, 0), float64Pointer(4),
+			}, tp{
+				time.Unix(8, 0), float64Pointer(0),
+			}),
+			series: makeSeries("", nil, tp{
+				time.Unix(0, 0), NaN,
+			}, tp{
+				time.Unix(3, 0), NaN,
+			}, tp{
+				time.Unix(6, 0), float64Pointer(0.5),
+			}, tp{
+				time.Unix(9, 0), NaN,
+			}),
+		},
+		{
+			name:        "resample series: unknown downsampler",
+			interval:    time.Second * 3,
+			downsampler: "foo",
+			upsampler:   "pad",
+			timeRange: backend.TimeRange{
+				From: time.Unix(0, 0),
+				To:   time.Unix(11, 0),
+			},
+			seriesToResample: makeSeries("", nil, tp{
+				time.Unix(0, 0), float64Pointer(0),
+			}),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -285,7 +330,13 @@ func TestResampleSeries(t *testing.T) {
 				require.Error(t, err)
 			} else {
 				require.NoError(t, err)
-				assert.Equal(t, tt.series, series)
+				opt := cmp.Comparer(func(x, y float64) bool {
+					return (math.IsNaN(x) && math.IsNaN(y)) || x == y
+				})
+				options := append([]cmp.Option{opt}, data.FrameTestCompareOptions()...)
+				if diff := cmp.Diff(tt.series, series, options...); diff != "" {
+					t.Errorf("Result mismatch (-want +got):\n%s", diff)
+				}
 			}
 		})
 	}
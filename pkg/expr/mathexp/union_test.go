@@ -5,7 +5,9 @@ import (
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/expr/mathexp/parse"
+	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_union(t *testing.T) {
@@ -295,8 +297,124 @@ func Test_union(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			fakeNode := &parse.BinaryNode{Args: [2]parse.Node{&parse.VarNode{}, &parse.VarNode{}}}
-			unions := (&State{}).union(tt.aResults, tt.bResults, fakeNode)
+			unions, err := (&State{}).union(tt.aResults, tt.bResults, fakeNode)
+			require.NoError(t, err)
 			tt.unionsAre(t, tt.unions, unions)
 		})
 	}
 }
+
+func TestVectorMatching(t *testing.T) {
+	number := func(labels data.Labels, f float64) Value {
+		return makeNumber("", labels, float64Pointer(f))
+	}
+	var tests = []struct {
+		name      string
+		expr      string
+		vars      Vars
+		newErrIs  require.ErrorAssertionFunc
+		execErrIs require.ErrorAssertionFunc
+		results   Results
+	}{
+		{
+			name: "on matches only the listed labels and keeps them",
+			expr: "$A + on(host) $B",
+			vars: Vars{
+				"A": resultValuesNoErr(number(data.Labels{"host": "a", "job": "node"}, 1)),
+				"B": resultValuesNoErr(number(data.Labels{"host": "a", "dc": "eu"}, 10)),
+			},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results:   resultValuesNoErr(number(data.Labels{"host": "a"}, 11)),
+		},
+		{
+			name: "ignoring matches all other labels",
+			expr: "$A / ignoring(code) $B",
+			vars: Vars{
+				"A": resultValuesNoErr(number(data.Labels{"host": "a", "code": "500"}, 5)),
+				"B": resultValuesNoErr(number(data.Labels{"host": "a"}, 10)),
+			},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results:   resultValuesNoErr(number(data.Labels{"host": "a"}, 0.5)),
+		},
+		{
+			name: "many-to-one without group_left - should error",
+			expr: "$A / on(host) $B",
+			vars: Vars{
+				"A": resultValuesNoErr(number(data.Labels{"host": "a", "code": "500"}, 5), number(data.Labels{"host": "a", "code": "200"}, 5)),
+				"B": resultValuesNoErr(number(data.Labels{"host": "a"}, 10)),
+			},
+			newErrIs:  require.NoError,
+			execErrIs: require.Error,
+		},
+		{
+			name: "group_left keeps the labels of the many side and includes labels of the one side",
+			expr: "$A / on(host) group_left(dc) $B",
+			vars: Vars{
+				"A": resultValuesNoErr(number(data.Labels{"host": "a", "code": "500"}, 5), number(data.Labels{"host": "a", "code": "200"}, 15)),
+				"B": resultValuesNoErr(number(data.Labels{"host": "a", "dc": "eu"}, 10)),
+			},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				number(data.Labels{"host": "a", "code": "500", "dc": "eu"}, 0.5),
+				number(data.Labels{"host": "a", "code": "200", "dc": "eu"}, 1.5),
+			),
+		},
+		{
+			name: "group_right keeps the operand order",
+			expr: "$A - on(host) group_right $B",
+			vars: Vars{
+				"A": resultValuesNoErr(number(data.Labels{"host": "a"}, 10)),
+				"B": resultValuesNoErr(number(data.Labels{"host": "a", "code": "500"}, 4)),
+			},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results:   resultValuesNoErr(number(data.Labels{"host": "a", "code": "500"}, 6)),
+		},
+		{
+			name: "duplicate groups on the one side - should error",
+			expr: "$A / on(host) group_left $B",
+			vars: Vars{
+				"A": resultValuesNoErr(number(data.Labels{"host": "a", "code": "500"}, 5)),
+				"B": resultValuesNoErr(number(data.Labels{"host": "a", "dc": "eu"}, 10), number(data.Labels{"host": "a", "dc": "us"}, 10)),
+			},
+			newErrIs:  require.NoError,
+			execErrIs: require.Error,
+		},
+		{
+			name: "matching is ignored for scalars",
+			expr: "$A * on(host) 2",
+			vars: Vars{
+				"A": resultValuesNoErr(number(data.Labels{"host": "a"}, 5)),
+			},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results:   resultValuesNoErr(number(data.Labels{"host": "a"}, 10)),
+		},
+		{
+			name:     "label list with a trailing comma - should error",
+			expr:     "$A + on(host,) $B",
+			newErrIs: require.Error,
+		},
+		{
+			name:     "label list without parentheses - should error",
+			expr:     "$A + on host $B",
+			newErrIs: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := New(tt.expr)
+			tt.newErrIs(t, err)
+			if e != nil {
+				res, err := e.Execute("", tt.vars, tracing.InitializeTracerForTest())
+				tt.execErrIs(t, err)
+				if err == nil {
+					require.Equal(t, tt.results, res)
+				}
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@ package expr
 import (
 	"embed"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
 	"github.com/grafana/grafana/pkg/expr/classic"
 	"github.com/grafana/grafana/pkg/expr/mathexp"
 )
@@ -29,6 +31,12 @@ const (
 
 	// SQL query
 	QueryTypeSQL QueryType = "sql"
+
+	// Forecast expected values and anomaly bands
+	QueryTypeForecast QueryType = "forecast"
+
+	// Detect missing dimensions
+	QueryTypeAbsent QueryType = "absent"
 )
 
 type MathQuery struct {
@@ -80,6 +88,62 @@ type SQLExpression struct {
 	Format     string `json:"format"`
 }
 
+// QueryType = forecast
+type ForecastQuery struct {
+	// Reference to single query result
+	Expression string `json:"expression" jsonschema:"minLength=1,example=$A"`
+
+	// The forecasting method
+	Method ForecastMethod `json:"method"`
+
+	// Width of the bands in standard deviations, 3 when not set
+	Sensitivity float64 `json:"sensitivity,omitempty"`
+
+	// Trailing window used by zscore, mad and predict_linear, the whole series when not set
+	Window string `json:"window,omitempty" jsonschema:"example=1h,example=1d"`
+
+	// Season length used by holt_winters, no seasonality when not set
+	Season string `json:"season,omitempty" jsonschema:"example=1d,example=1w"`
+
+	// How far past the last point holt_winters and predict_linear extend the baseline and bands
+	Horizon string `json:"horizon,omitempty" jsonschema:"example=1h"`
+
+	// Holt-Winters smoothing factors for the level, trend and season
+	Alpha *float64 `json:"alpha,omitempty"`
+	Beta  *float64 `json:"beta,omitempty"`
+	Gamma *float64 `json:"gamma,omitempty"`
+
+	// The series to return for each input series, all of them when not set
+	Outputs []ForecastOutput `json:"outputs,omitempty"`
+}
+
+// QueryType = absent
+type AbsentQuery struct {
+	// Reference to single query result
+	Expression string `json:"expression" jsonschema:"minLength=1,example=$A"`
+
+	// Label sets that must be present in the result
+	Expected []map[string]string `json:"expected,omitempty"`
+
+	// Series with values in the time range but none in this window before its end are missing
+	Lookback string `json:"lookback,omitempty" jsonschema:"example=5m,example=1h"`
+
+	// Dimensions of the previous results of the alert rule, which are missing when they are not in the time range
+	LoadedDimensions *data.Frame `json:"loadedDimensions,omitempty"`
+}
+
+// ExpectedLabels returns the expected label sets as data.Labels.
+func (q AbsentQuery) ExpectedLabels() []data.Labels {
+	if len(q.Expected) == 0 {
+		return nil
+	}
+	labels := make([]data.Labels, 0, len(q.Expected))
+	for _, l := range q.Expected {
+		labels = append(labels, data.Labels(l))
+	}
+	return labels
+}
+
 //-------------------------------
 // Non-query commands
 //-------------------------------
@@ -26,6 +26,8 @@ func TestQueryTypeDefinitions(t *testing.T) {
 				reflect.TypeOf(mathexp.UpsamplerPad), // pick an example value (not the root)
 				reflect.TypeOf(ReduceModeDrop),       // pick an example value (not the root)
 				reflect.TypeOf(ThresholdIsAbove),
+				reflect.TypeOf(ForecastMethodZScore),
+				reflect.TypeOf(ForecastOutputScore),
 				reflect.TypeOf(classic.ConditionOperatorAnd),
 			},
 		})
@@ -161,6 +163,49 @@ func TestQueryTypeDefinitions(t *testing.T) {
 				},
 			},
 		},
+		schemabuilder.QueryTypeInfo{
+			Discriminators: data.NewDiscriminators("type", QueryTypeForecast),
+			GoType:         reflect.TypeOf(&ForecastQuery{}),
+			Examples: []data.QueryExample{
+				{
+					Name: "daily seasonal baseline of A",
+					SaveModel: data.AsUnstructured(ForecastQuery{
+						Expression: "$A",
+						Method:     ForecastMethodHoltWinters,
+						Season:     "1d",
+					}),
+				},
+				{
+					Name: "anomaly score of A over the last hour",
+					SaveModel: data.AsUnstructured(ForecastQuery{
+						Expression: "$A",
+						Method:     ForecastMethodMAD,
+						Window:     "1h",
+						Outputs:    []ForecastOutput{ForecastOutputScore},
+					}),
+				},
+			},
+		},
+		schemabuilder.QueryTypeInfo{
+			Discriminators: data.NewDiscriminators("type", QueryTypeAbsent),
+			GoType:         reflect.TypeOf(&AbsentQuery{}),
+			Examples: []data.QueryExample{
+				{
+					Name: "hosts missing from A",
+					SaveModel: data.AsUnstructured(AbsentQuery{
+						Expression: "$A",
+						Expected:   []map[string]string{{"host": "a"}, {"host": "b"}},
+					}),
+				},
+				{
+					Name: "series of A without values in the last 5 minutes",
+					SaveModel: data.AsUnstructured(AbsentQuery{
+						Expression: "$A",
+						Lookback:   "5m",
+					}),
+				},
+			},
+		},
 	)
 
 	require.NoError(t, err)
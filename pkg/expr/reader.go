@@ -178,6 +178,42 @@ func (h *ExpressionQueryReader) ReadQuery(
 			}
 		}
 
+	case QueryTypeForecast:
+		q := &ForecastQuery{}
+		err = iter.ReadVal(q)
+		if err == nil {
+			referenceVar, err = getReferenceVar(q.Expression, common.RefID)
+		}
+		if err == nil {
+			eq.Properties = q
+			eq.Command, err = NewForecastCommand(common.RefID, referenceVar, *q)
+		}
+
+	case QueryTypeAbsent:
+		q := &AbsentQuery{}
+		err = iter.ReadVal(q)
+		if err == nil {
+			referenceVar, err = getReferenceVar(q.Expression, common.RefID)
+		}
+		if err == nil {
+			var tr TimeRange
+			if common.TimeRange != nil {
+				r := gtime.NewTimeRange(common.TimeRange.From, common.TimeRange.To)
+				tr = AbsoluteTimeRange{
+					From: r.GetFromAsTimeUTC(),
+					To:   r.GetToAsTimeUTC(),
+				}
+			}
+			var loaded Dimensions
+			if q.LoadedDimensions != nil {
+				loaded, err = DimensionsFromFrame(q.LoadedDimensions)
+			}
+			if err == nil {
+				eq.Properties = q
+				eq.Command, err = NewAbsentCommand(common.RefID, referenceVar, q.ExpectedLabels(), q.Lookback, tr, loaded)
+			}
+		}
+
 	default:
 		err = fmt.Errorf("unknown query type (%s)", common.QueryType)
 	}
@@ -25,6 +25,11 @@ type DB interface {
 	// through [context.Context] or if that's not present, as non-transactional database
 	// operations.
 	WithDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc) error
+	// WithReadReplica runs read-only database operations on a read replica, if read
+	// replicas are configured and healthy, or on the primary otherwise. Only use it for
+	// reads that tolerate data that is up to the maximum replication lag old, like
+	// searches and lists. The session in the [context.Context] is used if there is one.
+	WithReadReplica(ctx context.Context, callback sqlstore.DBTransactionFunc) error
 	// GetDialect returns an object that contains information about the peculiarities of
 	// the particular database type available to the runtime.
 	GetDialect() migrator.Dialect
@@ -38,6 +43,9 @@ type DB interface {
 	// the expected parameters: "&sql_mode='ANSI_QUOTES" and "&parseTime=true"
 	// The sqlx session is useful, but be careful not to expect automagic date parsing
 	GetSqlxSession() *session.SessionDB
+	// ReadSession is the sqlx session of a read replica, if read replicas are configured
+	// and healthy, or of the primary otherwise. See [DB.WithReadReplica].
+	ReadSession() *session.SessionDB
 	// InTransaction creates a new SQL transaction that is placed on the context.
 	// Use together with [DB.WithDbSession] to run database operations.
 	InTransaction(ctx context.Context, fn func(ctx context.Context) error) error
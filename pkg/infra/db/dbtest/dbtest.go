@@ -28,6 +28,10 @@ func (f *FakeDB) WithDbSession(ctx context.Context, callback sqlstore.DBTransact
 	return f.ExpectedError
 }
 
+func (f *FakeDB) WithReadReplica(ctx context.Context, callback sqlstore.DBTransactionFunc) error {
+	return f.ExpectedError
+}
+
 func (f *FakeDB) WithNewDbSession(ctx context.Context, callback sqlstore.DBTransactionFunc) error {
 	return f.ExpectedError
 }
@@ -52,6 +56,10 @@ func (f *FakeDB) GetSqlxSession() *session.SessionDB {
 	return nil
 }
 
+func (f *FakeDB) ReadSession() *session.SessionDB {
+	return nil
+}
+
 func (f *FakeDB) Quote(value string) string {
 	return ""
 }
@@ -21,6 +21,8 @@ type PluginManagementCfg struct {
 	GrafanaComAPIURL   string
 	GrafanaComAPIToken string
 
+	PluginRepositoryURL string
+
 	GrafanaAppURL string
 
 	Features Features
@@ -41,6 +43,7 @@ type Features struct {
 func NewPluginManagementCfg(devMode bool, pluginsPath string, pluginSettings setting.PluginSettings, pluginsAllowUnsigned []string,
 	pluginsCDNURLTemplate string, appURL string, features Features,
 	grafanaComAPIURL string, disablePlugins []string, hideAngularDeprecation []string, forwardHostEnvVars []string, grafanaComAPIToken string,
+	pluginRepositoryURL string,
 ) *PluginManagementCfg {
 	return &PluginManagementCfg{
 		PluginsPath:            pluginsPath,
@@ -55,5 +58,6 @@ func NewPluginManagementCfg(devMode bool, pluginsPath string, pluginSettings set
 		HideAngularDeprecation: hideAngularDeprecation,
 		ForwardHostEnvVars:     forwardHostEnvVars,
 		GrafanaComAPIToken:     grafanaComAPIToken,
+		PluginRepositoryURL:    pluginRepositoryURL,
 	}
 }
@@ -1,7 +1,9 @@
 package repo
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 
 	"github.com/grafana/grafana/pkg/apimachinery/errutil"
 )
@@ -47,6 +49,12 @@ func (e ErrResponse4xx) Error() string {
 	return fmt.Sprintf("%d", e.statusCode)
 }
 
+// isNotFound returns whether the error is a not found response of the repository.
+func isNotFound(err error) bool {
+	var errResp ErrResponse4xx
+	return errors.As(err, &errResp) && errResp.StatusCode() == http.StatusNotFound
+}
+
 var (
 	ErrVersionUnsupportedMsg  = "{{.Public.PluginID}} v{{.Public.Version}} is not supported on your system {{.Public.SysInfo}}"
 	ErrVersionUnsupportedBase = errutil.Conflict("plugin.unsupportedVersion").
@@ -2,11 +2,7 @@ package repo
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"net/http"
 	"net/url"
-	"path"
 	"strings"
 
 	"github.com/grafana/grafana/pkg/plugins/config"
@@ -19,14 +15,19 @@ const (
 
 type Manager struct {
 	client *Client
+	source source
 
 	log log.PrettyLogger
 }
 
 func ProvideService(cfg *config.PluginManagementCfg) (*Manager, error) {
-	baseURL, err := url.JoinPath(cfg.GrafanaComAPIURL, "/plugins")
-	if err != nil {
-		return nil, err
+	baseURL := cfg.PluginRepositoryURL
+	if baseURL == "" {
+		var err error
+		baseURL, err = url.JoinPath(cfg.GrafanaComAPIURL, "/plugins")
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return NewManager(ManagerCfg{
@@ -38,15 +39,20 @@ func ProvideService(cfg *config.PluginManagementCfg) (*Manager, error) {
 }
 
 type ManagerCfg struct {
-	SkipTLSVerify      bool
+	SkipTLSVerify bool
+	// BaseURL is the URL of the plugin repository. It is either the URL of the grafana.com plugins API or of a
+	// self-hosted catalog with the same API, a file:// URL or path of a directory of plugin archives, or an oci://
+	// URL of a namespace of an OCI registry. See newSource for details.
 	BaseURL            string
 	GrafanaComAPIToken string
 	Logger             log.PrettyLogger
 }
 
 func NewManager(cfg ManagerCfg) *Manager {
+	client := NewClient(cfg.SkipTLSVerify, cfg.GrafanaComAPIToken, cfg.BaseURL, cfg.Logger)
 	return &Manager{
-		client: NewClient(cfg.SkipTLSVerify, cfg.GrafanaComAPIToken, cfg.BaseURL, cfg.Logger),
+		client: client,
+		source: newSource(cfg, client),
 		log:    cfg.Logger,
 	}
 }
@@ -58,12 +64,12 @@ func (m *Manager) GetPluginArchive(ctx context.Context, pluginID, version string
 		return nil, err
 	}
 
-	return m.client.Download(ctx, dlOpts.URL, dlOpts.Checksum, compatOpts)
+	return m.source.download(ctx, dlOpts.URL, dlOpts.Checksum, compatOpts)
 }
 
 // GetPluginArchiveByURL fetches the requested plugin archive from the provided `pluginZipURL`
 func (m *Manager) GetPluginArchiveByURL(ctx context.Context, pluginZipURL string, compatOpts CompatOpts) (*PluginArchive, error) {
-	return m.client.Download(ctx, pluginZipURL, "", compatOpts)
+	return m.source.download(ctx, pluginZipURL, "", compatOpts)
 }
 
 // GetPluginArchiveInfo returns the options for downloading the requested plugin (with optional `version`)
@@ -76,13 +82,13 @@ func (m *Manager) GetPluginArchiveInfo(ctx context.Context, pluginID, version st
 	return &PluginArchiveInfo{
 		Version:  v.Version,
 		Checksum: v.Checksum,
-		URL:      m.downloadURL(pluginID, v.Version),
+		URL:      m.source.downloadURL(pluginID, v, compatOpts),
 	}, nil
 }
 
 // PluginVersion will return plugin version based on the requested information
 func (m *Manager) PluginVersion(ctx context.Context, pluginID, version string, compatOpts CompatOpts) (VersionData, error) {
-	versions, err := m.grafanaCompatiblePluginVersions(ctx, pluginID, compatOpts)
+	versions, err := m.source.pluginVersions(ctx, pluginID, compatOpts)
 	if err != nil {
 		return VersionData{}, err
 	}
@@ -102,89 +108,11 @@ func (m *Manager) PluginVersion(ctx context.Context, pluginID, version string, c
 	return compatibleVer, nil
 }
 
-func (m *Manager) downloadURL(pluginID, version string) string {
-	return fmt.Sprintf("%s/%s/versions/%s/download", m.client.grafanaComAPIURL, pluginID, version)
-}
-
-// grafanaCompatiblePluginVersions will get version info from /api/plugins/$pluginID/versions
-func (m *Manager) grafanaCompatiblePluginVersions(ctx context.Context, pluginID string, compatOpts CompatOpts) ([]Version, error) {
-	u, err := url.Parse(m.client.grafanaComAPIURL)
-	if err != nil {
-		return nil, err
-	}
-
-	u.Path = path.Join(u.Path, pluginID, "versions")
-
-	body, err := m.client.SendReq(ctx, u, compatOpts)
-	if err != nil {
-		return nil, err
-	}
-
-	var v PluginVersions
-	err = json.Unmarshal(body, &v)
-	if err != nil {
-		m.log.Error("Failed to unmarshal plugin repo response", err)
-		return nil, err
-	}
-
-	if len(v.Versions) == 0 {
-		// /plugins/{pluginId}/versions returns 200 even if the plugin doesn't exists
-		// but the response is empty. In this case we return 404.
-		return nil, newErrResponse4xx(http.StatusNotFound).withMessage("Plugin not found")
-	}
-
-	return v.Versions, nil
-}
-
 type GetPluginsInfoOptions struct {
 	IncludeDeprecated bool
 	Plugins           []string
 }
 
 func (m *Manager) GetPluginsInfo(ctx context.Context, options GetPluginsInfoOptions, compatOpts CompatOpts) ([]PluginInfo, error) {
-	u, err := url.Parse(m.client.grafanaComAPIURL)
-	if err != nil {
-		return nil, err
-	}
-
-	// If we are requesting more than 50 plugins, split the request into multiple requests to avoid
-	// the URL limit (local testing shows that the URL is <2000 characters for 100 plugins, so 50 is
-	// a safe limit).
-	plugins := [][]string{}
-	results := []PluginInfo{}
-	if len(options.Plugins) > getPluginsInfoMaxPlugins {
-		for i := 0; i < len(options.Plugins); i += getPluginsInfoMaxPlugins {
-			plugins = append(plugins, options.Plugins[i:min(i+getPluginsInfoMaxPlugins, len(options.Plugins))])
-		}
-	} else {
-		plugins = [][]string{options.Plugins}
-	}
-	for _, p := range plugins {
-		q := u.Query()
-		if options.IncludeDeprecated {
-			q.Set("includeDeprecated", "true")
-		}
-		if len(p) > 0 {
-			q.Set("slugIn", strings.Join(p, ","))
-		}
-		u.RawQuery = q.Encode()
-
-		body, err := m.client.SendReq(ctx, u, compatOpts)
-		if err != nil {
-			return nil, err
-		}
-
-		var v struct {
-			Items []PluginInfo `json:"items"`
-		}
-		err = json.Unmarshal(body, &v)
-		if err != nil {
-			m.log.Error("Failed to unmarshal plugin repo response", "error", err)
-			return nil, err
-		}
-
-		results = append(results, v.Items...)
-	}
-
-	return results, nil
+	return m.source.pluginsInfo(ctx, options, compatOpts)
 }
@@ -0,0 +1,221 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// source is a plugin repository the Manager resolves plugin versions and fetches plugin archives from.
+type source interface {
+	// pluginVersions returns the versions of the plugin, sorted so the newest version is first.
+	pluginVersions(ctx context.Context, pluginID string, compatOpts CompatOpts) ([]Version, error)
+	// pluginsInfo returns the latest version of the requested plugins, or of all the plugins if none is requested.
+	pluginsInfo(ctx context.Context, options GetPluginsInfoOptions, compatOpts CompatOpts) ([]PluginInfo, error)
+	// downloadURL returns the URL of the archive of the plugin version for the system.
+	downloadURL(pluginID string, v VersionData, compatOpts CompatOpts) string
+	// download fetches the plugin archive at the URL, and verifies it against the SHA256 checksum if it is set.
+	download(ctx context.Context, archiveURL, checksum string, compatOpts CompatOpts) (*PluginArchive, error)
+}
+
+// newSource returns the source of the repository at the base URL:
+//   - file:// URLs and paths are directories of plugin archives, see localSource.
+//   - oci:// and oci+http:// URLs are namespaces of an OCI registry, see ociSource.
+//   - Any other URL is the grafana.com plugins API, or a self-hosted catalog with the same API.
+func newSource(cfg ManagerCfg, client *Client) source {
+	switch {
+	case strings.HasPrefix(cfg.BaseURL, "file://"):
+		return newLocalSource(strings.TrimPrefix(cfg.BaseURL, "file://"), client, cfg.Logger)
+	case strings.HasPrefix(cfg.BaseURL, "/"):
+		return newLocalSource(cfg.BaseURL, client, cfg.Logger)
+	case strings.HasPrefix(cfg.BaseURL, ociScheme), strings.HasPrefix(cfg.BaseURL, ociHTTPScheme):
+		return newOCISource(cfg, client)
+	default:
+		return &catalogSource{client: client}
+	}
+}
+
+// catalogSource is the grafana.com plugins API, or a self-hosted catalog with the same API.
+type catalogSource struct {
+	client *Client
+}
+
+// pluginVersions will get version info from /api/plugins/$pluginID/versions
+func (s *catalogSource) pluginVersions(ctx context.Context, pluginID string, compatOpts CompatOpts) ([]Version, error) {
+	u, err := url.Parse(s.client.grafanaComAPIURL)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Path = path.Join(u.Path, pluginID, "versions")
+
+	body, err := s.client.SendReq(ctx, u, compatOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var v PluginVersions
+	err = json.Unmarshal(body, &v)
+	if err != nil {
+		s.client.log.Error("Failed to unmarshal plugin repo response", err)
+		return nil, err
+	}
+
+	if len(v.Versions) == 0 {
+		// /plugins/{pluginId}/versions returns 200 even if the plugin doesn't exists
+		// but the response is empty. In this case we return 404.
+		return nil, newErrResponse4xx(http.StatusNotFound).withMessage("Plugin not found")
+	}
+
+	return v.Versions, nil
+}
+
+func (s *catalogSource) pluginsInfo(ctx context.Context, options GetPluginsInfoOptions, compatOpts CompatOpts) ([]PluginInfo, error) {
+	u, err := url.Parse(s.client.grafanaComAPIURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// If we are requesting more than 50 plugins, split the request into multiple requests to avoid
+	// the URL limit (local testing shows that the URL is <2000 characters for 100 plugins, so 50 is
+	// a safe limit).
+	plugins := [][]string{}
+	results := []PluginInfo{}
+	if len(options.Plugins) > getPluginsInfoMaxPlugins {
+		for i := 0; i < len(options.Plugins); i += getPluginsInfoMaxPlugins {
+			plugins = append(plugins, options.Plugins[i:min(i+getPluginsInfoMaxPlugins, len(options.Plugins))])
+		}
+	} else {
+		plugins = [][]string{options.Plugins}
+	}
+	for _, p := range plugins {
+		q := u.Query()
+		if options.IncludeDeprecated {
+			q.Set("includeDeprecated", "true")
+		}
+		if len(p) > 0 {
+			q.Set("slugIn", strings.Join(p, ","))
+		}
+		u.RawQuery = q.Encode()
+
+		body, err := s.client.SendReq(ctx, u, compatOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		var v struct {
+			Items []PluginInfo `json:"items"`
+		}
+		err = json.Unmarshal(body, &v)
+		if err != nil {
+			s.client.log.Error("Failed to unmarshal plugin repo response", "error", err)
+			return nil, err
+		}
+
+		results = append(results, v.Items...)
+	}
+
+	return results, nil
+}
+
+func (s *catalogSource) downloadURL(pluginID string, v VersionData, _ CompatOpts) string {
+	return fmt.Sprintf("%s/%s/versions/%s/download", s.client.grafanaComAPIURL, pluginID, v.Version)
+}
+
+func (s *catalogSource) download(ctx context.Context, archiveURL, checksum string, compatOpts CompatOpts) (*PluginArchive, error) {
+	return s.client.Download(ctx, archiveURL, checksum, compatOpts)
+}
+
+// archiveNameRegexp matches the names of the plugin archives published on grafana.com, which are suffixed with the
+// system they are built for, e.g. `grafana-piechart-panel-1.6.4.linux_amd64.zip`.
+var archiveNameRegexp = regexp.MustCompile(`\.([a-z0-9]+)_([a-z0-9]+)\.zip$`)
+
+// archiveSystem returns the system of the plugin archive with the given file name, in the format of the packages of
+// the versions of grafana.com, or "any" if the archive is not built for a specific system.
+func archiveSystem(name string) string {
+	m := archiveNameRegexp.FindStringSubmatch(strings.ToLower(name))
+	if m == nil {
+		return "any"
+	}
+	return m[1] + "-" + m[2]
+}
+
+// isCompatible returns whether the Grafana version satisfies the Grafana dependency of a plugin version, or nil if it
+// cannot be determined, like grafana.com does for the versions of its plugins.
+func isCompatible(grafanaDependency string, compatOpts CompatOpts) *bool {
+	grafanaVersion, ok := compatOpts.GrafanaVersion()
+	if !ok || grafanaDependency == "" {
+		return nil
+	}
+	constraint, err := semver.NewConstraint(grafanaDependency)
+	if err != nil {
+		return nil
+	}
+	v, err := semver.NewVersion(grafanaVersion)
+	if err != nil {
+		return nil
+	}
+	// Pre-release versions of Grafana satisfy the dependencies of their release version.
+	release, err := semver.NewVersion(fmt.Sprintf("%d.%d.%d", v.Major(), v.Minor(), v.Patch()))
+	if err != nil {
+		return nil
+	}
+	compatible := constraint.Check(release)
+	return &compatible
+}
+
+// sortVersions sorts the versions so the newest version is first. Versions that are not valid semantic versions are
+// sorted last.
+func sortVersions(versions []Version) {
+	slices.SortStableFunc(versions, func(a, b Version) int {
+		va, errA := semver.NewVersion(a.Version)
+		vb, errB := semver.NewVersion(b.Version)
+		switch {
+		case errA != nil && errB != nil:
+			return strings.Compare(b.Version, a.Version)
+		case errA != nil:
+			return 1
+		case errB != nil:
+			return -1
+		}
+		return vb.Compare(va)
+	})
+}
+
+// latestPluginsInfo returns the latest version compatible with the system of each plugin, for sources that don't
+// provide an index of their plugins. Plugins without compatible versions are skipped.
+func latestPluginsInfo(ctx context.Context, s source, pluginIDs []string, compatOpts CompatOpts) ([]PluginInfo, error) {
+	results := make([]PluginInfo, 0, len(pluginIDs))
+	for _, pluginID := range pluginIDs {
+		versions, err := s.pluginVersions(ctx, pluginID, compatOpts)
+		if err != nil {
+			if isNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		latest, err := latestSupportedVersion(pluginID, versions, compatOpts)
+		if err != nil {
+			continue
+		}
+		results = append(results, PluginInfo{Slug: pluginID, Version: latest.Version, Status: "active"})
+	}
+	return results, nil
+}
+
+// versionDownloadURL returns the download URL of the package of the version for the system, for sources that set the
+// download URL of every package.
+func versionDownloadURL(v VersionData, compatOpts CompatOpts) string {
+	if meta, ok := v.Arch[compatOpts.system.OSAndArch()]; ok {
+		return meta.DownloadURL
+	}
+	return v.Arch["any"].DownloadURL
+}
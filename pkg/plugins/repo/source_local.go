@@ -0,0 +1,199 @@
+package repo
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/plugins/log"
+)
+
+// localSource is a directory of plugin archives, for air-gapped environments. The archives are found in the directory
+// and its subdirectories, and their plugin ID, version and Grafana dependency are read from their plugin.json.
+//
+// Archives that are built for a specific system must be suffixed with it like on grafana.com, e.g.
+// `grafana-piechart-panel-1.6.4.linux_amd64.zip`. The SHA256 checksum of an archive is read from the file with the
+// same name and the `.sha256` extension, in the format of sha256sum, if it exists.
+type localSource struct {
+	dir    string
+	client *Client
+	log    log.PrettyLogger
+}
+
+func newLocalSource(dir string, client *Client, logger log.PrettyLogger) *localSource {
+	return &localSource{
+		dir:    dir,
+		client: client,
+		log:    logger,
+	}
+}
+
+// localArchive is a plugin archive of the directory.
+type localArchive struct {
+	path              string
+	pluginID          string
+	version           string
+	grafanaDependency string
+}
+
+func (s *localSource) pluginVersions(_ context.Context, pluginID string, compatOpts CompatOpts) ([]Version, error) {
+	archives, err := s.archives()
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[string]*Version{}
+	for _, a := range archives {
+		if a.pluginID != pluginID {
+			continue
+		}
+		v, ok := byVersion[a.version]
+		if !ok {
+			v = &Version{
+				Version:           a.version,
+				Arch:              map[string]ArchMeta{},
+				GrafanaDependency: a.grafanaDependency,
+				IsCompatible:      isCompatible(a.grafanaDependency, compatOpts),
+			}
+			byVersion[a.version] = v
+		}
+		v.Arch[archiveSystem(a.path)] = ArchMeta{
+			SHA256:      s.checksum(a.path),
+			PackageName: filepath.Base(a.path),
+			DownloadURL: a.path,
+		}
+	}
+	if len(byVersion) == 0 {
+		return nil, newErrResponse4xx(http.StatusNotFound).withMessage("Plugin not found")
+	}
+
+	versions := make([]Version, 0, len(byVersion))
+	for _, v := range byVersion {
+		versions = append(versions, *v)
+	}
+	sortVersions(versions)
+	return versions, nil
+}
+
+func (s *localSource) pluginsInfo(ctx context.Context, options GetPluginsInfoOptions, compatOpts CompatOpts) ([]PluginInfo, error) {
+	pluginIDs := options.Plugins
+	if len(pluginIDs) == 0 {
+		archives, err := s.archives()
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range archives {
+			if !slices.Contains(pluginIDs, a.pluginID) {
+				pluginIDs = append(pluginIDs, a.pluginID)
+			}
+		}
+	}
+	return latestPluginsInfo(ctx, s, pluginIDs, compatOpts)
+}
+
+func (s *localSource) downloadURL(_ string, v VersionData, compatOpts CompatOpts) string {
+	return versionDownloadURL(v, compatOpts)
+}
+
+func (s *localSource) download(ctx context.Context, archiveURL, checksum string, compatOpts CompatOpts) (*PluginArchive, error) {
+	return s.client.Download(ctx, archiveURL, checksum, compatOpts)
+}
+
+// archives returns the plugin archives of the directory. Files that are not valid plugin archives are skipped.
+func (s *localSource) archives() ([]localArchive, error) {
+	var archives []localArchive
+	err := filepath.WalkDir(s.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(p), ".zip") {
+			return nil
+		}
+		a, err := readLocalArchive(p)
+		if err != nil {
+			s.log.Warn("Skipping invalid plugin archive", "path", p, "error", err)
+			return nil
+		}
+		archives = append(archives, a)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin repository directory %s: %w", s.dir, err)
+	}
+	return archives, nil
+}
+
+// checksum returns the SHA256 checksum of the archive from its checksum file, or an empty string if there is none.
+func (s *localSource) checksum(archivePath string) string {
+	// nolint:gosec
+	// We can ignore the gosec G304 warning since the path stems from the configured plugin repository directory.
+	b, err := os.ReadFile(archivePath + ".sha256")
+	if err != nil {
+		return ""
+	}
+	fields := strings.Fields(string(b))
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToLower(fields[0])
+}
+
+// readLocalArchive reads the plugin.json of the plugin archive. The plugin.json closest to the root of the archive is
+// used, as the archives of plugins with nested plugins contain several of them.
+func readLocalArchive(archivePath string) (localArchive, error) {
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return localArchive{}, err
+	}
+	defer func() { _ = r.Close() }()
+
+	var pluginJSON *zip.File
+	for _, f := range r.File {
+		if path.Base(f.Name) != "plugin.json" {
+			continue
+		}
+		if pluginJSON == nil || strings.Count(f.Name, "/") < strings.Count(pluginJSON.Name, "/") {
+			pluginJSON = f
+		}
+	}
+	if pluginJSON == nil {
+		return localArchive{}, fmt.Errorf("archive does not contain a plugin.json")
+	}
+
+	rc, err := pluginJSON.Open()
+	if err != nil {
+		return localArchive{}, err
+	}
+	defer func() { _ = rc.Close() }()
+
+	var data struct {
+		ID   string `json:"id"`
+		Info struct {
+			Version string `json:"version"`
+		} `json:"info"`
+		Dependencies struct {
+			GrafanaDependency string `json:"grafanaDependency"`
+		} `json:"dependencies"`
+	}
+	if err := json.NewDecoder(rc).Decode(&data); err != nil {
+		return localArchive{}, fmt.Errorf("invalid plugin.json: %w", err)
+	}
+	if data.ID == "" || data.Info.Version == "" {
+		return localArchive{}, fmt.Errorf("plugin.json does not contain the plugin ID and version")
+	}
+
+	return localArchive{
+		path:              archivePath,
+		pluginID:          data.ID,
+		version:           data.Info.Version,
+		grafanaDependency: data.Dependencies.GrafanaDependency,
+	}, nil
+}
@@ -0,0 +1,105 @@
+package repo
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/plugins/log"
+)
+
+// pluginArchiveData returns a plugin archive with the plugin.json of a plugin version.
+func pluginArchiveData(t *testing.T, pluginID, version, grafanaDependency string) []byte {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	f, err := w.Create(pluginID + "/plugin.json")
+	require.NoError(t, err)
+	_, err = fmt.Fprintf(f, `{"id": %q, "info": {"version": %q}, "dependencies": {"grafanaDependency": %q}}`, pluginID, version, grafanaDependency)
+	require.NoError(t, err)
+	// Nested plugins have their own plugin.json deeper in the archive.
+	f, err = w.Create(pluginID + "/datasource/plugin.json")
+	require.NoError(t, err)
+	_, err = f.Write([]byte(`{"id": "nested-datasource", "info": {"version": "0.0.1"}}`))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestLocalSource(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o750))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0o600))
+	}
+
+	linuxArchive := pluginArchiveData(t, "test-app", "2.0.0", ">=10.0.0")
+	write("test-app-1.0.0.zip", pluginArchiveData(t, "test-app", "1.0.0", ""))
+	write("test-app/test-app-2.0.0.linux_amd64.zip", linuxArchive)
+	write("test-app/test-app-2.0.0.linux_amd64.zip.sha256", []byte(fmt.Sprintf("%x  test-app-2.0.0.linux_amd64.zip\n", sha256.Sum256(linuxArchive))))
+	write("test-app/test-app-2.0.0.darwin_arm64.zip", pluginArchiveData(t, "test-app", "2.0.0", ">=10.0.0"))
+	write("test-app-3.0.0.zip", pluginArchiveData(t, "test-app", "3.0.0", ">=99.0.0"))
+	write("test-panel-1.0.0.zip", pluginArchiveData(t, "test-panel", "1.0.0", ""))
+	write("test-panel-1.0.0.zip.sha256", []byte("1a2b3c"))
+	write("invalid.zip", []byte("not an archive"))
+
+	m := NewManager(ManagerCfg{
+		BaseURL: "file://" + dir,
+		Logger:  log.NewTestPrettyLogger(),
+	})
+	linux := NewCompatOpts("10.1.0-pre", "linux", "amd64")
+
+	t.Run("should resolve the latest compatible version for the system", func(t *testing.T) {
+		v, err := m.PluginVersion(context.Background(), "test-app", "", linux)
+		require.NoError(t, err)
+		require.Equal(t, "2.0.0", v.Version)
+		require.Equal(t, fmt.Sprintf("%x", sha256.Sum256(linuxArchive)), v.Checksum)
+
+		v, err = m.PluginVersion(context.Background(), "test-app", "", NewCompatOpts("10.1.0", "darwin", "amd64"))
+		require.NoError(t, err)
+		require.Equal(t, "1.0.0", v.Version)
+
+		v, err = m.PluginVersion(context.Background(), "test-app", "", NewCompatOpts("9.5.0", "linux", "amd64"))
+		require.NoError(t, err)
+		require.Equal(t, "1.0.0", v.Version)
+	})
+
+	t.Run("should download archives and verify their checksum", func(t *testing.T) {
+		info, err := m.GetPluginArchiveInfo(context.Background(), "test-app", "", linux)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(dir, "test-app", "test-app-2.0.0.linux_amd64.zip"), info.URL)
+
+		archive, err := m.GetPluginArchive(context.Background(), "test-app", "2.0.0", linux)
+		require.NoError(t, err)
+		require.NotEmpty(t, archive.File.File)
+		require.NoError(t, archive.File.Close())
+
+		_, err = m.GetPluginArchive(context.Background(), "test-panel", "", linux)
+		require.ErrorIs(t, err, ErrChecksumMismatchBase)
+	})
+
+	t.Run("should return not found for unknown plugins", func(t *testing.T) {
+		_, err := m.PluginVersion(context.Background(), "unknown", "", linux)
+		require.True(t, isNotFound(err))
+	})
+
+	t.Run("should list the latest version of the plugins", func(t *testing.T) {
+		info, err := m.GetPluginsInfo(context.Background(), GetPluginsInfoOptions{}, linux)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []PluginInfo{
+			{Slug: "test-app", Version: "2.0.0", Status: "active"},
+			{Slug: "test-panel", Version: "1.0.0", Status: "active"},
+		}, info)
+
+		info, err = m.GetPluginsInfo(context.Background(), GetPluginsInfoOptions{Plugins: []string{"test-panel", "unknown"}}, linux)
+		require.NoError(t, err)
+		require.Equal(t, []PluginInfo{{Slug: "test-panel", Version: "1.0.0", Status: "active"}}, info)
+	})
+}
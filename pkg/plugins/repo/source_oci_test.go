@@ -0,0 +1,206 @@
+package repo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/plugins/log"
+)
+
+// fakeRegistry is an OCI registry that requires a token of its token service.
+type fakeRegistry struct {
+	*httptest.Server
+	manifests map[string]ociManifest
+	blobs     map[string][]byte
+	tags      [][]string
+	// realm is the URL of the token service, the registry itself by default.
+	realm string
+}
+
+func newFakeRegistry(t *testing.T) *fakeRegistry {
+	t.Helper()
+	r := &fakeRegistry{manifests: map[string]ociManifest{}, blobs: map[string][]byte{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, req *http.Request) {
+		user, password, ok := req.BasicAuth()
+		if !ok || user != "user" || password != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"token": %q}`, "token:"+req.URL.Query().Get("scope"))
+	})
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, req *http.Request) {
+		apiPath := strings.TrimPrefix(req.URL.Path, "/v2/")
+		scope := "registry:catalog:*"
+		if apiPath != "_catalog" {
+			repository, _, _ := strings.Cut(strings.Replace(strings.Replace(strings.Replace(apiPath, "/tags/", "|", 1), "/manifests/", "|", 1), "/blobs/", "|", 1), "|")
+			scope = "repository:" + repository + ":pull"
+		}
+		if req.Header.Get("Authorization") != "Bearer token:"+scope {
+			realm := r.realm
+			if realm == "" {
+				realm = r.URL + "/token"
+			}
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s",service="registry",scope="%s"`, realm, scope))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case apiPath == "_catalog":
+			_, _ = w.Write([]byte(`{"repositories": ["plugins/test-app", "other/test-panel"]}`))
+		case apiPath == "plugins/test-app/tags/list":
+			page := 0
+			if req.URL.Query().Get("last") != "" {
+				page = 1
+			} else {
+				w.Header().Set("Link", fmt.Sprintf(`<%s/v2/plugins/test-app/tags/list?last=latest&n=2>; rel="next"`, r.URL))
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"tags": r.tags[page]})
+		case strings.Contains(apiPath, "/manifests/"):
+			m, ok := r.manifests[apiPath[strings.LastIndex(apiPath, "/")+1:]]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			require.Contains(t, req.Header.Get("Accept"), "application/vnd.oci.image.manifest.v1+json")
+			_ = json.NewEncoder(w).Encode(m)
+		case strings.Contains(apiPath, "/blobs/"):
+			b, ok := r.blobs[apiPath[strings.LastIndex(apiPath, "/")+1:]]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(b)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors": [{"code": "NAME_UNKNOWN"}]}`))
+		}
+	})
+	r.Server = httptest.NewServer(mux)
+	t.Cleanup(r.Close)
+	return r
+}
+
+// push adds a version of the plugin with the archives, keyed by their file name.
+func (r *fakeRegistry) push(version, grafanaDependency string, archives map[string][]byte) {
+	m := ociManifest{MediaType: "application/vnd.oci.image.manifest.v1+json", Annotations: map[string]string{}}
+	if grafanaDependency != "" {
+		m.Annotations[ociGrafanaDependencyAnnotation] = grafanaDependency
+	}
+	for name, data := range archives {
+		digest := fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+		r.blobs[digest] = data
+		m.Layers = append(m.Layers, ociDescriptor{
+			MediaType:   "application/vnd.oci.image.layer.v1.tar",
+			Digest:      digest,
+			Size:        int64(len(data)),
+			Annotations: map[string]string{ociTitleAnnotation: name},
+		})
+	}
+	r.manifests[version] = m
+}
+
+func TestOCISource(t *testing.T) {
+	registry := newFakeRegistry(t)
+	registry.tags = [][]string{{"1.0.0", "latest"}, {"2.0.0"}}
+	v1 := pluginArchiveData(t, "test-app", "1.0.0", "")
+	v2 := pluginArchiveData(t, "test-app", "2.0.0", ">=10.0.0")
+	registry.push("1.0.0", "", map[string][]byte{"test-app-1.0.0.zip": v1})
+	registry.push("2.0.0", ">=10.0.0", map[string][]byte{"test-app-2.0.0.linux_amd64.zip": v2})
+	registry.push("latest", "", map[string][]byte{"test-app-2.0.0.linux_amd64.zip": v2})
+
+	m := NewManager(ManagerCfg{
+		BaseURL: strings.Replace(registry.URL, "http://", "oci+http://user:pass@", 1) + "/plugins",
+		Logger:  log.NewTestPrettyLogger(),
+	})
+	linux := NewCompatOpts("10.1.0", "linux", "amd64")
+
+	t.Run("should resolve the latest compatible version for the system", func(t *testing.T) {
+		v, err := m.PluginVersion(context.Background(), "test-app", "", linux)
+		require.NoError(t, err)
+		require.Equal(t, "2.0.0", v.Version)
+		require.Equal(t, fmt.Sprintf("%x", sha256.Sum256(v2)), v.Checksum)
+
+		v, err = m.PluginVersion(context.Background(), "test-app", "", NewCompatOpts("9.5.0", "linux", "amd64"))
+		require.NoError(t, err)
+		require.Equal(t, "1.0.0", v.Version)
+
+		_, err = m.PluginVersion(context.Background(), "unknown", "", linux)
+		require.True(t, isNotFound(err))
+	})
+
+	t.Run("should download archives and verify their checksum", func(t *testing.T) {
+		info, err := m.GetPluginArchiveInfo(context.Background(), "test-app", "", linux)
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("oci+http://%s/plugins/test-app@sha256:%x", registry.Listener.Addr(), sha256.Sum256(v2)), info.URL)
+
+		archive, err := m.GetPluginArchive(context.Background(), "test-app", "", linux)
+		require.NoError(t, err)
+		require.NotEmpty(t, archive.File.File)
+		require.NoError(t, archive.File.Close())
+
+		archive, err = m.GetPluginArchiveByURL(context.Background(), info.URL, linux)
+		require.NoError(t, err)
+		require.NoError(t, archive.File.Close())
+
+		registry.blobs[fmt.Sprintf("sha256:%x", sha256.Sum256(v2))] = v1
+		_, err = m.GetPluginArchive(context.Background(), "test-app", "", linux)
+		require.ErrorIs(t, err, ErrChecksumMismatchBase)
+	})
+
+	t.Run("should list the latest version of the plugins of the namespace", func(t *testing.T) {
+		info, err := m.GetPluginsInfo(context.Background(), GetPluginsInfoOptions{}, linux)
+		require.NoError(t, err)
+		require.Equal(t, []PluginInfo{{Slug: "test-app", Version: "2.0.0", Status: "active"}}, info)
+	})
+
+	t.Run("should fail without valid credentials", func(t *testing.T) {
+		m := NewManager(ManagerCfg{
+			BaseURL: strings.Replace(registry.URL, "http://", "oci+http://", 1) + "/plugins",
+			Logger:  log.NewTestPrettyLogger(),
+		})
+		_, err := m.PluginVersion(context.Background(), "test-app", "", linux)
+		require.Error(t, err)
+	})
+
+	t.Run("should send the credentials only to the token services of the configured hosts", func(t *testing.T) {
+		var withCredentials bool
+		tokens := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, _, withCredentials = req.BasicAuth()
+			_, _ = fmt.Fprintf(w, `{"token": %q}`, "token:"+req.URL.Query().Get("scope"))
+		}))
+		t.Cleanup(tokens.Close)
+		registry.realm = tokens.URL + "/token"
+		t.Cleanup(func() { registry.realm = "" })
+
+		baseURL := strings.Replace(registry.URL, "http://", "oci+http://user:pass@", 1) + "/plugins"
+		for tokenHosts, expected := range map[string]bool{"": false, "?token_hosts=" + tokens.Listener.Addr().String(): true} {
+			m := NewManager(ManagerCfg{BaseURL: baseURL + tokenHosts, Logger: log.NewTestPrettyLogger(), SkipTLSVerify: true})
+			_, err := m.PluginVersion(context.Background(), "test-app", "", linux)
+			require.NoError(t, err)
+			require.Equal(t, expected, withCredentials)
+		}
+	})
+
+	t.Run("should refuse token services over HTTP on another host", func(t *testing.T) {
+		registry.realm = "http://auth.example.com/token"
+		t.Cleanup(func() { registry.realm = "" })
+
+		m := NewManager(ManagerCfg{
+			BaseURL: strings.Replace(registry.URL, "http://", "oci+http://user:pass@", 1) + "/plugins",
+			Logger:  log.NewTestPrettyLogger(),
+		})
+		_, err := m.PluginVersion(context.Background(), "test-app", "", linux)
+		require.ErrorContains(t, err, "insecure token service")
+	})
+}
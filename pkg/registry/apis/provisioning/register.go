@@ -81,6 +81,7 @@ type APIBuilder struct {
 	clients             resources.ClientFactory
 	ghFactory           *github.Factory
 	clonedir            string // where repo clones are managed
+	gitOptions          gogit.Options
 	jobs                interface {
 		jobs.Queue
 		jobs.Store
@@ -108,6 +109,7 @@ func NewAPIBuilder(
 	features featuremgmt.FeatureToggles,
 	unified resource.ResourceClient,
 	clonedir string, // where repo clones are managed
+	gitOptions gogit.Options,
 	configProvider apiserver.RestConfigProvider,
 	ghFactory *github.Factory,
 	legacyMigrator legacy.LegacyMigrator,
@@ -128,6 +130,7 @@ func NewAPIBuilder(
 		parsers:             parsers,
 		repositoryResources: resources.NewRepositoryResourcesFactory(parsers, clients, resourceLister),
 		clonedir:            clonedir,
+		gitOptions:          gitOptions,
 		resourceLister:      resourceLister,
 		legacyMigrator:      legacyMigrator,
 		storageStatus:       storageStatus,
@@ -174,6 +177,7 @@ func RegisterAPIService(
 	builder := NewAPIBuilder(folderResolver, features,
 		client,
 		filepath.Join(cfg.DataPath, "clone"), // where repositories are cloned (temporarialy for now)
+		gogit.Options{AllowLocalRemotes: cfg.ProvisioningAllowLocalGitRemotes},
 		configProvider, ghFactory,
 		legacyMigrator, storageStatus,
 		secrets.NewSingleTenant(secretsSvc), access,
@@ -415,6 +419,10 @@ func (b *APIBuilder) Mutate(ctx context.Context, a admission.Attributes, o admis
 		return fmt.Errorf("failed to encrypt secrets: %w", err)
 	}
 
+	if err := b.encryptGitSecrets(ctx, r); err != nil {
+		return fmt.Errorf("failed to encrypt secrets: %w", err)
+	}
+
 	// Mutate the repository with any extra mutators
 	for _, extra := range b.extras {
 		if err := extra.Mutate(ctx, r); err != nil {
@@ -440,6 +448,31 @@ func (b *APIBuilder) encryptGithubToken(ctx context.Context, repo *provisioning.
 	return nil
 }
 
+func (b *APIBuilder) encryptGitSecrets(ctx context.Context, repo *provisioning.Repository) error {
+	if repo.Spec.Git == nil {
+		return nil
+	}
+
+	var err error
+	if repo.Spec.Git.Token != "" {
+		repo.Spec.Git.EncryptedToken, err = b.secrets.Encrypt(ctx, []byte(repo.Spec.Git.Token))
+		if err != nil {
+			return err
+		}
+		repo.Spec.Git.Token = ""
+	}
+
+	if repo.Spec.Git.SSHKey != "" {
+		repo.Spec.Git.EncryptedSSHKey, err = b.secrets.Encrypt(ctx, []byte(repo.Spec.Git.SSHKey))
+		if err != nil {
+			return err
+		}
+		repo.Spec.Git.SSHKey = ""
+	}
+
+	return nil
+}
+
 // TODO: move logic to a more appropriate place. Probably controller/validation.go
 func (b *APIBuilder) Validate(ctx context.Context, a admission.Attributes, o admission.ObjectInterfaces) (err error) {
 	obj := a.GetObject()
@@ -1123,6 +1156,8 @@ func (b *APIBuilder) AsRepository(ctx context.Context, r *provisioning.Repositor
 			return gogit.Clone(ctx, b.clonedir, r, opts, b.secrets)
 		}
 		return repository.NewGitHub(ctx, r, b.ghFactory, b.secrets, cloneFn)
+	case provisioning.GitRepositoryType:
+		return gogit.NewGitRepository(r, b.clonedir, b.secrets, b.gitOptions), nil
 	default:
 		return nil, fmt.Errorf("unknown repository type (%s)", r.Spec.Type)
 	}
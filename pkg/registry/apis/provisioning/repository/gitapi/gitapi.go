@@ -0,0 +1,137 @@
+// The gitapi package provides what the clients of the APIs of git servers, such as GitLab and Gitea, have in common:
+// the webhook configuration, the errors, the JSON requests and the parsing of webhook events.
+package gitapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+var (
+	ErrResourceNotFound = errors.New("the resource does not exist")
+	ErrUnsupportedEvent = errors.New("unsupported webhook event")
+)
+
+type WebhookConfig struct {
+	// The ID of the webhook.
+	// Can be 0 on creation.
+	ID int64
+	// The events which this webhook shall contact the URL for.
+	Events []string
+	// The URL the server should contact on events.
+	URL string
+	// The secret the server authenticates the events with.
+	// If fetched from the server, this is empty as it is never returned.
+	Secret string
+}
+
+// Client sends JSON requests to the API of a git server.
+type Client struct {
+	// Name of the server in errors, e.g. `gitlab`.
+	Name string
+	// Base URL of the API, e.g. `https://gitlab.com/api/v4`.
+	APIURL string
+	// Header and value that authenticate the requests.
+	AuthHeader string
+	AuthValue  string
+	HTTPClient *http.Client
+}
+
+// NewClient returns a client of the API at the given URL, using the default HTTP client if client is nil.
+func NewClient(name, apiURL, authHeader, authValue string, client *http.Client) *Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Client{
+		Name:       name,
+		APIURL:     strings.TrimSuffix(apiURL, "/"),
+		AuthHeader: authHeader,
+		AuthValue:  authValue,
+		HTTPClient: client,
+	}
+}
+
+// Do sends in as the JSON body of the request, if not nil, and decodes the JSON response into out, if not nil.
+// ErrResourceNotFound is returned if the API responds with 404.
+func (c *Client) Do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	// Escaped path segments are kept as is when the URL is parsed
+	req, err := http.NewRequestWithContext(ctx, method, c.APIURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(c.AuthHeader, c.AuthValue)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	rsp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rsp.Body.Close() }()
+
+	switch {
+	case rsp.StatusCode == http.StatusNotFound:
+		return ErrResourceNotFound
+	case rsp.StatusCode == http.StatusServiceUnavailable:
+		return apierrors.NewServiceUnavailable(c.Name + " is unavailable")
+	case rsp.StatusCode >= 300:
+		msg, _ := io.ReadAll(io.LimitReader(rsp.Body, 1024))
+		return fmt.Errorf("%s responded with %s: %s", c.Name, rsp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(rsp.Body).Decode(out)
+}
+
+// ResourcePath joins the path of a resource with its elements, e.g. the ID of a webhook.
+func ResourcePath(path string, elems ...any) string {
+	for _, e := range elems {
+		path += fmt.Sprintf("/%v", e)
+	}
+	return path
+}
+
+// ParseWebHook parses the payload into the event returned by the constructor of the event type.
+// ErrUnsupportedEvent is returned for event types without a constructor.
+func ParseWebHook(eventType string, payload []byte, events map[string]func() any) (any, error) {
+	newEvent, ok := events[eventType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEvent, eventType)
+	}
+
+	event := newEvent()
+	if err := json.Unmarshal(payload, event); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", eventType, err)
+	}
+	return event, nil
+}
+
+// FirstHeader returns the value of the first of the headers that is set in the request.
+func FirstHeader(req *http.Request, names ...string) string {
+	for _, name := range names {
+		if v := req.Header.Get(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
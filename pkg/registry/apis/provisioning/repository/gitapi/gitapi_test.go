@@ -0,0 +1,59 @@
+package gitapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func TestClient_Do(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.EscapedPath() {
+		case "/api/projects/group%2Fproject/hooks/1":
+			_, _ = w.Write([]byte(`{"id":1}`))
+		case "/api/unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	client := NewClient("gitlab", srv.URL+"/api/", "PRIVATE-TOKEN", "token", nil)
+	ctx := context.Background()
+
+	var out struct{ ID int64 }
+	require.NoError(t, client.Do(ctx, http.MethodGet, ResourcePath("/projects/group%2Fproject", "hooks", 1), nil, &out))
+	require.Equal(t, int64(1), out.ID)
+
+	require.ErrorIs(t, client.Do(ctx, http.MethodGet, "/missing", nil, nil), ErrResourceNotFound)
+	require.True(t, apierrors.IsServiceUnavailable(client.Do(ctx, http.MethodGet, "/unavailable", nil, nil)))
+
+	client.AuthValue = "other"
+	require.EqualError(t, client.Do(ctx, http.MethodGet, "/missing", nil, nil), "gitlab responded with 401 Unauthorized: ")
+}
+
+func TestParseWebHook(t *testing.T) {
+	type push struct {
+		Ref string `json:"ref"`
+	}
+	events := map[string]func() any{"push": func() any { return &push{} }}
+
+	event, err := ParseWebHook("push", []byte(`{"ref":"refs/heads/main"}`), events)
+	require.NoError(t, err)
+	require.Equal(t, &push{Ref: "refs/heads/main"}, event)
+
+	_, err = ParseWebHook("issues", []byte(`{}`), events)
+	require.ErrorIs(t, err, ErrUnsupportedEvent)
+
+	_, err = ParseWebHook("push", []byte(`{`), events)
+	require.Error(t, err)
+}
@@ -0,0 +1,26 @@
+// The gitea package provides a client for the parts of the Gitea API needed by git repositories, and parses the webhooks sent by Gitea.
+// Forgejo is a fork of Gitea with the same API and webhooks, so it is supported as well.
+package gitea
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/registry/apis/provisioning/repository/gitapi"
+)
+
+// Webhook events that can be subscribed to.
+const (
+	PushEvents        = "push"
+	PullRequestEvents = "pull_request"
+)
+
+type Client interface {
+	CreateWebhook(ctx context.Context, owner, repository string, cfg gitapi.WebhookConfig) (gitapi.WebhookConfig, error)
+	// GetWebhook returns the webhook of the repository. gitapi.ErrResourceNotFound is returned if it does not exist.
+	GetWebhook(ctx context.Context, owner, repository string, webhookID int64) (gitapi.WebhookConfig, error)
+	EditWebhook(ctx context.Context, owner, repository string, cfg gitapi.WebhookConfig) error
+	DeleteWebhook(ctx context.Context, owner, repository string, webhookID int64) error
+
+	// CreatePullRequestComment adds a comment to the pull request. Pull requests share their numbers with issues.
+	CreatePullRequestComment(ctx context.Context, owner, repository string, number int, body string) error
+}
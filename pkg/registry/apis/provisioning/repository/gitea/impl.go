@@ -0,0 +1,97 @@
+package gitea
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/registry/apis/provisioning/repository/gitapi"
+)
+
+type giteaClient struct {
+	api *gitapi.Client
+}
+
+// NewClient returns a client for the API of the Gitea instance at the given base URL (e.g. `https://gitea.com`).
+// The token must have read and write access to the repository and its issues.
+func NewClient(baseURL, token string, client *http.Client) Client {
+	return &giteaClient{
+		api: gitapi.NewClient("gitea", strings.TrimSuffix(baseURL, "/")+"/api/v1", "Authorization", "token "+token, client),
+	}
+}
+
+type hook struct {
+	ID     int64      `json:"id,omitempty"`
+	Type   string     `json:"type,omitempty"`
+	Config hookConfig `json:"config"`
+	Events []string   `json:"events"`
+	Active bool       `json:"active"`
+}
+
+type hookConfig struct {
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Secret      string `json:"secret,omitempty"`
+}
+
+func toHook(cfg gitapi.WebhookConfig) hook {
+	return hook{
+		Config: hookConfig{
+			URL:         cfg.URL,
+			ContentType: "json",
+			Secret:      cfg.Secret,
+		},
+		Events: cfg.Events,
+		Active: true,
+	}
+}
+
+func (r *giteaClient) CreateWebhook(ctx context.Context, owner, repository string, cfg gitapi.WebhookConfig) (gitapi.WebhookConfig, error) {
+	h := toHook(cfg)
+	h.Type = "gitea"
+
+	var created hook
+	if err := r.api.Do(ctx, http.MethodPost, repoPath(owner, repository, "hooks"), h, &created); err != nil {
+		return gitapi.WebhookConfig{}, err
+	}
+
+	return gitapi.WebhookConfig{
+		ID:     created.ID,
+		Events: created.Events,
+		URL:    created.Config.URL,
+		// Secret is not returned by Gitea.
+		Secret: cfg.Secret,
+	}, nil
+}
+
+func (r *giteaClient) GetWebhook(ctx context.Context, owner, repository string, webhookID int64) (gitapi.WebhookConfig, error) {
+	var existing hook
+	if err := r.api.Do(ctx, http.MethodGet, repoPath(owner, repository, "hooks", webhookID), nil, &existing); err != nil {
+		return gitapi.WebhookConfig{}, err
+	}
+
+	return gitapi.WebhookConfig{
+		ID:     existing.ID,
+		Events: existing.Events,
+		URL:    existing.Config.URL,
+		// Intentionally not setting Secret.
+	}, nil
+}
+
+func (r *giteaClient) EditWebhook(ctx context.Context, owner, repository string, cfg gitapi.WebhookConfig) error {
+	return r.api.Do(ctx, http.MethodPatch, repoPath(owner, repository, "hooks", cfg.ID), toHook(cfg), nil)
+}
+
+func (r *giteaClient) DeleteWebhook(ctx context.Context, owner, repository string, webhookID int64) error {
+	return r.api.Do(ctx, http.MethodDelete, repoPath(owner, repository, "hooks", webhookID), nil, nil)
+}
+
+func (r *giteaClient) CreatePullRequestComment(ctx context.Context, owner, repository string, number int, body string) error {
+	comment := map[string]string{"body": body}
+	return r.api.Do(ctx, http.MethodPost, repoPath(owner, repository, "issues", number, "comments"), comment, nil)
+}
+
+func repoPath(owner, repository string, elems ...any) string {
+	return gitapi.ResourcePath("/repos/"+url.PathEscape(owner)+"/"+url.PathEscape(repository), elems...)
+}
@@ -0,0 +1,99 @@
+package gitea
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/registry/apis/provisioning/repository/gitapi"
+)
+
+// Forgejo sends its own headers in addition to the ones of Gitea.
+var (
+	eventHeaders     = []string{"X-Gitea-Event", "X-Forgejo-Event"}
+	signatureHeaders = []string{"X-Gitea-Signature", "X-Forgejo-Signature"}
+)
+
+// Values of the event header for the events we handle.
+const (
+	PushHook        = "push"
+	PullRequestHook = "pull_request"
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// PushEvent is sent when commits are pushed to a branch.
+type PushEvent struct {
+	Ref        string     `json:"ref"`
+	Before     string     `json:"before"`
+	After      string     `json:"after"`
+	Repository Repository `json:"repository"`
+}
+
+// PullRequestEvent is sent when a pull request is opened, updated or closed.
+type PullRequestEvent struct {
+	Action      string      `json:"action"`
+	Number      int         `json:"number"`
+	PullRequest PullRequest `json:"pull_request"`
+	Repository  Repository  `json:"repository"`
+}
+
+type Repository struct {
+	ID       int64  `json:"id"`
+	FullName string `json:"full_name"`
+	HTMLURL  string `json:"html_url"`
+}
+
+type PullRequest struct {
+	Number  int            `json:"number"`
+	HTMLURL string         `json:"html_url"`
+	Head    PullRequestRef `json:"head"`
+	Base    PullRequestRef `json:"base"`
+}
+
+type PullRequestRef struct {
+	Ref    string `json:"ref"`
+	Sha    string `json:"sha"`
+	RepoID int64  `json:"repo_id"`
+}
+
+// WebHookType returns the event type of the webhook request.
+func WebHookType(req *http.Request) string {
+	return gitapi.FirstHeader(req, eventHeaders...)
+}
+
+// ValidatePayload checks the HMAC-SHA256 signature of the request body, and returns the body.
+func ValidatePayload(req *http.Request, secret []byte) ([]byte, error) {
+	if len(secret) == 0 {
+		return nil, ErrInvalidSignature
+	}
+
+	signature, err := hex.DecodeString(gitapi.FirstHeader(req, signatureHeaders...))
+	if err != nil || len(signature) == 0 {
+		return nil, ErrInvalidSignature
+	}
+
+	payload, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(payload)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrInvalidSignature
+	}
+	return payload, nil
+}
+
+// ParseWebHook parses the payload of the event type into *PushEvent or *PullRequestEvent.
+// gitapi.ErrUnsupportedEvent is returned for other event types.
+func ParseWebHook(eventType string, payload []byte) (any, error) {
+	return gitapi.ParseWebHook(eventType, payload, map[string]func() any{
+		PushHook:        func() any { return &PushEvent{} },
+		PullRequestHook: func() any { return &PullRequestEvent{} },
+	})
+}
@@ -0,0 +1,27 @@
+// The gitlab package provides a client for the parts of the GitLab API needed by git repositories, and parses the webhooks sent by GitLab.
+// Only the REST API (v4) is used, so the client works with self-managed instances as well as with gitlab.com.
+package gitlab
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/registry/apis/provisioning/repository/gitapi"
+)
+
+// Webhook events that can be subscribed to.
+const (
+	PushEvents         = "push"
+	MergeRequestEvents = "merge_request"
+)
+
+type Client interface {
+	// CreateWebhook registers a webhook in the project. The project is its full path, e.g. `group/subgroup/project`.
+	CreateWebhook(ctx context.Context, project string, cfg gitapi.WebhookConfig) (gitapi.WebhookConfig, error)
+	// GetWebhook returns the webhook of the project. gitapi.ErrResourceNotFound is returned if it does not exist.
+	GetWebhook(ctx context.Context, project string, webhookID int64) (gitapi.WebhookConfig, error)
+	EditWebhook(ctx context.Context, project string, cfg gitapi.WebhookConfig) error
+	DeleteWebhook(ctx context.Context, project string, webhookID int64) error
+
+	// CreateMergeRequestNote adds a comment to the merge request with the given internal ID.
+	CreateMergeRequestNote(ctx context.Context, project string, iid int, body string) error
+}
@@ -0,0 +1,101 @@
+package gitlab
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/registry/apis/provisioning/repository/gitapi"
+)
+
+type gitlabClient struct {
+	api *gitapi.Client
+}
+
+// NewClient returns a client for the API of the GitLab instance at the given base URL (e.g. `https://gitlab.com`).
+// The token must have the `api` scope.
+func NewClient(baseURL, token string, client *http.Client) Client {
+	return &gitlabClient{
+		api: gitapi.NewClient("gitlab", strings.TrimSuffix(baseURL, "/")+"/api/v4", "PRIVATE-TOKEN", token, client),
+	}
+}
+
+type hook struct {
+	ID                  int64  `json:"id,omitempty"`
+	URL                 string `json:"url"`
+	Token               string `json:"token,omitempty"`
+	PushEvents          bool   `json:"push_events"`
+	MergeRequestsEvents bool   `json:"merge_requests_events"`
+	// Always verified, but the field defaults to false when editing a hook.
+	EnableSSLVerification bool `json:"enable_ssl_verification"`
+}
+
+func toHook(cfg gitapi.WebhookConfig) hook {
+	return hook{
+		URL:                   cfg.URL,
+		Token:                 cfg.Secret,
+		PushEvents:            slices.Contains(cfg.Events, PushEvents),
+		MergeRequestsEvents:   slices.Contains(cfg.Events, MergeRequestEvents),
+		EnableSSLVerification: true,
+	}
+}
+
+func (h hook) events() []string {
+	events := []string{}
+	if h.PushEvents {
+		events = append(events, PushEvents)
+	}
+	if h.MergeRequestsEvents {
+		events = append(events, MergeRequestEvents)
+	}
+	return events
+}
+
+func (r *gitlabClient) CreateWebhook(ctx context.Context, project string, cfg gitapi.WebhookConfig) (gitapi.WebhookConfig, error) {
+	var created hook
+	if err := r.api.Do(ctx, http.MethodPost, projectPath(project, "hooks"), toHook(cfg), &created); err != nil {
+		return gitapi.WebhookConfig{}, err
+	}
+
+	return gitapi.WebhookConfig{
+		ID:     created.ID,
+		Events: created.events(),
+		URL:    created.URL,
+		// Secret is not returned by GitLab.
+		Secret: cfg.Secret,
+	}, nil
+}
+
+func (r *gitlabClient) GetWebhook(ctx context.Context, project string, webhookID int64) (gitapi.WebhookConfig, error) {
+	var existing hook
+	if err := r.api.Do(ctx, http.MethodGet, projectPath(project, "hooks", webhookID), nil, &existing); err != nil {
+		return gitapi.WebhookConfig{}, err
+	}
+
+	return gitapi.WebhookConfig{
+		ID:     existing.ID,
+		Events: existing.events(),
+		URL:    existing.URL,
+		// Intentionally not setting Secret.
+	}, nil
+}
+
+func (r *gitlabClient) EditWebhook(ctx context.Context, project string, cfg gitapi.WebhookConfig) error {
+	return r.api.Do(ctx, http.MethodPut, projectPath(project, "hooks", cfg.ID), toHook(cfg), nil)
+}
+
+func (r *gitlabClient) DeleteWebhook(ctx context.Context, project string, webhookID int64) error {
+	return r.api.Do(ctx, http.MethodDelete, projectPath(project, "hooks", webhookID), nil, nil)
+}
+
+func (r *gitlabClient) CreateMergeRequestNote(ctx context.Context, project string, iid int, body string) error {
+	note := map[string]string{"body": body}
+	return r.api.Do(ctx, http.MethodPost, projectPath(project, "merge_requests", iid, "notes"), note, nil)
+}
+
+// projectPath returns the path of a project resource. Projects are identified by their URL encoded full path.
+func projectPath(project string, elems ...any) string {
+	return gitapi.ResourcePath("/projects/"+url.PathEscape(project), elems...)
+}
@@ -0,0 +1,83 @@
+package gitlab
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/registry/apis/provisioning/repository/gitapi"
+)
+
+const (
+	eventHeader = "X-Gitlab-Event"
+	tokenHeader = "X-Gitlab-Token"
+)
+
+// Values of the event header for the events we handle.
+const (
+	PushHook         = "Push Hook"
+	MergeRequestHook = "Merge Request Hook"
+)
+
+var ErrInvalidToken = errors.New("invalid webhook token")
+
+// PushEvent is sent when commits are pushed to a branch.
+type PushEvent struct {
+	Ref     string  `json:"ref"`
+	Before  string  `json:"before"`
+	After   string  `json:"after"`
+	Project Project `json:"project"`
+}
+
+// MergeRequestEvent is sent when a merge request is created, updated or merged.
+type MergeRequestEvent struct {
+	Project          Project                `json:"project"`
+	ObjectAttributes MergeRequestAttributes `json:"object_attributes"`
+}
+
+type Project struct {
+	ID                int64  `json:"id"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	WebURL            string `json:"web_url"`
+}
+
+type MergeRequestAttributes struct {
+	// The internal ID, which identifies the merge request within its project.
+	IID             int    `json:"iid"`
+	Action          string `json:"action"`
+	URL             string `json:"url"`
+	SourceBranch    string `json:"source_branch"`
+	TargetBranch    string `json:"target_branch"`
+	SourceProjectID int64  `json:"source_project_id"`
+	TargetProjectID int64  `json:"target_project_id"`
+	// Only set when commits were pushed to the merge request.
+	OldRev     string `json:"oldrev"`
+	LastCommit struct {
+		ID string `json:"id"`
+	} `json:"last_commit"`
+}
+
+// WebHookType returns the event type of the webhook request.
+func WebHookType(req *http.Request) string {
+	return req.Header.Get(eventHeader)
+}
+
+// ValidatePayload checks the secret token sent by GitLab, and returns the body of the request.
+func ValidatePayload(req *http.Request, secret []byte) ([]byte, error) {
+	token := req.Header.Get(tokenHeader)
+	if len(secret) == 0 || subtle.ConstantTimeCompare([]byte(token), secret) != 1 {
+		return nil, ErrInvalidToken
+	}
+
+	return io.ReadAll(req.Body)
+}
+
+// ParseWebHook parses the payload of the event type into *PushEvent or *MergeRequestEvent.
+// gitapi.ErrUnsupportedEvent is returned for other event types.
+func ParseWebHook(eventType string, payload []byte) (any, error) {
+	return gitapi.ParseWebHook(eventType, payload, map[string]func() any{
+		PushHook:         func() any { return &PushEvent{} },
+		MergeRequestHook: func() any { return &MergeRequestEvent{} },
+	})
+}
@@ -0,0 +1,979 @@
+package gogit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/grafana/grafana-app-sdk/logging"
+	provisioning "github.com/grafana/grafana/pkg/apis/provisioning/v0alpha1"
+	"github.com/grafana/grafana/pkg/registry/apis/provisioning/repository"
+	"github.com/grafana/grafana/pkg/registry/apis/provisioning/safepath"
+	"github.com/grafana/grafana/pkg/registry/apis/provisioning/secrets"
+)
+
+const (
+	originRemote = "origin"
+	// fetchInterval is how long fetched branches are considered up to date when reading them.
+	fetchInterval = 5 * time.Second
+	// maxHistoryItems is the maximum number of commits returned for the history of a file.
+	maxHistoryItems = 100
+)
+
+// GitRepository is a repository on any git server. It only uses the git protocol, so it works with
+// any remote that can be reached over HTTPS, SSH or the file system.
+type GitRepository interface {
+	repository.Repository
+	repository.Versioned
+	repository.Reader
+	repository.Writer
+	repository.ClonableRepository
+	repository.Hooks
+}
+
+// mirror is a bare copy of the remote on disk, shared by all the instances of a repository.
+// Reads use the fetched objects and writes create commits in it before pushing them.
+type mirror struct {
+	mu        sync.Mutex
+	lastFetch time.Time
+}
+
+var mirrors sync.Map // map[string]*mirror, by mirror directory
+
+type gitRepository struct {
+	config  *provisioning.Repository
+	secrets secrets.Service
+	opts    Options
+	root    string // where clones are managed
+	dir     string // the mirror of the remote
+}
+
+func NewGitRepository(config *provisioning.Repository, root string, secrets secrets.Service, opts Options) GitRepository {
+	return &gitRepository{
+		config:  config,
+		secrets: secrets,
+		opts:    opts,
+		root:    root,
+		dir:     filepath.Join(root, "mirrors", config.Namespace, config.Name),
+	}
+}
+
+// Config implements repository.Repository.
+func (r *gitRepository) Config() *provisioning.Repository {
+	return r.config
+}
+
+// Validate implements repository.Repository.
+func (r *gitRepository) Validate() (list field.ErrorList) {
+	cfg := r.config.Spec.Git
+	if cfg == nil {
+		list = append(list, field.Required(field.NewPath("spec", "git"), "a git config is required"))
+		return list
+	}
+
+	if cfg.URL == "" {
+		list = append(list, field.Required(field.NewPath("spec", "git", "url"), "a git url is required"))
+	} else if endpoint, err := transport.NewEndpoint(cfg.URL); err != nil {
+		list = append(list, field.Invalid(field.NewPath("spec", "git", "url"), cfg.URL, err.Error()))
+	} else {
+		switch endpoint.Protocol {
+		case "https":
+		case "file":
+			if !r.opts.AllowLocalRemotes {
+				list = append(list, field.Invalid(field.NewPath("spec", "git", "url"), cfg.URL, "URL must use https or ssh"))
+			}
+		case "ssh":
+			if cfg.SSHKey == "" && len(cfg.EncryptedSSHKey) == 0 {
+				list = append(list, field.Required(field.NewPath("spec", "git", "sshKey"), "an ssh key is required for ssh remotes"))
+			}
+		default:
+			list = append(list, field.Invalid(field.NewPath("spec", "git", "url"), cfg.URL, "URL must use https or ssh"))
+		}
+	}
+
+	if cfg.Branch == "" {
+		list = append(list, field.Required(field.NewPath("spec", "git", "branch"), "a git branch is required"))
+	} else if err := plumbing.NewBranchReferenceName(cfg.Branch).Validate(); err != nil {
+		list = append(list, field.Invalid(field.NewPath("spec", "git", "branch"), cfg.Branch, "invalid branch name"))
+	}
+
+	if err := safepath.IsSafe(cfg.Path); err != nil {
+		list = append(list, field.Invalid(field.NewPath("spec", "git", "path"), cfg.Path, err.Error()))
+	}
+
+	if safepath.IsAbs(cfg.Path) {
+		list = append(list, field.Invalid(field.NewPath("spec", "git", "path"), cfg.Path, "path must be relative"))
+	}
+
+	return list
+}
+
+// Test implements repository.Repository.
+func (r *gitRepository) Test(ctx context.Context) (*provisioning.TestResults, error) {
+	remote, err := newRemote(ctx, r.config, r.secrets, r.opts)
+	if err != nil {
+		return testFailure(field.Invalid(field.NewPath("spec", "git", "url"), r.config.Spec.Git.URL, err.Error())), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, maxOperationTimeout)
+	defer cancel()
+
+	refs, err := git.NewRemote(memory.NewStorage(), &gitconfig.RemoteConfig{
+		Name: originRemote,
+		URLs: []string{remote.url},
+	}).ListContext(ctx, &git.ListOptions{Auth: remote.auth})
+	if err != nil {
+		return testFailure(field.Invalid(field.NewPath("spec", "git", "url"), remote.url, err.Error())), nil
+	}
+
+	branch := plumbing.NewBranchReferenceName(remote.branch)
+	for _, ref := range refs {
+		if ref.Name() == branch {
+			return &provisioning.TestResults{
+				Code:    http.StatusOK,
+				Success: true,
+			}, nil
+		}
+	}
+
+	return testFailure(field.NotFound(field.NewPath("spec", "git", "branch"), remote.branch)), nil
+}
+
+func testFailure(err *field.Error) *provisioning.TestResults {
+	return &provisioning.TestResults{
+		Code:    http.StatusBadRequest,
+		Success: false,
+		Errors: []provisioning.ErrorDetails{{
+			Type:   metav1.CauseType(err.Type),
+			Field:  err.Field,
+			Detail: err.Detail,
+		}},
+	}
+}
+
+// Read implements repository.Reader.
+func (r *gitRepository) Read(ctx context.Context, filePath, ref string) (*repository.FileInfo, error) {
+	var info *repository.FileInfo
+	err := r.withMirror(ctx, ref, func(m *openMirror) error {
+		commit, err := m.commit(ctx, ref)
+		if err != nil {
+			return err
+		}
+		tree, err := commit.Tree()
+		if err != nil {
+			return fmt.Errorf("read tree: %w", err)
+		}
+
+		finalPath := safepath.Join(r.path(), filePath)
+		entry, err := findEntry(tree, finalPath)
+		if err != nil {
+			return err
+		}
+		info = &repository.FileInfo{
+			Path: filePath,
+			Ref:  ref,
+			Modified: &metav1.Time{
+				Time: commit.Committer.When,
+			},
+		}
+		if entry == nil || entry.Mode == filemode.Dir {
+			return nil
+		}
+
+		file, err := tree.TreeEntryFile(entry)
+		if err != nil {
+			return fmt.Errorf("read file '%s': %w", finalPath, err)
+		}
+		content, err := file.Contents()
+		if err != nil {
+			return fmt.Errorf("read file '%s': %w", finalPath, err)
+		}
+		// file.Name is only the base name, so the entry found above is used for the full path
+		info.Data = []byte(content)
+		info.Hash = entry.Hash.String()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// ReadTree implements repository.Reader.
+func (r *gitRepository) ReadTree(ctx context.Context, ref string) ([]repository.FileTreeEntry, error) {
+	var entries []repository.FileTreeEntry
+	err := r.withMirror(ctx, ref, func(m *openMirror) error {
+		commit, err := m.commit(ctx, ref)
+		if err != nil {
+			return err
+		}
+		tree, err := commit.Tree()
+		if err != nil {
+			return fmt.Errorf("read tree: %w", err)
+		}
+
+		if treePath := safepath.Clean(r.path()); treePath != "" {
+			tree, err = tree.Tree(treePath)
+			if errors.Is(err, object.ErrDirectoryNotFound) {
+				// the directory is created with the first file written to it
+				entries = []repository.FileTreeEntry{}
+				return nil
+			} else if err != nil {
+				return fmt.Errorf("read tree '%s': %w", treePath, err)
+			}
+		}
+
+		walker := object.NewTreeWalker(tree, true, nil)
+		defer walker.Close()
+		entries = make([]repository.FileTreeEntry, 0, 100)
+		for {
+			name, entry, err := walker.Next()
+			if errors.Is(err, io.EOF) {
+				return nil
+			} else if err != nil {
+				return fmt.Errorf("walk tree: %w", err)
+			}
+
+			if entry.Mode == filemode.Dir {
+				entries = append(entries, repository.FileTreeEntry{Path: name + "/"})
+				continue
+			}
+			if entry.Mode == filemode.Submodule {
+				continue
+			}
+			size, err := m.repo.Storer.EncodedObjectSize(entry.Hash)
+			if err != nil {
+				return fmt.Errorf("read size of '%s': %w", name, err)
+			}
+			entries = append(entries, repository.FileTreeEntry{
+				Path: name,
+				Hash: entry.Hash.String(),
+				Size: size,
+				Blob: true,
+			})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Create implements repository.Writer.
+func (r *gitRepository) Create(ctx context.Context, path, ref string, data []byte, message string) error {
+	finalPath := safepath.Join(r.path(), path)
+
+	// Create .keep file if it is a directory
+	if safepath.IsDir(finalPath) {
+		if data != nil {
+			return apierrors.NewBadRequest("data cannot be provided for a directory")
+		}
+
+		finalPath = safepath.Join(finalPath, ".keep")
+		data = []byte{}
+	}
+
+	return r.commit(ctx, ref, message, func(m *openMirror, tree *object.Tree) (plumbing.Hash, error) {
+		entry, err := findEntry(tree, finalPath)
+		if err != nil && !errors.Is(err, repository.ErrFileNotFound) {
+			return plumbing.ZeroHash, err
+		}
+		if entry != nil {
+			return plumbing.ZeroHash, &apierrors.StatusError{
+				ErrStatus: metav1.Status{
+					Message: "file already exists",
+					Code:    http.StatusConflict,
+				},
+			}
+		}
+		return m.writeFile(tree, finalPath, data)
+	})
+}
+
+// Update implements repository.Writer.
+func (r *gitRepository) Update(ctx context.Context, path, ref string, data []byte, message string) error {
+	finalPath := safepath.Join(r.path(), path)
+	return r.commit(ctx, ref, message, func(m *openMirror, tree *object.Tree) (plumbing.Hash, error) {
+		entry, err := findEntry(tree, finalPath)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		if entry.Mode == filemode.Dir {
+			return plumbing.ZeroHash, apierrors.NewBadRequest("cannot update a directory")
+		}
+		return m.writeFile(tree, finalPath, data)
+	})
+}
+
+// Write implements repository.Writer.
+func (r *gitRepository) Write(ctx context.Context, path, ref string, data []byte, message string) error {
+	if safepath.IsDir(path) {
+		return r.Create(ctx, path, ref, data, message)
+	}
+
+	finalPath := safepath.Join(r.path(), path)
+	return r.commit(ctx, ref, message, func(m *openMirror, tree *object.Tree) (plumbing.Hash, error) {
+		return m.writeFile(tree, finalPath, data)
+	})
+}
+
+// Delete implements repository.Writer.
+func (r *gitRepository) Delete(ctx context.Context, path, ref, message string) error {
+	finalPath := safepath.Join(r.path(), path)
+	return r.commit(ctx, ref, message, func(m *openMirror, tree *object.Tree) (plumbing.Hash, error) {
+		if _, err := findEntry(tree, finalPath); err != nil {
+			return plumbing.ZeroHash, err
+		}
+		// removing the entry of a directory removes everything in it
+		hash, _, err := m.updateTree(tree.Hash, splitPath(finalPath), nil)
+		return hash, err
+	})
+}
+
+// History implements repository.Versioned.
+func (r *gitRepository) History(ctx context.Context, path, ref string) ([]provisioning.HistoryItem, error) {
+	var items []provisioning.HistoryItem
+	err := r.withMirror(ctx, ref, func(m *openMirror) error {
+		commit, err := m.commit(ctx, ref)
+		if err != nil {
+			return err
+		}
+
+		finalPath := safepath.Clean(safepath.Join(r.path(), path))
+		iter, err := m.repo.Log(&git.LogOptions{
+			From: commit.Hash,
+			PathFilter: func(p string) bool {
+				return finalPath == "" || p == finalPath || strings.HasPrefix(p, finalPath+"/")
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("get commits: %w", err)
+		}
+		defer iter.Close()
+
+		for len(items) < maxHistoryItems {
+			c, err := iter.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return fmt.Errorf("get commits: %w", err)
+			}
+
+			authors := []provisioning.Author{{Name: c.Author.Name}}
+			if c.Committer.Name != c.Author.Name {
+				authors = append(authors, provisioning.Author{Name: c.Committer.Name})
+			}
+			items = append(items, provisioning.HistoryItem{
+				Ref:       c.Hash.String(),
+				Message:   c.Message,
+				Authors:   authors,
+				CreatedAt: c.Committer.When.UnixMilli(),
+			})
+		}
+
+		if len(items) == 0 {
+			return repository.ErrFileNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// LatestRef implements repository.Versioned.
+func (r *gitRepository) LatestRef(ctx context.Context) (string, error) {
+	var latest string
+	err := r.withMirror(ctx, "", func(m *openMirror) error {
+		if err := m.fetch(ctx, true); err != nil {
+			return err
+		}
+		commit, err := m.commit(ctx, "")
+		if err != nil {
+			return err
+		}
+		latest = commit.Hash.String()
+		return nil
+	})
+	return latest, err
+}
+
+// CompareFiles implements repository.Versioned.
+func (r *gitRepository) CompareFiles(ctx context.Context, base, ref string) ([]repository.VersionedFileChange, error) {
+	if ref == "" {
+		var err error
+		ref, err = r.LatestRef(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("get latest ref: %w", err)
+		}
+	}
+
+	var changes []repository.VersionedFileChange
+	err := r.withMirror(ctx, ref, func(m *openMirror) error {
+		baseCommit, err := m.commit(ctx, base)
+		if err != nil {
+			return fmt.Errorf("get base commit: %w", err)
+		}
+		refCommit, err := m.commit(ctx, ref)
+		if err != nil {
+			return fmt.Errorf("get commit: %w", err)
+		}
+		baseTree, err := baseCommit.Tree()
+		if err != nil {
+			return fmt.Errorf("read base tree: %w", err)
+		}
+		refTree, err := refCommit.Tree()
+		if err != nil {
+			return fmt.Errorf("read tree: %w", err)
+		}
+
+		diff, err := object.DiffTreeWithOptions(ctx, baseTree, refTree, &object.DiffTreeOptions{
+			DetectRenames:    true,
+			RenameScore:      object.DefaultDiffTreeOptions.RenameScore,
+			RenameLimit:      object.DefaultDiffTreeOptions.RenameLimit,
+			OnlyExactRenames: object.DefaultDiffTreeOptions.OnlyExactRenames,
+		})
+		if err != nil {
+			return fmt.Errorf("compare commits: %w", err)
+		}
+
+		changes = r.fileChanges(diff, base, ref)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// fileChanges converts the changes between two trees into changes of the files in the configured path.
+func (r *gitRepository) fileChanges(diff object.Changes, base, ref string) []repository.VersionedFileChange {
+	changes := make([]repository.VersionedFileChange, 0, len(diff))
+	for _, c := range diff {
+		from, to := c.From.Name, c.To.Name
+		switch {
+		case from == "":
+			currentPath, err := safepath.RelativeTo(to, r.path())
+			if err != nil {
+				// do nothing as it's outside of configured path
+				continue
+			}
+
+			changes = append(changes, repository.VersionedFileChange{
+				Path:   currentPath,
+				Ref:    ref,
+				Action: repository.FileActionCreated,
+			})
+		case to == "":
+			currentPath, err := safepath.RelativeTo(from, r.path())
+			if err != nil {
+				// do nothing as it's outside of configured path
+				continue
+			}
+
+			changes = append(changes, repository.VersionedFileChange{
+				Ref:          ref,
+				PreviousRef:  base,
+				Path:         currentPath,
+				PreviousPath: currentPath,
+				Action:       repository.FileActionDeleted,
+			})
+		case from == to:
+			currentPath, err := safepath.RelativeTo(to, r.path())
+			if err != nil {
+				// do nothing as it's outside of configured path
+				continue
+			}
+
+			changes = append(changes, repository.VersionedFileChange{
+				Path:   currentPath,
+				Ref:    ref,
+				Action: repository.FileActionUpdated,
+			})
+		default:
+			previousPath, previousErr := safepath.RelativeTo(from, r.path())
+			currentPath, currentErr := safepath.RelativeTo(to, r.path())
+
+			// Same as for renames on GitHub:
+			// 1. Both paths outside configured path, do nothing
+			// 2. Both paths inside configured path, rename
+			// 3. Moving out of configured path, delete previous file
+			// 4. Moving into configured path, create new file
+			switch {
+			case previousErr != nil && currentErr != nil:
+				// do nothing as it's outside of configured path
+			case previousErr == nil && currentErr == nil:
+				changes = append(changes, repository.VersionedFileChange{
+					Path:         currentPath,
+					PreviousPath: previousPath,
+					Ref:          ref,
+					PreviousRef:  base,
+					Action:       repository.FileActionRenamed,
+				})
+			case previousErr == nil && currentErr != nil:
+				changes = append(changes, repository.VersionedFileChange{
+					Path:   previousPath,
+					Ref:    base,
+					Action: repository.FileActionDeleted,
+				})
+			case previousErr != nil && currentErr == nil:
+				changes = append(changes, repository.VersionedFileChange{
+					Path:   currentPath,
+					Ref:    ref,
+					Action: repository.FileActionCreated,
+				})
+			}
+		}
+	}
+	return changes
+}
+
+// Clone implements repository.ClonableRepository.
+func (r *gitRepository) Clone(ctx context.Context, opts repository.CloneOptions) (repository.ClonedRepository, error) {
+	return cloneWithOptions(ctx, r.root, r.config, opts, r.secrets, r.opts)
+}
+
+// OnCreate implements repository.Hooks.
+func (r *gitRepository) OnCreate(ctx context.Context) ([]map[string]interface{}, error) {
+	return nil, nil
+}
+
+// OnUpdate implements repository.Hooks.
+func (r *gitRepository) OnUpdate(ctx context.Context) ([]map[string]interface{}, error) {
+	return nil, nil
+}
+
+// OnDelete implements repository.Hooks.
+func (r *gitRepository) OnDelete(ctx context.Context) error {
+	m := r.mirror()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mirrors.Delete(r.dir)
+	return os.RemoveAll(r.dir)
+}
+
+func (r *gitRepository) path() string {
+	return r.config.Spec.Git.Path
+}
+
+func (r *gitRepository) logger(ctx context.Context, ref string) (context.Context, logging.Logger) {
+	if ref == "" {
+		ref = r.config.Spec.Git.Branch
+	}
+	logger := logging.FromContext(ctx).With(slog.Group("git_repository", "namespace", r.config.Namespace, "name", r.config.Name, "ref", ref))
+	return logging.Context(ctx, logger), logger
+}
+
+func (r *gitRepository) mirror() *mirror {
+	m, _ := mirrors.LoadOrStore(r.dir, &mirror{})
+	return m.(*mirror)
+}
+
+// openMirror is a mirror that is locked for the duration of an operation.
+type openMirror struct {
+	*mirror
+	repo   *git.Repository
+	remote *remote
+}
+
+// withMirror runs the function with the mirror of the remote, initializing it if needed.
+// Operations on the mirror of a repository do not run concurrently.
+func (r *gitRepository) withMirror(ctx context.Context, ref string, fn func(m *openMirror) error) error {
+	ctx, _ = r.logger(ctx, ref)
+
+	remote, err := newRemote(ctx, r.config, r.secrets, r.opts)
+	if err != nil {
+		return err
+	}
+
+	m := r.mirror()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	repo, err := r.openRepo(remote)
+	if err != nil {
+		return fmt.Errorf("open mirror: %w", err)
+	}
+	return fn(&openMirror{mirror: m, repo: repo, remote: remote})
+}
+
+// openRepo opens the mirror, and replaces it when the URL of the remote changed.
+func (r *gitRepository) openRepo(remote *remote) (*git.Repository, error) {
+	repo, err := git.PlainOpen(r.dir)
+	if err == nil {
+		origin, err := repo.Remote(originRemote)
+		if err == nil && len(origin.Config().URLs) > 0 && origin.Config().URLs[0] == remote.url {
+			return repo, nil
+		}
+		if err := os.RemoveAll(r.dir); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, git.ErrRepositoryNotExists) {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(r.dir, 0700); err != nil {
+		return nil, err
+	}
+	repo, err = git.PlainInit(r.dir, true)
+	if err != nil {
+		return nil, err
+	}
+	_, err = repo.CreateRemote(&gitconfig.RemoteConfig{
+		Name:  originRemote,
+		URLs:  []string{remote.url},
+		Fetch: []gitconfig.RefSpec{gitconfig.RefSpec(fmt.Sprintf("+refs/heads/*:refs/remotes/%s/*", originRemote))},
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.mirror().lastFetch = time.Time{}
+	return repo, nil
+}
+
+// fetch updates the branches of the mirror. Unless forced, the branches are not fetched again shortly after.
+func (m *openMirror) fetch(ctx context.Context, force bool) error {
+	if !force && time.Since(m.lastFetch) < fetchInterval {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, maxOperationTimeout)
+	defer cancel()
+
+	err := m.repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: originRemote,
+		Auth:       m.remote.auth,
+		Tags:       git.NoTags,
+		Prune:      true,
+		Force:      true,
+	})
+	// an empty repository has nothing to fetch
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) && !errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return fmt.Errorf("fetch: %w", err)
+	}
+	m.lastFetch = time.Now()
+	return nil
+}
+
+// commit returns the commit of a ref, which is either a commit hash or a branch. The configured branch is used when
+// the ref is empty. Branches are fetched before they are resolved, commits only if they are not in the mirror yet.
+func (m *openMirror) commit(ctx context.Context, ref string) (*object.Commit, error) {
+	if ref == "" {
+		ref = m.remote.branch
+	}
+
+	if plumbing.IsHash(ref) {
+		commit, err := m.repo.CommitObject(plumbing.NewHash(ref))
+		if err == nil {
+			return commit, nil
+		} else if !errors.Is(err, plumbing.ErrObjectNotFound) {
+			return nil, err
+		}
+		if err := m.fetch(ctx, true); err != nil {
+			return nil, err
+		}
+		commit, err = m.repo.CommitObject(plumbing.NewHash(ref))
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			return nil, refNotFound(ref)
+		}
+		return commit, err
+	}
+
+	if err := m.fetch(ctx, false); err != nil {
+		return nil, err
+	}
+	hash, err := m.branch(ref)
+	if err != nil {
+		return nil, err
+	}
+	return m.repo.CommitObject(hash)
+}
+
+// branch returns the commit the branch of the remote points to.
+func (m *openMirror) branch(name string) (plumbing.Hash, error) {
+	reference, err := m.repo.Reference(plumbing.NewRemoteReferenceName(originRemote, name), true)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return plumbing.ZeroHash, refNotFound(name)
+	} else if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("resolve branch '%s': %w", name, err)
+	}
+	return reference.Hash(), nil
+}
+
+func refNotFound(ref string) error {
+	return &apierrors.StatusError{
+		ErrStatus: metav1.Status{
+			Message: fmt.Sprintf("ref not found; ref=%s", ref),
+			Code:    http.StatusNotFound,
+		},
+	}
+}
+
+// commit applies a change to the tree of the branch and pushes the result as a new commit. When the branch is not
+// the configured one and does not exist yet, it is created from the configured branch.
+func (r *gitRepository) commit(ctx context.Context, ref, message string, change func(m *openMirror, tree *object.Tree) (plumbing.Hash, error)) error {
+	if ref == "" {
+		ref = r.config.Spec.Git.Branch
+	}
+	branch := plumbing.NewBranchReferenceName(ref)
+	if err := branch.Validate(); err != nil {
+		return &apierrors.StatusError{
+			ErrStatus: metav1.Status{
+				Code:    http.StatusBadRequest,
+				Message: "invalid branch name",
+			},
+		}
+	}
+
+	return r.withMirror(ctx, ref, func(m *openMirror) error {
+		logger := logging.FromContext(ctx)
+		if err := m.fetch(ctx, true); err != nil {
+			return err
+		}
+
+		parent, err := m.branch(ref)
+		if err != nil && ref != m.remote.branch {
+			logger.Info("create branch", "branch", ref, "from", m.remote.branch)
+			parent, err = m.branch(m.remote.branch)
+		}
+		var tree *object.Tree
+		switch {
+		case err == nil:
+			commit, err := m.repo.CommitObject(parent)
+			if err != nil {
+				return fmt.Errorf("read parent commit: %w", err)
+			}
+			if tree, err = commit.Tree(); err != nil {
+				return fmt.Errorf("read parent tree: %w", err)
+			}
+		case apierrors.IsNotFound(err) && ref == m.remote.branch:
+			// the first commit of an empty repository
+			tree = &object.Tree{}
+		default:
+			return err
+		}
+
+		treeHash, err := change(m, tree)
+		if err != nil {
+			return err
+		}
+		if treeHash.IsZero() {
+			// everything was deleted
+			if treeHash, err = m.emptyTree(); err != nil {
+				return err
+			}
+		}
+		if treeHash == tree.Hash && !parent.IsZero() {
+			return nil // no change
+		}
+
+		hash, err := m.writeCommit(ctx, treeHash, parent, message)
+		if err != nil {
+			return err
+		}
+		return m.push(ctx, branch, hash)
+	})
+}
+
+func (m *openMirror) emptyTree() (plumbing.Hash, error) {
+	obj := m.repo.Storer.NewEncodedObject()
+	if err := (&object.Tree{}).Encode(obj); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("encode tree: %w", err)
+	}
+	return m.repo.Storer.SetEncodedObject(obj)
+}
+
+// writeCommit stores a commit of the tree in the mirror.
+func (m *openMirror) writeCommit(ctx context.Context, tree, parent plumbing.Hash, message string) (plumbing.Hash, error) {
+	sig := object.Signature{Name: "grafana"}
+	if author := repository.GetAuthorSignature(ctx); author != nil && author.Name != "" {
+		sig.Name = author.Name
+		sig.Email = author.Email
+		sig.When = author.When
+	}
+	if sig.When.IsZero() {
+		sig.When = time.Now()
+	}
+
+	commit := &object.Commit{
+		Author:    sig,
+		Committer: sig,
+		Message:   message,
+		TreeHash:  tree,
+	}
+	if !parent.IsZero() {
+		commit.ParentHashes = []plumbing.Hash{parent}
+	}
+	obj := m.repo.Storer.NewEncodedObject()
+	if err := commit.Encode(obj); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("encode commit: %w", err)
+	}
+	return m.repo.Storer.SetEncodedObject(obj)
+}
+
+// push updates the branch of the remote to the commit. It fails if the branch was changed in the meantime.
+func (m *openMirror) push(ctx context.Context, branch plumbing.ReferenceName, hash plumbing.Hash) error {
+	if err := m.repo.Storer.SetReference(plumbing.NewHashReference(branch, hash)); err != nil {
+		return fmt.Errorf("update branch: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, maxOperationTimeout)
+	defer cancel()
+
+	err := m.repo.PushContext(ctx, &git.PushOptions{
+		RemoteName: originRemote,
+		RefSpecs:   []gitconfig.RefSpec{gitconfig.RefSpec(fmt.Sprintf("%s:%s", branch, branch))},
+		Auth:       m.remote.auth,
+	})
+	if errors.Is(err, git.ErrNonFastForwardUpdate) {
+		return &apierrors.StatusError{
+			ErrStatus: metav1.Status{
+				Code:    http.StatusConflict,
+				Message: "branch was updated concurrently, try again",
+			},
+		}
+	} else if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("push: %w", err)
+	}
+
+	// reads use the branches of the remote, which now point to the new commit
+	return m.repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewRemoteReferenceName(originRemote, branch.Short()), hash))
+}
+
+// writeFile stores the data as a blob and returns the tree with the file at the path.
+func (m *openMirror) writeFile(tree *object.Tree, path string, data []byte) (plumbing.Hash, error) {
+	obj := m.repo.Storer.NewEncodedObject()
+	obj.SetType(plumbing.BlobObject)
+	w, err := obj.Writer()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if err := w.Close(); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	blob, err := m.repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("store file: %w", err)
+	}
+
+	hash, _, err := m.updateTree(tree.Hash, splitPath(path), &object.TreeEntry{Mode: filemode.Regular, Hash: blob})
+	return hash, err
+}
+
+// updateTree sets the entry at the path of the tree, or removes it when the entry is nil, and stores the changed
+// trees. It returns the hash of the new tree and whether the tree is empty.
+func (m *openMirror) updateTree(treeHash plumbing.Hash, path []string, entry *object.TreeEntry) (plumbing.Hash, bool, error) {
+	var entries []object.TreeEntry
+	if !treeHash.IsZero() {
+		tree, err := object.GetTree(m.repo.Storer, treeHash)
+		if err != nil {
+			return plumbing.ZeroHash, false, fmt.Errorf("read tree: %w", err)
+		}
+		entries = tree.Entries
+	}
+
+	name := path[0]
+	idx := -1
+	for i, e := range entries {
+		if e.Name == name {
+			idx = i
+			break
+		}
+	}
+
+	var updated *object.TreeEntry
+	if len(path) == 1 {
+		if entry != nil {
+			updated = &object.TreeEntry{Name: name, Mode: entry.Mode, Hash: entry.Hash}
+		}
+	} else {
+		subtree := plumbing.ZeroHash
+		if idx >= 0 {
+			if entries[idx].Mode != filemode.Dir {
+				return plumbing.ZeroHash, false, apierrors.NewBadRequest(fmt.Sprintf("'%s' is a file", name))
+			}
+			subtree = entries[idx].Hash
+		}
+		hash, empty, err := m.updateTree(subtree, path[1:], entry)
+		if err != nil {
+			return plumbing.ZeroHash, false, err
+		}
+		if !empty {
+			updated = &object.TreeEntry{Name: name, Mode: filemode.Dir, Hash: hash}
+		}
+	}
+
+	result := make([]object.TreeEntry, 0, len(entries)+1)
+	for i, e := range entries {
+		if i != idx {
+			result = append(result, e)
+		}
+	}
+	if updated != nil {
+		result = append(result, *updated)
+	}
+	if len(result) == 0 {
+		return plumbing.ZeroHash, true, nil
+	}
+
+	// git sorts the entries by name, with a trailing slash for directories
+	sortKey := func(e object.TreeEntry) string {
+		if e.Mode == filemode.Dir {
+			return e.Name + "/"
+		}
+		return e.Name
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return sortKey(result[i]) < sortKey(result[j])
+	})
+
+	obj := m.repo.Storer.NewEncodedObject()
+	if err := (&object.Tree{Entries: result}).Encode(obj); err != nil {
+		return plumbing.ZeroHash, false, fmt.Errorf("encode tree: %w", err)
+	}
+	hash, err := m.repo.Storer.SetEncodedObject(obj)
+	return hash, false, err
+}
+
+// findEntry returns the entry of the tree at the path, or nil for the root of the tree.
+func findEntry(tree *object.Tree, path string) (*object.TreeEntry, error) {
+	path = safepath.Clean(path)
+	if path == "" {
+		return nil, nil
+	}
+	entry, err := tree.FindEntry(path)
+	if errors.Is(err, object.ErrEntryNotFound) || errors.Is(err, object.ErrDirectoryNotFound) {
+		return nil, repository.ErrFileNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("find '%s': %w", path, err)
+	}
+	return entry, nil
+}
+
+func splitPath(path string) []string {
+	return strings.Split(safepath.Clean(path), "/")
+}
@@ -0,0 +1,252 @@
+package gogit
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	provisioning "github.com/grafana/grafana/pkg/apis/provisioning/v0alpha1"
+	"github.com/grafana/grafana/pkg/registry/apis/provisioning/repository"
+)
+
+// newTestRemote creates a bare repository with a first commit on the main branch and returns its URL.
+func newTestRemote(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	remoteDir := filepath.Join(t.TempDir(), "remote.git")
+	_, err := git.PlainInit(remoteDir, true)
+	require.NoError(t, err)
+	url := "file://" + remoteDir
+
+	workDir := t.TempDir()
+	repo, err := git.PlainInit(workDir, false)
+	require.NoError(t, err)
+	_, err = repo.CreateRemote(&gitconfig.RemoteConfig{Name: "origin", URLs: []string{url}})
+	require.NoError(t, err)
+
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+	for name, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(workDir, name)), 0750))
+		require.NoError(t, os.WriteFile(filepath.Join(workDir, name), []byte(content), 0600))
+		_, err = worktree.Add(name)
+		require.NoError(t, err)
+	}
+	_, err = worktree.Commit("initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", When: time.Now()},
+	})
+	require.NoError(t, err)
+	require.NoError(t, repo.Push(&git.PushOptions{
+		RemoteName: "origin",
+		RefSpecs:   []gitconfig.RefSpec{"refs/heads/master:refs/heads/main"},
+	}))
+
+	return url
+}
+
+func newTestGitRepository(t *testing.T, url string) GitRepository {
+	t.Helper()
+	return NewGitRepository(&provisioning.Repository{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+		Spec: provisioning.RepositorySpec{
+			Type: provisioning.GitRepositoryType,
+			Git: &provisioning.GitRepositoryConfig{
+				URL:    url,
+				Branch: "main",
+				Path:   "grafana",
+			},
+		},
+	}, t.TempDir(), nil, Options{AllowLocalRemotes: true})
+}
+
+func TestGitRepository_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *provisioning.GitRepositoryConfig
+		opts   Options
+		fields []string
+	}{
+		{
+			name:   "missing config",
+			fields: []string{"spec.git"},
+		},
+		{
+			name:   "valid",
+			config: &provisioning.GitRepositoryConfig{URL: "https://git.example.com/org/repo.git", Branch: "main"},
+		},
+		{
+			name:   "missing url and branch",
+			config: &provisioning.GitRepositoryConfig{},
+			fields: []string{"spec.git.url", "spec.git.branch"},
+		},
+		{
+			name:   "ssh without key",
+			config: &provisioning.GitRepositoryConfig{URL: "git@git.example.com:org/repo.git", Branch: "main"},
+			fields: []string{"spec.git.sshKey"},
+		},
+		{
+			name:   "invalid branch and path",
+			config: &provisioning.GitRepositoryConfig{URL: "https://git.example.com/org/repo.git", Branch: "a..b", Path: "../outside"},
+			fields: []string{"spec.git.branch", "spec.git.path"},
+		},
+		{
+			name:   "plain http",
+			config: &provisioning.GitRepositoryConfig{URL: "http://git.example.com/org/repo.git", Branch: "main"},
+			fields: []string{"spec.git.url"},
+		},
+		{
+			name:   "local remote",
+			config: &provisioning.GitRepositoryConfig{URL: "file:///tmp/repo.git", Branch: "main"},
+			fields: []string{"spec.git.url"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewGitRepository(&provisioning.Repository{
+				Spec: provisioning.RepositorySpec{Type: provisioning.GitRepositoryType, Git: tt.config},
+			}, t.TempDir(), nil, tt.opts)
+
+			var fields []string
+			for _, err := range repo.Validate() {
+				fields = append(fields, err.Field)
+			}
+			require.Equal(t, tt.fields, fields)
+		})
+	}
+}
+
+func TestGitRepository_Test(t *testing.T) {
+	url := newTestRemote(t, map[string]string{"README.md": "hello"})
+	ctx := context.Background()
+
+	results, err := newTestGitRepository(t, url).Test(ctx)
+	require.NoError(t, err)
+	require.True(t, results.Success, results.Errors)
+
+	repo := newTestGitRepository(t, url)
+	repo.Config().Spec.Git.Branch = "missing"
+	results, err = repo.Test(ctx)
+	require.NoError(t, err)
+	require.False(t, results.Success)
+	require.Equal(t, "spec.git.branch", results.Errors[0].Field)
+}
+
+func TestGitRepository_ReadWrite(t *testing.T) {
+	url := newTestRemote(t, map[string]string{
+		"README.md":                  "outside of the path",
+		"grafana/dashboard.json":     `{"title":"dashboard"}`,
+		"grafana/folder/nested.json": `{"title":"nested"}`,
+	})
+	repo := newTestGitRepository(t, url)
+	ctx := repository.WithAuthorSignature(context.Background(), repository.CommitSignature{Name: "editor", Email: "editor@example.com"})
+
+	tree, err := repo.ReadTree(ctx, "")
+	require.NoError(t, err)
+	var paths []string
+	for _, entry := range tree {
+		paths = append(paths, entry.Path)
+	}
+	require.ElementsMatch(t, []string{"dashboard.json", "folder/", "folder/nested.json"}, paths)
+
+	info, err := repo.Read(ctx, "dashboard.json", "")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"title":"dashboard"}`, string(info.Data))
+	require.NotEmpty(t, info.Hash)
+
+	info, err = repo.Read(ctx, "folder/", "")
+	require.NoError(t, err)
+	require.Nil(t, info.Data)
+
+	_, err = repo.Read(ctx, "missing.json", "")
+	require.ErrorIs(t, err, repository.ErrFileNotFound)
+
+	t.Run("create, update and delete", func(t *testing.T) {
+		require.NoError(t, repo.Create(ctx, "new.json", "", []byte(`{"title":"new"}`), "create new"))
+		err := repo.Create(ctx, "new.json", "", []byte(`{}`), "create again")
+		var statusErr *apierrors.StatusError
+		require.ErrorAs(t, err, &statusErr)
+		require.Equal(t, int32(http.StatusConflict), statusErr.ErrStatus.Code)
+
+		require.NoError(t, repo.Update(ctx, "new.json", "", []byte(`{"title":"updated"}`), "update new"))
+		require.ErrorIs(t, repo.Update(ctx, "other.json", "", []byte(`{}`), "update missing"), repository.ErrFileNotFound)
+
+		// a new instance reads the pushed changes from the remote
+		other := newTestGitRepository(t, url)
+		info, err := other.Read(ctx, "new.json", "")
+		require.NoError(t, err)
+		require.JSONEq(t, `{"title":"updated"}`, string(info.Data))
+
+		history, err := other.History(ctx, "new.json", "")
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		require.Equal(t, "update new", history[0].Message)
+		require.Equal(t, []provisioning.Author{{Name: "editor"}}, history[0].Authors)
+
+		require.NoError(t, repo.Delete(ctx, "folder/", "", "delete folder"))
+		_, err = repo.Read(ctx, "folder/nested.json", "")
+		require.ErrorIs(t, err, repository.ErrFileNotFound)
+	})
+
+	t.Run("write to branch", func(t *testing.T) {
+		require.NoError(t, repo.Write(ctx, "branch.json", "feature", []byte(`{"title":"branch"}`), "on a branch"))
+
+		info, err := repo.Read(ctx, "branch.json", "feature")
+		require.NoError(t, err)
+		require.JSONEq(t, `{"title":"branch"}`, string(info.Data))
+		// the branch is created from the configured branch
+		_, err = repo.Read(ctx, "dashboard.json", "feature")
+		require.NoError(t, err)
+
+		_, err = repo.Read(ctx, "branch.json", "")
+		require.ErrorIs(t, err, repository.ErrFileNotFound)
+
+		_, err = repo.Read(ctx, "branch.json", "missing")
+		require.True(t, apierrors.IsNotFound(err))
+	})
+}
+
+func TestGitRepository_CompareFiles(t *testing.T) {
+	url := newTestRemote(t, map[string]string{
+		"README.md":            "outside of the path",
+		"grafana/updated.json": `{"title":"updated"}`,
+		"grafana/deleted.json": `{"title":"deleted"}`,
+		"grafana/renamed.json": `{"title":"renamed and long enough to be detected as a rename"}`,
+	})
+	repo := newTestGitRepository(t, url)
+	ctx := context.Background()
+
+	base, err := repo.LatestRef(ctx)
+	require.NoError(t, err)
+
+	writeFile := func(path, content string) {
+		require.NoError(t, repo.Write(ctx, path, "", []byte(content), "write "+path))
+	}
+	writeFile("created.json", `{"title":"created"}`)
+	writeFile("updated.json", `{"title":"changed"}`)
+	require.NoError(t, repo.Delete(ctx, "deleted.json", "", "delete"))
+	require.NoError(t, repo.Delete(ctx, "renamed.json", "", "rename"))
+	writeFile("folder/renamed.json", `{"title":"renamed and long enough to be detected as a rename"}`)
+
+	latest, err := repo.LatestRef(ctx)
+	require.NoError(t, err)
+	require.NotEqual(t, base, latest)
+
+	changes, err := repo.CompareFiles(ctx, base, "")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []repository.VersionedFileChange{
+		{Path: "created.json", Ref: latest, Action: repository.FileActionCreated},
+		{Path: "updated.json", Ref: latest, Action: repository.FileActionUpdated},
+		{Path: "deleted.json", PreviousPath: "deleted.json", Ref: latest, PreviousRef: base, Action: repository.FileActionDeleted},
+		{Path: "folder/renamed.json", PreviousPath: "renamed.json", Ref: latest, PreviousRef: base, Action: repository.FileActionRenamed},
+	}, changes)
+}
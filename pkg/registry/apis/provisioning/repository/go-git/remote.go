@@ -0,0 +1,165 @@
+package gogit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	provisioning "github.com/grafana/grafana/pkg/apis/provisioning/v0alpha1"
+	"github.com/grafana/grafana/pkg/registry/apis/provisioning/secrets"
+)
+
+// defaultGitUsername is used for HTTPS and SSH remotes that do not specify a user.
+const defaultGitUsername = "git"
+
+// Options configure the git repositories.
+type Options struct {
+	// AllowLocalRemotes allows file:// remotes, which read any git repository on the filesystem of
+	// the Grafana server. It is only meant for tests and development.
+	AllowLocalRemotes bool
+}
+
+// remote contains what is needed to connect to the git remote of a repository.
+type remote struct {
+	url    string
+	branch string
+	auth   transport.AuthMethod
+}
+
+// newRemote returns the remote of a github or git repository with its decrypted credentials.
+func newRemote(ctx context.Context, config *provisioning.Repository, secrets secrets.Service, opts Options) (*remote, error) {
+	switch {
+	case config.Spec.GitHub != nil:
+		decrypted, err := secrets.Decrypt(ctx, config.Spec.GitHub.EncryptedToken)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting token: %w", err)
+		}
+
+		return &remote{
+			url:    fmt.Sprintf("%s.git", config.Spec.GitHub.URL),
+			branch: config.Spec.GitHub.Branch,
+			auth: &githttp.BasicAuth{
+				Username: "grafana",         // this can be anything except an empty string for PAT
+				Password: string(decrypted), // TODO... will need to get from a service!
+			},
+		}, nil
+	case config.Spec.Git != nil:
+		auth, err := gitAuth(ctx, config.Spec.Git, secrets, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &remote{
+			url:    config.Spec.Git.URL,
+			branch: config.Spec.Git.Branch,
+			auth:   auth,
+		}, nil
+	default:
+		return nil, errors.New("missing git configuration")
+	}
+}
+
+// repoPath returns the subdirectory of the repository that contains the Grafana data.
+func repoPath(config *provisioning.Repository) string {
+	switch {
+	case config.Spec.GitHub != nil:
+		return config.Spec.GitHub.Path
+	case config.Spec.Git != nil:
+		return config.Spec.Git.Path
+	default:
+		return ""
+	}
+}
+
+// gitAuth returns the authentication for the protocol of the remote URL. Remotes over HTTPS without
+// a token and local remotes, when allowed, do not need authentication.
+func gitAuth(ctx context.Context, cfg *provisioning.GitRepositoryConfig, secrets secrets.Service, opts Options) (transport.AuthMethod, error) {
+	endpoint, err := transport.NewEndpoint(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("parse url: %w", err)
+	}
+
+	switch endpoint.Protocol {
+	case "file":
+		if !opts.AllowLocalRemotes {
+			return nil, errors.New("local remotes are not allowed")
+		}
+		return nil, nil
+	case "https":
+		token, err := decryptOrPlain(ctx, secrets, cfg.Token, cfg.EncryptedToken)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting token: %w", err)
+		}
+		if len(token) == 0 {
+			return nil, nil
+		}
+		username := cfg.Username
+		if username == "" {
+			username = defaultGitUsername
+		}
+		return &githttp.BasicAuth{Username: username, Password: string(token)}, nil
+	case "ssh":
+		key, err := decryptOrPlain(ctx, secrets, cfg.SSHKey, cfg.EncryptedSSHKey)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting ssh key: %w", err)
+		}
+		if len(key) == 0 {
+			return nil, errors.New("an ssh key is required for ssh remotes")
+		}
+		username := endpoint.User
+		if username == "" {
+			username = defaultGitUsername
+		}
+		auth, err := gitssh.NewPublicKeys(username, key, "")
+		if err != nil {
+			return nil, fmt.Errorf("parse ssh key: %w", err)
+		}
+		if cfg.KnownHosts != "" {
+			auth.HostKeyCallback, err = knownHostsCallback(cfg.KnownHosts)
+			if err != nil {
+				return nil, fmt.Errorf("parse known hosts: %w", err)
+			}
+		}
+		return auth, nil
+	default:
+		return nil, fmt.Errorf("unsupported protocol %q", endpoint.Protocol)
+	}
+}
+
+// decryptOrPlain returns the plain value if it has not been encrypted yet, which is the case before the
+// repository is saved, and the decrypted value otherwise.
+func decryptOrPlain(ctx context.Context, secrets secrets.Service, plain string, encrypted []byte) ([]byte, error) {
+	if plain != "" {
+		return []byte(plain), nil
+	}
+	if len(encrypted) == 0 {
+		return nil, nil
+	}
+	return secrets.Decrypt(ctx, encrypted)
+}
+
+// knownHostsCallback verifies the keys of SSH servers with the known hosts, given in the format of a known_hosts file.
+func knownHostsCallback(knownHosts string) (ssh.HostKeyCallback, error) {
+	// knownhosts only reads files, the contents are kept in memory once parsed.
+	f, err := os.CreateTemp("", "known_hosts")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = os.Remove(f.Name())
+	}()
+	if _, err := f.WriteString(knownHosts); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return knownhosts.New(f.Name())
+}
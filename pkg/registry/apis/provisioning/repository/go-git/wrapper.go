@@ -16,6 +16,7 @@ import (
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/client"
 	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -69,9 +70,9 @@ func (w *worktree) Filesystem() billy.Filesystem {
 var _ repository.Repository = (*GoGitRepo)(nil)
 
 type GoGitRepo struct {
-	config            *provisioning.Repository
-	decryptedPassword string
-	opts              repository.CloneOptions
+	config *provisioning.Repository
+	auth   transport.AuthMethod
+	opts   repository.CloneOptions
 
 	repo Repository
 	tree Worktree
@@ -86,6 +87,17 @@ func Clone(
 	config *provisioning.Repository,
 	opts repository.CloneOptions,
 	secrets secrets.Service,
+) (repository.ClonedRepository, error) {
+	return cloneWithOptions(ctx, root, config, opts, secrets, Options{})
+}
+
+func cloneWithOptions(
+	ctx context.Context,
+	root string,
+	config *provisioning.Repository,
+	opts repository.CloneOptions,
+	secrets secrets.Service,
+	gitOpts Options,
 ) (repository.ClonedRepository, error) {
 	if root == "" {
 		return nil, fmt.Errorf("missing root config")
@@ -113,9 +125,9 @@ func Clone(
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	decrypted, err := secrets.Decrypt(ctx, config.Spec.GitHub.EncryptedToken)
+	remote, err := newRemote(ctx, config, secrets, gitOpts)
 	if err != nil {
-		return nil, fmt.Errorf("error decrypting token: %w", err)
+		return nil, err
 	}
 
 	if err := os.MkdirAll(root, 0700); err != nil {
@@ -132,7 +144,7 @@ func Clone(
 		progress = io.Discard
 	}
 
-	repo, tree, err := clone(ctx, config, opts, decrypted, dir, progress)
+	repo, tree, err := clone(ctx, remote, opts, dir, progress)
 	if err != nil {
 		if err := os.RemoveAll(dir); err != nil {
 			return nil, fmt.Errorf("remove temp clone dir after clone failed: %w", err)
@@ -142,31 +154,23 @@ func Clone(
 	}
 
 	return &GoGitRepo{
-		config:            config,
-		tree:              &worktree{Worktree: tree},
-		opts:              opts,
-		decryptedPassword: string(decrypted),
-		repo:              repo,
-		dir:               dir,
+		config: config,
+		tree:   &worktree{Worktree: tree},
+		opts:   opts,
+		auth:   remote.auth,
+		repo:   repo,
+		dir:    dir,
 	}, nil
 }
 
-func clone(ctx context.Context, config *provisioning.Repository, opts repository.CloneOptions, decrypted []byte, dir string, progress io.Writer) (*git.Repository, *git.Worktree, error) {
-	gitcfg := config.Spec.GitHub
-	url := gitcfg.URL
-	if !strings.HasPrefix(url, "file://") {
-		url = fmt.Sprintf("%s.git", url)
-	}
-
-	branch := plumbing.NewBranchReferenceName(gitcfg.Branch)
+func clone(ctx context.Context, remote *remote, opts repository.CloneOptions, dir string, progress io.Writer) (*git.Repository, *git.Worktree, error) {
+	url := remote.url
+	branch := plumbing.NewBranchReferenceName(remote.branch)
 	cloneOpts := &git.CloneOptions{
 		ReferenceName: branch,
-		Auth: &githttp.BasicAuth{
-			Username: "grafana",         // this can be anything except an empty string for PAT
-			Password: string(decrypted), // TODO... will need to get from a service!
-		},
-		URL:      url,
-		Progress: progress,
+		Auth:          remote.auth,
+		URL:           url,
+		Progress:      progress,
 	}
 
 	repo, err := git.PlainCloneContext(ctx, dir, false, cloneOpts)
@@ -249,10 +253,7 @@ func (g *GoGitRepo) Push(ctx context.Context, opts repository.PushOptions) error
 	err := g.repo.PushContext(ctx, &git.PushOptions{
 		Progress: progress,
 		Force:    true, // avoid fast-forward-errors
-		Auth: &githttp.BasicAuth{ // reuse logic from clone?
-			Username: "grafana",
-			Password: g.decryptedPassword,
-		},
+		Auth:     g.auth,
 	})
 	if errors.Is(err, git.NoErrAlreadyUpToDate) {
 		return nil // same as the target
@@ -271,11 +272,7 @@ func (g *GoGitRepo) Config() *provisioning.Repository {
 
 // ReadTree implements repository.Repository.
 func (g *GoGitRepo) ReadTree(ctx context.Context, ref string) ([]repository.FileTreeEntry, error) {
-	var treePath string
-	if g.config.Spec.GitHub.Path != "" {
-		treePath = g.config.Spec.GitHub.Path
-	}
-	treePath = safepath.Clean(treePath)
+	treePath := safepath.Clean(repoPath(g.config))
 
 	entries := make([]repository.FileTreeEntry, 0, 100)
 	err := util.Walk(g.tree.Filesystem(), treePath, func(path string, info fs.FileInfo, err error) error {
@@ -333,7 +330,7 @@ func (g *GoGitRepo) Write(ctx context.Context, fpath string, ref string, data []
 	if err := verifyPathWithoutRef(fpath, ref); err != nil {
 		return err
 	}
-	fpath = safepath.Join(g.config.Spec.GitHub.Path, fpath)
+	fpath = safepath.Join(repoPath(g.config), fpath)
 
 	// FIXME: this means that won't export empty folders
 	// should we create them with a .keep file?
@@ -400,7 +397,7 @@ func (g *GoGitRepo) Delete(ctx context.Context, fpath string, ref string, messag
 		return err
 	}
 
-	fpath = safepath.Join(g.config.Spec.GitHub.Path, fpath)
+	fpath = safepath.Join(repoPath(g.config), fpath)
 	if _, err := g.tree.Remove(fpath); err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
 			return repository.ErrFileNotFound
@@ -416,7 +413,7 @@ func (g *GoGitRepo) Read(ctx context.Context, path string, ref string) (*reposit
 	if err := verifyPathWithoutRef(path, ref); err != nil {
 		return nil, err
 	}
-	readPath := safepath.Join(g.config.Spec.GitHub.Path, path)
+	readPath := safepath.Join(repoPath(g.config), path)
 	stat, err := g.tree.Filesystem().Lstat(readPath)
 	if errors.Is(err, fs.ErrNotExist) {
 		return nil, repository.ErrFileNotFound
@@ -133,7 +133,7 @@ func TestReadTree(t *testing.T) {
 			},
 			Status: v0alpha1.RepositoryStatus{},
 		},
-		decryptedPassword: "password",
+		auth: &githttp.BasicAuth{Username: "grafana", Password: "password"},
 
 		repo: gitRepo,
 		tree: &worktree{
@@ -851,8 +851,8 @@ func TestGoGitRepo_Push(t *testing.T) {
 							},
 						},
 					},
-					repo:              mockRepo,
-					decryptedPassword: "test-token",
+					repo: mockRepo,
+					auth: &githttp.BasicAuth{Username: "grafana", Password: "test-token"},
 					opts: repository.CloneOptions{
 						PushOnWrites: true,
 					},
@@ -877,8 +877,8 @@ func TestGoGitRepo_Push(t *testing.T) {
 							},
 						},
 					},
-					repo:              mockRepo,
-					decryptedPassword: "test-token",
+					repo: mockRepo,
+					auth: &githttp.BasicAuth{Username: "grafana", Password: "test-token"},
 					opts: repository.CloneOptions{
 						PushOnWrites: true,
 					},
@@ -904,8 +904,8 @@ func TestGoGitRepo_Push(t *testing.T) {
 							},
 						},
 					},
-					repo:              mockRepo,
-					decryptedPassword: "test-token",
+					repo: mockRepo,
+					auth: &githttp.BasicAuth{Username: "grafana", Password: "test-token"},
 					opts: repository.CloneOptions{
 						PushOnWrites: true,
 					},
@@ -930,8 +930,8 @@ func TestGoGitRepo_Push(t *testing.T) {
 							},
 						},
 					},
-					repo:              mockRepo,
-					decryptedPassword: "test-token",
+					repo: mockRepo,
+					auth: &githttp.BasicAuth{Username: "grafana", Password: "test-token"},
 					opts: repository.CloneOptions{
 						PushOnWrites: true,
 					},
@@ -960,8 +960,8 @@ func TestGoGitRepo_Push(t *testing.T) {
 							},
 						},
 					},
-					repo:              mockRepo,
-					decryptedPassword: "test-token",
+					repo: mockRepo,
+					auth: &githttp.BasicAuth{Username: "grafana", Password: "test-token"},
 					opts: repository.CloneOptions{
 						PushOnWrites: true,
 					},
@@ -988,8 +988,8 @@ func TestGoGitRepo_Push(t *testing.T) {
 							},
 						},
 					},
-					repo:              mockRepo,
-					decryptedPassword: "test-token",
+					repo: mockRepo,
+					auth: &githttp.BasicAuth{Username: "grafana", Password: "test-token"},
 					opts: repository.CloneOptions{
 						PushOnWrites: true,
 					},
@@ -1017,8 +1017,8 @@ func TestGoGitRepo_Push(t *testing.T) {
 							},
 						},
 					},
-					repo:              NewMockRepository(t),
-					decryptedPassword: "test-token",
+					repo: NewMockRepository(t),
+					auth: &githttp.BasicAuth{Username: "grafana", Password: "test-token"},
 					opts: repository.CloneOptions{
 						PushOnWrites: true,
 					},
@@ -1053,9 +1053,9 @@ func TestGoGitRepo_Push(t *testing.T) {
 							},
 						},
 					},
-					repo:              mockRepo,
-					tree:              mockTree,
-					decryptedPassword: "test-token",
+					repo: mockRepo,
+					tree: mockTree,
+					auth: &githttp.BasicAuth{Username: "grafana", Password: "test-token"},
 					opts: repository.CloneOptions{
 						PushOnWrites: false,
 					},
@@ -1083,9 +1083,9 @@ func TestGoGitRepo_Push(t *testing.T) {
 							},
 						},
 					},
-					repo:              mockRepo,
-					tree:              mockTree,
-					decryptedPassword: "test-token",
+					repo: mockRepo,
+					tree: mockTree,
+					auth: &githttp.BasicAuth{Username: "grafana", Password: "test-token"},
 					opts: repository.CloneOptions{
 						PushOnWrites: false,
 					},
@@ -1110,9 +1110,9 @@ func TestGoGitRepo_Push(t *testing.T) {
 							},
 						},
 					},
-					repo:              NewMockRepository(t),
-					tree:              mockTree,
-					decryptedPassword: "test-token",
+					repo: NewMockRepository(t),
+					tree: mockTree,
+					auth: &githttp.BasicAuth{Username: "grafana", Password: "test-token"},
 					opts: repository.CloneOptions{
 						PushOnWrites: false,
 					},
@@ -1638,5 +1638,6 @@ func createTestRepo(t *testing.T) string {
 	srv := server.NewServer(loader)
 	client.InstallProtocol("file", srv)
 
-	return "file://test-repo.git"
+	// The remote of GitHub repositories is the URL with a .git suffix.
+	return "file://test-repo"
 }
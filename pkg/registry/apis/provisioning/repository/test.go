@@ -76,11 +76,16 @@ func ValidateRepository(repo Repository) field.ErrorList {
 			cfg.Spec.GitHub, "Github config only valid when type is github"))
 	}
 
+	if cfg.Spec.Type != provisioning.GitRepositoryType && cfg.Spec.Git != nil {
+		list = append(list, field.Invalid(field.NewPath("spec", "git"),
+			cfg.Spec.Git, "Git config only valid when type is git"))
+	}
+
 	for _, w := range cfg.Spec.Workflows {
 		switch w {
 		case provisioning.WriteWorkflow: // valid; no fall thru
 		case provisioning.BranchWorkflow:
-			if cfg.Spec.Type != provisioning.GitHubRepositoryType {
+			if cfg.Spec.Type != provisioning.GitHubRepositoryType && cfg.Spec.Type != provisioning.GitRepositoryType {
 				list = append(list, field.Invalid(field.NewPath("spec", "workflow"), w, "branch is only supported on git repositories"))
 			}
 		default:
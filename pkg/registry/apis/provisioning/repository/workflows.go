@@ -17,14 +17,18 @@ func IsWriteAllowed(repo *provisioning.Repository, ref string) error {
 		case provisioning.WriteWorkflow:
 			supportsWrite = true
 		case provisioning.BranchWorkflow:
-			supportsBranch = repo.Spec.Type == provisioning.GitHubRepositoryType
+			supportsBranch = repo.Spec.Type == provisioning.GitHubRepositoryType ||
+				repo.Spec.Type == provisioning.GitRepositoryType
 		}
 	}
 
-	// Ref may be the configured branch for github repositories
+	// Ref may be the configured branch for github and git repositories
 	if ref != "" && repo.Spec.GitHub != nil && repo.Spec.GitHub.Branch == ref {
 		ref = ""
 	}
+	if ref != "" && repo.Spec.Git != nil && repo.Spec.Git.Branch == ref {
+		ref = ""
+	}
 
 	switch {
 	case ref == "" && !supportsWrite:
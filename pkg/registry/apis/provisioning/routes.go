@@ -166,6 +166,9 @@ func (b *APIBuilder) handleSettings(w http.ResponseWriter, r *http.Request) {
 		if val.Spec.GitHub != nil {
 			branch = val.Spec.GitHub.Branch
 		}
+		if val.Spec.Git != nil {
+			branch = val.Spec.Git.Branch
+		}
 		settings.Items[i] = provisioning.RepositoryView{
 			Name:      val.Name,
 			Title:     val.Spec.Title,
@@ -0,0 +1,107 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+
+	provisioning "github.com/grafana/grafana/pkg/apis/provisioning/v0alpha1"
+	gogit "github.com/grafana/grafana/pkg/registry/apis/provisioning/repository/go-git"
+	"github.com/grafana/grafana/pkg/registry/apis/provisioning/secrets"
+)
+
+// GitWebhookRepository is a git repository hosted on a server whose API is used for webhooks and merge requests.
+type GitWebhookRepository interface {
+	gogit.GitRepository
+	WebhookRepository
+
+	CommentPullRequest(ctx context.Context, pr int, comment string) error
+}
+
+// NewGitWebhookRepository returns the repository with the integration of its provider.
+// It returns nil if the repository has no provider.
+func NewGitWebhookRepository(
+	ctx context.Context,
+	basic gogit.GitRepository,
+	webhookURL string,
+	secrets secrets.Service,
+	client *http.Client,
+) (GitWebhookRepository, error) {
+	cfg := basic.Config().Spec.Git
+	if cfg == nil || cfg.Provider == "" {
+		return nil, nil
+	}
+
+	baseURL, repoPath, err := gitRemoteAPI(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	token := []byte(cfg.Token)
+	if len(token) == 0 && len(cfg.EncryptedToken) > 0 {
+		token, err = secrets.Decrypt(ctx, cfg.EncryptedToken)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting token: %w", err)
+		}
+	}
+
+	switch cfg.Provider {
+	case provisioning.GitLabProvider:
+		return NewGitLabWebhookRepository(basic, baseURL, repoPath, string(token), webhookURL, secrets, client), nil
+	case provisioning.GiteaProvider:
+		owner, repo, ok := strings.Cut(repoPath, "/")
+		if !ok || strings.Contains(repo, "/") {
+			return nil, fmt.Errorf("unexpected repository path %q", repoPath)
+		}
+		return NewGiteaWebhookRepository(basic, baseURL, owner, repo, string(token), webhookURL, secrets, client), nil
+	default:
+		return nil, fmt.Errorf("unknown git provider (%s)", cfg.Provider)
+	}
+}
+
+// gitRemoteAPI returns the base URL of the server hosting the git remote, and the path of the repository on it.
+// The API of servers reached over SSH is expected on HTTPS.
+func gitRemoteAPI(remoteURL string) (string, string, error) {
+	endpoint, err := transport.NewEndpoint(remoteURL)
+	if err != nil {
+		return "", "", fmt.Errorf("parse url: %w", err)
+	}
+
+	var baseURL string
+	switch endpoint.Protocol {
+	case "http", "https":
+		baseURL = endpoint.Protocol + "://" + endpoint.Host
+		if endpoint.Port != 0 {
+			baseURL = fmt.Sprintf("%s:%d", baseURL, endpoint.Port)
+		}
+	case "ssh":
+		baseURL = "https://" + endpoint.Host
+	default:
+		return "", "", fmt.Errorf("the API cannot be reached for protocol %q", endpoint.Protocol)
+	}
+
+	repoPath := strings.Trim(endpoint.Path, "/")
+	repoPath = strings.TrimSuffix(repoPath, ".git")
+	if repoPath == "" {
+		return "", "", fmt.Errorf("missing repository path in url")
+	}
+	return baseURL, repoPath, nil
+}
+
+func webhookStatusPatch(id int64, url, secret string, events []string) []map[string]interface{} {
+	return []map[string]interface{}{
+		{
+			"op":   "replace",
+			"path": "/status/webhook",
+			"value": &provisioning.WebhookStatus{
+				ID:               id,
+				URL:              url,
+				Secret:           secret,
+				SubscribedEvents: events,
+			},
+		},
+	}
+}
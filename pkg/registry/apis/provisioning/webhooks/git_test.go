@@ -0,0 +1,37 @@
+package webhooks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitRemoteAPI(t *testing.T) {
+	tests := []struct {
+		url      string
+		baseURL  string
+		repoPath string
+		err      bool
+	}{
+		{url: "https://gitlab.com/grafana/dashboards.git", baseURL: "https://gitlab.com", repoPath: "grafana/dashboards"},
+		{url: "https://gitlab.com/group/subgroup/dashboards", baseURL: "https://gitlab.com", repoPath: "group/subgroup/dashboards"},
+		{url: "http://127.0.0.1:3000/grafana/dashboards.git", baseURL: "http://127.0.0.1:3000", repoPath: "grafana/dashboards"},
+		{url: "git@gitea.example.com:grafana/dashboards.git", baseURL: "https://gitea.example.com", repoPath: "grafana/dashboards"},
+		{url: "ssh://git@gitea.example.com:2222/grafana/dashboards.git", baseURL: "https://gitea.example.com", repoPath: "grafana/dashboards"},
+		{url: "file:///tmp/dashboards.git", err: true},
+		{url: "https://gitlab.com/", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			baseURL, repoPath, err := gitRemoteAPI(tt.url)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.baseURL, baseURL)
+			require.Equal(t, tt.repoPath, repoPath)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/registry/apis/provisioning/repository/gitapi"
+	"github.com/grafana/grafana/pkg/registry/apis/provisioning/repository/gitea"
+	gogit "github.com/grafana/grafana/pkg/registry/apis/provisioning/repository/go-git"
+	"github.com/grafana/grafana/pkg/registry/apis/provisioning/secrets"
+)
+
+var giteaSubscribedEvents = []string{gitea.PushEvents, gitea.PullRequestEvents}
+
+// giteaProvider is the gitProvider of repositories hosted on Gitea or Forgejo.
+type giteaProvider struct {
+	owner  string
+	repo   string
+	client gitea.Client
+}
+
+func NewGiteaWebhookRepository(
+	basic gogit.GitRepository,
+	baseURL string,
+	owner string,
+	repo string,
+	token string,
+	webhookURL string,
+	secrets secrets.Service,
+	client *http.Client,
+) GitWebhookRepository {
+	provider := &giteaProvider{
+		owner:  owner,
+		repo:   repo,
+		client: gitea.NewClient(baseURL, token, client),
+	}
+	return newGitProviderWebhookRepository(basic, owner+"/"+repo, provider, webhookURL, secrets)
+}
+
+func (p *giteaProvider) webhookEvents() []string {
+	return giteaSubscribedEvents
+}
+
+func (p *giteaProvider) validatePayload(req *http.Request, secret []byte) (string, []byte, error) {
+	payload, err := gitea.ValidatePayload(req, secret)
+	return gitea.WebHookType(req), payload, err
+}
+
+func (p *giteaProvider) parseEvent(eventType string, payload []byte) (any, error) {
+	event, err := gitea.ParseWebHook(eventType, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	switch event := event.(type) {
+	case *gitea.PushEvent:
+		return &gitPushEvent{
+			repository: event.Repository.FullName,
+			ref:        event.Ref,
+		}, nil
+	case *gitea.PullRequestEvent:
+		pr := event.PullRequest
+		return &gitPullRequestEvent{
+			repository:   event.Repository.FullName,
+			action:       event.Action,
+			changed:      event.Action == "opened" || event.Action == "reopened" || event.Action == "synchronized",
+			targetBranch: pr.Base.Ref,
+			fromFork:     pr.Head.RepoID != pr.Base.RepoID,
+			url:          pr.HTMLURL,
+			number:       event.Number,
+			ref:          pr.Head.Ref,
+			hash:         pr.Head.Sha,
+		}, nil
+	default:
+		return event, nil
+	}
+}
+
+func (p *giteaProvider) createWebhook(ctx context.Context, cfg gitapi.WebhookConfig) (gitapi.WebhookConfig, error) {
+	return p.client.CreateWebhook(ctx, p.owner, p.repo, cfg)
+}
+
+func (p *giteaProvider) getWebhook(ctx context.Context, id int64) (gitapi.WebhookConfig, error) {
+	return p.client.GetWebhook(ctx, p.owner, p.repo, id)
+}
+
+func (p *giteaProvider) editWebhook(ctx context.Context, cfg gitapi.WebhookConfig) error {
+	return p.client.EditWebhook(ctx, p.owner, p.repo, cfg)
+}
+
+func (p *giteaProvider) deleteWebhook(ctx context.Context, id int64) error {
+	return p.client.DeleteWebhook(ctx, p.owner, p.repo, id)
+}
+
+func (p *giteaProvider) commentPullRequest(ctx context.Context, number int, body string) error {
+	return p.client.CreatePullRequestComment(ctx, p.owner, p.repo, number, body)
+}
@@ -0,0 +1,274 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	provisioning "github.com/grafana/grafana/pkg/apis/provisioning/v0alpha1"
+	gogit "github.com/grafana/grafana/pkg/registry/apis/provisioning/repository/go-git"
+	"github.com/grafana/grafana/pkg/registry/apis/provisioning/secrets"
+)
+
+func newGiteaTestRepository(t *testing.T, baseURL string, status *provisioning.WebhookStatus, mockSecrets *secrets.MockService) *gitProviderWebhookRepository {
+	t.Helper()
+	config := &provisioning.Repository{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "unit-test-repo"},
+		Spec: provisioning.RepositorySpec{
+			Type: provisioning.GitRepositoryType,
+			Sync: provisioning.SyncOptions{Enabled: true},
+			Git: &provisioning.GitRepositoryConfig{
+				URL:      baseURL + "/grafana/dashboards.git",
+				Branch:   "main",
+				Token:    "api-token",
+				Provider: provisioning.GiteaProvider,
+			},
+		},
+		Status: provisioning.RepositoryStatus{Webhook: status},
+	}
+	basic := gogit.NewGitRepository(config, t.TempDir(), mockSecrets, gogit.Options{})
+	repo, err := NewGitWebhookRepository(context.Background(), basic, "https://grafana.example.com/webhook", mockSecrets, nil)
+	require.NoError(t, err)
+	return repo.(*gitProviderWebhookRepository)
+}
+
+func TestGiteaParseWebhooks(t *testing.T) {
+	tests := []struct {
+		messageType string
+		name        string
+		expected    provisioning.WebhookResponse
+	}{
+		{"push", "push", provisioning.WebhookResponse{
+			Code: http.StatusAccepted,
+			Job: &provisioning.JobSpec{
+				Repository: "unit-test-repo",
+				Action:     provisioning.JobActionPull,
+				Pull: &provisioning.SyncJobOptions{
+					Incremental: true,
+				},
+			},
+		}},
+		{"pull_request", "pull_request-opened", provisioning.WebhookResponse{
+			Code: http.StatusAccepted,
+			Job: &provisioning.JobSpec{
+				Repository: "unit-test-repo",
+				Action:     provisioning.JobActionPullRequest,
+				PullRequest: &provisioning.PullRequestJobOptions{
+					Ref:  "dashboard/update",
+					Hash: "bffeb74224043ba2feb48d137756c8a9331c449a",
+					PR:   3,
+					URL:  "https://gitea.example.com/grafana/dashboards/pulls/3",
+				},
+			},
+		}},
+		{"pull_request", "pull_request-closed", provisioning.WebhookResponse{
+			Code: http.StatusOK,
+		}},
+		{"pull_request", "pull_request-fork", provisioning.WebhookResponse{
+			Code: http.StatusOK,
+		}},
+	}
+
+	repo := newGiteaTestRepository(t, "https://gitea.example.com", nil, nil)
+	for _, tt := range tests {
+		name := fmt.Sprintf("webhook-gitea-%s.json", tt.name)
+		t.Run(name, func(t *testing.T) {
+			// nolint:gosec
+			payload, err := os.ReadFile(path.Join("testdata", name))
+			require.NoError(t, err)
+
+			rsp, err := repo.parseWebhook(tt.messageType, payload)
+			require.NoError(t, err)
+
+			require.Equal(t, tt.expected.Code, rsp.Code)
+			require.Equal(t, tt.expected.Job, rsp.Job)
+		})
+	}
+
+	t.Run("unsupported event", func(t *testing.T) {
+		rsp, err := repo.parseWebhook("issues", []byte(`{}`))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNotImplemented, rsp.Code)
+	})
+
+	t.Run("other branch", func(t *testing.T) {
+		rsp, err := repo.parseWebhook("push", []byte(`{"ref":"refs/heads/feature","repository":{"full_name":"grafana/dashboards"}}`))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, rsp.Code)
+		require.Nil(t, rsp.Job)
+	})
+}
+
+func TestGiteaRepository_Webhook(t *testing.T) {
+	payload, err := os.ReadFile(path.Join("testdata", "webhook-gitea-push.json"))
+	require.NoError(t, err)
+	sign := func(secret string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(payload)
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	tests := []struct {
+		name         string
+		headers      map[string]string
+		expectedCode int
+		expectedErr  error
+	}{
+		{
+			name:         "gitea signature",
+			headers:      map[string]string{"X-Gitea-Event": "push", "X-Gitea-Signature": sign("webhook-secret")},
+			expectedCode: http.StatusAccepted,
+		},
+		{
+			name:         "forgejo signature",
+			headers:      map[string]string{"X-Forgejo-Event": "push", "X-Forgejo-Signature": sign("webhook-secret")},
+			expectedCode: http.StatusAccepted,
+		},
+		{
+			name:        "invalid signature",
+			headers:     map[string]string{"X-Gitea-Event": "push", "X-Gitea-Signature": sign("other-secret")},
+			expectedErr: apierrors.NewUnauthorized("invalid webhook signature"),
+		},
+		{
+			name:        "missing signature",
+			headers:     map[string]string{"X-Gitea-Event": "push"},
+			expectedErr: apierrors.NewUnauthorized("invalid webhook signature"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSecrets := secrets.NewMockService(t)
+			mockSecrets.EXPECT().Decrypt(mock.Anything, []byte("encrypted-secret")).Return([]byte("webhook-secret"), nil)
+			repo := newGiteaTestRepository(t, "https://gitea.example.com", &provisioning.WebhookStatus{
+				EncryptedSecret: []byte("encrypted-secret"),
+			}, mockSecrets)
+
+			req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(payload))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			rsp, err := repo.Webhook(context.Background(), req)
+			if tt.expectedErr != nil {
+				require.Equal(t, tt.expectedErr, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedCode, rsp.Code)
+		})
+	}
+}
+
+// giteaStandIn fakes the hooks and comments API of a Gitea repository.
+type giteaStandIn struct {
+	mu       sync.Mutex
+	hooks    map[int64]map[string]any
+	comments []string
+	nextID   int64
+}
+
+func newGiteaStandIn(t *testing.T) (*giteaStandIn, *httptest.Server) {
+	s := &giteaStandIn{hooks: map[int64]map[string]any{}, nextID: 1}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if r.Header.Get("Authorization") != "token api-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var body map[string]any
+		if r.Body != nil && r.ContentLength > 0 {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		}
+
+		route := strings.TrimPrefix(r.URL.EscapedPath(), "/api/v1/repos/grafana/dashboards")
+		hookID, isHook := strings.CutPrefix(route, "/hooks/")
+		id, _ := strconv.ParseInt(hookID, 10, 64)
+		switch {
+		case r.Method == http.MethodPost && route == "/hooks":
+			body["id"] = s.nextID
+			s.hooks[s.nextID] = body
+			s.nextID++
+			w.WriteHeader(http.StatusCreated)
+			writeJSON(t, w, body)
+		case r.Method == http.MethodGet && isHook && s.hooks[id] != nil:
+			writeJSON(t, w, s.hooks[id])
+		case r.Method == http.MethodPatch && isHook && s.hooks[id] != nil:
+			body["id"] = id
+			s.hooks[id] = body
+			writeJSON(t, w, body)
+		case r.Method == http.MethodDelete && isHook && s.hooks[id] != nil:
+			delete(s.hooks, id)
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && route == "/issues/3/comments":
+			s.comments = append(s.comments, body["body"].(string))
+			w.WriteHeader(http.StatusCreated)
+			writeJSON(t, w, map[string]any{"id": len(s.comments)})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+func TestGiteaRepository_Hooks(t *testing.T) {
+	standIn, srv := newGiteaStandIn(t)
+	ctx := context.Background()
+	repo := newGiteaTestRepository(t, srv.URL, nil, nil)
+
+	patch, err := repo.OnCreate(ctx)
+	require.NoError(t, err)
+	require.Len(t, patch, 1)
+	status := patch[0]["value"].(*provisioning.WebhookStatus)
+	require.Equal(t, int64(1), status.ID)
+	require.Equal(t, "https://grafana.example.com/webhook", status.URL)
+	require.Equal(t, []string{"push", "pull_request"}, status.SubscribedEvents)
+	require.NotEmpty(t, status.Secret)
+	require.Equal(t, "gitea", standIn.hooks[1]["type"])
+	require.Equal(t, map[string]any{
+		"url":          "https://grafana.example.com/webhook",
+		"content_type": "json",
+		"secret":       status.Secret,
+	}, standIn.hooks[1]["config"])
+
+	// nothing to update
+	repo.config.Status.Webhook = status
+	patch, err = repo.OnUpdate(ctx)
+	require.NoError(t, err)
+	require.Nil(t, patch)
+
+	// the webhook was removed on the server
+	delete(standIn.hooks, 1)
+	patch, err = repo.OnUpdate(ctx)
+	require.NoError(t, err)
+	recreated := patch[0]["value"].(*provisioning.WebhookStatus)
+	require.Equal(t, int64(2), recreated.ID)
+
+	require.NoError(t, repo.CommentPullRequest(ctx, 3, "Grafana spotted some changes"))
+	require.Equal(t, []string{"Grafana spotted some changes"}, standIn.comments)
+
+	repo.config.Status.Webhook = recreated
+	require.NoError(t, repo.OnDelete(ctx))
+	require.Empty(t, standIn.hooks)
+}
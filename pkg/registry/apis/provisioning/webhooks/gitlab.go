@@ -0,0 +1,96 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/registry/apis/provisioning/repository/gitapi"
+	"github.com/grafana/grafana/pkg/registry/apis/provisioning/repository/gitlab"
+	gogit "github.com/grafana/grafana/pkg/registry/apis/provisioning/repository/go-git"
+	"github.com/grafana/grafana/pkg/registry/apis/provisioning/secrets"
+)
+
+var gitlabSubscribedEvents = []string{gitlab.PushEvents, gitlab.MergeRequestEvents}
+
+// gitlabProvider is the gitProvider of repositories hosted on GitLab, whose pull requests are merge requests.
+type gitlabProvider struct {
+	project string
+	client  gitlab.Client
+}
+
+func NewGitLabWebhookRepository(
+	basic gogit.GitRepository,
+	baseURL string,
+	project string,
+	token string,
+	webhookURL string,
+	secrets secrets.Service,
+	client *http.Client,
+) GitWebhookRepository {
+	provider := &gitlabProvider{
+		project: project,
+		client:  gitlab.NewClient(baseURL, token, client),
+	}
+	return newGitProviderWebhookRepository(basic, project, provider, webhookURL, secrets)
+}
+
+func (p *gitlabProvider) webhookEvents() []string {
+	return gitlabSubscribedEvents
+}
+
+func (p *gitlabProvider) validatePayload(req *http.Request, secret []byte) (string, []byte, error) {
+	payload, err := gitlab.ValidatePayload(req, secret)
+	return gitlab.WebHookType(req), payload, err
+}
+
+func (p *gitlabProvider) parseEvent(eventType string, payload []byte) (any, error) {
+	event, err := gitlab.ParseWebHook(eventType, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	switch event := event.(type) {
+	case *gitlab.PushEvent:
+		return &gitPushEvent{
+			repository: event.Project.PathWithNamespace,
+			ref:        event.Ref,
+		}, nil
+	case *gitlab.MergeRequestEvent:
+		mr := event.ObjectAttributes
+		return &gitPullRequestEvent{
+			repository: event.Project.PathWithNamespace,
+			action:     mr.Action,
+			// Updates without new commits only change the description, labels, etc.
+			changed:      mr.Action == "open" || mr.Action == "reopen" || (mr.Action == "update" && mr.OldRev != ""),
+			targetBranch: mr.TargetBranch,
+			fromFork:     mr.SourceProjectID != mr.TargetProjectID,
+			url:          mr.URL,
+			number:       mr.IID,
+			ref:          mr.SourceBranch,
+			hash:         mr.LastCommit.ID,
+		}, nil
+	default:
+		return event, nil
+	}
+}
+
+func (p *gitlabProvider) createWebhook(ctx context.Context, cfg gitapi.WebhookConfig) (gitapi.WebhookConfig, error) {
+	return p.client.CreateWebhook(ctx, p.project, cfg)
+}
+
+func (p *gitlabProvider) getWebhook(ctx context.Context, id int64) (gitapi.WebhookConfig, error) {
+	return p.client.GetWebhook(ctx, p.project, id)
+}
+
+func (p *gitlabProvider) editWebhook(ctx context.Context, cfg gitapi.WebhookConfig) error {
+	return p.client.EditWebhook(ctx, p.project, cfg)
+}
+
+func (p *gitlabProvider) deleteWebhook(ctx context.Context, id int64) error {
+	return p.client.DeleteWebhook(ctx, p.project, id)
+}
+
+// commentPullRequest adds a note to the merge request with the given internal ID.
+func (p *gitlabProvider) commentPullRequest(ctx context.Context, iid int, body string) error {
+	return p.client.CreateMergeRequestNote(ctx, p.project, iid, body)
+}
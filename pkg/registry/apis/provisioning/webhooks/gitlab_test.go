@@ -0,0 +1,276 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	provisioning "github.com/grafana/grafana/pkg/apis/provisioning/v0alpha1"
+	gogit "github.com/grafana/grafana/pkg/registry/apis/provisioning/repository/go-git"
+	"github.com/grafana/grafana/pkg/registry/apis/provisioning/secrets"
+)
+
+func newGitLabTestRepository(t *testing.T, baseURL string, status *provisioning.WebhookStatus, mockSecrets *secrets.MockService) *gitProviderWebhookRepository {
+	t.Helper()
+	config := &provisioning.Repository{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "unit-test-repo"},
+		Spec: provisioning.RepositorySpec{
+			Type: provisioning.GitRepositoryType,
+			Sync: provisioning.SyncOptions{Enabled: true},
+			Git: &provisioning.GitRepositoryConfig{
+				URL:      baseURL + "/grafana/Dashboards.git",
+				Branch:   "main",
+				Token:    "api-token",
+				Provider: provisioning.GitLabProvider,
+			},
+		},
+		Status: provisioning.RepositoryStatus{Webhook: status},
+	}
+	basic := gogit.NewGitRepository(config, t.TempDir(), mockSecrets, gogit.Options{})
+	repo, err := NewGitWebhookRepository(context.Background(), basic, "https://grafana.example.com/webhook", mockSecrets, nil)
+	require.NoError(t, err)
+	return repo.(*gitProviderWebhookRepository)
+}
+
+func TestGitLabParseWebhooks(t *testing.T) {
+	tests := []struct {
+		messageType string
+		name        string
+		expected    provisioning.WebhookResponse
+	}{
+		{"Push Hook", "push", provisioning.WebhookResponse{
+			Code: http.StatusAccepted,
+			Job: &provisioning.JobSpec{
+				Repository: "unit-test-repo",
+				Action:     provisioning.JobActionPull,
+				Pull: &provisioning.SyncJobOptions{
+					Incremental: true,
+				},
+			},
+		}},
+		{"Push Hook", "push-different_branch", provisioning.WebhookResponse{
+			Code: http.StatusOK,
+		}},
+		{"Merge Request Hook", "merge_request-opened", provisioning.WebhookResponse{
+			Code: http.StatusAccepted,
+			Job: &provisioning.JobSpec{
+				Repository: "unit-test-repo",
+				Action:     provisioning.JobActionPullRequest,
+				PullRequest: &provisioning.PullRequestJobOptions{
+					Ref:  "dashboard/update",
+					Hash: "da1560886d4f094c3e6c9ef40349f7d38b5d27d7",
+					PR:   4,
+					URL:  "https://gitlab.example.com/grafana/Dashboards/-/merge_requests/4",
+				},
+			},
+		}},
+		{"Merge Request Hook", "merge_request-updated", provisioning.WebhookResponse{
+			Code: http.StatusAccepted,
+			Job: &provisioning.JobSpec{
+				Repository: "unit-test-repo",
+				Action:     provisioning.JobActionPullRequest,
+				PullRequest: &provisioning.PullRequestJobOptions{
+					Ref:  "dashboard/update",
+					Hash: "c7c2ac4a0a1cbf6b5b7a4dd2b5fb0e3d0e5a3ba1",
+					PR:   4,
+					URL:  "https://gitlab.example.com/grafana/Dashboards/-/merge_requests/4",
+				},
+			},
+		}},
+		{"Merge Request Hook", "merge_request-edited", provisioning.WebhookResponse{
+			Code: http.StatusOK, // no new commits
+		}},
+		{"Merge Request Hook", "merge_request-fork", provisioning.WebhookResponse{
+			Code: http.StatusOK,
+		}},
+	}
+
+	repo := newGitLabTestRepository(t, "https://gitlab.example.com", nil, nil)
+	for _, tt := range tests {
+		name := fmt.Sprintf("webhook-gitlab-%s.json", tt.name)
+		t.Run(name, func(t *testing.T) {
+			// nolint:gosec
+			payload, err := os.ReadFile(path.Join("testdata", name))
+			require.NoError(t, err)
+
+			rsp, err := repo.parseWebhook(tt.messageType, payload)
+			require.NoError(t, err)
+
+			require.Equal(t, tt.expected.Code, rsp.Code)
+			require.Equal(t, tt.expected.Job, rsp.Job)
+		})
+	}
+
+	t.Run("unsupported event", func(t *testing.T) {
+		rsp, err := repo.parseWebhook("Issue Hook", []byte(`{}`))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNotImplemented, rsp.Code)
+	})
+
+	t.Run("other project", func(t *testing.T) {
+		_, err := repo.parseWebhook("Push Hook", []byte(`{"ref":"refs/heads/main","project":{"path_with_namespace":"other/project"}}`))
+		require.EqualError(t, err, "repository mismatch")
+	})
+}
+
+func TestGitLabRepository_Webhook(t *testing.T) {
+	payload, err := os.ReadFile(path.Join("testdata", "webhook-gitlab-push.json"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		token        string
+		expectedCode int
+		expectedErr  error
+	}{
+		{
+			name:         "valid token",
+			token:        "webhook-secret",
+			expectedCode: http.StatusAccepted,
+		},
+		{
+			name:        "invalid token",
+			token:       "other-secret",
+			expectedErr: apierrors.NewUnauthorized("invalid webhook token"),
+		},
+		{
+			name:        "missing token",
+			expectedErr: apierrors.NewUnauthorized("invalid webhook token"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSecrets := secrets.NewMockService(t)
+			mockSecrets.EXPECT().Decrypt(mock.Anything, []byte("encrypted-secret")).Return([]byte("webhook-secret"), nil)
+			repo := newGitLabTestRepository(t, "https://gitlab.example.com", &provisioning.WebhookStatus{
+				EncryptedSecret: []byte("encrypted-secret"),
+			}, mockSecrets)
+
+			req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(payload))
+			req.Header.Set("X-Gitlab-Event", "Push Hook")
+			if tt.token != "" {
+				req.Header.Set("X-Gitlab-Token", tt.token)
+			}
+
+			rsp, err := repo.Webhook(context.Background(), req)
+			if tt.expectedErr != nil {
+				require.Equal(t, tt.expectedErr, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedCode, rsp.Code)
+		})
+	}
+}
+
+// gitlabStandIn fakes the hooks and notes API of a GitLab project.
+type gitlabStandIn struct {
+	mu     sync.Mutex
+	hooks  map[int64]map[string]any
+	notes  []string
+	nextID int64
+}
+
+func newGitLabStandIn(t *testing.T) (*gitlabStandIn, *httptest.Server) {
+	s := &gitlabStandIn{hooks: map[int64]map[string]any{}, nextID: 1}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if r.Header.Get("PRIVATE-TOKEN") != "api-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var body map[string]any
+		if r.Body != nil && r.ContentLength > 0 {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		}
+
+		route := strings.TrimPrefix(r.URL.EscapedPath(), "/api/v4/projects/grafana%2FDashboards")
+		hookID, isHook := strings.CutPrefix(route, "/hooks/")
+		id, _ := strconv.ParseInt(hookID, 10, 64)
+		switch {
+		case r.Method == http.MethodPost && route == "/hooks":
+			body["id"] = s.nextID
+			s.hooks[s.nextID] = body
+			s.nextID++
+			writeJSON(t, w, body)
+		case r.Method == http.MethodGet && isHook && s.hooks[id] != nil:
+			writeJSON(t, w, s.hooks[id])
+		case r.Method == http.MethodPut && isHook && s.hooks[id] != nil:
+			body["id"] = id
+			s.hooks[id] = body
+			writeJSON(t, w, body)
+		case r.Method == http.MethodDelete && isHook && s.hooks[id] != nil:
+			delete(s.hooks, id)
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && route == "/merge_requests/4/notes":
+			s.notes = append(s.notes, body["body"].(string))
+			writeJSON(t, w, map[string]any{"id": len(s.notes)})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	require.NoError(t, json.NewEncoder(w).Encode(v))
+}
+
+func TestGitLabRepository_Hooks(t *testing.T) {
+	standIn, srv := newGitLabStandIn(t)
+	ctx := context.Background()
+
+	repo := newGitLabTestRepository(t, srv.URL, nil, nil)
+
+	patch, err := repo.OnCreate(ctx)
+	require.NoError(t, err)
+	require.Len(t, patch, 1)
+	status := patch[0]["value"].(*provisioning.WebhookStatus)
+	require.Equal(t, int64(1), status.ID)
+	require.Equal(t, "https://grafana.example.com/webhook", status.URL)
+	require.Equal(t, []string{"push", "merge_request"}, status.SubscribedEvents)
+	require.NotEmpty(t, status.Secret)
+	require.Equal(t, status.Secret, standIn.hooks[1]["token"])
+	require.Equal(t, true, standIn.hooks[1]["merge_requests_events"])
+
+	// nothing to update
+	repo.config.Status.Webhook = status
+	patch, err = repo.OnUpdate(ctx)
+	require.NoError(t, err)
+	require.Nil(t, patch)
+
+	// the URL changed
+	repo.webhookURL = "https://other.example.com/webhook"
+	patch, err = repo.OnUpdate(ctx)
+	require.NoError(t, err)
+	updated := patch[0]["value"].(*provisioning.WebhookStatus)
+	require.Equal(t, int64(1), updated.ID)
+	require.Equal(t, "https://other.example.com/webhook", standIn.hooks[1]["url"])
+	require.NotEqual(t, status.Secret, updated.Secret)
+
+	require.NoError(t, repo.CommentPullRequest(ctx, 4, "Grafana spotted some changes"))
+	require.Equal(t, []string{"Grafana spotted some changes"}, standIn.notes)
+
+	require.NoError(t, repo.OnDelete(ctx))
+	require.Empty(t, standIn.hooks)
+}
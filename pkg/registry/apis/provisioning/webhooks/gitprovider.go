@@ -0,0 +1,310 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/grafana/grafana-app-sdk/logging"
+	provisioning "github.com/grafana/grafana/pkg/apis/provisioning/v0alpha1"
+	"github.com/grafana/grafana/pkg/registry/apis/provisioning/repository/gitapi"
+	gogit "github.com/grafana/grafana/pkg/registry/apis/provisioning/repository/go-git"
+	"github.com/grafana/grafana/pkg/registry/apis/provisioning/secrets"
+)
+
+// gitProvider is the API of the server hosting a git repository, e.g. GitLab or Gitea, for the webhook and the pull
+// requests of the repository.
+type gitProvider interface {
+	// webhookEvents returns the events the webhook subscribes to.
+	webhookEvents() []string
+	// validatePayload authenticates the webhook request with the secret, and returns its event type and payload.
+	validatePayload(req *http.Request, secret []byte) (string, []byte, error)
+	// parseEvent parses the payload into a *gitPushEvent or a *gitPullRequestEvent.
+	// gitapi.ErrUnsupportedEvent is returned for other event types.
+	parseEvent(eventType string, payload []byte) (any, error)
+
+	createWebhook(ctx context.Context, cfg gitapi.WebhookConfig) (gitapi.WebhookConfig, error)
+	getWebhook(ctx context.Context, id int64) (gitapi.WebhookConfig, error)
+	editWebhook(ctx context.Context, cfg gitapi.WebhookConfig) error
+	deleteWebhook(ctx context.Context, id int64) error
+	commentPullRequest(ctx context.Context, number int, body string) error
+}
+
+// gitPushEvent is sent when commits are pushed to a branch.
+type gitPushEvent struct {
+	// The full name of the repository, e.g. `group/project`.
+	repository string
+	ref        string
+}
+
+// gitPullRequestEvent is sent when a pull request is opened, updated or closed.
+type gitPullRequestEvent struct {
+	// The full name of the repository, e.g. `group/project`.
+	repository string
+	action     string
+	// True when the pull request was opened or reopened, or got new commits.
+	changed      bool
+	targetBranch string
+	// True when the source branch is in a fork, whose branches are not fetched with the repository.
+	fromFork bool
+	url      string
+	number   int
+	ref      string
+	hash     string
+}
+
+// gitProviderWebhookRepository is a git repository whose webhook and pull requests are managed with the API of its
+// provider.
+type gitProviderWebhookRepository struct {
+	gogit.GitRepository
+	config *provisioning.Repository
+	// The full name of the repository, e.g. `group/project`.
+	repository string
+	secrets    secrets.Service
+	provider   gitProvider
+	webhookURL string
+}
+
+func newGitProviderWebhookRepository(
+	basic gogit.GitRepository,
+	repository string,
+	provider gitProvider,
+	webhookURL string,
+	secrets secrets.Service,
+) *gitProviderWebhookRepository {
+	return &gitProviderWebhookRepository{
+		GitRepository: basic,
+		config:        basic.Config(),
+		repository:    repository,
+		secrets:       secrets,
+		provider:      provider,
+		webhookURL:    webhookURL,
+	}
+}
+
+// Webhook implements Repository.
+func (r *gitProviderWebhookRepository) Webhook(ctx context.Context, req *http.Request) (*provisioning.WebhookResponse, error) {
+	if r.config.Status.Webhook == nil {
+		return nil, fmt.Errorf("unexpected webhook request")
+	}
+
+	secret, err := r.secrets.Decrypt(ctx, r.config.Status.Webhook.EncryptedSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+
+	messageType, payload, err := r.provider.validatePayload(req, secret)
+	if err != nil {
+		return nil, apierrors.NewUnauthorized(err.Error())
+	}
+
+	return r.parseWebhook(messageType, payload)
+}
+
+func (r *gitProviderWebhookRepository) parseWebhook(messageType string, payload []byte) (*provisioning.WebhookResponse, error) {
+	event, err := r.provider.parseEvent(messageType, payload)
+	if errors.Is(err, gitapi.ErrUnsupportedEvent) {
+		return &provisioning.WebhookResponse{
+			Code:    http.StatusNotImplemented,
+			Message: fmt.Sprintf("unsupported messageType: %s", messageType),
+		}, nil
+	}
+	if err != nil {
+		return nil, apierrors.NewBadRequest("invalid payload")
+	}
+
+	switch event := event.(type) {
+	case *gitPushEvent:
+		return r.parsePushEvent(event)
+	case *gitPullRequestEvent:
+		return r.parsePullRequestEvent(event)
+	default:
+		return nil, fmt.Errorf("unexpected event %T", event)
+	}
+}
+
+func (r *gitProviderWebhookRepository) parsePushEvent(event *gitPushEvent) (*provisioning.WebhookResponse, error) {
+	// Repository names are case insensitive
+	if !strings.EqualFold(event.repository, r.repository) {
+		return nil, fmt.Errorf("repository mismatch")
+	}
+
+	// No need to sync if not enabled
+	if !r.config.Spec.Sync.Enabled {
+		return &provisioning.WebhookResponse{Code: http.StatusOK}, nil
+	}
+
+	// Skip silently if the event is not for the configured branch,
+	// as the webhook cannot be configured to only publish events for one branch
+	if event.ref != fmt.Sprintf("refs/heads/%s", r.config.Spec.Git.Branch) {
+		return &provisioning.WebhookResponse{Code: http.StatusOK}, nil
+	}
+
+	return &provisioning.WebhookResponse{
+		Code: http.StatusAccepted,
+		Job: &provisioning.JobSpec{
+			Repository: r.config.GetName(),
+			Action:     provisioning.JobActionPull,
+			Pull: &provisioning.SyncJobOptions{
+				Incremental: true,
+			},
+		},
+	}, nil
+}
+
+func (r *gitProviderWebhookRepository) parsePullRequestEvent(event *gitPullRequestEvent) (*provisioning.WebhookResponse, error) {
+	if !strings.EqualFold(event.repository, r.repository) {
+		return nil, fmt.Errorf("repository mismatch")
+	}
+
+	if event.targetBranch != r.config.Spec.Git.Branch {
+		return &provisioning.WebhookResponse{
+			Code:    http.StatusOK,
+			Message: fmt.Sprintf("ignoring pull request event as %s is not the configured branch", event.targetBranch),
+		}, nil
+	}
+
+	if !event.changed {
+		return &provisioning.WebhookResponse{
+			Code:    http.StatusOK, // Nothing needed
+			Message: fmt.Sprintf("ignore pull request event: %s", event.action),
+		}, nil
+	}
+
+	if event.fromFork {
+		return &provisioning.WebhookResponse{
+			Code:    http.StatusOK,
+			Message: "ignoring pull request from a fork",
+		}, nil
+	}
+
+	// Queue an async job that will parse files
+	return &provisioning.WebhookResponse{
+		Code:    http.StatusAccepted,
+		Message: fmt.Sprintf("pull request: %s", event.action),
+		Job: &provisioning.JobSpec{
+			Repository: r.config.GetName(),
+			Action:     provisioning.JobActionPullRequest,
+			PullRequest: &provisioning.PullRequestJobOptions{
+				URL:  event.url,
+				PR:   event.number,
+				Ref:  event.ref,
+				Hash: event.hash,
+			},
+		},
+	}, nil
+}
+
+// CommentPullRequest adds a comment to a pull request.
+func (r *gitProviderWebhookRepository) CommentPullRequest(ctx context.Context, number int, comment string) error {
+	ctx, _ = r.logger(ctx)
+	return r.provider.commentPullRequest(ctx, number, comment)
+}
+
+func (r *gitProviderWebhookRepository) createWebhook(ctx context.Context) (gitapi.WebhookConfig, error) {
+	secret, err := uuid.NewRandom()
+	if err != nil {
+		return gitapi.WebhookConfig{}, fmt.Errorf("could not generate secret: %w", err)
+	}
+
+	hook, err := r.provider.createWebhook(ctx, gitapi.WebhookConfig{
+		URL:    r.webhookURL,
+		Secret: secret.String(),
+		Events: r.provider.webhookEvents(),
+	})
+	if err != nil {
+		return gitapi.WebhookConfig{}, err
+	}
+
+	logging.FromContext(ctx).Info("webhook created", "url", hook.URL, "id", hook.ID)
+	return hook, nil
+}
+
+// updateWebhook checks if the webhook needs to be updated and updates it if necessary.
+// If the webhook does not exist, it will create it. It returns false when the webhook is unchanged.
+func (r *gitProviderWebhookRepository) updateWebhook(ctx context.Context) (gitapi.WebhookConfig, bool, error) {
+	if r.config.Status.Webhook == nil || r.config.Status.Webhook.ID == 0 {
+		hook, err := r.createWebhook(ctx)
+		return hook, err == nil, err
+	}
+
+	hook, err := r.provider.getWebhook(ctx, r.config.Status.Webhook.ID)
+	switch {
+	case errors.Is(err, gitapi.ErrResourceNotFound):
+		hook, err := r.createWebhook(ctx)
+		return hook, err == nil, err
+	case err != nil:
+		return gitapi.WebhookConfig{}, false, fmt.Errorf("get webhook: %w", err)
+	}
+
+	events := r.provider.webhookEvents()
+	if hook.URL == r.webhookURL && slices.Equal(hook.Events, events) {
+		return hook, false, nil
+	}
+
+	// Something has changed in the webhook. Rotate the secret as well, as it cannot be read back.
+	secret, err := uuid.NewRandom()
+	if err != nil {
+		return gitapi.WebhookConfig{}, false, fmt.Errorf("could not generate secret: %w", err)
+	}
+	hook.URL = r.webhookURL
+	hook.Events = events
+	hook.Secret = secret.String()
+
+	if err := r.provider.editWebhook(ctx, hook); err != nil {
+		return gitapi.WebhookConfig{}, false, fmt.Errorf("edit webhook: %w", err)
+	}
+
+	return hook, true, nil
+}
+
+func (r *gitProviderWebhookRepository) OnCreate(ctx context.Context) ([]map[string]interface{}, error) {
+	if len(r.webhookURL) == 0 {
+		return nil, nil
+	}
+
+	ctx, _ = r.logger(ctx)
+	hook, err := r.createWebhook(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return webhookStatusPatch(hook.ID, hook.URL, hook.Secret, hook.Events), nil
+}
+
+func (r *gitProviderWebhookRepository) OnUpdate(ctx context.Context) ([]map[string]interface{}, error) {
+	if len(r.webhookURL) == 0 {
+		return nil, nil
+	}
+
+	ctx, _ = r.logger(ctx)
+	hook, changed, err := r.updateWebhook(ctx)
+	if err != nil || !changed {
+		return nil, err
+	}
+	return webhookStatusPatch(hook.ID, hook.URL, hook.Secret, hook.Events), nil
+}
+
+func (r *gitProviderWebhookRepository) OnDelete(ctx context.Context) error {
+	if len(r.webhookURL) > 0 && r.config.Status.Webhook != nil {
+		ctx, _ = r.logger(ctx)
+		id := r.config.Status.Webhook.ID
+		if err := r.provider.deleteWebhook(ctx, id); err != nil && !errors.Is(err, gitapi.ErrResourceNotFound) {
+			return fmt.Errorf("delete webhook: %w", err)
+		}
+		logging.FromContext(ctx).Info("webhook deleted", "url", r.config.Status.Webhook.URL, "id", id)
+	}
+
+	return r.GitRepository.OnDelete(ctx)
+}
+
+func (r *gitProviderWebhookRepository) logger(ctx context.Context) (context.Context, logging.Logger) {
+	logger := logging.FromContext(ctx).With(slog.Group("git_repository", "provider", r.config.Spec.Git.Provider, "repository", r.repository, "ref", r.config.Spec.Git.Branch))
+	return logging.Context(ctx, logger), logger
+}
@@ -72,7 +72,7 @@ func (e *evaluator) Evaluate(ctx context.Context, repo repository.Reader, opts p
 	}
 
 	rendererAvailable := e.render.IsAvailable(ctx)
-	shouldRender := rendererAvailable && len(changes) == 1 && cfg.Spec.GitHub.GenerateDashboardPreviews
+	shouldRender := rendererAvailable && len(changes) == 1 && generateDashboardPreviews(cfg.Spec)
 	info := changeInfo{
 		GrafanaBaseURL:       e.urlProvider(cfg.Namespace),
 		MissingImageRenderer: !rendererAvailable,
@@ -96,6 +96,17 @@ func (e *evaluator) Evaluate(ctx context.Context, repo repository.Reader, opts p
 	return info, nil
 }
 
+func generateDashboardPreviews(cfg provisioning.RepositorySpec) bool {
+	switch {
+	case cfg.GitHub != nil:
+		return cfg.GitHub.GenerateDashboardPreviews
+	case cfg.Git != nil:
+		return cfg.Git.GenerateDashboardPreviews
+	default:
+		return false
+	}
+}
+
 var dashboardKind = dashboard.DashboardResourceInfo.GroupVersionKind().Kind
 
 func (e *evaluator) evaluateFile(ctx context.Context, repo repository.Reader, baseURL string, change repository.VersionedFileChange, opts provisioning.PullRequestJobOptions, parser resources.Parser, shouldRender bool) fileChangeInfo {
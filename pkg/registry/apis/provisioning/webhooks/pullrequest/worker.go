@@ -64,8 +64,9 @@ func (c *PullRequestWorker) Process(ctx context.Context,
 	}
 
 	// FIXME: this is leaky because it's supposed to be already a PullRequestRepo
-	if cfg.GitHub == nil {
-		return apierrors.NewBadRequest("expecting github configuration")
+	base, ok := baseBranch(cfg)
+	if !ok {
+		return apierrors.NewBadRequest("expecting github or git configuration")
 	}
 
 	reader, ok := repo.(repository.Reader)
@@ -83,8 +84,6 @@ func (c *PullRequestWorker) Process(ctx context.Context,
 	defer logger.Info("pull request processed")
 
 	progress.SetMessage(ctx, "listing pull request files")
-	// FIXME: this is leaky because it's supposed to be already a PullRequestRepo
-	base := cfg.GitHub.Branch
 	files, err := prRepo.CompareFiles(ctx, base, opts.Ref)
 	if err != nil {
 		return fmt.Errorf("failed to list pull request files: %w", err)
@@ -109,6 +108,18 @@ func (c *PullRequestWorker) Process(ctx context.Context,
 	return nil
 }
 
+// baseBranch returns the configured branch, which pull requests are compared with
+func baseBranch(cfg provisioning.RepositorySpec) (string, bool) {
+	switch {
+	case cfg.GitHub != nil:
+		return cfg.GitHub.Branch, true
+	case cfg.Git != nil:
+		return cfg.Git.Branch, true
+	default:
+		return "", false
+	}
+}
+
 // Remove files we should not try to process
 func onlySupportedFiles(files []repository.VersionedFileChange) (ret []repository.VersionedFileChange) {
 	for _, file := range files {
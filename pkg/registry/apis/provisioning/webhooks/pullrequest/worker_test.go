@@ -164,7 +164,7 @@ func TestPullRequestWorker_Process(t *testing.T) {
 			expectedError: "missing spec.ref",
 		},
 		{
-			name: "missing github configuration",
+			name: "missing github or git configuration",
 			opts: &provisioning.PullRequestJobOptions{
 				PR:  123,
 				Ref: "test-ref",
@@ -179,7 +179,7 @@ func TestPullRequestWorker_Process(t *testing.T) {
 					},
 				})
 			},
-			expectedError: "expecting github configuration",
+			expectedError: "expecting github or git configuration",
 		},
 		{
 			name: "failed to list pull request files",
@@ -379,6 +379,32 @@ func TestPullRequestWorker_Process(t *testing.T) {
 			},
 			expectedError: "",
 		},
+		{
+			name: "successful process with git configuration",
+			opts: &provisioning.PullRequestJobOptions{
+				PR:  7,
+				Ref: "feature",
+			},
+			setupMocks: func(evaluator *MockEvaluator, commenter *MockCommenter, repo *mockPullRequestRepo, progress *jobs.MockJobProgressRecorder) {
+				repo.MockRepository.On("Config").Return(&provisioning.Repository{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test-repo",
+					},
+					Spec: provisioning.RepositorySpec{
+						Title: "test-repo",
+						Git:   &provisioning.GitRepositoryConfig{Branch: "develop", Provider: provisioning.GitLabProvider},
+					},
+				})
+				progress.On("SetMessage", mock.Anything, "listing pull request files").Return()
+				files := []repository.VersionedFileChange{
+					{Path: "test.yaml"},
+				}
+				repo.MockPullRequestRepo.On("CompareFiles", mock.Anything, "develop", "feature").Return(files, nil)
+				evaluator.On("Evaluate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(changeInfo{}, nil)
+				commenter.On("Comment", mock.Anything, mock.Anything, 7, mock.Anything).Return(nil)
+			},
+			expectedError: "",
+		},
 	}
 
 	for _, tt := range tests {
@@ -70,6 +70,7 @@ func ProvideWebhooks(
 				secrets.NewSingleTenant(secretsSvc),
 				ghFactory,
 				filepath.Join(cfg.DataPath, "clone"),
+				gogit.Options{AllowLocalRemotes: cfg.ProvisioningAllowLocalGitRemotes},
 				parsers,
 				[]jobs.Worker{pullRequestWorker},
 			)
@@ -86,6 +87,7 @@ type WebhookExtra struct {
 	secrets     secrets.Service
 	ghFactory   *github.Factory
 	clonedir    string
+	gitOptions  gogit.Options
 	parsers     resources.ParserFactory
 	workers     []jobs.Worker
 }
@@ -97,6 +99,7 @@ func NewWebhookExtra(
 	secrets secrets.Service,
 	ghFactory *github.Factory,
 	clonedir string,
+	gitOptions gogit.Options,
 	parsers resources.ParserFactory,
 	workers []jobs.Worker,
 ) *WebhookExtra {
@@ -107,6 +110,7 @@ func NewWebhookExtra(
 		secrets:     secrets,
 		ghFactory:   ghFactory,
 		clonedir:    clonedir,
+		gitOptions:  gitOptions,
 		parsers:     parsers,
 		workers:     workers,
 	}
@@ -161,17 +165,9 @@ func (e *WebhookExtra) GetJobWorkers() []jobs.Worker {
 
 // AsRepository delegates repository creation to the webhook connector
 func (e *WebhookExtra) AsRepository(ctx context.Context, r *provisioning.Repository) (repository.Repository, error) {
-	if r.Spec.Type == provisioning.GitHubRepositoryType {
-		gvr := provisioning.RepositoryResourceInfo.GroupVersionResource()
-		webhookURL := fmt.Sprintf(
-			"%sapis/%s/%s/namespaces/%s/%s/%s/webhook",
-			e.urlProvider(r.GetNamespace()),
-			gvr.Group,
-			gvr.Version,
-			r.GetNamespace(),
-			gvr.Resource,
-			r.GetName(),
-		)
+	switch {
+	case r.Spec.Type == provisioning.GitHubRepositoryType:
+		webhookURL := e.webhookURL(r)
 		cloneFn := func(ctx context.Context, opts repository.CloneOptions) (repository.ClonedRepository, error) {
 			return gogit.Clone(ctx, e.clonedir, r, opts, e.secrets)
 		}
@@ -182,7 +178,23 @@ func (e *WebhookExtra) AsRepository(ctx context.Context, r *provisioning.Reposit
 		}
 
 		return NewGithubWebhookRepository(basicRepo, webhookURL, e.secrets), nil
+	case r.Spec.Type == provisioning.GitRepositoryType && r.Spec.Git != nil && r.Spec.Git.Provider != "":
+		basicRepo := gogit.NewGitRepository(r, e.clonedir, e.secrets, e.gitOptions)
+		return NewGitWebhookRepository(ctx, basicRepo, e.webhookURL(r), e.secrets, nil)
 	}
 
 	return nil, nil
 }
+
+func (e *WebhookExtra) webhookURL(r *provisioning.Repository) string {
+	gvr := provisioning.RepositoryResourceInfo.GroupVersionResource()
+	return fmt.Sprintf(
+		"%sapis/%s/%s/namespaces/%s/%s/%s/webhook",
+		e.urlProvider(r.GetNamespace()),
+		gvr.Group,
+		gvr.Version,
+		r.GetNamespace(),
+		gvr.Resource,
+		r.GetName(),
+	)
+}
@@ -25,7 +25,7 @@ import (
 // See https://docs.github.com/en/webhooks/webhook-events-and-payloads
 const webhookMaxBodySize = 25 * 1024 * 1024
 
-// This only works for github right now
+// This works for github repositories, and for git repositories hosted on gitlab or gitea
 type webhookConnector struct {
 	webhooksEnabled bool
 	core            *provisioningapis.APIBuilder
@@ -91,7 +91,7 @@ func (s *webhookConnector) PostProcessOpenAPI(oas *spec3.OpenAPI) error {
 	repoprefix := root + "namespaces/{namespace}/repositories/{name}"
 	sub := oas.Paths.Paths[repoprefix+"/webhook"]
 	if sub != nil && sub.Get != nil {
-		sub.Post.Description = "Currently only supports github webhooks"
+		sub.Post.Description = "Currently supports github, gitlab and gitea webhooks"
 	}
 
 	return nil
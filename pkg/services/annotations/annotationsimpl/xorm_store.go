@@ -249,7 +249,8 @@ func (r *xormRepositoryImpl) Get(ctx context.Context, query annotations.ItemQuer
 	var sql bytes.Buffer
 	params := make([]interface{}, 0)
 	items := make([]*annotations.ItemDTO, 0)
-	err := r.db.WithDbSession(ctx, func(sess *db.Session) error {
+	// Annotation queries tolerate the replication lag of the read replicas.
+	err := r.db.WithReadReplica(ctx, func(sess *db.Session) error {
 		sql.WriteString(`
 			SELECT
 				annotation.id,
@@ -453,7 +454,7 @@ func (r *xormRepositoryImpl) Delete(ctx context.Context, params *annotations.Del
 
 func (r *xormRepositoryImpl) GetTags(ctx context.Context, query annotations.TagsQuery) (annotations.FindTagsResult, error) {
 	var items []*annotations.Tag
-	err := r.db.WithDbSession(ctx, func(dbSession *db.Session) error {
+	err := r.db.WithReadReplica(ctx, func(dbSession *db.Session) error {
 		if query.Limit == 0 {
 			query.Limit = 100
 		}
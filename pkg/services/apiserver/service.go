@@ -40,6 +40,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/apiserver/endpoints/request"
 	grafanaapiserveroptions "github.com/grafana/grafana/pkg/services/apiserver/options"
 	"github.com/grafana/grafana/pkg/services/apiserver/utils"
+	"github.com/grafana/grafana/pkg/services/auditlog"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/pluginstore"
@@ -102,6 +103,7 @@ type service struct {
 
 	buildHandlerChainFuncFromBuilders builder.BuildHandlerChainFuncFromBuilders
 	aggregatorRunner                  aggregatorrunner.AggregatorRunner
+	auditLog                          auditlog.Service
 }
 
 func ProvideService(
@@ -123,6 +125,7 @@ func ProvideService(
 	eventualRestConfigProvider *eventualRestConfigProvider,
 	reg prometheus.Registerer,
 	aggregatorRunner aggregatorrunner.AggregatorRunner,
+	auditLog auditlog.Service,
 ) (*service, error) {
 	scheme := builder.ProvideScheme()
 	codecs := builder.ProvideCodecFactory(scheme)
@@ -150,6 +153,7 @@ func ProvideService(
 		restConfigProvider:                restConfigProvider,
 		buildHandlerChainFuncFromBuilders: buildHandlerChainFuncFromBuilders,
 		aggregatorRunner:                  aggregatorRunner,
+		auditLog:                          auditLog,
 	}
 	// This will be used when running as a dskit service
 	service := services.NewBasicService(s.start, s.running, nil).WithName(modules.GrafanaAPIServer)
@@ -282,6 +286,11 @@ func (s *service) start(ctx context.Context) error {
 	serverConfig.LoopbackClientConfig.TLSClientConfig = clientrest.TLSClientConfig{}
 	serverConfig.MaxRequestBodyBytes = MaxRequestBodyBytes
 
+	if s.cfg.AuditLog.Enabled {
+		serverConfig.AuditBackend = auditlog.NewAPIServerBackend(s.auditLog)
+		serverConfig.AuditPolicyRuleEvaluator = auditlog.APIServerPolicy()
+	}
+
 	var optsregister apistore.StorageOptionsRegister
 
 	if o.StorageOptions.StorageType == grafanaapiserveroptions.StorageTypeEtcd {
@@ -0,0 +1,125 @@
+package auditlog
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/grafana/authlib/types"
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	"k8s.io/apiserver/pkg/audit"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+)
+
+var (
+	_ audit.Backend             = (*apiServerBackend)(nil)
+	_ audit.PolicyRuleEvaluator = apiServerPolicy{}
+)
+
+// NewAPIServerBackend returns an audit backend for the Kubernetes-style apiserver that records
+// the changes of resources. It is used together with APIServerPolicy.
+func NewAPIServerBackend(recorder Recorder) audit.Backend {
+	return &apiServerBackend{recorder: recorder}
+}
+
+// APIServerPolicy returns the audit policy of the apiserver, which audits the requests
+// changing resources with their request and response bodies once they complete.
+func APIServerPolicy() audit.PolicyRuleEvaluator {
+	return apiServerPolicy{}
+}
+
+type apiServerPolicy struct{}
+
+func (apiServerPolicy) EvaluatePolicyRule(attrs authorizer.Attributes) audit.RequestAuditConfig {
+	if !attrs.IsResourceRequest() || verbAction(attrs.GetVerb()) == "" {
+		return audit.RequestAuditConfig{Level: auditinternal.LevelNone}
+	}
+	return audit.RequestAuditConfig{
+		Level:             auditinternal.LevelRequestResponse,
+		OmitStages:        []auditinternal.Stage{auditinternal.StageRequestReceived, auditinternal.StageResponseStarted},
+		OmitManagedFields: true,
+	}
+}
+
+func verbAction(verb string) Action {
+	switch verb {
+	case "create":
+		return ActionCreate
+	case "update", "patch":
+		return ActionUpdate
+	case "delete", "deletecollection":
+		return ActionDelete
+	}
+	return ""
+}
+
+type apiServerBackend struct {
+	recorder Recorder
+}
+
+func (b *apiServerBackend) ProcessEvents(events ...*auditinternal.Event) bool {
+	for _, ev := range events {
+		if e, ok := eventFromAPIServer(ev); ok {
+			b.recorder.Record(context.Background(), e)
+		}
+	}
+	return true
+}
+
+func eventFromAPIServer(ev *auditinternal.Event) (Event, bool) {
+	action := verbAction(ev.Verb)
+	if action == "" || ev.ObjectRef == nil || ev.Stage != auditinternal.StageResponseComplete {
+		return Event{}, false
+	}
+
+	e := Event{
+		Created:      ev.StageTimestamp.Time,
+		Source:       SourceAPIServer,
+		Action:       action,
+		ResourceKind: ev.ObjectRef.Resource,
+		ResourceUID:  ev.ObjectRef.Name,
+		ActorUID:     ev.User.UID,
+		ActorLogin:   ev.User.Username,
+		UserAgent:    ev.UserAgent,
+		Method:       ev.Verb,
+		Path:         ev.RequestURI,
+	}
+	if ev.ObjectRef.Subresource != "" {
+		e.ResourceKind += "/" + ev.ObjectRef.Subresource
+	}
+	if info, err := types.ParseNamespace(ev.ObjectRef.Namespace); err == nil {
+		e.OrgID = info.OrgID
+	}
+	if len(ev.SourceIPs) > 0 {
+		e.IPAddress = ev.SourceIPs[0]
+	}
+	if ev.ResponseStatus != nil {
+		e.Status = int(ev.ResponseStatus.Code)
+	}
+	if ev.RequestObject != nil && len(ev.RequestObject.Raw) > 0 {
+		if payload, err := RedactJSON(ev.RequestObject.Raw); err == nil {
+			e.Payload = payload
+		}
+	}
+	// The name of created resources can be generated by the server.
+	if e.ResourceUID == "" && ev.ResponseObject != nil && len(ev.ResponseObject.Raw) > 0 {
+		var obj struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+		}
+		if err := json.Unmarshal(ev.ResponseObject.Raw, &obj); err == nil {
+			e.ResourceUID = obj.Metadata.Name
+		}
+	}
+	return e, true
+}
+
+func (b *apiServerBackend) Run(stopCh <-chan struct{}) error {
+	return nil
+}
+
+func (b *apiServerBackend) Shutdown() {}
+
+func (b *apiServerBackend) String() string {
+	return "grafana-audit-log"
+}
@@ -0,0 +1,74 @@
+package auditlog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authnv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+)
+
+func TestAPIServerBackend(t *testing.T) {
+	var events []Event
+	backend := NewAPIServerBackend(recorderFunc(func(_ context.Context, e Event) {
+		events = append(events, e)
+	}))
+
+	now := time.Now().UTC()
+	newEvent := func(verb string, stage auditinternal.Stage) *auditinternal.Event {
+		return &auditinternal.Event{
+			Stage:          stage,
+			Verb:           verb,
+			RequestURI:     "/apis/dashboard.grafana.app/v1/namespaces/org-2/dashboards",
+			User:           authnv1.UserInfo{UID: "u1", Username: "admin"},
+			SourceIPs:      []string{"10.0.0.1"},
+			UserAgent:      "kubectl",
+			ObjectRef:      &auditinternal.ObjectReference{Resource: "dashboards", Namespace: "org-2"},
+			ResponseStatus: &metav1.Status{Code: 201},
+			RequestObject:  &runtime.Unknown{Raw: []byte(`{"spec": {"title": "a"}, "secure": {"token": "abc"}}`)},
+			ResponseObject: &runtime.Unknown{Raw: []byte(`{"metadata": {"name": "generated"}}`)},
+			StageTimestamp: metav1.NewMicroTime(now),
+		}
+	}
+
+	require.True(t, backend.ProcessEvents(
+		newEvent("create", auditinternal.StageResponseComplete),
+		newEvent("get", auditinternal.StageResponseComplete),
+		newEvent("delete", auditinternal.StagePanic),
+	))
+
+	require.Len(t, events, 1)
+	e := events[0]
+	assert.Equal(t, SourceAPIServer, e.Source)
+	assert.Equal(t, ActionCreate, e.Action)
+	assert.Equal(t, "dashboards", e.ResourceKind)
+	assert.Equal(t, "generated", e.ResourceUID)
+	assert.Equal(t, int64(2), e.OrgID)
+	assert.Equal(t, "u1", e.ActorUID)
+	assert.Equal(t, "admin", e.ActorLogin)
+	assert.Equal(t, "10.0.0.1", e.IPAddress)
+	assert.Equal(t, 201, e.Status)
+	assert.True(t, now.Equal(e.Created))
+	assert.JSONEq(t, `{"spec": {"title": "a"}, "secure": {"token": "[REDACTED]"}}`, string(e.Payload))
+}
+
+func TestAPIServerPolicy(t *testing.T) {
+	policy := APIServerPolicy()
+	u := &user.DefaultInfo{Name: "admin"}
+
+	write := policy.EvaluatePolicyRule(authorizer.AttributesRecord{User: u, Verb: "patch", Resource: "dashboards", ResourceRequest: true})
+	assert.Equal(t, auditinternal.LevelRequestResponse, write.Level)
+
+	read := policy.EvaluatePolicyRule(authorizer.AttributesRecord{User: u, Verb: "list", Resource: "dashboards", ResourceRequest: true})
+	assert.Equal(t, auditinternal.LevelNone, read.Level)
+
+	nonResource := policy.EvaluatePolicyRule(authorizer.AttributesRecord{User: u, Verb: "create", Path: "/openapi/v3"})
+	assert.Equal(t, auditinternal.LevelNone, nonResource.Level)
+}
@@ -0,0 +1,68 @@
+package auditlog
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+)
+
+// Recorder records changes of the configuration.
+type Recorder interface {
+	// Record emits the event to the configured sinks without waiting for them.
+	// The actor, organization and client of the event are taken from the context when they are not set.
+	Record(ctx context.Context, event Event)
+}
+
+type Service interface {
+	Recorder
+	Search(ctx context.Context, query *SearchQuery) (SearchResult, error)
+	// DeleteExpired deletes the events stored in the database that are older than the retention.
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// Request is the request a change is made in.
+type Request struct {
+	Requester identity.Requester
+	IPAddress string
+	UserAgent string
+}
+
+type ctxRequestKey struct{}
+
+// WithRequest attaches the request to the context, so that the events recorded
+// by the services handling the request can be attributed to its actor and client.
+func WithRequest(ctx context.Context, req Request) context.Context {
+	return context.WithValue(ctx, ctxRequestKey{}, req)
+}
+
+func RequestFromContext(ctx context.Context) (Request, bool) {
+	req, ok := ctx.Value(ctxRequestKey{}).(Request)
+	return req, ok
+}
+
+// PopulateFromContext sets the fields of the event that are not set from the requester and the request in the context.
+func (e *Event) PopulateFromContext(ctx context.Context) {
+	req, _ := RequestFromContext(ctx)
+	requester, err := identity.GetRequester(ctx)
+	if err != nil {
+		requester = req.Requester
+	}
+
+	if requester != nil {
+		if e.ActorUID == "" {
+			e.ActorUID = requester.GetUID()
+		}
+		if e.ActorLogin == "" {
+			e.ActorLogin = requester.GetLogin()
+		}
+		if e.OrgID == 0 {
+			e.OrgID = requester.GetOrgID()
+		}
+	}
+	if e.IPAddress == "" {
+		e.IPAddress = req.IPAddress
+	}
+	if e.UserAgent == "" {
+		e.UserAgent = req.UserAgent
+	}
+}
@@ -0,0 +1,48 @@
+package auditlogimpl
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/auditlog"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	authorize := ac.Middleware(s.accessControl)
+
+	routeRegister.Group("/api/admin/audit-logs", func(route routing.RouteRegister) {
+		route.Get("/", middleware.ReqSignedIn, authorize(ac.EvalPermission(ActionRead)), routing.Wrap(s.searchHandler))
+	})
+}
+
+// searchHandler returns the events matching the query parameters, newest first.
+// The from and to parameters are epoch milliseconds.
+func (s *Service) searchHandler(c *contextmodel.ReqContext) response.Response {
+	query := &auditlog.SearchQuery{
+		OrgID:        c.QueryInt64("orgId"),
+		Source:       auditlog.Source(c.Query("source")),
+		Action:       auditlog.Action(c.Query("action")),
+		ResourceKind: c.Query("kind"),
+		ResourceUID:  c.Query("uid"),
+		Actor:        c.Query("actor"),
+		Page:         c.QueryInt("page"),
+		Limit:        c.QueryInt("perpage"),
+	}
+	if from := c.QueryInt64("from"); from > 0 {
+		query.From = time.UnixMilli(from)
+	}
+	if to := c.QueryInt64("to"); to > 0 {
+		query.To = time.UnixMilli(to)
+	}
+
+	result, err := s.Search(c.Req.Context(), query)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to search audit log", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,212 @@
+package auditlogimpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/grafana/grafana/pkg/infra/log"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/auditlog"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	// flushInterval is the longest time an event is buffered before it is written to the sinks.
+	flushInterval = 5 * time.Second
+	// maxBatchSize is the number of buffered events that triggers a write to the sinks.
+	maxBatchSize = 100
+
+	defaultSearchLimit = 100
+	maxSearchLimit     = 1000
+)
+
+var _ auditlog.Service = (*Service)(nil)
+
+type Service struct {
+	cfg           setting.AuditLogSettings
+	store         *store
+	sinks         []Sink
+	queue         chan auditlog.Event
+	accessControl ac.AccessControl
+	log           log.Logger
+	now           func() time.Time
+}
+
+func ProvideService(
+	cfg *setting.Cfg,
+	sqlStore db.DB,
+	routeRegister routing.RouteRegister,
+	accessControl ac.AccessControl,
+	accesscontrolService ac.Service,
+	httpClientProvider httpclient.Provider,
+) (*Service, error) {
+	s := &Service{
+		cfg:           cfg.AuditLog,
+		store:         &store{db: sqlStore},
+		accessControl: accessControl,
+		log:           log.New("auditlog"),
+		now:           time.Now,
+	}
+	if !s.cfg.Enabled {
+		return s, nil
+	}
+
+	s.queue = make(chan auditlog.Event, max(s.cfg.QueueSize, 1))
+
+	sinks, err := s.newSinks(cfg.LogsPath, httpClientProvider)
+	if err != nil {
+		return nil, err
+	}
+	s.sinks = sinks
+
+	if err := declareFixedRoles(accesscontrolService); err != nil {
+		return nil, err
+	}
+	s.registerAPIEndpoints(routeRegister)
+
+	return s, nil
+}
+
+func (s *Service) newSinks(logsPath string, httpClientProvider httpclient.Provider) ([]Sink, error) {
+	var sinks []Sink
+	for _, name := range s.cfg.Sinks {
+		switch name {
+		case setting.AuditLogSinkDatabase:
+			sinks = append(sinks, s.store)
+		case setting.AuditLogSinkFile:
+			path := s.cfg.FilePath
+			if path == "" {
+				path = filepath.Join(logsPath, "audit.log")
+			}
+			sink, err := newFileSink(path)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		case setting.AuditLogSinkLoki, setting.AuditLogSinkWebhook:
+			client, err := httpClientProvider.New()
+			if err != nil {
+				return nil, err
+			}
+			client.Timeout = s.cfg.SinkTimeout
+
+			if name == setting.AuditLogSinkLoki {
+				if s.cfg.LokiURL == "" {
+					return nil, errors.New("audit log sink loki requires loki_url to be set")
+				}
+				sinks = append(sinks, &lokiSink{
+					url:      s.cfg.LokiURL,
+					tenantID: s.cfg.LokiTenantID,
+					username: s.cfg.LokiUsername,
+					password: s.cfg.LokiPassword,
+					client:   client,
+				})
+				continue
+			}
+			if s.cfg.WebhookURL == "" {
+				return nil, errors.New("audit log sink webhook requires webhook_url to be set")
+			}
+			sinks = append(sinks, &webhookSink{
+				url:           s.cfg.WebhookURL,
+				authorization: s.cfg.WebhookAuthorization,
+				client:        client,
+			})
+		default:
+			return nil, fmt.Errorf("unknown audit log sink %q", name)
+		}
+	}
+	return sinks, nil
+}
+
+func (s *Service) IsDisabled() bool {
+	return !s.cfg.Enabled
+}
+
+// Record queues the event to be written to the sinks. Events are dropped when the queue is full,
+// so that slow sinks never block the changes that are being audited.
+func (s *Service) Record(ctx context.Context, event auditlog.Event) {
+	if !s.cfg.Enabled {
+		return
+	}
+
+	event.PopulateFromContext(ctx)
+	if event.Created.IsZero() {
+		event.Created = s.now()
+	}
+	event.Created = event.Created.UTC()
+
+	select {
+	case s.queue <- event:
+	default:
+		s.log.Warn("Dropping audit event because the queue is full", "kind", event.ResourceKind, "uid", event.ResourceUID, "action", event.Action, "actor", event.ActorLogin)
+	}
+}
+
+// Run writes the queued events to the sinks in batches.
+func (s *Service) Run(ctx context.Context) error {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]auditlog.Event, 0, maxBatchSize)
+	for {
+		select {
+		case e := <-s.queue:
+			batch = append(batch, e)
+			if len(batch) >= maxBatchSize {
+				s.flush(ctx, batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				s.flush(ctx, batch)
+				batch = batch[:0]
+			}
+		case <-ctx.Done():
+			// Write the remaining events before shutting down.
+			for len(s.queue) > 0 {
+				batch = append(batch, <-s.queue)
+			}
+			if len(batch) > 0 {
+				s.flush(context.WithoutCancel(ctx), batch)
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Service) flush(ctx context.Context, events []auditlog.Event) {
+	for _, sink := range s.sinks {
+		writeCtx, cancel := ctx, context.CancelFunc(func() {})
+		if s.cfg.SinkTimeout > 0 {
+			writeCtx, cancel = context.WithTimeout(ctx, s.cfg.SinkTimeout)
+		}
+		if err := sink.Write(writeCtx, events); err != nil {
+			s.log.Error("Failed to write audit events", "sink", sink.Name(), "events", len(events), "error", err)
+		}
+		cancel()
+	}
+}
+
+func (s *Service) Search(ctx context.Context, query *auditlog.SearchQuery) (auditlog.SearchResult, error) {
+	if query.Limit <= 0 {
+		query.Limit = defaultSearchLimit
+	}
+	query.Limit = min(query.Limit, maxSearchLimit)
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	return s.store.search(ctx, query)
+}
+
+func (s *Service) DeleteExpired(ctx context.Context) (int64, error) {
+	if s.cfg.Retention <= 0 {
+		return 0, nil
+	}
+	return s.store.deleteOlderThan(ctx, s.now().Add(-s.cfg.Retention))
+}
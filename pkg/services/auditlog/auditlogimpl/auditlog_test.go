@@ -0,0 +1,233 @@
+package auditlogimpl
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/auditlog"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tests/testsuite"
+)
+
+func TestMain(m *testing.M) {
+	testsuite.Run(m)
+}
+
+func TestIntegrationStore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	s := &store{db: db.InitTestDB(t)}
+	ctx := context.Background()
+	start := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+
+	events := []auditlog.Event{
+		{OrgID: 1, Created: start, Source: auditlog.SourceAPI, Action: auditlog.ActionCreate, ResourceKind: "dashboard", ResourceUID: "a", ActorLogin: "admin", Payload: json.RawMessage(`{"title":"a"}`)},
+		{OrgID: 1, Created: start.Add(time.Hour), Source: auditlog.SourceProvisioning, Action: auditlog.ActionUpdate, ResourceKind: "alert-rule", ResourceUID: "b", ActorUID: "u2", Diff: []auditlog.Change{{Path: "title", Old: "x", New: "y"}}},
+		{OrgID: 2, Created: start.Add(2 * time.Hour), Source: auditlog.SourceAPI, Action: auditlog.ActionDelete, ResourceKind: "dashboard", ResourceUID: "a", ActorLogin: "editor"},
+	}
+	require.NoError(t, s.Write(ctx, events))
+
+	t.Run("search returns the newest events first", func(t *testing.T) {
+		result, err := s.search(ctx, &auditlog.SearchQuery{Page: 1, Limit: 10})
+		require.NoError(t, err)
+		require.Equal(t, int64(3), result.TotalCount)
+		require.Len(t, result.Events, 3)
+		assert.Equal(t, "editor", result.Events[0].ActorLogin)
+		assert.Equal(t, []auditlog.Change{{Path: "title", Old: "x", New: "y"}}, result.Events[1].Diff)
+		assert.JSONEq(t, `{"title":"a"}`, string(result.Events[2].Payload))
+		assert.Equal(t, start, result.Events[2].Created)
+	})
+
+	t.Run("search filters and pages", func(t *testing.T) {
+		result, err := s.search(ctx, &auditlog.SearchQuery{OrgID: 1, Page: 1, Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), result.TotalCount)
+
+		result, err = s.search(ctx, &auditlog.SearchQuery{Actor: "u2", Page: 1, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, result.Events, 1)
+		assert.Equal(t, "b", result.Events[0].ResourceUID)
+
+		result, err = s.search(ctx, &auditlog.SearchQuery{ResourceKind: "dashboard", ResourceUID: "a", From: start.Add(time.Minute), Page: 1, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, result.Events, 1)
+		assert.Equal(t, auditlog.ActionDelete, result.Events[0].Action)
+
+		result, err = s.search(ctx, &auditlog.SearchQuery{Page: 2, Limit: 2})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), result.TotalCount)
+		require.Len(t, result.Events, 1)
+		assert.Equal(t, "admin", result.Events[0].ActorLogin)
+	})
+
+	t.Run("delete removes the events older than the cutoff", func(t *testing.T) {
+		deleted, err := s.deleteOlderThan(ctx, start.Add(90*time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), deleted)
+
+		result, err := s.search(ctx, &auditlog.SearchQuery{Page: 1, Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), result.TotalCount)
+	})
+}
+
+func TestSinks(t *testing.T) {
+	events := []auditlog.Event{
+		{OrgID: 1, Created: time.Unix(10, 0), Source: auditlog.SourceAPI, Action: auditlog.ActionCreate, ResourceKind: "dashboard", ResourceUID: "a"},
+		{OrgID: 1, Created: time.Unix(20, 0), Source: auditlog.SourceAPI, Action: auditlog.ActionCreate, ResourceKind: "dashboard", ResourceUID: "b"},
+		{OrgID: 2, Created: time.Unix(30, 0), Source: auditlog.SourceAPI, Action: auditlog.ActionDelete, ResourceKind: "folder", ResourceUID: "c"},
+	}
+
+	t.Run("file sink appends JSON lines", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "logs", "audit.log")
+		sink, err := newFileSink(path)
+		require.NoError(t, err)
+		require.NoError(t, sink.Write(context.Background(), events[:2]))
+		require.NoError(t, sink.Write(context.Background(), events[2:]))
+
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		defer func() { _ = f.Close() }()
+
+		var uids []string
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var e auditlog.Event
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+			uids = append(uids, e.ResourceUID)
+		}
+		assert.Equal(t, []string{"a", "b", "c"}, uids)
+	})
+
+	t.Run("loki sink pushes a stream per set of labels", func(t *testing.T) {
+		var req *http.Request
+		var body struct {
+			Streams []lokiStream `json:"streams"`
+		}
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			req = r
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer srv.Close()
+
+		sink := &lokiSink{url: srv.URL, tenantID: "tenant", username: "user", password: "pass", client: srv.Client()}
+		require.NoError(t, sink.Write(context.Background(), events))
+
+		assert.Equal(t, "/loki/api/v1/push", req.URL.Path)
+		assert.Equal(t, "tenant", req.Header.Get("X-Scope-OrgID"))
+		username, password, ok := req.BasicAuth()
+		require.True(t, ok)
+		assert.Equal(t, "user", username)
+		assert.Equal(t, "pass", password)
+
+		require.Len(t, body.Streams, 2)
+		assert.Equal(t, "dashboard", body.Streams[0].Stream["resource_kind"])
+		assert.Equal(t, "audit", body.Streams[0].Stream["log_type"])
+		require.Len(t, body.Streams[0].Values, 2)
+		assert.Equal(t, "10000000000", body.Streams[0].Values[0][0])
+		assert.Equal(t, "2", body.Streams[1].Stream["org_id"])
+	})
+
+	t.Run("webhook sink posts the events", func(t *testing.T) {
+		var authorization string
+		var body struct {
+			Events []auditlog.Event `json:"events"`
+		}
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization = r.Header.Get("Authorization")
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		}))
+		defer srv.Close()
+
+		sink := &webhookSink{url: srv.URL, authorization: "Bearer token", client: srv.Client()}
+		require.NoError(t, sink.Write(context.Background(), events))
+		assert.Equal(t, "Bearer token", authorization)
+		assert.Len(t, body.Events, 3)
+	})
+
+	t.Run("http sinks fail on error responses", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.Copy(io.Discard, r.Body)
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}))
+		defer srv.Close()
+
+		sink := &webhookSink{url: srv.URL, client: srv.Client()}
+		err := sink.Write(context.Background(), events)
+		require.ErrorContains(t, err, "unexpected status code 500: boom")
+	})
+}
+
+type fakeSink struct {
+	written chan []auditlog.Event
+}
+
+func (f *fakeSink) Name() string {
+	return "fake"
+}
+
+func (f *fakeSink) Write(_ context.Context, events []auditlog.Event) error {
+	f.written <- append([]auditlog.Event(nil), events...)
+	return nil
+}
+
+func TestService(t *testing.T) {
+	newService := func(queueSize int) (*Service, *fakeSink) {
+		sink := &fakeSink{written: make(chan []auditlog.Event, 10)}
+		return &Service{
+			cfg:   setting.AuditLogSettings{Enabled: true, QueueSize: queueSize},
+			sinks: []Sink{sink},
+			queue: make(chan auditlog.Event, queueSize),
+			log:   log.NewNopLogger(),
+			now:   func() time.Time { return time.Unix(100, 0) },
+		}, sink
+	}
+
+	t.Run("drops events when the queue is full", func(t *testing.T) {
+		s, _ := newService(1)
+		s.Record(context.Background(), auditlog.Event{ResourceUID: "a"})
+		s.Record(context.Background(), auditlog.Event{ResourceUID: "b"})
+
+		require.Len(t, s.queue, 1)
+		e := <-s.queue
+		assert.Equal(t, "a", e.ResourceUID)
+		assert.Equal(t, time.Unix(100, 0).UTC(), e.Created)
+	})
+
+	t.Run("writes the queued events on shutdown", func(t *testing.T) {
+		s, sink := newService(10)
+		s.Record(context.Background(), auditlog.Event{ResourceUID: "a"})
+		s.Record(context.Background(), auditlog.Event{ResourceUID: "b"})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.ErrorIs(t, s.Run(ctx), context.Canceled)
+
+		written := <-sink.written
+		require.Len(t, written, 2)
+		assert.Equal(t, "b", written[1].ResourceUID)
+	})
+
+	t.Run("ignores events when disabled", func(t *testing.T) {
+		s, _ := newService(1)
+		s.cfg.Enabled = false
+		s.Record(context.Background(), auditlog.Event{ResourceUID: "a"})
+		assert.Empty(t, s.queue)
+	})
+}
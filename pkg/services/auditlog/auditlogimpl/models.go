@@ -0,0 +1,24 @@
+package auditlogimpl
+
+import (
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+const ActionRead = "auditlogs:read"
+
+var auditLogReaderRole = accesscontrol.RoleDTO{
+	Name:        "fixed:auditlogs:reader",
+	DisplayName: "Reader",
+	Description: "Read the audit log of configuration changes",
+	Group:       "Audit log",
+	Permissions: []accesscontrol.Permission{
+		{Action: ActionRead},
+	},
+}
+
+func declareFixedRoles(ac accesscontrol.Service) error {
+	return ac.DeclareFixedRoles(accesscontrol.RoleRegistration{
+		Role:   auditLogReaderRole,
+		Grants: []string{accesscontrol.RoleGrafanaAdmin},
+	})
+}
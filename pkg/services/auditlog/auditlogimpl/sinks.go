@@ -0,0 +1,175 @@
+package auditlogimpl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/services/auditlog"
+)
+
+// Sink is a destination of audit events.
+type Sink interface {
+	Name() string
+	Write(ctx context.Context, events []auditlog.Event) error
+}
+
+// fileSink appends events to a file as JSON lines.
+type fileSink struct {
+	path string
+	mtx  sync.Mutex
+}
+
+func newFileSink(path string) (*fileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create the directory of the audit log file: %w", err)
+	}
+	return &fileSink{path: path}, nil
+}
+
+func (s *fileSink) Name() string {
+	return "file"
+}
+
+func (s *fileSink) Write(_ context.Context, events []auditlog.Event) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	// #nosec G304 -- the path is set by the administrator in the configuration.
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// lokiSink pushes events to Loki, one log line per event.
+type lokiSink struct {
+	url      string
+	tenantID string
+	username string
+	password string
+	client   *http.Client
+}
+
+func (s *lokiSink) Name() string {
+	return "loki"
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+func (s *lokiSink) Write(ctx context.Context, events []auditlog.Event) error {
+	streams := map[string]*lokiStream{}
+	var keys []string
+	for _, e := range events {
+		labels := map[string]string{
+			"service_name":  "grafana",
+			"log_type":      "audit",
+			"org_id":        strconv.FormatInt(e.OrgID, 10),
+			"source":        string(e.Source),
+			"resource_kind": e.ResourceKind,
+			"action":        string(e.Action),
+		}
+		key := fmt.Sprint(e.OrgID, e.Source, e.ResourceKind, e.Action)
+		stream, ok := streams[key]
+		if !ok {
+			stream = &lokiStream{Stream: labels}
+			streams[key] = stream
+			keys = append(keys, key)
+		}
+
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		stream.Values = append(stream.Values, [2]string{strconv.FormatInt(e.Created.UnixNano(), 10), string(line)})
+	}
+
+	body := struct {
+		Streams []*lokiStream `json:"streams"`
+	}{}
+	for _, key := range keys {
+		body.Streams = append(body.Streams, streams[key])
+	}
+
+	return postJSON(ctx, s.client, s.url+"/loki/api/v1/push", body, func(req *http.Request) {
+		if s.tenantID != "" {
+			req.Header.Set("X-Scope-OrgID", s.tenantID)
+		}
+		if s.username != "" || s.password != "" {
+			req.SetBasicAuth(s.username, s.password)
+		}
+	})
+}
+
+// webhookSink posts batches of events to a URL.
+type webhookSink struct {
+	url           string
+	authorization string
+	client        *http.Client
+}
+
+func (s *webhookSink) Name() string {
+	return "webhook"
+}
+
+func (s *webhookSink) Write(ctx context.Context, events []auditlog.Event) error {
+	body := struct {
+		Events []auditlog.Event `json:"events"`
+	}{Events: events}
+
+	return postJSON(ctx, s.client, s.url, body, func(req *http.Request) {
+		if s.authorization != "" {
+			req.Header.Set("Authorization", s.authorization)
+		}
+	})
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, body any, prepare func(*http.Request)) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	prepare(req)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
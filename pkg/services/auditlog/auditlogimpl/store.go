@@ -0,0 +1,194 @@
+package auditlogimpl
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/auditlog"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+type eventEntity struct {
+	ID           int64  `xorm:"pk autoincr 'id'"`
+	OrgID        int64  `xorm:"org_id"`
+	Created      int64  `xorm:"'created'"`
+	Source       string `xorm:"source"`
+	Action       string `xorm:"action"`
+	ResourceKind string `xorm:"resource_kind"`
+	ResourceUID  string `xorm:"resource_uid"`
+	ActorUID     string `xorm:"actor_uid"`
+	ActorLogin   string `xorm:"actor_login"`
+	IPAddress    string `xorm:"ip_address"`
+	UserAgent    string `xorm:"user_agent"`
+	Method       string `xorm:"method"`
+	Path         string `xorm:"path"`
+	Status       int    `xorm:"status"`
+	Payload      string `xorm:"payload"`
+	Diff         string `xorm:"diff"`
+}
+
+func (eventEntity) TableName() string {
+	return "audit_log"
+}
+
+func fromEvent(e auditlog.Event) (*eventEntity, error) {
+	entity := &eventEntity{
+		OrgID:        e.OrgID,
+		Created:      e.Created.UnixMilli(),
+		Source:       string(e.Source),
+		Action:       string(e.Action),
+		ResourceKind: e.ResourceKind,
+		ResourceUID:  e.ResourceUID,
+		ActorUID:     e.ActorUID,
+		ActorLogin:   e.ActorLogin,
+		IPAddress:    e.IPAddress,
+		UserAgent:    e.UserAgent,
+		Method:       e.Method,
+		Path:         e.Path,
+		Status:       e.Status,
+		Payload:      string(e.Payload),
+	}
+	if len(e.Diff) > 0 {
+		diff, err := json.Marshal(e.Diff)
+		if err != nil {
+			return nil, err
+		}
+		entity.Diff = string(diff)
+	}
+	return entity, nil
+}
+
+func (entity *eventEntity) toEvent() (*auditlog.Event, error) {
+	e := &auditlog.Event{
+		ID:           entity.ID,
+		OrgID:        entity.OrgID,
+		Created:      time.UnixMilli(entity.Created).UTC(),
+		Source:       auditlog.Source(entity.Source),
+		Action:       auditlog.Action(entity.Action),
+		ResourceKind: entity.ResourceKind,
+		ResourceUID:  entity.ResourceUID,
+		ActorUID:     entity.ActorUID,
+		ActorLogin:   entity.ActorLogin,
+		IPAddress:    entity.IPAddress,
+		UserAgent:    entity.UserAgent,
+		Method:       entity.Method,
+		Path:         entity.Path,
+		Status:       entity.Status,
+	}
+	if entity.Payload != "" {
+		e.Payload = json.RawMessage(entity.Payload)
+	}
+	if entity.Diff != "" {
+		if err := json.Unmarshal([]byte(entity.Diff), &e.Diff); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// store keeps events in the audit_log table. It is also the database sink.
+type store struct {
+	db db.DB
+}
+
+func (s *store) Name() string {
+	return "database"
+}
+
+func (s *store) Write(ctx context.Context, events []auditlog.Event) error {
+	rows := make([]*eventEntity, 0, len(events))
+	for _, e := range events {
+		row, err := fromEvent(e)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, row)
+	}
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.BulkInsert(eventEntity{}, rows, sqlstore.NativeSettingsForDialect(s.db.GetDialect()))
+		return err
+	})
+}
+
+func (s *store) search(ctx context.Context, query *auditlog.SearchQuery) (auditlog.SearchResult, error) {
+	var conditions []string
+	var args []any
+	addCondition := func(condition string, arg any) {
+		conditions = append(conditions, condition)
+		args = append(args, arg)
+	}
+
+	if query.OrgID > 0 {
+		addCondition("org_id = ?", query.OrgID)
+	}
+	if query.Source != "" {
+		addCondition("source = ?", string(query.Source))
+	}
+	if query.Action != "" {
+		addCondition("action = ?", string(query.Action))
+	}
+	if query.ResourceKind != "" {
+		addCondition("resource_kind = ?", query.ResourceKind)
+	}
+	if query.ResourceUID != "" {
+		addCondition("resource_uid = ?", query.ResourceUID)
+	}
+	if query.Actor != "" {
+		conditions = append(conditions, "(actor_login = ? OR actor_uid = ?)")
+		args = append(args, query.Actor, query.Actor)
+	}
+	if !query.From.IsZero() {
+		addCondition("created >= ?", query.From.UnixMilli())
+	}
+	if !query.To.IsZero() {
+		addCondition("created <= ?", query.To.UnixMilli())
+	}
+	where := "1 = 1"
+	if len(conditions) > 0 {
+		where = strings.Join(conditions, " AND ")
+	}
+
+	result := auditlog.SearchResult{
+		Events:  []*auditlog.Event{},
+		Page:    query.Page,
+		PerPage: query.Limit,
+	}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		total, err := sess.Where(where, args...).Count(&eventEntity{})
+		if err != nil {
+			return err
+		}
+		result.TotalCount = total
+
+		var rows []*eventEntity
+		offset := query.Limit * (query.Page - 1)
+		if err := sess.Where(where, args...).Desc("created", "id").Limit(query.Limit, offset).Find(&rows); err != nil {
+			return err
+		}
+		for _, row := range rows {
+			e, err := row.toEvent()
+			if err != nil {
+				return err
+			}
+			result.Events = append(result.Events, e)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (s *store) deleteOlderThan(ctx context.Context, olderThan time.Time) (int64, error) {
+	var affected int64
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		res, err := sess.Exec("DELETE FROM audit_log WHERE created < ?", olderThan.UnixMilli())
+		if err != nil {
+			return err
+		}
+		affected, err = res.RowsAffected()
+		return err
+	})
+	return affected, err
+}
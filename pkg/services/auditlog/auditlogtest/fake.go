@@ -0,0 +1,43 @@
+package auditlogtest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/services/auditlog"
+)
+
+var _ auditlog.Service = (*FakeService)(nil)
+
+// FakeService keeps the recorded events in memory.
+type FakeService struct {
+	mtx            sync.Mutex
+	Events         []auditlog.Event
+	ExpectedResult auditlog.SearchResult
+	ExpectedErr    error
+}
+
+func NewFakeService() *FakeService {
+	return &FakeService{}
+}
+
+func (f *FakeService) Record(ctx context.Context, event auditlog.Event) {
+	event.PopulateFromContext(ctx)
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.Events = append(f.Events, event)
+}
+
+func (f *FakeService) Recorded() []auditlog.Event {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return append([]auditlog.Event(nil), f.Events...)
+}
+
+func (f *FakeService) Search(ctx context.Context, query *auditlog.SearchQuery) (auditlog.SearchResult, error) {
+	return f.ExpectedResult, f.ExpectedErr
+}
+
+func (f *FakeService) DeleteExpired(ctx context.Context) (int64, error) {
+	return 0, f.ExpectedErr
+}
@@ -0,0 +1,185 @@
+package auditlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// RedactedValue replaces the values of secret fields.
+const RedactedValue = "[REDACTED]"
+
+// secretKeyFragments are the fragments of field names whose values are redacted, compared case-insensitively.
+var secretKeyFragments = []string{
+	"password",
+	"secret",
+	"token",
+	"apikey",
+	"api_key",
+	"privatekey",
+	"private_key",
+	"credential",
+	"authorization",
+	"securejsondata",
+	"securesettings",
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	if key == "key" {
+		return true
+	}
+	for _, fragment := range secretKeyFragments {
+		if strings.Contains(key, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns the JSON representation of v with the values of secret fields redacted.
+func Redact(v any) (json.RawMessage, error) {
+	generic, err := toGeneric(v)
+	if err != nil {
+		return nil, err
+	}
+	if generic == nil {
+		return nil, nil
+	}
+	return json.Marshal(generic)
+}
+
+// RedactJSON redacts the values of secret fields of a JSON document.
+func RedactJSON(doc []byte) (json.RawMessage, error) {
+	var generic any
+	if err := json.Unmarshal(doc, &generic); err != nil {
+		return nil, err
+	}
+	return json.Marshal(redact(generic))
+}
+
+// Diff returns the changes between two versions of a resource, compared field by field in their
+// JSON representation. Either version can be nil. Values of secret fields are redacted.
+func Diff(before, after any) ([]Change, error) {
+	old, err := toGeneric(before)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := toGeneric(after)
+	if err != nil {
+		return nil, err
+	}
+
+	oldFields, newFields := map[string]any{}, map[string]any{}
+	flatten("", old, oldFields)
+	flatten("", updated, newFields)
+
+	paths := make([]string, 0, len(oldFields)+len(newFields))
+	for path := range oldFields {
+		paths = append(paths, path)
+	}
+	for path := range newFields {
+		if _, ok := oldFields[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var changes []Change
+	for _, path := range paths {
+		o, n := oldFields[path], newFields[path]
+		if reflect.DeepEqual(o, n) {
+			continue
+		}
+		changes = append(changes, Change{Path: path, Old: o, New: n})
+	}
+	return changes, nil
+}
+
+// ResourceEvent returns the event of a change of a resource. The payload of the event is the resource that
+// was created or deleted, and the diff lists the changes of an updated resource. Resources that cannot be
+// encoded to JSON are left out of the event.
+func ResourceEvent(source Source, action Action, kind, uid string, before, after any) Event {
+	e := Event{
+		Source:       source,
+		Action:       action,
+		ResourceKind: kind,
+		ResourceUID:  uid,
+	}
+	switch action {
+	case ActionCreate:
+		e.Payload, _ = Redact(after)
+	case ActionDelete:
+		e.Payload, _ = Redact(before)
+	default:
+		e.Diff, _ = Diff(before, after)
+	}
+	return e
+}
+
+// toGeneric converts v to its redacted JSON representation decoded into maps, slices and scalars.
+func toGeneric(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return nil, err
+	}
+	return redact(generic), nil
+}
+
+func redact(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, value := range t {
+			if isSecretKey(k) && value != nil && value != "" {
+				t[k] = RedactedValue
+				continue
+			}
+			t[k] = redact(value)
+		}
+	case []any:
+		for i, value := range t {
+			t[i] = redact(value)
+		}
+	}
+	return v
+}
+
+// flatten adds the leaves of v to fields, keyed by their path. Empty objects and arrays are leaves.
+func flatten(prefix string, v any, fields map[string]any) {
+	switch t := v.(type) {
+	case map[string]any:
+		if len(t) == 0 && prefix != "" {
+			fields[prefix] = t
+		}
+		for k, value := range t {
+			path := k
+			if prefix != "" {
+				path = prefix + "." + k
+			}
+			flatten(path, value, fields)
+		}
+	case []any:
+		if len(t) == 0 && prefix != "" {
+			fields[prefix] = t
+		}
+		for i, value := range t {
+			flatten(fmt.Sprintf("%s[%d]", prefix, i), value, fields)
+		}
+	case nil:
+		// Null fields are equivalent to missing ones.
+	default:
+		fields[prefix] = t
+	}
+}
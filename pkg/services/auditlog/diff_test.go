@@ -0,0 +1,105 @@
+package auditlog
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactJSON(t *testing.T) {
+	doc := `{
+		"name": "prometheus",
+		"password": "hunter2",
+		"basicAuthPassword": "",
+		"jsonData": {"httpHeaderName1": "Authorization", "token": null},
+		"secureJsonData": {"httpHeaderValue1": "Bearer abc"},
+		"receivers": [{"settings": {"apiKey": "abc", "url": "http://localhost"}}],
+		"key": "abc",
+		"keyPrefix": "prefix"
+	}`
+
+	redacted, err := RedactJSON([]byte(doc))
+	require.NoError(t, err)
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(redacted, &got))
+	assert.Equal(t, map[string]any{
+		"name":              "prometheus",
+		"password":          RedactedValue,
+		"basicAuthPassword": "",
+		"jsonData":          map[string]any{"httpHeaderName1": "Authorization", "token": nil},
+		"secureJsonData":    RedactedValue,
+		"receivers":         []any{map[string]any{"settings": map[string]any{"apiKey": RedactedValue, "url": "http://localhost"}}},
+		"key":               RedactedValue,
+		"keyPrefix":         "prefix",
+	}, got)
+
+	_, err = RedactJSON([]byte("not json"))
+	require.Error(t, err)
+}
+
+func TestDiff(t *testing.T) {
+	type settings struct {
+		URL      string `json:"url,omitempty"`
+		Password string `json:"password,omitempty"`
+	}
+	type resource struct {
+		Title    string   `json:"title"`
+		Tags     []string `json:"tags"`
+		Settings settings `json:"settings"`
+		Folder   *string  `json:"folder"`
+	}
+
+	t.Run("lists the changed fields in order", func(t *testing.T) {
+		before := resource{Title: "a", Tags: []string{"x", "y"}, Settings: settings{URL: "http://a", Password: "old"}}
+		after := resource{Title: "b", Tags: []string{"x"}, Settings: settings{URL: "http://a", Password: "new"}}
+
+		changes, err := Diff(before, after)
+		require.NoError(t, err)
+		assert.Equal(t, []Change{
+			{Path: "tags[1]", Old: "y", New: nil},
+			{Path: "title", Old: "a", New: "b"},
+		}, changes)
+	})
+
+	t.Run("null fields are equal to missing ones", func(t *testing.T) {
+		changes, err := Diff(map[string]any{"a": 1, "b": nil}, map[string]any{"a": 1})
+		require.NoError(t, err)
+		assert.Empty(t, changes)
+	})
+
+	t.Run("secrets are redacted", func(t *testing.T) {
+		changes, err := Diff(settings{URL: "http://a"}, settings{URL: "http://a", Password: "secret"})
+		require.NoError(t, err)
+		assert.Equal(t, []Change{{Path: "password", Old: nil, New: RedactedValue}}, changes)
+	})
+
+	t.Run("nil versions have no fields", func(t *testing.T) {
+		var nothing *resource
+		changes, err := Diff(nothing, map[string]any{"title": "a"})
+		require.NoError(t, err)
+		assert.Equal(t, []Change{{Path: "title", Old: nil, New: "a"}}, changes)
+	})
+}
+
+func TestResourceEvent(t *testing.T) {
+	before := map[string]any{"title": "a", "token": "abc"}
+	after := map[string]any{"title": "b", "token": "abc"}
+
+	created := ResourceEvent(SourceProvisioning, ActionCreate, "thing", "uid", nil, after)
+	assert.JSONEq(t, `{"title": "b", "token": "[REDACTED]"}`, string(created.Payload))
+	assert.Empty(t, created.Diff)
+
+	deleted := ResourceEvent(SourceProvisioning, ActionDelete, "thing", "uid", before, nil)
+	assert.JSONEq(t, `{"title": "a", "token": "[REDACTED]"}`, string(deleted.Payload))
+	assert.Empty(t, deleted.Diff)
+
+	updated := ResourceEvent(SourceProvisioning, ActionUpdate, "thing", "uid", before, after)
+	assert.Nil(t, updated.Payload)
+	assert.Equal(t, []Change{{Path: "title", Old: "a", New: "b"}}, updated.Diff)
+	assert.Equal(t, "thing", updated.ResourceKind)
+	assert.Equal(t, "uid", updated.ResourceUID)
+	assert.Equal(t, SourceProvisioning, updated.Source)
+}
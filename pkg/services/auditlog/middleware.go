@@ -0,0 +1,246 @@
+package auditlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// route is a route of the HTTP API that changes a kind of resource.
+type route struct {
+	// pattern is matched against the prefix of the path of a request, segment by segment.
+	// A "*" segment matches any segment.
+	pattern string
+	kind    string
+	// uidSegments is the number of path segments following the pattern that identify the resource.
+	// The last segment matched by "*" identifies it when the path has no more segments.
+	uidSegments int
+	// uidField is the dot-separated path of the field of the request body holding the UID of the
+	// resource, for requests that do not identify the resource in their path.
+	uidField string
+	// postUpdates is set for routes where POST requests update an existing resource.
+	postUpdates bool
+}
+
+// routes are the audited routes. The first route matching a request is used, so more specific patterns go first.
+// The alerting provisioning API and the Kubernetes-style APIs are audited by their services instead.
+var routes = []route{
+	{pattern: "/api/dashboards/db", kind: "dashboard", uidField: "dashboard.uid"},
+	{pattern: "/api/dashboards/import", kind: "dashboard", uidField: "dashboard.uid"},
+	{pattern: "/api/dashboards/uid/*/permissions", kind: "dashboard-permissions", postUpdates: true},
+	{pattern: "/api/dashboards/uid", kind: "dashboard", uidSegments: 1},
+	{pattern: "/api/datasources/uid", kind: "datasource", uidSegments: 1},
+	{pattern: "/api/datasources/name", kind: "datasource", uidSegments: 1},
+	{pattern: "/api/datasources", kind: "datasource", uidSegments: 1, uidField: "uid"},
+	{pattern: "/api/folders/*/permissions", kind: "folder-permissions", postUpdates: true},
+	{pattern: "/api/folders", kind: "folder", uidSegments: 1, uidField: "uid"},
+	{pattern: "/api/ruler/grafana/api/v1/rules", kind: "alert-rule-group", uidSegments: 2, postUpdates: true},
+	{pattern: "/api/alertmanager/grafana/config", kind: "alertmanager-config", postUpdates: true},
+	{pattern: "/api/v1/ngalert/admin_config", kind: "alerting-admin-config", postUpdates: true},
+	{pattern: "/api/access-control", kind: "permissions", uidSegments: 2, postUpdates: true},
+	{pattern: "/api/org/users", kind: "org-user", uidSegments: 1},
+	{pattern: "/api/orgs/*/users", kind: "org-user", uidSegments: 1},
+	{pattern: "/api/orgs", kind: "org", uidSegments: 1},
+	{pattern: "/api/admin/users", kind: "user", uidSegments: 1},
+	{pattern: "/api/users", kind: "user", uidSegments: 1},
+	{pattern: "/api/teams", kind: "team", uidSegments: 1},
+	{pattern: "/api/auth/keys", kind: "api-key", uidSegments: 1},
+	{pattern: "/api/serviceaccounts/*/tokens", kind: "service-account-token", uidSegments: 1},
+	{pattern: "/api/serviceaccounts", kind: "service-account", uidSegments: 1},
+}
+
+// match returns the UID of the resource if the path matches the route.
+func (rt route) match(segments []string) (uid string, ok bool) {
+	pattern := strings.Split(strings.Trim(rt.pattern, "/"), "/")
+	if len(segments) < len(pattern) {
+		return "", false
+	}
+	for i, p := range pattern {
+		if p == "*" {
+			uid = segments[i]
+			continue
+		}
+		if p != segments[i] {
+			return "", false
+		}
+	}
+
+	rest := segments[len(pattern):]
+	if len(rest) > 0 && rt.uidSegments > 0 {
+		uid = strings.Join(rest[:min(rt.uidSegments, len(rest))], "/")
+	}
+	return uid, true
+}
+
+func (rt route) action(method string) Action {
+	switch method {
+	case http.MethodDelete:
+		return ActionDelete
+	case http.MethodPut, http.MethodPatch:
+		return ActionUpdate
+	}
+	if rt.postUpdates {
+		return ActionUpdate
+	}
+	return ActionCreate
+}
+
+func matchRoute(path string) (route, string, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, rt := range routes {
+		if uid, ok := rt.match(segments); ok {
+			return rt, uid, true
+		}
+	}
+	return route{}, "", false
+}
+
+// StateReader reads the current state of a resource of a kind, identified by the UID of its events.
+type StateReader func(ctx context.Context, orgID int64, uid string) (any, error)
+
+// Middleware records the requests of signed-in identities that change resources through the HTTP API.
+// The state of the resources of the kinds with a reader is read before and after the change, to record
+// the diff of updates and the resources that were created or deleted, like the provisioning services do.
+// It has to run after the context handler, which authenticates the requests.
+func Middleware(cfg *setting.Cfg, recorder Recorder, readers map[string]StateReader) web.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requester, err := identity.GetRequester(r.Context())
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rt, uid, audited := matchRoute(r.URL.Path)
+			audited = audited && (r.Method == http.MethodPost || r.Method == http.MethodPut || r.Method == http.MethodPatch || r.Method == http.MethodDelete)
+
+			var body []byte
+			if audited {
+				body = readBody(r, cfg.AuditLog.MaxPayloadSize)
+			}
+
+			// Handlers use the request of the web context, which is updated in place like the context handler does.
+			req := Request{Requester: requester, IPAddress: web.RemoteAddr(r), UserAgent: r.UserAgent()}
+			if c := web.FromContext(r.Context()); c != nil {
+				c.Req.Body = r.Body
+				*c.Req = *c.Req.WithContext(WithRequest(c.Req.Context(), req))
+			}
+			r = r.WithContext(WithRequest(r.Context(), req))
+
+			if !audited {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if uid == "" && rt.uidField != "" && len(body) > 0 {
+				uid = fieldValue(body, rt.uidField)
+			}
+			read := readers[rt.kind]
+			if uid == "" {
+				read = nil
+			}
+			var before any
+			if read != nil {
+				before = readState(r.Context(), read, requester.GetOrgID(), uid)
+			}
+
+			rw := web.Rw(w, r)
+			next.ServeHTTP(rw, r)
+
+			event := Event{
+				Source:       SourceAPI,
+				Action:       rt.action(r.Method),
+				ResourceKind: rt.kind,
+				ResourceUID:  uid,
+				Method:       r.Method,
+				Path:         r.URL.Path,
+				Status:       rw.Status(),
+			}
+			if len(body) > 0 {
+				if payload, err := RedactJSON(body); err == nil {
+					event.Payload = payload
+				}
+			}
+			if read != nil {
+				recordState(r.Context(), &event, read, requester.GetOrgID(), before)
+			}
+			recorder.Record(r.Context(), event)
+		})
+	}
+}
+
+// recordState adds the state of the resource to the event, once the request has changed it. Requests that create
+// resources that already existed are recorded as updates.
+func recordState(ctx context.Context, event *Event, read StateReader, orgID int64, before any) {
+	var after any
+	if event.Status < http.StatusBadRequest && event.Action != ActionDelete {
+		after = readState(ctx, read, orgID, event.ResourceUID)
+	}
+	if event.Action == ActionCreate && before != nil {
+		event.Action = ActionUpdate
+	}
+
+	switch event.Action {
+	case ActionCreate:
+		if after != nil {
+			event.Payload, _ = Redact(after)
+		}
+	case ActionDelete:
+		if before != nil {
+			event.Payload, _ = Redact(before)
+		}
+	default:
+		if before != nil && after != nil {
+			event.Diff, _ = Diff(before, after)
+		}
+	}
+}
+
+// readState returns the state of the resource, or nil if it cannot be read, for example because it does not exist.
+func readState(ctx context.Context, read StateReader, orgID int64, uid string) any {
+	state, err := read(ctx, orgID, uid)
+	if err != nil {
+		return nil
+	}
+	return state
+}
+
+// readBody returns the request body if it is not larger than limit, and restores the body of the request.
+func readBody(r *http.Request, limit int64) []byte {
+	if r.Body == nil || r.Body == http.NoBody || limit <= 0 {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	if err != nil || int64(len(body)) > limit {
+		return nil
+	}
+	return body
+}
+
+// fieldValue returns the string value of a field of a JSON object, identified by its dot-separated path.
+func fieldValue(body []byte, path string) string {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return ""
+	}
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return ""
+		}
+		v = obj[key]
+	}
+	s, _ := v.(string)
+	return s
+}
@@ -0,0 +1,192 @@
+package auditlog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	claims "github.com/grafana/authlib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type recorderFunc func(ctx context.Context, e Event)
+
+func (f recorderFunc) Record(ctx context.Context, e Event) {
+	f(ctx, e)
+}
+
+func TestMatchRoute(t *testing.T) {
+	testCases := []struct {
+		path   string
+		kind   string
+		uid    string
+		action Action
+		method string
+	}{
+		{path: "/api/dashboards/db", kind: "dashboard", method: http.MethodPost, action: ActionCreate},
+		{path: "/api/dashboards/uid/abc", kind: "dashboard", uid: "abc", method: http.MethodDelete, action: ActionDelete},
+		{path: "/api/dashboards/uid/abc/permissions", kind: "dashboard-permissions", uid: "abc", method: http.MethodPost, action: ActionUpdate},
+		{path: "/api/datasources/uid/ds1", kind: "datasource", uid: "ds1", method: http.MethodPut, action: ActionUpdate},
+		{path: "/api/folders/f1/permissions", kind: "folder-permissions", uid: "f1", method: http.MethodPost, action: ActionUpdate},
+		{path: "/api/ruler/grafana/api/v1/rules/folder-uid/group", kind: "alert-rule-group", uid: "folder-uid/group", method: http.MethodPost, action: ActionUpdate},
+		{path: "/api/orgs/2/users/3", kind: "org-user", uid: "3", method: http.MethodPatch, action: ActionUpdate},
+		{path: "/api/serviceaccounts/4/tokens", kind: "service-account-token", uid: "4", method: http.MethodPost, action: ActionCreate},
+		{path: "/api/serviceaccounts/4", kind: "service-account", uid: "4", method: http.MethodDelete, action: ActionDelete},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			rt, uid, ok := matchRoute(tc.path)
+			require.True(t, ok)
+			assert.Equal(t, tc.kind, rt.kind)
+			assert.Equal(t, tc.uid, uid)
+			assert.Equal(t, tc.action, rt.action(tc.method))
+		})
+	}
+
+	_, _, ok := matchRoute("/api/search")
+	require.False(t, ok)
+}
+
+func TestMiddleware(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.AuditLog.MaxPayloadSize = 1024
+
+	requester := &identity.StaticRequester{Type: claims.TypeUser, UserID: 1, UserUID: "u1", Login: "admin", OrgID: 3}
+
+	// The handler saves and deletes the dashboards of the requests, which the reader returns.
+	dashboards := map[string]any{}
+	readers := map[string]StateReader{
+		"dashboard": func(_ context.Context, orgID int64, uid string) (any, error) {
+			require.Equal(t, requester.GetOrgID(), orgID)
+			dash, ok := dashboards[uid]
+			if !ok {
+				return nil, errors.New("dashboard not found")
+			}
+			return dash, nil
+		},
+	}
+
+	serve := func(t *testing.T, req *http.Request, signedIn bool) ([]Event, string) {
+		t.Helper()
+		var events []Event
+		recorder := recorderFunc(func(ctx context.Context, e Event) {
+			e.PopulateFromContext(ctx)
+			events = append(events, e)
+		})
+
+		var handlerBody string
+		handler := Middleware(cfg, recorder, readers)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			handlerBody = string(b)
+			switch {
+			case r.Method == http.MethodPost && r.URL.Path == "/api/dashboards/db":
+				var cmd struct {
+					Dashboard map[string]any `json:"dashboard"`
+				}
+				require.NoError(t, json.Unmarshal(b, &cmd))
+				dashboards[cmd.Dashboard["uid"].(string)] = cmd.Dashboard
+			case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/api/dashboards/uid/"):
+				delete(dashboards, strings.TrimPrefix(r.URL.Path, "/api/dashboards/uid/"))
+			}
+			w.WriteHeader(http.StatusAccepted)
+		}))
+
+		if signedIn {
+			req = req.WithContext(identity.WithRequester(req.Context(), requester))
+		}
+		req.Header.Set("User-Agent", "test-agent")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		return events, handlerBody
+	}
+
+	t.Run("records changes of resources", func(t *testing.T) {
+		body := `{"dashboard": {"uid": "dash1", "title": "Test"}, "secureJsonData": {"password": "hunter2"}}`
+		events, handlerBody := serve(t, httptest.NewRequest(http.MethodPost, "/api/dashboards/db", strings.NewReader(body)), true)
+
+		assert.Equal(t, body, handlerBody, "the handler should read the whole body")
+		require.Len(t, events, 1)
+		e := events[0]
+		assert.Equal(t, SourceAPI, e.Source)
+		assert.Equal(t, ActionCreate, e.Action)
+		assert.Equal(t, "dashboard", e.ResourceKind)
+		assert.Equal(t, "dash1", e.ResourceUID)
+		assert.Equal(t, http.StatusAccepted, e.Status)
+		assert.Equal(t, http.MethodPost, e.Method)
+		assert.Equal(t, "/api/dashboards/db", e.Path)
+		assert.Equal(t, requester.GetUID(), e.ActorUID)
+		assert.Equal(t, "admin", e.ActorLogin)
+		assert.Equal(t, int64(3), e.OrgID)
+		assert.Equal(t, "test-agent", e.UserAgent)
+		assert.JSONEq(t, `{"uid": "dash1", "title": "Test"}`, string(e.Payload), "the payload should be the created resource")
+		assert.Nil(t, e.Diff)
+	})
+
+	t.Run("records the diff of updates", func(t *testing.T) {
+		dashboards["dash2"] = map[string]any{"uid": "dash2", "title": "Before"}
+		body := `{"dashboard": {"uid": "dash2", "title": "After"}, "overwrite": true}`
+		events, _ := serve(t, httptest.NewRequest(http.MethodPost, "/api/dashboards/db", strings.NewReader(body)), true)
+
+		require.Len(t, events, 1)
+		e := events[0]
+		assert.Equal(t, ActionUpdate, e.Action, "saving an existing dashboard should be recorded as an update")
+		assert.Equal(t, "dash2", e.ResourceUID)
+		assert.JSONEq(t, body, string(e.Payload))
+		expected, err := Diff(map[string]any{"uid": "dash2", "title": "Before"}, map[string]any{"uid": "dash2", "title": "After"})
+		require.NoError(t, err)
+		assert.Equal(t, expected, e.Diff)
+		assert.NotEmpty(t, e.Diff)
+	})
+
+	t.Run("records deleted resources", func(t *testing.T) {
+		dashboards["dash3"] = map[string]any{"uid": "dash3", "title": "Deleted", "secureJsonData": map[string]any{"password": "hunter2"}}
+		events, _ := serve(t, httptest.NewRequest(http.MethodDelete, "/api/dashboards/uid/dash3", nil), true)
+
+		require.Len(t, events, 1)
+		e := events[0]
+		assert.Equal(t, ActionDelete, e.Action)
+		assert.Equal(t, "dash3", e.ResourceUID)
+		assert.JSONEq(t, `{"uid": "dash3", "title": "Deleted", "secureJsonData": "[REDACTED]"}`, string(e.Payload))
+		assert.NotContains(t, dashboards, "dash3")
+	})
+
+	t.Run("records the redacted request payload of resources without a reader", func(t *testing.T) {
+		body := `{"name": "team", "secureJsonData": {"password": "hunter2"}}`
+		events, _ := serve(t, httptest.NewRequest(http.MethodPost, "/api/teams", strings.NewReader(body)), true)
+
+		require.Len(t, events, 1)
+		assert.JSONEq(t, `{"name": "team", "secureJsonData": "[REDACTED]"}`, string(events[0].Payload))
+		assert.Nil(t, events[0].Diff)
+	})
+
+	t.Run("leaves out payloads over the limit", func(t *testing.T) {
+		body := `{"name": "` + strings.Repeat("a", 2048) + `"}`
+		events, handlerBody := serve(t, httptest.NewRequest(http.MethodPost, "/api/teams", strings.NewReader(body)), true)
+
+		assert.Equal(t, body, handlerBody)
+		require.Len(t, events, 1)
+		assert.Nil(t, events[0].Payload)
+	})
+
+	t.Run("ignores reads and unaudited routes", func(t *testing.T) {
+		events, _ := serve(t, httptest.NewRequest(http.MethodGet, "/api/dashboards/uid/abc", nil), true)
+		assert.Empty(t, events)
+
+		events, _ = serve(t, httptest.NewRequest(http.MethodPost, "/api/search", strings.NewReader("{}")), true)
+		assert.Empty(t, events)
+	})
+
+	t.Run("ignores anonymous requests", func(t *testing.T) {
+		events, _ := serve(t, httptest.NewRequest(http.MethodDelete, "/api/dashboards/uid/abc", nil), false)
+		assert.Empty(t, events)
+	})
+}
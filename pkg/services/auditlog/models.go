@@ -0,0 +1,82 @@
+package auditlog
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Source is the part of Grafana a change was made through.
+type Source string
+
+const (
+	// SourceAPI is the HTTP API served under /api.
+	SourceAPI Source = "api"
+	// SourceProvisioning is the alerting provisioning, either through its API or from files.
+	SourceProvisioning Source = "provisioning"
+	// SourceAPIServer is the Kubernetes-style API served under /apis.
+	SourceAPIServer Source = "apiserver"
+)
+
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// Event is a change of the configuration of Grafana.
+type Event struct {
+	ID      int64     `json:"id,omitempty"`
+	OrgID   int64     `json:"orgId"`
+	Created time.Time `json:"created"`
+	Source  Source    `json:"source"`
+	Action  Action    `json:"action"`
+
+	ResourceKind string `json:"resourceKind"`
+	ResourceUID  string `json:"resourceUid,omitempty"`
+
+	ActorUID   string `json:"actorUid,omitempty"`
+	ActorLogin string `json:"actorLogin,omitempty"`
+	IPAddress  string `json:"ipAddress,omitempty"`
+	UserAgent  string `json:"userAgent,omitempty"`
+
+	// Method, Path and Status describe the request that made the change, if any.
+	Method string `json:"method,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Status int    `json:"status,omitempty"`
+
+	// Payload is the request body or the resource that was created or deleted, with secrets redacted.
+	Payload json.RawMessage `json:"payload,omitempty"`
+	// Diff are the changes of an updated resource, with secrets redacted.
+	Diff []Change `json:"diff,omitempty"`
+}
+
+// Change is the change of a single field of a resource, identified by its path in the JSON representation of the resource.
+type Change struct {
+	Path string `json:"path"`
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+}
+
+type SearchQuery struct {
+	// OrgID filters events by organization, 0 matches all organizations.
+	OrgID        int64
+	Source       Source
+	Action       Action
+	ResourceKind string
+	ResourceUID  string
+	// Actor matches either the login or the UID of the actor.
+	Actor string
+	From  time.Time
+	To    time.Time
+	Page  int
+	Limit int
+}
+
+type SearchResult struct {
+	TotalCount int64    `json:"totalCount"`
+	Events     []*Event `json:"events"`
+	Page       int      `json:"page"`
+	PerPage    int      `json:"perPage"`
+}
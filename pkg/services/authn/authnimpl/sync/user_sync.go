@@ -82,10 +82,9 @@ var (
 func ProvideUserSync(userService user.Service, userProtectionService login.UserProtectionService, authInfoService login.AuthInfoService,
 	quotaService quota.Service, tracer tracing.Tracer, features featuremgmt.FeatureToggles, cfg *setting.Cfg,
 ) *UserSync {
-	scimSection := cfg.Raw.Section("auth.scim")
 	return &UserSync{
-		allowNonProvisionedUsers:  scimSection.Key("allow_non_provisioned_users").MustBool(false),
-		isUserProvisioningEnabled: scimSection.Key("user_sync_enabled").MustBool(false),
+		allowNonProvisionedUsers:  cfg.SCIM.AllowNonProvisionedUsers,
+		isUserProvisioningEnabled: cfg.SCIM.UserSyncEnabled,
 		userService:               userService,
 		authInfoService:           authInfoService,
 		userProtectionService:     userProtectionService,
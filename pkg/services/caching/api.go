@@ -0,0 +1,37 @@
+package caching
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *OSSCachingService) registerAPIEndpoints(routeRegister routing.RouteRegister, accessControl ac.AccessControl) {
+	authorize := ac.Middleware(accessControl)
+	uidScope := datasources.ScopeProvider.GetResourceScopeUID(ac.Parameter(":uid"))
+
+	routeRegister.Post("/api/datasources/uid/:uid/cache/clean",
+		authorize(ac.EvalPermission(datasources.ActionWrite, uidScope)), routing.Wrap(s.handleCleanDataSourceCache))
+}
+
+// swagger:route POST /datasources/uid/{uid}/cache/clean datasources cleanDataSourceCache
+//
+// Drop all cached query and resource responses of a data source.
+//
+// Responses:
+// 200: okResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (s *OSSCachingService) handleCleanDataSourceCache(c *contextmodel.ReqContext) response.Response {
+	uid := web.Params(c.Req)[":uid"]
+	if err := s.InvalidateDataSource(c.Req.Context(), c.GetOrgID(), uid); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to clean data source cache", err)
+	}
+	return response.Success("Data source cache cleaned")
+}
@@ -0,0 +1,176 @@
+package caching
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+)
+
+const (
+	keyPrefix = "query-cache"
+
+	// maxTTL bounds every cache entry, which also bounds how long a data source generation must be kept.
+	maxTTL = 24 * time.Hour
+)
+
+// volatileQueryFields are query model fields that change between otherwise identical requests
+// or do not affect the response, so they are left out of the cache key.
+var volatileQueryFields = []string{"requestId", "key", "queryCachingTTL", "datasourceId", "hide"}
+
+type queryKeyData struct {
+	Generation    string            `json:"generation,omitempty"`
+	PluginID      string            `json:"pluginId"`
+	DataSourceUID string            `json:"datasourceUid"`
+	Updated       time.Time         `json:"updated"`
+	User          string            `json:"user,omitempty"`
+	Queries       []queryKeyElement `json:"queries"`
+}
+
+type queryKeyElement struct {
+	RefID         string         `json:"refId"`
+	QueryType     string         `json:"queryType,omitempty"`
+	MaxDataPoints int64          `json:"maxDataPoints"`
+	Interval      time.Duration  `json:"interval"`
+	From          time.Time      `json:"from"`
+	To            time.Time      `json:"to"`
+	Model         map[string]any `json:"model"`
+}
+
+type resourceKeyData struct {
+	Generation    string    `json:"generation,omitempty"`
+	PluginID      string    `json:"pluginId"`
+	DataSourceUID string    `json:"datasourceUid"`
+	Updated       time.Time `json:"updated"`
+	User          string    `json:"user,omitempty"`
+	Path          string    `json:"path"`
+	URL           string    `json:"url"`
+}
+
+// queryKey builds the cache key of a query request. The exact time range is part of the key: aligning it
+// would let requests for different ranges share an entry and return the data of another range.
+func (s *OSSCachingService) queryKey(ctx context.Context, req *backend.QueryDataRequest) (string, error) {
+	pCtx := req.PluginContext
+	ds := pCtx.DataSourceInstanceSettings
+
+	gen, err := s.generation(ctx, pCtx.OrgID, ds.UID)
+	if err != nil {
+		return "", err
+	}
+
+	data := queryKeyData{
+		Generation:    gen,
+		PluginID:      pCtx.PluginID,
+		DataSourceUID: ds.UID,
+		Updated:       ds.Updated.UTC(),
+		User:          identityKey(pCtx, req.GetHTTPHeader),
+		Queries:       make([]queryKeyElement, 0, len(req.Queries)),
+	}
+
+	for _, q := range req.Queries {
+		model := map[string]any{}
+		if len(q.JSON) > 0 {
+			if err := json.Unmarshal(q.JSON, &model); err != nil {
+				return "", fmt.Errorf("failed to parse query %s: %w", q.RefID, err)
+			}
+		}
+		for _, f := range volatileQueryFields {
+			delete(model, f)
+		}
+
+		data.Queries = append(data.Queries, queryKeyElement{
+			RefID:         q.RefID,
+			QueryType:     q.QueryType,
+			MaxDataPoints: q.MaxDataPoints,
+			Interval:      q.Interval,
+			From:          q.TimeRange.From.UTC(),
+			To:            q.TimeRange.To.UTC(),
+			Model:         model,
+		})
+	}
+
+	return hashKey(pCtx.OrgID, ds.UID, data)
+}
+
+// resourceKey builds the cache key of a resource request of a data source. Resource responses are per user, unless
+// the data source is configured to share them between the users of the organization.
+func (s *OSSCachingService) resourceKey(ctx context.Context, req *backend.CallResourceRequest) (string, error) {
+	pCtx := req.PluginContext
+	ds := pCtx.DataSourceInstanceSettings
+
+	gen, err := s.generation(ctx, pCtx.OrgID, ds.UID)
+	if err != nil {
+		return "", err
+	}
+
+	data := resourceKeyData{
+		Generation:    gen,
+		PluginID:      pCtx.PluginID,
+		DataSourceUID: ds.UID,
+		Updated:       ds.Updated.UTC(),
+		Path:          req.Path,
+		URL:           req.URL,
+	}
+	if s.settings.SharedResourceDataSources[ds.UID] {
+		// Responses still differ per user when the user credentials are forwarded to the data source.
+		data.User = identityKey(pCtx, req.GetHTTPHeader)
+	} else {
+		data.User = userKey(pCtx)
+	}
+
+	return hashKey(pCtx.OrgID, ds.UID, data)
+}
+
+// generation returns the current cache generation of a data source, which is bumped on invalidation.
+func (s *OSSCachingService) generation(ctx context.Context, orgID int64, dsUID string) (string, error) {
+	gen, err := s.cache.Get(ctx, generationKey(orgID, dsUID))
+	if err != nil {
+		if errors.Is(err, remotecache.ErrCacheItemNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	return string(gen), nil
+}
+
+// identityKey returns the user login when the request forwards user credentials to the data source,
+// in which case responses may differ per user and must not be shared.
+func identityKey(pCtx backend.PluginContext, header func(string) string) string {
+	if pCtx.User == nil {
+		return ""
+	}
+	for _, h := range []string{backend.OAuthIdentityTokenHeaderName, backend.OAuthIdentityIDTokenHeaderName, backend.CookiesHeaderName} {
+		if header(h) != "" {
+			return pCtx.User.Login
+		}
+	}
+	return ""
+}
+
+// userKey returns the user login, or an empty string for requests that are not made by a user.
+func userKey(pCtx backend.PluginContext) string {
+	if pCtx.User == nil {
+		return ""
+	}
+	return pCtx.User.Login
+}
+
+func generationKey(orgID int64, dsUID string) string {
+	return fmt.Sprintf("%s:gen:%d:%s", keyPrefix, orgID, dsUID)
+}
+
+func hashKey(orgID int64, dsUID string, data any) (string, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return fmt.Sprintf("%s:%d:%s:%s", keyPrefix, orgID, dsUID, hex.EncodeToString(sum[:])), nil
+}
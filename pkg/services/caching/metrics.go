@@ -0,0 +1,36 @@
+package caching
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/grafana/pkg/infra/metrics"
+)
+
+const (
+	kindQuery    = "query"
+	kindResource = "resource"
+)
+
+type cacheMetrics struct {
+	requests    *prometheus.CounterVec
+	writeErrors prometheus.Counter
+}
+
+func newMetrics(reg prometheus.Registerer) *cacheMetrics {
+	return &cacheMetrics{
+		// The hit ratio is requests_total{status="HIT"} / sum(requests_total{status=~"HIT|MISS"}).
+		requests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.ExporterName,
+			Subsystem: "caching",
+			Name:      "requests_total",
+			Help:      "Total number of query and resource cache lookups by cache status",
+		}, []string{"kind", "status"}),
+		writeErrors: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Namespace: metrics.ExporterName,
+			Subsystem: "caching",
+			Name:      "write_errors_total",
+			Help:      "Total number of failed writes to the query cache",
+		}),
+	}
+}
@@ -2,8 +2,20 @@ package caching
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 const (
@@ -13,6 +25,9 @@ const (
 	StatusBypass   = "BYPASS"
 	StatusError    = "ERROR"
 	StatusDisabled = "DISABLED"
+
+	// XCacheSkipHeader is sent by the frontend when a data source asks to skip the query cache.
+	XCacheSkipHeader = "X-Cache-Skip"
 )
 
 type CacheQueryResponseFn func(context.Context, *backend.QueryDataResponse)
@@ -33,10 +48,27 @@ type CachedResourceDataResponse struct {
 	// It can be set to nil by the method implementation (if there is an error, for example), so it should be checked before being called.
 	// Because plugins can send multiple responses asynchronously, the implementation should be able to handle multiple calls to this function for one request.
 	UpdateCacheFn CacheResourceResponseFn
+	// A function that should be called once the plugin has sent its last response without error, to write the responses
+	// passed to UpdateCacheFn to the cache. It can be nil, in which case UpdateCacheFn writes to the cache itself.
+	FlushCacheFn func(context.Context)
+}
+
+func ProvideCachingService(cfg *setting.Cfg, cache remotecache.CacheStorage, routeRegister routing.RouteRegister,
+	accessControl ac.AccessControl, reg prometheus.Registerer) *OSSCachingService {
+	s := newService(cfg.QueryCaching, cache, reg)
+	if s.settings.Enabled {
+		s.registerAPIEndpoints(routeRegister, accessControl)
+	}
+	return s
 }
 
-func ProvideCachingService() *OSSCachingService {
-	return &OSSCachingService{}
+func newService(settings setting.QueryCachingSettings, cache remotecache.CacheStorage, reg prometheus.Registerer) *OSSCachingService {
+	return &OSSCachingService{
+		settings: settings,
+		cache:    cache,
+		metrics:  newMetrics(reg),
+		log:      log.New("query-caching"),
+	}
 }
 
 type CachingService interface {
@@ -49,16 +81,225 @@ type CachingService interface {
 	HandleResourceRequest(context.Context, *backend.CallResourceRequest) (bool, CachedResourceDataResponse)
 }
 
-// Implementation of interface - does nothing
+// OSSCachingService caches query and resource responses in the remote cache.
+// The zero value is valid and never caches anything.
 type OSSCachingService struct {
+	settings setting.QueryCachingSettings
+	cache    remotecache.CacheStorage
+	metrics  *cacheMetrics
+	log      log.Logger
+}
+
+func (s *OSSCachingService) enabled() bool {
+	return s.settings.Enabled && s.cache != nil
 }
 
 func (s *OSSCachingService) HandleQueryRequest(ctx context.Context, req *backend.QueryDataRequest) (bool, CachedQueryDataResponse) {
-	return false, CachedQueryDataResponse{}
+	if !s.enabled() || req == nil || req.PluginContext.DataSourceInstanceSettings == nil {
+		return false, CachedQueryDataResponse{}
+	}
+
+	ttl := s.queryTTL(req)
+	if ttl <= 0 || skipRequested(ctx) {
+		s.setStatus(ctx, kindQuery, StatusBypass)
+		return false, CachedQueryDataResponse{}
+	}
+
+	key, err := s.queryKey(ctx, req)
+	if err != nil {
+		s.log.FromContext(ctx).Warn("Failed to compute query cache key", "error", err)
+		s.setStatus(ctx, kindQuery, StatusError)
+		return false, CachedQueryDataResponse{}
+	}
+
+	b, err := s.cache.Get(ctx, key)
+	switch {
+	case err == nil:
+		resp := &backend.QueryDataResponse{}
+		if err := json.Unmarshal(b, resp); err == nil {
+			s.setStatus(ctx, kindQuery, StatusHit)
+			return true, CachedQueryDataResponse{Response: resp}
+		}
+		// A broken entry is treated as a miss and overwritten with a fresh response.
+		s.log.FromContext(ctx).Warn("Failed to decode cached query response", "error", err)
+	case !errors.Is(err, remotecache.ErrCacheItemNotFound):
+		s.log.FromContext(ctx).Warn("Failed to read query response from cache", "error", err)
+		s.setStatus(ctx, kindQuery, StatusError)
+		return false, CachedQueryDataResponse{}
+	}
+
+	s.setStatus(ctx, kindQuery, StatusMiss)
+	return false, CachedQueryDataResponse{
+		UpdateCacheFn: func(ctx context.Context, resp *backend.QueryDataResponse) {
+			if resp == nil || !cacheableQueryResponse(resp) {
+				return
+			}
+			b, err := json.Marshal(resp)
+			if err != nil {
+				s.log.FromContext(ctx).Warn("Failed to encode query response for cache", "error", err)
+				return
+			}
+			s.store(ctx, key, b, ttl)
+		},
+	}
 }
 
 func (s *OSSCachingService) HandleResourceRequest(ctx context.Context, req *backend.CallResourceRequest) (bool, CachedResourceDataResponse) {
-	return false, CachedResourceDataResponse{}
+	// Only data source resources are cached, as app plugin resources cannot be invalidated and are often per user.
+	if !s.enabled() || req == nil || req.PluginContext.DataSourceInstanceSettings == nil {
+		return false, CachedResourceDataResponse{}
+	}
+
+	ttl := s.settings.ResourcesTTL
+	if dsTTL, ok := s.settings.DataSourceTTLs[req.PluginContext.DataSourceInstanceSettings.UID]; ok && dsTTL <= 0 {
+		ttl = 0
+	}
+	if req.Method != http.MethodGet || ttl <= 0 || skipRequested(ctx) {
+		s.setStatus(ctx, kindResource, StatusBypass)
+		return false, CachedResourceDataResponse{}
+	}
+
+	key, err := s.resourceKey(ctx, req)
+	if err != nil {
+		s.log.FromContext(ctx).Warn("Failed to compute resource cache key", "error", err)
+		s.setStatus(ctx, kindResource, StatusError)
+		return false, CachedResourceDataResponse{}
+	}
+
+	b, err := s.cache.Get(ctx, key)
+	switch {
+	case err == nil:
+		resp := &backend.CallResourceResponse{}
+		if err := json.Unmarshal(b, resp); err == nil {
+			s.setStatus(ctx, kindResource, StatusHit)
+			return true, CachedResourceDataResponse{Response: resp}
+		}
+		s.log.FromContext(ctx).Warn("Failed to decode cached resource response", "error", err)
+	case !errors.Is(err, remotecache.ErrCacheItemNotFound):
+		s.log.FromContext(ctx).Warn("Failed to read resource response from cache", "error", err)
+		s.setStatus(ctx, kindResource, StatusError)
+		return false, CachedResourceDataResponse{}
+	}
+
+	s.setStatus(ctx, kindResource, StatusMiss)
+
+	// Plugins may stream a response in several parts. The first part carries the status and headers,
+	// following parts are appended to the body, and the merged response is written once the stream ends.
+	var merged *backend.CallResourceResponse
+	var uncacheable bool
+	return false, CachedResourceDataResponse{
+		UpdateCacheFn: func(ctx context.Context, resp *backend.CallResourceResponse) {
+			if resp == nil || uncacheable {
+				return
+			}
+			if merged == nil {
+				if resp.Status < http.StatusOK || resp.Status >= http.StatusMultipleChoices {
+					uncacheable = true
+					return
+				}
+				merged = &backend.CallResourceResponse{
+					Status:  resp.Status,
+					Headers: resp.Headers,
+					Body:    append([]byte{}, resp.Body...),
+				}
+			} else {
+				merged.Body = append(merged.Body, resp.Body...)
+			}
+			// Stop buffering responses that are too large to be cached anyway.
+			if s.settings.MaxValueSize > 0 && len(merged.Body) > s.settings.MaxValueSize {
+				uncacheable = true
+				merged = nil
+			}
+		},
+		FlushCacheFn: func(ctx context.Context) {
+			if merged == nil || uncacheable {
+				return
+			}
+			b, err := json.Marshal(merged)
+			if err != nil {
+				s.log.FromContext(ctx).Warn("Failed to encode resource response for cache", "error", err)
+				return
+			}
+			s.store(ctx, key, b, ttl)
+		},
+	}
+}
+
+// InvalidateDataSource drops every cached query and resource response of a data source.
+func (s *OSSCachingService) InvalidateDataSource(ctx context.Context, orgID int64, dsUID string) error {
+	if !s.enabled() {
+		return nil
+	}
+	// Entries are keyed by a per data source generation, so bumping it orphans all existing entries
+	// which then expire on their own.
+	gen := []byte(time.Now().UTC().Format(time.RFC3339Nano))
+	return s.cache.Set(ctx, generationKey(orgID, dsUID), gen, maxTTL)
+}
+
+func (s *OSSCachingService) store(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	if s.settings.MaxValueSize > 0 && len(value) > s.settings.MaxValueSize {
+		s.log.FromContext(ctx).Debug("Response too large to cache", "size", len(value), "limit", s.settings.MaxValueSize)
+		return
+	}
+	if err := s.cache.Set(ctx, key, value, ttl); err != nil {
+		s.log.FromContext(ctx).Warn("Failed to write response to cache", "error", err)
+		s.metrics.writeErrors.Inc()
+	}
+}
+
+// queryTTL returns the TTL of a query request. A panel level TTL sent with the queries takes
+// precedence over the data source TTL, which takes precedence over the default TTL.
+func (s *OSSCachingService) queryTTL(req *backend.QueryDataRequest) time.Duration {
+	ttl := s.settings.TTL
+	if dsTTL, ok := s.settings.DataSourceTTLs[req.PluginContext.DataSourceInstanceSettings.UID]; ok {
+		if dsTTL <= 0 {
+			return 0
+		}
+		ttl = dsTTL
+	}
+
+	for _, q := range req.Queries {
+		var opts struct {
+			QueryCachingTTL int64 `json:"queryCachingTTL"`
+		}
+		if err := json.Unmarshal(q.JSON, &opts); err == nil && opts.QueryCachingTTL > 0 {
+			ttl = time.Duration(opts.QueryCachingTTL) * time.Millisecond
+			break
+		}
+	}
+
+	if ttl > maxTTL {
+		ttl = maxTTL
+	}
+	return ttl
+}
+
+// cacheableQueryResponse reports whether every response in the request succeeded.
+func cacheableQueryResponse(resp *backend.QueryDataResponse) bool {
+	for _, r := range resp.Responses {
+		if r.Error != nil || r.Status >= http.StatusBadRequest {
+			return false
+		}
+	}
+	return true
+}
+
+func skipRequested(ctx context.Context) bool {
+	reqCtx := contexthandler.FromContext(ctx)
+	if reqCtx == nil || reqCtx.Req == nil {
+		return false
+	}
+	return reqCtx.Req.Header.Get(XCacheSkipHeader) != ""
+}
+
+func (s *OSSCachingService) setStatus(ctx context.Context, kind, status string) {
+	s.metrics.requests.WithLabelValues(kind, status).Inc()
+
+	reqCtx := contexthandler.FromContext(ctx)
+	if reqCtx == nil || reqCtx.Resp == nil {
+		return
+	}
+	reqCtx.Resp.Header().Set(XCacheHeader, status)
 }
 
 var _ CachingService = &OSSCachingService{}
@@ -0,0 +1,339 @@
+package caching
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/services/contexthandler/ctxkey"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func newTestService(t *testing.T, settings setting.QueryCachingSettings) (*OSSCachingService, remotecache.FakeCacheStorage) {
+	t.Helper()
+	cache := remotecache.NewFakeCacheStorage()
+	return newService(settings, cache, prometheus.NewRegistry()), cache
+}
+
+func newTestContext(t *testing.T, header http.Header) (context.Context, *contextmodel.ReqContext) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/ds/query", nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	reqCtx := &contextmodel.ReqContext{
+		Context: &web.Context{
+			Req:  req,
+			Resp: web.NewResponseWriter(req.Method, httptest.NewRecorder()),
+		},
+	}
+	return ctxkey.Set(context.Background(), reqCtx), reqCtx
+}
+
+func newQueryRequest(from time.Time, model string) *backend.QueryDataRequest {
+	return &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			OrgID:    1,
+			PluginID: "prometheus",
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+				UID:     "ds-uid",
+				Updated: time.Unix(100, 0),
+			},
+		},
+		Queries: []backend.DataQuery{{
+			RefID:     "A",
+			TimeRange: backend.TimeRange{From: from, To: from.Add(time.Hour)},
+			JSON:      json.RawMessage(model),
+		}},
+	}
+}
+
+func newQueryResponse() *backend.QueryDataResponse {
+	return &backend.QueryDataResponse{Responses: backend.Responses{
+		"A": {Frames: data.Frames{data.NewFrame("A", data.NewField("value", nil, []float64{1, 2, 3}))}},
+	}}
+}
+
+func TestHandleQueryRequest(t *testing.T) {
+	settings := setting.QueryCachingSettings{Enabled: true, TTL: time.Minute}
+	from := time.Date(2025, 1, 1, 10, 0, 10, 0, time.UTC)
+
+	t.Run("does nothing when disabled", func(t *testing.T) {
+		s, _ := newTestService(t, setting.QueryCachingSettings{})
+		ctx, reqCtx := newTestContext(t, nil)
+
+		hit, cr := s.HandleQueryRequest(ctx, newQueryRequest(from, `{"expr":"up"}`))
+		assert.False(t, hit)
+		assert.Nil(t, cr.UpdateCacheFn)
+		assert.Empty(t, reqCtx.Resp.Header().Get(XCacheHeader))
+	})
+
+	t.Run("zero value service does nothing", func(t *testing.T) {
+		ctx, _ := newTestContext(t, nil)
+		hit, cr := (&OSSCachingService{}).HandleQueryRequest(ctx, newQueryRequest(from, `{}`))
+		assert.False(t, hit)
+		assert.Nil(t, cr.UpdateCacheFn)
+	})
+
+	t.Run("miss then hit", func(t *testing.T) {
+		s, _ := newTestService(t, settings)
+
+		ctx, reqCtx := newTestContext(t, nil)
+		hit, cr := s.HandleQueryRequest(ctx, newQueryRequest(from, `{"expr":"up","requestId":"1"}`))
+		require.False(t, hit)
+		require.NotNil(t, cr.UpdateCacheFn)
+		assert.Equal(t, StatusMiss, reqCtx.Resp.Header().Get(XCacheHeader))
+		cr.UpdateCacheFn(ctx, newQueryResponse())
+
+		// Same query with a different request ID is served from the cache.
+		ctx, reqCtx = newTestContext(t, nil)
+		hit, cr = s.HandleQueryRequest(ctx, newQueryRequest(from, `{"requestId":"2","expr":"up"}`))
+		require.True(t, hit)
+		assert.Equal(t, StatusHit, reqCtx.Resp.Header().Get(XCacheHeader))
+		require.NotNil(t, cr.Response)
+		assert.Equal(t, 3, cr.Response.Responses["A"].Frames[0].Rows())
+
+		assert.Equal(t, 1.0, testutil.ToFloat64(s.metrics.requests.WithLabelValues(kindQuery, StatusHit)))
+		assert.Equal(t, 1.0, testutil.ToFloat64(s.metrics.requests.WithLabelValues(kindQuery, StatusMiss)))
+	})
+
+	t.Run("different query model is a miss", func(t *testing.T) {
+		s, _ := newTestService(t, settings)
+		ctx, _ := newTestContext(t, nil)
+		_, cr := s.HandleQueryRequest(ctx, newQueryRequest(from, `{"expr":"up"}`))
+		cr.UpdateCacheFn(ctx, newQueryResponse())
+
+		hit, _ := s.HandleQueryRequest(ctx, newQueryRequest(from, `{"expr":"down"}`))
+		assert.False(t, hit)
+	})
+
+	t.Run("different time range is a miss", func(t *testing.T) {
+		s, _ := newTestService(t, setting.QueryCachingSettings{Enabled: true, TTL: time.Hour})
+		ctx, _ := newTestContext(t, nil)
+		_, cr := s.HandleQueryRequest(ctx, newQueryRequest(time.Date(2025, 1, 1, 10, 5, 0, 0, time.UTC), `{"expr":"up"}`))
+		cr.UpdateCacheFn(ctx, newQueryResponse())
+
+		// Both ranges start within the same hour, which must not make them share the entry.
+		ctx, reqCtx := newTestContext(t, nil)
+		hit, _ := s.HandleQueryRequest(ctx, newQueryRequest(time.Date(2025, 1, 1, 10, 55, 0, 0, time.UTC), `{"expr":"up"}`))
+		assert.False(t, hit)
+		assert.Equal(t, StatusMiss, reqCtx.Resp.Header().Get(XCacheHeader))
+	})
+
+	t.Run("updated data source is a miss", func(t *testing.T) {
+		s, _ := newTestService(t, settings)
+		ctx, _ := newTestContext(t, nil)
+		_, cr := s.HandleQueryRequest(ctx, newQueryRequest(from, `{"expr":"up"}`))
+		cr.UpdateCacheFn(ctx, newQueryResponse())
+
+		req := newQueryRequest(from, `{"expr":"up"}`)
+		req.PluginContext.DataSourceInstanceSettings.Updated = time.Unix(200, 0)
+		hit, _ := s.HandleQueryRequest(ctx, req)
+		assert.False(t, hit)
+	})
+
+	t.Run("error responses are not cached", func(t *testing.T) {
+		s, cache := newTestService(t, settings)
+		ctx, _ := newTestContext(t, nil)
+		_, cr := s.HandleQueryRequest(ctx, newQueryRequest(from, `{"expr":"up"}`))
+		cr.UpdateCacheFn(ctx, &backend.QueryDataResponse{Responses: backend.Responses{
+			"A": backend.ErrDataResponse(backend.StatusBadRequest, "bad query"),
+		}})
+		assert.Empty(t, cache.Storage)
+	})
+
+	t.Run("responses above the size limit are not cached", func(t *testing.T) {
+		s, cache := newTestService(t, setting.QueryCachingSettings{Enabled: true, TTL: time.Minute, MaxValueSize: 10})
+		ctx, _ := newTestContext(t, nil)
+		_, cr := s.HandleQueryRequest(ctx, newQueryRequest(from, `{"expr":"up"}`))
+		cr.UpdateCacheFn(ctx, newQueryResponse())
+		assert.Empty(t, cache.Storage)
+	})
+
+	t.Run("bypass", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			settings setting.QueryCachingSettings
+			header   http.Header
+		}{
+			{
+				name:     "skip header",
+				settings: settings,
+				header:   http.Header{XCacheSkipHeader: []string{"true"}},
+			},
+			{
+				name:     "data source TTL of zero",
+				settings: setting.QueryCachingSettings{Enabled: true, TTL: time.Minute, DataSourceTTLs: map[string]time.Duration{"ds-uid": 0}},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				s, _ := newTestService(t, tt.settings)
+				ctx, reqCtx := newTestContext(t, tt.header)
+				hit, cr := s.HandleQueryRequest(ctx, newQueryRequest(from, `{"expr":"up"}`))
+				assert.False(t, hit)
+				assert.Nil(t, cr.UpdateCacheFn)
+				assert.Equal(t, StatusBypass, reqCtx.Resp.Header().Get(XCacheHeader))
+			})
+		}
+	})
+
+	t.Run("invalidation", func(t *testing.T) {
+		s, _ := newTestService(t, settings)
+		ctx, _ := newTestContext(t, nil)
+		_, cr := s.HandleQueryRequest(ctx, newQueryRequest(from, `{"expr":"up"}`))
+		cr.UpdateCacheFn(ctx, newQueryResponse())
+
+		require.NoError(t, s.InvalidateDataSource(ctx, 1, "ds-uid"))
+
+		hit, _ := s.HandleQueryRequest(ctx, newQueryRequest(from, `{"expr":"up"}`))
+		assert.False(t, hit)
+	})
+}
+
+func TestQueryTTL(t *testing.T) {
+	s, _ := newTestService(t, setting.QueryCachingSettings{
+		Enabled:        true,
+		TTL:            time.Minute,
+		DataSourceTTLs: map[string]time.Duration{"ds-uid": 10 * time.Minute},
+	})
+	from := time.Now()
+
+	req := newQueryRequest(from, `{}`)
+	assert.Equal(t, 10*time.Minute, s.queryTTL(req))
+
+	req.PluginContext.DataSourceInstanceSettings.UID = "other"
+	assert.Equal(t, time.Minute, s.queryTTL(req))
+
+	req = newQueryRequest(from, `{"queryCachingTTL":30000}`)
+	assert.Equal(t, 30*time.Second, s.queryTTL(req))
+
+	req = newQueryRequest(from, `{"queryCachingTTL":172800000}`)
+	assert.Equal(t, maxTTL, s.queryTTL(req))
+}
+
+func TestHandleResourceRequest(t *testing.T) {
+	settings := setting.QueryCachingSettings{Enabled: true, ResourcesTTL: time.Minute}
+	newRequest := func(method string) *backend.CallResourceRequest {
+		return &backend.CallResourceRequest{
+			PluginContext: backend.PluginContext{
+				OrgID:                      1,
+				PluginID:                   "prometheus",
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "ds-uid"},
+			},
+			Method: method,
+			Path:   "api/v1/labels",
+			URL:    "api/v1/labels?match=up",
+		}
+	}
+
+	t.Run("multi part responses are merged", func(t *testing.T) {
+		s, cache := newTestService(t, settings)
+		ctx, reqCtx := newTestContext(t, nil)
+		hit, cr := s.HandleResourceRequest(ctx, newRequest(http.MethodGet))
+		require.False(t, hit)
+		assert.Equal(t, StatusMiss, reqCtx.Resp.Header().Get(XCacheHeader))
+		cr.UpdateCacheFn(ctx, &backend.CallResourceResponse{Status: http.StatusOK, Body: []byte("hello ")})
+		cr.UpdateCacheFn(ctx, &backend.CallResourceResponse{Body: []byte("world")})
+		assert.Empty(t, cache.Storage, "the response is written once the stream ends")
+		cr.FlushCacheFn(ctx)
+
+		ctx, reqCtx = newTestContext(t, nil)
+		hit, cr = s.HandleResourceRequest(ctx, newRequest(http.MethodGet))
+		require.True(t, hit)
+		assert.Equal(t, StatusHit, reqCtx.Resp.Header().Get(XCacheHeader))
+		assert.Equal(t, http.StatusOK, cr.Response.Status)
+		assert.Equal(t, "hello world", string(cr.Response.Body))
+	})
+
+	t.Run("failed responses are not cached", func(t *testing.T) {
+		s, cache := newTestService(t, settings)
+		ctx, _ := newTestContext(t, nil)
+		_, cr := s.HandleResourceRequest(ctx, newRequest(http.MethodGet))
+		cr.UpdateCacheFn(ctx, &backend.CallResourceResponse{Status: http.StatusInternalServerError})
+		cr.UpdateCacheFn(ctx, &backend.CallResourceResponse{Body: []byte("boom")})
+		cr.FlushCacheFn(ctx)
+		assert.Empty(t, cache.Storage)
+	})
+
+	t.Run("app plugin resources bypass the cache", func(t *testing.T) {
+		s, _ := newTestService(t, settings)
+		ctx, _ := newTestContext(t, nil)
+		req := newRequest(http.MethodGet)
+		req.PluginContext.DataSourceInstanceSettings = nil
+		hit, cr := s.HandleResourceRequest(ctx, req)
+		assert.False(t, hit)
+		assert.Nil(t, cr.UpdateCacheFn)
+	})
+
+	t.Run("non GET requests bypass the cache", func(t *testing.T) {
+		s, _ := newTestService(t, settings)
+		ctx, reqCtx := newTestContext(t, nil)
+		hit, cr := s.HandleResourceRequest(ctx, newRequest(http.MethodPost))
+		assert.False(t, hit)
+		assert.Nil(t, cr.UpdateCacheFn)
+		assert.Equal(t, StatusBypass, reqCtx.Resp.Header().Get(XCacheHeader))
+	})
+
+	cacheFor := func(s *OSSCachingService, ctx context.Context, login, token string) {
+		req := newRequest(http.MethodGet)
+		req.PluginContext.User = &backend.User{Login: login}
+		if token != "" {
+			req.SetHTTPHeader(backend.OAuthIdentityTokenHeaderName, token)
+		}
+		_, cr := s.HandleResourceRequest(ctx, req)
+		cr.UpdateCacheFn(ctx, &backend.CallResourceResponse{Status: http.StatusOK, Body: []byte(login)})
+		cr.FlushCacheFn(ctx)
+	}
+	hitFor := func(s *OSSCachingService, ctx context.Context, login, token string) bool {
+		req := newRequest(http.MethodGet)
+		req.PluginContext.User = &backend.User{Login: login}
+		if token != "" {
+			req.SetHTTPHeader(backend.OAuthIdentityTokenHeaderName, token)
+		}
+		hit, _ := s.HandleResourceRequest(ctx, req)
+		return hit
+	}
+
+	t.Run("responses are cached per user", func(t *testing.T) {
+		s, _ := newTestService(t, settings)
+		ctx, _ := newTestContext(t, nil)
+
+		cacheFor(s, ctx, "alice", "")
+		assert.True(t, hitFor(s, ctx, "alice", ""))
+		assert.False(t, hitFor(s, ctx, "bob", ""))
+	})
+
+	t.Run("responses of shared data sources are cached for the organization", func(t *testing.T) {
+		shared := settings
+		shared.SharedResourceDataSources = map[string]bool{"ds-uid": true}
+		s, _ := newTestService(t, shared)
+		ctx, _ := newTestContext(t, nil)
+
+		cacheFor(s, ctx, "alice", "")
+		assert.True(t, hitFor(s, ctx, "bob", ""))
+	})
+
+	t.Run("responses of shared data sources are cached per user when credentials are forwarded", func(t *testing.T) {
+		shared := settings
+		shared.SharedResourceDataSources = map[string]bool{"ds-uid": true}
+		s, _ := newTestService(t, shared)
+		ctx, _ := newTestContext(t, nil)
+
+		cacheFor(s, ctx, "alice", "Bearer a")
+		assert.False(t, hitFor(s, ctx, "bob", "Bearer b"))
+	})
+}
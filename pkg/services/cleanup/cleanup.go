@@ -17,10 +17,13 @@ import (
 	"github.com/grafana/grafana/pkg/infra/serverlock"
 	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/auditlog"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/dashboardsnapshots"
 	dashver "github.com/grafana/grafana/pkg/services/dashboardversion"
+	"github.com/grafana/grafana/pkg/services/ngalert/backtesting"
 	"github.com/grafana/grafana/pkg/services/ngalert/image"
+	"github.com/grafana/grafana/pkg/services/ngalert/state/historian"
 	"github.com/grafana/grafana/pkg/services/queryhistory"
 	"github.com/grafana/grafana/pkg/services/shorturls"
 	tempuser "github.com/grafana/grafana/pkg/services/temp_user"
@@ -46,12 +49,16 @@ type CleanUpService struct {
 	annotationCleaner         annotations.Cleaner
 	dashboardService          dashboards.DashboardService
 	alertRuleService          AlertRuleService
+	auditLogService           auditlog.Service
+	stateHistoryService       *historian.DeleteExpiredSQLService
+	backtestService           *backtesting.DeleteExpiredService
 }
 
 func ProvideService(cfg *setting.Cfg, serverLockService *serverlock.ServerLockService,
 	shortURLService shorturls.Service, sqlstore db.DB, queryHistoryService queryhistory.Service,
 	dashboardVersionService dashver.Service, dashSnapSvc dashboardsnapshots.Service, deleteExpiredImageService *image.DeleteExpiredService,
-	tempUserService tempuser.Service, tracer tracing.Tracer, annotationCleaner annotations.Cleaner, dashboardService dashboards.DashboardService, service AlertRuleService) *CleanUpService {
+	tempUserService tempuser.Service, tracer tracing.Tracer, annotationCleaner annotations.Cleaner, dashboardService dashboards.DashboardService, service AlertRuleService,
+	auditLogService auditlog.Service, stateHistoryService *historian.DeleteExpiredSQLService, backtestService *backtesting.DeleteExpiredService) *CleanUpService {
 	s := &CleanUpService{
 		Cfg:                       cfg,
 		ServerLockService:         serverLockService,
@@ -67,6 +74,9 @@ func ProvideService(cfg *setting.Cfg, serverLockService *serverlock.ServerLockSe
 		annotationCleaner:         annotationCleaner,
 		dashboardService:          dashboardService,
 		alertRuleService:          service,
+		auditLogService:           auditLogService,
+		stateHistoryService:       stateHistoryService,
+		backtestService:           backtestService,
 	}
 	return s
 }
@@ -121,6 +131,18 @@ func (srv *CleanUpService) clean(ctx context.Context) {
 		cleanupJobs = append(cleanupJobs, cleanUpJob{"cleanup trash alert rules", srv.cleanUpTrashAlertRules})
 	}
 
+	if srv.Cfg.AuditLog.Enabled && srv.Cfg.AuditLog.Retention > 0 {
+		cleanupJobs = append(cleanupJobs, cleanUpJob{"delete expired audit log events", srv.deleteExpiredAuditLogEvents})
+	}
+
+	if srv.Cfg.UnifiedAlerting.IsEnabled() && srv.Cfg.UnifiedAlerting.StateHistory.SQLMaxAge > 0 {
+		cleanupJobs = append(cleanupJobs, cleanUpJob{"delete expired alert state history", srv.deleteExpiredStateHistory})
+	}
+
+	if srv.Cfg.UnifiedAlerting.IsEnabled() && srv.Cfg.UnifiedAlerting.BacktestRetention > 0 {
+		cleanupJobs = append(cleanupJobs, cleanUpJob{"delete expired alert rule backtests", srv.deleteExpiredBacktests})
+	}
+
 	logger := srv.log.FromContext(ctx)
 	logger.Debug("Starting cleanup jobs", "jobs", fmt.Sprintf("%v", cleanupJobs))
 
@@ -322,3 +344,33 @@ func (srv *CleanUpService) cleanUpTrashAlertRules(ctx context.Context) {
 		logger.Debug("Cleaned up deleted alert rules", "rows affected", affected)
 	}
 }
+
+func (srv *CleanUpService) deleteExpiredAuditLogEvents(ctx context.Context) {
+	logger := srv.log.FromContext(ctx)
+	affected, err := srv.auditLogService.DeleteExpired(ctx)
+	if err != nil {
+		logger.Error("Problem deleting expired audit log events", "error", err)
+	} else {
+		logger.Debug("Deleted expired audit log events", "rows affected", affected)
+	}
+}
+
+func (srv *CleanUpService) deleteExpiredStateHistory(ctx context.Context) {
+	logger := srv.log.FromContext(ctx)
+	affected, err := srv.stateHistoryService.DeleteExpired(ctx)
+	if err != nil {
+		logger.Error("Problem deleting expired alert state history", "error", err)
+	} else {
+		logger.Debug("Deleted expired alert state history", "rows affected", affected)
+	}
+}
+
+func (srv *CleanUpService) deleteExpiredBacktests(ctx context.Context) {
+	logger := srv.log.FromContext(ctx)
+	affected, err := srv.backtestService.DeleteExpired(ctx)
+	if err != nil {
+		logger.Error("Problem deleting expired alert rule backtests", "error", err)
+	} else {
+		logger.Debug("Deleted expired alert rule backtests", "rows affected", affected)
+	}
+}
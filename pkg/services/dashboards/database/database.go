@@ -914,7 +914,8 @@ func (d *dashboardStore) FindDashboards(ctx context.Context, query *dashboards.F
 
 	sql, params := sb.ToSQL(limit, page)
 
-	err = d.store.WithDbSession(ctx, func(sess *db.Session) error {
+	// Searches tolerate the replication lag of the read replicas.
+	err = d.store.WithReadReplica(ctx, func(sess *db.Session) error {
 		return sess.SQL(sql, params...).Find(&res)
 	})
 
@@ -930,7 +931,7 @@ func (d *dashboardStore) GetDashboardTags(ctx context.Context, query *dashboards
 	defer span.End()
 
 	queryResult := make([]*dashboards.DashboardTagCloudItem, 0)
-	err := d.store.WithDbSession(ctx, func(dbSession *db.Session) error {
+	err := d.store.WithReadReplica(ctx, func(dbSession *db.Session) error {
 		sql := `SELECT
 					  COUNT(*) as count,
 						term
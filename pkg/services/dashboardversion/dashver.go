@@ -8,4 +8,6 @@ type Service interface {
 	Get(context.Context, *GetDashboardVersionQuery) (*DashboardVersionDTO, error)
 	DeleteExpired(context.Context, *DeleteExpiredVersionsCommand) error
 	List(context.Context, *ListDashboardVersionsQuery) (*DashboardVersionResponse, error)
+	// Diff returns a structured JSON patch between two versions of a dashboard.
+	Diff(context.Context, *DiffDashboardVersionsQuery) (*DashboardVersionDiff, error)
 }
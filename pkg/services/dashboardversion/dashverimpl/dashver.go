@@ -13,6 +13,7 @@ import (
 
 	dashv0 "github.com/grafana/grafana/apps/dashboard/pkg/apis/dashboard/v0alpha1"
 	"github.com/grafana/grafana/pkg/apimachinery/utils"
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -186,6 +187,51 @@ func (s *Service) List(ctx context.Context, query *dashver.ListDashboardVersions
 	}, nil
 }
 
+// Diff compares two versions of a dashboard, or a version with the current
+// dashboard when no new version is given.
+func (s *Service) Diff(ctx context.Context, query *dashver.DiffDashboardVersionsQuery) (*dashver.DashboardVersionDiff, error) {
+	base, err := s.Get(ctx, &dashver.GetDashboardVersionQuery{
+		DashboardID:  query.DashboardID,
+		DashboardUID: query.DashboardUID,
+		OrgID:        query.OrgID,
+		Version:      query.BaseVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var newVersion int
+	var newData *simplejson.Json
+	if query.NewVersion == 0 {
+		dash, err := s.dashSvc.GetDashboard(ctx, &dashboards.GetDashboardQuery{UID: base.DashboardUID, OrgID: query.OrgID})
+		if err != nil {
+			return nil, err
+		}
+		newVersion, newData = dash.Version, dash.Data
+	} else {
+		next, err := s.Get(ctx, &dashver.GetDashboardVersionQuery{
+			DashboardID:  base.DashboardID,
+			DashboardUID: base.DashboardUID,
+			OrgID:        query.OrgID,
+			Version:      query.NewVersion,
+		})
+		if err != nil {
+			return nil, err
+		}
+		newVersion, newData = next.Version, next.Data
+	}
+
+	diff, err := dashdiffs.CalculateStructuredDiff(base.Data, newData)
+	if err != nil {
+		return nil, err
+	}
+	return &dashver.DashboardVersionDiff{
+		BaseVersion:    base.Version,
+		NewVersion:     newVersion,
+		StructuredDiff: *diff,
+	}, nil
+}
+
 // getDashUIDMaybeEmpty is a helper function which takes a dashboardID and
 // returns the UID. If the dashboard is not found, it will return an empty
 // string.
@@ -398,6 +444,17 @@ func DashboardRestoreMessage(version int) string {
 	return fmt.Sprintf("%s%d", restoreMsg, version)
 }
 
+// PanelsRestoreMessage is the message of a dashboard version which restores
+// some panels only. It is not a full restore, so it is not parsed back by
+// getRestoreVersion.
+func PanelsRestoreMessage(version int, panelIDs []int64) string {
+	ids := make([]string, len(panelIDs))
+	for i, id := range panelIDs {
+		ids[i] = strconv.FormatInt(id, 10)
+	}
+	return fmt.Sprintf("Restored panels %s from version %d", strings.Join(ids, ", "), version)
+}
+
 func getRestoreVersion(msg string) (int, error) {
 	parts := strings.Split(msg, restoreMsg)
 	if len(parts) < 2 {
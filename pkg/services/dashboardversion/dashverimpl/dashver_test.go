@@ -14,6 +14,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/grafana/grafana/pkg/apimachinery/utils"
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/apiserver/client"
@@ -312,6 +313,48 @@ func TestListDashboardVersions(t *testing.T) {
 	})
 }
 
+func TestDiffDashboardVersions(t *testing.T) {
+	dashboardVersionStore := newDashboardVersionStoreFake()
+	dashboardService := dashboards.NewFakeDashboardService(t)
+	dashboardVersionService := Service{store: dashboardVersionStore, dashSvc: dashboardService, features: featuremgmt.WithFeatures(), log: log.NewNopLogger()}
+
+	dashboardVersionStore.ExpectedDashboardVersion = &dashver.DashboardVersion{
+		DashboardID: 42,
+		Version:     1,
+		Data: simplejson.NewFromAny(map[string]any{
+			"id":     42,
+			"title":  "Dash",
+			"panels": []any{map[string]any{"id": 1, "title": "Panel"}},
+		}),
+	}
+	dashboardService.On("GetDashboard", mock.Anything, mock.AnythingOfType("*dashboards.GetDashboardQuery")).Return(&dashboards.Dashboard{
+		ID:      42,
+		UID:     "uid",
+		Version: 3,
+		Data: simplejson.NewFromAny(map[string]any{
+			"id":     42,
+			"title":  "Dash",
+			"panels": []any{map[string]any{"id": 1, "title": "Renamed"}},
+		}),
+	}, nil)
+
+	t.Run("Compares a version with the current dashboard", func(t *testing.T) {
+		diff, err := dashboardVersionService.Diff(context.Background(), &dashver.DiffDashboardVersionsQuery{DashboardUID: "uid", OrgID: 1, BaseVersion: 1})
+		require.NoError(t, err)
+		require.Equal(t, 1, diff.BaseVersion)
+		require.Equal(t, 3, diff.NewVersion)
+		require.Equal(t, []dashdiffs.Operation{{Op: dashdiffs.OpReplace, Path: "/panels/0/title", Value: "Renamed"}}, diff.Patch)
+		require.Len(t, diff.Panels, 1)
+		require.Equal(t, dashdiffs.ElementModified, diff.Panels[0].Change)
+	})
+
+	t.Run("Returns an error when the version does not exist", func(t *testing.T) {
+		dashboardVersionStore.ExpectedError = dashver.ErrDashboardVersionNotFound
+		_, err := dashboardVersionService.Diff(context.Background(), &dashver.DiffDashboardVersionsQuery{DashboardUID: "uid", OrgID: 1, BaseVersion: 5})
+		require.ErrorIs(t, err, dashver.ErrDashboardVersionNotFound)
+	})
+}
+
 type FakeDashboardVersionStore struct {
 	ExpectedDashboardVersion *dashver.DashboardVersion
 	ExptectedDeletedVersions int64
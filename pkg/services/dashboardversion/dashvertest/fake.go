@@ -11,6 +11,7 @@ type FakeDashboardVersionService struct {
 	ExpectedDashboardVersions    []*dashver.DashboardVersionDTO
 	ExpectedListDashboarVersions []*dashver.DashboardVersionDTO
 	ExpectedContinueToken        string
+	ExpectedDiff                 *dashver.DashboardVersionDiff
 	counter                      int
 	ExpectedError                error
 }
@@ -37,3 +38,7 @@ func (f *FakeDashboardVersionService) List(ctx context.Context, query *dashver.L
 		Versions:      f.ExpectedListDashboarVersions,
 	}, f.ExpectedError
 }
+
+func (f *FakeDashboardVersionService) Diff(ctx context.Context, query *dashver.DiffDashboardVersionsQuery) (*dashver.DashboardVersionDiff, error) {
+	return f.ExpectedDiff, f.ExpectedError
+}
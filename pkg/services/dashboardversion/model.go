@@ -4,6 +4,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/grafana/grafana/pkg/components/dashdiffs"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 )
 
@@ -56,6 +57,25 @@ type GetDashboardVersionQuery struct {
 	Version      int64
 }
 
+// DiffDashboardVersionsQuery is used to compare two versions of a dashboard. Only
+// one of DashboardID and DashboardUID are required. When NewVersion is 0, the
+// base version is compared with the current dashboard.
+type DiffDashboardVersionsQuery struct {
+	DashboardID  int64
+	DashboardUID string
+	OrgID        int64
+	BaseVersion  int64
+	NewVersion   int64
+}
+
+// DashboardVersionDiff is the JSON patch which transforms the base version of a
+// dashboard into the new one, along with the changed panels and queries.
+type DashboardVersionDiff struct {
+	BaseVersion int `json:"baseVersion"`
+	NewVersion  int `json:"newVersion"`
+	dashdiffs.StructuredDiff
+}
+
 type DeleteExpiredVersionsCommand struct {
 	DeletedRows int64
 }
@@ -21,5 +21,32 @@ type LoginAttempt struct {
 	Id        int64
 	Username  string
 	IpAddress string
-	Created   int64
+	// Subnet is the subnet of the IP address, which is used to detect password spraying.
+	Subnet  string
+	Created int64
+}
+
+type LockoutKind string
+
+const (
+	LockoutKindUser   LockoutKind = "user"
+	LockoutKindIP     LockoutKind = "ip"
+	LockoutKindSubnet LockoutKind = "subnet"
+)
+
+// Lockout blocks the password logins of a username, an IP address or a subnet until LockedUntil.
+type Lockout struct {
+	ID   int64       `xorm:"pk autoincr 'id'" json:"id"`
+	Kind LockoutKind `xorm:"kind" json:"kind"`
+	// Key is the username, the IP address or the subnet that is locked.
+	Key string `xorm:"lock_key" json:"key"`
+	// Level is the number of consecutive lockouts, which sets the lockout duration when backoff is enabled.
+	Level       int   `xorm:"level" json:"level"`
+	LockedUntil int64 `xorm:"locked_until" json:"lockedUntil"`
+	Created     int64 `xorm:"created" json:"created"`
+	Updated     int64 `xorm:"updated" json:"updated"`
+}
+
+func (l Lockout) TableName() string {
+	return "login_lockout"
 }
@@ -0,0 +1,41 @@
+package loginattemptimpl
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	authorize := ac.Middleware(s.accessControl)
+
+	routeRegister.Group("/api/admin/login-lockouts", func(route routing.RouteRegister) {
+		route.Get("/", middleware.ReqSignedIn, authorize(ac.EvalPermission(ActionLockoutsRead)), routing.Wrap(s.listLockoutsHandler))
+		route.Delete("/:id", middleware.ReqSignedIn, authorize(ac.EvalPermission(ActionLockoutsWrite)), routing.Wrap(s.unlockHandler))
+	})
+}
+
+func (s *Service) listLockoutsHandler(c *contextmodel.ReqContext) response.Response {
+	result, err := s.GetLockouts(c.Req.Context())
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get lockouts", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+func (s *Service) unlockHandler(c *contextmodel.ReqContext) response.Response {
+	id, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+	if err := s.Unlock(c.Req.Context(), id); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to unlock", err)
+	}
+	return response.Success("Unlocked")
+}
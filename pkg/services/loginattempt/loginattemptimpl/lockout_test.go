@@ -0,0 +1,260 @@
+package loginattemptimpl
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/actest"
+	"github.com/grafana/grafana/pkg/services/loginattempt"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/usertest"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func newTestService(t *testing.T, cfg *setting.Cfg) *Service {
+	t.Helper()
+
+	service, err := ProvideService(db.InitTestDB(t), cfg, nil, routing.NewRouteRegister(), actest.FakeAccessControl{}, actest.FakeService{},
+		usertest.NewUserServiceFake(), notifications.MockNotificationService(), nil)
+	require.NoError(t, err)
+	return service
+}
+
+func lockoutCfg() *setting.Cfg {
+	cfg := setting.NewCfg()
+	cfg.BruteForceLoginProtectionMaxAttempts = 2
+	cfg.DisableIPAddressLoginProtection = true
+	cfg.LoginLockout = setting.LoginLockoutSettings{
+		InitialDuration:       time.Minute,
+		MaxDuration:           3 * time.Minute,
+		ResetAfter:            time.Hour,
+		SprayMaxUsernames:     3,
+		SprayIPv4PrefixLength: 24,
+		SprayIPv6PrefixLength: 64,
+	}
+	return cfg
+}
+
+// expire ends a lockout, as if its duration had elapsed since the failed login attempts.
+func expire(t *testing.T, s *Service, kind loginattempt.LockoutKind, key string) {
+	t.Helper()
+
+	ctx := context.Background()
+	lockout, err := s.store.GetLockout(ctx, GetLockoutQuery{Kind: kind, Key: key})
+	require.NoError(t, err)
+	require.NotNil(t, lockout)
+	lockout.LockedUntil = time.Now().Add(-time.Minute).Unix()
+	require.NoError(t, s.store.SaveLockout(ctx, lockout))
+
+	err = s.store.(*xormStore).db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Exec("UPDATE login_attempt SET created = created - 120")
+		return err
+	})
+	require.NoError(t, err)
+}
+
+func TestIntegrationLockoutBackoff(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	cfg := lockoutCfg()
+	cfg.LoginLockout.BackoffEnabled = true
+	service := newTestService(t, cfg)
+
+	for _, expected := range []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute} {
+		require.NoError(t, service.Add(ctx, "admin", "192.168.1.1"))
+		ok, err := service.Validate(ctx, "admin")
+		require.NoError(t, err)
+		assert.True(t, ok, "the account must not be locked before the limit of attempts is reached")
+
+		require.NoError(t, service.Add(ctx, "Admin", "192.168.1.1"))
+		ok, err = service.Validate(ctx, "admin")
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		lockout, err := service.store.GetLockout(ctx, GetLockoutQuery{Kind: loginattempt.LockoutKindUser, Key: "admin"})
+		require.NoError(t, err)
+		assert.InDelta(t, time.Now().Add(expected).Unix(), lockout.LockedUntil, 2)
+
+		expire(t, service, loginattempt.LockoutKindUser, "admin")
+		ok, err = service.Validate(ctx, "admin")
+		require.NoError(t, err)
+		assert.True(t, ok, "attempts made before the end of a lockout must not be counted again")
+	}
+
+	t.Run("Reset removes the lockout", func(t *testing.T) {
+		require.NoError(t, service.Reset(ctx, "ADMIN"))
+		lockout, err := service.store.GetLockout(ctx, GetLockoutQuery{Kind: loginattempt.LockoutKindUser, Key: "admin"})
+		require.NoError(t, err)
+		assert.Nil(t, lockout)
+	})
+}
+
+func TestLockoutDuration(t *testing.T) {
+	cfg := lockoutCfg()
+	service := &Service{cfg: cfg}
+
+	assert.Equal(t, time.Minute, service.lockoutDuration(3), "without backoff, all lockouts have the initial duration")
+
+	cfg.LoginLockout.BackoffEnabled = true
+	assert.Equal(t, time.Minute, service.lockoutDuration(1))
+	assert.Equal(t, 2*time.Minute, service.lockoutDuration(2))
+	assert.Equal(t, 3*time.Minute, service.lockoutDuration(3))
+	assert.Equal(t, 3*time.Minute, service.lockoutDuration(100))
+}
+
+func TestIntegrationLockoutNetworks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	cfg := lockoutCfg()
+	cfg.DisableIPAddressLoginProtection = false
+	_, allowed, _ := net.ParseCIDR("10.1.0.0/16")
+	_, denied, _ := net.ParseCIDR("2001:db8::/32")
+	cfg.LoginLockout.AllowedNetworks = []*net.IPNet{allowed}
+	cfg.LoginLockout.DeniedNetworks = []*net.IPNet{denied}
+	service := newTestService(t, cfg)
+
+	ok, err := service.ValidateIPAddress(ctx, "[2001:db8::1]")
+	require.NoError(t, err)
+	assert.False(t, ok, "denied networks can never log in")
+
+	for _, username := range []string{"user1", "user2", "user3"} {
+		require.NoError(t, service.Add(ctx, username, "10.1.2.3"))
+		require.NoError(t, service.Add(ctx, username, "192.168.1.1"))
+	}
+
+	ok, err = service.ValidateIPAddress(ctx, "10.1.2.3")
+	require.NoError(t, err)
+	assert.True(t, ok, "allowed networks are never locked")
+
+	ok, err = service.ValidateIPAddress(ctx, "192.168.1.1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = service.Validate(ctx, "user1")
+	require.NoError(t, err)
+	assert.False(t, ok, "accounts are locked whatever the network of the attempts")
+}
+
+func TestIntegrationSprayDetection(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	cfg := lockoutCfg()
+	cfg.BruteForceLoginProtectionMaxAttempts = 5
+	cfg.LoginLockout.SprayDetectionEnabled = true
+	service := newTestService(t, cfg)
+
+	require.NoError(t, service.Add(ctx, "user1", "203.0.113.1"))
+	require.NoError(t, service.Add(ctx, "user1", "203.0.113.2"))
+	require.NoError(t, service.Add(ctx, "user2", "203.0.113.3"))
+
+	ok, err := service.ValidateIPAddress(ctx, "203.0.113.99")
+	require.NoError(t, err)
+	assert.True(t, ok, "the subnet must not be throttled before the limit of usernames is reached")
+
+	require.NoError(t, service.Add(ctx, "user3", "203.0.113.4"))
+
+	ok, err = service.ValidateIPAddress(ctx, "203.0.113.99")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = service.ValidateIPAddress(ctx, "203.0.114.1")
+	require.NoError(t, err)
+	assert.True(t, ok, "other subnets must not be throttled")
+
+	lockouts, err := service.GetLockouts(ctx)
+	require.NoError(t, err)
+	require.Len(t, lockouts, 1)
+	assert.Equal(t, loginattempt.LockoutKindSubnet, lockouts[0].Kind)
+	assert.Equal(t, "203.0.113.0/24", lockouts[0].Key)
+}
+
+func TestIntegrationUnlock(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	cfg := lockoutCfg()
+	cfg.LoginLockout.NotifyUser = true
+	service := newTestService(t, cfg)
+	service.userService = &usertest.FakeUserService{ExpectedUser: &user.User{ID: 1, Login: "admin", Email: "admin@example.com"}}
+	emails := notifications.MockNotificationService()
+	service.emailSender = emails
+
+	require.NoError(t, service.Add(ctx, "admin", "192.168.1.1"))
+	require.NoError(t, service.Add(ctx, "admin", "192.168.1.1"))
+	assert.Equal(t, []string{"admin@example.com"}, emails.Email.To)
+	assert.Equal(t, lockedEmailTemplate, emails.Email.Template)
+
+	lockouts, err := service.GetLockouts(ctx)
+	require.NoError(t, err)
+	require.Len(t, lockouts, 1)
+	assert.Equal(t, loginattempt.LockoutKindUser, lockouts[0].Kind)
+	assert.Equal(t, "admin", lockouts[0].Key)
+
+	require.NoError(t, service.Unlock(ctx, lockouts[0].ID))
+
+	ok, err := service.Validate(ctx, "admin")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	lockouts, err = service.GetLockouts(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, lockouts)
+
+	require.ErrorIs(t, service.Unlock(ctx, 1000), ErrLockoutNotFound)
+}
+
+// racingStore saves a lockout of the same key right before each new lockout, as a concurrent failed login would.
+type racingStore struct {
+	store
+}
+
+func (s racingStore) SaveLockout(ctx context.Context, lockout *loginattempt.Lockout) error {
+	if lockout.ID == 0 {
+		concurrent := *lockout
+		if err := s.store.SaveLockout(ctx, &concurrent); err != nil {
+			return err
+		}
+	}
+	return s.store.SaveLockout(ctx, lockout)
+}
+
+func TestIntegrationConcurrentLockout(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	cfg := lockoutCfg()
+	cfg.DisableIPAddressLoginProtection = false
+	service := newTestService(t, cfg)
+
+	lockout := &loginattempt.Lockout{Kind: loginattempt.LockoutKindUser, Key: "admin", Level: 1, LockedUntil: time.Now().Add(time.Minute).Unix()}
+	require.NoError(t, service.store.SaveLockout(ctx, lockout))
+	require.ErrorIs(t, service.store.SaveLockout(ctx, &loginattempt.Lockout{Kind: loginattempt.LockoutKindUser, Key: "admin"}), ErrLockoutExists)
+	require.NoError(t, service.store.DeleteLockout(ctx, lockout.ID))
+
+	service.store = racingStore{service.store}
+	require.NoError(t, service.Add(ctx, "admin", "192.168.1.1"))
+	require.NoError(t, service.Add(ctx, "admin", "192.168.1.1"))
+
+	ok, err := service.Validate(ctx, "admin")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = service.ValidateIPAddress(ctx, "192.168.1.1")
+	require.NoError(t, err)
+	assert.False(t, ok, "the IP address must be locked when the username was locked concurrently")
+}
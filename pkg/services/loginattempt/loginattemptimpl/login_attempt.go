@@ -2,31 +2,69 @@ package loginattemptimpl
 
 import (
 	"context"
+	"errors"
+	"net"
 	"strings"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/serverlock"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/loginattempt"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
-const loginAttemptsWindow = time.Minute * 5
+const (
+	loginAttemptsWindow = time.Minute * 5
+
+	lockedEmailTemplate = "login_locked"
+)
 
-func ProvideService(db db.DB, cfg *setting.Cfg, lock *serverlock.ServerLockService) *Service {
-	return &Service{
-		&xormStore{db: db, now: time.Now},
-		cfg,
-		lock,
-		log.New("login_attempt"),
+func ProvideService(
+	db db.DB,
+	cfg *setting.Cfg,
+	lock *serverlock.ServerLockService,
+	routeRegister routing.RouteRegister,
+	accessControl ac.AccessControl,
+	accesscontrolService ac.Service,
+	userService user.Service,
+	emailSender notifications.EmailSender,
+	reg prometheus.Registerer,
+) (*Service, error) {
+	s := &Service{
+		store:         &xormStore{db: db, now: time.Now},
+		cfg:           cfg,
+		lock:          lock,
+		logger:        log.New("login_attempt"),
+		accessControl: accessControl,
+		userService:   userService,
+		emailSender:   emailSender,
+		metrics:       newMetrics(reg),
 	}
+
+	if err := declareFixedRoles(accesscontrolService); err != nil {
+		return nil, err
+	}
+	s.registerAPIEndpoints(routeRegister)
+
+	return s, nil
 }
 
 type Service struct {
-	store  store
-	cfg    *setting.Cfg
-	lock   *serverlock.ServerLockService
-	logger log.Logger
+	store         store
+	cfg           *setting.Cfg
+	lock          *serverlock.ServerLockService
+	logger        log.Logger
+	accessControl ac.AccessControl
+	userService   user.Service
+	emailSender   notifications.EmailSender
+	metrics       *metrics
 }
 
 func (s *Service) Run(ctx context.Context) error {
@@ -46,20 +84,65 @@ func (s *Service) Run(ctx context.Context) error {
 	}
 }
 
+// Add records a failed login attempt, and locks the username, the IP address or the subnet of the
+// IP address when they reach their limit of failed attempts.
 func (s *Service) Add(ctx context.Context, username, IPAddress string) error {
 	if s.cfg.DisableBruteForceLoginProtection {
 		return nil
 	}
 
+	username = strings.ToLower(username)
+	ip := parseIP(IPAddress)
+	subnet := s.subnet(ip)
 	_, err := s.store.CreateLoginAttempt(ctx, CreateLoginAttemptCommand{
-		Username:  strings.ToLower(username),
+		Username:  username,
 		IPAddress: IPAddress,
+		Subnet:    subnet,
 	})
-	return err
+	if err != nil {
+		return err
+	}
+
+	lockout, err := s.lockIfExceeded(ctx, loginattempt.LockoutKindUser, username)
+	if err != nil {
+		return err
+	}
+	if lockout != nil {
+		s.notifyLockedUser(ctx, username, lockout)
+	}
+
+	if isInNetworks(ip, s.cfg.LoginLockout.AllowedNetworks) {
+		return nil
+	}
+	if !s.cfg.DisableIPAddressLoginProtection {
+		if _, err := s.lockIfExceeded(ctx, loginattempt.LockoutKindIP, IPAddress); err != nil {
+			return err
+		}
+	}
+	if s.cfg.LoginLockout.SprayDetectionEnabled && subnet != "" {
+		lockout, err := s.lockIfExceeded(ctx, loginattempt.LockoutKindSubnet, subnet)
+		if err != nil {
+			return err
+		}
+		if lockout != nil {
+			s.logger.Warn("Throttling password logins from subnet after failed logins for many usernames", "subnet", subnet, "lockedUntil", time.Unix(lockout.LockedUntil, 0))
+		}
+	}
+	return nil
 }
 
+// Reset deletes the login attempts and the lockout of a username.
 func (s *Service) Reset(ctx context.Context, username string) error {
-	return s.store.DeleteLoginAttempts(ctx, DeleteLoginAttemptsCommand{strings.ToLower(username)})
+	username = strings.ToLower(username)
+	if err := s.store.DeleteLoginAttempts(ctx, DeleteLoginAttemptsCommand{Username: username}); err != nil {
+		return err
+	}
+
+	lockout, err := s.store.GetLockout(ctx, GetLockoutQuery{Kind: loginattempt.LockoutKindUser, Key: username})
+	if err != nil || lockout == nil {
+		return err
+	}
+	return s.store.DeleteLockout(ctx, lockout.ID)
 }
 
 func (s *Service) Validate(ctx context.Context, username string) (bool, error) {
@@ -67,43 +150,255 @@ func (s *Service) Validate(ctx context.Context, username string) (bool, error) {
 		return true, nil
 	}
 
-	loginAttemptCountQuery := GetUserLoginAttemptCountQuery{
-		Username: strings.ToLower(username),
-		Since:    time.Now().Add(-loginAttemptsWindow),
-	}
-
-	count, err := s.store.GetUserLoginAttemptCount(ctx, loginAttemptCountQuery)
+	ok, err := s.validate(ctx, loginattempt.LockoutKindUser, strings.ToLower(username))
 	if err != nil {
 		return false, err
 	}
+	if !ok {
+		s.metrics.rejected.WithLabelValues("user_locked").Inc()
+	}
+	return ok, nil
+}
 
-	if count >= s.cfg.BruteForceLoginProtectionMaxAttempts {
+func (s *Service) ValidateIPAddress(ctx context.Context, IPAddress string) (bool, error) {
+	ip := parseIP(IPAddress)
+	if isInNetworks(ip, s.cfg.LoginLockout.DeniedNetworks) {
+		s.metrics.rejected.WithLabelValues("ip_denied").Inc()
 		return false, nil
 	}
+	if isInNetworks(ip, s.cfg.LoginLockout.AllowedNetworks) {
+		return true, nil
+	}
 
-	return true, nil
-}
+	if s.cfg.LoginLockout.SprayDetectionEnabled && !s.cfg.DisableBruteForceLoginProtection {
+		if subnet := s.subnet(ip); subnet != "" {
+			ok, err := s.validate(ctx, loginattempt.LockoutKindSubnet, subnet)
+			if err != nil {
+				return false, err
+			}
+			if !ok {
+				s.metrics.rejected.WithLabelValues("subnet_locked").Inc()
+				return false, nil
+			}
+		}
+	}
 
-func (s *Service) ValidateIPAddress(ctx context.Context, IPAddress string) (bool, error) {
 	if s.cfg.DisableIPAddressLoginProtection {
 		return true, nil
 	}
 
-	loginAttemptCountQuery := GetIPLoginAttemptCountQuery{
-		IPAddress: IPAddress,
-		Since:     time.Now().Add(-loginAttemptsWindow),
+	ok, err := s.validate(ctx, loginattempt.LockoutKindIP, IPAddress)
+	if err != nil {
+		return false, err
 	}
+	if !ok {
+		s.metrics.rejected.WithLabelValues("ip_locked").Inc()
+	}
+	return ok, nil
+}
 
-	count, err := s.store.GetIPLoginAttemptCount(ctx, loginAttemptCountQuery)
+// GetLockouts returns the usernames, IP addresses and subnets that are currently locked.
+func (s *Service) GetLockouts(ctx context.Context) ([]*loginattempt.Lockout, error) {
+	return s.store.GetActiveLockouts(ctx)
+}
+
+// Unlock removes a lockout and the failed login attempts that caused it.
+func (s *Service) Unlock(ctx context.Context, id int64) error {
+	lockout, err := s.store.GetLockoutByID(ctx, id)
 	if err != nil {
-		return false, err
+		return err
 	}
 
-	if count >= s.cfg.BruteForceLoginProtectionMaxAttempts {
+	cmd := DeleteLoginAttemptsCommand{}
+	switch lockout.Kind {
+	case loginattempt.LockoutKindUser:
+		cmd.Username = lockout.Key
+	case loginattempt.LockoutKindIP:
+		cmd.IPAddress = lockout.Key
+	case loginattempt.LockoutKindSubnet:
+		cmd.Subnet = lockout.Key
+	}
+	if err := s.store.DeleteLoginAttempts(ctx, cmd); err != nil {
+		return err
+	}
+	if err := s.store.DeleteLockout(ctx, id); err != nil {
+		return err
+	}
+
+	s.metrics.unlocks.WithLabelValues(string(lockout.Kind)).Inc()
+	return nil
+}
+
+// validate returns false if a username, an IP address or a subnet is locked or has too many failed
+// login attempts.
+func (s *Service) validate(ctx context.Context, kind loginattempt.LockoutKind, key string) (bool, error) {
+	now := time.Now()
+	lockout, err := s.store.GetLockout(ctx, GetLockoutQuery{Kind: kind, Key: key})
+	if err != nil {
+		return false, err
+	}
+	if lockout != nil && lockout.LockedUntil > now.Unix() {
 		return false, nil
 	}
 
-	return true, nil
+	count, err := s.countAttempts(ctx, kind, key, attemptsSince(lockout, now))
+	if err != nil {
+		return false, err
+	}
+	return count < s.maxAttempts(kind), nil
+}
+
+// lockIfExceeded locks a username, an IP address or a subnet that reached its limit of failed login
+// attempts, and returns the new lockout. It returns nil if nothing was locked.
+func (s *Service) lockIfExceeded(ctx context.Context, kind loginattempt.LockoutKind, key string) (*loginattempt.Lockout, error) {
+	now := time.Now()
+	lockout, err := s.store.GetLockout(ctx, GetLockoutQuery{Kind: kind, Key: key})
+	if err != nil {
+		return nil, err
+	}
+	if lockout != nil && lockout.LockedUntil > now.Unix() {
+		return nil, nil
+	}
+
+	count, err := s.countAttempts(ctx, kind, key, attemptsSince(lockout, now))
+	if err != nil {
+		return nil, err
+	}
+	if count < s.maxAttempts(kind) {
+		return nil, nil
+	}
+
+	if lockout == nil {
+		lockout = &loginattempt.Lockout{Kind: kind, Key: key}
+	}
+	// The backoff starts over when the previous lockout ended long enough ago.
+	if lockout.Level > 0 && now.Sub(time.Unix(lockout.LockedUntil, 0)) < s.cfg.LoginLockout.ResetAfter {
+		lockout.Level++
+	} else {
+		lockout.Level = 1
+	}
+	lockout.LockedUntil = now.Add(s.lockoutDuration(lockout.Level)).Unix()
+	if err := s.store.SaveLockout(ctx, lockout); err != nil {
+		// A concurrent failed login for the same key locked it first.
+		if errors.Is(err, ErrLockoutExists) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	s.metrics.lockouts.WithLabelValues(string(kind)).Inc()
+	s.logger.Info("Locked password logins after failed login attempts", "kind", kind, "key", key, "level", lockout.Level, "lockedUntil", time.Unix(lockout.LockedUntil, 0))
+	return lockout, nil
+}
+
+// lockoutDuration returns the duration of a lockout, which doubles with every consecutive lockout
+// when backoff is enabled.
+func (s *Service) lockoutDuration(level int) time.Duration {
+	settings := s.cfg.LoginLockout
+	duration := settings.InitialDuration
+	if duration <= 0 {
+		duration = loginAttemptsWindow
+	}
+	if !settings.BackoffEnabled {
+		return duration
+	}
+	for i := 1; i < level && duration < settings.MaxDuration; i++ {
+		duration *= 2
+	}
+	return min(duration, settings.MaxDuration)
+}
+
+func (s *Service) maxAttempts(kind loginattempt.LockoutKind) int64 {
+	if kind == loginattempt.LockoutKindSubnet {
+		return s.cfg.LoginLockout.SprayMaxUsernames
+	}
+	return s.cfg.BruteForceLoginProtectionMaxAttempts
+}
+
+func (s *Service) countAttempts(ctx context.Context, kind loginattempt.LockoutKind, key string, since time.Time) (int64, error) {
+	switch kind {
+	case loginattempt.LockoutKindIP:
+		return s.store.GetIPLoginAttemptCount(ctx, GetIPLoginAttemptCountQuery{IPAddress: key, Since: since})
+	case loginattempt.LockoutKindSubnet:
+		return s.store.GetSubnetUsernameCount(ctx, GetSubnetUsernameCountQuery{Subnet: key, Since: since})
+	default:
+		return s.store.GetUserLoginAttemptCount(ctx, GetUserLoginAttemptCountQuery{Username: key, Since: since})
+	}
+}
+
+// attemptsSince returns the time from which failed login attempts are counted. The attempts made
+// before the end of the previous lockout are not counted again.
+func attemptsSince(lockout *loginattempt.Lockout, now time.Time) time.Time {
+	since := now.Add(-loginAttemptsWindow)
+	if lockout != nil && lockout.LockedUntil > since.Unix() {
+		return time.Unix(lockout.LockedUntil, 0)
+	}
+	return since
+}
+
+// notifyLockedUser emails a user whose account was locked, so that they learn that someone may be
+// trying to guess their password.
+func (s *Service) notifyLockedUser(ctx context.Context, username string, lockout *loginattempt.Lockout) {
+	if !s.cfg.LoginLockout.NotifyUser {
+		return
+	}
+
+	usr, err := s.userService.GetByLogin(ctx, &user.GetUserByLoginQuery{LoginOrEmail: username})
+	if err != nil {
+		if !errors.Is(err, user.ErrUserNotFound) {
+			s.logger.Warn("Failed to get locked user", "username", username, "error", err)
+		}
+		return
+	}
+	if usr.Email == "" {
+		return
+	}
+
+	err = s.emailSender.SendEmailCommandHandler(ctx, &notifications.SendEmailCommand{
+		To:       []string{usr.Email},
+		Template: lockedEmailTemplate,
+		Data: map[string]any{
+			"Name":        usr.NameOrFallback(),
+			"Login":       usr.Login,
+			"Attempts":    s.cfg.BruteForceLoginProtectionMaxAttempts,
+			"LockedUntil": time.Unix(lockout.LockedUntil, 0).UTC().Format(time.RFC1123),
+		},
+	})
+	if err != nil {
+		s.logger.Warn("Failed to send lockout notification", "username", username, "error", err)
+	}
+}
+
+// subnet returns the subnet of an IP address that is used to detect password spraying, or an empty
+// string if the address is invalid.
+func (s *Service) subnet(ip net.IP) string {
+	if ip == nil {
+		return ""
+	}
+	if v4 := ip.To4(); v4 != nil {
+		mask := net.CIDRMask(s.cfg.LoginLockout.SprayIPv4PrefixLength, 8*net.IPv4len)
+		return (&net.IPNet{IP: v4.Mask(mask), Mask: mask}).String()
+	}
+	mask := net.CIDRMask(s.cfg.LoginLockout.SprayIPv6PrefixLength, 8*net.IPv6len)
+	return (&net.IPNet{IP: ip.Mask(mask), Mask: mask}).String()
+}
+
+// parseIP parses the IP address of a request, which is enclosed in brackets for IPv6 addresses
+// that come from the remote address of the connection.
+func parseIP(s string) net.IP {
+	return net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]"))
+}
+
+func isInNetworks(ip net.IP, networks []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
 }
 
 func (s *Service) cleanup(ctx context.Context) {
@@ -116,6 +411,16 @@ func (s *Service) cleanup(ctx context.Context) {
 		} else {
 			s.logger.Debug("Deleted expired login attempts", "rows affected", deletedLogs)
 		}
+
+		// Lockouts are kept after they end, until the backoff starts over.
+		lockoutsCmd := DeleteOldLockoutsCommand{
+			LockedBefore: time.Now().Add(-s.cfg.LoginLockout.ResetAfter),
+		}
+		if deleted, err := s.store.DeleteOldLockouts(ctx, lockoutsCmd); err != nil {
+			s.logger.Error("Problem deleting expired lockouts", "error", err.Error())
+		} else {
+			s.logger.Debug("Deleted expired lockouts", "rows affected", deleted)
+		}
 	})
 
 	if err != nil {
@@ -6,7 +6,6 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
-	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/services/loginattempt"
 	"github.com/grafana/grafana/pkg/setting"
 )
@@ -73,7 +72,8 @@ func TestService_Validate(t *testing.T) {
 					ExpectedCount: tt.loginAttempts,
 					ExpectedErr:   tt.expectedErr,
 				},
-				cfg: cfg,
+				cfg:     cfg,
+				metrics: newMetrics(nil),
 			}
 
 			ok, err := service.Validate(context.Background(), "test")
@@ -88,8 +88,7 @@ func TestUserLoginAttempts(t *testing.T) {
 	cfg := setting.NewCfg()
 	cfg.DisableBruteForceLoginProtection = false
 	cfg.BruteForceLoginProtectionMaxAttempts = 5
-	db := db.InitTestDB(t)
-	service := ProvideService(db, cfg, nil)
+	service := newTestService(t, cfg)
 
 	// add multiple login attempts with different uppercases, they all should be counted as the same user
 	_ = service.Add(ctx, "admin", "[::1]")
@@ -171,7 +170,8 @@ func TestService_ValidateIPAddress(t *testing.T) {
 					ExpectedCount: tt.loginAttempts,
 					ExpectedErr:   tt.expectedErr,
 				},
-				cfg: cfg,
+				cfg:     cfg,
+				metrics: newMetrics(nil),
 			}
 
 			ok, err := service.ValidateIPAddress(context.Background(), "192.168.1.1")
@@ -186,8 +186,7 @@ func TestIPLoginAttempts(t *testing.T) {
 	cfg := setting.NewCfg()
 	cfg.DisableIPAddressLoginProtection = false
 	cfg.BruteForceLoginProtectionMaxAttempts = 3
-	db := db.InitTestDB(t)
-	service := ProvideService(db, cfg, nil)
+	service := newTestService(t, cfg)
 
 	_ = service.Add(ctx, "user1", "192.168.1.1")
 	_ = service.Add(ctx, "user2", "10.0.0.123")
@@ -232,3 +231,31 @@ func (f fakeStore) DeleteOldLoginAttempts(ctx context.Context, command DeleteOld
 func (f fakeStore) DeleteLoginAttempts(ctx context.Context, cmd DeleteLoginAttemptsCommand) error {
 	return f.ExpectedErr
 }
+
+func (f fakeStore) GetSubnetUsernameCount(ctx context.Context, query GetSubnetUsernameCountQuery) (int64, error) {
+	return f.ExpectedCount, f.ExpectedErr
+}
+
+func (f fakeStore) GetLockout(ctx context.Context, query GetLockoutQuery) (*loginattempt.Lockout, error) {
+	return nil, f.ExpectedErr
+}
+
+func (f fakeStore) GetLockoutByID(ctx context.Context, id int64) (*loginattempt.Lockout, error) {
+	return nil, ErrLockoutNotFound.Errorf("lockout %d not found", id)
+}
+
+func (f fakeStore) GetActiveLockouts(ctx context.Context) ([]*loginattempt.Lockout, error) {
+	return nil, f.ExpectedErr
+}
+
+func (f fakeStore) SaveLockout(ctx context.Context, lockout *loginattempt.Lockout) error {
+	return f.ExpectedErr
+}
+
+func (f fakeStore) DeleteLockout(ctx context.Context, id int64) error {
+	return f.ExpectedErr
+}
+
+func (f fakeStore) DeleteOldLockouts(ctx context.Context, cmd DeleteOldLockoutsCommand) (int64, error) {
+	return f.ExpectedDeletedRows, f.ExpectedErr
+}
@@ -0,0 +1,49 @@
+package loginattemptimpl
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsNamespace = "grafana"
+	metricsSubSystem = "login_attempt"
+)
+
+type metrics struct {
+	lockouts *prometheus.CounterVec
+	rejected *prometheus.CounterVec
+	unlocks  *prometheus.CounterVec
+}
+
+func newMetrics(reg prometheus.Registerer) *metrics {
+	m := &metrics{
+		lockouts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubSystem,
+			Name:      "lockouts_total",
+			Help:      "Number of lockouts of accounts, IP addresses and subnets after failed login attempts",
+		}, []string{"kind"}),
+		rejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubSystem,
+			Name:      "rejected_total",
+			Help:      "Number of login attempts rejected because of a lockout or a denied network",
+		}, []string{"reason"}),
+		unlocks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubSystem,
+			Name:      "unlocks_total",
+			Help:      "Number of lockouts removed by an administrator",
+		}, []string{"kind"}),
+	}
+
+	if reg != nil {
+		reg.MustRegister(
+			m.lockouts,
+			m.rejected,
+			m.unlocks,
+		)
+	}
+
+	return m
+}
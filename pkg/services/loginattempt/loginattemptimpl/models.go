@@ -2,11 +2,19 @@ package loginattemptimpl
 
 import (
 	"time"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/loginattempt"
 )
 
+var ErrLockoutNotFound = errutil.NotFound("login-attempt.lockout-not-found", errutil.WithPublicMessage("Lockout not found"))
+var ErrLockoutExists = errutil.Conflict("login-attempt.lockout-exists", errutil.WithPublicMessage("Lockout already exists"))
+
 type CreateLoginAttemptCommand struct {
 	Username  string
 	IPAddress string
+	Subnet    string
 }
 
 type GetUserLoginAttemptCountQuery struct {
@@ -19,10 +27,70 @@ type GetIPLoginAttemptCountQuery struct {
 	Since     time.Time
 }
 
+// GetSubnetUsernameCountQuery counts the distinct usernames that failed to log in from a subnet.
+type GetSubnetUsernameCountQuery struct {
+	Subnet string
+	Since  time.Time
+}
+
 type DeleteOldLoginAttemptsCommand struct {
 	OlderThan time.Time
 }
 
+// DeleteLoginAttemptsCommand deletes the login attempts of a username, an IP address or a subnet.
 type DeleteLoginAttemptsCommand struct {
-	Username string
+	Username  string
+	IPAddress string
+	Subnet    string
+}
+
+type GetLockoutQuery struct {
+	Kind loginattempt.LockoutKind
+	Key  string
+}
+
+type DeleteOldLockoutsCommand struct {
+	// LockedBefore deletes the lockouts that ended before this time.
+	LockedBefore time.Time
+}
+
+const (
+	ActionLockoutsRead  = "login.lockouts:read"
+	ActionLockoutsWrite = "login.lockouts:write"
+)
+
+var (
+	lockoutsReaderRole = accesscontrol.RoleDTO{
+		Name:        "fixed:login.lockouts:reader",
+		DisplayName: "Login lockouts reader",
+		Description: "List the accounts, IP addresses and subnets that are locked after failed login attempts",
+		Group:       "Users",
+		Permissions: []accesscontrol.Permission{
+			{Action: ActionLockoutsRead},
+		},
+	}
+
+	lockoutsWriterRole = accesscontrol.RoleDTO{
+		Name:        "fixed:login.lockouts:writer",
+		DisplayName: "Login lockouts writer",
+		Description: "List and unlock the accounts, IP addresses and subnets that are locked after failed login attempts",
+		Group:       "Users",
+		Permissions: []accesscontrol.Permission{
+			{Action: ActionLockoutsRead},
+			{Action: ActionLockoutsWrite},
+		},
+	}
+)
+
+func declareFixedRoles(ac accesscontrol.Service) error {
+	return ac.DeclareFixedRoles(
+		accesscontrol.RoleRegistration{
+			Role:   lockoutsReaderRole,
+			Grants: []string{accesscontrol.RoleGrafanaAdmin},
+		},
+		accesscontrol.RoleRegistration{
+			Role:   lockoutsWriterRole,
+			Grants: []string{accesscontrol.RoleGrafanaAdmin},
+		},
+	)
 }
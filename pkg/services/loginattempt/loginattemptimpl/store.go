@@ -19,6 +19,13 @@ type store interface {
 	DeleteLoginAttempts(ctx context.Context, cmd DeleteLoginAttemptsCommand) error
 	GetUserLoginAttemptCount(ctx context.Context, query GetUserLoginAttemptCountQuery) (int64, error)
 	GetIPLoginAttemptCount(ctx context.Context, query GetIPLoginAttemptCountQuery) (int64, error)
+	GetSubnetUsernameCount(ctx context.Context, query GetSubnetUsernameCountQuery) (int64, error)
+	GetLockout(ctx context.Context, query GetLockoutQuery) (*loginattempt.Lockout, error)
+	GetLockoutByID(ctx context.Context, id int64) (*loginattempt.Lockout, error)
+	GetActiveLockouts(ctx context.Context) ([]*loginattempt.Lockout, error)
+	SaveLockout(ctx context.Context, lockout *loginattempt.Lockout) error
+	DeleteLockout(ctx context.Context, id int64) error
+	DeleteOldLockouts(ctx context.Context, cmd DeleteOldLockoutsCommand) (int64, error)
 }
 
 func (xs *xormStore) CreateLoginAttempt(ctx context.Context, cmd CreateLoginAttemptCommand) (result loginattempt.LoginAttempt, err error) {
@@ -26,6 +33,7 @@ func (xs *xormStore) CreateLoginAttempt(ctx context.Context, cmd CreateLoginAtte
 		loginAttempt := loginattempt.LoginAttempt{
 			Username:  cmd.Username,
 			IpAddress: cmd.IPAddress,
+			Subnet:    cmd.Subnet,
 			Created:   xs.now().Unix(),
 		}
 
@@ -59,7 +67,15 @@ func (xs *xormStore) DeleteOldLoginAttempts(ctx context.Context, cmd DeleteOldLo
 
 func (xs *xormStore) DeleteLoginAttempts(ctx context.Context, cmd DeleteLoginAttemptsCommand) error {
 	return xs.db.WithDbSession(ctx, func(sess *db.Session) error {
-		_, err := sess.Exec("DELETE FROM login_attempt WHERE username = ?", cmd.Username)
+		var err error
+		switch {
+		case cmd.IPAddress != "":
+			_, err = sess.Exec("DELETE FROM login_attempt WHERE ip_address = ?", cmd.IPAddress)
+		case cmd.Subnet != "":
+			_, err = sess.Exec("DELETE FROM login_attempt WHERE subnet = ?", cmd.Subnet)
+		default:
+			_, err = sess.Exec("DELETE FROM login_attempt WHERE username = ?", cmd.Username)
+		}
 		return err
 	})
 }
@@ -99,3 +115,88 @@ func (xs *xormStore) GetIPLoginAttemptCount(ctx context.Context, query GetIPLogi
 
 	return total, err
 }
+
+func (xs *xormStore) GetSubnetUsernameCount(ctx context.Context, query GetSubnetUsernameCountQuery) (int64, error) {
+	var total int64
+	err := xs.db.WithDbSession(ctx, func(dbSession *db.Session) error {
+		_, err := dbSession.SQL("SELECT COUNT(DISTINCT username) FROM login_attempt WHERE subnet = ? AND created >= ?",
+			query.Subnet, query.Since.Unix()).Get(&total)
+		return err
+	})
+
+	return total, err
+}
+
+// GetLockout returns the lockout of a username, an IP address or a subnet, or nil if it was never locked.
+func (xs *xormStore) GetLockout(ctx context.Context, query GetLockoutQuery) (*loginattempt.Lockout, error) {
+	var lockout *loginattempt.Lockout
+	err := xs.db.WithDbSession(ctx, func(sess *db.Session) error {
+		l := loginattempt.Lockout{}
+		has, err := sess.Where("kind = ? AND lock_key = ?", query.Kind, query.Key).Get(&l)
+		if has {
+			lockout = &l
+		}
+		return err
+	})
+	return lockout, err
+}
+
+func (xs *xormStore) GetLockoutByID(ctx context.Context, id int64) (*loginattempt.Lockout, error) {
+	lockout := &loginattempt.Lockout{}
+	err := xs.db.WithDbSession(ctx, func(sess *db.Session) error {
+		has, err := sess.ID(id).Get(lockout)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return ErrLockoutNotFound.Errorf("lockout %d not found", id)
+		}
+		return nil
+	})
+	return lockout, err
+}
+
+// GetActiveLockouts returns the lockouts that did not end yet.
+func (xs *xormStore) GetActiveLockouts(ctx context.Context) ([]*loginattempt.Lockout, error) {
+	lockouts := make([]*loginattempt.Lockout, 0)
+	err := xs.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("locked_until > ?", xs.now().Unix()).Asc("locked_until").Find(&lockouts)
+	})
+	return lockouts, err
+}
+
+func (xs *xormStore) SaveLockout(ctx context.Context, lockout *loginattempt.Lockout) error {
+	return xs.db.WithDbSession(ctx, func(sess *db.Session) error {
+		lockout.Updated = xs.now().Unix()
+		if lockout.ID == 0 {
+			lockout.Created = lockout.Updated
+			_, err := sess.Insert(lockout)
+			if err != nil && xs.db.GetDialect().IsUniqueConstraintViolation(err) {
+				return ErrLockoutExists.Errorf("lockout of %s %q already exists", lockout.Kind, lockout.Key)
+			}
+			return err
+		}
+		_, err := sess.ID(lockout.ID).Cols("level", "locked_until", "updated").Update(lockout)
+		return err
+	})
+}
+
+func (xs *xormStore) DeleteLockout(ctx context.Context, id int64) error {
+	return xs.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Exec("DELETE FROM login_lockout WHERE id = ?", id)
+		return err
+	})
+}
+
+func (xs *xormStore) DeleteOldLockouts(ctx context.Context, cmd DeleteOldLockoutsCommand) (int64, error) {
+	var deletedRows int64
+	err := xs.db.WithDbSession(ctx, func(sess *db.Session) error {
+		result, err := sess.Exec("DELETE FROM login_lockout WHERE locked_until < ?", cmd.LockedBefore.Unix())
+		if err != nil {
+			return err
+		}
+		deletedRows, err = result.RowsAffected()
+		return err
+	})
+	return deletedRows, err
+}
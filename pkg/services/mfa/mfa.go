@@ -0,0 +1,14 @@
+package mfa
+
+import (
+	"context"
+)
+
+// Service manages the second factors that users enroll to log in with a password.
+type Service interface {
+	// GetStatus returns the second factors of a user and whether the user has to use one.
+	GetStatus(ctx context.Context, userID int64) (*Status, error)
+	// Reset removes all the second factors and recovery codes of a user, for example when the
+	// user lost their devices. Users who must use a second factor enroll a new one on their next login.
+	Reset(ctx context.Context, userID int64) error
+}
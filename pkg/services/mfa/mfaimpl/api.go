@@ -0,0 +1,199 @@
+package mfaimpl
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	claims "github.com/grafana/authlib/types"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/mfa"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	routeRegister.Group("/api/user/mfa", func(route routing.RouteRegister) {
+		route.Get("/", routing.Wrap(s.getStatusHandler))
+		route.Post("/enroll", routing.Wrap(s.enrollHandler))
+		route.Post("/enroll/finish", routing.Wrap(s.finishEnrollmentHandler))
+		route.Delete("/factors/:id", routing.Wrap(s.deleteFactorHandler))
+		route.Post("/recovery-codes", routing.Wrap(s.recoveryCodesHandler))
+	}, middleware.ReqSignedIn)
+
+	// Users who must use a second factor but have none enroll one during the login, with the
+	// token returned by the first step of the login instead of a session.
+	routeRegister.Group("/api/login/mfa/enroll", func(route routing.RouteRegister) {
+		route.Post("/", routing.Wrap(s.loginEnrollHandler))
+		route.Post("/finish", routing.Wrap(s.loginFinishEnrollmentHandler))
+	})
+}
+
+type enrollForm struct {
+	Type mfa.FactorType `json:"type"`
+	Name string         `json:"name"`
+}
+
+type finishEnrollmentForm struct {
+	Code       string          `json:"code"`
+	Credential json.RawMessage `json:"credential,omitempty"`
+}
+
+type loginEnrollForm struct {
+	Token string `json:"mfaToken"`
+	enrollForm
+}
+
+type loginFinishEnrollmentForm struct {
+	Token string `json:"mfaToken"`
+	finishEnrollmentForm
+}
+
+// signedInUserID returns the ID of the signed in user. Second factors are only used by users,
+// service accounts log in with tokens.
+func signedInUserID(c *contextmodel.ReqContext) (int64, response.Response) {
+	if !c.SignedInUser.IsIdentityType(claims.TypeUser) {
+		return 0, response.Error(http.StatusForbidden, "Only users can use a second factor", nil)
+	}
+	userID, err := c.SignedInUser.GetInternalID()
+	if err != nil {
+		return 0, response.Error(http.StatusForbidden, "Only users can use a second factor", err)
+	}
+	return userID, nil
+}
+
+func (s *Service) getStatusHandler(c *contextmodel.ReqContext) response.Response {
+	userID, errResp := signedInUserID(c)
+	if errResp != nil {
+		return errResp
+	}
+	status, err := s.GetStatus(c.Req.Context(), userID)
+	if err != nil {
+		return response.Err(err)
+	}
+	return response.JSON(http.StatusOK, status)
+}
+
+// enrollHandler starts to enroll a second factor. The factor is saved when the user confirms it
+// with a code of the authenticator app or the response of the security key.
+func (s *Service) enrollHandler(c *contextmodel.ReqContext) response.Response {
+	userID, errResp := signedInUserID(c)
+	if errResp != nil {
+		return errResp
+	}
+	form := enrollForm{}
+	if err := web.Bind(c.Req, &form); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	result, err := s.beginEnrollment(c.Req.Context(), userID, form.Type, form.Name)
+	if err != nil {
+		return response.Err(err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+func (s *Service) finishEnrollmentHandler(c *contextmodel.ReqContext) response.Response {
+	userID, errResp := signedInUserID(c)
+	if errResp != nil {
+		return errResp
+	}
+	form := finishEnrollmentForm{}
+	if err := web.Bind(c.Req, &form); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	result, err := s.finishEnrollment(c.Req.Context(), userID, form.Code, form.Credential)
+	if err != nil {
+		return response.Err(err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+func (s *Service) deleteFactorHandler(c *contextmodel.ReqContext) response.Response {
+	userID, errResp := signedInUserID(c)
+	if errResp != nil {
+		return errResp
+	}
+	id, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+	if err := s.deleteFactor(c.Req.Context(), userID, id); err != nil {
+		return response.Err(err)
+	}
+	return response.Success("Second factor removed")
+}
+
+// recoveryCodesHandler replaces the recovery codes of the user, for example when they were all used.
+func (s *Service) recoveryCodesHandler(c *contextmodel.ReqContext) response.Response {
+	userID, errResp := signedInUserID(c)
+	if errResp != nil {
+		return errResp
+	}
+	factors, err := s.store.listFactors(c.Req.Context(), userID)
+	if err != nil {
+		return response.Err(err)
+	}
+	if len(factors) == 0 {
+		return response.Error(http.StatusBadRequest, "Enroll a second factor before creating recovery codes", nil)
+	}
+	codes, err := s.regenerateRecoveryCodes(c.Req.Context(), userID)
+	if err != nil {
+		return response.Err(err)
+	}
+	return response.JSON(http.StatusOK, map[string]any{"recoveryCodes": codes})
+}
+
+// loginEnrollHandler starts to enroll the first second factor of a user during the login.
+func (s *Service) loginEnrollHandler(c *contextmodel.ReqContext) response.Response {
+	form := loginEnrollForm{}
+	if err := web.Bind(c.Req, &form); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	ch, err := s.getChallenge(c.Req.Context(), form.Token)
+	if err != nil {
+		return response.Err(err)
+	}
+	if !ch.Enroll || ch.Verified {
+		return response.Error(http.StatusBadRequest, "The login does not require an enrollment", nil)
+	}
+	result, err := s.beginEnrollment(c.Req.Context(), ch.UserID, form.Type, form.Name)
+	if err != nil {
+		return response.Err(err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// loginFinishEnrollmentHandler saves the first second factor of a user during the login. The login
+// is then completed with the same token, without a code.
+func (s *Service) loginFinishEnrollmentHandler(c *contextmodel.ReqContext) response.Response {
+	form := loginFinishEnrollmentForm{}
+	if err := web.Bind(c.Req, &form); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	ctx := c.Req.Context()
+	ch, err := s.getChallenge(ctx, form.Token)
+	if err != nil {
+		return response.Err(err)
+	}
+	if !ch.Enroll || ch.Verified {
+		return response.Error(http.StatusBadRequest, "The login does not require an enrollment", nil)
+	}
+	if err := s.checkLockout(ctx, ch.Username); err != nil {
+		return response.Err(err)
+	}
+
+	result, err := s.finishEnrollment(ctx, ch.UserID, form.Code, form.Credential)
+	if err != nil {
+		s.failChallenge(ctx, ch, web.RemoteAddr(c.Req))
+		return response.Err(err)
+	}
+
+	ch.Verified = true
+	if err := s.saveChallenge(ctx, form.Token, ch); err != nil {
+		return response.Err(err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,368 @@
+package mfaimpl
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+	claims "github.com/grafana/authlib/types"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/mfa"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+var (
+	errMFARequired        = errutil.Unauthorized("mfa.required", errutil.WithPublicMessage("A second factor is required"))
+	errEnrollmentRequired = errutil.Unauthorized("mfa.enrollment-required", errutil.WithPublicMessage("You must enroll a second factor to log in"))
+	errBasicAuthDenied    = errutil.Unauthorized("mfa.basic-auth", errutil.WithPublicMessage("Basic auth is not allowed for users with a second factor, use a service account token"))
+	errInvalidChallenge   = errutil.Unauthorized("mfa.invalid-challenge", errutil.WithPublicMessage("The login expired, log in again"))
+	errBadForm            = errutil.BadRequest("mfa.invalid-form", errutil.WithPublicMessage("bad login data"))
+	errTooManyAttempts    = errutil.Unauthorized("mfa.too-many-attempts", errutil.WithPublicMessage("Too many consecutive incorrect login attempts, try again later"))
+)
+
+const challengeKeyPrefix = "mfa-challenge-"
+
+// challenge is the state of a login between the password and the second factor. It is stored in the
+// remote cache, so that the second step can be handled by any instance.
+type challenge struct {
+	UserID int64 `json:"userId"`
+	// Username is the username of the password login, which the failed attempts are recorded for.
+	Username   string `json:"username"`
+	AuthModule string `json:"authModule"`
+	AuthID     string `json:"authId"`
+	// Enroll is true when the user has no second factor yet and must enroll one to log in.
+	Enroll bool `json:"enroll"`
+	// Verified is true when the user enrolled a second factor during the login.
+	Verified        bool                  `json:"verified"`
+	WebAuthnSession *webauthn.SessionData `json:"webAuthnSession,omitempty"`
+	Expires         time.Time             `json:"expires"`
+}
+
+func challengeKey(token string) string {
+	// The token is a credential, only its hash is used as key.
+	sum := sha256.Sum256([]byte(token))
+	return challengeKeyPrefix + hex.EncodeToString(sum[:])
+}
+
+// isPasswordAuth returns true if the identity was authenticated with a password by Grafana or LDAP.
+func isPasswordAuth(id *authn.Identity, r *authn.Request) bool {
+	if id.SessionToken != nil || !id.IsIdentityType(claims.TypeUser) {
+		return false
+	}
+	module := r.GetMeta(authn.MetaKeyAuthModule)
+	return module == "grafana" || module == login.LDAPAuthModule
+}
+
+// challengeHook interrupts the logins with a password of users who have a second factor or must use
+// one. The login is completed by the MFA client with the token returned in the error.
+func (s *Service) challengeHook(ctx context.Context, id *authn.Identity, r *authn.Request) error {
+	if !isPasswordAuth(id, r) {
+		return nil
+	}
+	userID, err := id.GetInternalID()
+	if err != nil {
+		return err
+	}
+
+	factors, err := s.store.listFactors(ctx, userID)
+	if err != nil {
+		return err
+	}
+	required, err := s.isRequired(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if len(factors) == 0 && !required {
+		return nil
+	}
+
+	// Basic auth cannot ask for a second factor, it would make the second factor useless.
+	if r.GetMeta(authn.MetaKeyIsLogin) != "true" {
+		return errBasicAuthDenied.Errorf("user %d must use a second factor", userID)
+	}
+
+	username := r.GetMeta(authn.MetaKeyUsername)
+	if username == "" {
+		username = id.Login
+	}
+	if err := s.checkLockout(ctx, username); err != nil {
+		return err
+	}
+
+	ch := &challenge{
+		UserID:     userID,
+		Username:   username,
+		AuthModule: id.AuthenticatedBy,
+		AuthID:     id.AuthID,
+		Enroll:     len(factors) == 0,
+		Expires:    s.now().Add(s.cfg.MFA.ChallengeTTL),
+	}
+	token, err := util.GetRandomString(32)
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]any{"mfaToken": token}
+	var mfaErr errutil.Error
+	if ch.Enroll {
+		mfaErr = errEnrollmentRequired.Errorf("user %d must enroll a second factor", userID)
+	} else {
+		methods, err := s.challengeMethods(ctx, ch, factors, payload)
+		if err != nil {
+			return err
+		}
+		payload["methods"] = methods
+		mfaErr = errMFARequired.Errorf("user %d must use a second factor", userID)
+	}
+
+	if err := s.saveChallenge(ctx, token, ch); err != nil {
+		return err
+	}
+	mfaErr.PublicPayload = payload
+	return mfaErr
+}
+
+// challengeMethods returns the ways the user can complete the login, and adds the options to use
+// a security key to the payload.
+func (s *Service) challengeMethods(ctx context.Context, ch *challenge, factors []*factorEntity, payload map[string]any) ([]string, error) {
+	var hasTOTP, hasWebAuthn bool
+	for _, f := range factors {
+		switch mfa.FactorType(f.Type) {
+		case mfa.FactorTypeTOTP:
+			hasTOTP = true
+		case mfa.FactorTypeWebAuthn:
+			hasWebAuthn = true
+		}
+	}
+
+	methods := []string{}
+	if hasTOTP {
+		methods = append(methods, string(mfa.FactorTypeTOTP))
+	}
+	if hasWebAuthn {
+		account, _, err := s.webAuthnUser(ctx, &user.User{ID: ch.UserID})
+		if err != nil {
+			return nil, err
+		}
+		options, session, err := s.webAuthn.beginLogin(account)
+		if err != nil {
+			return nil, err
+		}
+		ch.WebAuthnSession = session
+		payload["publicKey"] = options
+		methods = append(methods, string(mfa.FactorTypeWebAuthn))
+	}
+	return append(methods, "recovery"), nil
+}
+
+func (s *Service) saveChallenge(ctx context.Context, token string, ch *challenge) error {
+	value, err := json.Marshal(ch)
+	if err != nil {
+		return err
+	}
+	return s.cache.Set(ctx, challengeKey(token), value, time.Until(ch.Expires))
+}
+
+func (s *Service) getChallenge(ctx context.Context, token string) (*challenge, error) {
+	if token == "" {
+		return nil, errInvalidChallenge.Errorf("missing token")
+	}
+	value, err := s.cache.Get(ctx, challengeKey(token))
+	if err != nil {
+		if errors.Is(err, remotecache.ErrCacheItemNotFound) {
+			return nil, errInvalidChallenge.Errorf("challenge not found")
+		}
+		return nil, err
+	}
+	var ch challenge
+	if err := json.Unmarshal(value, &ch); err != nil {
+		return nil, err
+	}
+	if !s.now().Before(ch.Expires) {
+		return nil, errInvalidChallenge.Errorf("challenge expired")
+	}
+	return &ch, nil
+}
+
+// checkLockout returns an error if the username is locked after too many failed logins, which include
+// the invalid second factors.
+func (s *Service) checkLockout(ctx context.Context, username string) error {
+	ok, err := s.loginAttempts.Validate(ctx, username)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errTooManyAttempts.Errorf("too many consecutive incorrect login attempts for user - login for user temporarily blocked")
+	}
+	return nil
+}
+
+// failChallenge records an invalid second factor as a failed login of the user, so that the user is locked
+// out like after invalid passwords.
+func (s *Service) failChallenge(ctx context.Context, ch *challenge, ipAddress string) {
+	if err := s.loginAttempts.Add(ctx, ch.Username, ipAddress); err != nil {
+		s.log.Error("Failed to record an invalid second factor", "userID", ch.UserID, "error", err)
+	}
+}
+
+// verify checks a code of an authenticator app, a recovery code or an assertion of a security key.
+func (s *Service) verify(ctx context.Context, ch *challenge, code string, cred json.RawMessage) error {
+	if len(cred) > 0 {
+		return s.verifyWebAuthn(ctx, ch, cred)
+	}
+
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return mfa.ErrInvalidCode.Errorf("missing code")
+	}
+	if len(code) == totpDigits {
+		return s.verifyTOTP(ctx, ch.UserID, code)
+	}
+
+	used, err := s.store.useRecoveryCode(ctx, ch.UserID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return mfa.ErrInvalidCode.Errorf("invalid recovery code")
+	}
+	s.log.Info("User logged in with a recovery code", "userID", ch.UserID)
+	return nil
+}
+
+func (s *Service) verifyTOTP(ctx context.Context, userID int64, code string) error {
+	factors, err := s.store.listFactors(ctx, userID)
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	for _, f := range factors {
+		if f.Type != string(mfa.FactorTypeTOTP) {
+			continue
+		}
+		encrypted, err := base64.StdEncoding.DecodeString(f.Secret)
+		if err != nil {
+			return err
+		}
+		secret, err := s.secretsService.Decrypt(ctx, encrypted)
+		if err != nil {
+			return err
+		}
+		step, ok, err := validateTOTP(string(secret), code, now, f.Counter)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		updated, err := s.store.useFactor(ctx, f.ID, f.Counter, step, now)
+		if err != nil {
+			return err
+		}
+		if updated {
+			return nil
+		}
+	}
+	return mfa.ErrInvalidCode.Errorf("invalid code")
+}
+
+func (s *Service) verifyWebAuthn(ctx context.Context, ch *challenge, cred json.RawMessage) error {
+	if ch.WebAuthnSession == nil {
+		return mfa.ErrInvalidCode.Errorf("no security key challenge")
+	}
+	account, credentials, err := s.webAuthnUser(ctx, &user.User{ID: ch.UserID})
+	if err != nil {
+		return err
+	}
+	used, err := s.webAuthn.verifyAssertion(account, ch.WebAuthnSession, cred)
+	if err != nil {
+		return mfa.ErrInvalidCode.Errorf("failed to verify security key: %w", err)
+	}
+	for factorID, stored := range credentials {
+		if !bytes.Equal(stored.ID, used.ID) {
+			continue
+		}
+		updated, err := s.store.useFactor(ctx, factorID, int64(stored.SignCount), int64(used.SignCount), s.now())
+		if err != nil {
+			return err
+		}
+		if !updated {
+			return mfa.ErrInvalidCode.Errorf("security key was used concurrently")
+		}
+		return nil
+	}
+	return mfa.ErrInvalidCode.Errorf("unknown security key")
+}
+
+type loginForm struct {
+	Token      string          `json:"mfaToken"`
+	Code       string          `json:"code"`
+	Credential json.RawMessage `json:"credential,omitempty"`
+}
+
+var _ authn.Client = new(client)
+
+// client completes a login interrupted by challengeHook with a second factor.
+type client struct {
+	service *Service
+}
+
+func (c *client) Name() string {
+	return authn.ClientMFA
+}
+
+func (c *client) IsEnabled() bool {
+	return true
+}
+
+func (c *client) Authenticate(ctx context.Context, r *authn.Request) (*authn.Identity, error) {
+	form := loginForm{}
+	if err := web.Bind(r.HTTPRequest, &form); err != nil {
+		return nil, errBadForm.Errorf("failed to parse request: %w", err)
+	}
+
+	ch, err := c.service.getChallenge(ctx, form.Token)
+	if err != nil {
+		return nil, err
+	}
+	if !ch.Verified {
+		if ch.Enroll {
+			return nil, errEnrollmentRequired.Errorf("user %d did not enroll a second factor", ch.UserID)
+		}
+		if err := c.service.checkLockout(ctx, ch.Username); err != nil {
+			return nil, err
+		}
+		if err := c.service.verify(ctx, ch, form.Code, form.Credential); err != nil {
+			c.service.failChallenge(ctx, ch, web.RemoteAddr(r.HTTPRequest))
+			return nil, err
+		}
+	}
+
+	// A challenge completes a single login.
+	if err := c.service.cache.Delete(ctx, challengeKey(form.Token)); err != nil {
+		return nil, err
+	}
+
+	return &authn.Identity{
+		ID:              strconv.FormatInt(ch.UserID, 10),
+		Type:            claims.TypeUser,
+		OrgID:           r.OrgID,
+		AuthenticatedBy: ch.AuthModule,
+		AuthID:          ch.AuthID,
+		ClientParams:    authn.ClientParams{FetchSyncedUser: true, SyncPermissions: true},
+	}, nil
+}
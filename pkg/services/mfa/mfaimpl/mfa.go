@@ -0,0 +1,343 @@
+package mfaimpl
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/loginattempt"
+	"github.com/grafana/grafana/pkg/services/mfa"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	enrollmentKeyPrefix = "mfa-enrollment-%d"
+	// enrollmentTTL is how long a user has to confirm a new second factor.
+	enrollmentTTL = 10 * time.Minute
+)
+
+var _ mfa.Service = (*Service)(nil)
+
+type Service struct {
+	cfg            *setting.Cfg
+	store          *store
+	cache          remotecache.CacheStorage
+	secretsService secrets.Service
+	userService    user.Service
+	orgService     org.Service
+	loginAttempts  loginattempt.Service
+	webAuthn       *webAuthn
+	log            log.Logger
+	now            func() time.Time
+}
+
+func ProvideService(
+	cfg *setting.Cfg,
+	sqlStore db.DB,
+	routeRegister routing.RouteRegister,
+	authnService authn.Service,
+	cache remotecache.CacheStorage,
+	secretsService secrets.Service,
+	userService user.Service,
+	orgService org.Service,
+	loginAttempts loginattempt.Service,
+) *Service {
+	s := &Service{
+		cfg:            cfg,
+		store:          &store{db: sqlStore},
+		cache:          cache,
+		secretsService: secretsService,
+		userService:    userService,
+		orgService:     orgService,
+		loginAttempts:  loginAttempts,
+		webAuthn:       newWebAuthn(cfg.MFA.WebAuthnRPID, cfg.MFA.Issuer, cfg.MFA.WebAuthnOrigins),
+		log:            log.New("mfa"),
+		now:            time.Now,
+	}
+	if !cfg.MFA.Enabled {
+		return s
+	}
+
+	// The challenge runs after the user is synced and before the permissions are loaded.
+	authnService.RegisterPostAuthHook(s.challengeHook, 105)
+	authnService.RegisterClient(&client{service: s})
+	s.registerAPIEndpoints(routeRegister)
+
+	return s
+}
+
+func (s *Service) GetStatus(ctx context.Context, userID int64) (*mfa.Status, error) {
+	entities, err := s.store.listFactors(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	required, err := s.isRequired(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := s.store.countRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &mfa.Status{Required: required, Factors: make([]*mfa.Factor, 0, len(entities)), RecoveryCodes: codes}
+	for _, entity := range entities {
+		status.Factors = append(status.Factors, entity.toFactor())
+	}
+	return status, nil
+}
+
+func (s *Service) Reset(ctx context.Context, userID int64) error {
+	if err := s.store.deleteAll(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.cache.Delete(ctx, fmt.Sprintf(enrollmentKeyPrefix, userID)); err != nil && !errors.Is(err, remotecache.ErrCacheItemNotFound) {
+		return err
+	}
+	s.log.Info("Removed the second factors of user", "userID", userID)
+	return nil
+}
+
+// isRequired returns true if the user has a role that enforces a second factor in any organization,
+// so that a user cannot avoid the second factor by logging in to another organization. Server admins
+// have every role, a second factor is required for them as soon as a role enforces it.
+func (s *Service) isRequired(ctx context.Context, userID int64) (bool, error) {
+	if len(s.cfg.MFA.EnforcedRoles) == 0 {
+		return false, nil
+	}
+	usr, err := s.userService.GetByID(ctx, &user.GetUserByIDQuery{ID: userID})
+	if err != nil {
+		return false, err
+	}
+	if usr.IsAdmin {
+		return true, nil
+	}
+	orgs, err := s.orgService.GetUserOrgList(ctx, &org.GetUserOrgListQuery{UserID: userID})
+	if err != nil {
+		return false, err
+	}
+	for _, o := range orgs {
+		for _, role := range s.cfg.MFA.EnforcedRoles {
+			if o.Role.Includes(org.RoleType(role)) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// EnrollmentStart is returned when a user starts to enroll a second factor. It holds either the key
+// of an authenticator app or the options to create a credential with a security key.
+type EnrollmentStart struct {
+	Type      mfa.FactorType                               `json:"type"`
+	Secret    string                                       `json:"secret,omitempty"`
+	URL       string                                       `json:"url,omitempty"`
+	PublicKey *protocol.PublicKeyCredentialCreationOptions `json:"publicKey,omitempty"`
+}
+
+// EnrollmentResult is returned when a user confirms a new second factor. RecoveryCodes are only
+// returned when the user had none, they cannot be read again.
+type EnrollmentResult struct {
+	Factor        *mfa.Factor `json:"factor"`
+	RecoveryCodes []string    `json:"recoveryCodes,omitempty"`
+}
+
+type enrollment struct {
+	Type            mfa.FactorType        `json:"type"`
+	Name            string                `json:"name"`
+	Secret          string                `json:"secret,omitempty"`
+	WebAuthnSession *webauthn.SessionData `json:"webAuthnSession,omitempty"`
+}
+
+// beginEnrollment starts to enroll a second factor, which is saved once the user confirms it.
+func (s *Service) beginEnrollment(ctx context.Context, userID int64, typ mfa.FactorType, name string) (*EnrollmentStart, error) {
+	usr, err := s.userService.GetByID(ctx, &user.GetUserByIDQuery{ID: userID})
+	if err != nil {
+		return nil, err
+	}
+	if len(name) > 190 {
+		return nil, mfa.ErrInvalidFactor.Errorf("the name cannot be longer than 190 characters")
+	}
+
+	e := &enrollment{Type: typ, Name: name}
+	start := &EnrollmentStart{Type: typ}
+	switch typ {
+	case mfa.FactorTypeTOTP:
+		if e.Name == "" {
+			e.Name = "Authenticator app"
+		}
+		if e.Secret, err = generateTOTPSecret(); err != nil {
+			return nil, err
+		}
+		start.Secret = e.Secret
+		start.URL = totpURL(s.cfg.MFA.Issuer, usr.Login, e.Secret)
+	case mfa.FactorTypeWebAuthn:
+		if e.Name == "" {
+			e.Name = "Security key"
+		}
+		account, _, err := s.webAuthnUser(ctx, usr)
+		if err != nil {
+			return nil, err
+		}
+		if start.PublicKey, e.WebAuthnSession, err = s.webAuthn.beginRegistration(account); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, mfa.ErrInvalidFactor.Errorf("unsupported factor type %q", typ)
+	}
+
+	value, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cache.Set(ctx, fmt.Sprintf(enrollmentKeyPrefix, userID), value, enrollmentTTL); err != nil {
+		return nil, err
+	}
+	return start, nil
+}
+
+// finishEnrollment verifies the code or the credential of the second factor being enrolled and saves it.
+func (s *Service) finishEnrollment(ctx context.Context, userID int64, code string, cred json.RawMessage) (*EnrollmentResult, error) {
+	key := fmt.Sprintf(enrollmentKeyPrefix, userID)
+	value, err := s.cache.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, remotecache.ErrCacheItemNotFound) {
+			return nil, mfa.ErrEnrollmentNotFound.Errorf("no enrollment for user %d", userID)
+		}
+		return nil, err
+	}
+	var e enrollment
+	if err := json.Unmarshal(value, &e); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	entity := &factorEntity{UserID: userID, Type: string(e.Type), Name: e.Name, Created: now}
+	switch e.Type {
+	case mfa.FactorTypeTOTP:
+		step, ok, err := validateTOTP(e.Secret, code, now, 0)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, mfa.ErrInvalidCode.Errorf("invalid code")
+		}
+		encrypted, err := s.secretsService.Encrypt(ctx, []byte(e.Secret), secrets.WithoutScope())
+		if err != nil {
+			return nil, err
+		}
+		entity.Secret = base64.StdEncoding.EncodeToString(encrypted)
+		entity.Counter = step
+	case mfa.FactorTypeWebAuthn:
+		if len(cred) == 0 || e.WebAuthnSession == nil {
+			return nil, mfa.ErrInvalidFactor.Errorf("missing credential")
+		}
+		account, _, err := s.webAuthnUser(ctx, &user.User{ID: userID})
+		if err != nil {
+			return nil, err
+		}
+		registered, err := s.webAuthn.verifyRegistration(account, e.WebAuthnSession, cred)
+		if err != nil {
+			return nil, mfa.ErrInvalidFactor.Errorf("failed to verify security key: %w", err)
+		}
+		entity.CredentialID = base64.RawURLEncoding.EncodeToString(registered.ID)
+		entity.PublicKey = base64.StdEncoding.EncodeToString(registered.PublicKey)
+		entity.Counter = int64(registered.SignCount)
+	}
+
+	if err := s.store.insertFactor(ctx, entity); err != nil {
+		return nil, err
+	}
+	if err := s.cache.Delete(ctx, key); err != nil {
+		s.log.Warn("Failed to delete enrollment", "userID", userID, "error", err)
+	}
+	s.log.Info("Enrolled second factor", "userID", userID, "type", e.Type)
+
+	result := &EnrollmentResult{Factor: entity.toFactor()}
+	count, err := s.store.countRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		if result.RecoveryCodes, err = s.regenerateRecoveryCodes(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// deleteFactor removes a second factor of a user. The last factor of a user who must use one cannot
+// be removed, and the recovery codes are removed with the last factor.
+func (s *Service) deleteFactor(ctx context.Context, userID, id int64) error {
+	factors, err := s.store.listFactors(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if len(factors) == 1 && factors[0].ID == id {
+		required, err := s.isRequired(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if required {
+			return mfa.ErrLastFactor.Errorf("user %d must keep a second factor", userID)
+		}
+		return s.store.deleteAll(ctx, userID)
+	}
+	return s.store.deleteFactor(ctx, userID, id)
+}
+
+// regenerateRecoveryCodes replaces the recovery codes of a user and returns the new codes.
+func (s *Service) regenerateRecoveryCodes(ctx context.Context, userID int64) ([]string, error) {
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.replaceRecoveryCodes(ctx, userID, hashes, s.now()); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// webAuthnUser returns the WebAuthn account of a user with the security keys of the user, keyed by the ID
+// of their factor.
+func (s *Service) webAuthnUser(ctx context.Context, usr *user.User) (*webAuthnUser, map[int64]*registeredCredential, error) {
+	factors, err := s.store.listFactors(ctx, usr.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	account := &webAuthnUser{id: usr.ID, name: usr.Login, displayName: usr.Name}
+	if account.displayName == "" {
+		account.displayName = usr.Login
+	}
+	credentials := map[int64]*registeredCredential{}
+	for _, f := range factors {
+		if f.Type != string(mfa.FactorTypeWebAuthn) {
+			continue
+		}
+		id, err := base64.RawURLEncoding.DecodeString(f.CredentialID)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, err := base64.StdEncoding.DecodeString(f.PublicKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		credentials[f.ID] = &registeredCredential{ID: id, PublicKey: key, SignCount: uint32(f.Counter)}
+		account.credentials = append(account.credentials, credentials[f.ID])
+	}
+	return account, credentials, nil
+}
@@ -0,0 +1,358 @@
+package mfaimpl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	claims "github.com/grafana/authlib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/loginattempt"
+	"github.com/grafana/grafana/pkg/services/mfa"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/org/orgtest"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/usertest"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tests/testsuite"
+)
+
+func TestMain(m *testing.M) {
+	testsuite.Run(m)
+}
+
+type testService struct {
+	*Service
+	client   *client
+	users    *usertest.FakeUserService
+	orgs     *orgtest.FakeOrgService
+	attempts *fakeLoginAttempts
+	cache    remotecache.FakeCacheStorage
+	clock    time.Time
+}
+
+// fakeLoginAttempts locks a user out after the maximum number of failed logins.
+type fakeLoginAttempts struct {
+	loginattempt.Service
+	max    int
+	failed map[string]int
+}
+
+func (f *fakeLoginAttempts) Add(_ context.Context, username, _ string) error {
+	f.failed[username]++
+	return nil
+}
+
+func (f *fakeLoginAttempts) Validate(_ context.Context, username string) (bool, error) {
+	return f.failed[username] < f.max, nil
+}
+
+func setupTestService(t *testing.T) *testService {
+	t.Helper()
+
+	cfg := setting.NewCfg()
+	cfg.MFA = setting.AuthMFASettings{
+		Enabled:         true,
+		Issuer:          "Grafana",
+		WebAuthnRPID:    testRPID,
+		WebAuthnOrigins: []string{testOrigin},
+		ChallengeTTL:    5 * time.Minute,
+	}
+	ts := &testService{
+		users:    &usertest.FakeUserService{ExpectedUser: &user.User{ID: 1, Login: "admin"}},
+		orgs:     &orgtest.FakeOrgService{ExpectedUserOrgDTO: []*org.UserOrgDTO{{OrgID: 1, Role: org.RoleViewer}}},
+		attempts: &fakeLoginAttempts{max: 3, failed: map[string]int{}},
+		cache:    remotecache.NewFakeCacheStorage(),
+		clock:    time.Date(2024, time.May, 2, 8, 0, 0, 0, time.UTC),
+	}
+	ts.Service = &Service{
+		cfg:            cfg,
+		store:          &store{db: db.InitTestDB(t)},
+		cache:          ts.cache,
+		secretsService: fakes.NewFakeSecretsService(),
+		userService:    ts.users,
+		orgService:     ts.orgs,
+		loginAttempts:  ts.attempts,
+		webAuthn:       newTestWebAuthn(),
+		log:            log.NewNopLogger(),
+		now:            func() time.Time { return ts.clock },
+	}
+	ts.client = &client{service: ts.Service}
+	return ts
+}
+
+func passwordIdentity(userID string) *authn.Identity {
+	return &authn.Identity{ID: userID, Type: claims.TypeUser, OrgID: 1, Login: "admin", AuthenticatedBy: "password"}
+}
+
+func passwordRequest(isLogin bool) *authn.Request {
+	r := &authn.Request{}
+	r.SetMeta(authn.MetaKeyAuthModule, "grafana")
+	if isLogin {
+		r.SetMeta(authn.MetaKeyIsLogin, "true")
+	}
+	return r
+}
+
+// login runs the challenge of a password login and returns the token and the payload of the error.
+func (ts *testService) login(t *testing.T, expected error) (string, map[string]any) {
+	t.Helper()
+
+	err := ts.challengeHook(context.Background(), passwordIdentity("1"), passwordRequest(true))
+	require.ErrorIs(t, err, expected)
+	var mfaErr errutil.Error
+	require.True(t, errors.As(err, &mfaErr))
+	token, ok := mfaErr.PublicPayload["mfaToken"].(string)
+	require.True(t, ok)
+	require.NotEmpty(t, token)
+	return token, mfaErr.PublicPayload
+}
+
+func (ts *testService) complete(t *testing.T, form loginForm) (*authn.Identity, error) {
+	t.Helper()
+
+	body, err := json.Marshal(form)
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodPost, "/api/login/mfa", bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	return ts.client.Authenticate(context.Background(), &authn.Request{OrgID: 1, HTTPRequest: req})
+}
+
+func (ts *testService) enrollTOTP(t *testing.T) (string, *EnrollmentResult) {
+	t.Helper()
+
+	start, err := ts.beginEnrollment(context.Background(), 1, mfa.FactorTypeTOTP, "")
+	require.NoError(t, err)
+	code, err := totpCode(start.Secret, totpStep(ts.clock))
+	require.NoError(t, err)
+	result, err := ts.finishEnrollment(context.Background(), 1, code, nil)
+	require.NoError(t, err)
+	return start.Secret, result
+}
+
+func TestIntegrationTOTPLogin(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ts := setupTestService(t)
+	ctx := context.Background()
+
+	t.Run("users without a second factor log in with their password", func(t *testing.T) {
+		require.NoError(t, ts.challengeHook(ctx, passwordIdentity("1"), passwordRequest(true)))
+	})
+
+	t.Run("enrollment requires a valid code", func(t *testing.T) {
+		_, err := ts.beginEnrollment(ctx, 1, mfa.FactorTypeTOTP, "")
+		require.NoError(t, err)
+		_, err = ts.finishEnrollment(ctx, 1, "000000", nil)
+		require.ErrorIs(t, err, mfa.ErrInvalidCode)
+	})
+
+	secret, result := ts.enrollTOTP(t)
+	require.Len(t, result.RecoveryCodes, 10)
+	assert.Equal(t, "Authenticator app", result.Factor.Name)
+
+	_, err := ts.finishEnrollment(ctx, 1, "000000", nil)
+	require.ErrorIs(t, err, mfa.ErrEnrollmentNotFound)
+
+	status, err := ts.GetStatus(ctx, 1)
+	require.NoError(t, err)
+	assert.False(t, status.Required)
+	require.Len(t, status.Factors, 1)
+	assert.Equal(t, 10, status.RecoveryCodes)
+
+	t.Run("password logins require the second factor", func(t *testing.T) {
+		_, payload := ts.login(t, errMFARequired)
+		assert.Equal(t, []string{"totp", "recovery"}, payload["methods"])
+	})
+
+	t.Run("basic auth is denied", func(t *testing.T) {
+		err := ts.challengeHook(ctx, passwordIdentity("1"), passwordRequest(false))
+		require.ErrorIs(t, err, errBasicAuthDenied)
+	})
+
+	t.Run("other logins are not challenged", func(t *testing.T) {
+		r := &authn.Request{}
+		r.SetMeta(authn.MetaKeyAuthModule, "oauth_generic_oauth")
+		r.SetMeta(authn.MetaKeyIsLogin, "true")
+		require.NoError(t, ts.challengeHook(ctx, passwordIdentity("1"), r))
+	})
+
+	t.Run("the code used to enroll cannot be used again", func(t *testing.T) {
+		token, _ := ts.login(t, errMFARequired)
+		code, err := totpCode(secret, totpStep(ts.clock))
+		require.NoError(t, err)
+		_, err = ts.complete(t, loginForm{Token: token, Code: code})
+		require.ErrorIs(t, err, mfa.ErrInvalidCode)
+	})
+
+	t.Run("a valid code completes the login once", func(t *testing.T) {
+		ts.clock = ts.clock.Add(totpPeriod)
+		token, _ := ts.login(t, errMFARequired)
+		code, err := totpCode(secret, totpStep(ts.clock))
+		require.NoError(t, err)
+
+		id, err := ts.complete(t, loginForm{Token: token, Code: code})
+		require.NoError(t, err)
+		assert.Equal(t, "1", id.ID)
+		assert.Equal(t, "password", id.AuthenticatedBy)
+		assert.True(t, id.ClientParams.FetchSyncedUser)
+
+		_, err = ts.complete(t, loginForm{Token: token, Code: code})
+		require.ErrorIs(t, err, errInvalidChallenge)
+	})
+
+	t.Run("recovery codes can be used once", func(t *testing.T) {
+		token, _ := ts.login(t, errMFARequired)
+		_, err := ts.complete(t, loginForm{Token: token, Code: result.RecoveryCodes[0]})
+		require.NoError(t, err)
+
+		token, _ = ts.login(t, errMFARequired)
+		_, err = ts.complete(t, loginForm{Token: token, Code: result.RecoveryCodes[0]})
+		require.ErrorIs(t, err, mfa.ErrInvalidCode)
+	})
+
+	t.Run("invalid codes lock the user out", func(t *testing.T) {
+		ts.attempts.failed = map[string]int{}
+		t.Cleanup(func() { ts.attempts.failed = map[string]int{} })
+
+		// The failed attempts are counted for the user, not for the login.
+		for i := 0; i < ts.attempts.max; i++ {
+			token, _ := ts.login(t, errMFARequired)
+			_, err := ts.complete(t, loginForm{Token: token, Code: "000000"})
+			require.ErrorIs(t, err, mfa.ErrInvalidCode)
+		}
+		assert.Equal(t, ts.attempts.max, ts.attempts.failed["admin"])
+
+		err := ts.challengeHook(ctx, passwordIdentity("1"), passwordRequest(true))
+		require.ErrorIs(t, err, errTooManyAttempts)
+	})
+
+	t.Run("a pending login cannot be completed once the user is locked out", func(t *testing.T) {
+		t.Cleanup(func() { ts.attempts.failed = map[string]int{} })
+
+		token, _ := ts.login(t, errMFARequired)
+		ts.attempts.failed["admin"] = ts.attempts.max
+		_, err := ts.complete(t, loginForm{Token: token, Code: result.RecoveryCodes[1]})
+		require.ErrorIs(t, err, errTooManyAttempts)
+	})
+
+	t.Run("the login expires", func(t *testing.T) {
+		token, _ := ts.login(t, errMFARequired)
+		ts.clock = ts.clock.Add(ts.cfg.MFA.ChallengeTTL)
+		_, err := ts.complete(t, loginForm{Token: token, Code: result.RecoveryCodes[1]})
+		require.ErrorIs(t, err, errInvalidChallenge)
+	})
+
+	t.Run("reset removes the second factors", func(t *testing.T) {
+		require.NoError(t, ts.Reset(ctx, 1))
+		status, err := ts.GetStatus(ctx, 1)
+		require.NoError(t, err)
+		assert.Empty(t, status.Factors)
+		assert.Zero(t, status.RecoveryCodes)
+		require.NoError(t, ts.challengeHook(ctx, passwordIdentity("1"), passwordRequest(true)))
+	})
+}
+
+func TestIntegrationWebAuthnLogin(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ts := setupTestService(t)
+	ctx := context.Background()
+	a := newFakeAuthenticator(t)
+
+	start, err := ts.beginEnrollment(ctx, 1, mfa.FactorTypeWebAuthn, "YubiKey")
+	require.NoError(t, err)
+	require.NotNil(t, start.PublicKey)
+	assert.Empty(t, start.PublicKey.CredentialExcludeList)
+	result, err := ts.finishEnrollment(ctx, 1, "", a.register(start.PublicKey.Challenge.String()))
+	require.NoError(t, err)
+	assert.Equal(t, "YubiKey", result.Factor.Name)
+
+	token, payload := ts.login(t, errMFARequired)
+	assert.Equal(t, []string{"webauthn", "recovery"}, payload["methods"])
+	options, ok := payload["publicKey"].(*protocol.PublicKeyCredentialRequestOptions)
+	require.True(t, ok)
+	require.Len(t, options.AllowedCredentials, 1)
+
+	t.Run("a response to another challenge is rejected", func(t *testing.T) {
+		_, other := ts.login(t, errMFARequired)
+		_, err = ts.complete(t, loginForm{Token: token, Credential: a.assert(other["publicKey"].(*protocol.PublicKeyCredentialRequestOptions).Challenge.String(), 1)})
+		require.ErrorIs(t, err, mfa.ErrInvalidCode)
+	})
+
+	t.Run("the security key completes the login", func(t *testing.T) {
+		_, err := ts.complete(t, loginForm{Token: token, Credential: a.assert(options.Challenge.String(), 1)})
+		require.NoError(t, err)
+	})
+
+	t.Run("a replayed response is rejected", func(t *testing.T) {
+		token, payload := ts.login(t, errMFARequired)
+		options := payload["publicKey"].(*protocol.PublicKeyCredentialRequestOptions)
+		_, err := ts.complete(t, loginForm{Token: token, Credential: a.assert(options.Challenge.String(), 1)})
+		require.ErrorIs(t, err, mfa.ErrInvalidCode)
+	})
+}
+
+func TestIntegrationEnforcedRoles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ts := setupTestService(t)
+	ts.cfg.MFA.EnforcedRoles = []string{string(org.RoleEditor)}
+	ctx := context.Background()
+
+	t.Run("viewers are not required to use a second factor", func(t *testing.T) {
+		require.NoError(t, ts.challengeHook(ctx, passwordIdentity("1"), passwordRequest(true)))
+	})
+
+	t.Run("server admins must use a second factor", func(t *testing.T) {
+		t.Cleanup(func() { ts.users.ExpectedUser = &user.User{ID: 1, Login: "admin"} })
+		ts.users.ExpectedUser = &user.User{ID: 1, Login: "admin", IsAdmin: true}
+		ts.login(t, errEnrollmentRequired)
+	})
+
+	// An admin of any organization includes the editor role.
+	ts.orgs.ExpectedUserOrgDTO = append(ts.orgs.ExpectedUserOrgDTO, &org.UserOrgDTO{OrgID: 2, Role: org.RoleAdmin})
+
+	token, _ := ts.login(t, errEnrollmentRequired)
+
+	t.Run("the login cannot be completed before the enrollment", func(t *testing.T) {
+		_, err := ts.complete(t, loginForm{Token: token, Code: "000000"})
+		require.ErrorIs(t, err, errEnrollmentRequired)
+	})
+
+	t.Run("basic auth is denied", func(t *testing.T) {
+		err := ts.challengeHook(ctx, passwordIdentity("1"), passwordRequest(false))
+		require.ErrorIs(t, err, errBasicAuthDenied)
+	})
+
+	t.Run("the last factor cannot be removed", func(t *testing.T) {
+		_, result := ts.enrollTOTP(t)
+		err := ts.deleteFactor(ctx, 1, result.Factor.ID)
+		require.ErrorIs(t, err, mfa.ErrLastFactor)
+
+		status, err := ts.GetStatus(ctx, 1)
+		require.NoError(t, err)
+		assert.True(t, status.Required)
+		assert.Len(t, status.Factors, 1)
+	})
+}
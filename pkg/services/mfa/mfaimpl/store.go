@@ -0,0 +1,147 @@
+package mfaimpl
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/mfa"
+)
+
+type factorEntity struct {
+	ID           int64      `xorm:"pk autoincr 'id'"`
+	UserID       int64      `xorm:"'user_id'"`
+	Type         string     `xorm:"'type'"`
+	Name         string     `xorm:"'name'"`
+	Secret       string     `xorm:"'secret'"`
+	CredentialID string     `xorm:"'credential_id'"`
+	PublicKey    string     `xorm:"'public_key'"`
+	Counter      int64      `xorm:"'counter'"`
+	Created      time.Time  `xorm:"'created'"`
+	LastUsed     *time.Time `xorm:"'last_used'"`
+}
+
+func (factorEntity) TableName() string {
+	return "user_mfa_factor"
+}
+
+func (entity *factorEntity) toFactor() *mfa.Factor {
+	return &mfa.Factor{
+		ID:       entity.ID,
+		UserID:   entity.UserID,
+		Type:     mfa.FactorType(entity.Type),
+		Name:     entity.Name,
+		Created:  entity.Created,
+		LastUsed: entity.LastUsed,
+	}
+}
+
+type recoveryCodeEntity struct {
+	ID       int64     `xorm:"pk autoincr 'id'"`
+	UserID   int64     `xorm:"'user_id'"`
+	CodeHash string    `xorm:"'code_hash'"`
+	Created  time.Time `xorm:"'created'"`
+}
+
+func (recoveryCodeEntity) TableName() string {
+	return "user_mfa_recovery_code"
+}
+
+type store struct {
+	db db.DB
+}
+
+func (s *store) insertFactor(ctx context.Context, factor *factorEntity) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Insert(factor)
+		return err
+	})
+}
+
+func (s *store) listFactors(ctx context.Context, userID int64) ([]*factorEntity, error) {
+	var result []*factorEntity
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("user_id = ?", userID).Asc("id").Find(&result)
+	})
+	return result, err
+}
+
+// useFactor records that a factor was used. The counter is only updated if it did not change since
+// the factor was read, so that a code or a signature is accepted only once.
+func (s *store) useFactor(ctx context.Context, id, expectedCounter, counter int64, now time.Time) (bool, error) {
+	var updated bool
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		res, err := sess.Exec("UPDATE user_mfa_factor SET counter = ?, last_used = ? WHERE id = ? AND counter = ?", counter, now, id, expectedCounter)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		updated = affected == 1
+		return err
+	})
+	return updated, err
+}
+
+func (s *store) deleteFactor(ctx context.Context, userID, id int64) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		affected, err := sess.Where("user_id = ? AND id = ?", userID, id).Delete(&factorEntity{})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return mfa.ErrFactorNotFound.Errorf("factor %d not found", id)
+		}
+		return nil
+	})
+}
+
+// replaceRecoveryCodes removes the recovery codes of a user and adds new ones.
+func (s *store) replaceRecoveryCodes(ctx context.Context, userID int64, hashes []string, now time.Time) error {
+	return s.db.InTransaction(ctx, func(ctx context.Context) error {
+		return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+			if _, err := sess.Exec("DELETE FROM user_mfa_recovery_code WHERE user_id = ?", userID); err != nil {
+				return err
+			}
+			for _, hash := range hashes {
+				if _, err := sess.Insert(&recoveryCodeEntity{UserID: userID, CodeHash: hash, Created: now}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+func (s *store) countRecoveryCodes(ctx context.Context, userID int64) (int, error) {
+	var count int64
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		count, err = sess.Where("user_id = ?", userID).Count(&recoveryCodeEntity{})
+		return err
+	})
+	return int(count), err
+}
+
+// useRecoveryCode removes a recovery code of a user. It returns false if the user does not have the code.
+func (s *store) useRecoveryCode(ctx context.Context, userID int64, hash string) (bool, error) {
+	var used bool
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		affected, err := sess.Where("user_id = ? AND code_hash = ?", userID, hash).Delete(&recoveryCodeEntity{})
+		used = affected == 1
+		return err
+	})
+	return used, err
+}
+
+// deleteAll removes all the factors and recovery codes of a user.
+func (s *store) deleteAll(ctx context.Context, userID int64) error {
+	return s.db.InTransaction(ctx, func(ctx context.Context) error {
+		return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+			if _, err := sess.Exec("DELETE FROM user_mfa_factor WHERE user_id = ?", userID); err != nil {
+				return err
+			}
+			_, err := sess.Exec("DELETE FROM user_mfa_recovery_code WHERE user_id = ?", userID)
+			return err
+		})
+	})
+}
@@ -0,0 +1,101 @@
+package mfaimpl
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA1 key of the test vectors of RFC 6238, "12345678901234567890" encoded with base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// The codes of RFC 6238, Appendix B, truncated to 6 digits.
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1111111111, code: "050471"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+	}
+	for _, tt := range tests {
+		code, err := totpCode(rfcSecret, totpStep(time.Unix(tt.unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, tt.code, code, "unix time %d", tt.unix)
+	}
+
+	_, err := totpCode("not base32!", 1)
+	require.Error(t, err)
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	current := totpStep(now)
+	codeAt := func(step int64) string {
+		code, err := totpCode(rfcSecret, step)
+		require.NoError(t, err)
+		return code
+	}
+
+	t.Run("accepts the current code", func(t *testing.T) {
+		step, ok, err := validateTOTP(rfcSecret, codeAt(current), now, 0)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, current, step)
+	})
+
+	t.Run("accepts the codes of the adjacent steps", func(t *testing.T) {
+		for _, s := range []int64{current - 1, current + 1} {
+			step, ok, err := validateTOTP(rfcSecret, codeAt(s), now, 0)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, s, step)
+		}
+	})
+
+	t.Run("rejects codes outside of the skew", func(t *testing.T) {
+		for _, s := range []int64{current - 2, current + 2} {
+			_, ok, err := validateTOTP(rfcSecret, codeAt(s), now, 0)
+			require.NoError(t, err)
+			assert.False(t, ok)
+		}
+	})
+
+	t.Run("rejects codes that were already used", func(t *testing.T) {
+		_, ok, err := validateTOTP(rfcSecret, codeAt(current), now, current)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		_, ok, err = validateTOTP(rfcSecret, codeAt(current-1), now, current-1)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("rejects codes of the wrong length", func(t *testing.T) {
+		_, ok, err := validateTOTP(rfcSecret, "12345", now, 0)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestTOTPURL(t *testing.T) {
+	secret, err := generateTOTPSecret()
+	require.NoError(t, err)
+	key, err := totpEncoding.DecodeString(secret)
+	require.NoError(t, err)
+	assert.Len(t, key, totpSecretSize)
+
+	u, err := url.Parse(totpURL("Grafana", "admin", secret))
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/Grafana:admin", u.Path)
+	assert.Equal(t, secret, u.Query().Get("secret"))
+	assert.Equal(t, "Grafana", u.Query().Get("issuer"))
+}
@@ -0,0 +1,143 @@
+package mfaimpl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+// Security keys are registered and verified with go-webauthn (https://www.w3.org/TR/webauthn-2/). They are
+// used as a second factor: attestations are not required and the user presence is enough.
+
+var errInvalidWebAuthnResponse = errors.New("invalid WebAuthn response")
+
+// registeredCredential is a credential verified at registration.
+type registeredCredential struct {
+	ID        []byte
+	PublicKey []byte
+	SignCount uint32
+}
+
+// webAuthnUser is the account of a user for the relying party. The user handle is the ID of the user.
+type webAuthnUser struct {
+	id          int64
+	name        string
+	displayName string
+	credentials []*registeredCredential
+}
+
+var _ webauthn.User = (*webAuthnUser)(nil)
+
+func (u *webAuthnUser) WebAuthnID() []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(u.id))
+}
+
+func (u *webAuthnUser) WebAuthnName() string {
+	return u.name
+}
+
+func (u *webAuthnUser) WebAuthnDisplayName() string {
+	return u.displayName
+}
+
+func (u *webAuthnUser) WebAuthnIcon() string {
+	return ""
+}
+
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.credentials))
+	for _, c := range u.credentials {
+		credentials = append(credentials, webauthn.Credential{
+			ID:            c.ID,
+			PublicKey:     c.PublicKey,
+			Authenticator: webauthn.Authenticator{SignCount: c.SignCount},
+		})
+	}
+	return credentials
+}
+
+type webAuthn struct {
+	relyingParty *webauthn.WebAuthn
+}
+
+func newWebAuthn(rpID, rpName string, origins []string) *webAuthn {
+	// The configuration is validated when a ceremony begins, so that an invalid root URL only breaks
+	// security keys.
+	return &webAuthn{relyingParty: &webauthn.WebAuthn{Config: &webauthn.Config{
+		RPID:                  rpID,
+		RPDisplayName:         rpName,
+		RPOrigins:             origins,
+		AttestationPreference: protocol.PreferNoAttestation,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			UserVerification: protocol.VerificationDiscouraged,
+		},
+	}}}
+}
+
+// beginRegistration returns the options of navigator.credentials.create and the session to verify the response.
+// The existing credentials of the user are excluded.
+func (w *webAuthn) beginRegistration(user *webAuthnUser) (*protocol.PublicKeyCredentialCreationOptions, *webauthn.SessionData, error) {
+	exclude := make([]protocol.CredentialDescriptor, 0, len(user.credentials))
+	for _, c := range user.WebAuthnCredentials() {
+		exclude = append(exclude, c.Descriptor())
+	}
+	creation, session, err := w.relyingParty.BeginRegistration(user, webauthn.WithExclusions(exclude))
+	if err != nil {
+		return nil, nil, err
+	}
+	return &creation.Response, session, nil
+}
+
+// beginLogin returns the options of navigator.credentials.get and the session to verify the response.
+func (w *webAuthn) beginLogin(user *webAuthnUser) (*protocol.PublicKeyCredentialRequestOptions, *webauthn.SessionData, error) {
+	assertion, session, err := w.relyingParty.BeginLogin(user, webauthn.WithUserVerification(protocol.VerificationDiscouraged))
+	if err != nil {
+		return nil, nil, err
+	}
+	return &assertion.Response, session, nil
+}
+
+// verifyRegistration verifies the response of navigator.credentials.create, as serialized by
+// PublicKeyCredential.toJSON, and returns the new credential.
+func (w *webAuthn) verifyRegistration(user *webAuthnUser, session *webauthn.SessionData, response json.RawMessage) (*registeredCredential, error) {
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(response))
+	if err != nil {
+		return nil, webAuthnError(err)
+	}
+	cred, err := w.relyingParty.CreateCredential(user, *session, parsed)
+	if err != nil {
+		return nil, webAuthnError(err)
+	}
+	return &registeredCredential{ID: cred.ID, PublicKey: cred.PublicKey, SignCount: cred.Authenticator.SignCount}, nil
+}
+
+// verifyAssertion verifies the response of navigator.credentials.get, as serialized by PublicKeyCredential.toJSON,
+// and returns the credential of the user that was used with its new signature counter.
+func (w *webAuthn) verifyAssertion(user *webAuthnUser, session *webauthn.SessionData, response json.RawMessage) (*registeredCredential, error) {
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(response))
+	if err != nil {
+		return nil, webAuthnError(err)
+	}
+	cred, err := w.relyingParty.ValidateLogin(user, *session, parsed)
+	if err != nil {
+		return nil, webAuthnError(err)
+	}
+	// A counter that does not increase indicates that the authenticator may have been cloned.
+	if cred.Authenticator.CloneWarning {
+		return nil, fmt.Errorf("%w: signature counter did not increase", errInvalidWebAuthnResponse)
+	}
+	return &registeredCredential{ID: cred.ID, PublicKey: cred.PublicKey, SignCount: cred.Authenticator.SignCount}, nil
+}
+
+func webAuthnError(err error) error {
+	var protocolErr *protocol.Error
+	if errors.As(err, &protocolErr) && protocolErr.DevInfo != "" {
+		return fmt.Errorf("%w: %s: %s", errInvalidWebAuthnResponse, protocolErr.Details, protocolErr.DevInfo)
+	}
+	return fmt.Errorf("%w: %w", errInvalidWebAuthnResponse, err)
+}
@@ -0,0 +1,239 @@
+package mfaimpl
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRPID   = "grafana.example.com"
+	testOrigin = "https://grafana.example.com"
+
+	flagUserPresent            = 0x01
+	flagAttestedCredentialData = 0x40
+)
+
+// fakeAuthenticator is a security key with a P-256 key, which produces the responses of a browser.
+type fakeAuthenticator struct {
+	t      *testing.T
+	key    *ecdsa.PrivateKey
+	id     []byte
+	rpID   string
+	origin string
+}
+
+func newFakeAuthenticator(t *testing.T) *fakeAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	id := make([]byte, 16)
+	_, err = rand.Read(id)
+	require.NoError(t, err)
+	return &fakeAuthenticator{t: t, key: key, id: id, rpID: testRPID, origin: testOrigin}
+}
+
+func (a *fakeAuthenticator) clientData(typ, challenge string) []byte {
+	data, err := json.Marshal(map[string]string{"type": typ, "challenge": challenge, "origin": a.origin})
+	require.NoError(a.t, err)
+	return data
+}
+
+func (a *fakeAuthenticator) authData(flags byte, signCount uint32) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	data := slices.Concat(rpIDHash[:], []byte{flags}, binary.BigEndian.AppendUint32(nil, signCount))
+	if flags&flagAttestedCredentialData == 0 {
+		return data
+	}
+
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	a.key.X.FillBytes(x)
+	a.key.Y.FillBytes(y)
+	// The public key is a COSE key of type EC2 for ES256 on P-256.
+	publicKey, err := cbor.Marshal(map[int]any{1: 2, 3: -7, -1: 1, -2: x, -3: y})
+	require.NoError(a.t, err)
+	return slices.Concat(data, make([]byte, 16), binary.BigEndian.AppendUint16(nil, uint16(len(a.id))), a.id, publicKey)
+}
+
+// credential returns a PublicKeyCredential serialized by toJSON.
+func (a *fakeAuthenticator) credential(response map[string][]byte) json.RawMessage {
+	encoded := map[string]string{}
+	for k, v := range response {
+		encoded[k] = base64.RawURLEncoding.EncodeToString(v)
+	}
+	id := base64.RawURLEncoding.EncodeToString(a.id)
+	data, err := json.Marshal(map[string]any{"id": id, "rawId": id, "type": "public-key", "response": encoded})
+	require.NoError(a.t, err)
+	return data
+}
+
+// register returns the response of navigator.credentials.create.
+func (a *fakeAuthenticator) register(challenge string) json.RawMessage {
+	return a.registerWithClientData(a.clientData("webauthn.create", challenge))
+}
+
+func (a *fakeAuthenticator) registerWithClientData(clientData []byte) json.RawMessage {
+	attestation, err := cbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": a.authData(flagUserPresent|flagAttestedCredentialData, 0),
+	})
+	require.NoError(a.t, err)
+	return a.credential(map[string][]byte{"clientDataJSON": clientData, "attestationObject": attestation})
+}
+
+// assert returns the response of navigator.credentials.get.
+func (a *fakeAuthenticator) assert(challenge string, signCount uint32) json.RawMessage {
+	return a.assertWithAuthData(challenge, a.authData(flagUserPresent, signCount), a.authData(flagUserPresent, signCount))
+}
+
+// assertWithAuthData returns a response with the authenticator data sent, which may differ from the signed one.
+func (a *fakeAuthenticator) assertWithAuthData(challenge string, signed, sent []byte) json.RawMessage {
+	clientData := a.clientData("webauthn.get", challenge)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(slices.Concat(signed, clientDataHash[:]))
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(a.t, err)
+	return a.credential(map[string][]byte{"clientDataJSON": clientData, "authenticatorData": sent, "signature": sig})
+}
+
+func newTestWebAuthn() *webAuthn {
+	return newWebAuthn(testRPID, "Grafana", []string{testOrigin})
+}
+
+func beginTestRegistration(t *testing.T, w *webAuthn, account *webAuthnUser) (string, *webauthn.SessionData) {
+	t.Helper()
+	options, session, err := w.beginRegistration(account)
+	require.NoError(t, err)
+	return options.Challenge.String(), session
+}
+
+func TestWebAuthnRegistration(t *testing.T) {
+	w := newTestWebAuthn()
+	account := &webAuthnUser{id: 1, name: "admin", displayName: "admin"}
+
+	t.Run("returns the credential of a valid response", func(t *testing.T) {
+		challenge, session := beginTestRegistration(t, w, account)
+		a := newFakeAuthenticator(t)
+		registered, err := w.verifyRegistration(account, session, a.register(challenge))
+		require.NoError(t, err)
+		assert.Equal(t, a.id, registered.ID)
+		assert.Equal(t, uint32(0), registered.SignCount)
+		assert.NotEmpty(t, registered.PublicKey)
+	})
+
+	t.Run("excludes the existing credentials", func(t *testing.T) {
+		options, _, err := w.beginRegistration(&webAuthnUser{id: 1, name: "admin", credentials: []*registeredCredential{{ID: []byte("key")}}})
+		require.NoError(t, err)
+		require.Len(t, options.CredentialExcludeList, 1)
+		assert.Equal(t, []byte("key"), []byte(options.CredentialExcludeList[0].CredentialID))
+	})
+
+	t.Run("rejects another challenge", func(t *testing.T) {
+		_, session := beginTestRegistration(t, w, account)
+		other, _ := beginTestRegistration(t, w, account)
+		_, err := w.verifyRegistration(account, session, newFakeAuthenticator(t).register(other))
+		require.ErrorIs(t, err, errInvalidWebAuthnResponse)
+	})
+
+	t.Run("rejects another origin", func(t *testing.T) {
+		challenge, session := beginTestRegistration(t, w, account)
+		a := newFakeAuthenticator(t)
+		a.origin = "https://evil.example.com"
+		_, err := w.verifyRegistration(account, session, a.register(challenge))
+		require.ErrorIs(t, err, errInvalidWebAuthnResponse)
+	})
+
+	t.Run("rejects another relying party", func(t *testing.T) {
+		challenge, session := beginTestRegistration(t, w, account)
+		a := newFakeAuthenticator(t)
+		a.rpID = "evil.example.com"
+		_, err := w.verifyRegistration(account, session, a.register(challenge))
+		require.ErrorIs(t, err, errInvalidWebAuthnResponse)
+	})
+
+	t.Run("rejects an assertion", func(t *testing.T) {
+		challenge, session := beginTestRegistration(t, w, account)
+		a := newFakeAuthenticator(t)
+		_, err := w.verifyRegistration(account, session, a.registerWithClientData(a.clientData("webauthn.get", challenge)))
+		require.ErrorIs(t, err, errInvalidWebAuthnResponse)
+	})
+}
+
+func TestWebAuthnAssertion(t *testing.T) {
+	w := newTestWebAuthn()
+	a := newFakeAuthenticator(t)
+	account := &webAuthnUser{id: 1, name: "admin", displayName: "admin"}
+	challenge, session := beginTestRegistration(t, w, account)
+	registered, err := w.verifyRegistration(account, session, a.register(challenge))
+	require.NoError(t, err)
+	account.credentials = []*registeredCredential{registered}
+
+	beginLogin := func(t *testing.T, account *webAuthnUser) (string, *webauthn.SessionData) {
+		t.Helper()
+		options, session, err := w.beginLogin(account)
+		require.NoError(t, err)
+		require.Len(t, options.AllowedCredentials, 1)
+		return options.Challenge.String(), session
+	}
+
+	t.Run("returns the new signature counter", func(t *testing.T) {
+		challenge, session := beginLogin(t, account)
+		used, err := w.verifyAssertion(account, session, a.assert(challenge, 3))
+		require.NoError(t, err)
+		assert.Equal(t, a.id, used.ID)
+		assert.Equal(t, uint32(3), used.SignCount)
+	})
+
+	t.Run("accepts authenticators without a counter", func(t *testing.T) {
+		challenge, session := beginLogin(t, account)
+		used, err := w.verifyAssertion(account, session, a.assert(challenge, 0))
+		require.NoError(t, err)
+		assert.Equal(t, uint32(0), used.SignCount)
+	})
+
+	t.Run("rejects a counter that did not increase", func(t *testing.T) {
+		stored := &webAuthnUser{id: 1, credentials: []*registeredCredential{{ID: registered.ID, PublicKey: registered.PublicKey, SignCount: 3}}}
+		challenge, session := beginLogin(t, stored)
+		_, err := w.verifyAssertion(stored, session, a.assert(challenge, 3))
+		require.ErrorIs(t, err, errInvalidWebAuthnResponse)
+	})
+
+	t.Run("rejects another challenge", func(t *testing.T) {
+		_, session := beginLogin(t, account)
+		other, _ := beginLogin(t, account)
+		_, err := w.verifyAssertion(account, session, a.assert(other, 1))
+		require.ErrorIs(t, err, errInvalidWebAuthnResponse)
+	})
+
+	t.Run("rejects the signature of another key", func(t *testing.T) {
+		challenge, session := beginLogin(t, account)
+		other := newFakeAuthenticator(t)
+		other.id = a.id
+		_, err := w.verifyAssertion(account, session, other.assert(challenge, 1))
+		require.ErrorIs(t, err, errInvalidWebAuthnResponse)
+	})
+
+	t.Run("rejects tampered authenticator data", func(t *testing.T) {
+		challenge, session := beginLogin(t, account)
+		_, err := w.verifyAssertion(account, session, a.assertWithAuthData(challenge, a.authData(flagUserPresent, 1), a.authData(flagUserPresent, 2)))
+		require.ErrorIs(t, err, errInvalidWebAuthnResponse)
+	})
+
+	t.Run("rejects a response without user presence", func(t *testing.T) {
+		challenge, session := beginLogin(t, account)
+		_, err := w.verifyAssertion(account, session, a.assertWithAuthData(challenge, a.authData(0, 1), a.authData(0, 1)))
+		require.ErrorIs(t, err, errInvalidWebAuthnResponse)
+	})
+}
@@ -0,0 +1,24 @@
+package mfatest
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/mfa"
+)
+
+var _ mfa.Service = (*FakeService)(nil)
+
+type FakeService struct {
+	ExpectedStatus *mfa.Status
+	ExpectedError  error
+	ResetUserIDs   []int64
+}
+
+func (s *FakeService) GetStatus(ctx context.Context, userID int64) (*mfa.Status, error) {
+	return s.ExpectedStatus, s.ExpectedError
+}
+
+func (s *FakeService) Reset(ctx context.Context, userID int64) error {
+	s.ResetUserIDs = append(s.ResetUserIDs, userID)
+	return s.ExpectedError
+}
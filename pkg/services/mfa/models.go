@@ -0,0 +1,42 @@
+package mfa
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+)
+
+var (
+	ErrFactorNotFound     = errutil.NotFound("mfa.factor-not-found", errutil.WithPublicMessage("Second factor not found"))
+	ErrEnrollmentNotFound = errutil.BadRequest("mfa.enrollment-not-found", errutil.WithPublicMessage("No enrollment in progress, start again"))
+	ErrInvalidFactor      = errutil.BadRequest("mfa.invalid-factor", errutil.WithPublicMessage("Invalid second factor"))
+	ErrInvalidCode        = errutil.Unauthorized("mfa.invalid-code", errutil.WithPublicMessage("Invalid code"))
+	ErrLastFactor         = errutil.BadRequest("mfa.last-factor", errutil.WithPublicMessage("You must keep at least one second factor"))
+)
+
+type FactorType string
+
+const (
+	// FactorTypeTOTP is an authenticator app that generates time-based one-time passwords.
+	FactorTypeTOTP FactorType = "totp"
+	// FactorTypeWebAuthn is a security key or a platform authenticator.
+	FactorTypeWebAuthn FactorType = "webauthn"
+)
+
+// Factor is a second factor enrolled by a user. The secrets of a factor are never returned.
+type Factor struct {
+	ID       int64      `json:"id"`
+	UserID   int64      `json:"userId"`
+	Type     FactorType `json:"type"`
+	Name     string     `json:"name"`
+	Created  time.Time  `json:"created"`
+	LastUsed *time.Time `json:"lastUsed,omitempty"`
+}
+
+type Status struct {
+	// Required is true when one of the roles of the user enforces a second factor.
+	Required bool      `json:"required"`
+	Factors  []*Factor `json:"factors"`
+	// RecoveryCodes is the number of unused recovery codes.
+	RecoveryCodes int `json:"recoveryCodes"`
+}
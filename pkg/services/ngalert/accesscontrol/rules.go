@@ -158,6 +158,26 @@ func (r *RuleService) AuthorizeAccessInFolder(ctx context.Context, user identity
 	})
 }
 
+// AuthorizeWriteInFolder checks that the identity.Requester has permissions to create or update alert rules in the given folder,
+// which requires the following permissions:
+// - ("folders:read") read the folder
+// - ("alert.rules:read") read alert rules in the folder
+// - ("alert.rules:create" or "alert.rules:write") create or update alert rules in the folder
+// Returns error if at least one permission is missing or if something went wrong during the permission evaluation
+func (r *RuleService) AuthorizeWriteInFolder(ctx context.Context, user identity.Requester, rule models.Namespaced) error {
+	scope := dashboards.ScopeFoldersProvider.GetResourceScopeUID(rule.GetNamespaceUID())
+	eval := accesscontrol.EvalAll(
+		getReadFolderAccessEvaluator(rule.GetNamespaceUID()),
+		accesscontrol.EvalAny(
+			accesscontrol.EvalPermission(ruleCreate, scope),
+			accesscontrol.EvalPermission(ruleUpdate, scope),
+		),
+	)
+	return r.HasAccessOrError(ctx, user, eval, func() string {
+		return fmt.Sprintf("change rules in folder '%s'", rule.GetNamespaceUID())
+	})
+}
+
 // AuthorizeRuleChanges analyzes changes in the rule group, and checks whether the changes are authorized.
 // NOTE: if there are rules for deletion, and the user does not have access to data sources that a rule uses, the rule is removed from the list.
 // If the user is not authorized to perform the changes the function returns ErrAuthorization with a description of what action is not authorized.
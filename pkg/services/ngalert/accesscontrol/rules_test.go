@@ -731,3 +731,60 @@ func TestCanReadAllRules(t *testing.T) {
 		assert.Equalf(t, tc.expected, result, "permissions: %v", tc.permissions)
 	}
 }
+
+func TestAuthorizeWriteInFolder(t *testing.T) {
+	ac := &recordingAccessControlFake{}
+	svc := NewRuleService(ac)
+	folderScope := dashboards.ScopeFoldersProvider.GetResourceScopeUID("test")
+
+	testCases := []struct {
+		permissions map[string][]string
+		expected    bool
+	}{
+		{
+			permissions: map[string][]string{
+				ruleRead:                     {folderScope},
+				ruleCreate:                   {folderScope},
+				dashboards.ActionFoldersRead: {folderScope},
+			},
+			expected: true,
+		},
+		{
+			permissions: map[string][]string{
+				ruleRead:                     {dashboards.ScopeFoldersProvider.GetResourceAllScope()},
+				ruleUpdate:                   {dashboards.ScopeFoldersProvider.GetResourceAllScope()},
+				dashboards.ActionFoldersRead: {dashboards.ScopeFoldersProvider.GetResourceAllScope()},
+			},
+			expected: true,
+		},
+		{
+			permissions: map[string][]string{
+				ruleRead:                     {folderScope},
+				dashboards.ActionFoldersRead: {folderScope},
+			},
+		},
+		{
+			permissions: map[string][]string{
+				ruleRead:                     {folderScope},
+				ruleDelete:                   {folderScope},
+				dashboards.ActionFoldersRead: {folderScope},
+			},
+		},
+		{
+			permissions: map[string][]string{
+				ruleRead:                     {folderScope},
+				ruleCreate:                   {dashboards.ScopeFoldersProvider.GetResourceScopeUID("other")},
+				dashboards.ActionFoldersRead: {folderScope},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		err := svc.AuthorizeWriteInFolder(context.Background(), createUserWithPermissions(tc.permissions), models.Backtest{NamespaceUID: "test"})
+		if tc.expected {
+			assert.NoErrorf(t, err, "permissions: %v", tc.permissions)
+		} else {
+			assert.ErrorIsf(t, err, ErrAuthorizationBase, "permissions: %v", tc.permissions)
+		}
+	}
+}
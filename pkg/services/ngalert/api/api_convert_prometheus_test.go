@@ -1349,6 +1349,7 @@ func createConvertPrometheusSrv(t *testing.T, opts ...convertPrometheusSrvOption
 		log.New("test"),
 		&provisioning.NotificationSettingsValidatorProviderFake{},
 		options.fakeAccessControlRuleService,
+		nil,
 	)
 
 	cfg := &setting.UnifiedAlertingSettings{
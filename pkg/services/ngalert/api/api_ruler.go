@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
 	"net/url"
 	"slices"
@@ -509,6 +510,10 @@ func (srv RulerSrv) performUpdateAlertRules(ctx context.Context, c *contextmodel
 			return err
 		}
 
+		if err := validateRuleDependencies(tranCtx, srv.store, groupKey.OrgID, groupChanges); err != nil {
+			return err
+		}
+
 		newOrUpdatedNotificationSettings := groupChanges.NewOrUpdatedNotificationSettings()
 		if len(newOrUpdatedNotificationSettings) > 0 {
 			dbConfig, err = srv.amConfigStore.GetLatestAlertmanagerConfiguration(tranCtx, groupChanges.GroupKey.OrgID)
@@ -739,6 +744,63 @@ func validateQueries(ctx context.Context, groupChanges *store.GroupDelta, valida
 	return nil
 }
 
+// validateRuleDependencies checks that the rules the changed rules depend on exist, and that the changes
+// do not create a dependency cycle.
+func validateRuleDependencies(ctx context.Context, ruleStore RuleStore, orgID int64, ch *store.GroupDelta) error {
+	changed := make([]*ngmodels.AlertRule, 0, len(ch.New)+len(ch.Update))
+	for i, rule := range ch.New {
+		if rule.UID == "" {
+			// UIDs of new rules can be assigned on insert, but they still can be part of a cycle through recorded metrics.
+			rule = rule.Copy()
+			rule.UID = fmt.Sprintf("__new_rule_%d__", i)
+		}
+		changed = append(changed, rule)
+	}
+	for _, upd := range ch.Update {
+		changed = append(changed, upd.New)
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	existing, err := ruleStore.ListAlertRules(ctx, &ngmodels.ListAlertRulesQuery{OrgID: orgID})
+	if err != nil {
+		return fmt.Errorf("failed to fetch rules of organization: %w", err)
+	}
+	byUID := make(map[string]*ngmodels.AlertRule, len(existing)+len(ch.New))
+	for _, rule := range existing {
+		byUID[rule.UID] = rule
+	}
+	for _, rule := range ch.Delete {
+		delete(byUID, rule.UID)
+	}
+	for _, rule := range changed {
+		byUID[rule.UID] = rule
+	}
+
+	for _, rule := range changed {
+		for _, uid := range rule.Metadata.DependsOn {
+			if _, ok := byUID[uid]; !ok {
+				return fmt.Errorf("%w: rule '%s' depends on rule with UID '%s' that does not exist", ngmodels.ErrAlertRuleFailedValidation, rule.Title, uid)
+			}
+		}
+	}
+
+	deps := ngmodels.BuildRuleDependencies(slices.Collect(maps.Values(byUID)))
+	for _, rule := range changed {
+		cycle := deps.FindCycle(rule.GetKey())
+		if cycle == nil {
+			continue
+		}
+		titles := make([]string, 0, len(cycle))
+		for _, key := range cycle {
+			titles = append(titles, fmt.Sprintf("'%s'", byUID[key.UID].Title))
+		}
+		return fmt.Errorf("%w: rule '%s' is part of a dependency cycle %s", ngmodels.ErrAlertRuleFailedValidation, rule.Title, strings.Join(titles, " -> "))
+	}
+	return nil
+}
+
 // shouldValidate returns true if the rule is not paused and there are changes in the rule that are not ignored
 func shouldValidate(delta store.RuleDelta) bool {
 	for _, diff := range delta.Diff {
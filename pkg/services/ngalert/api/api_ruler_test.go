@@ -951,6 +951,53 @@ func TestValidateQueries(t *testing.T) {
 	})
 }
 
+func TestValidateRuleDependencies(t *testing.T) {
+	gen := models.RuleGen.With(models.RuleGen.WithOrgID(1))
+	dependsOn := func(uids ...string) models.AlertRuleMutator {
+		return models.RuleGen.WithMetadata(models.AlertRuleMetadata{DependsOn: uids})
+	}
+	ruleStore := fakes.NewRuleStore(t)
+	a := gen.With(gen.WithUID("a"), gen.WithTitle("A")).GenerateRef()
+	b := gen.With(gen.WithUID("b"), gen.WithTitle("B"), dependsOn("a")).GenerateRef()
+	ruleStore.PutRule(context.Background(), a, b)
+
+	t.Run("should accept dependencies on existing rules", func(t *testing.T) {
+		delta := &store.GroupDelta{
+			New: []*models.AlertRule{gen.With(gen.WithUID("c"), dependsOn("a", "b")).GenerateRef()},
+		}
+		require.NoError(t, validateRuleDependencies(context.Background(), ruleStore, 1, delta))
+	})
+
+	t.Run("should reject dependencies on missing rules", func(t *testing.T) {
+		delta := &store.GroupDelta{
+			New: []*models.AlertRule{gen.With(gen.WithUID("c"), dependsOn("missing")).GenerateRef()},
+		}
+		err := validateRuleDependencies(context.Background(), ruleStore, 1, delta)
+		require.ErrorIs(t, err, models.ErrAlertRuleFailedValidation)
+		require.ErrorContains(t, err, "missing")
+	})
+
+	t.Run("should reject dependencies on deleted rules", func(t *testing.T) {
+		delta := &store.GroupDelta{
+			Delete: []*models.AlertRule{a},
+			Update: []store.RuleDelta{{Existing: b, New: b}},
+		}
+		err := validateRuleDependencies(context.Background(), ruleStore, 1, delta)
+		require.ErrorIs(t, err, models.ErrAlertRuleFailedValidation)
+	})
+
+	t.Run("should reject dependency cycles", func(t *testing.T) {
+		updated := a.Copy()
+		updated.Metadata.DependsOn = []string{"b"}
+		delta := &store.GroupDelta{
+			Update: []store.RuleDelta{{Existing: a, New: updated}},
+		}
+		err := validateRuleDependencies(context.Background(), ruleStore, 1, delta)
+		require.ErrorIs(t, err, models.ErrAlertRuleFailedValidation)
+		require.ErrorContains(t, err, "'A' -> 'B' -> 'A'")
+	})
+}
+
 func createServiceWithProvenanceStore(store *fakes.RuleStore, provenanceStore provisioning.ProvisioningStore) *RulerSrv {
 	svc := createService(store, nil)
 	svc.provenanceStore = provenanceStore
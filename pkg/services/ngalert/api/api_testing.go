@@ -2,7 +2,9 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -25,6 +27,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/folder"
+	authz "github.com/grafana/grafana/pkg/services/ngalert/accesscontrol"
 	. "github.com/grafana/grafana/pkg/services/ngalert/api/compat"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	apivalidation "github.com/grafana/grafana/pkg/services/ngalert/api/validation"
@@ -49,6 +52,7 @@ type TestingApiSrv struct {
 	evaluator       eval.EvaluatorFactory
 	cfg             *setting.UnifiedAlertingSettings
 	backtesting     *backtesting.Engine
+	backtests       *backtesting.Runner
 	featureManager  featuremgmt.FeatureToggles
 	appUrl          *url.URL
 	tracer          tracing.Tracer
@@ -227,40 +231,53 @@ func (srv TestingApiSrv) BacktestAlertRule(c *contextmodel.ReqContext, cmd apimo
 		return ErrResp(http.StatusNotFound, nil, "Backgtesting API is not enabled")
 	}
 
+	rule, errResp := srv.backtestRule(c, cmd)
+	if errResp != nil {
+		return errResp
+	}
+
+	result, err := srv.backtesting.Test(c.Req.Context(), c.SignedInUser, rule, cmd.From, cmd.To)
+	if err != nil {
+		if errors.Is(err, backtesting.ErrInvalidInputData) {
+			return ErrResp(400, err, "Failed to evaluate")
+		}
+		return ErrResp(500, err, "Failed to evaluate")
+	}
+
+	body, err := data.FrameToJSON(result, data.IncludeAll)
+	if err != nil {
+		return ErrResp(500, err, "Failed to convert frame to JSON")
+	}
+	return response.JSON(http.StatusOK, body)
+}
+
+// backtestRule validates the backtest configuration and builds the rule to test from it.
+func (srv TestingApiSrv) backtestRule(c *contextmodel.ReqContext, cmd apimodels.BacktestConfig) (*ngmodels.AlertRule, response.Response) {
 	if cmd.From.After(cmd.To) {
-		return ErrResp(400, nil, "From cannot be greater than To")
+		return nil, ErrResp(400, nil, "From cannot be greater than To")
 	}
 
 	noDataState, err := ngmodels.NoDataStateFromString(string(cmd.NoDataState))
 
 	if err != nil {
-		return ErrResp(400, err, "")
+		return nil, ErrResp(400, err, "")
 	}
 	forInterval := time.Duration(cmd.For)
 	if forInterval < 0 {
-		return ErrResp(400, nil, "Bad For interval")
+		return nil, ErrResp(400, nil, "Bad For interval")
 	}
 
 	intervalSeconds, err := apivalidation.ValidateInterval(time.Duration(cmd.Interval), srv.cfg.BaseInterval)
 	if err != nil {
-		return ErrResp(400, err, "")
+		return nil, ErrResp(400, err, "")
 	}
 
 	queries := AlertQueriesFromApiAlertQueries(cmd.Data)
 	if err := srv.authz.AuthorizeDatasourceAccessForRule(c.Req.Context(), c.SignedInUser, &ngmodels.AlertRule{Data: queries}); err != nil {
-		return errorToResponse(err)
+		return nil, errorToResponse(err)
 	}
 
-	rule := &ngmodels.AlertRule{
-		// ID:             0,
-		// Updated:        time.Time{},
-		// Version:        0,
-		// NamespaceUID:   "",
-		// DashboardUID:   nil,
-		// PanelID:        nil,
-		// RuleGroup:      "",
-		// RuleGroupIndex: 0,
-		// ExecErrState:   "",
+	return &ngmodels.AlertRule{
 		Title: cmd.Title,
 		// prefix backtesting- is to distinguish between executions of regular rule and backtesting in logs (like expression engine, evaluator, state manager etc)
 		UID:             "backtesting-" + util.GenerateShortUID(),
@@ -272,19 +289,222 @@ func (srv TestingApiSrv) BacktestAlertRule(c *contextmodel.ReqContext, cmd apimo
 		For:             forInterval,
 		Annotations:     cmd.Annotations,
 		Labels:          cmd.Labels,
+	}, nil
+}
+
+// RouteCreateBacktest persists a backtest of the rule and runs it in the background.
+func (srv TestingApiSrv) RouteCreateBacktest(c *contextmodel.ReqContext, cmd apimodels.PostableBacktest) response.Response {
+	if !srv.featureManager.IsEnabled(c.Req.Context(), featuremgmt.FlagAlertingBacktesting) {
+		return ErrResp(http.StatusNotFound, nil, "Backgtesting API is not enabled")
 	}
+	return srv.startBacktest(c, cmd, cmd, nil, nil)
+}
 
-	result, err := srv.backtesting.Test(c.Req.Context(), c.SignedInUser, rule, cmd.From, cmd.To)
+// RouteSimulateNotifications starts a backtest of the rule with its notification settings or a proposed notification
+// policy tree, to preview the notifications that the Alertmanager of the organization would have sent for its alerts.
+func (srv TestingApiSrv) RouteSimulateNotifications(c *contextmodel.ReqContext, cmd apimodels.PostableNotificationSimulation) response.Response {
+	if !srv.featureManager.IsEnabled(c.Req.Context(), featuremgmt.FlagAlertingBacktesting) {
+		return ErrResp(http.StatusNotFound, nil, "Backgtesting API is not enabled")
+	}
+	return srv.startBacktest(c, cmd.PostableBacktest, cmd, cmd.NotificationSettings, cmd.Route)
+}
+
+// startBacktest starts a backtest of the rule of the request. The request is stored as the configuration of the backtest.
+func (srv TestingApiSrv) startBacktest(c *contextmodel.ReqContext, cmd apimodels.PostableBacktest, request any, settings *apimodels.AlertRuleNotificationSettings, route *apimodels.Route) response.Response {
+	rule, errResp := srv.backtestRule(c, cmd.BacktestConfig)
+	if errResp != nil {
+		return errResp
+	}
+	opts := backtesting.SimulationOptions{Route: route}
+	if cmd.FolderUID != "" {
+		folder, err := srv.folderService.GetNamespaceByUID(c.Req.Context(), cmd.FolderUID, c.GetOrgID(), c.SignedInUser)
+		if err != nil {
+			return toNamespaceErrorResponse(dashboards.ErrFolderAccessDenied)
+		}
+		rule.NamespaceUID = folder.UID
+		if err := srv.authz.AuthorizeAccessInFolder(c.Req.Context(), c.SignedInUser, rule); err != nil {
+			return errorToResponse(err)
+		}
+		opts.FolderTitle = folder.Title
+	}
+	if settings != nil {
+		rule.NotificationSettings = NotificationSettingsFromAlertRuleNotificationSettings(settings)
+	}
+
+	config, err := json.Marshal(request)
 	if err != nil {
-		if errors.Is(err, backtesting.ErrInvalidInputData) {
-			return ErrResp(400, err, "Failed to evaluate")
+		return ErrResp(500, err, "Failed to encode backtest configuration")
+	}
+	b := &ngmodels.Backtest{
+		RuleUID:      cmd.RuleUID,
+		RuleVersion:  cmd.RuleVersion,
+		NamespaceUID: rule.NamespaceUID,
+		Title:        cmd.Title,
+		From:         cmd.From,
+		To:           cmd.To,
+		Config:       string(config),
+	}
+	if err := srv.backtests.Start(c.Req.Context(), c.SignedInUser, rule, b, opts); err != nil {
+		switch {
+		case errors.Is(err, backtesting.ErrInvalidInputData):
+			return ErrResp(400, err, "Failed to start backtest")
+		case errors.Is(err, ngmodels.ErrTooManyBacktests):
+			return ErrResp(http.StatusTooManyRequests, err, "Failed to start backtest")
 		}
-		return ErrResp(500, err, "Failed to evaluate")
+		return ErrResp(500, err, "Failed to start backtest")
 	}
+	return response.JSON(http.StatusAccepted, toGettableBacktest(b, false))
+}
 
-	body, err := data.FrameToJSON(result, data.IncludeAll)
+func (srv TestingApiSrv) RouteGetBacktests(c *contextmodel.ReqContext) response.Response {
+	if !srv.featureManager.IsEnabled(c.Req.Context(), featuremgmt.FlagAlertingBacktesting) {
+		return ErrResp(http.StatusNotFound, nil, "Backgtesting API is not enabled")
+	}
+
+	backtests, err := srv.backtests.List(c.Req.Context(), ngmodels.ListBacktestsQuery{
+		OrgID:   c.GetOrgID(),
+		RuleUID: c.Query("rule_uid"),
+		Limit:   c.QueryInt("limit"),
+	})
 	if err != nil {
-		return ErrResp(500, err, "Failed to convert frame to JSON")
+		return ErrResp(500, err, "Failed to list backtests")
 	}
-	return response.JSON(http.StatusOK, body)
+	result := make(apimodels.GettableBacktests, 0, len(backtests))
+	for _, b := range backtests {
+		// The backtests the user cannot read are left out, like the rules of other folders.
+		if err := srv.authorizeBacktestRead(c.Req.Context(), c.SignedInUser, b); err != nil {
+			if errors.Is(err, authz.ErrAuthorizationBase) {
+				continue
+			}
+			return ErrResp(500, err, "Failed to list backtests")
+		}
+		result = append(result, toGettableBacktest(b, false))
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+func (srv TestingApiSrv) RouteGetBacktest(c *contextmodel.ReqContext, uid string) response.Response {
+	if !srv.featureManager.IsEnabled(c.Req.Context(), featuremgmt.FlagAlertingBacktesting) {
+		return ErrResp(http.StatusNotFound, nil, "Backgtesting API is not enabled")
+	}
+
+	b, err := srv.backtests.Get(c.Req.Context(), c.GetOrgID(), uid)
+	if err != nil {
+		return backtestErrorResponse(err)
+	}
+	if err := srv.authorizeBacktestRead(c.Req.Context(), c.SignedInUser, b); err != nil {
+		return backtestErrorResponse(err)
+	}
+	return response.JSON(http.StatusOK, toGettableBacktest(b, true))
+}
+
+func (srv TestingApiSrv) RouteCancelBacktest(c *contextmodel.ReqContext, uid string) response.Response {
+	if !srv.featureManager.IsEnabled(c.Req.Context(), featuremgmt.FlagAlertingBacktesting) {
+		return ErrResp(http.StatusNotFound, nil, "Backgtesting API is not enabled")
+	}
+
+	if err := srv.authorizeBacktestChange(c.Req.Context(), c.SignedInUser, uid); err != nil {
+		return backtestErrorResponse(err)
+	}
+	if err := srv.backtests.Cancel(c.Req.Context(), c.GetOrgID(), uid); err != nil {
+		return backtestErrorResponse(err)
+	}
+	return response.JSON(http.StatusAccepted, util.DynMap{"message": "backtest canceled"})
+}
+
+func (srv TestingApiSrv) RouteDeleteBacktest(c *contextmodel.ReqContext, uid string) response.Response {
+	if !srv.featureManager.IsEnabled(c.Req.Context(), featuremgmt.FlagAlertingBacktesting) {
+		return ErrResp(http.StatusNotFound, nil, "Backgtesting API is not enabled")
+	}
+
+	if err := srv.authorizeBacktestChange(c.Req.Context(), c.SignedInUser, uid); err != nil {
+		return backtestErrorResponse(err)
+	}
+	if err := srv.backtests.Delete(c.Req.Context(), c.GetOrgID(), uid); err != nil {
+		return backtestErrorResponse(err)
+	}
+	return response.Empty(http.StatusNoContent)
+}
+
+// authorizeBacktestRead checks that the user can read the rules of the folder of the backtest and query its data sources.
+func (srv TestingApiSrv) authorizeBacktestRead(ctx context.Context, user identity.Requester, b *ngmodels.Backtest) error {
+	if b.NamespaceUID != "" {
+		if err := srv.authz.AuthorizeAccessInFolder(ctx, user, b); err != nil {
+			return err
+		}
+	}
+	var cfg apimodels.BacktestConfig
+	if err := json.Unmarshal([]byte(b.Config), &cfg); err != nil {
+		return fmt.Errorf("failed to decode backtest configuration: %w", err)
+	}
+	return srv.authz.AuthorizeDatasourceAccessForRule(ctx, user, &ngmodels.AlertRule{UID: b.RuleUID, Data: AlertQueriesFromApiAlertQueries(cfg.Data)})
+}
+
+// authorizeBacktestChange checks that the user can cancel or delete the backtest. Besides its creator, only the users
+// who can change the rules of its folder can.
+func (srv TestingApiSrv) authorizeBacktestChange(ctx context.Context, user identity.Requester, uid string) error {
+	b, err := srv.backtests.Get(ctx, user.GetOrgID(), uid)
+	if err != nil {
+		return err
+	}
+	if err := srv.authorizeBacktestRead(ctx, user, b); err != nil {
+		return err
+	}
+	if b.CreatedBy == user.GetIdentifier() {
+		return nil
+	}
+	if b.NamespaceUID == "" {
+		return authz.NewAuthorizationErrorGeneric("change backtests of other users without a folder")
+	}
+	return srv.authz.AuthorizeWriteInFolder(ctx, user, b)
+}
+
+func backtestErrorResponse(err error) response.Response {
+	switch {
+	case errors.Is(err, ngmodels.ErrBacktestNotFound):
+		return ErrResp(http.StatusNotFound, err, "")
+	case errors.Is(err, backtesting.ErrBacktestFinished):
+		return ErrResp(http.StatusConflict, err, "")
+	}
+	return errorToResponse(err)
+}
+
+// toGettableBacktest converts the backtest to its API model. The configuration and results are only
+// included if withResult is true.
+func toGettableBacktest(b *ngmodels.Backtest, withResult bool) apimodels.GettableBacktest {
+	result := apimodels.GettableBacktest{
+		UID:         b.UID,
+		RuleUID:     b.RuleUID,
+		RuleVersion: b.RuleVersion,
+		FolderUID:   b.NamespaceUID,
+		Title:       b.Title,
+		Status:      string(b.Status),
+		Progress:    b.Progress,
+		Evaluations: b.Evaluations,
+		From:        b.From,
+		To:          b.To,
+		Error:       b.Error,
+		CreatedBy:   b.CreatedBy,
+		Created:     b.Created,
+		Updated:     b.Updated,
+	}
+	if !withResult {
+		return result
+	}
+	if b.Config != "" {
+		var cfg apimodels.BacktestConfig
+		if err := json.Unmarshal([]byte(b.Config), &cfg); err == nil {
+			result.Config = &cfg
+		}
+	}
+	if b.Result != "" {
+		result.Result = json.RawMessage(b.Result)
+	}
+	if b.Notifications != "" {
+		_ = json.Unmarshal([]byte(b.Notifications), &result.Notifications)
+	}
+	if b.Suppressed != "" {
+		_ = json.Unmarshal([]byte(b.Suppressed), &result.Suppressed)
+	}
+	return result
 }
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	acMock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/ngalert/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/backtesting"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func TestRouteBacktestJobs(t *testing.T) {
+	config, err := json.Marshal(definitions.BacktestConfig{
+		Data: []definitions.AlertQuery{{RefID: "A", DatasourceUID: "ds", Model: json.RawMessage(`{}`)}},
+	})
+	require.NoError(t, err)
+
+	newBacktests := func() *fakeBacktestStore {
+		return &fakeBacktestStore{backtests: map[string]*models.Backtest{
+			"in-folder":      {ID: 1, OrgID: 1, UID: "in-folder", NamespaceUID: "folder", Config: string(config), CreatedBy: "creator", Status: models.BacktestStatusRunning},
+			"in-other":       {ID: 2, OrgID: 1, UID: "in-other", NamespaceUID: "other", Config: string(config), CreatedBy: "creator", Status: models.BacktestStatusRunning},
+			"without-folder": {ID: 3, OrgID: 1, UID: "without-folder", Config: string(config), CreatedBy: "creator", Status: models.BacktestStatusRunning},
+		}}
+	}
+	folderScope := dashboards.ScopeFoldersProvider.GetResourceScopeUID("folder")
+	readFolder := []ac.Permission{
+		{Action: ac.ActionAlertingRuleRead, Scope: folderScope},
+		{Action: dashboards.ActionFoldersRead, Scope: folderScope},
+	}
+	queryDatasource := ac.Permission{Action: datasources.ActionQuery, Scope: datasources.ScopeProvider.GetResourceScopeUID("ds")}
+
+	newSrv := func(backtests *fakeBacktestStore, permissions ...ac.Permission) *TestingApiSrv {
+		return &TestingApiSrv{
+			authz:          accesscontrol.NewRuleService(acMock.New().WithPermissions(permissions)),
+			backtests:      backtesting.NewRunner(nil, backtests, nil, nil),
+			featureManager: featuremgmt.WithFeatures(featuremgmt.FlagAlertingBacktesting),
+		}
+	}
+	reqCtx := func(userUID string) *contextmodel.ReqContext {
+		return &contextmodel.ReqContext{
+			Context:      &web.Context{Req: httptest.NewRequest(http.MethodGet, "/api/v1/rule/backtest/jobs", nil)},
+			SignedInUser: &user.SignedInUser{OrgID: 1, UserUID: userUID},
+		}
+	}
+
+	t.Run("should list only the backtests of readable folders", func(t *testing.T) {
+		srv := newSrv(newBacktests(), append(readFolder, queryDatasource)...)
+		resp := srv.RouteGetBacktests(reqCtx("user"))
+		require.Equal(t, http.StatusOK, resp.Status())
+
+		var result definitions.GettableBacktests
+		require.NoError(t, json.Unmarshal(resp.Body(), &result))
+		uids := make([]string, 0, len(result))
+		for _, b := range result {
+			uids = append(uids, b.UID)
+		}
+		require.ElementsMatch(t, []string{"in-folder", "without-folder"}, uids)
+	})
+
+	t.Run("should require access to the folder and the data sources to get a backtest", func(t *testing.T) {
+		srv := newSrv(newBacktests(), append(readFolder, queryDatasource)...)
+		require.Equal(t, http.StatusOK, srv.RouteGetBacktest(reqCtx("user"), "in-folder").Status())
+		require.Equal(t, http.StatusForbidden, srv.RouteGetBacktest(reqCtx("user"), "in-other").Status())
+
+		srv = newSrv(newBacktests(), readFolder...)
+		require.Equal(t, http.StatusForbidden, srv.RouteGetBacktest(reqCtx("user"), "in-folder").Status())
+	})
+
+	t.Run("should let the creator cancel and delete a backtest", func(t *testing.T) {
+		srv := newSrv(newBacktests(), append(readFolder, queryDatasource)...)
+		require.Equal(t, http.StatusAccepted, srv.RouteCancelBacktest(reqCtx("creator"), "in-folder").Status())
+		require.Equal(t, http.StatusNoContent, srv.RouteDeleteBacktest(reqCtx("creator"), "without-folder").Status())
+	})
+
+	t.Run("should require rule write access to the folder to cancel and delete the backtests of other users", func(t *testing.T) {
+		backtests := newBacktests()
+		srv := newSrv(backtests, append(readFolder, queryDatasource)...)
+		require.Equal(t, http.StatusForbidden, srv.RouteCancelBacktest(reqCtx("user"), "in-folder").Status())
+		require.Equal(t, http.StatusForbidden, srv.RouteDeleteBacktest(reqCtx("user"), "in-folder").Status())
+		require.Len(t, backtests.backtests, 3)
+
+		srv = newSrv(backtests, append(readFolder, queryDatasource, ac.Permission{Action: ac.ActionAlertingRuleUpdate, Scope: folderScope})...)
+		require.Equal(t, http.StatusAccepted, srv.RouteCancelBacktest(reqCtx("user"), "in-folder").Status())
+		require.Equal(t, http.StatusNoContent, srv.RouteDeleteBacktest(reqCtx("user"), "in-folder").Status())
+		// only the creator can change backtests without a folder
+		require.Equal(t, http.StatusForbidden, srv.RouteDeleteBacktest(reqCtx("user"), "without-folder").Status())
+	})
+}
+
+type fakeBacktestStore struct {
+	backtests map[string]*models.Backtest
+}
+
+func (f *fakeBacktestStore) InsertBacktest(_ context.Context, b *models.Backtest) error {
+	f.backtests[b.UID] = b
+	return nil
+}
+
+func (f *fakeBacktestStore) UpdateBacktestProgress(_ context.Context, _ int64, _ int) (bool, error) {
+	return true, nil
+}
+
+func (f *fakeBacktestStore) FinishBacktest(_ context.Context, _ *models.Backtest) (bool, error) {
+	return true, nil
+}
+
+func (f *fakeBacktestStore) CancelBacktest(ctx context.Context, orgID int64, uid string) (bool, error) {
+	b, err := f.GetBacktest(ctx, orgID, uid)
+	if err != nil {
+		return false, err
+	}
+	if b.Status.IsFinal() {
+		return false, nil
+	}
+	f.backtests[uid].Status = models.BacktestStatusCanceled
+	return true, nil
+}
+
+func (f *fakeBacktestStore) GetBacktest(_ context.Context, orgID int64, uid string) (*models.Backtest, error) {
+	b, ok := f.backtests[uid]
+	if !ok || b.OrgID != orgID {
+		return nil, models.ErrBacktestNotFound
+	}
+	cp := *b
+	return &cp, nil
+}
+
+func (f *fakeBacktestStore) ListBacktests(_ context.Context, query models.ListBacktestsQuery) ([]*models.Backtest, error) {
+	var result []*models.Backtest
+	for _, b := range f.backtests {
+		if b.OrgID == query.OrgID {
+			cp := *b
+			result = append(result, &cp)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeBacktestStore) DeleteBacktest(ctx context.Context, orgID int64, uid string) error {
+	if _, err := f.GetBacktest(ctx, orgID, uid); err != nil {
+		return err
+	}
+	delete(f.backtests, uid)
+	return nil
+}
+
+func (f *fakeBacktestStore) FailStaleBacktests(_ context.Context, _ time.Time) (int64, error) {
+	return 0, nil
+}
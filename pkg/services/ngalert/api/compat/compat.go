@@ -446,6 +446,7 @@ func AlertRuleEditorSettingsFromModelEditorSettings(es models.EditorSettings) *d
 func AlertRuleMetadataFromModelMetadata(es models.AlertRuleMetadata) *definitions.AlertRuleMetadata {
 	return &definitions.AlertRuleMetadata{
 		EditorSettings: *AlertRuleEditorSettingsFromModelEditorSettings(es.EditorSettings),
+		DependsOn:      es.DependsOn,
 	}
 }
 
@@ -525,6 +526,7 @@ func AlertRuleRecordExportFromRecord(r *models.Record) *definitions.AlertRuleRec
 		Metric:              r.Metric,
 		From:                r.From,
 		TargetDatasourceUID: pointerOmitEmpty(r.TargetDatasourceUID),
+		TargetTable:         pointerOmitEmpty(r.TargetTable),
 	}
 }
 
@@ -536,6 +538,7 @@ func ModelRecordFromApiRecord(r *definitions.Record) *models.Record {
 		Metric:              r.Metric,
 		From:                r.From,
 		TargetDatasourceUID: r.TargetDatasourceUID,
+		TargetTable:         r.TargetTable,
 	}
 }
 
@@ -547,6 +550,7 @@ func ApiRecordFromModelRecord(r *models.Record) *definitions.Record {
 		Metric:              r.Metric,
 		From:                r.From,
 		TargetDatasourceUID: r.TargetDatasourceUID,
+		TargetTable:         r.TargetTable,
 	}
 }
 
@@ -21,7 +21,13 @@ import (
 
 type TestingApi interface {
 	BacktestConfig(*contextmodel.ReqContext) response.Response
+	RouteCancelBacktest(*contextmodel.ReqContext) response.Response
+	RouteCreateBacktest(*contextmodel.ReqContext) response.Response
+	RouteDeleteBacktest(*contextmodel.ReqContext) response.Response
 	RouteEvalQueries(*contextmodel.ReqContext) response.Response
+	RouteGetBacktest(*contextmodel.ReqContext) response.Response
+	RouteGetBacktests(*contextmodel.ReqContext) response.Response
+	RouteSimulateNotifications(*contextmodel.ReqContext) response.Response
 	RouteTestRuleConfig(*contextmodel.ReqContext) response.Response
 	RouteTestRuleGrafanaConfig(*contextmodel.ReqContext) response.Response
 }
@@ -34,6 +40,24 @@ func (f *TestingApiHandler) BacktestConfig(ctx *contextmodel.ReqContext) respons
 	}
 	return f.handleBacktestConfig(ctx, conf)
 }
+func (f *TestingApiHandler) RouteCancelBacktest(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	backtestUIDParam := web.Params(ctx.Req)[":BacktestUID"]
+	return f.handleRouteCancelBacktest(ctx, backtestUIDParam)
+}
+func (f *TestingApiHandler) RouteCreateBacktest(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Request Body
+	conf := apimodels.PostableBacktest{}
+	if err := web.Bind(ctx.Req, &conf); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	return f.handleRouteCreateBacktest(ctx, conf)
+}
+func (f *TestingApiHandler) RouteDeleteBacktest(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	backtestUIDParam := web.Params(ctx.Req)[":BacktestUID"]
+	return f.handleRouteDeleteBacktest(ctx, backtestUIDParam)
+}
 func (f *TestingApiHandler) RouteEvalQueries(ctx *contextmodel.ReqContext) response.Response {
 	// Parse Request Body
 	conf := apimodels.EvalQueriesPayload{}
@@ -42,6 +66,22 @@ func (f *TestingApiHandler) RouteEvalQueries(ctx *contextmodel.ReqContext) respo
 	}
 	return f.handleRouteEvalQueries(ctx, conf)
 }
+func (f *TestingApiHandler) RouteGetBacktest(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	backtestUIDParam := web.Params(ctx.Req)[":BacktestUID"]
+	return f.handleRouteGetBacktest(ctx, backtestUIDParam)
+}
+func (f *TestingApiHandler) RouteGetBacktests(ctx *contextmodel.ReqContext) response.Response {
+	return f.handleRouteGetBacktests(ctx)
+}
+func (f *TestingApiHandler) RouteSimulateNotifications(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Request Body
+	conf := apimodels.PostableNotificationSimulation{}
+	if err := web.Bind(ctx.Req, &conf); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	return f.handleRouteSimulateNotifications(ctx, conf)
+}
 func (f *TestingApiHandler) RouteTestRuleConfig(ctx *contextmodel.ReqContext) response.Response {
 	// Parse Path Parameters
 	datasourceUIDParam := web.Params(ctx.Req)[":DatasourceUID"]
@@ -75,6 +115,42 @@ func (api *API) RegisterTestingApiEndpoints(srv TestingApi, m *metrics.API) {
 				m,
 			),
 		)
+		group.Post(
+			toMacaronPath("/api/v1/rule/backtest/jobs/{BacktestUID}/cancel"),
+			requestmeta.SetOwner(requestmeta.TeamAlerting),
+			requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow),
+			api.authorize(http.MethodPost, "/api/v1/rule/backtest/jobs/{BacktestUID}/cancel"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/v1/rule/backtest/jobs/{BacktestUID}/cancel",
+				api.Hooks.Wrap(srv.RouteCancelBacktest),
+				m,
+			),
+		)
+		group.Post(
+			toMacaronPath("/api/v1/rule/backtest/jobs"),
+			requestmeta.SetOwner(requestmeta.TeamAlerting),
+			requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow),
+			api.authorize(http.MethodPost, "/api/v1/rule/backtest/jobs"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/v1/rule/backtest/jobs",
+				api.Hooks.Wrap(srv.RouteCreateBacktest),
+				m,
+			),
+		)
+		group.Delete(
+			toMacaronPath("/api/v1/rule/backtest/jobs/{BacktestUID}"),
+			requestmeta.SetOwner(requestmeta.TeamAlerting),
+			requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow),
+			api.authorize(http.MethodDelete, "/api/v1/rule/backtest/jobs/{BacktestUID}"),
+			metrics.Instrument(
+				http.MethodDelete,
+				"/api/v1/rule/backtest/jobs/{BacktestUID}",
+				api.Hooks.Wrap(srv.RouteDeleteBacktest),
+				m,
+			),
+		)
 		group.Post(
 			toMacaronPath("/api/v1/eval"),
 			requestmeta.SetOwner(requestmeta.TeamAlerting),
@@ -87,6 +163,42 @@ func (api *API) RegisterTestingApiEndpoints(srv TestingApi, m *metrics.API) {
 				m,
 			),
 		)
+		group.Get(
+			toMacaronPath("/api/v1/rule/backtest/jobs/{BacktestUID}"),
+			requestmeta.SetOwner(requestmeta.TeamAlerting),
+			requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow),
+			api.authorize(http.MethodGet, "/api/v1/rule/backtest/jobs/{BacktestUID}"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/rule/backtest/jobs/{BacktestUID}",
+				api.Hooks.Wrap(srv.RouteGetBacktest),
+				m,
+			),
+		)
+		group.Get(
+			toMacaronPath("/api/v1/rule/backtest/jobs"),
+			requestmeta.SetOwner(requestmeta.TeamAlerting),
+			requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow),
+			api.authorize(http.MethodGet, "/api/v1/rule/backtest/jobs"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/rule/backtest/jobs",
+				api.Hooks.Wrap(srv.RouteGetBacktests),
+				m,
+			),
+		)
+		group.Post(
+			toMacaronPath("/api/v1/rule/backtest/notifications"),
+			requestmeta.SetOwner(requestmeta.TeamAlerting),
+			requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow),
+			api.authorize(http.MethodPost, "/api/v1/rule/backtest/notifications"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/v1/rule/backtest/notifications",
+				api.Hooks.Wrap(srv.RouteSimulateNotifications),
+				m,
+			),
+		)
 		group.Post(
 			toMacaronPath("/api/v1/rule/test/{DatasourceUID}"),
 			requestmeta.SetOwner(requestmeta.TeamAlerting),
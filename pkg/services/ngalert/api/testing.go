@@ -141,6 +141,10 @@ func (f fakeRuleAccessControlService) AuthorizeAccessInFolder(ctx context.Contex
 	return nil
 }
 
+func (f fakeRuleAccessControlService) AuthorizeWriteInFolder(ctx context.Context, user identity.Requester, namespaced models.Namespaced) error {
+	return nil
+}
+
 func (f fakeRuleAccessControlService) AuthorizeRuleChanges(ctx context.Context, user identity.Requester, change *store.GroupDelta) error {
 	return nil
 }
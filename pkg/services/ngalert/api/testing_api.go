@@ -32,3 +32,27 @@ func (f *TestingApiHandler) handleRouteEvalQueries(c *contextmodel.ReqContext, b
 func (f *TestingApiHandler) handleBacktestConfig(ctx *contextmodel.ReqContext, conf apimodels.BacktestConfig) response.Response {
 	return f.svc.BacktestAlertRule(ctx, conf)
 }
+
+func (f *TestingApiHandler) handleRouteCreateBacktest(ctx *contextmodel.ReqContext, body apimodels.PostableBacktest) response.Response {
+	return f.svc.RouteCreateBacktest(ctx, body)
+}
+
+func (f *TestingApiHandler) handleRouteGetBacktests(ctx *contextmodel.ReqContext) response.Response {
+	return f.svc.RouteGetBacktests(ctx)
+}
+
+func (f *TestingApiHandler) handleRouteGetBacktest(ctx *contextmodel.ReqContext, uid string) response.Response {
+	return f.svc.RouteGetBacktest(ctx, uid)
+}
+
+func (f *TestingApiHandler) handleRouteCancelBacktest(ctx *contextmodel.ReqContext, uid string) response.Response {
+	return f.svc.RouteCancelBacktest(ctx, uid)
+}
+
+func (f *TestingApiHandler) handleRouteDeleteBacktest(ctx *contextmodel.ReqContext, uid string) response.Response {
+	return f.svc.RouteDeleteBacktest(ctx, uid)
+}
+
+func (f *TestingApiHandler) handleRouteSimulateNotifications(ctx *contextmodel.ReqContext, body apimodels.PostableNotificationSimulation) response.Response {
+	return f.svc.RouteSimulateNotifications(ctx, body)
+}
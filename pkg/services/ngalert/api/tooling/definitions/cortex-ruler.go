@@ -502,6 +502,9 @@ const (
 // swagger:model
 type AlertRuleMetadata struct {
 	EditorSettings AlertRuleEditorSettings `json:"editor_settings" yaml:"editor_settings"`
+	// UIDs of rules that are evaluated before this rule when both are scheduled at the same time.
+	// Rules also depend on the recording rules that write a metric they query.
+	DependsOn []string `json:"depends_on,omitempty" yaml:"depends_on,omitempty"`
 }
 
 // swagger:model
@@ -574,6 +577,10 @@ type Record struct {
 	// required: false
 	// example: my-prom
 	TargetDatasourceUID string `json:"target_datasource_uid,omitempty" yaml:"target_datasource_uid,omitempty"`
+	// Which table the output of the recording rule is inserted into, when the target data source is a SQL data source.
+	// required: false
+	// example: recorded_metrics
+	TargetTable string `json:"target_table,omitempty" yaml:"target_table,omitempty"`
 }
 
 // swagger:model
@@ -320,4 +320,5 @@ type AlertRuleRecordExport struct {
 	Metric              string  `json:"metric" yaml:"metric" hcl:"metric"`
 	From                string  `json:"from" yaml:"from" hcl:"from"`
 	TargetDatasourceUID *string `json:"targetDatasourceUid,omitempty" yaml:"targetDatasourceUid,omitempty" hcl:"target_datasource_uid,optional"`
+	TargetTable         *string `json:"targetTable,omitempty" yaml:"targetTable,omitempty" hcl:"target_table,optional"`
 }
@@ -68,6 +68,72 @@ import (
 //     Responses:
 //       200: BacktestResult
 
+// swagger:route POST /v1/rule/backtest/jobs testing RouteCreateBacktest
+//
+// Start a backtest of a rule in the background
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       202: GettableBacktest
+//       400: ValidationError
+
+// swagger:route GET /v1/rule/backtest/jobs testing RouteGetBacktests
+//
+// List backtests without their results
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: GettableBacktests
+
+// swagger:route GET /v1/rule/backtest/jobs/{BacktestUID} testing RouteGetBacktest
+//
+// Get a backtest with its results
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: GettableBacktest
+//       404: NotFound
+
+// swagger:route POST /v1/rule/backtest/jobs/{BacktestUID}/cancel testing RouteCancelBacktest
+//
+// Cancel a backtest that has not finished yet
+//
+//     Responses:
+//       202: Ack
+//       404: NotFound
+//       409: Failure
+
+// swagger:route DELETE /v1/rule/backtest/jobs/{BacktestUID} testing RouteDeleteBacktest
+//
+// Delete a backtest
+//
+//     Responses:
+//       204: description: The backtest was deleted successfully.
+//       404: NotFound
+
+// swagger:route POST /v1/rule/backtest/notifications testing RouteSimulateNotifications
+//
+// Start a backtest of a rule in the background, with the notification settings of the rule or a proposed notification policy tree
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       202: GettableBacktest
+//       400: ValidationError
+
 // swagger:parameters RouteTestReceiverConfig
 type TestReceiverRequest struct {
 	// in:body
@@ -234,3 +300,111 @@ type BacktestConfig struct {
 
 // swagger:model
 type BacktestResult data.Frame
+
+// swagger:parameters RouteCreateBacktest
+type CreateBacktestRequest struct {
+	// in:body
+	Body PostableBacktest
+}
+
+// swagger:model
+type PostableBacktest struct {
+	BacktestConfig
+
+	// UID and version of the stored rule that is tested. Backtests of a rule
+	// can be listed to compare the results of its versions.
+	RuleUID     string `json:"rule_uid,omitempty"`
+	RuleVersion int64  `json:"rule_version,omitempty"`
+	// UID of the folder of the rule. Its title is added to the alerts as the grafana_folder label.
+	// Only users with access to the folder can see the backtest, and users who can change rules
+	// in the folder can cancel and delete it.
+	FolderUID string `json:"folder_uid,omitempty"`
+}
+
+// swagger:parameters RouteGetBacktests
+type GetBacktestsParams struct {
+	// Only return the backtests of the rule with the given UID.
+	// in:query
+	RuleUID string `json:"rule_uid"`
+	// Limit response to n backtests.
+	// in:query
+	Limit int `json:"limit"`
+}
+
+// swagger:parameters RouteGetBacktest RouteCancelBacktest RouteDeleteBacktest
+type BacktestUIDParam struct {
+	// in:path
+	BacktestUID string
+}
+
+// swagger:model
+type GettableBacktest struct {
+	UID         string    `json:"uid"`
+	RuleUID     string    `json:"rule_uid,omitempty"`
+	RuleVersion int64     `json:"rule_version,omitempty"`
+	FolderUID   string    `json:"folder_uid,omitempty"`
+	Title       string    `json:"title"`
+	Status      string    `json:"status"`
+	Progress    int       `json:"progress"`
+	Evaluations int       `json:"evaluations"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	Error       string    `json:"error,omitempty"`
+	CreatedBy   string    `json:"created_by"`
+	Created     time.Time `json:"created"`
+	Updated     time.Time `json:"updated"`
+
+	Config        *BacktestConfig          `json:"config,omitempty"`
+	Result        json.RawMessage          `json:"result,omitempty"`
+	Notifications []SimulatedNotification  `json:"notifications,omitempty"`
+	Suppressed    []SuppressedNotification `json:"suppressed,omitempty"`
+}
+
+// swagger:model
+type GettableBacktests []GettableBacktest
+
+// swagger:parameters RouteSimulateNotifications
+type SimulateNotificationsRequest struct {
+	// in:body
+	Body PostableNotificationSimulation
+}
+
+// swagger:model
+type PostableNotificationSimulation struct {
+	PostableBacktest
+
+	// Simplified routing settings of the rule. If set, the alerts are routed by the autogenerated policies.
+	NotificationSettings *AlertRuleNotificationSettings `json:"notification_settings,omitempty"`
+	// Notification policy tree that replaces the tree of the organization in the simulation.
+	Route *Route `json:"route,omitempty"`
+}
+
+// SimulatedNotification is a notification that a receiver would have received during a simulation.
+type SimulatedNotification struct {
+	Time        time.Time         `json:"time"`
+	Receiver    string            `json:"receiver"`
+	GroupKey    string            `json:"group_key"`
+	GroupLabels map[string]string `json:"group_labels"`
+	Status      string            `json:"status"`
+	Alerts      []SimulatedAlert  `json:"alerts"`
+}
+
+// SimulatedAlert is an alert of a simulated notification.
+type SimulatedAlert struct {
+	Labels   map[string]string `json:"labels"`
+	Status   string            `json:"status"`
+	StartsAt time.Time         `json:"starts_at"`
+	EndsAt   *time.Time        `json:"ends_at,omitempty"`
+}
+
+// SuppressedNotification lists the alerts of a group that were not notified because of inhibition rules ("inhibited"),
+// time intervals ("muted" or "inactive") or silences ("silenced").
+type SuppressedNotification struct {
+	Time          time.Time           `json:"time"`
+	Receiver      string              `json:"receiver"`
+	GroupKey      string              `json:"group_key"`
+	Reason        string              `json:"reason"`
+	Alerts        []map[string]string `json:"alerts"`
+	Silences      []string            `json:"silences,omitempty"`
+	TimeIntervals []string            `json:"time_intervals,omitempty"`
+}
@@ -3,6 +3,7 @@ package validation
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -153,6 +154,10 @@ func validateAlertingRuleFields(in *apimodels.PostableExtendedRuleNode, newRule
 			SimplifiedQueryAndExpressionsSection: in.GrafanaManagedAlert.Metadata.EditorSettings.SimplifiedQueryAndExpressionsSection,
 			SimplifiedNotificationsSection:       in.GrafanaManagedAlert.Metadata.EditorSettings.SimplifiedNotificationsSection,
 		}
+		newRule.Metadata.DependsOn, err = validateDependsOn(in.GrafanaManagedAlert.Metadata.DependsOn, newRule.UID)
+		if err != nil {
+			return ngmodels.AlertRule{}, err
+		}
 	}
 
 	newRule.MissingSeriesEvalsToResolve, err = validateMissingSeriesEvalsToResolve(in)
@@ -409,3 +414,24 @@ func ValidateNotificationSettings(n *apimodels.AlertRuleNotificationSettings) ([
 		s,
 	}, nil
 }
+
+// validateDependsOn validates the UIDs of the rules a rule depends on. Whether the rules exist is checked
+// when the changes are saved.
+func validateDependsOn(dependsOn []string, ruleUID string) ([]string, error) {
+	if len(dependsOn) == 0 {
+		return nil, nil
+	}
+	result := make([]string, 0, len(dependsOn))
+	for _, uid := range dependsOn {
+		if uid == "" {
+			return nil, fmt.Errorf("%w: field `depends_on` must not contain empty UIDs", ngmodels.ErrAlertRuleFailedValidation)
+		}
+		if ruleUID != "" && uid == ruleUID {
+			return nil, fmt.Errorf("%w: rule cannot depend on itself", ngmodels.ErrAlertRuleFailedValidation)
+		}
+		if !slices.Contains(result, uid) {
+			result = append(result, uid)
+		}
+	}
+	return result, nil
+}
@@ -63,17 +63,37 @@ func NewEngine(appUrl *url.URL, evalFactory eval.EvaluatorFactory, tracer tracin
 	}
 }
 
+// ProgressFunc is called after every evaluation with the number of done evaluations.
+type ProgressFunc func(done, total int)
+
 func (e *Engine) Test(ctx context.Context, user identity.Requester, rule *models.AlertRule, from, to time.Time) (*data.Frame, error) {
-	ruleCtx := models.WithRuleKey(ctx, rule.GetKey())
-	logger := logger.FromContext(ctx)
+	return e.evaluate(ctx, user, rule, from, to, nil, nil, nil)
+}
 
+// Evaluations returns the number of evaluations of the rule in the given interval.
+func Evaluations(rule *models.AlertRule, from, to time.Time) (int, error) {
 	if !from.Before(to) {
-		return nil, fmt.Errorf("%w: invalid interval of the backtesting [%d,%d]", ErrInvalidInputData, from.Unix(), to.Unix())
+		return 0, fmt.Errorf("%w: invalid interval of the backtesting [%d,%d]", ErrInvalidInputData, from.Unix(), to.Unix())
 	}
 	if to.Sub(from).Seconds() < float64(rule.IntervalSeconds) {
-		return nil, fmt.Errorf("%w: interval of the backtesting [%d,%d] is less than evaluation interval [%ds]", ErrInvalidInputData, from.Unix(), to.Unix(), rule.IntervalSeconds)
+		return 0, fmt.Errorf("%w: interval of the backtesting [%d,%d] is less than evaluation interval [%ds]", ErrInvalidInputData, from.Unix(), to.Unix(), rule.IntervalSeconds)
+	}
+	return int(to.Sub(from).Seconds()) / int(rule.IntervalSeconds), nil
+}
+
+// observeFunc is called with the state transitions of every evaluation of a run.
+type observeFunc func(now time.Time, states state.StateTransitions)
+
+// evaluate evaluates the rule at every evaluation interval between from and to, and returns the timeline of states of
+// every dimension of the rule. The extra labels are added to the states, like the scheduler does for stored rules.
+func (e *Engine) evaluate(ctx context.Context, user identity.Requester, rule *models.AlertRule, from, to time.Time, extraLabels data.Labels, observe observeFunc, progress ProgressFunc) (*data.Frame, error) {
+	ruleCtx := models.WithRuleKey(ctx, rule.GetKey())
+	logger := logger.FromContext(ctx)
+
+	length, err := Evaluations(rule, from, to)
+	if err != nil {
+		return nil, err
 	}
-	length := int(to.Sub(from).Seconds()) / int(rule.IntervalSeconds)
 
 	stateManager := e.createStateManager()
 
@@ -93,11 +113,14 @@ func (e *Engine) Test(ctx context.Context, user identity.Requester, rule *models
 	valueFields := make(map[data.Fingerprint]*data.Field)
 
 	err = evaluator.Eval(ruleCtx, from, time.Duration(rule.IntervalSeconds)*time.Second, length, func(idx int, currentTime time.Time, results eval.Results) error {
+		if err := ruleCtx.Err(); err != nil {
+			return err
+		}
 		if idx >= length {
 			logger.Info("Unexpected evaluation. Skipping", "from", from, "to", to, "interval", rule.IntervalSeconds, "evaluationTime", currentTime, "evaluationIndex", idx, "expectedEvaluations", length)
 			return nil
 		}
-		states := stateManager.ProcessEvalResults(ruleCtx, currentTime, rule, results, nil, nil)
+		states := stateManager.ProcessEvalResults(ruleCtx, currentTime, rule, results, extraLabels, nil)
 		tsField.Set(idx, currentTime)
 		for _, s := range states {
 			field, ok := valueFields[s.CacheID]
@@ -114,6 +137,12 @@ func (e *Engine) Test(ctx context.Context, user identity.Requester, rule *models
 				continue
 			}
 		}
+		if observe != nil {
+			observe(currentTime, states)
+		}
+		if progress != nil {
+			progress(idx+1, length)
+		}
 		return nil
 	})
 	fields := make([]*data.Field, 0, len(valueFields)+1)
@@ -0,0 +1,32 @@
+package backtesting
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// DeleteExpiredService deletes the backtests that finished longer ago than the configured retention.
+type DeleteExpiredService struct {
+	store *store.DBstore
+	cfg   *setting.Cfg
+}
+
+func ProvideDeleteExpiredService(store *store.DBstore, cfg *setting.Cfg) *DeleteExpiredService {
+	return &DeleteExpiredService{store: store, cfg: cfg}
+}
+
+func (s *DeleteExpiredService) DeleteExpired(ctx context.Context) (int64, error) {
+	retention := s.cfg.UnifiedAlerting.BacktestRetention
+	if retention <= 0 {
+		return 0, nil
+	}
+	affected, err := s.store.DeleteFinishedBacktests(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired backtests: %w", err)
+	}
+	return affected, nil
+}
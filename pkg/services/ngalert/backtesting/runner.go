@@ -0,0 +1,271 @@
+package backtesting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// ErrBacktestFinished is returned when a backtest that has already finished is canceled.
+var ErrBacktestFinished = errors.New("backtest has already finished")
+
+const (
+	// progressUpdateInterval is how often the progress of a running backtest is persisted.
+	progressUpdateInterval = 5 * time.Second
+	// staleBacktestTimeout is how long an unfinished backtest can go without updates before it is considered interrupted.
+	staleBacktestTimeout = 10 * time.Minute
+	// maxRunningBacktests is how many backtests an instance runs at the same time.
+	maxRunningBacktests = 4
+)
+
+// BacktestStore persists backtests.
+type BacktestStore interface {
+	InsertBacktest(ctx context.Context, b *models.Backtest) error
+	UpdateBacktestProgress(ctx context.Context, id int64, progress int) (bool, error)
+	FinishBacktest(ctx context.Context, b *models.Backtest) (bool, error)
+	CancelBacktest(ctx context.Context, orgID int64, uid string) (bool, error)
+	GetBacktest(ctx context.Context, orgID int64, uid string) (*models.Backtest, error)
+	ListBacktests(ctx context.Context, query models.ListBacktestsQuery) ([]*models.Backtest, error)
+	DeleteBacktest(ctx context.Context, orgID int64, uid string) error
+	FailStaleBacktests(ctx context.Context, updatedBefore time.Time) (int64, error)
+}
+
+// AlertmanagerConfigStore provides the configuration the notifications of a backtest are routed with.
+type AlertmanagerConfigStore interface {
+	GetLatestAlertmanagerConfiguration(ctx context.Context, orgID int64) (*models.AlertConfiguration, error)
+}
+
+// Runner runs backtests in the background and persists their progress and results. The alerts of a backtest are
+// dispatched like the Alertmanager of the organization would, to simulate the notifications they would have caused.
+type Runner struct {
+	engine      *Engine
+	store       BacktestStore
+	configStore AlertmanagerConfigStore
+	silences    SilenceStore
+	clock       clock.Clock
+	log         log.Logger
+
+	mtx        sync.Mutex
+	cancels    map[int64]context.CancelFunc
+	running    int
+	maxRunning int
+}
+
+func NewRunner(engine *Engine, store BacktestStore, configStore AlertmanagerConfigStore, silences SilenceStore) *Runner {
+	return &Runner{
+		engine:      engine,
+		store:       store,
+		configStore: configStore,
+		silences:    silences,
+		clock:       clock.New(),
+		log:         log.New("ngalert.backtesting.runner"),
+		cancels:     make(map[int64]context.CancelFunc),
+		maxRunning:  maxRunningBacktests,
+	}
+}
+
+// Start persists a new backtest of the rule and runs it in the background. The caller sets the metadata of the
+// backtest, such as the time range, the rule it was created for and its configuration. It returns
+// models.ErrTooManyBacktests if the instance already runs the maximum number of backtests.
+func (r *Runner) Start(ctx context.Context, user identity.Requester, rule *models.AlertRule, b *models.Backtest, opts SimulationOptions) error {
+	evaluations, err := Evaluations(rule, b.From, b.To)
+	if err != nil {
+		return err
+	}
+	d, err := r.dispatcher(ctx, rule, opts.Route)
+	if err != nil {
+		return err
+	}
+	extraLabels := state.GetRuleExtraLabels(r.log, rule, opts.FolderTitle, opts.FolderTitle != "")
+
+	b.OrgID = rule.OrgID
+	b.UID = util.GenerateShortUID()
+	b.Status = models.BacktestStatusPending
+	b.Evaluations = evaluations
+	if user != nil {
+		b.CreatedBy = user.GetIdentifier()
+	}
+
+	r.mtx.Lock()
+	if r.running >= r.maxRunning {
+		r.mtx.Unlock()
+		return models.ErrTooManyBacktests
+	}
+	r.running++
+	r.mtx.Unlock()
+
+	if err := r.store.InsertBacktest(ctx, b); err != nil {
+		r.mtx.Lock()
+		r.running--
+		r.mtx.Unlock()
+		return err
+	}
+
+	// The backtest outlives the request that started it.
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	r.mtx.Lock()
+	r.cancels[b.ID] = cancel
+	r.mtx.Unlock()
+
+	go r.run(runCtx, cancel, user, rule, *b, extraLabels, d)
+	return nil
+}
+
+func (r *Runner) run(ctx context.Context, cancel context.CancelFunc, user identity.Requester, rule *models.AlertRule, b models.Backtest, extraLabels data.Labels, d *dispatcher) {
+	defer func() {
+		cancel()
+		r.mtx.Lock()
+		delete(r.cancels, b.ID)
+		r.running--
+		r.mtx.Unlock()
+	}()
+	logger := r.log.FromContext(ctx).New("backtest", b.UID)
+
+	// Failures to persist the progress are not fatal, the final result is stored with the next updates.
+	updateProgress := func(done int) {
+		ok, err := r.store.UpdateBacktestProgress(ctx, b.ID, done)
+		if err != nil {
+			logger.Warn("Failed to update backtest progress", "error", err)
+			return
+		}
+		if !ok {
+			// the backtest was canceled, possibly by another instance
+			cancel()
+		}
+	}
+	updateProgress(0)
+
+	var observe observeFunc
+	if d != nil {
+		observe = d.observe
+	}
+	lastUpdate := r.clock.Now()
+	frame, err := r.engine.evaluate(ctx, user, rule, b.From, b.To, extraLabels, observe, func(done, total int) {
+		b.Progress = done
+		if r.clock.Since(lastUpdate) < progressUpdateInterval {
+			return
+		}
+		lastUpdate = r.clock.Now()
+		updateProgress(done)
+	})
+
+	switch {
+	case err == nil:
+		b.Status = models.BacktestStatusSucceeded
+		err = encodeResult(&b, frame, d)
+		if err != nil {
+			b.Status = models.BacktestStatusFailed
+			b.Error = err.Error()
+		}
+	case ctx.Err() != nil:
+		b.Status = models.BacktestStatusCanceled
+	default:
+		b.Status = models.BacktestStatusFailed
+		b.Error = err.Error()
+	}
+
+	if _, err := r.store.FinishBacktest(context.WithoutCancel(ctx), &b); err != nil {
+		logger.Error("Failed to store backtest result", "error", err)
+		return
+	}
+	logger.Info("Backtest finished", "status", b.Status, "evaluations", b.Progress)
+}
+
+// encodeResult stores the frame and the notifications of the dispatcher in the backtest. The groups that are still
+// waiting are flushed at the end of the time range first.
+func encodeResult(b *models.Backtest, frame *data.Frame, d *dispatcher) error {
+	result, err := data.FrameToJSON(frame, data.IncludeAll)
+	if err != nil {
+		return fmt.Errorf("failed to encode result frame: %w", err)
+	}
+	notifications := []SimulatedNotification{}
+	suppressed := []SuppressedNotification{}
+	if d != nil {
+		d.flushUntil(b.To, true)
+		notifications = append(notifications, d.notifications...)
+		suppressed = append(suppressed, d.suppressed...)
+	}
+	n, err := json.Marshal(notifications)
+	if err != nil {
+		return fmt.Errorf("failed to encode notifications: %w", err)
+	}
+	s, err := json.Marshal(suppressed)
+	if err != nil {
+		return fmt.Errorf("failed to encode suppressed notifications: %w", err)
+	}
+	b.Result = string(result)
+	b.Notifications = string(n)
+	b.Suppressed = string(s)
+	return nil
+}
+
+// Cancel stops a backtest that has not finished yet.
+func (r *Runner) Cancel(ctx context.Context, orgID int64, uid string) error {
+	b, err := r.store.GetBacktest(ctx, orgID, uid)
+	if err != nil {
+		return err
+	}
+	ok, err := r.store.CancelBacktest(ctx, orgID, uid)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrBacktestFinished
+	}
+	r.stop(b.ID)
+	return nil
+}
+
+// Delete removes a backtest and stops it if it is still running.
+func (r *Runner) Delete(ctx context.Context, orgID int64, uid string) error {
+	b, err := r.store.GetBacktest(ctx, orgID, uid)
+	if err != nil {
+		return err
+	}
+	r.stop(b.ID)
+	return r.store.DeleteBacktest(ctx, orgID, uid)
+}
+
+func (r *Runner) stop(id int64) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if cancel, ok := r.cancels[id]; ok {
+		cancel()
+	}
+}
+
+// Get returns the backtest with its results.
+func (r *Runner) Get(ctx context.Context, orgID int64, uid string) (*models.Backtest, error) {
+	r.failStale(ctx)
+	return r.store.GetBacktest(ctx, orgID, uid)
+}
+
+// List returns the backtests without their results.
+func (r *Runner) List(ctx context.Context, query models.ListBacktestsQuery) ([]*models.Backtest, error) {
+	r.failStale(ctx)
+	return r.store.ListBacktests(ctx, query)
+}
+
+// failStale marks backtests that were interrupted, for example by a restart, as failed.
+func (r *Runner) failStale(ctx context.Context) {
+	n, err := r.store.FailStaleBacktests(ctx, r.clock.Now().Add(-staleBacktestTimeout))
+	if err != nil {
+		r.log.FromContext(ctx).Warn("Failed to expire stale backtests", "error", err)
+		return
+	}
+	if n > 0 {
+		r.log.FromContext(ctx).Info("Marked interrupted backtests as failed", "count", n)
+	}
+}
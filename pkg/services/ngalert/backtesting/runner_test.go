@@ -0,0 +1,324 @@
+package backtesting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/infra/log"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+)
+
+const testAlertmanagerConfig = `{
+	"alertmanager_config": {
+		"route": {
+			"receiver": "default",
+			"group_wait": "0s",
+			"group_interval": "1s",
+			"routes": [{"receiver": "team-a", "object_matchers": [["team", "=", "a"]]}]
+		},
+		"receivers": [{"name": "default"}, {"name": "team-a"}]
+	}
+}`
+
+func TestRunner(t *testing.T) {
+	gen := models.RuleGen
+	rule := gen.With(gen.WithInterval(time.Second), gen.WithOrgID(1), gen.WithNoNotificationSettings()).GenerateRef()
+	ruleInterval := time.Duration(rule.IntervalSeconds) * time.Second
+	from := time.Unix(0, 0)
+	to := from.Add(4 * ruleInterval)
+
+	labels := data.Labels{"team": "a"}
+	states := []eval.State{eval.Normal, eval.Alerting, eval.Alerting, eval.Normal}
+	manager := &fakeStateManager{
+		stateCallback: func(now time.Time) []state.StateTransition {
+			idx := int(now.Sub(from) / ruleInterval)
+			prev := eval.Normal
+			if idx > 0 {
+				prev = states[idx-1]
+			}
+			s := &state.State{CacheID: labels.Fingerprint(), Labels: labels, State: states[idx], StartsAt: from.Add(ruleInterval), EndsAt: now.Add(4 * ruleInterval)}
+			if prev == eval.Alerting && s.State == eval.Normal {
+				s.ResolvedAt = &now
+				s.EndsAt = now
+			}
+			return []state.StateTransition{{State: s, PreviousState: prev}}
+		},
+	}
+	evaluator := &fakeBacktestingEvaluator{
+		evalCallback: func(now time.Time) (eval.Results, error) {
+			return eval.Results{}, nil
+		},
+	}
+	backtestingEvaluatorFactory = func(ctx context.Context, evalFactory eval.EvaluatorFactory, user identity.Requester, condition models.Condition, r eval.AlertingResultsReader) (backtestingEvaluator, error) {
+		return evaluator, nil
+	}
+	t.Cleanup(func() {
+		backtestingEvaluatorFactory = newBacktestingEvaluator
+	})
+
+	newRunner := func(configStore AlertmanagerConfigStore) (*Runner, *fakeBacktestStore) {
+		st := newFakeBacktestStore()
+		r := NewRunner(&Engine{createStateManager: func() stateManager { return manager }}, st, configStore, &fakeSilenceStore{})
+		r.clock = clock.NewMock()
+		r.log = log.NewNopLogger()
+		return r, st
+	}
+	amConfig := &fakeConfigStore{cfg: &models.AlertConfiguration{AlertmanagerConfiguration: testAlertmanagerConfig}}
+
+	t.Run("should persist result and notifications", func(t *testing.T) {
+		r, st := newRunner(amConfig)
+		b := &models.Backtest{From: from, To: to, RuleUID: "rule", RuleVersion: 3}
+		require.NoError(t, r.Start(context.Background(), nil, rule, b, SimulationOptions{}))
+		require.Equal(t, models.BacktestStatusPending, b.Status)
+		require.Equal(t, 4, b.Evaluations)
+
+		result := st.waitFinished(t, b.ID)
+		require.Equal(t, models.BacktestStatusSucceeded, result.Status, result.Error)
+		require.Equal(t, 4, result.Progress)
+
+		frame := &data.Frame{}
+		require.NoError(t, json.Unmarshal([]byte(result.Result), frame))
+		require.Len(t, frame.Fields, 2)
+
+		var notifications []SimulatedNotification
+		require.NoError(t, json.Unmarshal([]byte(result.Notifications), &notifications))
+		require.Len(t, notifications, 2)
+		assert.Equal(t, NotificationStatusFiring, notifications[0].Status)
+		assert.Equal(t, from.Add(ruleInterval).UTC(), notifications[0].Time.UTC())
+		assert.Equal(t, "team-a", notifications[0].Receiver)
+		assert.Equal(t, NotificationStatusResolved, notifications[1].Status)
+		assert.Equal(t, from.Add(3*ruleInterval).UTC(), notifications[1].Time.UTC())
+		require.JSONEq(t, "[]", result.Suppressed)
+	})
+
+	t.Run("should not route notifications without configuration", func(t *testing.T) {
+		r, st := newRunner(&fakeConfigStore{err: store.ErrNoAlertmanagerConfiguration})
+		b := &models.Backtest{From: from, To: to}
+		require.NoError(t, r.Start(context.Background(), nil, rule, b, SimulationOptions{}))
+
+		result := st.waitFinished(t, b.ID)
+		require.Equal(t, models.BacktestStatusSucceeded, result.Status, result.Error)
+		require.JSONEq(t, "[]", result.Notifications)
+	})
+
+	t.Run("should reject notification policy tree without configuration", func(t *testing.T) {
+		r, st := newRunner(&fakeConfigStore{err: store.ErrNoAlertmanagerConfiguration})
+		err := r.Start(context.Background(), nil, rule, &models.Backtest{From: from, To: to}, SimulationOptions{Route: &apimodels.Route{Receiver: "default"}})
+		require.ErrorIs(t, err, ErrInvalidInputData)
+		require.Empty(t, st.backtests)
+	})
+
+	t.Run("should validate interval before starting", func(t *testing.T) {
+		r, st := newRunner(amConfig)
+		err := r.Start(context.Background(), nil, rule, &models.Backtest{From: to, To: from}, SimulationOptions{})
+		require.ErrorIs(t, err, ErrInvalidInputData)
+		require.Empty(t, st.backtests)
+	})
+
+	t.Run("should store failure", func(t *testing.T) {
+		expectedErr := errors.New("test-error")
+		evaluator.evalCallback = func(now time.Time) (eval.Results, error) {
+			return nil, expectedErr
+		}
+		t.Cleanup(func() {
+			evaluator.evalCallback = func(now time.Time) (eval.Results, error) {
+				return eval.Results{}, nil
+			}
+		})
+		r, st := newRunner(amConfig)
+		b := &models.Backtest{From: from, To: to}
+		require.NoError(t, r.Start(context.Background(), nil, rule, b, SimulationOptions{}))
+
+		result := st.waitFinished(t, b.ID)
+		require.Equal(t, models.BacktestStatusFailed, result.Status)
+		require.Equal(t, expectedErr.Error(), result.Error)
+	})
+
+	t.Run("should stop canceled backtest", func(t *testing.T) {
+		block := make(chan struct{})
+		evaluator.evalCallback = func(now time.Time) (eval.Results, error) {
+			<-block
+			return eval.Results{}, nil
+		}
+		t.Cleanup(func() {
+			evaluator.evalCallback = func(now time.Time) (eval.Results, error) {
+				return eval.Results{}, nil
+			}
+		})
+		r, st := newRunner(amConfig)
+		b := &models.Backtest{From: from, To: to}
+		require.NoError(t, r.Start(context.Background(), nil, rule, b, SimulationOptions{}))
+
+		require.NoError(t, r.Cancel(context.Background(), b.OrgID, b.UID))
+		close(block)
+
+		result := st.waitFinished(t, b.ID)
+		require.Equal(t, models.BacktestStatusCanceled, result.Status)
+		require.ErrorIs(t, r.Cancel(context.Background(), b.OrgID, b.UID), ErrBacktestFinished)
+	})
+
+	t.Run("should limit the number of running backtests", func(t *testing.T) {
+		block := make(chan struct{})
+		evaluator.evalCallback = func(now time.Time) (eval.Results, error) {
+			<-block
+			return eval.Results{}, nil
+		}
+		t.Cleanup(func() {
+			evaluator.evalCallback = func(now time.Time) (eval.Results, error) {
+				return eval.Results{}, nil
+			}
+		})
+		r, st := newRunner(amConfig)
+		r.maxRunning = 1
+		b := &models.Backtest{From: from, To: to}
+		require.NoError(t, r.Start(context.Background(), nil, rule, b, SimulationOptions{}))
+
+		err := r.Start(context.Background(), nil, rule, &models.Backtest{From: from, To: to}, SimulationOptions{})
+		require.ErrorIs(t, err, models.ErrTooManyBacktests)
+
+		close(block)
+		st.waitFinished(t, b.ID)
+		next := &models.Backtest{From: from, To: to}
+		require.Eventually(t, func() bool {
+			return r.Start(context.Background(), nil, rule, next, SimulationOptions{}) == nil
+		}, 5*time.Second, 10*time.Millisecond)
+		st.waitFinished(t, next.ID)
+	})
+}
+
+type fakeConfigStore struct {
+	cfg *models.AlertConfiguration
+	err error
+}
+
+func (f *fakeConfigStore) GetLatestAlertmanagerConfiguration(_ context.Context, _ int64) (*models.AlertConfiguration, error) {
+	return f.cfg, f.err
+}
+
+type fakeBacktestStore struct {
+	mtx       sync.Mutex
+	backtests map[int64]*models.Backtest
+	finished  chan int64
+}
+
+func newFakeBacktestStore() *fakeBacktestStore {
+	return &fakeBacktestStore{
+		backtests: map[int64]*models.Backtest{},
+		finished:  make(chan int64, 10),
+	}
+}
+
+func (f *fakeBacktestStore) waitFinished(t *testing.T, id int64) models.Backtest {
+	t.Helper()
+	select {
+	case finished := <-f.finished:
+		require.Equal(t, id, finished)
+	case <-time.After(5 * time.Second):
+		t.Fatal("backtest did not finish in time")
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return *f.backtests[id]
+}
+
+func (f *fakeBacktestStore) InsertBacktest(_ context.Context, b *models.Backtest) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	b.ID = int64(len(f.backtests) + 1)
+	cp := *b
+	f.backtests[b.ID] = &cp
+	return nil
+}
+
+func (f *fakeBacktestStore) UpdateBacktestProgress(_ context.Context, id int64, progress int) (bool, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	b := f.backtests[id]
+	if b.Status.IsFinal() {
+		return false, nil
+	}
+	b.Status = models.BacktestStatusRunning
+	b.Progress = progress
+	return true, nil
+}
+
+func (f *fakeBacktestStore) FinishBacktest(_ context.Context, b *models.Backtest) (bool, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	defer func() { f.finished <- b.ID }()
+	existing := f.backtests[b.ID]
+	if existing.Status.IsFinal() {
+		return false, nil
+	}
+	cp := *b
+	f.backtests[b.ID] = &cp
+	return true, nil
+}
+
+func (f *fakeBacktestStore) CancelBacktest(ctx context.Context, orgID int64, uid string) (bool, error) {
+	b, err := f.GetBacktest(ctx, orgID, uid)
+	if err != nil {
+		return false, err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if b.Status.IsFinal() {
+		return false, nil
+	}
+	f.backtests[b.ID].Status = models.BacktestStatusCanceled
+	return true, nil
+}
+
+func (f *fakeBacktestStore) GetBacktest(_ context.Context, orgID int64, uid string) (*models.Backtest, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for _, b := range f.backtests {
+		if b.OrgID == orgID && b.UID == uid {
+			cp := *b
+			return &cp, nil
+		}
+	}
+	return nil, models.ErrBacktestNotFound
+}
+
+func (f *fakeBacktestStore) ListBacktests(_ context.Context, query models.ListBacktestsQuery) ([]*models.Backtest, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	var result []*models.Backtest
+	for _, b := range f.backtests {
+		if b.OrgID == query.OrgID && (query.RuleUID == "" || b.RuleUID == query.RuleUID) {
+			cp := *b
+			result = append(result, &cp)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeBacktestStore) DeleteBacktest(ctx context.Context, orgID int64, uid string) error {
+	b, err := f.GetBacktest(ctx, orgID, uid)
+	if err != nil {
+		return err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	delete(f.backtests, b.ID)
+	return nil
+}
+
+func (f *fakeBacktestStore) FailStaleBacktests(_ context.Context, _ time.Time) (int64, error) {
+	return 0, nil
+}
@@ -0,0 +1,89 @@
+package backtesting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+)
+
+// SilenceStore provides the silences of an organization.
+type SilenceStore interface {
+	ListSilences(ctx context.Context, orgID int64, filter []string) ([]*models.Silence, error)
+}
+
+// SimulationOptions configure the notification simulation of a backtest.
+type SimulationOptions struct {
+	// FolderTitle is added to the alerts as the folder label, like for the rules of the folder.
+	FolderTitle string
+	// Route replaces the notification policy tree of the organization if it is set, to preview changes of the tree.
+	Route *apimodels.Route
+}
+
+// dispatcher returns the dispatcher that simulates the notifications the Alertmanager of the organization would
+// have sent for the alerts of the rule, using the latest configuration and the current silences. Only the alerts
+// of the rule are taken into account, in particular for inhibition. It returns nil if the organization has no
+// Alertmanager configuration and no notification policy tree is given, in which case no notifications are simulated.
+func (r *Runner) dispatcher(ctx context.Context, rule *models.AlertRule, route *apimodels.Route) (*dispatcher, error) {
+	cfg, err := r.config(ctx, rule, route)
+	if err != nil || cfg == nil {
+		return nil, err
+	}
+	silences, err := r.silences.ListSilences(ctx, rule.OrgID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list silences: %w", err)
+	}
+	return newDispatcher(&cfg.AlertmanagerConfig, silences)
+}
+
+// config returns the latest Alertmanager configuration of the organization, with the given notification policy tree
+// if it is set, and with the autogenerated policies of the rule if it uses simplified routing.
+func (r *Runner) config(ctx context.Context, rule *models.AlertRule, route *apimodels.Route) (*apimodels.PostableUserConfig, error) {
+	stored, err := r.configStore.GetLatestAlertmanagerConfiguration(ctx, rule.OrgID)
+	if err != nil {
+		if errors.Is(err, store.ErrNoAlertmanagerConfiguration) {
+			if route != nil {
+				return nil, fmt.Errorf("%w: the organization has no Alertmanager configuration", ErrInvalidInputData)
+			}
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get latest configuration: %w", err)
+	}
+	cfg, err := notifier.Load([]byte(stored.AlertmanagerConfiguration))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse configuration: %w", err)
+	}
+
+	if route != nil {
+		if err := route.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: invalid notification policy tree: %s", ErrInvalidInputData, err)
+		}
+		cfg.AlertmanagerConfig.Route = route
+		if err := cfg.AlertmanagerConfig.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: invalid notification policy tree: %s", ErrInvalidInputData, err)
+		}
+	}
+
+	if len(rule.NotificationSettings) > 0 {
+		err := notifier.AddAutogenConfig(ctx, r.log, ruleNotificationSettings{rule: rule}, rule.OrgID, &cfg.AlertmanagerConfig, false)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidInputData, err)
+		}
+	}
+	return cfg, nil
+}
+
+// ruleNotificationSettings provides the notification settings of a single rule to generate its autogenerated policies.
+type ruleNotificationSettings struct {
+	rule *models.AlertRule
+}
+
+func (r ruleNotificationSettings) ListNotificationSettings(_ context.Context, _ models.ListNotificationSettingsQuery) (map[models.AlertRuleKey][]models.NotificationSettings, error) {
+	return map[models.AlertRuleKey][]models.NotificationSettings{
+		r.rule.GetKey(): r.rule.NotificationSettings,
+	}, nil
+}
@@ -0,0 +1,549 @@
+package backtesting
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/inhibit"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/timeinterval"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+)
+
+// Statuses of simulated notifications and their alerts.
+const (
+	NotificationStatusFiring   = "firing"
+	NotificationStatusResolved = "resolved"
+)
+
+// Reasons why the alerts of a group were not notified during a simulation.
+const (
+	SuppressedByInhibition   = "inhibited"
+	SuppressedBySilence      = "silenced"
+	SuppressedByMuteTiming   = "muted"
+	SuppressedByActiveTiming = "inactive"
+)
+
+// SimulatedNotification is a notification that a receiver would have received during a simulation.
+type SimulatedNotification struct {
+	Time        time.Time        `json:"time"`
+	Receiver    string           `json:"receiver"`
+	GroupKey    string           `json:"group_key"`
+	GroupLabels data.Labels      `json:"group_labels"`
+	Status      string           `json:"status"`
+	Alerts      []SimulatedAlert `json:"alerts"`
+}
+
+// SimulatedAlert is an alert of a simulated notification.
+type SimulatedAlert struct {
+	Labels   data.Labels `json:"labels"`
+	Status   string      `json:"status"`
+	StartsAt time.Time   `json:"starts_at"`
+	EndsAt   *time.Time  `json:"ends_at,omitempty"`
+}
+
+// SuppressedNotification records alerts of a group that were not notified, and why. It is only recorded when the
+// suppressed alerts of the group change, not at every flush of the group.
+type SuppressedNotification struct {
+	Time          time.Time     `json:"time"`
+	Receiver      string        `json:"receiver"`
+	GroupKey      string        `json:"group_key"`
+	Reason        string        `json:"reason"`
+	Alerts        []data.Labels `json:"alerts"`
+	Silences      []string      `json:"silences,omitempty"`
+	TimeIntervals []string      `json:"time_intervals,omitempty"`
+}
+
+type simulatedAlert struct {
+	fingerprint model.Fingerprint
+	labels      model.LabelSet
+	startsAt    time.Time
+	endsAt      time.Time
+}
+
+func (a *simulatedAlert) resolved(now time.Time) bool {
+	return !a.endsAt.After(now)
+}
+
+type simulatedSilence struct {
+	id       string
+	matchers labels.Matchers
+	startsAt time.Time
+	endsAt   time.Time
+}
+
+func (s simulatedSilence) mutes(lbs model.LabelSet, now time.Time) bool {
+	return !now.Before(s.startsAt) && now.Before(s.endsAt) && s.matchers.Matches(lbs)
+}
+
+type groupID struct {
+	route       *dispatch.Route
+	fingerprint model.Fingerprint
+}
+
+type aggregationGroup struct {
+	key    string
+	route  *dispatch.Route
+	labels model.LabelSet
+	alerts map[model.Fingerprint]*simulatedAlert
+	next   time.Time
+}
+
+type notificationLogEntry struct {
+	firing    map[model.Fingerprint]struct{}
+	resolved  map[model.Fingerprint]struct{}
+	timestamp time.Time
+}
+
+// dispatcher simulates how the Alertmanager dispatches alerts: alerts are grouped by the routes they match, and the
+// groups are flushed after group_wait and then every group_interval. Alerts are removed from the notifications by
+// inhibition rules, time intervals and silences, and the notifications are deduplicated like the notification log does.
+type dispatcher struct {
+	route        *dispatch.Route
+	intervener   *timeinterval.Intervener
+	inhibitRules []*inhibit.InhibitRule
+	silences     []simulatedSilence
+	sendResolved map[string]bool
+
+	alerts          map[model.Fingerprint]*simulatedAlert
+	groups          map[groupID]*aggregationGroup
+	notificationLog map[string]*notificationLogEntry
+	lastSuppressed  map[string]string
+
+	notifications []SimulatedNotification
+	suppressed    []SuppressedNotification
+}
+
+func newDispatcher(cfg *apimodels.PostableApiAlertingConfig, silences []*models.Silence) (*dispatcher, error) {
+	if cfg.Route == nil {
+		return nil, fmt.Errorf("%w: the configuration has no notification policy tree", ErrInvalidInputData)
+	}
+
+	intervals := make(map[string][]timeinterval.TimeInterval, len(cfg.TimeIntervals)+len(cfg.MuteTimeIntervals))
+	for _, ti := range cfg.TimeIntervals {
+		intervals[ti.Name] = ti.TimeIntervals
+	}
+	for _, ti := range cfg.MuteTimeIntervals {
+		intervals[ti.Name] = ti.TimeIntervals
+	}
+
+	route := dispatch.NewRoute(cfg.Route.AsAMRoute(), nil)
+	var err error
+	route.Walk(func(r *dispatch.Route) {
+		for _, name := range slices.Concat(r.RouteOpts.MuteTimeIntervals, r.RouteOpts.ActiveTimeIntervals) {
+			if _, ok := intervals[name]; !ok && err == nil {
+				err = fmt.Errorf("%w: time interval %q of the notification policy tree does not exist", ErrInvalidInputData, name)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d := &dispatcher{
+		route:           route,
+		intervener:      timeinterval.NewIntervener(intervals),
+		sendResolved:    make(map[string]bool, len(cfg.Receivers)),
+		alerts:          make(map[model.Fingerprint]*simulatedAlert),
+		groups:          make(map[groupID]*aggregationGroup),
+		notificationLog: make(map[string]*notificationLogEntry),
+		lastSuppressed:  make(map[string]string),
+	}
+	for _, r := range cfg.InhibitRules {
+		d.inhibitRules = append(d.inhibitRules, inhibit.NewInhibitRule(r))
+	}
+	for _, r := range cfg.Receivers {
+		// A receiver sends resolved notifications if any of its integrations does.
+		sendResolved := len(r.GrafanaManagedReceivers) == 0
+		for _, i := range r.GrafanaManagedReceivers {
+			sendResolved = sendResolved || !i.DisableResolveMessage
+		}
+		d.sendResolved[r.Name] = sendResolved
+	}
+	for _, s := range silences {
+		sil, err := toSimulatedSilence(s)
+		if err != nil {
+			return nil, err
+		}
+		d.silences = append(d.silences, sil)
+	}
+	return d, nil
+}
+
+func toSimulatedSilence(s *models.Silence) (simulatedSilence, error) {
+	var sil simulatedSilence
+	if s.ID != nil {
+		sil.id = *s.ID
+	}
+	if s.StartsAt != nil {
+		sil.startsAt = time.Time(*s.StartsAt)
+	}
+	if s.EndsAt != nil {
+		sil.endsAt = time.Time(*s.EndsAt)
+	}
+	for _, m := range s.Matchers {
+		if m.Name == nil || m.Value == nil {
+			continue
+		}
+		isEqual := m.IsEqual == nil || *m.IsEqual
+		isRegex := m.IsRegex != nil && *m.IsRegex
+		var t labels.MatchType
+		switch {
+		case isEqual && isRegex:
+			t = labels.MatchRegexp
+		case isEqual:
+			t = labels.MatchEqual
+		case isRegex:
+			t = labels.MatchNotRegexp
+		default:
+			t = labels.MatchNotEqual
+		}
+		matcher, err := labels.NewMatcher(t, *m.Name, *m.Value)
+		if err != nil {
+			return simulatedSilence{}, fmt.Errorf("invalid matcher of silence %s: %w", sil.id, err)
+		}
+		sil.matchers = append(sil.matchers, matcher)
+	}
+	return sil, nil
+}
+
+// observe sends the alerts of the state transitions of an evaluation to the dispatcher, like the scheduler sends them
+// to the Alertmanager. The groups that are due before the evaluation are flushed first.
+func (d *dispatcher) observe(now time.Time, states state.StateTransitions) {
+	d.flushUntil(now, false)
+	for _, s := range states {
+		if !sendsAlert(s, now) {
+			continue
+		}
+		alert := state.StateToPostableAlert(s, nil, featuremgmt.WithFeatures())
+		lbs := make(model.LabelSet, len(alert.Labels))
+		for k, v := range alert.Labels {
+			lbs[model.LabelName(k)] = model.LabelValue(v)
+		}
+		d.put(now, lbs, time.Time(alert.EndsAt))
+	}
+	d.flushUntil(now, true)
+}
+
+// sendsAlert reports whether the state is sent to the Alertmanager: firing states are, and states that were just resolved.
+func sendsAlert(s state.StateTransition, now time.Time) bool {
+	switch s.State.State {
+	case eval.Alerting, eval.Recovering, eval.NoData, eval.Error:
+		return true
+	}
+	return s.State.ResolvedAt != nil && s.State.ResolvedAt.Equal(now)
+}
+
+// put adds or updates an alert, and adds it to the groups of the routes it matches.
+func (d *dispatcher) put(now time.Time, lbs model.LabelSet, endsAt time.Time) {
+	fp := lbs.Fingerprint()
+	alert, ok := d.alerts[fp]
+	if !ok {
+		alert = &simulatedAlert{fingerprint: fp, labels: lbs, startsAt: now}
+		d.alerts[fp] = alert
+	} else if alert.resolved(now) {
+		alert.startsAt = now
+	}
+	alert.endsAt = endsAt
+
+	for _, route := range d.route.Match(lbs) {
+		groupLabels := getGroupLabels(lbs, route)
+		id := groupID{route: route, fingerprint: groupLabels.Fingerprint()}
+		g, ok := d.groups[id]
+		if !ok {
+			g = &aggregationGroup{
+				key:    fmt.Sprintf("%s:%s", route.Key(), groupLabels),
+				route:  route,
+				labels: groupLabels,
+				alerts: make(map[model.Fingerprint]*simulatedAlert),
+				next:   now.Add(route.RouteOpts.GroupWait),
+			}
+			d.groups[id] = g
+		}
+		g.alerts[fp] = alert
+	}
+}
+
+func getGroupLabels(lbs model.LabelSet, route *dispatch.Route) model.LabelSet {
+	groupLabels := model.LabelSet{}
+	for ln, lv := range lbs {
+		if _, ok := route.RouteOpts.GroupBy[ln]; ok || route.RouteOpts.GroupByAll {
+			groupLabels[ln] = lv
+		}
+	}
+	return groupLabels
+}
+
+// flushUntil flushes the groups that are due before t, or at t if inclusive is true, in chronological order.
+func (d *dispatcher) flushUntil(t time.Time, inclusive bool) {
+	for {
+		var next *aggregationGroup
+		var nextID groupID
+		for id, g := range d.groups {
+			if g.next.After(t) || (!inclusive && g.next.Equal(t)) {
+				continue
+			}
+			if next == nil || g.next.Before(next.next) || (g.next.Equal(next.next) && g.route.ID()+g.key < next.route.ID()+next.key) {
+				next, nextID = g, id
+			}
+		}
+		if next == nil {
+			return
+		}
+		d.flush(nextID, next, next.next)
+	}
+}
+
+func (d *dispatcher) flush(id groupID, g *aggregationGroup, now time.Time) {
+	alerts := make([]*simulatedAlert, 0, len(g.alerts))
+	for _, a := range g.alerts {
+		alerts = append(alerts, a)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].labels.Before(alerts[j].labels)
+	})
+
+	d.notify(g, alerts, now)
+
+	// Resolved alerts are removed from the group once it is flushed, whether they were notified or not.
+	for _, a := range alerts {
+		if a.resolved(now) {
+			delete(g.alerts, a.fingerprint)
+		}
+	}
+	if len(g.alerts) == 0 {
+		delete(d.groups, id)
+		return
+	}
+	g.next = now.Add(g.route.RouteOpts.GroupInterval)
+}
+
+// notify runs the alerts of a flushed group through the stages of the notification pipeline of the receiver.
+func (d *dispatcher) notify(g *aggregationGroup, alerts []*simulatedAlert, now time.Time) {
+	receiver := g.route.RouteOpts.Receiver
+
+	var inhibited []*simulatedAlert
+	alerts, inhibited = partition(alerts, func(a *simulatedAlert) bool {
+		return d.inhibited(a.labels, now)
+	})
+	d.suppress(now, g, SuppressedByInhibition, inhibited, nil, nil)
+	if len(alerts) == 0 {
+		return
+	}
+
+	if active := g.route.RouteOpts.ActiveTimeIntervals; len(active) > 0 {
+		if isActive, _ := d.intervener.Mutes(active, now); !isActive {
+			d.suppress(now, g, SuppressedByActiveTiming, alerts, nil, active)
+			return
+		}
+	}
+	d.suppress(now, g, SuppressedByActiveTiming, nil, nil, nil)
+	if mute := g.route.RouteOpts.MuteTimeIntervals; len(mute) > 0 {
+		if muted, _ := d.intervener.Mutes(mute, now); muted {
+			d.suppress(now, g, SuppressedByMuteTiming, alerts, nil, mute)
+			return
+		}
+	}
+	d.suppress(now, g, SuppressedByMuteTiming, nil, nil, nil)
+
+	var silenced []*simulatedAlert
+	silenceIDs := map[string]struct{}{}
+	alerts, silenced = partition(alerts, func(a *simulatedAlert) bool {
+		ids := d.silencedBy(a.labels, now)
+		for _, id := range ids {
+			silenceIDs[id] = struct{}{}
+		}
+		return len(ids) > 0
+	})
+	ids := make([]string, 0, len(silenceIDs))
+	for id := range silenceIDs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	d.suppress(now, g, SuppressedBySilence, silenced, ids, nil)
+	if len(alerts) == 0 {
+		return
+	}
+
+	firing := map[model.Fingerprint]struct{}{}
+	resolved := map[model.Fingerprint]struct{}{}
+	for _, a := range alerts {
+		if a.resolved(now) {
+			resolved[a.fingerprint] = struct{}{}
+		} else {
+			firing[a.fingerprint] = struct{}{}
+		}
+	}
+	sendResolved, ok := d.sendResolved[receiver]
+	if !ok {
+		sendResolved = true
+	}
+	logKey := g.key + "/" + receiver
+	entry := d.notificationLog[logKey]
+	if !needsUpdate(entry, firing, resolved, sendResolved, g.route.RouteOpts.RepeatInterval, now) {
+		return
+	}
+	d.notificationLog[logKey] = &notificationLogEntry{firing: firing, resolved: resolved, timestamp: now}
+	if len(firing) == 0 && !sendResolved {
+		return
+	}
+
+	n := SimulatedNotification{
+		Time:        now,
+		Receiver:    receiver,
+		GroupKey:    g.key,
+		GroupLabels: toDataLabels(g.labels),
+		Status:      NotificationStatusResolved,
+	}
+	if len(firing) > 0 {
+		n.Status = NotificationStatusFiring
+	}
+	for _, a := range alerts {
+		sa := SimulatedAlert{
+			Labels:   toDataLabels(a.labels),
+			Status:   NotificationStatusFiring,
+			StartsAt: a.startsAt,
+		}
+		if a.resolved(now) {
+			if !sendResolved {
+				continue
+			}
+			endsAt := a.endsAt
+			sa.Status = NotificationStatusResolved
+			sa.EndsAt = &endsAt
+		}
+		n.Alerts = append(n.Alerts, sa)
+	}
+	d.notifications = append(d.notifications, n)
+}
+
+// needsUpdate reports whether a notification is sent for the alerts, given the last notification of the group.
+// It follows the deduplication of notifications in the Alertmanager.
+func needsUpdate(entry *notificationLogEntry, firing, resolved map[model.Fingerprint]struct{}, sendResolved bool, repeat time.Duration, now time.Time) bool {
+	// If we haven't notified about the alert group before, notify right away unless we only have resolved alerts.
+	if entry == nil {
+		return len(firing) > 0
+	}
+	if !isSubset(firing, entry.firing) {
+		return true
+	}
+	// Notify about all alerts being resolved, if the receiver knew about some of them.
+	if len(firing) == 0 {
+		return len(entry.firing) > 0
+	}
+	if sendResolved && !isSubset(resolved, entry.resolved) {
+		return true
+	}
+	// Nothing changed, only notify if the repeat interval has passed.
+	return entry.timestamp.Before(now.Add(-repeat))
+}
+
+func isSubset(subset, set map[model.Fingerprint]struct{}) bool {
+	for fp := range subset {
+		if _, ok := set[fp]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// inhibited reports whether any firing alert inhibits an alert with the given labels.
+func (d *dispatcher) inhibited(lbs model.LabelSet, now time.Time) bool {
+	for _, r := range d.inhibitRules {
+		if !r.TargetMatchers.Matches(lbs) {
+			continue
+		}
+		// Alerts that match both sides of the rule do not inhibit each other.
+		excludeTwoSidedMatch := r.SourceMatchers.Matches(lbs)
+	sources:
+		for _, source := range d.alerts {
+			if source.resolved(now) || !r.SourceMatchers.Matches(source.labels) {
+				continue
+			}
+			for ln := range r.Equal {
+				if source.labels[ln] != lbs[ln] {
+					continue sources
+				}
+			}
+			if excludeTwoSidedMatch && r.TargetMatchers.Matches(source.labels) {
+				continue
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// silencedBy returns the IDs of the silences that are active at the given time and match the labels.
+func (d *dispatcher) silencedBy(lbs model.LabelSet, now time.Time) []string {
+	var ids []string
+	for _, s := range d.silences {
+		if s.mutes(lbs, now) {
+			ids = append(ids, s.id)
+		}
+	}
+	return ids
+}
+
+// suppress records the alerts of the group that were suppressed for the given reason, if they changed since the
+// last flush of the group.
+func (d *dispatcher) suppress(now time.Time, g *aggregationGroup, reason string, alerts []*simulatedAlert, silences, timeIntervals []string) {
+	key := g.key + "/" + g.route.RouteOpts.Receiver + "/" + reason
+	if len(alerts) == 0 {
+		delete(d.lastSuppressed, key)
+		return
+	}
+	fps := make([]string, 0, len(alerts))
+	for _, a := range alerts {
+		fps = append(fps, a.fingerprint.String())
+	}
+	signature := strings.Join(fps, ",")
+	if d.lastSuppressed[key] == signature {
+		return
+	}
+	d.lastSuppressed[key] = signature
+
+	s := SuppressedNotification{
+		Time:          now,
+		Receiver:      g.route.RouteOpts.Receiver,
+		GroupKey:      g.key,
+		Reason:        reason,
+		Alerts:        make([]data.Labels, 0, len(alerts)),
+		Silences:      silences,
+		TimeIntervals: timeIntervals,
+	}
+	for _, a := range alerts {
+		s.Alerts = append(s.Alerts, toDataLabels(a.labels))
+	}
+	d.suppressed = append(d.suppressed, s)
+}
+
+func partition(alerts []*simulatedAlert, match func(*simulatedAlert) bool) (kept, matched []*simulatedAlert) {
+	for _, a := range alerts {
+		if match(a) {
+			matched = append(matched, a)
+		} else {
+			kept = append(kept, a)
+		}
+	}
+	return kept, matched
+}
+
+func toDataLabels(lbs model.LabelSet) data.Labels {
+	result := make(data.Labels, len(lbs))
+	for k, v := range lbs {
+		result[string(k)] = string(v)
+	}
+	return result
+}
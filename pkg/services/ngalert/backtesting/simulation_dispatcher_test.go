@@ -0,0 +1,240 @@
+package backtesting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const testSimulationConfig = `{
+	"alertmanager_config": {
+		"route": {
+			"receiver": "default",
+			"group_by": ["alertname"],
+			"group_wait": "30s",
+			"group_interval": "5m",
+			"repeat_interval": "1h",
+			"routes": [
+				{"receiver": "team-a", "object_matchers": [["team", "=", "a"]]},
+				{"receiver": "weekend-only", "object_matchers": [["team", "=", "weekend-only"]], "active_time_intervals": ["weekends"]},
+				{"receiver": "weekday-only", "object_matchers": [["team", "=", "weekday-only"]], "mute_time_intervals": ["weekends"]}
+			]
+		},
+		"inhibit_rules": [{
+			"source_matchers": ["severity=critical"],
+			"target_matchers": ["severity=warning"],
+			"equal": ["alertname"]
+		}],
+		"time_intervals": [{"name": "weekends", "time_intervals": [{"weekdays": ["saturday", "sunday"]}]}],
+		"receivers": [
+			{"name": "default"},
+			{"name": "team-a"},
+			{"name": "weekend-only"},
+			{"name": "weekday-only"},
+			{"name": "no-resolved", "grafana_managed_receiver_configs": [{"uid": "1", "name": "no-resolved", "type": "email", "disableResolveMessage": true}]}
+		]
+	}
+}`
+
+// saturday is the start of a weekend, for the tests of time intervals.
+var saturday = time.Date(2024, time.January, 6, 0, 0, 0, 0, time.UTC)
+
+func newTestDispatcher(t *testing.T, silences ...*models.Silence) *dispatcher {
+	t.Helper()
+	cfg, err := notifier.Load([]byte(testSimulationConfig))
+	require.NoError(t, err)
+	d, err := newDispatcher(&cfg.AlertmanagerConfig, silences)
+	require.NoError(t, err)
+	return d
+}
+
+// simulateStates evaluates every minute from the start until the end of the simulation, and sends the state of every
+// alert to the dispatcher. The firing function returns whether the alert with the given labels is firing at a time.
+func simulateStates(d *dispatcher, from, to time.Time, alerts []data.Labels, firing func(lbs data.Labels, now time.Time) bool) {
+	previous := make(map[string]eval.State, len(alerts))
+	for now := from; now.Before(to); now = now.Add(time.Minute) {
+		transitions := make(state.StateTransitions, 0, len(alerts))
+		for _, lbs := range alerts {
+			s := &state.State{Labels: lbs, State: eval.Normal, LastEvaluationTime: now}
+			if firing(lbs, now) {
+				s.State = eval.Alerting
+				s.StartsAt = now
+				s.EndsAt = now.Add(4 * time.Minute)
+			} else if previous[lbs.String()] == eval.Alerting {
+				s.ResolvedAt = util.Pointer(now)
+				s.EndsAt = now
+			}
+			transitions = append(transitions, state.StateTransition{State: s, PreviousState: previous[lbs.String()]})
+			previous[lbs.String()] = s.State
+		}
+		d.observe(now, transitions)
+	}
+	d.flushUntil(to, true)
+}
+
+func firingBetween(from, to time.Time) func(data.Labels, time.Time) bool {
+	return func(_ data.Labels, now time.Time) bool {
+		return !now.Before(from) && now.Before(to)
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	from := saturday.Add(-24 * time.Hour)
+
+	t.Run("should group alerts and notify after group_wait and group_interval", func(t *testing.T) {
+		d := newTestDispatcher(t)
+		first := data.Labels{"alertname": "test", "team": "a", "instance": "1"}
+		second := data.Labels{"alertname": "test", "team": "a", "instance": "2"}
+		simulateStates(d, from, from.Add(2*time.Hour), []data.Labels{first, second}, func(lbs data.Labels, now time.Time) bool {
+			if lbs["instance"] == "2" {
+				return !now.Before(from.Add(2*time.Minute)) && now.Before(from.Add(12*time.Minute))
+			}
+			return now.Before(from.Add(12 * time.Minute))
+		})
+
+		require.Len(t, d.notifications, 3)
+		assert.Empty(t, d.suppressed)
+
+		n := d.notifications[0]
+		assert.Equal(t, from.Add(30*time.Second), n.Time, "the first notification is sent after group_wait")
+		assert.Equal(t, "team-a", n.Receiver)
+		assert.Equal(t, `{}/{team="a"}:{alertname="test"}`, n.GroupKey)
+		assert.Equal(t, data.Labels{"alertname": "test"}, n.GroupLabels)
+		assert.Equal(t, NotificationStatusFiring, n.Status)
+		require.Len(t, n.Alerts, 1)
+		assert.Equal(t, first, n.Alerts[0].Labels)
+		assert.Equal(t, from, n.Alerts[0].StartsAt)
+
+		n = d.notifications[1]
+		assert.Equal(t, from.Add(5*time.Minute+30*time.Second), n.Time, "new alerts are notified after group_interval")
+		require.Len(t, n.Alerts, 2)
+
+		n = d.notifications[2]
+		assert.Equal(t, from.Add(15*time.Minute+30*time.Second), n.Time, "unchanged groups are not notified before repeat_interval")
+		assert.Equal(t, NotificationStatusResolved, n.Status)
+		require.Len(t, n.Alerts, 2)
+		for _, a := range n.Alerts {
+			assert.Equal(t, NotificationStatusResolved, a.Status)
+			require.NotNil(t, a.EndsAt)
+			assert.Equal(t, from.Add(12*time.Minute), *a.EndsAt)
+		}
+	})
+
+	t.Run("should repeat notifications after repeat_interval", func(t *testing.T) {
+		d := newTestDispatcher(t)
+		lbs := data.Labels{"alertname": "test"}
+		simulateStates(d, from, from.Add(2*time.Hour), []data.Labels{lbs}, firingBetween(from, from.Add(2*time.Hour)))
+
+		require.Len(t, d.notifications, 2)
+		assert.Equal(t, "default", d.notifications[0].Receiver)
+		assert.Equal(t, from.Add(30*time.Second), d.notifications[0].Time)
+		assert.Equal(t, from.Add(time.Hour+5*time.Minute+30*time.Second), d.notifications[1].Time)
+	})
+
+	t.Run("should not notify resolved alerts to receivers that disable them", func(t *testing.T) {
+		cfg, err := notifier.Load([]byte(testSimulationConfig))
+		require.NoError(t, err)
+		cfg.AlertmanagerConfig.Route.Receiver = "no-resolved"
+		cfg.AlertmanagerConfig.Route.Routes = nil
+		d, err := newDispatcher(&cfg.AlertmanagerConfig, nil)
+		require.NoError(t, err)
+
+		lbs := data.Labels{"alertname": "test"}
+		simulateStates(d, from, from.Add(time.Hour), []data.Labels{lbs}, firingBetween(from, from.Add(10*time.Minute)))
+
+		require.Len(t, d.notifications, 1)
+		assert.Equal(t, NotificationStatusFiring, d.notifications[0].Status)
+	})
+
+	t.Run("should suppress inhibited alerts", func(t *testing.T) {
+		d := newTestDispatcher(t)
+		warning := data.Labels{"alertname": "test", "severity": "warning"}
+		critical := data.Labels{"alertname": "test", "severity": "critical"}
+		simulateStates(d, from, from.Add(time.Hour), []data.Labels{warning, critical}, func(lbs data.Labels, now time.Time) bool {
+			if lbs["severity"] == "critical" {
+				return now.Before(from.Add(10 * time.Minute))
+			}
+			return true
+		})
+
+		require.NotEmpty(t, d.notifications)
+		require.Len(t, d.notifications[0].Alerts, 1)
+		assert.Equal(t, critical, d.notifications[0].Alerts[0].Labels)
+
+		require.Len(t, d.suppressed, 1, "suppressed alerts are only recorded when they change")
+		assert.Equal(t, SuppressedByInhibition, d.suppressed[0].Reason)
+		assert.Equal(t, from.Add(30*time.Second), d.suppressed[0].Time)
+		assert.Equal(t, []data.Labels{warning}, d.suppressed[0].Alerts)
+
+		last := d.notifications[len(d.notifications)-1]
+		assert.Equal(t, from.Add(10*time.Minute+30*time.Second), last.Time, "the alert is notified once the source is resolved")
+		assert.Equal(t, NotificationStatusFiring, last.Status)
+		require.Len(t, last.Alerts, 2)
+	})
+
+	t.Run("should suppress silenced alerts", func(t *testing.T) {
+		silence := &models.Silence{
+			ID:     util.Pointer("silence-1"),
+			Status: &amv2.SilenceStatus{State: util.Pointer(amv2.SilenceStatusStateActive)},
+			Silence: amv2.Silence{
+				StartsAt: util.Pointer(strfmt.DateTime(from)),
+				EndsAt:   util.Pointer(strfmt.DateTime(from.Add(8 * time.Minute))),
+				Matchers: amv2.Matchers{{Name: util.Pointer("team"), Value: util.Pointer("a"), IsEqual: util.Pointer(true), IsRegex: util.Pointer(false)}},
+			},
+		}
+		d := newTestDispatcher(t, silence)
+		lbs := data.Labels{"alertname": "test", "team": "a"}
+		simulateStates(d, from, from.Add(time.Hour), []data.Labels{lbs}, firingBetween(from, from.Add(time.Hour)))
+
+		require.Len(t, d.suppressed, 1)
+		assert.Equal(t, SuppressedBySilence, d.suppressed[0].Reason)
+		assert.Equal(t, []string{"silence-1"}, d.suppressed[0].Silences)
+		require.Len(t, d.notifications, 1)
+		assert.Equal(t, from.Add(10*time.Minute+30*time.Second), d.notifications[0].Time, "the alert is notified once the silence expires")
+	})
+
+	t.Run("should suppress alerts in mute time intervals", func(t *testing.T) {
+		d := newTestDispatcher(t)
+		lbs := data.Labels{"alertname": "test", "team": "weekday-only"}
+		simulateStates(d, saturday.Add(-10*time.Minute), saturday.Add(10*time.Minute), []data.Labels{lbs}, firingBetween(saturday.Add(-10*time.Minute), saturday.Add(10*time.Minute)))
+
+		require.Len(t, d.notifications, 1)
+		assert.Equal(t, saturday.Add(-10*time.Minute+30*time.Second), d.notifications[0].Time)
+		require.Len(t, d.suppressed, 1)
+		assert.Equal(t, SuppressedByMuteTiming, d.suppressed[0].Reason)
+		assert.Equal(t, []string{"weekends"}, d.suppressed[0].TimeIntervals)
+		assert.Equal(t, saturday.Add(30*time.Second), d.suppressed[0].Time)
+	})
+
+	t.Run("should suppress alerts outside of active time intervals", func(t *testing.T) {
+		d := newTestDispatcher(t)
+		lbs := data.Labels{"alertname": "test", "team": "weekend-only"}
+		simulateStates(d, saturday.Add(-10*time.Minute), saturday.Add(10*time.Minute), []data.Labels{lbs}, firingBetween(saturday.Add(-10*time.Minute), saturday.Add(10*time.Minute)))
+
+		require.Len(t, d.suppressed, 1)
+		assert.Equal(t, SuppressedByActiveTiming, d.suppressed[0].Reason)
+		assert.Equal(t, saturday.Add(-10*time.Minute+30*time.Second), d.suppressed[0].Time)
+		require.Len(t, d.notifications, 1)
+		assert.Equal(t, saturday.Add(30*time.Second), d.notifications[0].Time)
+	})
+
+	t.Run("should fail if a time interval does not exist", func(t *testing.T) {
+		cfg, err := notifier.Load([]byte(testSimulationConfig))
+		require.NoError(t, err)
+		cfg.AlertmanagerConfig.Route.Routes = []*apimodels.Route{{Receiver: "team-a", MuteTimeIntervals: []string{"unknown"}}}
+		_, err = newDispatcher(&cfg.AlertmanagerConfig, nil)
+		require.ErrorIs(t, err, ErrInvalidInputData)
+	})
+}
@@ -0,0 +1,125 @@
+package backtesting
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+type fakeSilenceStore struct {
+	silences []*models.Silence
+}
+
+func (f *fakeSilenceStore) ListSilences(_ context.Context, _ int64, _ []string) ([]*models.Silence, error) {
+	return f.silences, nil
+}
+
+func TestRunnerSimulation(t *testing.T) {
+	gen := models.RuleGen
+	from := saturday.Add(-24 * time.Hour)
+	to := from.Add(20 * time.Minute)
+
+	// The alert is firing during the first 10 minutes of the simulation.
+	evaluator := &fakeBacktestingEvaluator{
+		evalCallback: func(now time.Time) (eval.Results, error) {
+			result := eval.Result{Instance: data.Labels{"team": "a"}, State: eval.Normal, EvaluatedAt: now}
+			if now.Before(from.Add(10 * time.Minute)) {
+				result.State = eval.Alerting
+			}
+			return eval.Results{result}, nil
+		},
+	}
+	backtestingEvaluatorFactory = func(ctx context.Context, evalFactory eval.EvaluatorFactory, user identity.Requester, condition models.Condition, r eval.AlertingResultsReader) (backtestingEvaluator, error) {
+		return evaluator, nil
+	}
+	t.Cleanup(func() {
+		backtestingEvaluatorFactory = newBacktestingEvaluator
+	})
+
+	newRule := func(mutators ...models.AlertRuleMutator) *models.AlertRule {
+		mutators = append([]models.AlertRuleMutator{
+			gen.WithInterval(time.Minute), gen.WithFor(0), gen.WithKeepFiringFor(0), gen.WithOrgID(1),
+			gen.WithTitle("test"), gen.WithLabels(nil), gen.WithNoNotificationSettings(),
+		}, mutators...)
+		return gen.With(mutators...).GenerateRef()
+	}
+	engine := NewEngine(nil, nil, tracing.InitializeTracerForTest())
+	newRunner := func(configStore AlertmanagerConfigStore) (*Runner, *fakeBacktestStore) {
+		st := newFakeBacktestStore()
+		r := NewRunner(engine, st, configStore, &fakeSilenceStore{})
+		r.log = log.NewNopLogger()
+		return r, st
+	}
+	amConfig := &fakeConfigStore{cfg: &models.AlertConfiguration{AlertmanagerConfiguration: testSimulationConfig}}
+
+	simulate := func(t *testing.T, rule *models.AlertRule, opts SimulationOptions) (models.Backtest, []SimulatedNotification) {
+		t.Helper()
+		r, st := newRunner(amConfig)
+		b := &models.Backtest{From: from, To: to}
+		require.NoError(t, r.Start(context.Background(), nil, rule, b, opts))
+		result := st.waitFinished(t, b.ID)
+		require.Equal(t, models.BacktestStatusSucceeded, result.Status, result.Error)
+		var notifications []SimulatedNotification
+		require.NoError(t, json.Unmarshal([]byte(result.Notifications), &notifications))
+		return result, notifications
+	}
+
+	t.Run("should simulate notifications with the configuration of the organization", func(t *testing.T) {
+		result, notifications := simulate(t, newRule(), SimulationOptions{FolderTitle: "folder"})
+		frame := &data.Frame{}
+		require.NoError(t, json.Unmarshal([]byte(result.Result), frame))
+		assert.Equal(t, 20, frame.Rows())
+
+		require.Len(t, notifications, 2)
+		firing := notifications[0]
+		assert.Equal(t, "team-a", firing.Receiver)
+		assert.Equal(t, from.Add(30*time.Second), firing.Time)
+		assert.Equal(t, NotificationStatusFiring, firing.Status)
+		require.Len(t, firing.Alerts, 1)
+		assert.Equal(t, "test", firing.Alerts[0].Labels[model.AlertNameLabel])
+		assert.Equal(t, "folder", firing.Alerts[0].Labels[models.FolderTitleLabel])
+
+		resolved := notifications[1]
+		assert.Equal(t, "team-a", resolved.Receiver)
+		assert.Equal(t, NotificationStatusResolved, resolved.Status)
+		assert.Equal(t, from.Add(10*time.Minute+30*time.Second), resolved.Time)
+	})
+
+	t.Run("should simulate notifications with the proposed notification policy tree", func(t *testing.T) {
+		route := &apimodels.Route{Receiver: "default", GroupByStr: []string{model.AlertNameLabel}}
+		_, notifications := simulate(t, newRule(), SimulationOptions{Route: route})
+		require.NotEmpty(t, notifications)
+		for _, n := range notifications {
+			assert.Equal(t, "default", n.Receiver)
+		}
+	})
+
+	t.Run("should reject invalid notification policy tree", func(t *testing.T) {
+		r, _ := newRunner(amConfig)
+		route := &apimodels.Route{Receiver: "unknown"}
+		err := r.Start(context.Background(), nil, newRule(), &models.Backtest{From: from, To: to}, SimulationOptions{Route: route})
+		require.ErrorIs(t, err, ErrInvalidInputData)
+	})
+
+	t.Run("should route alerts of rules with simplified routing to their receiver", func(t *testing.T) {
+		rule := newRule(gen.WithNotificationSettings(models.NotificationSettings{Receiver: "default"}))
+		_, notifications := simulate(t, rule, SimulationOptions{})
+		require.NotEmpty(t, notifications)
+		for _, n := range notifications {
+			assert.Equal(t, "default", n.Receiver)
+		}
+	})
+}
@@ -8,10 +8,12 @@ import (
 	"github.com/grafana/grafana/pkg/apimachinery/identity"
 )
 
-// AlertingResultsReader provides fingerprints of results that are in alerting state.
+// AlertingResultsReader provides fingerprints of results that are in alerting state,
+// and the dimensions of all previous results of the rule.
 // It is used during the evaluation of queries.
 type AlertingResultsReader interface {
 	Read() map[data.Fingerprint]struct{}
+	ReadDimensions() map[data.Fingerprint]data.Labels
 }
 
 // EvaluationContext represents the context in which a condition is evaluated.
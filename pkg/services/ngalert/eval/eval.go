@@ -404,6 +404,19 @@ func getExprRequest(ctx EvaluationContext, condition models.Condition, dsCacheSe
 					}
 				}
 			}
+			// an absent command with a lookback window reports the dimensions of the previous results that are gone
+			isAbsent, err := q.IsAbsentExpression()
+			if err != nil {
+				return nil, fmt.Errorf("failed to build query '%s': %w", q.RefID, err)
+			}
+			if isAbsent && reader != nil {
+				dimensions := reader.ReadDimensions()
+				logger.FromContext(ctx.Ctx).Debug("Detected absent command. Populating with the previous dimensions", "items", len(dimensions))
+				err = q.PatchAbsentExpression(dimensions)
+				if err != nil {
+					return nil, fmt.Errorf("failed to amend absent command '%s': %w", q.RefID, err)
+				}
+			}
 		}
 
 		model, err := q.GetModel()
@@ -2,6 +2,7 @@ package eval
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -732,6 +733,84 @@ func TestCreate_HysteresisCommand(t *testing.T) {
 	}
 }
 
+func TestCreate_AbsentCommand(t *testing.T) {
+	testCases := []struct {
+		name     string
+		reader   AlertingResultsReader
+		lookback string
+		expected expr.Dimensions
+	}{
+		{
+			name:     "populate with the previous dimensions",
+			reader:   FakeLoadedMetricsReader{dimensions: map[data.Fingerprint]data.Labels{data.Labels{"host": "a"}.Fingerprint(): {"host": "a"}}},
+			lookback: "5m",
+			expected: expr.Dimensions{data.Labels{"host": "a"}.Fingerprint(): {"host": "a"}},
+		},
+		{
+			name:     "do nothing without lookback window",
+			reader:   FakeLoadedMetricsReader{dimensions: map[data.Fingerprint]data.Labels{data.Labels{"host": "a"}.Fingerprint(): {"host": "a"}}},
+			lookback: "",
+		},
+		{
+			name:     "do nothing if reader is not specified",
+			reader:   nil,
+			lookback: "5m",
+		},
+	}
+
+	for _, testCase := range testCases {
+		u := &user.SignedInUser{}
+
+		t.Run(testCase.name, func(t *testing.T) {
+			cacheService := &fakes.FakeCacheService{}
+			dsQuery := models.GenerateAlertQuery()
+			ds := &datasources.DataSource{
+				UID:  dsQuery.DatasourceUID,
+				Type: util.GenerateShortUID(),
+			}
+			cacheService.DataSources = append(cacheService.DataSources, ds)
+			expected := `[{"host": "b"}]`
+			if testCase.lookback != "" {
+				expected = "null"
+			}
+			condition := models.Condition{
+				Condition: "B",
+				Data: []models.AlertQuery{
+					dsQuery,
+					{
+						RefID:         "B",
+						QueryType:     expr.DatasourceType,
+						DatasourceUID: expr.DatasourceUID,
+						Model: json.RawMessage(fmt.Sprintf(`{
+							"refId": "B",
+							"type": "absent",
+							"datasource": {"uid": "%s", "type": "%s"},
+							"expression": "%s",
+							"expected": %s,
+							"lookback": "%s"
+						}`, expr.DatasourceUID, expr.DatasourceType, dsQuery.RefID, expected, testCase.lookback)),
+					},
+				},
+			}
+			evaluator := NewEvaluatorFactory(setting.UnifiedAlertingSettings{}, cacheService, expr.ProvideService(&setting.Cfg{ExpressionsEnabled: true}, nil, nil, featuremgmt.WithFeatures(), nil, tracing.InitializeTracerForTest()))
+			evalCtx := NewContextWithPreviousResults(context.Background(), u, testCase.reader)
+
+			eval, err := evaluator.Create(evalCtx, condition)
+			require.NoError(t, err)
+			require.IsType(t, &conditionEvaluator{}, eval)
+			ce := eval.(*conditionEvaluator)
+
+			cmds := expr.GetCommandsFromPipeline[*expr.AbsentCommand](ce.pipeline)
+			require.Len(t, cmds, 1)
+			if testCase.expected == nil {
+				require.Empty(t, cmds[0].LoadedDimensions)
+			} else {
+				require.EqualValues(t, testCase.expected, cmds[0].LoadedDimensions)
+			}
+		})
+	}
+}
+
 func TestQueryDataResponseToExecutionResults(t *testing.T) {
 	t.Run("should set datasource type for captured values", func(t *testing.T) {
 		c := models.Condition{
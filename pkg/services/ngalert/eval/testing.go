@@ -88,8 +88,13 @@ func WithValues(values map[string]NumberValueCapture) ResultMutator {
 
 type FakeLoadedMetricsReader struct {
 	fingerprints map[data.Fingerprint]struct{}
+	dimensions   map[data.Fingerprint]data.Labels
 }
 
 func (f FakeLoadedMetricsReader) Read() map[data.Fingerprint]struct{} {
 	return f.fingerprints
 }
+
+func (f FakeLoadedMetricsReader) ReadDimensions() map[data.Fingerprint]data.Labels {
+	return f.dimensions
+}
@@ -146,6 +146,28 @@ func (aq *AlertQuery) PatchHysteresisExpression(loadedMetrics map[data.Fingerpri
 	return expr.SetLoadedDimensionsToHysteresisCommand(aq.modelProps, loadedMetrics)
 }
 
+// IsAbsentExpression returns true if the model describes an absent command expression with a lookback window. Returns error if the Model is not a valid JSON
+func (aq *AlertQuery) IsAbsentExpression() (bool, error) {
+	if aq.modelProps == nil {
+		err := aq.setModelProps()
+		if err != nil {
+			return false, err
+		}
+	}
+	return expr.IsAbsentExpression(aq.modelProps), nil
+}
+
+// PatchAbsentExpression updates the AlertQuery to include the dimensions of the previous results into the absent command
+func (aq *AlertQuery) PatchAbsentExpression(dimensions map[data.Fingerprint]data.Labels) error {
+	if aq.modelProps == nil {
+		err := aq.setModelProps()
+		if err != nil {
+			return err
+		}
+	}
+	return expr.SetLoadedDimensionsToAbsentCommand(aq.modelProps, dimensions)
+}
+
 // setMaxDatapoints sets the model maxDataPoints if it's missing or invalid
 func (aq *AlertQuery) setMaxDatapoints() error {
 	if aq.modelProps == nil {
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"hash/fnv"
 	"maps"
+	"regexp"
 	"slices"
 	"sort"
 	"strconv"
@@ -198,6 +199,9 @@ var (
 		AutogeneratedRouteReceiverNameLabel: {},
 		AutogeneratedRouteSettingsHashLabel: {},
 	}
+
+	// targetTableRegexp matches the table names a recording rule can write to, e.g. "metrics" or "public.metrics".
+	targetTableRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
 )
 
 // AlertRuleGroup is the base model for a rule group in unified alerting.
@@ -309,6 +313,13 @@ type AlertRule struct {
 type AlertRuleMetadata struct {
 	EditorSettings      EditorSettings       `json:"editor_settings"`
 	PrometheusStyleRule *PrometheusStyleRule `json:"prometheus_style_rule,omitempty"`
+	// DependsOn contains UIDs of rules that are evaluated before this rule when both are scheduled at the same time.
+	DependsOn []string `json:"depends_on,omitempty"`
+}
+
+// IsEmpty returns true if no metadata is set.
+func (m AlertRuleMetadata) IsEmpty() bool {
+	return m.EditorSettings == (EditorSettings{}) && m.PrometheusStyleRule == nil && len(m.DependsOn) == 0
 }
 
 type EditorSettings struct {
@@ -700,6 +711,9 @@ func validateRecordingRuleFields(rule *AlertRule) error {
 	if !prommodels.IsValidMetricName(metricName) {
 		return errors.New("metric name for recording rule must be a valid Prometheus metric name")
 	}
+	if rule.Record.TargetTable != "" && !targetTableRegexp.MatchString(rule.Record.TargetTable) {
+		return errors.New("target table for recording rule must be a table name, optionally qualified with a schema")
+	}
 
 	ClearRecordingRuleIgnoredFields(rule)
 
@@ -798,8 +812,10 @@ func (alertRule *AlertRule) Copy() *AlertRule {
 
 	if alertRule.Record != nil {
 		result.Record = &Record{
-			From:   alertRule.Record.From,
-			Metric: alertRule.Record.Metric,
+			From:                alertRule.Record.From,
+			Metric:              alertRule.Record.Metric,
+			TargetDatasourceUID: alertRule.Record.TargetDatasourceUID,
+			TargetTable:         alertRule.Record.TargetTable,
 		}
 	}
 
@@ -807,6 +823,7 @@ func (alertRule *AlertRule) Copy() *AlertRule {
 		prometheusStyleRule := *alertRule.Metadata.PrometheusStyleRule
 		result.Metadata.PrometheusStyleRule = &prometheusStyleRule
 	}
+	result.Metadata.DependsOn = slices.Clone(alertRule.Metadata.DependsOn)
 
 	for _, s := range alertRule.NotificationSettings {
 		result.NotificationSettings = append(result.NotificationSettings, CopyNotificationSettings(s))
@@ -977,7 +994,9 @@ func PatchPartialAlertRule(existingRule *AlertRule, ruleToPatch *AlertRuleWithOp
 		ruleToPatch.IsPaused = existingRule.IsPaused
 	}
 	if !ruleToPatch.HasEditorSettings {
+		// the metadata was not sent, so keep all of it
 		ruleToPatch.Metadata.EditorSettings = existingRule.Metadata.EditorSettings
+		ruleToPatch.Metadata.DependsOn = existingRule.Metadata.DependsOn
 	}
 	if ruleToPatch.MissingSeriesEvalsToResolve != nil && *ruleToPatch.MissingSeriesEvalsToResolve == -1 {
 		ruleToPatch.MissingSeriesEvalsToResolve = existingRule.MissingSeriesEvalsToResolve
@@ -1061,6 +1080,8 @@ type Record struct {
 	From string
 	// TargetDatasourceUID is the data source to write the result of the recording rule.
 	TargetDatasourceUID string
+	// TargetTable is the table the result is inserted into when the target data source is a SQL data source.
+	TargetTable string
 }
 
 func (r *Record) Fingerprint() data.Fingerprint {
@@ -1076,6 +1097,7 @@ func (r *Record) Fingerprint() data.Fingerprint {
 	writeString(r.Metric)
 	writeString(r.From)
 	writeString(r.TargetDatasourceUID)
+	writeString(r.TargetTable)
 	return data.Fingerprint(h.Sum64())
 }
 
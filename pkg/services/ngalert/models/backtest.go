@@ -0,0 +1,79 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrBacktestNotFound is returned when a backtest does not exist.
+	ErrBacktestNotFound = errors.New("backtest not found")
+	// ErrTooManyBacktests is returned when a backtest is started while the maximum number of backtests are running.
+	ErrTooManyBacktests = errors.New("too many backtests are running")
+)
+
+// BacktestStatus is the lifecycle status of a backtest.
+type BacktestStatus string
+
+const (
+	BacktestStatusPending   BacktestStatus = "pending"
+	BacktestStatusRunning   BacktestStatus = "running"
+	BacktestStatusSucceeded BacktestStatus = "succeeded"
+	BacktestStatusFailed    BacktestStatus = "failed"
+	BacktestStatusCanceled  BacktestStatus = "canceled"
+)
+
+// IsFinal returns true if the backtest will not change its status anymore.
+func (s BacktestStatus) IsFinal() bool {
+	return s == BacktestStatusSucceeded || s == BacktestStatusFailed || s == BacktestStatusCanceled
+}
+
+// Backtest is a persisted backtesting run of an alert rule.
+type Backtest struct {
+	ID    int64  `xorm:"pk autoincr 'id'"`
+	OrgID int64  `xorm:"org_id"`
+	UID   string `xorm:"uid"`
+	// RuleUID and RuleVersion identify the stored rule the backtest was run for.
+	// They are empty for rules that were not saved yet.
+	RuleUID     string `xorm:"rule_uid"`
+	RuleVersion int64  `xorm:"rule_version"`
+	// NamespaceUID is the folder of the rule. Users need access to the folder to see the backtest.
+	// It is empty for rules that were tested without a folder.
+	NamespaceUID string         `xorm:"namespace_uid"`
+	Title        string         `xorm:"title"`
+	Status       BacktestStatus `xorm:"status"`
+	// Progress is the number of evaluations done out of Evaluations.
+	Progress    int       `xorm:"progress"`
+	Evaluations int       `xorm:"evaluations"`
+	From        time.Time `xorm:"range_from"`
+	To          time.Time `xorm:"range_to"`
+	// Config is the JSON encoded request the backtest was started with.
+	Config string `xorm:"config"`
+	// Result is the JSON encoded data frame with the state timeline of every dimension.
+	Result string `xorm:"result"`
+	// Notifications is the JSON encoded list of notifications that would have been sent.
+	Notifications string `xorm:"notifications"`
+	// Suppressed is the JSON encoded list of alerts that were not notified because of inhibition rules,
+	// time intervals or silences.
+	Suppressed string    `xorm:"suppressed"`
+	Error      string    `xorm:"error"`
+	CreatedBy  string    `xorm:"created_by"`
+	Created    time.Time `xorm:"created"`
+	Updated    time.Time `xorm:"updated"`
+}
+
+func (b Backtest) TableName() string {
+	return "alert_rule_backtest"
+}
+
+func (b Backtest) GetNamespaceUID() string {
+	return b.NamespaceUID
+}
+
+// ListBacktestsQuery is the query for listing the backtests of an organization.
+type ListBacktestsQuery struct {
+	OrgID int64
+	// RuleUID limits the result to the backtests of a single rule if not empty.
+	RuleUID string
+	Limit   int
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"cmp"
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	// quotedStringRegexp matches string literals of PromQL-like query languages.
+	quotedStringRegexp = regexp.MustCompile("\"(?:[^\"\\\\]|\\\\.)*\"|'(?:[^'\\\\]|\\\\.)*'|`[^`]*`")
+	// metricNameRegexp matches tokens that can be a metric name.
+	metricNameRegexp = regexp.MustCompile(`[a-zA-Z_:][a-zA-Z0-9_:]*`)
+)
+
+// RuleDependencies maps a rule to the rules of the same organization that have to be evaluated before it.
+type RuleDependencies map[AlertRuleKey][]AlertRuleKey
+
+// BuildRuleDependencies returns the dependencies between the rules. A rule depends on the rules declared in
+// its metadata, and on the recording rules that write a metric its queries read. Declared dependencies on
+// rules that are not in the list are ignored.
+func BuildRuleDependencies(rules []*AlertRule) RuleDependencies {
+	type recordingTarget struct {
+		key           AlertRuleKey
+		datasourceUID string
+	}
+	known := make(map[AlertRuleKey]struct{}, len(rules))
+	recorded := make(map[int64]map[string][]recordingTarget)
+	for _, rule := range rules {
+		known[rule.GetKey()] = struct{}{}
+		if rule.Record == nil || rule.Record.Metric == "" {
+			continue
+		}
+		byMetric, ok := recorded[rule.OrgID]
+		if !ok {
+			byMetric = make(map[string][]recordingTarget)
+			recorded[rule.OrgID] = byMetric
+		}
+		byMetric[rule.Record.Metric] = append(byMetric[rule.Record.Metric], recordingTarget{
+			key:           rule.GetKey(),
+			datasourceUID: rule.Record.TargetDatasourceUID,
+		})
+	}
+
+	result := make(RuleDependencies)
+	for _, rule := range rules {
+		key := rule.GetKey()
+		var deps []AlertRuleKey
+		add := func(dep AlertRuleKey) {
+			if dep != key && !slices.Contains(deps, dep) {
+				deps = append(deps, dep)
+			}
+		}
+		for _, uid := range rule.Metadata.DependsOn {
+			dep := AlertRuleKey{OrgID: rule.OrgID, UID: uid}
+			if _, ok := known[dep]; ok {
+				add(dep)
+			}
+		}
+		if byMetric := recorded[rule.OrgID]; len(byMetric) > 0 {
+			for _, q := range rule.Data {
+				for _, metric := range queryMetricNames(q) {
+					for _, target := range byMetric[metric] {
+						// recording rules without a target data source write to the default one
+						if target.datasourceUID == "" || target.datasourceUID == q.DatasourceUID {
+							add(target.key)
+						}
+					}
+				}
+			}
+		}
+		if len(deps) > 0 {
+			slices.SortFunc(deps, func(a, b AlertRuleKey) int {
+				return cmp.Or(cmp.Compare(a.OrgID, b.OrgID), strings.Compare(a.UID, b.UID))
+			})
+			result[key] = deps
+		}
+	}
+	return result
+}
+
+// queryMetricNames returns the tokens of the query expression that can be metric names. It does not parse
+// the query, so the result can contain function and label names as well.
+func queryMetricNames(q AlertQuery) []string {
+	var model struct {
+		Expr string `json:"expr"`
+	}
+	if err := json.Unmarshal(q.Model, &model); err != nil || model.Expr == "" {
+		return nil
+	}
+	return metricNameRegexp.FindAllString(quotedStringRegexp.ReplaceAllString(model.Expr, ""), -1)
+}
+
+// FindCycle returns a dependency cycle that goes through the rule with the given key, starting and ending
+// with the key. It returns nil if there is no such cycle.
+func (d RuleDependencies) FindCycle(key AlertRuleKey) []AlertRuleKey {
+	parent := map[AlertRuleKey]AlertRuleKey{}
+	queue := []AlertRuleKey{key}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dep := range d[current] {
+			if _, visited := parent[dep]; visited {
+				continue
+			}
+			parent[dep] = current
+			if dep == key {
+				cycle := []AlertRuleKey{key}
+				for k := current; k != key; k = parent[k] {
+					cycle = append(cycle, k)
+				}
+				cycle = append(cycle, key)
+				slices.Reverse(cycle)
+				return cycle
+			}
+			queue = append(queue, dep)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildRuleDependencies(t *testing.T) {
+	query := func(datasourceUID, expr string) AlertQuery {
+		model, err := json.Marshal(map[string]string{"expr": expr})
+		require.NoError(t, err)
+		return AlertQuery{RefID: "A", DatasourceUID: datasourceUID, Model: model}
+	}
+	key := func(uid string) AlertRuleKey {
+		return AlertRuleKey{OrgID: 1, UID: uid}
+	}
+
+	rules := []*AlertRule{
+		{OrgID: 1, UID: "recording", Record: &Record{Metric: "job:requests:rate5m", TargetDatasourceUID: "prom"}},
+		{OrgID: 1, UID: "recording-default", Record: &Record{Metric: "job:errors:rate5m"}},
+		{OrgID: 1, UID: "reads-recorded", Data: []AlertQuery{query("prom", `sum(job:requests:rate5m{job="api"}) / job:errors:rate5m`)}},
+		{OrgID: 1, UID: "other-datasource", Data: []AlertQuery{query("loki", `job:requests:rate5m`)}},
+		{OrgID: 1, UID: "quoted", Data: []AlertQuery{query("prom", `up{job="job:requests:rate5m"}`)}},
+		{OrgID: 1, UID: "declared", Metadata: AlertRuleMetadata{DependsOn: []string{"quoted", "declared", "missing"}}},
+		{OrgID: 2, UID: "other-org", Data: []AlertQuery{query("prom", `job:requests:rate5m`)}},
+	}
+
+	deps := BuildRuleDependencies(rules)
+	require.Equal(t, RuleDependencies{
+		key("reads-recorded"): {key("recording"), key("recording-default")},
+		key("declared"):       {key("quoted")},
+	}, deps)
+}
+
+func TestRuleDependenciesFindCycle(t *testing.T) {
+	key := func(uid string) AlertRuleKey {
+		return AlertRuleKey{OrgID: 1, UID: uid}
+	}
+	deps := RuleDependencies{
+		key("a"): {key("b")},
+		key("b"): {key("c"), key("d")},
+		key("c"): {key("a")},
+		key("e"): {key("a")},
+	}
+
+	require.Equal(t, []AlertRuleKey{key("a"), key("b"), key("c"), key("a")}, deps.FindCycle(key("a")))
+	require.Equal(t, []AlertRuleKey{key("c"), key("a"), key("b"), key("c")}, deps.FindCycle(key("c")))
+	require.Nil(t, deps.FindCycle(key("e")))
+	require.Nil(t, deps.FindCycle(key("d")))
+}
@@ -189,6 +189,11 @@ func (moa *MultiOrgAlertmanager) setupClustering(cfg *setting.Cfg) error {
 			tlsEnabled:  cfg.UnifiedAlerting.HARedisTLSEnabled,
 			tls:         cfg.UnifiedAlerting.HARedisTLSConfig,
 			clusterMode: cfg.UnifiedAlerting.HARedisClusterModeEnabled,
+
+			sentinelMode:       cfg.UnifiedAlerting.HARedisSentinelModeEnabled,
+			sentinelMasterName: cfg.UnifiedAlerting.HARedisSentinelMasterName,
+			sentinelUsername:   cfg.UnifiedAlerting.HARedisSentinelUsername,
+			sentinelPassword:   cfg.UnifiedAlerting.HARedisSentinelPassword,
 		}, clusterLogger, moa.metrics.Registerer, cfg.UnifiedAlerting.HAPushPullInterval)
 		if err != nil {
 			return fmt.Errorf("unable to initialize redis: %w", err)
@@ -3,6 +3,8 @@ package notifier
 import (
 	"context"
 	"crypto/tls"
+	"errors"
+	"net"
 	"slices"
 	"sort"
 	"strconv"
@@ -32,6 +34,12 @@ type redisConfig struct {
 	maxConns    int
 	clusterMode bool
 
+	// In sentinel mode, addr is the list of sentinels and the master is discovered through them.
+	sentinelMode       bool
+	sentinelMasterName string
+	sentinelUsername   string
+	sentinelPassword   string
+
 	tlsEnabled bool
 	tls        dstls.ClientConfig
 }
@@ -52,6 +60,7 @@ const (
 	heartbeatInterval       = time.Second * 5
 	heartbeatTimeout        = time.Minute
 	defaultPoolSize         = 5
+	switchMasterChannel     = "+switch-master"
 	// The duration we want to return the members if the network is down.
 	membersValidFor = time.Minute
 )
@@ -78,12 +87,20 @@ type redisPeer struct {
 	messagesPublishFailures *prometheus.CounterVec
 	nodePingDuration        *prometheus.HistogramVec
 	nodePingFailures        prometheus.Counter
+	failovers               prometheus.Counter
 
 	// List of active members of the cluster. Should be accessed through the Members function.
 	members    []string
 	membersMtx sync.Mutex
 	// The time when we fetched the members from redis the last time successfully.
 	membersFetchedAt time.Time
+
+	// The sentinels that are watched for failovers in sentinel mode.
+	sentinels []*redis.SentinelClient
+	// The address of the master in sentinel mode, or the addresses of the masters in cluster mode,
+	// as last seen by the peer. They are used to detect failovers.
+	masters    []string
+	mastersMtx sync.Mutex
 }
 
 func newRedisPeer(cfg redisConfig, logger log.Logger, reg prometheus.Registerer,
@@ -121,9 +138,20 @@ func newRedisPeer(cfg redisConfig, logger log.Logger, reg prometheus.Registerer,
 	}
 
 	var rdb redis.UniversalClient
-	if cfg.clusterMode {
+	switch {
+	case cfg.sentinelMode && cfg.clusterMode:
+		return nil, errors.New("redis sentinel mode cannot be used together with cluster mode")
+	case cfg.sentinelMode:
+		if cfg.sentinelMasterName == "" {
+			return nil, errors.New("the name of the redis master is required in sentinel mode")
+		}
+		opts.MasterName = cfg.sentinelMasterName
+		opts.SentinelUsername = cfg.sentinelUsername
+		opts.SentinelPassword = cfg.sentinelPassword
+		rdb = redis.NewFailoverClient(opts.Failover())
+	case cfg.clusterMode:
 		rdb = redis.NewClusterClient(opts.Cluster())
-	} else {
+	default:
 		rdb = redis.NewClient(opts.Simple())
 	}
 
@@ -200,6 +228,10 @@ func newRedisPeer(cfg redisConfig, logger log.Logger, reg prometheus.Registerer,
 		Name: "alertmanager_cluster_pings_failures_total",
 		Help: "Total number of failed pings.",
 	})
+	failovers := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "alertmanager_cluster_redis_failovers_total",
+		Help: "Total number of redis failovers detected by the peer.",
+	})
 
 	messagesReceived.WithLabelValues(fullState)
 	messagesReceivedSize.WithLabelValues(fullState)
@@ -215,7 +247,7 @@ func newRedisPeer(cfg redisConfig, logger log.Logger, reg prometheus.Registerer,
 
 	reg.MustRegister(messagesReceived, messagesReceivedSize, messagesSent, messagesSentSize,
 		gossipClusterMembers, peerPosition, healthScore, nodePingDuration, nodePingFailures,
-		messagesPublishFailures,
+		messagesPublishFailures, failovers,
 	)
 
 	p.messagesReceived = messagesReceived
@@ -225,6 +257,7 @@ func newRedisPeer(cfg redisConfig, logger log.Logger, reg prometheus.Registerer,
 	p.messagesPublishFailures = messagesPublishFailures
 	p.nodePingDuration = nodePingDuration
 	p.nodePingFailures = nodePingFailures
+	p.failovers = failovers
 
 	p.subsMtx.Lock()
 	p.subs[fullStateChannel] = p.redis.Subscribe(context.Background(), p.withPrefix(fullStateChannel))
@@ -237,6 +270,22 @@ func newRedisPeer(cfg redisConfig, logger log.Logger, reg prometheus.Registerer,
 	go p.fullStateSyncReceiveLoop()
 	go p.fullStateReqReceiveLoop()
 
+	switch {
+	case cfg.sentinelMode:
+		for _, addr := range addrs {
+			sentinel := redis.NewSentinelClient(&redis.Options{
+				Addr:      addr,
+				Username:  cfg.sentinelUsername,
+				Password:  cfg.sentinelPassword,
+				TLSConfig: tlsClientConfig,
+			})
+			p.sentinels = append(p.sentinels, sentinel)
+			go p.sentinelFailoverLoop(sentinel, cfg.sentinelMasterName)
+		}
+	case cfg.clusterMode:
+		go p.clusterFailoverLoop()
+	}
+
 	return p, nil
 }
 
@@ -612,11 +661,127 @@ func (p *redisPeer) fullStateSyncPublishLoop() {
 }
 
 func (p *redisPeer) requestFullState() {
+	_ = p.publishFullStateRequest()
+}
+
+func (p *redisPeer) publishFullStateRequest() error {
 	pub := p.redis.Publish(context.Background(), p.withPrefix(fullStateChannelReq), p.name)
 	if pub.Err() != nil {
 		p.messagesPublishFailures.WithLabelValues(fullState, reasonRedisIssue).Inc()
 		p.logger.Error("Error publishing a message to redis", "err", pub.Err(), "channel", p.withPrefix(fullStateChannelReq))
 	}
+	return pub.Err()
+}
+
+// sentinelFailoverLoop listens to the failovers announced by a sentinel.
+func (p *redisPeer) sentinelFailoverLoop(sentinel *redis.SentinelClient, masterName string) {
+	sub := sentinel.Subscribe(context.Background(), switchMasterChannel)
+	defer func() {
+		if err := sub.Close(); err != nil {
+			p.logger.Debug("Error closing the sentinel subscription", "err", err)
+		}
+	}()
+	ch := sub.Channel()
+	for {
+		select {
+		case <-p.shutdownc:
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			// The payload is "<master name> <old ip> <old port> <new ip> <new port>".
+			parts := strings.Split(msg.Payload, " ")
+			if len(parts) != 5 || parts[0] != masterName {
+				continue
+			}
+			master := net.JoinHostPort(parts[3], parts[4])
+			if p.switchMaster(master) {
+				p.failover(master)
+			}
+		}
+	}
+}
+
+// clusterFailoverLoop periodically looks up the masters of the redis cluster, as the cluster does not announce failovers.
+func (p *redisPeer) clusterFailoverLoop() {
+	ticker := time.NewTicker(membersSyncInterval)
+	for {
+		select {
+		case <-ticker.C:
+			slots, err := p.redis.ClusterSlots(context.Background()).Result()
+			if err != nil {
+				p.logger.Debug("Error getting the slots of the redis cluster", "err", err)
+				continue
+			}
+			masters := clusterMasters(slots)
+			if p.setMasters(masters) {
+				p.failover(strings.Join(masters, ","))
+			}
+		case <-p.shutdownc:
+			ticker.Stop()
+			return
+		}
+	}
+}
+
+// clusterMasters returns the sorted addresses of the masters of the slots. The first node of a slot is its master.
+func clusterMasters(slots []redis.ClusterSlot) []string {
+	masters := make([]string, 0, len(slots))
+	for _, slot := range slots {
+		if len(slot.Nodes) > 0 {
+			masters = append(masters, slot.Nodes[0].Addr)
+		}
+	}
+	sort.Strings(masters)
+	return slices.Compact(masters)
+}
+
+// switchMaster records the master announced by a sentinel, and reports whether it changed. Every sentinel
+// announces the same failover, but it must only be handled once.
+func (p *redisPeer) switchMaster(master string) bool {
+	p.mastersMtx.Lock()
+	defer p.mastersMtx.Unlock()
+	if len(p.masters) == 1 && p.masters[0] == master {
+		return false
+	}
+	p.masters = []string{master}
+	return true
+}
+
+// setMasters records the current masters of the cluster, and reports whether a failover happened: that is, whether one of the
+// previously known masters is no longer a master. Masters added to a cluster are not failovers.
+func (p *redisPeer) setMasters(masters []string) bool {
+	p.mastersMtx.Lock()
+	defer p.mastersMtx.Unlock()
+	previous := p.masters
+	p.masters = masters
+	for _, m := range previous {
+		if !slices.Contains(masters, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// failover makes the peer catch up after a redis failover. Messages published during the failover are lost, and
+// the new master might not have received the latest writes, so the peer requests the full state of the other
+// peers and publishes its own.
+func (p *redisPeer) failover(master string) {
+	p.failovers.Inc()
+	p.logger.Warn("Redis failover detected, requesting the full state of the other peers", "master", master)
+	go func() {
+		backoff := networkRetryIntervalMin
+		for p.publishFullStateRequest() != nil {
+			select {
+			case <-p.shutdownc:
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(2*backoff, networkRetryIntervalMax)
+		}
+		p.fullStateSyncPublish()
+	}()
 }
 
 func (p *redisPeer) LocalState() []byte {
@@ -652,4 +817,9 @@ func (p *redisPeer) Shutdown() {
 	if del.Err() != nil {
 		p.logger.Error("Error deleting the redis key on shutdown", "err", del.Err(), "key", p.withPrefix(p.name))
 	}
+	for _, sentinel := range p.sentinels {
+		if err := sentinel.Close(); err != nil {
+			p.logger.Debug("Error closing the sentinel client on shutdown", "err", err)
+		}
+	}
 }
@@ -4,15 +4,21 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"net"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/alicebob/miniredis/v2"
+	"github.com/alicebob/miniredis/v2/server"
 	dstls "github.com/grafana/dskit/crypto/tls"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/madflojo/testcerts"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/require"
 )
 
@@ -111,6 +117,111 @@ func TestNewRedisPeerWithMutualTLS(t *testing.T) {
 	require.NoError(t, ping.Err())
 }
 
+// fakeSentinel is a miniredis instance that answers the sentinel commands needed to discover a master.
+type fakeSentinel struct {
+	*miniredis.Miniredis
+	mtx    sync.Mutex
+	master []string
+}
+
+func runFakeSentinel(t *testing.T, master *miniredis.Miniredis) *fakeSentinel {
+	t.Helper()
+	s := &fakeSentinel{Miniredis: miniredis.RunT(t), master: []string{master.Host(), master.Port()}}
+	err := s.Server().Register("SENTINEL", func(c *server.Peer, cmd string, args []string) {
+		if len(args) == 0 {
+			c.WriteError("ERR wrong number of arguments")
+			return
+		}
+		switch strings.ToLower(args[0]) {
+		case "get-master-addr-by-name":
+			s.mtx.Lock()
+			defer s.mtx.Unlock()
+			c.WriteStrings(s.master)
+		case "sentinels":
+			c.WriteLen(0)
+		default:
+			c.WriteError("ERR unknown sentinel subcommand")
+		}
+	})
+	require.NoError(t, err)
+	return s
+}
+
+// failover promotes a new master and announces it like a sentinel does.
+func (s *fakeSentinel) failover(masterName string, master *miniredis.Miniredis) {
+	s.mtx.Lock()
+	old := s.master
+	s.master = []string{master.Host(), master.Port()}
+	s.mtx.Unlock()
+	s.Publish(switchMasterChannel, strings.Join(append(append([]string{masterName}, old...), s.master...), " "))
+}
+
+func TestNewRedisPeerSentinelMode(t *testing.T) {
+	master := miniredis.RunT(t)
+	sentinel := runFakeSentinel(t, master)
+
+	peer, err := newRedisPeer(redisConfig{
+		name:               "peer",
+		addr:               sentinel.Addr(),
+		sentinelMode:       true,
+		sentinelMasterName: "mymaster",
+	}, log.NewNopLogger(), prometheus.NewRegistry(), time.Second*60)
+	require.NoError(t, err)
+	t.Cleanup(peer.Shutdown)
+
+	require.NoError(t, peer.redis.Set(context.Background(), "key", "value", 0).Err())
+	value, err := master.Get("key")
+	require.NoError(t, err)
+	require.Equal(t, "value", value, "the peer should write to the master discovered through the sentinel")
+
+	// Wait for the peer to listen to the failovers announced by the sentinel.
+	require.Eventually(t, func() bool {
+		return sentinel.PubSubNumSub(switchMasterChannel)[switchMasterChannel] > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The peer should request the full state of the other peers from the new master.
+	newMaster := miniredis.RunT(t)
+	sub := redis.NewClient(&redis.Options{Addr: newMaster.Addr()}).Subscribe(context.Background(), fullStateChannelReq)
+	t.Cleanup(func() { _ = sub.Close() })
+	_, err = sub.Receive(context.Background())
+	require.NoError(t, err)
+
+	sentinel.failover("mymaster", newMaster)
+	select {
+	case msg := <-sub.Channel():
+		require.Equal(t, "peer", msg.Payload)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the peer did not request the full state after the failover")
+	}
+	require.Equal(t, 1.0, testutil.ToFloat64(peer.failovers))
+
+	// Every sentinel announces the same failover.
+	require.False(t, peer.switchMaster(net.JoinHostPort(newMaster.Host(), newMaster.Port())))
+}
+
+func TestNewRedisPeerSentinelModeValidation(t *testing.T) {
+	_, err := newRedisPeer(redisConfig{addr: "localhost:26379", sentinelMode: true}, log.NewNopLogger(), prometheus.NewRegistry(), time.Second*60)
+	require.Error(t, err, "the master name is required")
+
+	_, err = newRedisPeer(redisConfig{addr: "localhost:26379", sentinelMode: true, sentinelMasterName: "mymaster", clusterMode: true}, log.NewNopLogger(), prometheus.NewRegistry(), time.Second*60)
+	require.Error(t, err, "sentinel and cluster mode cannot be used together")
+}
+
+func TestRedisPeerClusterMasters(t *testing.T) {
+	slots := []redis.ClusterSlot{
+		{Start: 0, End: 5460, Nodes: []redis.ClusterNode{{Addr: "b:6379"}, {Addr: "b-replica:6379"}}},
+		{Start: 5461, End: 10922, Nodes: []redis.ClusterNode{{Addr: "a:6379"}}},
+		{Start: 10923, End: 16383, Nodes: []redis.ClusterNode{{Addr: "a:6379"}}},
+	}
+	require.Equal(t, []string{"a:6379", "b:6379"}, clusterMasters(slots))
+
+	p := &redisPeer{}
+	require.False(t, p.setMasters([]string{"a:6379", "b:6379"}), "the first masters are not a failover")
+	require.False(t, p.setMasters([]string{"a:6379", "b:6379", "c:6379"}), "new masters are not a failover")
+	require.True(t, p.setMasters([]string{"a:6379", "b-replica:6379", "c:6379"}), "a replica was promoted")
+	require.False(t, p.setMasters([]string{"a:6379", "b-replica:6379", "c:6379"}))
+}
+
 type certPaths struct {
 	clientCert string
 	clientKey  string
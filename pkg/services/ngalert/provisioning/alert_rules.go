@@ -9,6 +9,7 @@ import (
 	"github.com/grafana/grafana/pkg/apimachinery/errutil"
 	"github.com/grafana/grafana/pkg/apimachinery/identity"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/auditlog"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/folder"
 	"github.com/grafana/grafana/pkg/services/ngalert/accesscontrol"
@@ -51,6 +52,7 @@ type AlertRuleService struct {
 	log                    log.Logger
 	nsValidatorProvider    NotificationSettingsValidatorProvider
 	authz                  ruleAccessControlService
+	auditLog               auditlog.Recorder
 }
 
 func NewAlertRuleService(ruleStore RuleStore,
@@ -64,6 +66,7 @@ func NewAlertRuleService(ruleStore RuleStore,
 	log log.Logger,
 	ns NotificationSettingsValidatorProvider,
 	authz RuleAccessControlService,
+	auditLog auditlog.Recorder,
 ) *AlertRuleService {
 	return &AlertRuleService{
 		defaultIntervalSeconds: defaultIntervalSeconds,
@@ -77,6 +80,7 @@ func NewAlertRuleService(ruleStore RuleStore,
 		log:                    log,
 		nsValidatorProvider:    ns,
 		authz:                  newRuleAccessControlService(authz),
+		auditLog:               auditLog,
 	}
 }
 
@@ -265,6 +269,7 @@ func (service *AlertRuleService) CreateAlertRule(ctx context.Context, user ident
 	if err != nil {
 		return models.AlertRule{}, err
 	}
+	recordChange(ctx, service.auditLog, user, rule.OrgID, auditlog.ActionCreate, auditKindAlertRule, rule.UID, nil, rule)
 	return rule, nil
 }
 
@@ -551,7 +556,9 @@ func (service *AlertRuleService) calcDelta(ctx context.Context, user identity.Re
 }
 
 func (service *AlertRuleService) persistDelta(ctx context.Context, user identity.Requester, delta *store.GroupDelta, provenance models.Provenance) error {
-	return service.xact.InTransaction(ctx, func(ctx context.Context) error {
+	var updated []models.UpdateRule
+	var created []models.AlertRule
+	err := service.xact.InTransaction(ctx, func(ctx context.Context) error {
 		// Delete first as this could prevent future unique constraint violations.
 		if len(delta.Delete) > 0 {
 			for _, del := range delta.Delete {
@@ -600,6 +607,7 @@ func (service *AlertRuleService) persistDelta(ctx context.Context, user identity
 			if err := service.ruleStore.UpdateAlertRules(ctx, userUidOrFallback(user), updates); err != nil {
 				return fmt.Errorf("failed to update alert rules: %w", err)
 			}
+			updated = updates
 			for _, update := range delta.Update {
 				if err := service.provenanceStore.SetProvenance(ctx, update.New, user.GetOrgID(), provenance); err != nil {
 					return err
@@ -608,10 +616,18 @@ func (service *AlertRuleService) persistDelta(ctx context.Context, user identity
 		}
 
 		if len(delta.New) > 0 {
-			uids, err := service.ruleStore.InsertAlertRules(ctx, userUidOrFallback(user), withoutNilAlertRules(delta.New))
+			newRules := withoutNilAlertRules(delta.New)
+			uids, err := service.ruleStore.InsertAlertRules(ctx, userUidOrFallback(user), newRules)
 			if err != nil {
 				return fmt.Errorf("failed to insert alert rules: %w", err)
 			}
+			// The keys are returned in the order of the rules, with the UIDs generated for the rules without one.
+			if len(uids) == len(newRules) {
+				for i, key := range uids {
+					newRules[i].UID = key.UID
+				}
+				created = newRules
+			}
 			for _, key := range uids {
 				if err := service.provenanceStore.SetProvenance(ctx, &models.AlertRule{UID: key.UID}, user.GetOrgID(), provenance); err != nil {
 					return err
@@ -625,6 +641,11 @@ func (service *AlertRuleService) persistDelta(ctx context.Context, user identity
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	recordRuleChanges(ctx, service.auditLog, user, delta.GroupKey.OrgID, delta.Delete, updated, created)
+	return nil
 }
 
 // UpdateAlertRule updates an alert rule.
@@ -699,7 +720,7 @@ func (service *AlertRuleService) UpdateAlertRule(ctx context.Context, user ident
 	// Currently metadata contains only editor settings, so we can just copy it.
 	// If we add more fields to metadata, we might need to handle them separately,
 	// and/or merge or update their values.
-	if rule.Metadata == (models.AlertRuleMetadata{}) {
+	if rule.Metadata.IsEmpty() {
 		rule.Metadata = storedRule.Metadata
 	}
 
@@ -722,6 +743,7 @@ func (service *AlertRuleService) UpdateAlertRule(ctx context.Context, user ident
 	if err != nil {
 		return models.AlertRule{}, err
 	}
+	recordChange(ctx, service.auditLog, user, rule.OrgID, auditlog.ActionUpdate, auditKindAlertRule, rule.UID, storedRule, rule)
 	return rule, err
 }
 
@@ -762,9 +784,25 @@ func (service *AlertRuleService) DeleteAlertRule(ctx context.Context, user ident
 	// The single delete is idempotent, and doesn't error when deleting a group that already doesn't exist.
 	// This is different from deleting groups. We delete the rules directly rather than persisting a delta here to keep the semantics the same.
 	// TODO: Either persist a delta here as a breaking change, or deprecate this endpoint in favor of the group endpoint.
-	return service.xact.InTransaction(ctx, func(ctx context.Context) error {
+	var deleted *models.AlertRule
+	err = service.xact.InTransaction(ctx, func(ctx context.Context) error {
+		if service.auditLog != nil {
+			existing, err := service.ruleStore.GetAlertRuleByUID(ctx, &models.GetAlertRuleByUIDQuery{UID: rule.UID, OrgID: rule.OrgID})
+			if err != nil && !errors.Is(err, models.ErrAlertRuleNotFound) {
+				return err
+			}
+			deleted = existing
+		}
 		return service.deleteRules(ctx, user, rule)
 	})
+	if err != nil {
+		return err
+	}
+	// Deleting a rule that does not exist is not a change.
+	if deleted != nil {
+		recordChange(ctx, service.auditLog, user, rule.OrgID, auditlog.ActionDelete, auditKindAlertRule, rule.UID, deleted, nil)
+	}
+	return nil
 }
 
 // checkLimitsTransactionCtx checks whether the current transaction (as identified by the ctx) breaches configured alert rule limits.
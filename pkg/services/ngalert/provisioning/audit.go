@@ -0,0 +1,69 @@
+package provisioning
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/auditlog"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+const (
+	auditKindAlertRule    = "alert-rule"
+	auditKindContactPoint = "contact-point"
+)
+
+// recordChange records the change of a resource in the audit log, if there is one.
+// The actor is taken from the context when user is nil.
+func recordChange(ctx context.Context, recorder auditlog.Recorder, user identity.Requester, orgID int64, action auditlog.Action, kind, uid string, before, after any) {
+	if recorder == nil {
+		return
+	}
+	e := auditlog.ResourceEvent(auditlog.SourceProvisioning, action, kind, uid, before, after)
+	e.OrgID = orgID
+	if user != nil {
+		e.ActorUID = user.GetUID()
+		e.ActorLogin = user.GetLogin()
+	}
+	recorder.Record(ctx, e)
+}
+
+// recordRuleChanges records the changes of a rule group that was persisted.
+func recordRuleChanges(ctx context.Context, recorder auditlog.Recorder, user identity.Requester, orgID int64, deleted []*models.AlertRule, updated []models.UpdateRule, created []models.AlertRule) {
+	for _, rule := range deleted {
+		recordChange(ctx, recorder, user, orgID, auditlog.ActionDelete, auditKindAlertRule, rule.UID, rule, nil)
+	}
+	for _, update := range updated {
+		recordChange(ctx, recorder, user, orgID, auditlog.ActionUpdate, auditKindAlertRule, update.New.UID, update.Existing, update.New)
+	}
+	for _, rule := range created {
+		recordChange(ctx, recorder, user, orgID, auditlog.ActionCreate, auditKindAlertRule, rule.UID, nil, rule)
+	}
+}
+
+// redactedContactPoint returns a copy of the contact point with the values of the given settings redacted.
+func redactedContactPoint(cp apimodels.EmbeddedContactPoint, keys []string) apimodels.EmbeddedContactPoint {
+	if cp.Settings == nil {
+		return cp
+	}
+	// Leave the settings out rather than risk leaking secrets.
+	raw, err := cp.Settings.MarshalJSON()
+	if err != nil {
+		cp.Settings = nil
+		return cp
+	}
+	settings, err := simplejson.NewJson(raw)
+	if err != nil {
+		cp.Settings = nil
+		return cp
+	}
+	for _, key := range keys {
+		if _, ok := settings.CheckGet(key); ok {
+			settings.Set(key, apimodels.RedactedValue)
+		}
+	}
+	cp.Settings = settings
+	return cp
+}
@@ -0,0 +1,62 @@
+package provisioning
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/auditlog"
+	"github.com/grafana/grafana/pkg/services/auditlog/auditlogtest"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func TestAlertRuleServiceAuditLog(t *testing.T) {
+	ruleService := createAlertRuleService(t, nil)
+	auditLog := auditlogtest.NewFakeService()
+	ruleService.auditLog = auditLog
+
+	var orgID int64 = 1
+	u := &user.SignedInUser{UserUID: util.GenerateShortUID(), UserID: 1, OrgID: orgID, Login: "admin"}
+	ctx := context.Background()
+
+	rule, err := ruleService.CreateAlertRule(ctx, u, dummyRule("audited", orgID), models.ProvenanceAPI)
+	require.NoError(t, err)
+
+	rule.Title = "audited and renamed"
+	_, err = ruleService.UpdateAlertRule(ctx, u, rule, models.ProvenanceAPI)
+	require.NoError(t, err)
+
+	require.NoError(t, ruleService.DeleteAlertRule(ctx, u, rule.UID, models.ProvenanceAPI))
+
+	events := auditLog.Recorded()
+	require.Len(t, events, 3)
+	for _, e := range events {
+		assert.Equal(t, auditlog.SourceProvisioning, e.Source)
+		assert.Equal(t, auditKindAlertRule, e.ResourceKind)
+		assert.Equal(t, rule.UID, e.ResourceUID)
+		assert.Equal(t, orgID, e.OrgID)
+		assert.Equal(t, "admin", e.ActorLogin)
+	}
+	assert.Equal(t, auditlog.ActionCreate, events[0].Action)
+	assert.NotEmpty(t, events[0].Payload)
+
+	assert.Equal(t, auditlog.ActionUpdate, events[1].Action)
+	assert.Contains(t, events[1].Diff, auditlog.Change{Path: "Title", Old: "audited", New: "audited and renamed"})
+
+	assert.Equal(t, auditlog.ActionDelete, events[2].Action)
+	assert.NotEmpty(t, events[2].Payload)
+}
+
+func TestRedactedContactPoint(t *testing.T) {
+	cp := createTestContactPoint()
+	redacted := redactedContactPoint(cp, []string{"recipient", "missing"})
+
+	assert.Equal(t, "[REDACTED]", redacted.Settings.Get("recipient").MustString())
+	_, ok := redacted.Settings.CheckGet("missing")
+	assert.False(t, ok)
+	assert.NotEqual(t, "[REDACTED]", cp.Settings.Get("recipient").MustString(), "the original contact point should not change")
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/auditlog"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels_config"
@@ -39,6 +40,7 @@ type ContactPointService struct {
 	receiverService           receiverService
 	log                       log.Logger
 	resourcePermissions       ac.ReceiverPermissionsService
+	auditLog                  auditlog.Recorder
 }
 
 type receiverService interface {
@@ -55,6 +57,7 @@ func NewContactPointService(
 	log log.Logger,
 	nsStore AlertRuleNotificationSettingsStore,
 	resourcePermissions ac.ReceiverPermissionsService,
+	auditLog auditlog.Recorder,
 ) *ContactPointService {
 	return &ContactPointService{
 		configStore:               store,
@@ -65,6 +68,7 @@ func NewContactPointService(
 		log:                       log,
 		notificationSettingsStore: nsStore,
 		resourcePermissions:       resourcePermissions,
+		auditLog:                  auditLog,
 	}
 }
 
@@ -235,6 +239,7 @@ func (ecp *ContactPointService) CreateContactPoint(
 	for k := range extractedSecrets {
 		contactPoint.Settings.Set(k, apimodels.RedactedValue)
 	}
+	recordChange(ctx, ecp.auditLog, user, orgID, auditlog.ActionCreate, auditKindContactPoint, contactPoint.UID, nil, contactPoint)
 	return contactPoint, nil
 }
 
@@ -262,6 +267,8 @@ func (ecp *ContactPointService) UpdateContactPoint(ctx context.Context, orgID in
 	if err := ValidateContactPoint(ctx, contactPoint, ecp.encryptionService.GetDecryptedValue); err != nil {
 		return fmt.Errorf("%w: %s", ErrValidation, err.Error())
 	}
+	auditBefore := redactedContactPoint(rawContactPoint, secretKeys)
+	auditAfter := redactedContactPoint(contactPoint, secretKeys)
 
 	// check that provenance is not changed in an invalid way
 	storedProvenance, err := ecp.provenanceStore.GetProvenance(ctx, &contactPoint, orgID)
@@ -337,6 +344,7 @@ func (ecp *ContactPointService) UpdateContactPoint(ctx context.Context, orgID in
 	if err != nil {
 		return err
 	}
+	recordChange(ctx, ecp.auditLog, nil, orgID, auditlog.ActionUpdate, auditKindContactPoint, contactPoint.UID, auditBefore, auditAfter)
 	return nil
 }
 
@@ -352,10 +360,12 @@ func (ecp *ContactPointService) DeleteContactPoint(ctx context.Context, orgID in
 	// Name of the contact point that will be removed, might be used if a
 	// full removal is done to check if it's referenced in any route.
 	name := ""
+	var deleted *apimodels.PostableGrafanaReceiver
 	for i, receiver := range revision.Config.AlertmanagerConfig.Receivers {
 		for j, grafanaReceiver := range receiver.GrafanaManagedReceivers {
 			if grafanaReceiver.UID == uid {
 				name = grafanaReceiver.Name
+				deleted = grafanaReceiver
 				receiver.GrafanaManagedReceivers = append(receiver.GrafanaManagedReceivers[:j], receiver.GrafanaManagedReceivers[j+1:]...)
 				// if this was the last receiver we removed, we remove the whole receiver
 				if len(receiver.GrafanaManagedReceivers) == 0 {
@@ -370,7 +380,7 @@ func (ecp *ContactPointService) DeleteContactPoint(ctx context.Context, orgID in
 		return ErrContactPointReferenced.Errorf("")
 	}
 
-	return ecp.xact.InTransaction(ctx, func(ctx context.Context) error {
+	err = ecp.xact.InTransaction(ctx, func(ctx context.Context) error {
 		if fullRemoval {
 			used, err := ecp.notificationSettingsStore.ListNotificationSettings(ctx, models.ListNotificationSettingsQuery{OrgID: orgID, ReceiverName: name})
 			if err != nil {
@@ -400,6 +410,14 @@ func (ecp *ContactPointService) DeleteContactPoint(ctx context.Context, orgID in
 		}
 		return ecp.provenanceStore.DeleteProvenance(ctx, target, orgID)
 	})
+	if err != nil {
+		return err
+	}
+	// Deleting an unknown contact point is not an error, but it is not a change either.
+	if deleted != nil {
+		recordChange(ctx, ecp.auditLog, nil, orgID, auditlog.ActionDelete, auditKindContactPoint, uid, deleted, nil)
+	}
+	return nil
 }
 
 // decryptValueOrRedacted returns a function that decodes a string from Base64 and then decrypts using secrets.Service.
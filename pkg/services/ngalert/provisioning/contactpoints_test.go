@@ -509,6 +509,7 @@ func createContactPointServiceSutWithConfigStore(t *testing.T, secretService sec
 		log.NewNopLogger(),
 		nil,
 		fakes.NewFakeReceiverPermissionsService(),
+		nil,
 	)
 }
 
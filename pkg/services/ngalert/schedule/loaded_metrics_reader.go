@@ -3,6 +3,7 @@ package schedule
 import (
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/state"
@@ -22,7 +23,8 @@ type RuleStateProvider interface {
 }
 
 // AlertingResultsFromRuleState implements eval.AlertingResultsReader that gets the data from state manager.
-// It returns results fingerprints only for Alerting and Pending states that have empty StateReason.
+// It returns results fingerprints only for Alerting and Pending states that have empty StateReason,
+// and the dimensions of the results of all states that are not missing, in error or without data.
 type AlertingResultsFromRuleState struct {
 	Manager RuleStateProvider
 	Rule    *ngmodels.AlertRule
@@ -42,3 +44,35 @@ func (n AlertingResultsFromRuleState) Read() map[data.Fingerprint]struct{} {
 	}
 	return active
 }
+
+func (n AlertingResultsFromRuleState) ReadDimensions() map[data.Fingerprint]data.Labels {
+	states := n.Manager.GetStatesForRuleUID(n.Rule.OrgID, n.Rule.UID)
+
+	// The labels of a state are the labels of the result merged with the labels of the rule and the built-in labels.
+	ruleKeys := map[string]struct{}{}
+	for key := range n.Rule.Labels {
+		ruleKeys[key] = struct{}{}
+	}
+	for key := range state.GetRuleExtraLabels(log.NewNopLogger(), n.Rule, "", true) {
+		ruleKeys[key] = struct{}{}
+	}
+
+	dimensions := map[data.Fingerprint]data.Labels{}
+	for _, st := range states {
+		if st.StateReason == ngmodels.StateReasonMissingSeries || st.State == eval.NoData || st.State == eval.Error {
+			continue
+		}
+		labels := make(data.Labels, len(st.Labels))
+		for key, value := range st.Labels {
+			if _, ok := ruleKeys[key]; !ok {
+				labels[key] = value
+			}
+		}
+		// Skip the states whose result labels were renamed or overridden, their dimensions cannot be restored.
+		if labels.Fingerprint() != st.ResultFingerprint {
+			continue
+		}
+		dimensions[st.ResultFingerprint] = labels
+	}
+	return dimensions
+}
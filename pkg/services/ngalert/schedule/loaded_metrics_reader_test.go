@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/google/uuid"
+	alertingModels "github.com/grafana/alerting/models"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/stretchr/testify/require"
 
@@ -53,6 +54,52 @@ func TestLoadedResultsFromRuleState(t *testing.T) {
 	})
 }
 
+func TestLoadedDimensionsFromRuleState(t *testing.T) {
+	rule := ngmodels.RuleGen.With(ngmodels.RuleMuts.WithLabels(data.Labels{"team": "a"})).GenerateRef()
+	newState := func(st eval.State, result data.Labels) *state.State {
+		labels := data.Labels{"team": "a", alertingModels.RuleUIDLabel: rule.UID}
+		for key, value := range result {
+			labels[key] = value
+		}
+		return &state.State{State: st, Labels: labels, ResultFingerprint: result.Fingerprint()}
+	}
+	overridden := newState(eval.Normal, data.Labels{"host": "d", "team": "b"})
+	overridden.Labels["team"] = "a"
+	missing := newState(eval.Normal, data.Labels{"host": "e"})
+	missing.StateReason = ngmodels.StateReasonMissingSeries
+	p := &FakeRuleStateProvider{
+		map[ngmodels.AlertRuleKey][]*state.State{
+			rule.GetKey(): {
+				newState(eval.Alerting, data.Labels{"host": "a"}),
+				newState(eval.Normal, data.Labels{"host": "b"}),
+				newState(eval.NoData, data.Labels{}),
+				newState(eval.Error, data.Labels{"host": "c"}),
+				overridden,
+				missing,
+			},
+		},
+	}
+
+	reader := AlertingResultsFromRuleState{
+		Manager: p,
+		Rule:    rule,
+	}
+
+	t.Run("should return the result labels of the states", func(t *testing.T) {
+		loaded := reader.ReadDimensions()
+		require.Equal(t, map[data.Fingerprint]data.Labels{
+			data.Labels{"host": "a"}.Fingerprint(): {"host": "a"},
+			data.Labels{"host": "b"}.Fingerprint(): {"host": "b"},
+		}, loaded)
+	})
+
+	t.Run("empty if no states", func(t *testing.T) {
+		p.states[rule.GetKey()] = nil
+		loaded := reader.ReadDimensions()
+		require.Empty(t, loaded)
+	})
+}
+
 type FakeRuleStateProvider struct {
 	states map[ngmodels.AlertRuleKey][]*state.State
 }
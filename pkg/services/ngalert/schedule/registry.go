@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"hash/fnv"
+	"maps"
 	"math"
+	"slices"
 	"sort"
 	"sync"
 	"time"
@@ -101,6 +103,8 @@ func (e *Evaluation) Fingerprint() fingerprint {
 type alertRulesRegistry struct {
 	rules        map[models.AlertRuleKey]*models.AlertRule
 	folderTitles map[models.FolderKey]string
+	// dependencies are calculated when the rules change because inferring them requires inspecting the queries.
+	dependencies models.RuleDependencies
 	mu           sync.Mutex
 }
 
@@ -115,6 +119,13 @@ func (r *alertRulesRegistry) all() ([]*models.AlertRule, map[models.FolderKey]st
 	return result, r.folderTitles
 }
 
+// ruleDependencies returns the dependencies between the rules in the registry.
+func (r *alertRulesRegistry) ruleDependencies() models.RuleDependencies {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.dependencies
+}
+
 func (r *alertRulesRegistry) get(k models.AlertRuleKey) *models.AlertRule {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -131,6 +142,7 @@ func (r *alertRulesRegistry) set(rules []*models.AlertRule, folders map[models.F
 	}
 	d := r.getDiff(rulesMap)
 	r.rules = rulesMap
+	r.dependencies = models.BuildRuleDependencies(rules)
 	// return the map as is without copying because it is not mutated
 	r.folderTitles = folders
 	return d
@@ -141,6 +153,7 @@ func (r *alertRulesRegistry) update(rule *models.AlertRule) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.rules[rule.GetKey()] = rule
+	r.dependencies = models.BuildRuleDependencies(slices.Collect(maps.Values(r.rules)))
 }
 
 // del removes pair that has specific key from alertRulesRegistry.
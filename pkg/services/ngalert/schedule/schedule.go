@@ -48,7 +48,7 @@ type RulesStore interface {
 }
 
 type RecordingWriter interface {
-	WriteDatasource(ctx context.Context, dsUID string, name string, t time.Time, frames data.Frames, orgID int64, extraLabels map[string]string) error
+	WriteRecord(ctx context.Context, record ngmodels.Record, t time.Time, frames data.Frames, orgID int64, extraLabels map[string]string) error
 }
 
 // AlertRuleStopReasonProvider is an interface for determining the reason why an alert rule was stopped.
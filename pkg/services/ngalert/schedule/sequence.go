@@ -4,6 +4,7 @@ import (
 	"cmp"
 	"slices"
 	"strings"
+	"sync/atomic"
 
 	models "github.com/grafana/grafana/pkg/services/ngalert/models"
 )
@@ -19,20 +20,37 @@ type groupKey struct {
 	groupName   string
 }
 
-// buildSequences organizes rules into evaluation sequences where rules in the same group
-// are chained together. The first rule in each group will trigger the evaluation of subsequent
-// rules in that group through the afterEval callback.
+// buildSequences organizes rules into evaluation sequences where rules are evaluated after the rules
+// they depend on. A rule depends on the previous rule of the same group if the group is evaluated
+// sequentially, and on the rules it declares or reads the metrics of (see models.BuildRuleDependencies).
+// Rules are triggered through the afterEval callback of the rules they depend on, once all of them
+// have been evaluated.
 //
-// For example, if we have rules A, B, C in group G1 and rules D, E in group G2:
+// For example, if we have rules A, B, C in group G1 and rules D, E in group G2, and E depends on B:
 // - A will have afterEval set to evaluate B
-// - B will have afterEval set to evaluate C
+// - B will have afterEval set to evaluate C and E
 // - D will have afterEval set to evaluate E
+// - E will be evaluated after both B and D have been evaluated
 //
-// The function returns a slice of sequences, where each sequence represents a chain of rules
-// that should be evaluated in order.
+// The function returns a slice of sequences, where each sequence represents a rule that does not
+// depend on other rules that are ready to run. Dependencies on rules that are not ready to run on this
+// tick are ignored, and rules that are part of a dependency cycle are evaluated independently.
 //
-// NOTE: This currently only chains rules in imported groups.
+// NOTE: This currently only chains rules of the same group in imported groups.
 func (sch *schedule) buildSequences(items []readyToRunItem, runJobFn func(next readyToRunItem, prev ...readyToRunItem) func()) []sequence {
+	items = slices.Clone(items)
+	index := make(map[models.AlertRuleKey]int, len(items))
+	for i, item := range items {
+		index[item.rule.GetKey()] = i
+	}
+	// dependents[i] are the indices of the rules that are evaluated after the rule i.
+	dependents := make([][]int, len(items))
+	addEdge := func(from, to int) {
+		if !slices.Contains(dependents[from], to) {
+			dependents[from] = append(dependents[from], to)
+		}
+	}
+
 	// Step 1: Group rules by their folder and group name
 	groups := map[groupKey][]readyToRunItem{}
 	var keys []groupKey
@@ -58,18 +76,68 @@ func (sch *schedule) buildSequences(items []readyToRunItem, runJobFn func(next r
 		)
 	})
 
-	// Step 3: Build evaluation sequences for each group
-	result := make([]sequence, 0, len(items))
+	// Step 3: Chain the rules of the groups that are evaluated sequentially
 	for _, key := range keys {
 		groupItems := groups[key]
+		if !sch.shouldEvaluateSequentially(groupItems) {
+			continue
+		}
+		slices.SortFunc(groupItems, func(a, b readyToRunItem) int {
+			return models.RulesGroupComparer(a.rule, b.rule)
+		})
+		uids := make([]string, 0, len(groupItems))
+		for i, item := range groupItems {
+			if i > 0 {
+				addEdge(index[groupItems[i-1].rule.GetKey()], index[item.rule.GetKey()])
+			}
+			uids = append(uids, item.rule.UID)
+		}
+		sch.log.Debug("Sequence created", "folder", key.folderTitle, "group", key.groupName, "sequence", strings.Join(uids, "->"))
+	}
+
+	// Step 4: Add the dependencies between the rules that are ready to run
+	deps := sch.schedulableAlertRules.ruleDependencies()
+	for i, item := range items {
+		for _, dep := range deps[item.rule.GetKey()] {
+			if j, ok := index[dep]; ok {
+				addEdge(j, i)
+			}
+		}
+	}
 
-		if sch.shouldEvaluateSequentially(groupItems) {
-			result = append(result, sch.buildSequence(key, groupItems, runJobFn))
+	// Step 5: Order the rules topologically and set the afterEval callbacks
+	order := sch.evaluationOrder(items, dependents)
+	pending := make([]atomic.Int32, len(items))
+	for _, next := range dependents {
+		for _, to := range next {
+			pending[to].Add(1)
+		}
+	}
+	// iterate over the rules backwards so that the callbacks of the dependents are set before they are copied
+	for k := len(order) - 1; k >= 0; k-- {
+		i := order[k]
+		if len(dependents[i]) == 0 {
 			continue
 		}
+		next := dependents[i]
+		jobs := make([]func(), 0, len(next))
+		for _, to := range next {
+			jobs = append(jobs, runJobFn(items[to], items[i]))
+		}
+		items[i].afterEval = func() {
+			for j, to := range next {
+				// the rule is evaluated by the last of the rules it depends on
+				if pending[to].Add(-1) == 0 {
+					jobs[j]()
+				}
+			}
+		}
+	}
 
-		for _, item := range groupItems {
-			result = append(result, sequence(item))
+	result := make([]sequence, 0, len(items))
+	for i := range items {
+		if pending[i].Load() == 0 {
+			result = append(result, sequence(items[i]))
 		}
 	}
 
@@ -81,27 +149,48 @@ func (sch *schedule) buildSequences(items []readyToRunItem, runJobFn func(next r
 	return result
 }
 
-func (sch *schedule) buildSequence(groupKey groupKey, groupItems []readyToRunItem, runJobFn func(next readyToRunItem, prev ...readyToRunItem) func()) sequence {
-	if len(groupItems) < 2 {
-		return sequence(groupItems[0])
+// evaluationOrder returns the indices of the items in topological order of the dependents graph. Rules that are
+// part of a dependency cycle, or depend on one, are appended at the end and their incoming edges are removed
+// from the graph, so that they are evaluated independently.
+func (sch *schedule) evaluationOrder(items []readyToRunItem, dependents [][]int) []int {
+	indegree := make([]int, len(items))
+	for _, next := range dependents {
+		for _, to := range next {
+			indegree[to]++
+		}
 	}
-
-	slices.SortFunc(groupItems, func(a, b readyToRunItem) int {
-		return models.RulesGroupComparer(a.rule, b.rule)
-	})
-
-	// iterate over the group items backwards to set the afterEval callback
-	for i := len(groupItems) - 2; i >= 0; i-- {
-		groupItems[i].afterEval = runJobFn(groupItems[i+1], groupItems[i])
+	order := make([]int, 0, len(items))
+	for i, d := range indegree {
+		if d == 0 {
+			order = append(order, i)
+		}
 	}
-
-	uids := make([]string, 0, len(groupItems))
-	for _, item := range groupItems {
-		uids = append(uids, item.rule.UID)
+	for k := 0; k < len(order); k++ {
+		for _, to := range dependents[order[k]] {
+			indegree[to]--
+			if indegree[to] == 0 {
+				order = append(order, to)
+			}
+		}
+	}
+	if len(order) == len(items) {
+		return order
 	}
-	sch.log.Debug("Sequence created", "folder", groupKey.folderTitle, "group", groupKey.groupName, "sequence", strings.Join(uids, "->"))
 
-	return sequence(groupItems[0])
+	var uids []string
+	for i, d := range indegree {
+		if d > 0 {
+			order = append(order, i)
+			uids = append(uids, items[i].rule.UID)
+		}
+	}
+	for i := range dependents {
+		dependents[i] = slices.DeleteFunc(dependents[i], func(to int) bool {
+			return indegree[to] > 0
+		})
+	}
+	sch.log.Warn("Rules have cyclic dependencies and will be evaluated independently", "rules", strings.Join(uids, ","))
+	return order
 }
 
 func (sch *schedule) shouldEvaluateSequentially(groupItems []readyToRunItem) bool {
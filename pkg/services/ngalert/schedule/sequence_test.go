@@ -12,9 +12,14 @@ type fakeSequenceRule struct {
 	// these fields help with debugging tests
 	UID   string
 	Group string
+
+	evaluated *[]string
 }
 
 func (r *fakeSequenceRule) Eval(e *Evaluation) (bool, *Evaluation) {
+	if r.evaluated != nil {
+		*r.evaluated = append(*r.evaluated, r.UID)
+	}
 	if e.afterEval != nil {
 		e.afterEval()
 	}
@@ -150,4 +155,55 @@ func TestSequence(t *testing.T) {
 		require.Equal(t, []string{"4", "5"}, nextByGroup["rg2"])
 		require.Equal(t, []string{"3", "4"}, prevByGroup["rg2"])
 	})
+	t.Run("should evaluate rules after their dependencies", func(t *testing.T) {
+		var evaluated []string
+		callback := func(next readyToRunItem, prev ...readyToRunItem) func() {
+			return func() {
+				next.ruleRoutine.Eval(&next.Evaluation)
+			}
+		}
+		item := func(uid, group string, dependsOn ...string) readyToRunItem {
+			return readyToRunItem{
+				ruleRoutine: &fakeSequenceRule{UID: uid, Group: group, evaluated: &evaluated},
+				Evaluation: Evaluation{
+					rule: gen.With(
+						models.RuleGen.WithUID(uid),
+						models.RuleGen.WithGroupName(group),
+						models.RuleGen.WithOrgID(1),
+						models.RuleGen.WithMetadata(models.AlertRuleMetadata{DependsOn: dependsOn}),
+					).GenerateRef(),
+					folderTitle: "folder1",
+				},
+			}
+		}
+		// 4 depends on 1 and 3 from other groups, 3 depends on 2, 5 and 6 depend on each other
+		items := []readyToRunItem{
+			item("1", "rg1"),
+			item("2", "rg2"),
+			item("3", "rg3", "2"),
+			item("4", "rg4", "1", "3"),
+			item("5", "rg5", "6"),
+			item("6", "rg5", "5"),
+		}
+		rules := make([]*models.AlertRule, 0, len(items))
+		for _, item := range items {
+			rules = append(rules, item.rule)
+		}
+		sch.schedulableAlertRules.set(rules, nil)
+		t.Cleanup(func() {
+			sch.schedulableAlertRules.set(nil, nil)
+		})
+
+		sequences := sch.buildSequences(items, callback)
+		uids := make([]string, 0, len(sequences))
+		for _, sequence := range sequences {
+			uids = append(uids, sequence.rule.UID)
+		}
+		require.Equal(t, []string{"1", "2", "5", "6"}, uids)
+
+		for _, sequence := range sequences {
+			sequence.ruleRoutine.Eval(&sequence.Evaluation)
+		}
+		require.Equal(t, []string{"1", "2", "3", "4", "5", "6"}, evaluated)
+	})
 }
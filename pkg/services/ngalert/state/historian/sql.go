@@ -0,0 +1,323 @@
+package historian
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	history_model "github.com/grafana/grafana/pkg/services/ngalert/state/historian/model"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// SQLBackend is a state.Historian that records state history to dedicated tables of the Grafana database.
+// Unlike the annotations backend, it keeps the same data as Loki and supports the same queries.
+// Writes are batched: they are flushed when enough transitions are pending, or after the flush interval.
+type SQLBackend struct {
+	store          *sqlStore
+	ruleStore      RuleStore
+	ac             AccessControl
+	externalLabels map[string]string
+	batchSize      int
+	flushInterval  time.Duration
+	clock          clock.Clock
+	metrics        *metrics.Historian
+	log            log.Logger
+
+	mtx         sync.Mutex
+	pending     []pendingWrite
+	pendingRows int
+	timer       *clock.Timer
+}
+
+type pendingWrite struct {
+	orgID  int64
+	rows   []*stateHistoryEntity
+	labels []*stateHistoryLabelEntity
+	errCh  chan error
+}
+
+func NewSQLBackend(logger log.Logger, db db.DB, cfg setting.UnifiedAlertingStateHistorySettings, metrics *metrics.Historian, ruleStore RuleStore, ac AccessControl) *SQLBackend {
+	batchSize := cfg.SQLBatchSize
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	return &SQLBackend{
+		store:          &sqlStore{db: db, log: logger},
+		ruleStore:      ruleStore,
+		ac:             ac,
+		externalLabels: cfg.ExternalLabels,
+		batchSize:      batchSize,
+		flushInterval:  cfg.SQLFlushInterval,
+		clock:          clock.New(),
+		metrics:        metrics,
+		log:            logger,
+	}
+}
+
+// Record queues a number of state transitions for a given rule. The returned channel is closed once they are written.
+func (h *SQLBackend) Record(ctx context.Context, rule history_model.RuleMeta, states []state.StateTransition) <-chan error {
+	logger := h.log.FromContext(ctx)
+	rows, labels := statesToEntities(rule, states, logger)
+
+	errCh := make(chan error, 1)
+	if len(rows) == 0 {
+		close(errCh)
+		return errCh
+	}
+
+	h.mtx.Lock()
+	h.pending = append(h.pending, pendingWrite{orgID: rule.OrgID, rows: rows, labels: labels, errCh: errCh})
+	h.pendingRows += len(rows)
+	if h.pendingRows >= h.batchSize || h.flushInterval <= 0 {
+		batch := h.takePending()
+		h.mtx.Unlock()
+		go h.flush(batch)
+		return errCh
+	}
+	if h.timer == nil {
+		h.timer = h.clock.AfterFunc(h.flushInterval, h.flushPending)
+	}
+	h.mtx.Unlock()
+	return errCh
+}
+
+// takePending must be called with the mutex held.
+func (h *SQLBackend) takePending() []pendingWrite {
+	if h.timer != nil {
+		h.timer.Stop()
+		h.timer = nil
+	}
+	batch := h.pending
+	h.pending = nil
+	h.pendingRows = 0
+	return batch
+}
+
+func (h *SQLBackend) flushPending() {
+	h.mtx.Lock()
+	batch := h.takePending()
+	h.mtx.Unlock()
+	h.flush(batch)
+}
+
+func (h *SQLBackend) flush(batch []pendingWrite) {
+	if len(batch) == 0 {
+		return
+	}
+
+	// This is a new background job, so let's create a brand new context for it, see RemoteLokiBackend.Record.
+	ctx, cancel := context.WithTimeout(context.Background(), StateHistoryWriteTimeout)
+	defer cancel()
+
+	var rows []*stateHistoryEntity
+	var labels []*stateHistoryLabelEntity
+	for _, w := range batch {
+		rows = append(rows, w.rows...)
+		labels = append(labels, w.labels...)
+		org := fmt.Sprint(w.orgID)
+		h.metrics.WritesTotal.WithLabelValues(org, "sql").Inc()
+		h.metrics.TransitionsTotal.WithLabelValues(org).Add(float64(len(w.rows)))
+	}
+
+	h.log.Debug("Saving state history batch", "samples", len(rows))
+	err := h.store.save(ctx, rows, labels)
+	if err != nil {
+		h.log.Error("Failed to save alert state history batch", "samples", len(rows), "error", err)
+		err = fmt.Errorf("failed to save alert state history batch: %w", err)
+	} else {
+		h.log.Debug("Done saving alert state history batch", "samples", len(rows))
+	}
+
+	for _, w := range batch {
+		if err != nil {
+			org := fmt.Sprint(w.orgID)
+			h.metrics.WritesFailed.WithLabelValues(org, "sql").Inc()
+			h.metrics.TransitionsFailed.WithLabelValues(org).Add(float64(len(w.rows)))
+			w.errCh <- err
+		}
+		close(w.errCh)
+	}
+}
+
+// Query retrieves state history entries from the database and formats them into the same dataframe as the Loki backend.
+func (h *SQLBackend) Query(ctx context.Context, query models.HistoryQuery) (*data.Frame, error) {
+	uids, err := getFolderUIDsForFilter(ctx, h.ac, h.ruleStore, query)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	to, from := query.To, query.From
+	if to.IsZero() || to.Unix() <= 0 {
+		to = now
+	}
+	if from.IsZero() || from.Unix() <= 0 {
+		from = to.Add(-defaultQueryRange)
+	}
+	limit := query.Limit
+	if limit < 1 {
+		limit = defaultPageSize
+	}
+	limit = min(limit, maximumPageSize)
+
+	rows, err := h.store.find(ctx, query, uids, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	return h.entitiesToFrame(rows, query.Labels)
+}
+
+// entitiesToFrame converts history entries, sorted from the newest, to a dataframe sorted from the oldest.
+// Entries are matched against the label filters again, as long labels are truncated in the label table.
+func (h *SQLBackend) entitiesToFrame(rows []*stateHistoryEntity, labelFilters map[string]string) (*data.Frame, error) {
+	frame := data.NewFrame("states")
+	lbls := data.Labels(map[string]string{})
+
+	times := make([]time.Time, 0, len(rows))
+	lines := make([]json.RawMessage, 0, len(rows))
+	labels := make([]json.RawMessage, 0, len(rows))
+
+	for i := len(rows) - 1; i >= 0; i-- {
+		row := rows[i]
+		entry, err := entityToEntry(row)
+		if err != nil {
+			return nil, fmt.Errorf("a history entry was in an invalid format: %w", err)
+		}
+		if !matchLabels(entry.InstanceLabels, labelFilters) {
+			continue
+		}
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return nil, err
+		}
+		streamLabels, err := json.Marshal(h.streamLabels(row))
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize stream labels: %w", err)
+		}
+
+		times = append(times, time.UnixMilli(row.EvaluatedAt))
+		lines = append(lines, line)
+		labels = append(labels, streamLabels)
+	}
+
+	frame.Fields = append(frame.Fields, data.NewField(dfTime, lbls, times))
+	frame.Fields = append(frame.Fields, data.NewField(dfLine, lbls, lines))
+	frame.Fields = append(frame.Fields, data.NewField(dfLabels, lbls, labels))
+	return frame, nil
+}
+
+// streamLabels returns the labels that the Loki backend attaches to the stream of an entry.
+func (h *SQLBackend) streamLabels(row *stateHistoryEntity) map[string]string {
+	labels := mergeLabels(make(map[string]string), h.externalLabels)
+	labels[StateHistoryLabelKey] = StateHistoryLabelValue
+	labels[OrgIDLabel] = fmt.Sprint(row.OrgID)
+	labels[GroupLabel] = row.RuleGroup
+	labels[FolderUIDLabel] = row.FolderUID
+	return labels
+}
+
+func statesToEntities(rule history_model.RuleMeta, states []state.StateTransition, logger log.Logger) ([]*stateHistoryEntity, []*stateHistoryLabelEntity) {
+	rows := make([]*stateHistoryEntity, 0, len(states))
+	var labels []*stateHistoryLabelEntity
+	seen := map[string]bool{}
+
+	for _, state := range states {
+		if !shouldRecord(state) {
+			continue
+		}
+
+		sanitizedLabels := removePrivateLabels(state.Labels)
+		fingerprint := labelFingerprint(sanitizedLabels)
+		instanceLabels, err := json.Marshal(sanitizedLabels)
+		if err != nil {
+			logger.Error("Failed to construct history record for state, skipping", "error", err)
+			continue
+		}
+		values, err := json.Marshal(valuesAsDataBlob(state.State))
+		if err != nil {
+			logger.Error("Failed to construct history record for state, skipping", "error", err)
+			continue
+		}
+
+		row := &stateHistoryEntity{
+			OrgID:        rule.OrgID,
+			RuleUID:      rule.UID,
+			RuleID:       rule.ID,
+			RuleTitle:    rule.Title,
+			RuleGroup:    rule.Group,
+			FolderUID:    rule.NamespaceUID,
+			DashboardUID: rule.DashboardUID,
+			PanelID:      rule.PanelID,
+			Fingerprint:  fingerprint,
+			Previous:     state.PreviousFormatted(),
+			Current:      state.Formatted(),
+			Values:       string(values),
+			Condition:    rule.Condition,
+			Labels:       string(instanceLabels),
+			EvaluatedAt:  state.LastEvaluationTime.UnixMilli(),
+		}
+		if state.State.State == eval.Error {
+			row.Error = state.Error.Error()
+		}
+		rows = append(rows, row)
+
+		if seen[fingerprint] {
+			continue
+		}
+		seen[fingerprint] = true
+		for k, v := range sanitizedLabels {
+			labels = append(labels, &stateHistoryLabelEntity{
+				OrgID:       rule.OrgID,
+				Fingerprint: fingerprint,
+				Key:         truncateLabel(k),
+				Value:       truncateLabel(v),
+			})
+		}
+	}
+	return rows, labels
+}
+
+func entityToEntry(row *stateHistoryEntity) (LokiEntry, error) {
+	entry := LokiEntry{
+		SchemaVersion: 1,
+		Previous:      row.Previous,
+		Current:       row.Current,
+		Error:         row.Error,
+		Condition:     row.Condition,
+		DashboardUID:  row.DashboardUID,
+		PanelID:       row.PanelID,
+		Fingerprint:   row.Fingerprint,
+		RuleTitle:     row.RuleTitle,
+		RuleID:        row.RuleID,
+		RuleUID:       row.RuleUID,
+	}
+	if err := json.Unmarshal([]byte(row.Labels), &entry.InstanceLabels); err != nil {
+		return entry, err
+	}
+	if row.Values != "" {
+		if err := json.Unmarshal([]byte(row.Values), &entry.Values); err != nil {
+			return entry, err
+		}
+	}
+	return entry, nil
+}
+
+func matchLabels(labels, filters map[string]string) bool {
+	for k, v := range filters {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
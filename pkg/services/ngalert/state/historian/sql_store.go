@@ -0,0 +1,278 @@
+package historian
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// maxIndexedLabelLength is the length of the label keys and values in the label table. Longer labels are truncated,
+// and matched exactly against the labels of the history entries instead.
+const maxIndexedLabelLength = 190
+
+// maxFoldersPerQuery bounds the number of folder UIDs in a single query, like BuildLogQuery does for Loki.
+const maxFoldersPerQuery = 500
+
+type stateHistoryEntity struct {
+	ID           int64  `xorm:"pk autoincr 'id'"`
+	OrgID        int64  `xorm:"org_id"`
+	RuleUID      string `xorm:"rule_uid"`
+	RuleID       int64  `xorm:"rule_id"`
+	RuleTitle    string `xorm:"rule_title"`
+	RuleGroup    string `xorm:"rule_group"`
+	FolderUID    string `xorm:"folder_uid"`
+	DashboardUID string `xorm:"dashboard_uid"`
+	PanelID      int64  `xorm:"panel_id"`
+	Fingerprint  string `xorm:"fingerprint"`
+	Previous     string `xorm:"previous_state"`
+	Current      string `xorm:"current_state"`
+	Error        string `xorm:"error"`
+	Values       string `xorm:"state_values"`
+	Condition    string `xorm:"rule_condition"`
+	Labels       string `xorm:"labels"`
+	EvaluatedAt  int64  `xorm:"evaluated_at"`
+}
+
+func (stateHistoryEntity) TableName() string {
+	return "alert_state_history"
+}
+
+type stateHistoryLabelEntity struct {
+	ID          int64  `xorm:"pk autoincr 'id'"`
+	OrgID       int64  `xorm:"org_id"`
+	Fingerprint string `xorm:"fingerprint"`
+	Key         string `xorm:"label_key"`
+	Value       string `xorm:"label_value"`
+}
+
+func (stateHistoryLabelEntity) TableName() string {
+	return "alert_state_history_label"
+}
+
+// sqlStore keeps state history in the alert_state_history and alert_state_history_label tables.
+type sqlStore struct {
+	db  db.DB
+	log log.Logger
+}
+
+func (s *sqlStore) save(ctx context.Context, rows []*stateHistoryEntity, labels []*stateHistoryLabelEntity) error {
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.BulkInsert(stateHistoryEntity{}, rows, sqlstore.NativeSettingsForDialect(s.db.GetDialect()))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return s.saveLabels(ctx, labels)
+}
+
+// saveLabels stores the labels of the fingerprints which are not known yet.
+func (s *sqlStore) saveLabels(ctx context.Context, labels []*stateHistoryLabelEntity) error {
+	if len(labels) == 0 {
+		return nil
+	}
+
+	missing, err := s.missingLabels(ctx, labels)
+	if err != nil || len(missing) == 0 {
+		return err
+	}
+
+	err = s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.BulkInsert(stateHistoryLabelEntity{}, missing, sqlstore.NativeSettingsForDialect(s.db.GetDialect()))
+		return err
+	})
+	if err == nil || !s.db.GetDialect().IsUniqueConstraintViolation(err) {
+		return err
+	}
+
+	// Another instance stored some of the labels concurrently, insert them one by one instead.
+	s.log.Debug("Labels were stored concurrently, retrying one by one", "labels", len(missing))
+	for _, label := range missing {
+		err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+			_, err := sess.Insert(label)
+			return err
+		})
+		if err != nil && !s.db.GetDialect().IsUniqueConstraintViolation(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sqlStore) missingLabels(ctx context.Context, labels []*stateHistoryLabelEntity) ([]*stateHistoryLabelEntity, error) {
+	type fingerprintKey struct {
+		orgID       int64
+		fingerprint string
+	}
+	byOrg := map[int64][]string{}
+	seen := map[fingerprintKey]bool{}
+	for _, l := range labels {
+		k := fingerprintKey{l.OrgID, l.Fingerprint}
+		if !seen[k] {
+			seen[k] = true
+			byOrg[l.OrgID] = append(byOrg[l.OrgID], l.Fingerprint)
+		}
+	}
+
+	known := map[fingerprintKey]bool{}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		for orgID, fingerprints := range byOrg {
+			var existing []string
+			err := sess.Table(stateHistoryLabelEntity{}).Distinct("fingerprint").
+				Where("org_id = ?", orgID).In("fingerprint", fingerprints).Find(&existing)
+			if err != nil {
+				return err
+			}
+			for _, f := range existing {
+				known[fingerprintKey{orgID, f}] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []*stateHistoryLabelEntity
+	for _, l := range labels {
+		if !known[fingerprintKey{l.OrgID, l.Fingerprint}] {
+			missing = append(missing, l)
+		}
+	}
+	return missing, nil
+}
+
+// find returns the newest history entries which match the query, up to limit entries, sorted from the newest.
+// The entries are restricted to the given folders, unless there are none.
+func (s *sqlStore) find(ctx context.Context, query models.HistoryQuery, folderUIDs []string, from, to time.Time, limit int) ([]*stateHistoryEntity, error) {
+	if len(folderUIDs) <= maxFoldersPerQuery {
+		return s.findInFolders(ctx, query, folderUIDs, from, to, limit)
+	}
+
+	var result []*stateHistoryEntity
+	for start := 0; start < len(folderUIDs); start += maxFoldersPerQuery {
+		end := min(start+maxFoldersPerQuery, len(folderUIDs))
+		rows, err := s.findInFolders(ctx, query, folderUIDs[start:end], from, to, limit)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, rows...)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].EvaluatedAt > result[j].EvaluatedAt
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (s *sqlStore) findInFolders(ctx context.Context, query models.HistoryQuery, folderUIDs []string, from, to time.Time, limit int) ([]*stateHistoryEntity, error) {
+	var sql strings.Builder
+	sql.WriteString("SELECT * FROM alert_state_history WHERE org_id = ? AND evaluated_at >= ? AND evaluated_at <= ?")
+	args := []any{query.OrgID, from.UnixMilli(), to.UnixMilli()}
+
+	if query.RuleUID != "" {
+		sql.WriteString(" AND rule_uid = ?")
+		args = append(args, query.RuleUID)
+	}
+	if query.DashboardUID != "" {
+		sql.WriteString(" AND dashboard_uid = ?")
+		args = append(args, query.DashboardUID)
+	}
+	if query.PanelID != 0 {
+		sql.WriteString(" AND panel_id = ?")
+		args = append(args, query.PanelID)
+	}
+	if len(folderUIDs) > 0 {
+		sql.WriteString(" AND folder_uid IN (?" + strings.Repeat(",?", len(folderUIDs)-1) + ")")
+		for _, uid := range folderUIDs {
+			args = append(args, uid)
+		}
+	}
+
+	// Ensure that all queries we build are deterministic.
+	keys := make([]string, 0, len(query.Labels))
+	for k := range query.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		sql.WriteString(" AND fingerprint IN (SELECT fingerprint FROM alert_state_history_label WHERE org_id = ? AND label_key = ? AND label_value = ?)")
+		args = append(args, query.OrgID, truncateLabel(k), truncateLabel(query.Labels[k]))
+	}
+
+	sql.WriteString(" ORDER BY evaluated_at DESC, id DESC")
+	sql.WriteString(s.db.GetDialect().Limit(int64(limit)))
+
+	var rows []*stateHistoryEntity
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.SQL(sql.String(), args...).Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// deleteOlderThan deletes the history entries evaluated before the given time, and the labels which are not used anymore.
+func (s *sqlStore) deleteOlderThan(ctx context.Context, t time.Time) (int64, error) {
+	var affected int64
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		res, err := sess.Exec("DELETE FROM alert_state_history WHERE evaluated_at < ?", t.UnixMilli())
+		if err != nil {
+			return err
+		}
+		affected, err = res.RowsAffected()
+		if err != nil || affected == 0 {
+			return err
+		}
+
+		_, err = sess.Exec(`DELETE FROM alert_state_history_label WHERE NOT EXISTS (
+			SELECT 1 FROM alert_state_history h
+			WHERE h.org_id = alert_state_history_label.org_id AND h.fingerprint = alert_state_history_label.fingerprint)`)
+		return err
+	})
+	return affected, err
+}
+
+func truncateLabel(s string) string {
+	if utf8.RuneCountInString(s) <= maxIndexedLabelLength {
+		return s
+	}
+	return string([]rune(s)[:maxIndexedLabelLength])
+}
+
+// DeleteExpiredSQLService deletes the state history of the "sql" backend once it is older than the configured retention.
+type DeleteExpiredSQLService struct {
+	store *sqlStore
+	cfg   *setting.Cfg
+}
+
+func ProvideDeleteExpiredSQLService(db db.DB, cfg *setting.Cfg) *DeleteExpiredSQLService {
+	return &DeleteExpiredSQLService{
+		store: &sqlStore{db: db, log: log.New("ngalert.state.historian", "backend", "sql")},
+		cfg:   cfg,
+	}
+}
+
+func (s *DeleteExpiredSQLService) DeleteExpired(ctx context.Context) (int64, error) {
+	maxAge := s.cfg.UnifiedAlerting.StateHistory.SQLMaxAge
+	if maxAge <= 0 {
+		return 0, nil
+	}
+	affected, err := s.store.deleteOlderThan(ctx, time.Now().Add(-maxAge))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired state history: %w", err)
+	}
+	return affected, nil
+}
@@ -0,0 +1,239 @@
+package historian
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	acfakes "github.com/grafana/grafana/pkg/services/ngalert/accesscontrol/fakes"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	history_model "github.com/grafana/grafana/pkg/services/ngalert/state/historian/model"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests/fakes"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tests/testsuite"
+)
+
+func TestMain(m *testing.M) {
+	testsuite.Run(m)
+}
+
+func createTestSQLBackend(t *testing.T, sqlStore db.DB, cfg setting.UnifiedAlertingStateHistorySettings) *SQLBackend {
+	t.Helper()
+	ac := &acfakes.FakeRuleService{
+		CanReadAllRulesFunc: func(context.Context, identity.Requester) (bool, error) {
+			return true, nil
+		},
+	}
+	met := metrics.NewHistorianMetrics(prometheus.NewRegistry(), metrics.Subsystem)
+	return NewSQLBackend(log.NewNopLogger(), sqlStore, cfg, met, fakes.NewRuleStore(t), ac)
+}
+
+func transitionAt(st eval.State, labels data.Labels, at time.Time) state.StateTransition {
+	return state.StateTransition{
+		State: &state.State{
+			State:              st,
+			Labels:             labels,
+			LastEvaluationTime: at,
+		},
+		PreviousState: eval.Normal,
+	}
+}
+
+func recordAndWait(t *testing.T, h *SQLBackend, rule history_model.RuleMeta, states ...state.StateTransition) {
+	t.Helper()
+	for err := range h.Record(context.Background(), rule, states) {
+		require.NoError(t, err)
+	}
+}
+
+func frameEntries(t *testing.T, frame *data.Frame) []LokiEntry {
+	t.Helper()
+	require.Len(t, frame.Fields, 3)
+	entries := make([]LokiEntry, 0, frame.Rows())
+	for i := 0; i < frame.Rows(); i++ {
+		var entry LokiEntry
+		require.NoError(t, json.Unmarshal(frame.Fields[1].At(i).(json.RawMessage), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestIntegrationSQLBackend(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sqlStore := db.InitTestDB(t)
+	h := createTestSQLBackend(t, sqlStore, setting.UnifiedAlertingStateHistorySettings{SQLBatchSize: 1})
+
+	now := time.Now().Truncate(time.Millisecond)
+	rule := createTestRule()
+	other := createTestRule()
+	other.UID = "other-rule"
+	other.DashboardUID = ""
+	other.PanelID = 0
+
+	alerting := transitionAt(eval.Alerting, data.Labels{"team": "a", "__private__": "x"}, now.Add(-2*time.Minute))
+	alerting.Values = map[string]float64{"A": 2.5}
+	recordAndWait(t, h, rule,
+		alerting,
+		transitionAt(eval.Pending, data.Labels{"team": "b"}, now.Add(-time.Minute)),
+		transitionAt(eval.Normal, data.Labels{"team": "skipped"}, now),
+	)
+	recordAndWait(t, h, other, transitionAt(eval.Alerting, data.Labels{"team": "a"}, now))
+
+	query := func(q models.HistoryQuery) []LokiEntry {
+		t.Helper()
+		q.OrgID = 1
+		frame, err := h.Query(context.Background(), q)
+		require.NoError(t, err)
+		return frameEntries(t, frame)
+	}
+
+	t.Run("keeps the same data as Loki", func(t *testing.T) {
+		entries := query(models.HistoryQuery{RuleUID: rule.UID})
+		require.Len(t, entries, 2)
+		assert.Equal(t, "Alerting", entries[0].Current)
+		assert.Equal(t, "Normal", entries[0].Previous)
+		assert.Equal(t, map[string]string{"team": "a"}, entries[0].InstanceLabels)
+		assert.Equal(t, rule.Title, entries[0].RuleTitle)
+		assert.Equal(t, rule.DashboardUID, entries[0].DashboardUID)
+		assert.Equal(t, rule.PanelID, entries[0].PanelID)
+		assert.Equal(t, labelFingerprint(data.Labels{"team": "a"}), entries[0].Fingerprint)
+		require.NotNil(t, entries[0].Values)
+		assert.Equal(t, 2.5, entries[0].Values.Get("A").MustFloat64())
+		assert.Equal(t, "Pending", entries[1].Current, "entries are sorted from the oldest")
+
+		frame, err := h.Query(context.Background(), models.HistoryQuery{OrgID: 1, RuleUID: rule.UID})
+		require.NoError(t, err)
+		assert.Equal(t, now.Add(-2*time.Minute).UnixMilli(), frame.Fields[0].At(0).(time.Time).UnixMilli())
+		var streamLabels map[string]string
+		require.NoError(t, json.Unmarshal(frame.Fields[2].At(0).(json.RawMessage), &streamLabels))
+		assert.Equal(t, map[string]string{
+			StateHistoryLabelKey: StateHistoryLabelValue,
+			OrgIDLabel:           "1",
+			GroupLabel:           rule.Group,
+			FolderUIDLabel:       rule.NamespaceUID,
+		}, streamLabels)
+	})
+
+	t.Run("filters by labels", func(t *testing.T) {
+		entries := query(models.HistoryQuery{Labels: map[string]string{"team": "a"}})
+		require.Len(t, entries, 2)
+		assert.Equal(t, rule.UID, entries[0].RuleUID)
+		assert.Equal(t, other.UID, entries[1].RuleUID)
+
+		assert.Empty(t, query(models.HistoryQuery{Labels: map[string]string{"team": "a", "env": "prod"}}))
+	})
+
+	t.Run("filters by dashboard and panel", func(t *testing.T) {
+		assert.Len(t, query(models.HistoryQuery{DashboardUID: rule.DashboardUID}), 2)
+		assert.Len(t, query(models.HistoryQuery{DashboardUID: rule.DashboardUID, PanelID: rule.PanelID}), 2)
+		assert.Empty(t, query(models.HistoryQuery{DashboardUID: rule.DashboardUID, PanelID: 1}))
+	})
+
+	t.Run("filters by time range and limit", func(t *testing.T) {
+		entries := query(models.HistoryQuery{From: now.Add(-90 * time.Second), To: now.Add(time.Second)})
+		require.Len(t, entries, 2)
+
+		entries = query(models.HistoryQuery{Limit: 1})
+		require.Len(t, entries, 1)
+		assert.Equal(t, other.UID, entries[0].RuleUID, "the limit keeps the newest entries")
+	})
+
+	t.Run("filters by the folders the user can read", func(t *testing.T) {
+		h := createTestSQLBackend(t, sqlStore, setting.UnifiedAlertingStateHistorySettings{SQLBatchSize: 1})
+		h.ac = &acfakes.FakeRuleService{}
+		_, err := h.Query(context.Background(), models.HistoryQuery{OrgID: 1})
+		require.Error(t, err, "users without access to any folder cannot query history")
+	})
+}
+
+func TestIntegrationSQLBackendBatching(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	h := createTestSQLBackend(t, db.InitTestDB(t), setting.UnifiedAlertingStateHistorySettings{SQLBatchSize: 3, SQLFlushInterval: time.Minute})
+	mockClock := clock.NewMock()
+	h.clock = mockClock
+	rule := createTestRule()
+	now := time.Now()
+
+	first := h.Record(context.Background(), rule, []state.StateTransition{transitionAt(eval.Alerting, data.Labels{"a": "1"}, now)})
+	second := h.Record(context.Background(), rule, []state.StateTransition{transitionAt(eval.Alerting, data.Labels{"a": "2"}, now)})
+	select {
+	case <-first:
+		t.Fatal("transitions must not be written before the batch is full or the flush interval elapses")
+	default:
+	}
+
+	mockClock.Add(time.Minute)
+	for _, ch := range []<-chan error{first, second} {
+		for err := range ch {
+			require.NoError(t, err)
+		}
+	}
+
+	third := h.Record(context.Background(), rule, []state.StateTransition{
+		transitionAt(eval.Alerting, data.Labels{"a": "3"}, now),
+		transitionAt(eval.Alerting, data.Labels{"a": "4"}, now),
+		transitionAt(eval.Alerting, data.Labels{"a": "5"}, now),
+	})
+	for err := range third {
+		require.NoError(t, err)
+	}
+
+	frame, err := h.Query(context.Background(), models.HistoryQuery{OrgID: 1, RuleUID: rule.UID})
+	require.NoError(t, err)
+	assert.Equal(t, 5, frame.Rows())
+}
+
+func TestIntegrationDeleteExpiredSQLService(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sqlStore := db.InitTestDB(t)
+	h := createTestSQLBackend(t, sqlStore, setting.UnifiedAlertingStateHistorySettings{SQLBatchSize: 1})
+	rule := createTestRule()
+	now := time.Now()
+
+	recordAndWait(t, h, rule,
+		transitionAt(eval.Alerting, data.Labels{"instance": "old"}, now.Add(-2*time.Hour)),
+		transitionAt(eval.Alerting, data.Labels{"instance": "new"}, now),
+	)
+
+	cfg := setting.NewCfg()
+	service := ProvideDeleteExpiredSQLService(sqlStore, cfg)
+	affected, err := service.DeleteExpired(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, affected, "history is kept forever by default")
+
+	cfg.UnifiedAlerting.StateHistory.SQLMaxAge = time.Hour
+	affected, err = service.DeleteExpired(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	frame, err := h.Query(context.Background(), models.HistoryQuery{OrgID: 1, From: now.Add(-3 * time.Hour), To: now.Add(time.Second)})
+	require.NoError(t, err)
+	assert.Equal(t, 1, frame.Rows())
+
+	var labels []stateHistoryLabelEntity
+	err = sqlStore.WithDbSession(context.Background(), func(sess *db.Session) error {
+		return sess.Find(&labels)
+	})
+	require.NoError(t, err)
+	require.Len(t, labels, 1, "labels of the deleted entries must be deleted as well")
+	assert.Equal(t, "new", labels[0].Value)
+}
@@ -0,0 +1,164 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func (st DBstore) InsertBacktest(ctx context.Context, b *models.Backtest) error {
+	return st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		now := TimeNow().UTC()
+		b.Created = now
+		b.Updated = now
+		if _, err := sess.Insert(b); err != nil {
+			return fmt.Errorf("failed to insert backtest: %w", err)
+		}
+		return nil
+	})
+}
+
+// FinishBacktest stores the final status and the results of a backtest that has not finished yet.
+// It returns false if the backtest has already finished, for example because it was canceled.
+func (st DBstore) FinishBacktest(ctx context.Context, b *models.Backtest) (bool, error) {
+	var updated int64
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		b.Updated = TimeNow().UTC()
+		updated, err = sess.ID(b.ID).
+			In("status", models.BacktestStatusPending, models.BacktestStatusRunning).
+			AllCols().Cols("status", "progress", "result", "notifications", "suppressed", "error", "updated").
+			Update(b)
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to update backtest: %w", err)
+	}
+	return updated > 0, nil
+}
+
+// CancelBacktest marks a backtest that has not finished yet as canceled.
+// It returns false if the backtest has already finished.
+func (st DBstore) CancelBacktest(ctx context.Context, orgID int64, uid string) (bool, error) {
+	var updated int64
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		updated, err = sess.Where("org_id = ? AND uid = ?", orgID, uid).
+			In("status", models.BacktestStatusPending, models.BacktestStatusRunning).
+			AllCols().Cols("status", "updated").
+			Update(&models.Backtest{Status: models.BacktestStatusCanceled, Updated: TimeNow().UTC()})
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel backtest: %w", err)
+	}
+	return updated > 0, nil
+}
+
+// UpdateBacktestProgress updates the progress of a backtest that has not finished yet.
+// It returns false if the backtest has already finished, for example because it was canceled.
+func (st DBstore) UpdateBacktestProgress(ctx context.Context, id int64, progress int) (bool, error) {
+	var updated int64
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		updated, err = sess.ID(id).
+			In("status", models.BacktestStatusPending, models.BacktestStatusRunning).
+			AllCols().Cols("progress", "status", "updated").
+			Update(&models.Backtest{Progress: progress, Status: models.BacktestStatusRunning, Updated: TimeNow().UTC()})
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to update backtest progress: %w", err)
+	}
+	return updated > 0, nil
+}
+
+func (st DBstore) GetBacktest(ctx context.Context, orgID int64, uid string) (*models.Backtest, error) {
+	var b models.Backtest
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		exists, err := sess.Where("org_id = ? AND uid = ?", orgID, uid).Get(&b)
+		if err != nil {
+			return fmt.Errorf("failed to get backtest: %w", err)
+		}
+		if !exists {
+			return models.ErrBacktestNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// ListBacktests returns the backtests of an organization, most recent first.
+// The result frame and the notifications are not loaded.
+func (st DBstore) ListBacktests(ctx context.Context, query models.ListBacktestsQuery) ([]*models.Backtest, error) {
+	result := make([]*models.Backtest, 0)
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		q := sess.Where("org_id = ?", query.OrgID)
+		if query.RuleUID != "" {
+			q = q.And("rule_uid = ?", query.RuleUID)
+		}
+		if query.Limit > 0 {
+			q = q.Limit(query.Limit)
+		}
+		return q.Omit("result", "notifications", "suppressed").Desc("created").Find(&result)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backtests: %w", err)
+	}
+	return result, nil
+}
+
+func (st DBstore) DeleteBacktest(ctx context.Context, orgID int64, uid string) error {
+	return st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		deleted, err := sess.Where("org_id = ? AND uid = ?", orgID, uid).Delete(&models.Backtest{})
+		if err != nil {
+			return fmt.Errorf("failed to delete backtest: %w", err)
+		}
+		if deleted == 0 {
+			return models.ErrBacktestNotFound
+		}
+		return nil
+	})
+}
+
+// FailStaleBacktests marks unfinished backtests that were not updated since the given time as failed.
+// This happens when the instance that ran the backtest was stopped before it finished.
+func (st DBstore) FailStaleBacktests(ctx context.Context, updatedBefore time.Time) (int64, error) {
+	var updated int64
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		updated, err = sess.
+			In("status", models.BacktestStatusPending, models.BacktestStatusRunning).
+			And("updated < ?", updatedBefore.UTC()).
+			AllCols().Cols("status", "error", "updated").
+			Update(&models.Backtest{Status: models.BacktestStatusFailed, Error: "backtest was interrupted", Updated: TimeNow().UTC()})
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire stale backtests: %w", err)
+	}
+	return updated, nil
+}
+
+// DeleteFinishedBacktests deletes the backtests that finished before the given time.
+func (st DBstore) DeleteFinishedBacktests(ctx context.Context, finishedBefore time.Time) (int64, error) {
+	var deleted int64
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		deleted, err = sess.
+			In("status", models.BacktestStatusSucceeded, models.BacktestStatusFailed, models.BacktestStatusCanceled).
+			And("updated < ?", finishedBefore.UTC()).
+			Delete(&models.Backtest{})
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete finished backtests: %w", err)
+	}
+	return deleted, nil
+}
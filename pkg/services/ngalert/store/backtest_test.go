@@ -0,0 +1,160 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func TestIntegrationBacktests(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	// our database schema uses second precision for timestamps
+	store.TimeNow = func() time.Time {
+		return time.Now().Truncate(time.Second)
+	}
+	t.Cleanup(func() {
+		store.TimeNow = time.Now
+	})
+
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+
+	newBacktest := func(orgID int64, ruleUID string) *models.Backtest {
+		b := &models.Backtest{
+			OrgID:        orgID,
+			UID:          util.GenerateShortUID(),
+			RuleUID:      ruleUID,
+			RuleVersion:  1,
+			NamespaceUID: "folder",
+			Title:        "test",
+			Status:       models.BacktestStatusPending,
+			Evaluations:  10,
+			From:         time.Unix(0, 0).UTC(),
+			To:           time.Unix(600, 0).UTC(),
+			Config:       "{}",
+			CreatedBy:    "user",
+		}
+		require.NoError(t, dbstore.InsertBacktest(ctx, b))
+		require.NotZero(t, b.ID)
+		return b
+	}
+
+	t.Run("should update progress and results of unfinished backtest", func(t *testing.T) {
+		b := newBacktest(1, "rule-1")
+
+		ok, err := dbstore.UpdateBacktestProgress(ctx, b.ID, 5)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		b.Status = models.BacktestStatusSucceeded
+		b.Progress = 10
+		b.Result = `{"schema":{}}`
+		b.Notifications = "[]"
+		b.Suppressed = "[]"
+		ok, err = dbstore.FinishBacktest(ctx, b)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		got, err := dbstore.GetBacktest(ctx, 1, b.UID)
+		require.NoError(t, err)
+		assert.Equal(t, models.BacktestStatusSucceeded, got.Status)
+		assert.Equal(t, 10, got.Progress)
+		assert.Equal(t, b.Result, got.Result)
+		assert.Equal(t, b.Suppressed, got.Suppressed)
+		assert.Equal(t, "folder", got.NamespaceUID)
+
+		// finished backtests are not changed anymore
+		ok, err = dbstore.UpdateBacktestProgress(ctx, b.ID, 1)
+		require.NoError(t, err)
+		require.False(t, ok)
+		ok, err = dbstore.CancelBacktest(ctx, 1, b.UID)
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("should not finish canceled backtest", func(t *testing.T) {
+		b := newBacktest(1, "rule-1")
+		ok, err := dbstore.CancelBacktest(ctx, 1, b.UID)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		b.Status = models.BacktestStatusSucceeded
+		ok, err = dbstore.FinishBacktest(ctx, b)
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		got, err := dbstore.GetBacktest(ctx, 1, b.UID)
+		require.NoError(t, err)
+		assert.Equal(t, models.BacktestStatusCanceled, got.Status)
+	})
+
+	t.Run("should list backtests of organization and rule without results", func(t *testing.T) {
+		b := newBacktest(2, "rule-2")
+		b.Status = models.BacktestStatusSucceeded
+		b.Result = `{"schema":{}}`
+		_, err := dbstore.FinishBacktest(ctx, b)
+		require.NoError(t, err)
+		newBacktest(2, "rule-3")
+
+		all, err := dbstore.ListBacktests(ctx, models.ListBacktestsQuery{OrgID: 2})
+		require.NoError(t, err)
+		require.Len(t, all, 2)
+
+		byRule, err := dbstore.ListBacktests(ctx, models.ListBacktestsQuery{OrgID: 2, RuleUID: "rule-2"})
+		require.NoError(t, err)
+		require.Len(t, byRule, 1)
+		assert.Equal(t, b.UID, byRule[0].UID)
+		assert.Empty(t, byRule[0].Result)
+	})
+
+	t.Run("should fail stale backtests", func(t *testing.T) {
+		b := newBacktest(3, "")
+		n, err := dbstore.FailStaleBacktests(ctx, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, n, int64(1))
+
+		got, err := dbstore.GetBacktest(ctx, 3, b.UID)
+		require.NoError(t, err)
+		assert.Equal(t, models.BacktestStatusFailed, got.Status)
+		assert.NotEmpty(t, got.Error)
+	})
+
+	t.Run("should delete backtest", func(t *testing.T) {
+		b := newBacktest(4, "")
+		require.NoError(t, dbstore.DeleteBacktest(ctx, 4, b.UID))
+		_, err := dbstore.GetBacktest(ctx, 4, b.UID)
+		require.ErrorIs(t, err, models.ErrBacktestNotFound)
+		require.ErrorIs(t, dbstore.DeleteBacktest(ctx, 4, b.UID), models.ErrBacktestNotFound)
+	})
+
+	t.Run("should delete only finished backtests", func(t *testing.T) {
+		finished := newBacktest(5, "")
+		finished.Status = models.BacktestStatusFailed
+		_, err := dbstore.FinishBacktest(ctx, finished)
+		require.NoError(t, err)
+		running := newBacktest(5, "")
+
+		n, err := dbstore.DeleteFinishedBacktests(ctx, time.Now().Add(-time.Minute))
+		require.NoError(t, err)
+		require.Zero(t, n)
+
+		n, err = dbstore.DeleteFinishedBacktests(ctx, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, n, int64(1))
+		_, err = dbstore.GetBacktest(ctx, 5, finished.UID)
+		require.ErrorIs(t, err, models.ErrBacktestNotFound)
+		_, err = dbstore.GetBacktest(ctx, 5, running.UID)
+		require.NoError(t, err)
+	})
+}
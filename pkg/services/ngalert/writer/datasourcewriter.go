@@ -4,12 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/url"
 	"path"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/benbjohnson/clock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	gocache "github.com/patrickmn/go-cache"
@@ -17,6 +22,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/adapters"
 )
 
@@ -47,9 +53,19 @@ type DatasourceWriter struct {
 	l                  log.Logger
 	metrics            *metrics.RemoteWriter
 
+	// mtx guards the reference counts of the cached writers.
+	mtx     sync.Mutex
 	writers *gocache.Cache
 }
 
+// cachedWriter is a writer of the cache. Writers holding connections, like SQL writers, are closed
+// once they have expired and the writes that got them from the cache have finished.
+type cachedWriter struct {
+	Writer
+	refs    int
+	evicted bool
+}
+
 func NewDatasourceWriter(
 	cfg DatasourceWriterConfig,
 	datasources datasources.DataSourceService,
@@ -58,7 +74,7 @@ func NewDatasourceWriter(
 	l log.Logger,
 	metrics *metrics.RemoteWriter,
 ) *DatasourceWriter {
-	return &DatasourceWriter{
+	w := &DatasourceWriter{
 		cfg:                cfg,
 		datasources:        datasources,
 		httpClientProvider: httpClientProvider,
@@ -67,6 +83,72 @@ func NewDatasourceWriter(
 		metrics:            metrics,
 		writers:            gocache.New(cacheExpiration, cacheCleanupInterval),
 	}
+	w.writers.OnEvicted(func(_ string, val any) {
+		if cw, ok := val.(*cachedWriter); ok {
+			w.evict(cw)
+		}
+	})
+	return w
+}
+
+// acquire returns the cached writer of the key, if any, which must be released once the write is done.
+func (w *DatasourceWriter) acquire(key string) (*cachedWriter, bool) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	val, ok := w.writers.Get(key)
+	if !ok {
+		return nil, false
+	}
+	cw, ok := val.(*cachedWriter)
+	if !ok {
+		return nil, false
+	}
+	cw.refs++
+	return cw, true
+}
+
+// store caches the new writer of the key and acquires it. If another write cached a writer for the key
+// in the meantime, that writer is acquired instead and the new one is closed.
+func (w *DatasourceWriter) store(key string, writer Writer) *cachedWriter {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	if val, ok := w.writers.Get(key); ok {
+		if cw, ok := val.(*cachedWriter); ok {
+			cw.refs++
+			closeWriter(w.l, writer)
+			return cw
+		}
+	}
+	cw := &cachedWriter{Writer: writer, refs: 1}
+	w.writers.Set(key, cw, 0)
+	return cw
+}
+
+func (w *DatasourceWriter) release(cw *cachedWriter) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	cw.refs--
+	if cw.evicted && cw.refs == 0 {
+		closeWriter(w.l, cw.Writer)
+	}
+}
+
+func (w *DatasourceWriter) evict(cw *cachedWriter) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	cw.evicted = true
+	if cw.refs == 0 {
+		closeWriter(w.l, cw.Writer)
+	}
+}
+
+// closeWriter releases the connections of writers holding them, like SQL writers.
+func closeWriter(l log.Logger, writer Writer) {
+	if c, ok := writer.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			l.Warn("Failed to close expired writer", "error", err)
+		}
+	}
 }
 
 func (w *DatasourceWriter) decrypt(ds *datasources.DataSource) (map[string]string, error) {
@@ -144,7 +226,7 @@ func getRemoteWriteURL(ds *datasources.DataSource) (*url.URL, error) {
 	return u, nil
 }
 
-func (w *DatasourceWriter) makeWriter(ctx context.Context, orgID int64, dsUID string) (*PrometheusWriter, error) {
+func (w *DatasourceWriter) makeWriter(ctx context.Context, orgID int64, dsUID string, record models.Record) (Writer, error) {
 	ds, err := w.datasources.GetDataSource(ctx, &datasources.GetDataSourceQuery{
 		UID:   dsUID,
 		OrgID: orgID,
@@ -153,10 +235,19 @@ func (w *DatasourceWriter) makeWriter(ctx context.Context, orgID int64, dsUID st
 		return nil, err
 	}
 
-	if ds.Type != datasources.DS_PROMETHEUS {
-		return nil, errors.New("can only write to data sources of type prometheus")
+	switch ds.Type {
+	case datasources.DS_PROMETHEUS:
+		return w.makePrometheusWriter(ctx, ds)
+	case datasources.DS_INFLUXDB:
+		return w.makeInfluxDBWriter(ctx, ds)
+	case datasources.DS_POSTGRES, datasources.DS_MYSQL:
+		return w.makeSQLWriter(ctx, ds, record.TargetTable)
+	default:
+		return nil, errors.New("can only write to data sources of type prometheus, influxdb, postgres or mysql")
 	}
+}
 
+func (w *DatasourceWriter) makePrometheusWriter(ctx context.Context, ds *datasources.DataSource) (*PrometheusWriter, error) {
 	is, err := adapters.ModelToInstanceSettings(ds, w.decrypt)
 	if err != nil {
 		return nil, err
@@ -181,12 +272,9 @@ func (w *DatasourceWriter) makeWriter(ctx context.Context, orgID int64, dsUID st
 		},
 		Timeout: w.cfg.Timeout,
 	}
-	if err != nil {
-		return nil, err
-	}
 
 	w.l.Debug("Created Prometheus remote writer",
-		"datasource_uid", dsUID,
+		"datasource_uid", ds.UID,
 		"type", ds.Type,
 		"prometheusType", getPrometheusType(ds),
 		"url", cfg.URL,
@@ -202,11 +290,223 @@ func (w *DatasourceWriter) makeWriter(ctx context.Context, orgID int64, dsUID st
 		w.metrics)
 }
 
+func (w *DatasourceWriter) makeInfluxDBWriter(ctx context.Context, ds *datasources.DataSource) (*InfluxDBWriter, error) {
+	is, err := adapters.ModelToInstanceSettings(ds, w.decrypt)
+	if err != nil {
+		return nil, err
+	}
+
+	ho, err := is.HTTPClientOptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := getInfluxDBWriterConfig(ds, is.DecryptedSecureJSONData)
+	if err != nil {
+		return nil, err
+	}
+	cfg.HTTPOptions = httpclient.Options{
+		Timeouts:  ho.Timeouts,
+		TLS:       ho.TLS,
+		BasicAuth: ho.BasicAuth,
+	}
+	cfg.Timeout = w.cfg.Timeout
+
+	w.l.Debug("Created InfluxDB writer",
+		"datasource_uid", ds.UID,
+		"type", ds.Type,
+		"version", getJSONString(ds, "version"),
+		"url", cfg.URL,
+		"bucket", cfg.Bucket,
+		"tls", cfg.HTTPOptions.TLS != nil,
+		"timeout", cfg.Timeout)
+
+	return NewInfluxDBWriter(
+		cfg,
+		w.httpClientProvider,
+		w.clock,
+		w.l,
+		w.metrics)
+}
+
+func (w *DatasourceWriter) makeSQLWriter(ctx context.Context, ds *datasources.DataSource, table string) (*SQLWriter, error) {
+	is, err := adapters.ModelToInstanceSettings(ds, w.decrypt)
+	if err != nil {
+		return nil, err
+	}
+
+	var tlsConfig string
+	if ds.Type == datasources.DS_MYSQL {
+		if tlsConfig, err = registerMySQLTLSConfig(ctx, ds, is); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg, err := getSQLWriterConfig(ds, is.DecryptedSecureJSONData, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Table = table
+	cfg.Timeout = w.cfg.Timeout
+
+	w.l.Debug("Created SQL writer",
+		"datasource_uid", ds.UID,
+		"type", ds.Type,
+		"table", cfg.Table,
+		"timeout", cfg.Timeout)
+
+	return NewSQLWriter(
+		cfg,
+		w.clock,
+		w.l,
+		w.metrics)
+}
+
+func getJSONString(ds *datasources.DataSource, key string) string {
+	if ds.JsonData == nil {
+		return ""
+	}
+	return ds.JsonData.Get(key).MustString()
+}
+
+func getInfluxDBWriterConfig(ds *datasources.DataSource, secureJSONData map[string]string) (InfluxDBWriterConfig, error) {
+	cfg := InfluxDBWriterConfig{
+		URL: ds.URL,
+	}
+
+	switch version := getJSONString(ds, "version"); version {
+	case "", "InfluxQL":
+		cfg.Bucket = getJSONString(ds, "dbName")
+		if cfg.Bucket == "" {
+			cfg.Bucket = ds.Database
+		}
+		// InfluxDB 1.8+ accepts the credentials of the user as token.
+		if ds.User != "" {
+			cfg.Token = ds.User + ":" + secureJSONData["password"]
+		}
+	case "Flux":
+		cfg.Organization = getJSONString(ds, "organization")
+		cfg.Bucket = getJSONString(ds, "defaultBucket")
+		cfg.Token = secureJSONData["token"]
+	case "SQL":
+		cfg.Bucket = getJSONString(ds, "dbName")
+		cfg.Token = secureJSONData["token"]
+	default:
+		return InfluxDBWriterConfig{}, fmt.Errorf("unsupported InfluxDB query language %q", version)
+	}
+
+	return cfg, nil
+}
+
+// getSQLWriterConfig returns the configuration of the writer of a SQL data source. tlsConfig is the name of the
+// TLS configuration of MySQL data sources, see registerMySQLTLSConfig.
+func getSQLWriterConfig(ds *datasources.DataSource, secureJSONData map[string]string, tlsConfig string) (SQLWriterConfig, error) {
+	database := getJSONString(ds, "database")
+	if database == "" {
+		database = ds.Database
+	}
+
+	switch ds.Type {
+	case datasources.DS_POSTGRES:
+		return SQLWriterConfig{
+			Dialect: PostgresDialect,
+			DSN:     postgresDSN(ds, database, secureJSONData["password"]),
+		}, nil
+	case datasources.DS_MYSQL:
+		return SQLWriterConfig{
+			Dialect: MySQLDialect,
+			DSN:     mysqlDSN(ds, database, secureJSONData["password"], tlsConfig),
+		}, nil
+	default:
+		return SQLWriterConfig{}, fmt.Errorf("unsupported SQL data source type %q", ds.Type)
+	}
+}
+
+// postgresDSN returns the connection string of a PostgreSQL data source. ds.URL is either
+// host:port, [ipv6]:port or the path of a Unix socket.
+func postgresDSN(ds *datasources.DataSource, database, password string) string {
+	escape := func(s string) string {
+		return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s)
+	}
+
+	host, port := ds.URL, ""
+	if !strings.HasPrefix(ds.URL, "/") {
+		if h, p, err := net.SplitHostPort(ds.URL); err == nil {
+			host, port = h, p
+		} else {
+			host = strings.Trim(ds.URL, "[]")
+		}
+	}
+
+	sslmode := getJSONString(ds, "sslmode")
+	if sslmode == "" {
+		sslmode = "verify-full"
+	}
+
+	dsn := fmt.Sprintf("user='%s' password='%s' host='%s' dbname='%s' sslmode='%s'",
+		escape(ds.User), escape(password), escape(host), escape(database), escape(sslmode))
+	if port != "" {
+		dsn += fmt.Sprintf(" port='%s'", escape(port))
+	}
+	if getJSONString(ds, "tlsConfigurationMethod") == "file-path" {
+		for param, key := range map[string]string{"sslrootcert": "sslRootCertFile", "sslcert": "sslCertFile", "sslkey": "sslKeyFile"} {
+			if file := getJSONString(ds, key); file != "" {
+				dsn += fmt.Sprintf(" %s='%s'", param, escape(file))
+			}
+		}
+	}
+	return dsn
+}
+
+// registerMySQLTLSConfig registers the TLS configuration of a MySQL data source with the driver, like the
+// MySQL data source does, and returns its name for the connection string. It is empty if TLS is not used.
+func registerMySQLTLSConfig(ctx context.Context, ds *datasources.DataSource, is *backend.DataSourceInstanceSettings) (string, error) {
+	opts, err := is.HTTPClientOptions(ctx)
+	if err != nil {
+		return "", err
+	}
+	tlsConfig, err := httpclient.GetTLSConfig(opts)
+	if err != nil {
+		return "", err
+	}
+
+	if tlsConfig.RootCAs != nil || len(tlsConfig.Certificates) > 0 {
+		name := fmt.Sprintf("recording-rules-ds%d", ds.ID)
+		if err := mysql.RegisterTLSConfig(name, tlsConfig); err != nil {
+			return "", err
+		}
+		return name, nil
+	}
+	if tlsConfig.InsecureSkipVerify {
+		return "skip-verify", nil
+	}
+	return "", nil
+}
+
+// mysqlDSN returns the connection string of a MySQL data source. ds.URL is either
+// host:port or the path of a Unix socket.
+func mysqlDSN(ds *datasources.DataSource, database, password, tlsConfig string) string {
+	cfg := mysql.NewConfig()
+	cfg.User = ds.User
+	cfg.Passwd = password
+	cfg.DBName = database
+	cfg.Net = "tcp"
+	cfg.Addr = ds.URL
+	if strings.HasPrefix(ds.URL, "/") {
+		cfg.Net = "unix"
+	}
+	cfg.Collation = "utf8mb4_unicode_ci"
+	cfg.AllowNativePasswords = true
+	cfg.TLSConfig = tlsConfig
+	return cfg.FormatDSN()
+}
+
 func uidKey(orgID int64, uid string) string {
 	return fmt.Sprintf("%d-%s", orgID, uid)
 }
 
-func (w *DatasourceWriter) WriteDatasource(ctx context.Context, dsUID string, name string, t time.Time, frames data.Frames, orgID int64, extraLabels map[string]string) error {
+func (w *DatasourceWriter) WriteRecord(ctx context.Context, record models.Record, t time.Time, frames data.Frames, orgID int64, extraLabels map[string]string) error {
+	dsUID := record.TargetDatasourceUID
 	if dsUID == "" {
 		if w.cfg.DefaultDatasourceUID == "" {
 			return errors.New("data source uid not specified and no default set")
@@ -216,28 +516,23 @@ func (w *DatasourceWriter) WriteDatasource(ctx context.Context, dsUID string, na
 			"org_id", orgID, "datasource_uid", dsUID)
 	}
 
+	// SQL writers are bound to the table of the rule.
 	key := uidKey(orgID, dsUID)
+	if record.TargetTable != "" {
+		key += "-" + record.TargetTable
+	}
 
-	var writer *PrometheusWriter
-
-	val, ok := w.writers.Get(key)
-	if ok {
-		var ok bool
-		writer, ok = val.(*PrometheusWriter)
-		if !ok {
-			return errors.New("type in cache not a Writer")
-		}
-	} else {
-		var err error
-		writer, err = w.makeWriter(ctx, orgID, dsUID)
+	writer, ok := w.acquire(key)
+	if !ok {
+		newWriter, err := w.makeWriter(ctx, orgID, dsUID, record)
 		if err != nil {
 			w.l.Error("Failed to create writer for data source",
 				"org_id", orgID, "datasource_uid", dsUID)
 			return err
 		}
-
-		w.writers.Set(key, writer, 0)
+		writer = w.store(key, newWriter)
 	}
+	defer w.release(writer)
 
-	return writer.Write(ctx, name, t, frames, orgID, extraLabels)
+	return writer.Write(ctx, record.Metric, t, frames, orgID, extraLabels)
 }
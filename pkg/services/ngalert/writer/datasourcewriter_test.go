@@ -2,6 +2,13 @@ package writer
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
 	"testing"
 	"time"
 
@@ -17,6 +24,8 @@ import (
 	"github.com/grafana/grafana/pkg/services/datasources"
 	dsfakes "github.com/grafana/grafana/pkg/services/datasources/fakes"
 	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/pluginsintegration/adapters"
 )
 
 type testDataSources struct {
@@ -65,6 +74,13 @@ func setupDataSources(t *testing.T) *testDataSources {
 		Type: datasources.DS_LOKI,
 	})
 
+	_, _ = res.AddDataSource(context.Background(), &datasources.AddDataSourceCommand{
+		UID:      "postgres-1",
+		Type:     datasources.DS_POSTGRES,
+		URL:      "localhost:5432",
+		JsonData: simplejson.New(),
+	})
+
 	return res
 }
 
@@ -85,13 +101,13 @@ func TestDatasourceWriter(t *testing.T) {
 	t.Run("when writing a prometheus datasource then the request is made to the expected endpoint", func(t *testing.T) {
 		datasources.Reset()
 
-		err := writer.WriteDatasource(context.Background(), "prom-1", "metric", time.Now(), frames, 1, map[string]string{})
+		err := writer.WriteRecord(context.Background(), models.Record{Metric: "metric", TargetDatasourceUID: "prom-1"}, time.Now(), frames, 1, map[string]string{})
 		require.NoError(t, err)
 
 		assert.Equal(t, 1, datasources.prom1.RequestsCount)
 		assert.Equal(t, 0, datasources.prom2.RequestsCount)
 
-		err = writer.WriteDatasource(context.Background(), "prom-2", "metric", time.Now(), frames, 1, map[string]string{})
+		err = writer.WriteRecord(context.Background(), models.Record{Metric: "metric", TargetDatasourceUID: "prom-2"}, time.Now(), frames, 1, map[string]string{})
 		require.NoError(t, err)
 
 		assert.Equal(t, 1, datasources.prom1.RequestsCount)
@@ -101,7 +117,7 @@ func TestDatasourceWriter(t *testing.T) {
 	t.Run("when writing an unknown datasource then an error is returned", func(t *testing.T) {
 		datasources.Reset()
 
-		err := writer.WriteDatasource(context.Background(), "prom-3", "metric", time.Now(), frames, 1, map[string]string{})
+		err := writer.WriteRecord(context.Background(), models.Record{Metric: "metric", TargetDatasourceUID: "prom-3"}, time.Now(), frames, 1, map[string]string{})
 		require.Error(t, err)
 		require.EqualError(t, err, "data source not found")
 	})
@@ -109,15 +125,22 @@ func TestDatasourceWriter(t *testing.T) {
 	t.Run("when writing a non-prometheus datasource then an error is returned", func(t *testing.T) {
 		datasources.Reset()
 
-		err := writer.WriteDatasource(context.Background(), "loki-1", "metric", time.Now(), frames, 1, map[string]string{})
+		err := writer.WriteRecord(context.Background(), models.Record{Metric: "metric", TargetDatasourceUID: "loki-1"}, time.Now(), frames, 1, map[string]string{})
 		require.Error(t, err)
-		require.EqualError(t, err, "can only write to data sources of type prometheus")
+		require.EqualError(t, err, "can only write to data sources of type prometheus, influxdb, postgres or mysql")
+	})
+
+	t.Run("when writing a sql datasource without a target table then an error is returned", func(t *testing.T) {
+		datasources.Reset()
+
+		err := writer.WriteRecord(context.Background(), models.Record{Metric: "metric", TargetDatasourceUID: "postgres-1"}, time.Now(), frames, 1, map[string]string{})
+		require.EqualError(t, err, "a target table is required to write to a SQL data source")
 	})
 
 	t.Run("when writing with an empty datasource uid then the default is written", func(t *testing.T) {
 		datasources.Reset()
 
-		err := writer.WriteDatasource(context.Background(), "", "metric", time.Now(), frames, 1, map[string]string{})
+		err := writer.WriteRecord(context.Background(), models.Record{Metric: "metric"}, time.Now(), frames, 1, map[string]string{})
 		require.NoError(t, err)
 	})
 }
@@ -194,3 +217,202 @@ func TestDatasourceWriterGetRemoteWriteURL(t *testing.T) {
 		})
 	}
 }
+
+func TestDatasourceWriterGetInfluxDBWriterConfig(t *testing.T) {
+	tc := []struct {
+		name     string
+		ds       datasources.DataSource
+		secure   map[string]string
+		expected InfluxDBWriterConfig
+	}{
+		{
+			"influxql",
+			datasources.DataSource{
+				JsonData: simplejson.MustJson([]byte(`{"dbName":"telegraf"}`)),
+				URL:      "http://example.com",
+				User:     "grafana",
+			},
+			map[string]string{"password": "pwd"},
+			InfluxDBWriterConfig{URL: "http://example.com", Bucket: "telegraf", Token: "grafana:pwd"},
+		},
+		{
+			"influxql with legacy database",
+			datasources.DataSource{
+				JsonData: simplejson.New(),
+				URL:      "http://example.com",
+				Database: "telegraf",
+			},
+			nil,
+			InfluxDBWriterConfig{URL: "http://example.com", Bucket: "telegraf"},
+		},
+		{
+			"flux",
+			datasources.DataSource{
+				JsonData: simplejson.MustJson([]byte(`{"version":"Flux","organization":"grafana","defaultBucket":"metrics"}`)),
+				URL:      "http://example.com",
+			},
+			map[string]string{"token": "secret"},
+			InfluxDBWriterConfig{URL: "http://example.com", Organization: "grafana", Bucket: "metrics", Token: "secret"},
+		},
+		{
+			"sql",
+			datasources.DataSource{
+				JsonData: simplejson.MustJson([]byte(`{"version":"SQL","dbName":"metrics"}`)),
+				URL:      "http://example.com",
+			},
+			map[string]string{"token": "secret"},
+			InfluxDBWriterConfig{URL: "http://example.com", Bucket: "metrics", Token: "secret"},
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := getInfluxDBWriterConfig(&tt.ds, tt.secure)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, res)
+		})
+	}
+}
+
+func TestDatasourceWriterGetSQLWriterConfig(t *testing.T) {
+	tc := []struct {
+		name      string
+		ds        datasources.DataSource
+		tlsConfig string
+		expected  SQLWriterConfig
+	}{
+		{
+			"postgres",
+			datasources.DataSource{
+				Type:     datasources.DS_POSTGRES,
+				JsonData: simplejson.MustJson([]byte(`{"database":"metrics","sslmode":"disable"}`)),
+				URL:      "localhost:5432",
+				User:     "grafana",
+			},
+			"",
+			SQLWriterConfig{Dialect: PostgresDialect, DSN: `user='grafana' password='it\'s' host='localhost' dbname='metrics' sslmode='disable' port='5432'`},
+		},
+		{
+			"postgres with unix socket",
+			datasources.DataSource{
+				Type:     datasources.DS_POSTGRES,
+				JsonData: simplejson.New(),
+				URL:      "/var/run/postgresql",
+				Database: "metrics",
+			},
+			"",
+			SQLWriterConfig{Dialect: PostgresDialect, DSN: `user='' password='it\'s' host='/var/run/postgresql' dbname='metrics' sslmode='verify-full'`},
+		},
+		{
+			"mysql",
+			datasources.DataSource{
+				Type:     datasources.DS_MYSQL,
+				JsonData: simplejson.MustJson([]byte(`{"database":"metrics"}`)),
+				URL:      "localhost:3306",
+				User:     "grafana",
+			},
+			"",
+			SQLWriterConfig{Dialect: MySQLDialect, DSN: "grafana:it's@tcp(localhost:3306)/metrics?collation=utf8mb4_unicode_ci"},
+		},
+		{
+			"mysql with tls",
+			datasources.DataSource{
+				Type:     datasources.DS_MYSQL,
+				JsonData: simplejson.MustJson([]byte(`{"database":"metrics"}`)),
+				URL:      "localhost:3306",
+				User:     "grafana",
+			},
+			"recording-rules-ds1",
+			SQLWriterConfig{Dialect: MySQLDialect, DSN: "grafana:it's@tcp(localhost:3306)/metrics?collation=utf8mb4_unicode_ci&tls=recording-rules-ds1"},
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := getSQLWriterConfig(&tt.ds, map[string]string{"password": "it's"}, tt.tlsConfig)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, res)
+		})
+	}
+}
+
+func TestRegisterMySQLTLSConfig(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "mysql"}, NotAfter: time.Now().Add(time.Hour), IsCA: true, BasicConstraintsValid: true}
+	cert, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	caCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}))
+
+	tc := []struct {
+		name     string
+		jsonData string
+		secure   map[string]string
+		expected string
+	}{
+		{"without tls", `{}`, nil, ""},
+		{"skip verify", `{"tlsSkipVerify":true}`, nil, "skip-verify"},
+		{"ca certificate", `{"tlsAuthWithCACert":true}`, map[string]string{"tlsCACert": caCert}, "recording-rules-ds1"},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := &datasources.DataSource{ID: 1, Type: datasources.DS_MYSQL, JsonData: simplejson.MustJson([]byte(tt.jsonData))}
+			is, err := adapters.ModelToInstanceSettings(ds, func(*datasources.DataSource) (map[string]string, error) {
+				return tt.secure, nil
+			})
+			require.NoError(t, err)
+
+			name, err := registerMySQLTLSConfig(context.Background(), ds, is)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, name)
+		})
+	}
+}
+
+type closingWriter struct {
+	closed bool
+}
+
+func (w *closingWriter) Write(context.Context, string, time.Time, data.Frames, int64, map[string]string) error {
+	return nil
+}
+
+func (w *closingWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestDatasourceWriterCache(t *testing.T) {
+	w := NewDatasourceWriter(DatasourceWriterConfig{}, nil, nil, clock.New(), log.New("test"), nil)
+
+	t.Run("writers in use are closed once released", func(t *testing.T) {
+		inner := &closingWriter{}
+		cw := w.store("key", inner)
+
+		w.writers.Delete("key")
+		require.False(t, inner.closed, "the writer should not be closed while it is used")
+
+		w.release(cw)
+		require.True(t, inner.closed)
+	})
+
+	t.Run("writers not in use are closed when evicted", func(t *testing.T) {
+		inner := &closingWriter{}
+		w.release(w.store("key", inner))
+		cw, ok := w.acquire("key")
+		require.True(t, ok)
+		w.release(cw)
+
+		w.writers.Delete("key")
+		require.True(t, inner.closed)
+	})
+
+	t.Run("writers created concurrently are closed", func(t *testing.T) {
+		first, second := &closingWriter{}, &closingWriter{}
+		cw := w.store("other", first)
+		require.Same(t, cw, w.store("other", second))
+		require.True(t, second.closed)
+		require.False(t, first.closed)
+	})
+}
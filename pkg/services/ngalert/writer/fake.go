@@ -6,20 +6,22 @@ import (
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
 )
 
 type FakeWriter struct {
 	WriteFunc func(ctx context.Context, name string, t time.Time, frames data.Frames, orgID int64, extraLabels map[string]string) error
 }
 
-func (w FakeWriter) WriteDatasource(ctx context.Context, dsUID string, name string, t time.Time, frames data.Frames, orgID int64, extraLabels map[string]string) error {
+func (w FakeWriter) WriteRecord(ctx context.Context, record models.Record, t time.Time, frames data.Frames, orgID int64, extraLabels map[string]string) error {
 	if w.WriteFunc == nil {
 		return nil
 	}
 
-	if dsUID != "" {
+	if record.TargetDatasourceUID != "" {
 		return errors.New("expected empty data source uid")
 	}
 
-	return w.WriteFunc(ctx, name, t, frames, orgID, extraLabels)
+	return w.WriteFunc(ctx, record.Metric, t, frames, orgID, extraLabels)
 }
@@ -0,0 +1,141 @@
+package writer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/api"
+	influxhttp "github.com/influxdata/influxdb-client-go/v2/api/http"
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
+	"github.com/m3db/prometheus_remote_client_golang/promremote"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+)
+
+const influxDBBackendType = "influxdb"
+
+// influxDBValueField is the field holding the value of a recorded point.
+const influxDBValueField = "value"
+
+type InfluxDBWriterConfig struct {
+	URL string
+	// Organization is ignored by InfluxDB 1.x and 3.x.
+	Organization string
+	// Bucket is the bucket to write to. For InfluxDB 1.x it is the database,
+	// optionally followed by the retention policy, e.g. "telegraf/autogen".
+	Bucket string
+	// Token authenticates the writes. For InfluxDB 1.x it is "username:password".
+	Token       string
+	HTTPOptions httpclient.Options
+	Timeout     time.Duration
+}
+
+// InfluxDBWriter writes recording rule results using the InfluxDB v2 write API,
+// which is also served by InfluxDB 1.8+ and 3.x. Each point is written as a measurement
+// named after the metric, with the labels as tags and the value in the "value" field.
+type InfluxDBWriter struct {
+	writeAPI api.WriteAPIBlocking
+	clock    clock.Clock
+	logger   log.Logger
+	metrics  *metrics.RemoteWriter
+}
+
+func NewInfluxDBWriter(
+	cfg InfluxDBWriterConfig,
+	httpClientProvider HttpClientProvider,
+	clock clock.Clock,
+	l log.Logger,
+	metrics *metrics.RemoteWriter,
+) (*InfluxDBWriter, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("a bucket or database is required to write to InfluxDB")
+	}
+
+	cl, err := httpClientProvider.New(cfg.HTTPOptions)
+	if err != nil {
+		return nil, err
+	}
+	cl.Timeout = cfg.Timeout
+
+	opts := influxdb2.DefaultOptions().
+		SetHTTPClient(cl).
+		SetPrecision(time.Millisecond).
+		SetApplicationName("grafana-recording-rule")
+	client := influxdb2.NewClientWithOptions(cfg.URL, cfg.Token, opts)
+
+	return &InfluxDBWriter{
+		writeAPI: client.WriteAPIBlocking(cfg.Organization, cfg.Bucket),
+		clock:    clock,
+		logger:   l,
+		metrics:  metrics,
+	}, nil
+}
+
+// Write writes the given frames to InfluxDB.
+func (w *InfluxDBWriter) Write(ctx context.Context, name string, t time.Time, frames data.Frames, orgID int64, extraLabels map[string]string) error {
+	l := w.logger.FromContext(ctx)
+	lvs := []string{fmt.Sprint(orgID), influxDBBackendType}
+
+	points, err := PointsFromFrames(name, t, frames, extraLabels)
+	if err != nil {
+		return errors.Join(ErrBadFrame, err)
+	}
+
+	influxPoints := make([]*write.Point, 0, len(points))
+	for _, p := range points {
+		// Line protocol has no representation for NaN and infinite values.
+		if math.IsNaN(p.Metric.V) || math.IsInf(p.Metric.V, 0) {
+			l.Debug("Skipping point that is not a finite number", "name", name, "value", p.Metric.V)
+			continue
+		}
+		influxPoints = append(influxPoints, write.NewPoint(p.Name, p.Labels, map[string]any{influxDBValueField: p.Metric.V}, p.Metric.T))
+	}
+	if len(influxPoints) == 0 {
+		return nil
+	}
+
+	l.Debug("Writing metric", "name", name)
+	writeStart := w.clock.Now()
+	writeErr := w.writeAPI.WritePoint(ctx, influxPoints...)
+	w.metrics.WriteDuration.WithLabelValues(lvs...).Observe(w.clock.Now().Sub(writeStart).Seconds())
+
+	statusCode := http.StatusNoContent
+	if writeErr != nil {
+		statusCode = 0
+		var httpErr *influxhttp.Error
+		if errors.As(writeErr, &httpErr) {
+			statusCode = httpErr.StatusCode
+		}
+	}
+	lvs = append(lvs, fmt.Sprint(statusCode))
+	w.metrics.WritesTotal.WithLabelValues(lvs...).Inc()
+
+	if writeErr != nil {
+		if err, ignored := checkInfluxDBWriteError(statusError{statusCode: statusCode, err: writeErr}); err != nil {
+			return err
+		} else if ignored {
+			l.Debug("Ignored write error", "error", writeErr, "status_code", statusCode)
+		}
+	}
+
+	return nil
+}
+
+func checkInfluxDBWriteError(writeErr promremote.WriteError) (err error, ignored bool) {
+	switch writeErr.StatusCode() {
+	// InfluxDB rejects points that cannot be parsed, that conflict with the type of an existing field,
+	// or that are outside of the retention policy of the bucket.
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return fmt.Errorf("%w: %s", ErrRejectedWrite, writeErr.Error()), false
+	}
+	return checkWriteError(writeErr)
+}
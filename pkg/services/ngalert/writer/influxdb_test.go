@@ -0,0 +1,105 @@
+package writer
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+)
+
+func valueFrames(values map[string]float64) data.Frames {
+	fields := []*data.Field{data.NewField("T", nil, []time.Time{time.Now()})}
+	for foo, v := range values {
+		fields = append(fields, data.NewField("value", data.Labels{"foo": foo}, []float64{v}))
+	}
+	frame := data.NewFrame("test", fields...)
+	frame.SetMeta(&data.FrameMeta{
+		Type:        data.FrameTypeNumericWide,
+		TypeVersion: data.FrameTypeVersion{0, 1},
+	})
+	return data.Frames{frame}
+}
+
+func TestInfluxDBWriter_Write(t *testing.T) {
+	var (
+		status  int
+		body    string
+		request *http.Request
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		request, body = r, string(b)
+		if status >= 300 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"code":"invalid","message":"write failed"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	writer, err := NewInfluxDBWriter(InfluxDBWriterConfig{
+		URL:          srv.URL,
+		Organization: "grafana",
+		Bucket:       "recorded",
+		Token:        "secret-token",
+		Timeout:      time.Second,
+	}, httpclient.NewProvider(), clock.New(), log.New("test"), metrics.NewRemoteWriterMetrics(prometheus.NewRegistry()))
+	require.NoError(t, err)
+
+	now := time.UnixMilli(1700000000123)
+
+	t.Run("writes points as line protocol", func(t *testing.T) {
+		status = http.StatusNoContent
+		frames := valueFrames(map[string]float64{"a b": 1.5, "c": math.NaN()})
+
+		err := writer.Write(context.Background(), "test_metric", now, frames, 1, map[string]string{"extra": "label"})
+		require.NoError(t, err)
+
+		require.Equal(t, "/api/v2/write", request.URL.Path)
+		require.Equal(t, "grafana", request.URL.Query().Get("org"))
+		require.Equal(t, "recorded", request.URL.Query().Get("bucket"))
+		require.Equal(t, "ms", request.URL.Query().Get("precision"))
+		require.Equal(t, "Token secret-token", request.Header.Get("Authorization"))
+		// The NaN value cannot be written.
+		require.Equal(t, `test_metric,extra=label,foo=a\ b value=1.5 1700000000123`, strings.TrimSpace(body))
+	})
+
+	t.Run("error when frames are empty", func(t *testing.T) {
+		err := writer.Write(context.Background(), "test_metric", now, data.Frames{data.NewFrame("test")}, 1, nil)
+		require.ErrorIs(t, err, ErrBadFrame)
+	})
+
+	for _, tc := range []struct {
+		status   int
+		expected error
+	}{
+		{http.StatusBadRequest, ErrRejectedWrite},
+		{http.StatusUnprocessableEntity, ErrRejectedWrite},
+		{http.StatusUnauthorized, ErrDatasourceUnauthorized},
+		{http.StatusForbidden, ErrDatasourceForbidden},
+		{http.StatusInternalServerError, ErrUnexpectedWriteFailure},
+	} {
+		t.Run(http.StatusText(tc.status), func(t *testing.T) {
+			status = tc.status
+			err := writer.Write(context.Background(), "test_metric", now, valueFrames(map[string]float64{"a": 1}), 1, nil)
+			require.ErrorIs(t, err, tc.expected)
+			require.ErrorContains(t, err, "write failed")
+		})
+	}
+}
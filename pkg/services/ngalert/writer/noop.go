@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
 )
 
 type NoopWriter struct{}
@@ -13,6 +15,6 @@ func (w NoopWriter) Write(ctx context.Context, name string, t time.Time, frames
 	return nil
 }
 
-func (w NoopWriter) WriteDatasource(ctx context.Context, dsUID string, name string, t time.Time, frames data.Frames, orgID int64, extraLabels map[string]string) error {
+func (w NoopWriter) WriteRecord(ctx context.Context, record models.Record, t time.Time, frames data.Frames, orgID int64, extraLabels map[string]string) error {
 	return nil
 }
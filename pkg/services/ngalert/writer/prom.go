@@ -16,6 +16,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/setting"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
@@ -329,16 +330,16 @@ func createAuthOpts(username, password string) *httpclient.BasicAuthOptions {
 	}
 }
 
-// Write writes the given frames to the Prometheus remote write endpoint.
-func (w PrometheusWriter) WriteDatasource(ctx context.Context, dsUID string, name string, t time.Time, frames data.Frames, orgID int64, extraLabels map[string]string) error {
+// WriteRecord writes the given frames to the Prometheus remote write endpoint.
+func (w PrometheusWriter) WriteRecord(ctx context.Context, record models.Record, t time.Time, frames data.Frames, orgID int64, extraLabels map[string]string) error {
 	l := w.logger.FromContext(ctx)
 
-	if dsUID != "" {
-		l.Error("Writing to specific data sources is not enabled", "org_id", orgID, "datasource_uid", dsUID)
+	if record.TargetDatasourceUID != "" {
+		l.Error("Writing to specific data sources is not enabled", "org_id", orgID, "datasource_uid", record.TargetDatasourceUID)
 		return errors.New("writing to specific data sources is not enabled")
 	}
 
-	return w.Write(ctx, name, t, frames, orgID, extraLabels)
+	return w.Write(ctx, record.Metric, t, frames, orgID, extraLabels)
 }
 
 // Write writes the given frames to the Prometheus remote write endpoint.
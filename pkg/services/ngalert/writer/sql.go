@@ -0,0 +1,276 @@
+package writer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/lib/pq"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+)
+
+const sqlBackendType = "sql"
+
+// sqlBatchSize is the maximum number of rows inserted by a single statement,
+// to stay well below the limit of bind parameters per statement.
+const sqlBatchSize = 500
+
+// SQLDialect is the SQL dialect of the database, which is also the name of its driver.
+type SQLDialect string
+
+const (
+	PostgresDialect SQLDialect = "postgres"
+	MySQLDialect    SQLDialect = "mysql"
+)
+
+var sqlColumns = []string{"time", "metric", "labels", "value"}
+
+type SQLWriterConfig struct {
+	Dialect SQLDialect
+	// DSN is the connection string passed to the driver of the dialect.
+	DSN string
+	// Table is the table the points are inserted into, optionally qualified with a schema.
+	Table   string
+	Timeout time.Duration
+}
+
+// SQLWriter inserts recording rule results into a table of a SQL database.
+// The table is expected to have the following columns:
+//
+//	time   TIMESTAMP (TIMESTAMPTZ for PostgreSQL)
+//	metric TEXT
+//	labels JSON (JSONB for PostgreSQL) or TEXT
+//	value  DOUBLE PRECISION, nullable
+//
+// Values that are not finite numbers are written as NULL. Rows that already exist
+// are skipped if the table has a unique constraint, as in the case of HA writers.
+type SQLWriter struct {
+	db      *sql.DB
+	dialect SQLDialect
+	table   string
+	timeout time.Duration
+	clock   clock.Clock
+	logger  log.Logger
+	metrics *metrics.RemoteWriter
+}
+
+func NewSQLWriter(
+	cfg SQLWriterConfig,
+	clock clock.Clock,
+	l log.Logger,
+	metrics *metrics.RemoteWriter,
+) (*SQLWriter, error) {
+	switch cfg.Dialect {
+	case PostgresDialect, MySQLDialect:
+	default:
+		return nil, fmt.Errorf("unsupported SQL dialect %q", cfg.Dialect)
+	}
+	if cfg.Table == "" {
+		return nil, errors.New("a target table is required to write to a SQL data source")
+	}
+
+	db, err := sql.Open(string(cfg.Dialect), cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSQLWriter(db, cfg, clock, l, metrics), nil
+}
+
+func newSQLWriter(db *sql.DB, cfg SQLWriterConfig, clock clock.Clock, l log.Logger, metrics *metrics.RemoteWriter) *SQLWriter {
+	return &SQLWriter{
+		db:      db,
+		dialect: cfg.Dialect,
+		table:   cfg.Dialect.quoteTable(cfg.Table),
+		timeout: cfg.Timeout,
+		clock:   clock,
+		logger:  l,
+		metrics: metrics,
+	}
+}
+
+// Close closes the connections to the database.
+func (w *SQLWriter) Close() error {
+	return w.db.Close()
+}
+
+// Write inserts the given frames into the table.
+func (w *SQLWriter) Write(ctx context.Context, name string, t time.Time, frames data.Frames, orgID int64, extraLabels map[string]string) error {
+	l := w.logger.FromContext(ctx)
+	lvs := []string{fmt.Sprint(orgID), sqlBackendType}
+
+	points, err := PointsFromFrames(name, t, frames, extraLabels)
+	if err != nil {
+		return errors.Join(ErrBadFrame, err)
+	}
+	if len(points) == 0 {
+		return nil
+	}
+
+	if w.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.timeout)
+		defer cancel()
+	}
+
+	l.Debug("Writing metric", "name", name)
+	writeStart := w.clock.Now()
+	writeErr := w.insert(ctx, points)
+	w.metrics.WriteDuration.WithLabelValues(lvs...).Observe(w.clock.Now().Sub(writeStart).Seconds())
+
+	status := "ok"
+	if writeErr != nil {
+		status = "error"
+	}
+	lvs = append(lvs, status)
+	w.metrics.WritesTotal.WithLabelValues(lvs...).Inc()
+
+	if writeErr != nil {
+		if err, ignored := checkSQLWriteError(writeErr); err != nil {
+			return err
+		} else if ignored {
+			l.Debug("Ignored write error", "error", writeErr)
+		}
+	}
+
+	return nil
+}
+
+func (w *SQLWriter) insert(ctx context.Context, points []Point) error {
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for start := 0; start < len(points); start += sqlBatchSize {
+		batch := points[start:min(start+sqlBatchSize, len(points))]
+
+		args := make([]any, 0, len(batch)*len(sqlColumns))
+		for _, p := range batch {
+			labels, err := json.Marshal(p.Labels)
+			if err != nil {
+				return err
+			}
+
+			var value any = p.Metric.V
+			if math.IsNaN(p.Metric.V) || math.IsInf(p.Metric.V, 0) {
+				value = nil
+			}
+
+			args = append(args, p.Metric.T.UTC(), p.Name, string(labels), value)
+		}
+
+		if _, err := tx.ExecContext(ctx, w.dialect.insertStatement(w.table, len(batch)), args...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// insertStatement returns the statement inserting the given number of rows into the table.
+func (d SQLDialect) insertStatement(table string, rows int) string {
+	var b strings.Builder
+	b.WriteString("INSERT INTO ")
+	b.WriteString(table)
+	b.WriteString(" (")
+	for i, c := range sqlColumns {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(d.quoteIdentifier(c))
+	}
+	b.WriteString(") VALUES ")
+
+	param := 1
+	for row := 0; row < rows; row++ {
+		if row > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString("(")
+		for i := range sqlColumns {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			if d == PostgresDialect {
+				fmt.Fprintf(&b, "$%d", param)
+			} else {
+				b.WriteString("?")
+			}
+			param++
+		}
+		b.WriteString(")")
+	}
+
+	if d == PostgresDialect {
+		b.WriteString(" ON CONFLICT DO NOTHING")
+	}
+	return b.String()
+}
+
+// quoteTable quotes a table name, optionally qualified with a schema.
+func (d SQLDialect) quoteTable(table string) string {
+	parts := strings.Split(table, ".")
+	for i, p := range parts {
+		parts[i] = d.quoteIdentifier(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+func (d SQLDialect) quoteIdentifier(name string) string {
+	if d == MySQLDialect {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+	return pq.QuoteIdentifier(name)
+}
+
+// checkSQLWriteError classifies the errors of the database like checkWriteError does for HTTP targets.
+func checkSQLWriteError(writeErr error) (err error, ignored bool) {
+	if writeErr == nil {
+		return nil, false
+	}
+
+	var pqErr *pq.Error
+	if errors.As(writeErr, &pqErr) {
+		switch {
+		case pqErr.Code == "42501": // insufficient_privilege
+			return fmt.Errorf("%w: %s", ErrDatasourceForbidden, pqErr.Message), false
+		case pqErr.Code.Class() == "28": // invalid_authorization_specification
+			return fmt.Errorf("%w: %s", ErrDatasourceUnauthorized, pqErr.Message), false
+		// data_exception, integrity_constraint_violation, syntax_error_or_access_rule_violation
+		case pqErr.Code.Class() == "22", pqErr.Code.Class() == "23", pqErr.Code.Class() == "42":
+			return fmt.Errorf("%w: %s", ErrRejectedWrite, pqErr.Message), false
+		}
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(writeErr, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1062: // ER_DUP_ENTRY, HA writers may write the same row
+			return nil, true
+		case 1045: // ER_ACCESS_DENIED_ERROR
+			return fmt.Errorf("%w: %s", ErrDatasourceUnauthorized, mysqlErr.Message), false
+		case 1044, 1142, 1143: // ER_DBACCESS_DENIED_ERROR, ER_TABLEACCESS_DENIED_ERROR, ER_COLUMNACCESS_DENIED_ERROR
+			return fmt.Errorf("%w: %s", ErrDatasourceForbidden, mysqlErr.Message), false
+		// ER_BAD_NULL_ERROR, ER_BAD_FIELD_ERROR, ER_NO_SUCH_TABLE, ER_WARN_DATA_OUT_OF_RANGE,
+		// ER_TRUNCATED_WRONG_VALUE, ER_TRUNCATED_WRONG_VALUE_FOR_FIELD, ER_DATA_TOO_LONG
+		case 1048, 1054, 1146, 1264, 1292, 1366, 1406:
+			return fmt.Errorf("%w: %s", ErrRejectedWrite, mysqlErr.Message), false
+		}
+	}
+
+	return errors.Join(ErrUnexpectedWriteFailure, writeErr), false
+}
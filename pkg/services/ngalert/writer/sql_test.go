@@ -0,0 +1,129 @@
+package writer
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/benbjohnson/clock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+)
+
+func newTestSQLWriter(t *testing.T, dialect SQLDialect, table string) (*SQLWriter, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	writer := newSQLWriter(db, SQLWriterConfig{Dialect: dialect, Table: table, Timeout: time.Second},
+		clock.New(), log.New("test"), metrics.NewRemoteWriterMetrics(prometheus.NewRegistry()))
+	return writer, mock
+}
+
+func TestSQLWriter_Write(t *testing.T) {
+	now := time.UnixMilli(1700000000123)
+
+	t.Run("inserts points into a postgres table", func(t *testing.T) {
+		writer, mock := newTestSQLWriter(t, PostgresDialect, "metrics.recorded")
+		frames := valueFrames(map[string]float64{"a": 1.5})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO "metrics"."recorded" ("time", "metric", "labels", "value") VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING`).
+			WithArgs(now.UTC(), "test_metric", `{"extra":"label","foo":"a"}`, 1.5).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := writer.Write(context.Background(), "test_metric", now, frames, 1, map[string]string{"extra": "label"})
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("inserts points into a mysql table", func(t *testing.T) {
+		writer, mock := newTestSQLWriter(t, MySQLDialect, "recorded")
+		frames := valueFrames(map[string]float64{"a": math.Inf(1)})
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `recorded` (`time`, `metric`, `labels`, `value`) VALUES (?, ?, ?, ?)").
+			WithArgs(now.UTC(), "test_metric", `{"foo":"a"}`, nil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := writer.Write(context.Background(), "test_metric", now, frames, 1, nil)
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error when frames are empty", func(t *testing.T) {
+		writer, _ := newTestSQLWriter(t, PostgresDialect, "recorded")
+		err := writer.Write(context.Background(), "test_metric", now, data.Frames{data.NewFrame("test")}, 1, nil)
+		require.ErrorIs(t, err, ErrBadFrame)
+	})
+
+	t.Run("rolls back when the insert fails", func(t *testing.T) {
+		writer, mock := newTestSQLWriter(t, PostgresDialect, "recorded")
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO "recorded" ("time", "metric", "labels", "value") VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING`).
+			WillReturnError(&pq.Error{Code: "42P01", Message: `relation "recorded" does not exist`})
+		mock.ExpectRollback()
+
+		err := writer.Write(context.Background(), "test_metric", now, valueFrames(map[string]float64{"a": 1}), 1, nil)
+		require.ErrorIs(t, err, ErrRejectedWrite)
+		require.ErrorContains(t, err, `relation "recorded" does not exist`)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("requires a table", func(t *testing.T) {
+		_, err := NewSQLWriter(SQLWriterConfig{Dialect: PostgresDialect}, clock.New(), log.New("test"), nil)
+		require.EqualError(t, err, "a target table is required to write to a SQL data source")
+	})
+}
+
+func TestSQLDialect_InsertStatement(t *testing.T) {
+	require.Equal(t,
+		`INSERT INTO "recorded" ("time", "metric", "labels", "value") VALUES ($1, $2, $3, $4), ($5, $6, $7, $8) ON CONFLICT DO NOTHING`,
+		PostgresDialect.insertStatement(`"recorded"`, 2))
+	require.Equal(t,
+		"INSERT INTO `recorded` (`time`, `metric`, `labels`, `value`) VALUES (?, ?, ?, ?), (?, ?, ?, ?)",
+		MySQLDialect.insertStatement("`recorded`", 2))
+}
+
+func TestCheckSQLWriteError(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		err      error
+		expected error
+		ignored  bool
+	}{
+		{"postgres invalid password", &pq.Error{Code: "28P01"}, ErrDatasourceUnauthorized, false},
+		{"postgres insufficient privilege", &pq.Error{Code: "42501"}, ErrDatasourceForbidden, false},
+		{"postgres undefined column", &pq.Error{Code: "42703"}, ErrRejectedWrite, false},
+		{"postgres not null violation", &pq.Error{Code: "23502"}, ErrRejectedWrite, false},
+		{"postgres too many connections", &pq.Error{Code: "53300"}, ErrUnexpectedWriteFailure, false},
+		{"mysql duplicate entry", &mysql.MySQLError{Number: 1062}, nil, true},
+		{"mysql access denied", &mysql.MySQLError{Number: 1045}, ErrDatasourceUnauthorized, false},
+		{"mysql table access denied", &mysql.MySQLError{Number: 1142}, ErrDatasourceForbidden, false},
+		{"mysql no such table", &mysql.MySQLError{Number: 1146}, ErrRejectedWrite, false},
+		{"connection failure", errors.New("connection refused"), ErrUnexpectedWriteFailure, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err, ignored := checkSQLWriteError(tc.err)
+			require.Equal(t, tc.ignored, ignored)
+			if tc.expected == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
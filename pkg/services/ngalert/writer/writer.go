@@ -0,0 +1,33 @@
+package writer
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// Writer writes the result of a recording rule to a single target.
+type Writer interface {
+	Write(ctx context.Context, name string, t time.Time, frames data.Frames, orgID int64, extraLabels map[string]string) error
+}
+
+var (
+	_ Writer = PrometheusWriter{}
+	_ Writer = (*InfluxDBWriter)(nil)
+	_ Writer = (*SQLWriter)(nil)
+)
+
+// statusError is a write error carrying the status code of the target, so that it can be classified by checkWriteError.
+type statusError struct {
+	statusCode int
+	err        error
+}
+
+func (e statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e statusError) StatusCode() int {
+	return e.statusCode
+}
@@ -162,5 +162,9 @@ func (m *CachingMiddleware) CallResource(ctx context.Context, req *backend.CallR
 		return sender.Send(res)
 	})
 
-	return m.BaseHandler.CallResource(ctx, req, cacheSender)
+	err := m.BaseHandler.CallResource(ctx, req, cacheSender)
+	if err == nil && cr.FlushCacheFn != nil {
+		cr.FlushCacheFn(ctx)
+	}
+	return err
 }
@@ -178,7 +178,7 @@ func TestCachingMiddleware(t *testing.T) {
 
 		// This is the response returned by the HandleResourceRequest call
 		// Track whether the update cache fn was called, depending on what the response headers are in the cache request
-		var updateCacheCalled bool
+		var updateCacheCalled, flushCacheCalled bool
 		dataResponse := caching.CachedResourceDataResponse{
 			Response: &backend.CallResourceResponse{
 				Status: 200,
@@ -187,6 +187,9 @@ func TestCachingMiddleware(t *testing.T) {
 			UpdateCacheFn: func(ctx context.Context, rdr *backend.CallResourceResponse) {
 				updateCacheCalled = true
 			},
+			FlushCacheFn: func(ctx context.Context) {
+				flushCacheCalled = true
+			},
 		}
 
 		// This is the response sent via the passed-in sender when there is a cache miss
@@ -244,6 +247,7 @@ func TestCachingMiddleware(t *testing.T) {
 			assert.Equal(t, dataResponse.Response, sentResponse)
 			// Cache was not updated by the middleware
 			assert.False(t, updateCacheCalled)
+			assert.False(t, flushCacheCalled)
 		})
 
 		t.Run("If cache returns a miss, resource call is issued and the update cache function is called", func(t *testing.T) {
@@ -262,8 +266,9 @@ func TestCachingMiddleware(t *testing.T) {
 			// Simulated plugin response was sent
 			assert.NotNil(t, sentResponse)
 			assert.Equal(t, simulatedPluginResponse, sentResponse)
-			// Since it was a miss, the middleware called the update func
+			// Since it was a miss, the middleware called the update func, and the flush func once the call ended
 			assert.True(t, updateCacheCalled)
+			assert.True(t, flushCacheCalled)
 		})
 	})
 
@@ -0,0 +1,360 @@
+package clientmiddleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	limitDataSource = "datasource"
+	limitUser       = "user"
+
+	reasonConcurrency = "concurrency"
+	reasonRateLimit   = "rate_limit"
+
+	// limitersSweepInterval is how often limiters that have not been used since the previous sweep are removed.
+	limitersSweepInterval = 10 * time.Minute
+)
+
+// queryLimitError is returned when a request is rejected by a limit.
+type queryLimitError struct {
+	limit      string
+	reason     string
+	retryAfter time.Duration
+}
+
+func (e *queryLimitError) Error() string {
+	if e.reason == reasonConcurrency {
+		return fmt.Sprintf("too many concurrent requests to the %s", limitSubject(e.limit))
+	}
+	return fmt.Sprintf("too many requests to the %s, request rate limit exceeded", limitSubject(e.limit))
+}
+
+func newQueryLimitError(limit, reason string, retryAfter time.Duration) *queryLimitError {
+	return &queryLimitError{
+		limit:      limit,
+		reason:     reason,
+		retryAfter: retryAfter,
+	}
+}
+
+func limitSubject(limit string) string {
+	if limit == limitUser {
+		return "data sources by the user"
+	}
+	return "data source"
+}
+
+// queryLimitsMetrics contains the prometheus metrics used by the QueryLimitsMiddleware.
+type queryLimitsMetrics struct {
+	queued        *prometheus.GaugeVec
+	rejected      *prometheus.CounterVec
+	queueDuration *prometheus.HistogramVec
+}
+
+func newQueryLimitsMetrics(promRegisterer prometheus.Registerer) queryLimitsMetrics {
+	m := queryLimitsMetrics{
+		queued: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "grafana",
+			Name:      "plugin_requests_queued",
+			Help:      "The number of plugin requests waiting for a concurrency or rate limit",
+		}, []string{"plugin_id", "limit"}),
+		rejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "grafana",
+			Name:      "plugin_requests_rejected_total",
+			Help:      "The total amount of plugin requests rejected by a concurrency or rate limit",
+		}, []string{"plugin_id", "endpoint", "limit", "reason"}),
+		queueDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "grafana",
+			Name:      "plugin_request_queue_duration_seconds",
+			Help:      "Time plugin requests waited for the concurrency and rate limits",
+			Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 25},
+		}, []string{"plugin_id", "endpoint"}),
+	}
+	promRegisterer.MustRegister(m.queued, m.rejected, m.queueDuration)
+	return m
+}
+
+// limiter enforces a concurrency cap and a token bucket rate limit. A nil semaphore or rate limiter means no limit.
+type limiter struct {
+	sem  chan struct{}
+	rate *rate.Limiter
+
+	// inFlight and used are protected by the mutex of the queryLimiter.
+	inFlight int
+	used     bool
+}
+
+func newLimiter(maxConcurrent int, rps float64, burst int) *limiter {
+	l := &limiter{}
+	if maxConcurrent > 0 {
+		l.sem = make(chan struct{}, maxConcurrent)
+	}
+	if rps > 0 {
+		if burst <= 0 {
+			burst = int(math.Ceil(rps))
+		}
+		l.rate = rate.NewLimiter(rate.Limit(rps), burst)
+	}
+	return l
+}
+
+// queryLimiter holds the limiters of every data source and user. It is shared by all the handlers of the middleware.
+type queryLimiter struct {
+	cfg     setting.QueryLimitsSettings
+	metrics queryLimitsMetrics
+
+	mtx       sync.Mutex
+	limiters  map[string]*limiter
+	lastSweep time.Time
+}
+
+func (q *queryLimiter) get(key string, create func() *limiter) *limiter {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	now := time.Now()
+	if now.Sub(q.lastSweep) > limitersSweepInterval {
+		for k, l := range q.limiters {
+			if l.inFlight == 0 && !l.used {
+				delete(q.limiters, k)
+				continue
+			}
+			l.used = false
+		}
+		q.lastSweep = now
+	}
+
+	l, ok := q.limiters[key]
+	if !ok {
+		l = create()
+		q.limiters[key] = l
+	}
+	l.inFlight++
+	l.used = true
+	return l
+}
+
+func (q *queryLimiter) put(l *limiter) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	l.inFlight--
+}
+
+// acquire waits until the request is allowed by the user and data source limits, or until the queue timeout expires.
+// The returned function must be called once the request has completed.
+func (q *queryLimiter) acquire(ctx context.Context, pCtx backend.PluginContext) (func(), error) {
+	ds := pCtx.DataSourceInstanceSettings
+	deadline := time.Now().Add(q.cfg.QueueTimeout)
+
+	var releases []func()
+	release := func() {
+		for i := len(releases) - 1; i >= 0; i-- {
+			releases[i]()
+		}
+	}
+
+	if pCtx.User != nil && pCtx.User.Login != "" && (q.cfg.UserMaxConcurrentRequests > 0 || q.cfg.UserRequestsPerSecond > 0) {
+		key := fmt.Sprintf("%s/%d/%s", limitUser, pCtx.OrgID, pCtx.User.Login)
+		l := q.get(key, func() *limiter {
+			return newLimiter(q.cfg.UserMaxConcurrentRequests, q.cfg.UserRequestsPerSecond, q.cfg.UserBurst)
+		})
+		r, err := q.wait(ctx, l, pCtx.PluginID, limitUser, deadline)
+		if err != nil {
+			q.put(l)
+			return nil, err
+		}
+		releases = append(releases, r)
+	}
+
+	maxConcurrent, rps := q.cfg.DataSourceMaxConcurrentRequests, q.cfg.DataSourceRequestsPerSecond
+	if v, ok := q.cfg.DataSourceMaxConcurrentRequestsByUID[ds.UID]; ok {
+		maxConcurrent = v
+	}
+	if v, ok := q.cfg.DataSourceRequestsPerSecondByUID[ds.UID]; ok {
+		rps = v
+	}
+	if maxConcurrent > 0 || rps > 0 {
+		key := fmt.Sprintf("%s/%d/%s", limitDataSource, pCtx.OrgID, ds.UID)
+		l := q.get(key, func() *limiter {
+			return newLimiter(maxConcurrent, rps, q.cfg.DataSourceBurst)
+		})
+		r, err := q.wait(ctx, l, pCtx.PluginID, limitDataSource, deadline)
+		if err != nil {
+			q.put(l)
+			release()
+			return nil, err
+		}
+		releases = append(releases, r)
+	}
+
+	return release, nil
+}
+
+// wait waits for a token of the rate limiter and then for a slot of the semaphore of the limiter.
+func (q *queryLimiter) wait(ctx context.Context, l *limiter, pluginID, limit string, deadline time.Time) (func(), error) {
+	if l.rate != nil {
+		now := time.Now()
+		r := l.rate.ReserveN(now, 1)
+		if !r.OK() {
+			return nil, newQueryLimitError(limit, reasonRateLimit, time.Second)
+		}
+		if delay := r.DelayFrom(now); delay > 0 {
+			if now.Add(delay).After(deadline) {
+				r.CancelAt(now)
+				return nil, newQueryLimitError(limit, reasonRateLimit, delay)
+			}
+			if err := q.queue(ctx, pluginID, limit, delay, nil); err != nil {
+				r.Cancel()
+				return nil, err
+			}
+		}
+	}
+
+	if l.sem != nil {
+		select {
+		case l.sem <- struct{}{}:
+		default:
+			timeout := time.Until(deadline)
+			if timeout <= 0 {
+				return nil, newQueryLimitError(limit, reasonConcurrency, time.Second)
+			}
+			if err := q.queue(ctx, pluginID, limit, timeout, l.sem); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return func() {
+		if l.sem != nil {
+			<-l.sem
+		}
+		q.put(l)
+	}, nil
+}
+
+// queue waits for a slot of the semaphore if it is set, or for the timeout otherwise.
+func (q *queryLimiter) queue(ctx context.Context, pluginID, limit string, timeout time.Duration, sem chan struct{}) error {
+	queued := q.metrics.queued.WithLabelValues(pluginID, limit)
+	queued.Inc()
+	defer queued.Dec()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case sem <- struct{}{}:
+		return nil
+	case <-timer.C:
+		if sem != nil {
+			return newQueryLimitError(limit, reasonConcurrency, time.Second)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// NewQueryLimitsMiddleware creates a new backend.HandlerMiddleware that enforces per data source and per user
+// concurrency and rate limits on data source queries and resource calls. Requests that exceed a limit wait for up
+// to the queue timeout, and are then rejected with a 429 Too Many Requests status.
+func NewQueryLimitsMiddleware(cfg setting.QueryLimitsSettings, promRegisterer prometheus.Registerer) backend.HandlerMiddleware {
+	limiter := &queryLimiter{
+		cfg:       cfg,
+		metrics:   newQueryLimitsMetrics(promRegisterer),
+		limiters:  map[string]*limiter{},
+		lastSweep: time.Now(),
+	}
+	return backend.HandlerMiddlewareFunc(func(next backend.Handler) backend.Handler {
+		return &QueryLimitsMiddleware{
+			BaseHandler: backend.NewBaseHandler(next),
+			limiter:     limiter,
+		}
+	})
+}
+
+// QueryLimitsMiddleware is a middleware that limits the concurrency and the rate of data source requests.
+type QueryLimitsMiddleware struct {
+	backend.BaseHandler
+	limiter *queryLimiter
+}
+
+func (m *QueryLimitsMiddleware) acquire(ctx context.Context, pCtx backend.PluginContext, endpoint string) (func(), error) {
+	start := time.Now()
+	release, err := m.limiter.acquire(ctx, pCtx)
+	if err != nil {
+		if limitErr, ok := err.(*queryLimitError); ok {
+			m.limiter.metrics.rejected.WithLabelValues(pCtx.PluginID, endpoint, limitErr.limit, limitErr.reason).Inc()
+		}
+		return nil, err
+	}
+	m.limiter.metrics.queueDuration.WithLabelValues(pCtx.PluginID, endpoint).Observe(time.Since(start).Seconds())
+	return release, nil
+}
+
+func (m *QueryLimitsMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	if req == nil || req.PluginContext.DataSourceInstanceSettings == nil {
+		return m.BaseHandler.QueryData(ctx, req)
+	}
+
+	release, err := m.acquire(ctx, req.PluginContext, "queryData")
+	if err != nil {
+		limitErr, ok := err.(*queryLimitError)
+		if !ok {
+			return nil, err
+		}
+		resp := backend.NewQueryDataResponse()
+		for _, q := range req.Queries {
+			resp.Responses[q.RefID] = backend.DataResponse{
+				Error:  limitErr,
+				Status: backend.StatusTooManyRequests,
+			}
+		}
+		return resp, nil
+	}
+	defer release()
+
+	return m.BaseHandler.QueryData(ctx, req)
+}
+
+func (m *QueryLimitsMiddleware) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	if req == nil || req.PluginContext.DataSourceInstanceSettings == nil {
+		return m.BaseHandler.CallResource(ctx, req, sender)
+	}
+
+	release, err := m.acquire(ctx, req.PluginContext, "callResource")
+	if err != nil {
+		limitErr, ok := err.(*queryLimitError)
+		if !ok {
+			return err
+		}
+		body, err := json.Marshal(map[string]string{"message": limitErr.Error()})
+		if err != nil {
+			return err
+		}
+		retryAfter := int(math.Ceil(limitErr.retryAfter.Seconds()))
+		return sender.Send(&backend.CallResourceResponse{
+			Status: http.StatusTooManyRequests,
+			Headers: map[string][]string{
+				"Content-Type": {"application/json"},
+				"Retry-After":  {strconv.Itoa(max(retryAfter, 1))},
+			},
+			Body: body,
+		})
+	}
+	defer release()
+
+	return m.BaseHandler.CallResource(ctx, req, sender)
+}
@@ -0,0 +1,215 @@
+package clientmiddleware
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/handlertest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestQueryLimitsMiddleware(t *testing.T) {
+	pCtx := func(dsUID, login string) backend.PluginContext {
+		return backend.PluginContext{
+			OrgID:                      1,
+			PluginID:                   pluginID,
+			User:                       &backend.User{Login: login},
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: dsUID},
+		}
+	}
+	queryReq := func(pCtx backend.PluginContext) *backend.QueryDataRequest {
+		return &backend.QueryDataRequest{PluginContext: pCtx, Queries: []backend.DataQuery{{RefID: "A"}, {RefID: "B"}}}
+	}
+
+	// setup returns a handler whose requests are blocked until the returned channel is closed or receives a value.
+	setup := func(t *testing.T, cfg setting.QueryLimitsSettings) (backend.Handler, *prometheus.Registry, chan struct{}, chan struct{}) {
+		t.Helper()
+		started := make(chan struct{}, 10)
+		unblock := make(chan struct{})
+		next := handlertest.Handler{
+			QueryDataFunc: func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+				started <- struct{}{}
+				<-unblock
+				return backend.NewQueryDataResponse(), nil
+			},
+			CallResourceFunc: func(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+				started <- struct{}{}
+				<-unblock
+				return sender.Send(&backend.CallResourceResponse{Status: http.StatusOK})
+			},
+		}
+		registry := prometheus.NewRegistry()
+		return NewQueryLimitsMiddleware(cfg, registry).CreateHandlerMiddleware(next), registry, started, unblock
+	}
+
+	requireRejected := func(t *testing.T, resp *backend.QueryDataResponse, msg string) {
+		t.Helper()
+		require.Len(t, resp.Responses, 2)
+		for _, r := range resp.Responses {
+			require.Equal(t, backend.StatusTooManyRequests, r.Status)
+			require.EqualError(t, r.Error, msg)
+		}
+	}
+
+	t.Run("should reject concurrent requests to a data source above the limit", func(t *testing.T) {
+		handler, registry, started, unblock := setup(t, setting.QueryLimitsSettings{DataSourceMaxConcurrentRequests: 1})
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _ = handler.QueryData(context.Background(), queryReq(pCtx("ds-1", "user-1")))
+		}()
+		<-started
+
+		resp, err := handler.QueryData(context.Background(), queryReq(pCtx("ds-1", "user-2")))
+		require.NoError(t, err)
+		requireRejected(t, resp, "too many concurrent requests to the data source")
+
+		// Other data sources are not limited.
+		unblock <- struct{}{}
+		<-done
+		close(unblock)
+		_, err = handler.QueryData(context.Background(), queryReq(pCtx("ds-2", "user-2")))
+		require.NoError(t, err)
+
+		count, err := testutil.GatherAndCount(registry, "grafana_plugin_requests_rejected_total")
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+		require.Equal(t, 1.0, testutil.ToFloat64(getRejected(t, handler, "queryData", limitDataSource, reasonConcurrency)))
+	})
+
+	t.Run("should queue requests until the queue timeout", func(t *testing.T) {
+		handler, _, started, unblock := setup(t, setting.QueryLimitsSettings{DataSourceMaxConcurrentRequests: 1, QueueTimeout: time.Minute})
+		limiter := handler.(*QueryLimitsMiddleware).limiter
+
+		go func() {
+			_, _ = handler.QueryData(context.Background(), queryReq(pCtx("ds-1", "user-1")))
+		}()
+		<-started
+
+		done := make(chan *backend.QueryDataResponse)
+		go func() {
+			resp, _ := handler.QueryData(context.Background(), queryReq(pCtx("ds-1", "user-2")))
+			done <- resp
+		}()
+		require.Eventually(t, func() bool {
+			return testutil.ToFloat64(limiter.metrics.queued.WithLabelValues(pluginID, limitDataSource)) == 1
+		}, time.Second, 10*time.Millisecond)
+
+		close(unblock)
+		resp := <-done
+		require.Empty(t, resp.Responses)
+		require.Equal(t, 0.0, testutil.ToFloat64(limiter.metrics.queued.WithLabelValues(pluginID, limitDataSource)))
+	})
+
+	t.Run("should stop waiting when the request is canceled", func(t *testing.T) {
+		handler, _, started, unblock := setup(t, setting.QueryLimitsSettings{DataSourceMaxConcurrentRequests: 1, QueueTimeout: time.Minute})
+		defer close(unblock)
+
+		go func() {
+			_, _ = handler.QueryData(context.Background(), queryReq(pCtx("ds-1", "user-1")))
+		}()
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := handler.QueryData(ctx, queryReq(pCtx("ds-1", "user-2")))
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("should rate limit requests to a data source", func(t *testing.T) {
+		handler, _, _, unblock := setup(t, setting.QueryLimitsSettings{
+			DataSourceRequestsPerSecondByUID: map[string]float64{"ds-1": 0.1},
+		})
+		close(unblock)
+
+		_, err := handler.QueryData(context.Background(), queryReq(pCtx("ds-1", "user-1")))
+		require.NoError(t, err)
+
+		resp, err := handler.QueryData(context.Background(), queryReq(pCtx("ds-1", "user-1")))
+		require.NoError(t, err)
+		requireRejected(t, resp, "too many requests to the data source, request rate limit exceeded")
+
+		var res *backend.CallResourceResponse
+		err = handler.CallResource(context.Background(), &backend.CallResourceRequest{PluginContext: pCtx("ds-1", "user-1")}, backend.CallResourceResponseSenderFunc(func(r *backend.CallResourceResponse) error {
+			res = r
+			return nil
+		}))
+		require.NoError(t, err)
+		require.NotNil(t, res)
+		require.Equal(t, http.StatusTooManyRequests, res.Status)
+		require.Equal(t, []string{"10"}, res.Headers["Retry-After"])
+		require.JSONEq(t, `{"message": "too many requests to the data source, request rate limit exceeded"}`, string(res.Body))
+		require.Equal(t, 1.0, testutil.ToFloat64(getRejected(t, handler, "callResource", limitDataSource, reasonRateLimit)))
+
+		// The override only applies to its data source.
+		_, err = handler.QueryData(context.Background(), queryReq(pCtx("ds-2", "user-1")))
+		require.NoError(t, err)
+		_, err = handler.QueryData(context.Background(), queryReq(pCtx("ds-2", "user-1")))
+		require.NoError(t, err)
+	})
+
+	t.Run("should limit the requests of a user to all data sources", func(t *testing.T) {
+		handler, _, started, unblock := setup(t, setting.QueryLimitsSettings{UserMaxConcurrentRequests: 1})
+		defer close(unblock)
+
+		go func() {
+			_, _ = handler.QueryData(context.Background(), queryReq(pCtx("ds-1", "user-1")))
+		}()
+		<-started
+
+		resp, err := handler.QueryData(context.Background(), queryReq(pCtx("ds-2", "user-1")))
+		require.NoError(t, err)
+		requireRejected(t, resp, "too many concurrent requests to the data sources by the user")
+
+		go func() {
+			_, _ = handler.QueryData(context.Background(), queryReq(pCtx("ds-2", "user-2")))
+		}()
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatal("the requests of other users should not be limited")
+		}
+	})
+
+	t.Run("should not limit requests that are not for a data source", func(t *testing.T) {
+		handler, _, _, unblock := setup(t, setting.QueryLimitsSettings{UserMaxConcurrentRequests: 1, UserRequestsPerSecond: 0.1})
+		close(unblock)
+
+		for i := 0; i < 3; i++ {
+			err := handler.CallResource(context.Background(), &backend.CallResourceRequest{PluginContext: backend.PluginContext{PluginID: pluginID, User: &backend.User{Login: "user-1"}}}, nopCallResourceSender)
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("should remove idle limiters", func(t *testing.T) {
+		handler, _, _, unblock := setup(t, setting.QueryLimitsSettings{DataSourceMaxConcurrentRequests: 1})
+		close(unblock)
+		limiter := handler.(*QueryLimitsMiddleware).limiter
+
+		_, err := handler.QueryData(context.Background(), queryReq(pCtx("ds-1", "user-1")))
+		require.NoError(t, err)
+		require.Len(t, limiter.limiters, 1)
+
+		// The first sweep marks the limiter as unused, the second one removes it.
+		for i := 0; i < 2; i++ {
+			limiter.lastSweep = time.Now().Add(-2 * limitersSweepInterval)
+			_, err = handler.QueryData(context.Background(), queryReq(pCtx("ds-2", "user-1")))
+			require.NoError(t, err)
+		}
+		require.Len(t, limiter.limiters, 1)
+		require.Contains(t, limiter.limiters, "datasource/1/ds-2")
+	})
+}
+
+func getRejected(t *testing.T, handler backend.Handler, endpoint, limit, reason string) prometheus.Counter {
+	t.Helper()
+	return handler.(*QueryLimitsMiddleware).limiter.metrics.rejected.WithLabelValues(pluginID, endpoint, limit, reason)
+}
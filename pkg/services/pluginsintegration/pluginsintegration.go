@@ -198,6 +198,14 @@ func CreateMiddlewares(cfg *setting.Cfg, oAuthTokenService oauthtoken.OAuthToken
 		clientmiddleware.NewOAuthTokenMiddleware(oAuthTokenService),
 		clientmiddleware.NewCookiesMiddleware(skipCookiesNames),
 		clientmiddleware.NewCachingMiddlewareWithFeatureManager(cachingService, features),
+	)
+
+	// Requests served from the cache are not limited.
+	if cfg.QueryLimits.Enabled {
+		middlewares = append(middlewares, clientmiddleware.NewQueryLimitsMiddleware(cfg.QueryLimits, promRegisterer))
+	}
+
+	middlewares = append(middlewares,
 		clientmiddleware.NewForwardIDMiddleware(),
 		clientmiddleware.NewUseAlertHeadersMiddleware(),
 	)
@@ -0,0 +1,117 @@
+package reports
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+)
+
+var (
+	ErrReportNotFound       = errutil.NotFound("reports.not-found", errutil.WithPublicMessage("Report not found"))
+	ErrInvalidReport        = errutil.ValidationFailed("reports.invalid")
+	ErrDashboardNotFound    = errutil.NotFound("reports.dashboard-not-found", errutil.WithPublicMessage("Dashboard not found"))
+	ErrDashboardNotReadable = errutil.Forbidden("reports.dashboard-forbidden", errutil.WithPublicMessage("You do not have access to the dashboard of the report"))
+)
+
+type Format string
+
+const (
+	FormatPDF Format = "pdf"
+	FormatPNG Format = "png"
+)
+
+// TimeRange is the time range of the dashboard in a report, with the syntax of the
+// dashboard time picker, for example "now-7d" and "now".
+type TimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Schedule is when a report is sent: a cron expression with five fields or a descriptor such
+// as @daily, evaluated in a time zone.
+type Schedule struct {
+	Cron string `json:"cron"`
+	// Timezone is an IANA time zone name. The default is UTC.
+	Timezone string `json:"timezone,omitempty"`
+}
+
+type Report struct {
+	ID           int64               `json:"id"`
+	UID          string              `json:"uid"`
+	OrgID        int64               `json:"orgId"`
+	Name         string              `json:"name"`
+	DashboardUID string              `json:"dashboardUid"`
+	Formats      []Format            `json:"formats"`
+	TimeRange    TimeRange           `json:"timeRange"`
+	Variables    map[string][]string `json:"variables,omitempty"`
+	Recipients   []string            `json:"recipients"`
+	ReplyTo      string              `json:"replyTo,omitempty"`
+	Subject      string              `json:"subject,omitempty"`
+	Message      string              `json:"message,omitempty"`
+	Schedule     Schedule            `json:"schedule"`
+	Enabled      bool                `json:"enabled"`
+	// NextRun is when the report is sent next. It is zero when the report is disabled.
+	NextRun   time.Time `json:"nextRun,omitempty"`
+	CreatedBy int64     `json:"createdBy"`
+	// UpdatedBy is the user who saved the report last. The report is rendered with their permissions.
+	UpdatedBy int64     `json:"updatedBy"`
+	Created   time.Time `json:"created"`
+	Updated   time.Time `json:"updated"`
+}
+
+// ReportSpec is the part of a report that is set by its users.
+type ReportSpec struct {
+	Name         string              `json:"name"`
+	DashboardUID string              `json:"dashboardUid"`
+	Formats      []Format            `json:"formats"`
+	TimeRange    TimeRange           `json:"timeRange"`
+	Variables    map[string][]string `json:"variables,omitempty"`
+	Recipients   []string            `json:"recipients"`
+	ReplyTo      string              `json:"replyTo,omitempty"`
+	Subject      string              `json:"subject,omitempty"`
+	Message      string              `json:"message,omitempty"`
+	Schedule     Schedule            `json:"schedule"`
+	Enabled      bool                `json:"enabled"`
+}
+
+type CreateReportCommand struct {
+	ReportSpec
+	OrgID     int64 `json:"-"`
+	CreatedBy int64 `json:"-"`
+}
+
+type UpdateReportCommand struct {
+	ReportSpec
+	UID       string `json:"-"`
+	OrgID     int64  `json:"-"`
+	UpdatedBy int64  `json:"-"`
+}
+
+type RunStatus string
+
+const (
+	RunStatusSuccess RunStatus = "success"
+	RunStatusFailed  RunStatus = "failed"
+)
+
+type RunTrigger string
+
+const (
+	RunTriggerSchedule RunTrigger = "schedule"
+	RunTriggerManual   RunTrigger = "manual"
+)
+
+// Run is an attempt to send a report, which is part of the send history of the report.
+type Run struct {
+	ID       int64      `json:"id"`
+	ReportID int64      `json:"reportId"`
+	OrgID    int64      `json:"orgId"`
+	Trigger  RunTrigger `json:"trigger"`
+	Status   RunStatus  `json:"status"`
+	// Attempts is the number of times the report was rendered and sent, including retries.
+	Attempts   int       `json:"attempts"`
+	Recipients int       `json:"recipients"`
+	Error      string    `json:"error,omitempty"`
+	Started    time.Time `json:"started"`
+	Finished   time.Time `json:"finished"`
+}
@@ -0,0 +1,17 @@
+package reports
+
+import (
+	"context"
+)
+
+type Service interface {
+	CreateReport(ctx context.Context, cmd *CreateReportCommand) (*Report, error)
+	UpdateReport(ctx context.Context, cmd *UpdateReportCommand) (*Report, error)
+	DeleteReport(ctx context.Context, orgID int64, uid string) error
+	GetReport(ctx context.Context, orgID int64, uid string) (*Report, error)
+	GetReports(ctx context.Context, orgID int64) ([]*Report, error)
+	// GetRuns returns the send history of a report, newest first.
+	GetRuns(ctx context.Context, orgID int64, uid string) ([]*Run, error)
+	// SendReport sends a report immediately, regardless of its schedule.
+	SendReport(ctx context.Context, orgID int64, uid string) (*Run, error)
+}
@@ -0,0 +1,110 @@
+package reportsimpl
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/middleware"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/reports"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints(routeRegister routing.RouteRegister) {
+	authorize := ac.Middleware(s.accessControl)
+
+	routeRegister.Group("/api/reports", func(route routing.RouteRegister) {
+		route.Get("/", middleware.ReqSignedIn, authorize(ac.EvalPermission(ActionRead)), routing.Wrap(s.listHandler))
+		route.Post("/", middleware.ReqSignedIn, authorize(ac.EvalPermission(ActionCreate)), routing.Wrap(s.createHandler))
+		route.Get("/:uid", middleware.ReqSignedIn, authorize(ac.EvalPermission(ActionRead)), routing.Wrap(s.getHandler))
+		route.Put("/:uid", middleware.ReqSignedIn, authorize(ac.EvalPermission(ActionWrite)), routing.Wrap(s.updateHandler))
+		route.Delete("/:uid", middleware.ReqSignedIn, authorize(ac.EvalPermission(ActionDelete)), routing.Wrap(s.deleteHandler))
+		route.Get("/:uid/history", middleware.ReqSignedIn, authorize(ac.EvalPermission(ActionRead)), routing.Wrap(s.historyHandler))
+		route.Post("/:uid/send", middleware.ReqSignedIn, authorize(ac.EvalPermission(ActionSend)), routing.Wrap(s.sendHandler))
+	})
+}
+
+func (s *Service) listHandler(c *contextmodel.ReqContext) response.Response {
+	result, err := s.GetReports(c.Req.Context(), c.SignedInUser.GetOrgID())
+	if err != nil {
+		return response.Err(err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+func (s *Service) getHandler(c *contextmodel.ReqContext) response.Response {
+	result, err := s.GetReport(c.Req.Context(), c.SignedInUser.GetOrgID(), web.Params(c.Req)[":uid"])
+	if err != nil {
+		return response.Err(err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// createHandler creates a report. The report is rendered with the permissions of the user who creates it.
+func (s *Service) createHandler(c *contextmodel.ReqContext) response.Response {
+	userID, err := identity.UserIdentifier(c.GetID())
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Reports can only be created by users and service accounts", err)
+	}
+	cmd := reports.CreateReportCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.OrgID = c.SignedInUser.GetOrgID()
+	cmd.CreatedBy = userID
+
+	result, err := s.CreateReport(c.Req.Context(), &cmd)
+	if err != nil {
+		return response.Err(err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// updateHandler updates a report. The report is rendered with the permissions of the user who updates it from then on.
+func (s *Service) updateHandler(c *contextmodel.ReqContext) response.Response {
+	userID, err := identity.UserIdentifier(c.GetID())
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Reports can only be updated by users and service accounts", err)
+	}
+	cmd := reports.UpdateReportCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	cmd.OrgID = c.SignedInUser.GetOrgID()
+	cmd.UID = web.Params(c.Req)[":uid"]
+	cmd.UpdatedBy = userID
+
+	result, err := s.UpdateReport(c.Req.Context(), &cmd)
+	if err != nil {
+		return response.Err(err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+func (s *Service) deleteHandler(c *contextmodel.ReqContext) response.Response {
+	if err := s.DeleteReport(c.Req.Context(), c.SignedInUser.GetOrgID(), web.Params(c.Req)[":uid"]); err != nil {
+		return response.Err(err)
+	}
+	return response.Success("Report deleted")
+}
+
+func (s *Service) historyHandler(c *contextmodel.ReqContext) response.Response {
+	result, err := s.GetRuns(c.Req.Context(), c.SignedInUser.GetOrgID(), web.Params(c.Req)[":uid"])
+	if err != nil {
+		return response.Err(err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// sendHandler sends a report immediately and returns the result. A report that fails to send is
+// not an error of the request, the error is in the returned run.
+func (s *Service) sendHandler(c *contextmodel.ReqContext) response.Response {
+	result, err := s.SendReport(c.Req.Context(), c.SignedInUser.GetOrgID(), web.Params(c.Req)[":uid"])
+	if err != nil {
+		return response.Err(err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
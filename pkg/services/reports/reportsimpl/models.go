@@ -0,0 +1,53 @@
+package reportsimpl
+
+import (
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/org"
+)
+
+const (
+	ActionRead   = "reports:read"
+	ActionCreate = "reports:create"
+	ActionWrite  = "reports:write"
+	ActionDelete = "reports:delete"
+	ActionSend   = "reports:send"
+)
+
+var (
+	reportsReaderRole = accesscontrol.RoleDTO{
+		Name:        "fixed:reports:reader",
+		DisplayName: "Reader",
+		Description: "Read all reports and their send history",
+		Group:       "Reports",
+		Permissions: []accesscontrol.Permission{
+			{Action: ActionRead},
+		},
+	}
+
+	reportsWriterRole = accesscontrol.RoleDTO{
+		Name:        "fixed:reports:writer",
+		DisplayName: "Writer",
+		Description: "Create, read, update, delete and send all reports",
+		Group:       "Reports",
+		Permissions: []accesscontrol.Permission{
+			{Action: ActionRead},
+			{Action: ActionCreate},
+			{Action: ActionWrite},
+			{Action: ActionDelete},
+			{Action: ActionSend},
+		},
+	}
+)
+
+func declareFixedRoles(ac accesscontrol.Service) error {
+	return ac.DeclareFixedRoles(
+		accesscontrol.RoleRegistration{
+			Role:   reportsReaderRole,
+			Grants: []string{string(org.RoleAdmin)},
+		},
+		accesscontrol.RoleRegistration{
+			Role:   reportsWriterRole,
+			Grants: []string{string(org.RoleAdmin)},
+		},
+	)
+}
@@ -0,0 +1,279 @@
+package reportsimpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/reports"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	// schedulerInterval is how often the scheduler looks for reports that are due.
+	schedulerInterval = 30 * time.Second
+	// lockMaxInterval is the time after which the lock of a report that is being sent is considered
+	// abandoned. It has to be longer than sending a report can take, including the retries.
+	lockMaxInterval = time.Hour
+)
+
+var _ reports.Service = (*Service)(nil)
+
+type Service struct {
+	cfg               *setting.Cfg
+	store             *store
+	serverLockService *serverlock.ServerLockService
+	renderService     rendering.Service
+	emailSender       notifications.EmailSender
+	dashboardService  dashboards.DashboardService
+	accessControl     ac.AccessControl
+	log               log.Logger
+	now               func() time.Time
+}
+
+func ProvideService(
+	cfg *setting.Cfg,
+	sqlStore db.DB,
+	routeRegister routing.RouteRegister,
+	serverLockService *serverlock.ServerLockService,
+	renderService rendering.Service,
+	notificationService notifications.Service,
+	dashboardService dashboards.DashboardService,
+	accessControl ac.AccessControl,
+	accesscontrolService ac.Service,
+) (*Service, error) {
+	s := &Service{
+		cfg:               cfg,
+		store:             &store{db: sqlStore},
+		serverLockService: serverLockService,
+		renderService:     renderService,
+		emailSender:       notificationService,
+		dashboardService:  dashboardService,
+		accessControl:     accessControl,
+		log:               log.New("reports"),
+		now:               time.Now,
+	}
+	if !cfg.Reports.Enabled {
+		return s, nil
+	}
+
+	if err := declareFixedRoles(accesscontrolService); err != nil {
+		return nil, err
+	}
+	s.registerAPIEndpoints(routeRegister)
+
+	return s, nil
+}
+
+func (s *Service) IsDisabled() bool {
+	return !s.cfg.Reports.Enabled
+}
+
+// Run sends the reports that are due until the context is cancelled.
+func (s *Service) Run(ctx context.Context) error {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.sendDueReports(ctx)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// sendDueReports sends the reports whose next run is due. Every report is sent under a server lock,
+// so that only one instance of a high availability setup sends it.
+func (s *Service) sendDueReports(ctx context.Context) {
+	due, err := s.store.listDue(ctx, s.now())
+	if err != nil {
+		s.log.Error("Failed to get the reports that are due", "error", err)
+		return
+	}
+
+	for _, r := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		lockName := fmt.Sprintf("report-%d", r.ID)
+		err := s.serverLockService.LockExecuteAndRelease(ctx, lockName, lockMaxInterval, func(ctx context.Context) {
+			s.sendScheduled(ctx, r)
+		})
+		var lockedErr *serverlock.ServerLockExistsError
+		if err != nil && !errors.As(err, &lockedErr) {
+			s.log.Error("Failed to lock report", "org", r.OrgID, "uid", r.UID, "error", err)
+		}
+	}
+}
+
+// sendScheduled claims the scheduled run of a report by moving its next run forward, and sends it.
+// A report that another instance has already sent, or that was changed in the meantime, is skipped.
+func (s *Service) sendScheduled(ctx context.Context, r *reports.Report) {
+	next, err := r.Schedule.Next(s.now())
+	if err != nil {
+		// The schedule was valid when the report was saved, but time zones can disappear.
+		s.log.Error("Failed to schedule report, disabling it", "org", r.OrgID, "uid", r.UID, "error", err)
+		next = time.Time{}
+	}
+	claimed, err := s.store.updateNextRun(ctx, r.ID, r.NextRun, next)
+	if err != nil {
+		s.log.Error("Failed to schedule the next run of report", "org", r.OrgID, "uid", r.UID, "error", err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	s.log.Info("Sending scheduled report", "org", r.OrgID, "uid", r.UID, "recipients", len(r.Recipients))
+	run := s.sendWithRetries(ctx, r, reports.RunTriggerSchedule, s.cfg.Reports.MaxRetries)
+	s.saveRun(ctx, run)
+}
+
+func (s *Service) saveRun(ctx context.Context, run *reports.Run) {
+	// The history is saved even when the service is stopping.
+	if err := s.store.insertRun(context.WithoutCancel(ctx), run, s.cfg.Reports.HistoryLimit); err != nil {
+		s.log.Error("Failed to save the history of report", "org", run.OrgID, "report", run.ReportID, "error", err)
+	}
+}
+
+func (s *Service) CreateReport(ctx context.Context, cmd *reports.CreateReportCommand) (*reports.Report, error) {
+	if err := s.validate(ctx, cmd.OrgID, &cmd.ReportSpec); err != nil {
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	r := &reports.Report{
+		UID:       util.GenerateShortUID(),
+		OrgID:     cmd.OrgID,
+		CreatedBy: cmd.CreatedBy,
+		UpdatedBy: cmd.CreatedBy,
+		Created:   now,
+	}
+	if err := s.apply(r, &cmd.ReportSpec, now); err != nil {
+		return nil, err
+	}
+	if err := s.store.insert(ctx, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (s *Service) UpdateReport(ctx context.Context, cmd *reports.UpdateReportCommand) (*reports.Report, error) {
+	r, err := s.store.get(ctx, cmd.OrgID, cmd.UID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validate(ctx, cmd.OrgID, &cmd.ReportSpec); err != nil {
+		return nil, err
+	}
+	if err := s.apply(r, &cmd.ReportSpec, s.now().UTC()); err != nil {
+		return nil, err
+	}
+	// The editor has been checked to read the dashboard, and the report is rendered with their permissions from now on.
+	r.UpdatedBy = cmd.UpdatedBy
+	if err := s.store.update(ctx, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// validate checks that the spec is valid and that the user saving it can read its dashboard.
+func (s *Service) validate(ctx context.Context, orgID int64, spec *reports.ReportSpec) error {
+	if err := spec.Validate(); err != nil {
+		return err
+	}
+
+	_, err := s.dashboardService.GetDashboard(ctx, &dashboards.GetDashboardQuery{OrgID: orgID, UID: spec.DashboardUID})
+	if err != nil {
+		if errors.Is(err, dashboards.ErrDashboardNotFound) {
+			return reports.ErrDashboardNotFound.Errorf("dashboard %s not found", spec.DashboardUID)
+		}
+		return err
+	}
+
+	user, err := identity.GetRequester(ctx)
+	if err != nil {
+		return err
+	}
+	scope := dashboards.ScopeDashboardsProvider.GetResourceScopeUID(spec.DashboardUID)
+	ok, err := s.accessControl.Evaluate(ctx, user, ac.EvalPermission(dashboards.ActionDashboardsRead, scope))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return reports.ErrDashboardNotReadable.Errorf("user cannot read dashboard %s", spec.DashboardUID)
+	}
+	return nil
+}
+
+// apply sets the spec of a report and schedules its next run.
+func (s *Service) apply(r *reports.Report, spec *reports.ReportSpec, now time.Time) error {
+	r.Name = spec.Name
+	r.DashboardUID = spec.DashboardUID
+	r.Formats = spec.Formats
+	r.TimeRange = spec.TimeRange
+	r.Variables = spec.Variables
+	r.Recipients = spec.Recipients
+	r.ReplyTo = spec.ReplyTo
+	r.Subject = spec.Subject
+	r.Message = spec.Message
+	r.Schedule = spec.Schedule
+	r.Enabled = spec.Enabled
+	r.Updated = now
+
+	r.NextRun = time.Time{}
+	if r.Enabled {
+		next, err := r.Schedule.Next(now)
+		if err != nil {
+			return reports.ErrInvalidReport.Errorf("invalid schedule: %w", err)
+		}
+		r.NextRun = next
+	}
+	return nil
+}
+
+func (s *Service) DeleteReport(ctx context.Context, orgID int64, uid string) error {
+	return s.store.delete(ctx, orgID, uid)
+}
+
+func (s *Service) GetReport(ctx context.Context, orgID int64, uid string) (*reports.Report, error) {
+	return s.store.get(ctx, orgID, uid)
+}
+
+func (s *Service) GetReports(ctx context.Context, orgID int64) ([]*reports.Report, error) {
+	return s.store.list(ctx, orgID)
+}
+
+func (s *Service) GetRuns(ctx context.Context, orgID int64, uid string) ([]*reports.Run, error) {
+	r, err := s.store.get(ctx, orgID, uid)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.listRuns(ctx, r.ID)
+}
+
+func (s *Service) SendReport(ctx context.Context, orgID int64, uid string) (*reports.Run, error) {
+	r, err := s.store.get(ctx, orgID, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reports sent on demand are not retried, the user is waiting for the result.
+	s.log.Info("Sending report", "org", r.OrgID, "uid", r.UID, "recipients", len(r.Recipients))
+	run := s.sendWithRetries(ctx, r, reports.RunTriggerManual, 0)
+	s.saveRun(ctx, run)
+	return run, nil
+}
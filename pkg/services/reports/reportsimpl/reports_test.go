@@ -0,0 +1,214 @@
+package reportsimpl
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/reports"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tests/testsuite"
+)
+
+func TestMain(m *testing.M) {
+	testsuite.Run(m)
+}
+
+func newReport(name string, nextRun time.Time) *reports.Report {
+	return &reports.Report{
+		UID:          name,
+		OrgID:        1,
+		Name:         name,
+		DashboardUID: "dash",
+		Formats:      []reports.Format{reports.FormatPDF, reports.FormatPNG},
+		TimeRange:    reports.TimeRange{From: "now-7d", To: "now"},
+		Variables:    map[string][]string{"host": {"a", "b"}},
+		Recipients:   []string{"ops@example.com"},
+		Schedule:     reports.Schedule{Cron: "0 8 * * *", Timezone: "Europe/Paris"},
+		Enabled:      !nextRun.IsZero(),
+		NextRun:      nextRun,
+		CreatedBy:    1,
+		UpdatedBy:    1,
+		Created:      time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC),
+		Updated:      time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestIntegrationStore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	s := &store{db: db.InitTestDB(t)}
+	ctx := context.Background()
+	now := time.Date(2024, time.May, 2, 8, 0, 0, 0, time.UTC)
+
+	due := newReport("due", now.Add(-time.Minute))
+	later := newReport("later", now.Add(time.Hour))
+	disabled := newReport("disabled", time.Time{})
+	for _, r := range []*reports.Report{due, later, disabled} {
+		require.NoError(t, s.insert(ctx, r))
+	}
+
+	t.Run("get returns the saved report", func(t *testing.T) {
+		r, err := s.get(ctx, 1, "due")
+		require.NoError(t, err)
+		assert.Equal(t, due, r)
+
+		_, err = s.get(ctx, 2, "due")
+		require.ErrorIs(t, err, reports.ErrReportNotFound)
+	})
+
+	t.Run("listDue returns the enabled reports that are due", func(t *testing.T) {
+		result, err := s.listDue(ctx, now)
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, "due", result[0].UID)
+	})
+
+	t.Run("updateNextRun claims a run only once", func(t *testing.T) {
+		next := now.Add(24 * time.Hour)
+		claimed, err := s.updateNextRun(ctx, due.ID, due.NextRun, next)
+		require.NoError(t, err)
+		assert.True(t, claimed)
+
+		claimed, err = s.updateNextRun(ctx, due.ID, due.NextRun, next)
+		require.NoError(t, err)
+		assert.False(t, claimed)
+
+		result, err := s.listDue(ctx, now)
+		require.NoError(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("update keeps the identity of the report and changes its owner", func(t *testing.T) {
+		changed := *later
+		changed.Name = "renamed"
+		changed.CreatedBy = 42
+		changed.UpdatedBy = 43
+		require.NoError(t, s.update(ctx, &changed))
+
+		r, err := s.get(ctx, 1, "later")
+		require.NoError(t, err)
+		assert.Equal(t, "renamed", r.Name)
+		assert.Equal(t, int64(1), r.CreatedBy)
+		assert.Equal(t, int64(43), r.UpdatedBy)
+	})
+
+	t.Run("insertRun keeps the newest runs", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			run := &reports.Run{ReportID: due.ID, OrgID: 1, Trigger: reports.RunTriggerSchedule, Status: reports.RunStatusSuccess, Attempts: i + 1, Started: now.Add(time.Duration(i) * time.Minute), Finished: now}
+			require.NoError(t, s.insertRun(ctx, run, 3))
+		}
+		runs, err := s.listRuns(ctx, due.ID)
+		require.NoError(t, err)
+		require.Len(t, runs, 3)
+		assert.Equal(t, 5, runs[0].Attempts)
+		assert.Equal(t, 3, runs[2].Attempts)
+	})
+
+	t.Run("delete removes the report and its runs", func(t *testing.T) {
+		require.NoError(t, s.delete(ctx, 1, "due"))
+		_, err := s.get(ctx, 1, "due")
+		require.ErrorIs(t, err, reports.ErrReportNotFound)
+		runs, err := s.listRuns(ctx, due.ID)
+		require.NoError(t, err)
+		assert.Empty(t, runs)
+
+		require.ErrorIs(t, s.delete(ctx, 1, "due"), reports.ErrReportNotFound)
+	})
+}
+
+func TestDashboardPath(t *testing.T) {
+	r := newReport("r", time.Time{})
+	dash := &dashboards.Dashboard{UID: "dash", Slug: "my-dashboard"}
+	assert.Equal(t, "d/dash/my-dashboard?from=now-7d&orgId=1&timezone=Europe%2FParis&to=now&var-host=a&var-host=b", dashboardPath(r, dash))
+}
+
+func TestSendWithRetries(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.AppURL = "http://localhost:3000/"
+	cfg.Reports.RetryInterval = time.Millisecond
+	cfg.Reports.RenderTimeout = time.Minute
+
+	newService := func(t *testing.T, email *notifications.NotificationServiceMock) *Service {
+		renderService := rendering.NewMockService(gomock.NewController(t))
+		renderService.EXPECT().Render(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, renderType rendering.RenderType, opts rendering.Opts, _ rendering.Session) (*rendering.RenderResult, error) {
+				assert.Equal(t, int64(1), opts.UserID)
+				path := filepath.Join(t.TempDir(), "render")
+				require.NoError(t, os.WriteFile(path, []byte(renderType), 0o600))
+				return &rendering.RenderResult{FilePath: path}, nil
+			}).AnyTimes()
+
+		dashboardService := dashboards.NewFakeDashboardService(t)
+		dashboardService.On("GetDashboard", mock.Anything, mock.Anything).Return(&dashboards.Dashboard{UID: "dash", Slug: "my-dashboard", Title: "My dashboard"}, nil)
+
+		return &Service{
+			cfg:              cfg,
+			renderService:    renderService,
+			emailSender:      email,
+			dashboardService: dashboardService,
+			log:              log.NewNopLogger(),
+			now:              time.Now,
+		}
+	}
+
+	t.Run("attaches every format", func(t *testing.T) {
+		email := &notifications.NotificationServiceMock{}
+		s := newService(t, email)
+
+		run := s.sendWithRetries(context.Background(), newReport("r", time.Time{}), reports.RunTriggerManual, 0)
+		assert.Equal(t, reports.RunStatusSuccess, run.Status)
+		assert.Equal(t, 1, run.Attempts)
+		assert.Equal(t, 1, run.Recipients)
+
+		require.Len(t, email.EmailSync.AttachedFiles, 2)
+		assert.Equal(t, "my-dashboard.pdf", email.EmailSync.AttachedFiles[0].Name)
+		assert.Equal(t, []byte(rendering.RenderPDF), email.EmailSync.AttachedFiles[0].Content)
+		assert.Equal(t, "my-dashboard.png", email.EmailSync.AttachedFiles[1].Name)
+		assert.Equal(t, "r", email.EmailSync.Subject)
+		assert.Equal(t, "http://localhost:3000/d/dash/my-dashboard?from=now-7d&orgId=1&timezone=Europe%2FParis&to=now&var-host=a&var-host=b", email.EmailSync.Data["DashboardURL"])
+	})
+
+	t.Run("retries failed sends", func(t *testing.T) {
+		failures := 2
+		email := &notifications.NotificationServiceMock{
+			EmailHandlerSync: func(context.Context, *notifications.SendEmailCommandSync) error {
+				if failures > 0 {
+					failures--
+					return errors.New("smtp unavailable")
+				}
+				return nil
+			},
+		}
+		s := newService(t, email)
+
+		run := s.sendWithRetries(context.Background(), newReport("r", time.Time{}), reports.RunTriggerSchedule, 3)
+		assert.Equal(t, reports.RunStatusSuccess, run.Status)
+		assert.Equal(t, 3, run.Attempts)
+	})
+
+	t.Run("records the error when the retries are exhausted", func(t *testing.T) {
+		email := &notifications.NotificationServiceMock{ShouldError: errors.New("smtp unavailable")}
+		s := newService(t, email)
+
+		run := s.sendWithRetries(context.Background(), newReport("r", time.Time{}), reports.RunTriggerSchedule, 1)
+		assert.Equal(t, reports.RunStatusFailed, run.Status)
+		assert.Equal(t, 2, run.Attempts)
+		assert.Contains(t, run.Error, "smtp unavailable")
+	})
+}
@@ -0,0 +1,195 @@
+package reportsimpl
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/reports"
+)
+
+const (
+	emailTemplate = "report"
+
+	renderWidth = 1920
+	// renderHeight of -1 renders the whole dashboard rather than the first screen of it.
+	renderHeight = -1
+)
+
+// dashboardPath returns the path of the dashboard of a report, relative to the root URL of Grafana.
+func dashboardPath(r *reports.Report, dash *dashboards.Dashboard) string {
+	u := url.URL{Path: path.Join("d", dash.UID, dash.Slug)}
+	q := url.Values{}
+	q.Set("orgId", strconv.FormatInt(r.OrgID, 10))
+	if r.TimeRange.From != "" {
+		q.Set("from", r.TimeRange.From)
+		q.Set("to", r.TimeRange.To)
+	}
+	if r.Schedule.Timezone != "" {
+		q.Set("timezone", r.Schedule.Timezone)
+	}
+	names := make([]string, 0, len(r.Variables))
+	for name := range r.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range r.Variables[name] {
+			q.Add("var-"+name, value)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// send renders the dashboard of a report in all of its formats and emails the files to its recipients.
+func (s *Service) send(ctx context.Context, r *reports.Report) error {
+	dash, err := s.dashboardService.GetDashboard(identity.WithServiceIdentityContext(ctx, r.OrgID), &dashboards.GetDashboardQuery{
+		OrgID: r.OrgID,
+		UID:   r.DashboardUID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get dashboard %s: %w", r.DashboardUID, err)
+	}
+
+	dashPath := dashboardPath(r, dash)
+	files := make([]*notifications.SendEmailAttachFile, 0, len(r.Formats))
+	for _, format := range r.Formats {
+		file, err := s.render(ctx, r, dashPath, dash, format)
+		if err != nil {
+			return err
+		}
+		files = append(files, file)
+	}
+
+	subject := r.Subject
+	if subject == "" {
+		subject = r.Name
+	}
+	cmd := &notifications.SendEmailCommandSync{
+		SendEmailCommand: notifications.SendEmailCommand{
+			To:       r.Recipients,
+			Template: emailTemplate,
+			Subject:  subject,
+			Data: map[string]any{
+				"Name":           r.Name,
+				"Message":        r.Message,
+				"DashboardTitle": dash.Title,
+				"DashboardURL":   s.cfg.AppURL + dashPath,
+				"From":           r.TimeRange.From,
+				"To":             r.TimeRange.To,
+			},
+			AttachedFiles: files,
+		},
+	}
+	if r.ReplyTo != "" {
+		cmd.ReplyTo = []string{r.ReplyTo}
+	}
+	if err := s.emailSender.SendEmailCommandHandlerSync(ctx, cmd); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// render renders the dashboard as the user who saved the report last, so that recipients never
+// receive more than the owner of the report can see.
+func (s *Service) render(ctx context.Context, r *reports.Report, dashPath string, dash *dashboards.Dashboard, format reports.Format) (*notifications.SendEmailAttachFile, error) {
+	renderType := rendering.RenderPNG
+	if format == reports.FormatPDF {
+		renderType = rendering.RenderPDF
+	}
+
+	result, err := s.renderService.Render(ctx, renderType, rendering.Opts{
+		CommonOpts: rendering.CommonOpts{
+			AuthOpts: rendering.AuthOpts{
+				OrgID:   r.OrgID,
+				UserID:  r.UpdatedBy,
+				OrgRole: org.RoleViewer,
+			},
+			TimeoutOpts: rendering.TimeoutOpts{
+				Timeout: s.cfg.Reports.RenderTimeout,
+			},
+			ConcurrentLimit: s.cfg.RendererConcurrentRequestLimit,
+			Path:            dashPath + "&kiosk",
+			Timezone:        r.Schedule.Timezone,
+		},
+		ErrorOpts: rendering.ErrorOpts{
+			ErrorConcurrentLimitReached: true,
+			ErrorRenderUnavailable:      true,
+		},
+		Width:  renderWidth,
+		Height: renderHeight,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render the dashboard as %s: %w", format, err)
+	}
+
+	// #nosec G304 -- the file is created by the rendering service.
+	content, err := os.ReadFile(result.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the rendered %s file: %w", format, err)
+	}
+	if err := os.Remove(result.FilePath); err != nil {
+		s.log.Warn("Failed to remove rendered report file", "path", result.FilePath, "error", err)
+	}
+
+	name := dash.Slug
+	if name == "" {
+		name = "dashboard"
+	}
+	return &notifications.SendEmailAttachFile{
+		Name:    fmt.Sprintf("%s.%s", name, format),
+		Content: content,
+	}, nil
+}
+
+// sendWithRetries sends a report, retrying up to maxRetries times with an exponential backoff when it fails.
+func (s *Service) sendWithRetries(ctx context.Context, r *reports.Report, trigger reports.RunTrigger, maxRetries int) *reports.Run {
+	run := &reports.Run{
+		ReportID:   r.ID,
+		OrgID:      r.OrgID,
+		Trigger:    trigger,
+		Recipients: len(r.Recipients),
+		Started:    s.now(),
+	}
+
+	wait := s.cfg.Reports.RetryInterval
+	var err error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			s.log.Warn("Failed to send report, retrying", "org", r.OrgID, "uid", r.UID, "attempt", attempt, "wait", wait, "error", err)
+			select {
+			case <-ctx.Done():
+				err = ctx.Err()
+			case <-time.After(wait):
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			wait *= 2
+		}
+		run.Attempts++
+		if err = s.send(ctx, r); err == nil {
+			break
+		}
+	}
+
+	run.Finished = s.now()
+	run.Status = reports.RunStatusSuccess
+	if err != nil {
+		run.Status = reports.RunStatusFailed
+		run.Error = err.Error()
+		s.log.Error("Failed to send report", "org", r.OrgID, "uid", r.UID, "attempts", run.Attempts, "error", err)
+	}
+	return run
+}
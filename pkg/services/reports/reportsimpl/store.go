@@ -0,0 +1,317 @@
+package reportsimpl
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/reports"
+)
+
+type reportEntity struct {
+	ID           int64     `xorm:"pk autoincr 'id'"`
+	UID          string    `xorm:"'uid'"`
+	OrgID        int64     `xorm:"'org_id'"`
+	Name         string    `xorm:"'name'"`
+	DashboardUID string    `xorm:"'dashboard_uid'"`
+	Formats      string    `xorm:"'formats'"`
+	TimeFrom     string    `xorm:"'time_from'"`
+	TimeTo       string    `xorm:"'time_to'"`
+	Variables    string    `xorm:"'variables'"`
+	Recipients   string    `xorm:"'recipients'"`
+	ReplyTo      string    `xorm:"'reply_to'"`
+	Subject      string    `xorm:"'subject'"`
+	Message      string    `xorm:"'message'"`
+	Schedule     string    `xorm:"'schedule'"`
+	Timezone     string    `xorm:"'timezone'"`
+	Enabled      bool      `xorm:"'enabled'"`
+	NextRun      int64     `xorm:"'next_run'"`
+	CreatedBy    int64     `xorm:"'created_by'"`
+	UpdatedBy    int64     `xorm:"'updated_by'"`
+	Created      time.Time `xorm:"'created'"`
+	Updated      time.Time `xorm:"'updated'"`
+}
+
+func (reportEntity) TableName() string {
+	return "report"
+}
+
+func fromReport(r *reports.Report) (*reportEntity, error) {
+	formats := make([]string, 0, len(r.Formats))
+	for _, f := range r.Formats {
+		formats = append(formats, string(f))
+	}
+	recipients, err := json.Marshal(r.Recipients)
+	if err != nil {
+		return nil, err
+	}
+	entity := &reportEntity{
+		ID:           r.ID,
+		UID:          r.UID,
+		OrgID:        r.OrgID,
+		Name:         r.Name,
+		DashboardUID: r.DashboardUID,
+		Formats:      strings.Join(formats, ","),
+		TimeFrom:     r.TimeRange.From,
+		TimeTo:       r.TimeRange.To,
+		Recipients:   string(recipients),
+		ReplyTo:      r.ReplyTo,
+		Subject:      r.Subject,
+		Message:      r.Message,
+		Schedule:     r.Schedule.Cron,
+		Timezone:     r.Schedule.Timezone,
+		Enabled:      r.Enabled,
+		CreatedBy:    r.CreatedBy,
+		UpdatedBy:    r.UpdatedBy,
+		Created:      r.Created,
+		Updated:      r.Updated,
+	}
+	if !r.NextRun.IsZero() {
+		entity.NextRun = r.NextRun.Unix()
+	}
+	if len(r.Variables) > 0 {
+		variables, err := json.Marshal(r.Variables)
+		if err != nil {
+			return nil, err
+		}
+		entity.Variables = string(variables)
+	}
+	return entity, nil
+}
+
+func (entity *reportEntity) toReport() (*reports.Report, error) {
+	r := &reports.Report{
+		ID:           entity.ID,
+		UID:          entity.UID,
+		OrgID:        entity.OrgID,
+		Name:         entity.Name,
+		DashboardUID: entity.DashboardUID,
+		TimeRange:    reports.TimeRange{From: entity.TimeFrom, To: entity.TimeTo},
+		ReplyTo:      entity.ReplyTo,
+		Subject:      entity.Subject,
+		Message:      entity.Message,
+		Schedule:     reports.Schedule{Cron: entity.Schedule, Timezone: entity.Timezone},
+		Enabled:      entity.Enabled,
+		CreatedBy:    entity.CreatedBy,
+		UpdatedBy:    entity.UpdatedBy,
+		Created:      entity.Created,
+		Updated:      entity.Updated,
+	}
+	for _, f := range strings.Split(entity.Formats, ",") {
+		if f != "" {
+			r.Formats = append(r.Formats, reports.Format(f))
+		}
+	}
+	if entity.NextRun > 0 {
+		r.NextRun = time.Unix(entity.NextRun, 0).UTC()
+	}
+	if err := json.Unmarshal([]byte(entity.Recipients), &r.Recipients); err != nil {
+		return nil, err
+	}
+	if entity.Variables != "" {
+		if err := json.Unmarshal([]byte(entity.Variables), &r.Variables); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+type runEntity struct {
+	ID         int64     `xorm:"pk autoincr 'id'"`
+	ReportID   int64     `xorm:"'report_id'"`
+	OrgID      int64     `xorm:"'org_id'"`
+	Trigger    string    `xorm:"'trigger_type'"`
+	Status     string    `xorm:"'status'"`
+	Attempts   int       `xorm:"'attempts'"`
+	Recipients int       `xorm:"'recipients'"`
+	Error      string    `xorm:"'error'"`
+	Started    time.Time `xorm:"'started'"`
+	Finished   time.Time `xorm:"'finished'"`
+}
+
+func (runEntity) TableName() string {
+	return "report_run"
+}
+
+func (entity *runEntity) toRun() *reports.Run {
+	return &reports.Run{
+		ID:         entity.ID,
+		ReportID:   entity.ReportID,
+		OrgID:      entity.OrgID,
+		Trigger:    reports.RunTrigger(entity.Trigger),
+		Status:     reports.RunStatus(entity.Status),
+		Attempts:   entity.Attempts,
+		Recipients: entity.Recipients,
+		Error:      entity.Error,
+		Started:    entity.Started,
+		Finished:   entity.Finished,
+	}
+}
+
+type store struct {
+	db db.DB
+}
+
+func (s *store) insert(ctx context.Context, r *reports.Report) error {
+	entity, err := fromReport(r)
+	if err != nil {
+		return err
+	}
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		if _, err := sess.Insert(entity); err != nil {
+			return err
+		}
+		r.ID = entity.ID
+		return nil
+	})
+}
+
+func (s *store) update(ctx context.Context, r *reports.Report) error {
+	entity, err := fromReport(r)
+	if err != nil {
+		return err
+	}
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		affected, err := sess.ID(entity.ID).AllCols().Omit("id", "uid", "org_id", "created_by", "created").Update(entity)
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return reports.ErrReportNotFound.Errorf("report %s not found", r.UID)
+		}
+		return nil
+	})
+}
+
+// updateNextRun sets the time a report is sent next. It returns false if the report was changed
+// since expected was read, so that the scheduled run of a report is claimed only once.
+func (s *store) updateNextRun(ctx context.Context, id int64, expected, next time.Time) (bool, error) {
+	var nextRun int64
+	if !next.IsZero() {
+		nextRun = next.Unix()
+	}
+	var claimed bool
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		res, err := sess.Exec("UPDATE report SET next_run = ? WHERE id = ? AND next_run = ?", nextRun, id, expected.Unix())
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		claimed = affected == 1
+		return err
+	})
+	return claimed, err
+}
+
+func (s *store) delete(ctx context.Context, orgID int64, uid string) error {
+	return s.db.InTransaction(ctx, func(ctx context.Context) error {
+		return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+			var entity reportEntity
+			has, err := sess.Where("org_id = ? AND uid = ?", orgID, uid).Get(&entity)
+			if err != nil {
+				return err
+			}
+			if !has {
+				return reports.ErrReportNotFound.Errorf("report %s not found", uid)
+			}
+			if _, err := sess.Exec("DELETE FROM report_run WHERE report_id = ?", entity.ID); err != nil {
+				return err
+			}
+			_, err = sess.Exec("DELETE FROM report WHERE id = ?", entity.ID)
+			return err
+		})
+	})
+}
+
+func (s *store) get(ctx context.Context, orgID int64, uid string) (*reports.Report, error) {
+	var result *reports.Report
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		var entity reportEntity
+		has, err := sess.Where("org_id = ? AND uid = ?", orgID, uid).Get(&entity)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return reports.ErrReportNotFound.Errorf("report %s not found", uid)
+		}
+		result, err = entity.toReport()
+		return err
+	})
+	return result, err
+}
+
+func (s *store) list(ctx context.Context, orgID int64) ([]*reports.Report, error) {
+	return s.find(ctx, "org_id = ?", orgID)
+}
+
+// listDue returns the enabled reports that are due at the given time, in all organizations.
+func (s *store) listDue(ctx context.Context, now time.Time) ([]*reports.Report, error) {
+	return s.find(ctx, "enabled = ? AND next_run > 0 AND next_run <= ?", s.db.GetDialect().BooleanValue(true), now.Unix())
+}
+
+func (s *store) find(ctx context.Context, where string, args ...any) ([]*reports.Report, error) {
+	result := []*reports.Report{}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		var entities []*reportEntity
+		if err := sess.Where(where, args...).Asc("name", "id").Find(&entities); err != nil {
+			return err
+		}
+		for _, entity := range entities {
+			r, err := entity.toReport()
+			if err != nil {
+				return err
+			}
+			result = append(result, r)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// insertRun adds a run to the history of a report and removes the oldest runs above the limit.
+func (s *store) insertRun(ctx context.Context, run *reports.Run, limit int) error {
+	entity := &runEntity{
+		ReportID:   run.ReportID,
+		OrgID:      run.OrgID,
+		Trigger:    string(run.Trigger),
+		Status:     string(run.Status),
+		Attempts:   run.Attempts,
+		Recipients: run.Recipients,
+		Error:      run.Error,
+		Started:    run.Started,
+		Finished:   run.Finished,
+	}
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		if _, err := sess.Insert(entity); err != nil {
+			return err
+		}
+		run.ID = entity.ID
+
+		var ids []int64
+		if err := sess.Table("report_run").Where("report_id = ?", run.ReportID).Desc("id").Limit(1, limit).Cols("id").Find(&ids); err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		_, err := sess.Exec("DELETE FROM report_run WHERE report_id = ? AND id <= ?", run.ReportID, ids[0])
+		return err
+	})
+}
+
+func (s *store) listRuns(ctx context.Context, reportID int64) ([]*reports.Run, error) {
+	result := []*reports.Run{}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		var entities []*runEntity
+		if err := sess.Where("report_id = ?", reportID).Desc("started", "id").Find(&entities); err != nil {
+			return err
+		}
+		for _, entity := range entities {
+			result = append(result, entity.toRun())
+		}
+		return nil
+	})
+	return result, err
+}
@@ -0,0 +1,89 @@
+package reports
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const maxRecipients = 100
+
+// Next returns the first time the schedule is due after the given time.
+func (s Schedule) Next(after time.Time) (time.Time, error) {
+	sched, err := cron.ParseStandard(s.Cron)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid cron expression %q: %w", s.Cron, err)
+	}
+	loc, err := s.location()
+	if err != nil {
+		return time.Time{}, err
+	}
+	next := sched.Next(after.In(loc))
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("cron expression %q is never due", s.Cron)
+	}
+	return next.UTC(), nil
+}
+
+func (s Schedule) location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", s.Timezone, err)
+	}
+	return loc, nil
+}
+
+// Validate returns ErrInvalidReport if the spec cannot be sent.
+func (spec *ReportSpec) Validate() error {
+	if spec.Name == "" {
+		return ErrInvalidReport.Errorf("the name is required")
+	}
+	if len(spec.Name) > 190 || len(spec.Subject) > 190 {
+		return ErrInvalidReport.Errorf("the name and the subject cannot be longer than 190 characters")
+	}
+	if spec.DashboardUID == "" {
+		return ErrInvalidReport.Errorf("the dashboard is required")
+	}
+
+	if len(spec.Formats) == 0 {
+		return ErrInvalidReport.Errorf("at least one format is required")
+	}
+	for _, f := range spec.Formats {
+		if f != FormatPDF && f != FormatPNG {
+			return ErrInvalidReport.Errorf("unsupported format %q", f)
+		}
+	}
+	slices.Sort(spec.Formats)
+	spec.Formats = slices.Compact(spec.Formats)
+
+	if (spec.TimeRange.From == "") != (spec.TimeRange.To == "") {
+		return ErrInvalidReport.Errorf("the time range requires both from and to")
+	}
+
+	if len(spec.Recipients) == 0 {
+		return ErrInvalidReport.Errorf("at least one recipient is required")
+	}
+	if len(spec.Recipients) > maxRecipients {
+		return ErrInvalidReport.Errorf("a report cannot have more than %d recipients", maxRecipients)
+	}
+	for _, r := range spec.Recipients {
+		if !util.IsEmail(r) {
+			return ErrInvalidReport.Errorf("invalid recipient %q", r)
+		}
+	}
+	if spec.ReplyTo != "" && !util.IsEmail(spec.ReplyTo) {
+		return ErrInvalidReport.Errorf("invalid reply-to address %q", spec.ReplyTo)
+	}
+
+	if _, err := spec.Schedule.Next(time.Now()); err != nil {
+		return ErrInvalidReport.Errorf("invalid schedule: %w", err)
+	}
+	return nil
+}
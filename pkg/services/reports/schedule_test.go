@@ -0,0 +1,80 @@
+package reports
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleNext(t *testing.T) {
+	after := time.Date(2024, time.March, 1, 10, 30, 0, 0, time.UTC)
+
+	t.Run("evaluates the cron expression in UTC by default", func(t *testing.T) {
+		next, err := Schedule{Cron: "0 8 * * *"}.Next(after)
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.March, 2, 8, 0, 0, 0, time.UTC), next)
+	})
+
+	t.Run("evaluates the cron expression in the time zone", func(t *testing.T) {
+		next, err := Schedule{Cron: "0 8 * * *", Timezone: "America/New_York"}.Next(after)
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.March, 1, 13, 0, 0, 0, time.UTC), next)
+		assert.Equal(t, time.UTC, next.Location())
+	})
+
+	t.Run("supports descriptors", func(t *testing.T) {
+		next, err := Schedule{Cron: "@weekly"}.Next(after)
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.March, 3, 0, 0, 0, 0, time.UTC), next)
+	})
+
+	t.Run("fails on invalid schedules", func(t *testing.T) {
+		_, err := Schedule{Cron: "every day"}.Next(after)
+		require.Error(t, err)
+		_, err = Schedule{Cron: "0 8 * * *", Timezone: "Mars/Olympus"}.Next(after)
+		require.Error(t, err)
+	})
+}
+
+func TestReportSpecValidate(t *testing.T) {
+	valid := func() *ReportSpec {
+		return &ReportSpec{
+			Name:         "Weekly",
+			DashboardUID: "dash",
+			Formats:      []Format{FormatPNG, FormatPDF, FormatPNG},
+			Recipients:   []string{"ops@example.com"},
+			Schedule:     Schedule{Cron: "0 8 * * 1"},
+		}
+	}
+
+	t.Run("valid spec is normalized", func(t *testing.T) {
+		spec := valid()
+		require.NoError(t, spec.Validate())
+		assert.Equal(t, []Format{FormatPDF, FormatPNG}, spec.Formats)
+	})
+
+	tests := map[string]func(*ReportSpec){
+		"no name":             func(s *ReportSpec) { s.Name = "" },
+		"long subject":        func(s *ReportSpec) { s.Subject = strings.Repeat("a", 191) },
+		"no dashboard":        func(s *ReportSpec) { s.DashboardUID = "" },
+		"no format":           func(s *ReportSpec) { s.Formats = nil },
+		"unknown format":      func(s *ReportSpec) { s.Formats = []Format{"csv"} },
+		"partial time range":  func(s *ReportSpec) { s.TimeRange = TimeRange{From: "now-7d"} },
+		"no recipients":       func(s *ReportSpec) { s.Recipients = nil },
+		"invalid recipient":   func(s *ReportSpec) { s.Recipients = []string{"ops"} },
+		"invalid reply-to":    func(s *ReportSpec) { s.ReplyTo = "ops" },
+		"invalid schedule":    func(s *ReportSpec) { s.Schedule.Cron = "0 25 * * *" },
+		"invalid time zone":   func(s *ReportSpec) { s.Schedule.Timezone = "Nowhere" },
+		"too many recipients": func(s *ReportSpec) { s.Recipients = make([]string, maxRecipients+1) },
+	}
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			spec := valid()
+			mutate(spec)
+			require.ErrorIs(t, spec.Validate(), ErrInvalidReport)
+		})
+	}
+}
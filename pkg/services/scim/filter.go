@@ -0,0 +1,471 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// This file implements the filters of RFC 7644, Section 3.4.2.2. Filters are evaluated against the JSON
+// representation of a resource, so that they apply to any attribute the resource returns.
+
+// caseExactAttributes are compared with their exact case, the other string attributes ignore the case.
+var caseExactAttributes = map[string]bool{
+	"id":            true,
+	"externalid":    true,
+	"members.value": true,
+}
+
+type filter interface {
+	matches(resource map[string]any) bool
+}
+
+type logicalFilter struct {
+	and         bool
+	left, right filter
+}
+
+func (f *logicalFilter) matches(resource map[string]any) bool {
+	if f.and {
+		return f.left.matches(resource) && f.right.matches(resource)
+	}
+	return f.left.matches(resource) || f.right.matches(resource)
+}
+
+type notFilter struct {
+	filter filter
+}
+
+func (f *notFilter) matches(resource map[string]any) bool {
+	return !f.filter.matches(resource)
+}
+
+// attributeFilter compares the values of an attribute. Multi-valued attributes match if any of their values does.
+type attributeFilter struct {
+	path      []string
+	op        string
+	value     any
+	caseExact bool
+}
+
+func (f *attributeFilter) matches(resource map[string]any) bool {
+	values := make([]any, 0)
+	for _, v := range lookup(resource, f.path) {
+		// The value sub-attribute is implied for complex attributes, for example emails co "example.org".
+		if m, ok := v.(map[string]any); ok {
+			v = m["value"]
+		}
+		values = append(values, v)
+	}
+	if len(values) == 0 {
+		// A missing attribute is null.
+		values = []any{nil}
+	}
+	if f.op == "pr" {
+		for _, v := range values {
+			if v != nil && v != "" {
+				return true
+			}
+		}
+		return false
+	}
+	if f.op == "ne" {
+		for _, v := range values {
+			if f.compare(v, "eq") {
+				return false
+			}
+		}
+		return true
+	}
+	for _, v := range values {
+		if f.compare(v, f.op) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *attributeFilter) compare(v any, op string) bool {
+	switch expected := f.value.(type) {
+	case string:
+		actual, ok := v.(string)
+		if !ok {
+			return false
+		}
+		if !f.caseExact {
+			actual, expected = strings.ToLower(actual), strings.ToLower(expected)
+		}
+		switch op {
+		case "eq":
+			return actual == expected
+		case "co":
+			return strings.Contains(actual, expected)
+		case "sw":
+			return strings.HasPrefix(actual, expected)
+		case "ew":
+			return strings.HasSuffix(actual, expected)
+		case "gt":
+			return actual > expected
+		case "ge":
+			return actual >= expected
+		case "lt":
+			return actual < expected
+		case "le":
+			return actual <= expected
+		}
+	case float64:
+		actual, ok := v.(float64)
+		if !ok {
+			return false
+		}
+		switch op {
+		case "eq":
+			return actual == expected
+		case "gt":
+			return actual > expected
+		case "ge":
+			return actual >= expected
+		case "lt":
+			return actual < expected
+		case "le":
+			return actual <= expected
+		}
+	case bool:
+		actual, ok := v.(bool)
+		return ok && op == "eq" && actual == expected
+	case nil:
+		return op == "eq" && v == nil
+	}
+	return false
+}
+
+// valuePathFilter matches resources that have a value of a complex attribute matching the filter,
+// for example emails[type eq "work"].
+type valuePathFilter struct {
+	attribute string
+	filter    filter
+}
+
+func (f *valuePathFilter) matches(resource map[string]any) bool {
+	for _, v := range lookup(resource, []string{f.attribute}) {
+		if m, ok := v.(map[string]any); ok && f.filter.matches(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the values of an attribute. Attribute names are case insensitive, and the values of
+// multi-valued attributes are flattened.
+func lookup(value any, path []string) []any {
+	if len(path) == 0 {
+		if values, ok := value.([]any); ok {
+			return values
+		}
+		return []any{value}
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		key, ok := findKey(v, path[0])
+		if !ok {
+			return nil
+		}
+		return lookup(v[key], path[1:])
+	case []any:
+		var result []any
+		for _, item := range v {
+			result = append(result, lookup(item, path)...)
+		}
+		return result
+	}
+	return nil
+}
+
+// findKey returns the key of an attribute in a resource, ignoring the case.
+func findKey(m map[string]any, name string) (string, bool) {
+	if _, ok := m[name]; ok {
+		return name, true
+	}
+	for key := range m {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// attributePath splits an attribute path and removes the schema prefix of fully qualified names.
+func attributePath(s string) []string {
+	if strings.HasPrefix(strings.ToLower(s), "urn:") {
+		if i := strings.LastIndex(s, ":"); i >= 0 {
+			s = s[i+1:]
+		}
+	}
+	return strings.Split(s, ".")
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenString
+	tokenNumber
+	tokenOpenParen
+	tokenCloseParen
+	tokenOpenBracket
+	tokenCloseBracket
+	tokenEOF
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value any
+}
+
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenOpenParen, text: "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenCloseParen, text: ")"})
+			i++
+		case c == '[':
+			tokens = append(tokens, token{kind: tokenOpenBracket, text: "["})
+			i++
+		case c == ']':
+			tokens = append(tokens, token{kind: tokenCloseBracket, text: "]"})
+			i++
+		case c == '"':
+			end := i + 1
+			for ; end < len(s) && s[end] != '"'; end++ {
+				if s[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(s) {
+				return nil, ErrInvalidFilter.Errorf("unterminated string at position %d", i)
+			}
+			var value string
+			if err := json.Unmarshal([]byte(s[i:end+1]), &value); err != nil {
+				return nil, ErrInvalidFilter.Errorf("invalid string at position %d: %w", i, err)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: s[i : end+1], value: value})
+			i = end + 1
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(s) && strings.ContainsRune("0123456789.eE+-", rune(s[end])) {
+				end++
+			}
+			var value float64
+			if err := json.Unmarshal([]byte(s[i:end]), &value); err != nil {
+				return nil, ErrInvalidFilter.Errorf("invalid number at position %d", i)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: s[i:end], value: value})
+			i = end
+		case isWordChar(rune(c)):
+			end := i + 1
+			for end < len(s) && isWordChar(rune(s[end])) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: s[i:end]})
+			i = end
+		default:
+			return nil, ErrInvalidFilter.Errorf("unexpected character %q at position %d", c, i)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF}), nil
+}
+
+func isWordChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_-.:$", r)
+}
+
+type filterParser struct {
+	tokens []token
+	pos    int
+	// prefix is the attribute of a value path, for the filter between the brackets.
+	prefix string
+}
+
+// parseFilter parses a filter, for example userName eq "bjensen" and not (emails co "example.org").
+func parseFilter(s string) (filter, error) {
+	return parseValueFilter("", s)
+}
+
+// parseValueFilter parses the filter of a value path, which applies to the values of a complex attribute.
+func parseValueFilter(attribute, s string) (filter, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens, prefix: attribute}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, ErrInvalidFilter.Errorf("unexpected %q", p.peek().text)
+	}
+	return f, nil
+}
+
+func (p *filterParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *filterParser) isKeyword(keyword string) bool {
+	t := p.peek()
+	return t.kind == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+func (p *filterParser) parseOr() (filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalFilter{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("and") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalFilter{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filter, error) {
+	if p.isKeyword("not") {
+		p.next()
+		f, err := p.parseGroup()
+		if err != nil {
+			return nil, err
+		}
+		return &notFilter{filter: f}, nil
+	}
+	if p.peek().kind == tokenOpenParen {
+		return p.parseGroup()
+	}
+	return p.parseAttribute()
+}
+
+func (p *filterParser) parseGroup() (filter, error) {
+	if t := p.next(); t.kind != tokenOpenParen {
+		return nil, ErrInvalidFilter.Errorf("expected ( but got %q", t.text)
+	}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.next(); t.kind != tokenCloseParen {
+		return nil, ErrInvalidFilter.Errorf("expected ) but got %q", t.text)
+	}
+	return f, nil
+}
+
+func (p *filterParser) parseAttribute() (filter, error) {
+	t := p.next()
+	if t.kind != tokenWord {
+		return nil, ErrInvalidFilter.Errorf("expected an attribute but got %q", t.text)
+	}
+	path := attributePath(t.text)
+
+	if p.peek().kind == tokenOpenBracket {
+		if p.prefix != "" || len(path) != 1 {
+			return nil, ErrInvalidFilter.Errorf("invalid value path %q", t.text)
+		}
+		p.next()
+		inner := &filterParser{tokens: p.tokens, pos: p.pos, prefix: path[0]}
+		f, err := inner.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		p.pos = inner.pos
+		if t := p.next(); t.kind != tokenCloseBracket {
+			return nil, ErrInvalidFilter.Errorf("expected ] but got %q", t.text)
+		}
+		return &valuePathFilter{attribute: path[0], filter: f}, nil
+	}
+
+	fullPath := strings.ToLower(strings.Join(path, "."))
+	if p.prefix != "" {
+		fullPath = strings.ToLower(p.prefix) + "." + fullPath
+	}
+	f := &attributeFilter{path: path, caseExact: caseExactAttributes[fullPath]}
+
+	op := p.next()
+	if op.kind != tokenWord {
+		return nil, ErrInvalidFilter.Errorf("expected an operator but got %q", op.text)
+	}
+	f.op = strings.ToLower(op.text)
+	switch f.op {
+	case "pr":
+		return f, nil
+	case "eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le":
+	default:
+		return nil, ErrInvalidFilter.Errorf("unsupported operator %q", op.text)
+	}
+
+	value := p.next()
+	switch {
+	case value.kind == tokenString || value.kind == tokenNumber:
+		f.value = value.value
+	case value.kind == tokenWord && strings.EqualFold(value.text, "true"):
+		f.value = true
+	case value.kind == tokenWord && strings.EqualFold(value.text, "false"):
+		f.value = false
+	case value.kind == tokenWord && strings.EqualFold(value.text, "null"):
+		f.value = nil
+	default:
+		return nil, ErrInvalidFilter.Errorf("invalid value %q", value.text)
+	}
+	if f.value == nil && f.op != "eq" && f.op != "ne" {
+		return nil, ErrInvalidFilter.Errorf("null can only be compared with eq and ne")
+	}
+	return f, nil
+}
+
+// toMap returns the JSON representation of a resource, which filters and patches work on.
+func toMap(resource any) (map[string]any, error) {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to convert resource: %w", err)
+	}
+	return m, nil
+}
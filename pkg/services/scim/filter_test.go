@@ -0,0 +1,78 @@
+package scim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+)
+
+func TestFilter(t *testing.T) {
+	active := true
+	user, err := toMap(&User{
+		Schemas:     []string{SchemaUser},
+		ID:          "u1",
+		ExternalID:  "ext-1",
+		UserName:    "bjensen",
+		DisplayName: "Barbara Jensen",
+		Emails:      []Email{{Value: "bjensen@example.com", Type: "work", Primary: true}},
+		Active:      &active,
+		Meta:        &Meta{ResourceType: "User", LastModified: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		filter  string
+		matches bool
+	}{
+		{filter: `userName eq "bjensen"`, matches: true},
+		{filter: `USERNAME eq "BJensen"`, matches: true},
+		{filter: `urn:ietf:params:scim:schemas:core:2.0:User:userName eq "bjensen"`, matches: true},
+		{filter: `userName ne "bjensen"`, matches: false},
+		{filter: `userName sw "bj"`, matches: true},
+		{filter: `userName ew "sen"`, matches: true},
+		{filter: `displayName co "ara J"`, matches: true},
+		{filter: `externalId eq "EXT-1"`, matches: false},
+		{filter: `externalId eq "ext-1"`, matches: true},
+		{filter: `active eq true`, matches: true},
+		{filter: `name pr`, matches: false},
+		{filter: `name eq null`, matches: true},
+		{filter: `emails.value eq "bjensen@example.com"`, matches: true},
+		{filter: `emails[type eq "work" and value ew "example.com"]`, matches: true},
+		{filter: `emails[type eq "home"]`, matches: false},
+		{filter: `meta.lastModified gt "2024-01-01T00:00:00Z"`, matches: true},
+		{filter: `meta.lastModified lt "2024-01-01T00:00:00Z"`, matches: false},
+		{filter: `userName eq "jdoe" or userName eq "bjensen"`, matches: true},
+		{filter: `userName eq "bjensen" and not (active eq true)`, matches: false},
+		{filter: `(userName eq "jdoe" or active eq true) and emails pr`, matches: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			f, err := parseFilter(tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.matches, f.matches(user))
+		})
+	}
+}
+
+func TestFilterErrors(t *testing.T) {
+	for _, s := range []string{
+		`userName`,
+		`userName eq`,
+		`userName like "bjensen"`,
+		`userName eq "bjensen`,
+		`(userName eq "bjensen"`,
+		`userName eq "bjensen" and`,
+		`emails[type eq "work"`,
+	} {
+		t.Run(s, func(t *testing.T) {
+			_, err := parseFilter(s)
+			var gfErr errutil.Error
+			require.ErrorAs(t, err, &gfErr)
+			assert.Equal(t, "scim.invalid-filter", gfErr.MessageID)
+		})
+	}
+}
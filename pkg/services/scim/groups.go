@@ -0,0 +1,281 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/team"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// groupSpec holds the attributes of a SCIM group that are stored by Grafana.
+type groupSpec struct {
+	name       string
+	externalID string
+	// members are the IDs of the users who are members of the team.
+	members map[int64]bool
+}
+
+func (s *Service) parseGroup(ctx context.Context, orgID int64, g *Group) (*groupSpec, error) {
+	spec := &groupSpec{
+		name:       strings.TrimSpace(g.DisplayName),
+		externalID: g.ExternalID,
+		members:    make(map[int64]bool, len(g.Members)),
+	}
+	if spec.name == "" {
+		return nil, ErrInvalidValue.Errorf("displayName is required")
+	}
+	if len(g.Members) == 0 {
+		return spec, nil
+	}
+
+	users, err := s.store.findUsers(ctx, orgID, s.cfg.SCIM.AuthModule, "")
+	if err != nil {
+		return nil, err
+	}
+	byUID := make(map[string]int64, len(users))
+	for _, u := range users {
+		byUID[u.UID] = u.ID
+	}
+	for _, m := range g.Members {
+		id, ok := byUID[m.Value]
+		if !ok {
+			return nil, ErrInvalidValue.Errorf("member %q is not a user of the organization", m.Value)
+		}
+		spec.members[id] = true
+	}
+	return spec, nil
+}
+
+func (s *Service) toGroup(row *teamRow) *Group {
+	g := &Group{
+		Schemas:     []string{SchemaGroup},
+		ID:          row.UID,
+		ExternalID:  row.ExternalUID,
+		DisplayName: row.Name,
+		Members:     make([]Member, 0, len(row.Members)),
+		Meta: &Meta{
+			ResourceType: "Group",
+			Created:      row.Created,
+			LastModified: row.Updated,
+			Location:     s.location("Groups", row.UID),
+		},
+	}
+	for _, m := range row.Members {
+		g.Members = append(g.Members, Member{
+			Value:   m.UserUID,
+			Display: m.Login,
+			Ref:     s.location("Users", m.UserUID),
+		})
+	}
+	return g
+}
+
+func (s *Service) getTeamRow(ctx context.Context, orgID int64, uid string) (*teamRow, error) {
+	rows, err := s.store.findTeams(ctx, orgID, uid)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrNotFound.Errorf("group %q not found", uid)
+	}
+	return rows[0], nil
+}
+
+func (s *Service) getGroups(c *contextmodel.ReqContext) response.Response {
+	rows, err := s.store.findTeams(c.Req.Context(), c.SignedInUser.GetOrgID(), "")
+	if err != nil {
+		return s.errorResponse(err)
+	}
+
+	resources := make([]any, 0, len(rows))
+	for _, row := range rows {
+		resources = append(resources, s.toGroup(row))
+	}
+	list, err := listResponse(c, resources)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	return scimResponse(http.StatusOK, list)
+}
+
+func (s *Service) getGroup(c *contextmodel.ReqContext) response.Response {
+	row, err := s.getTeamRow(c.Req.Context(), c.SignedInUser.GetOrgID(), web.Params(c.Req)[":id"])
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	return scimResponse(http.StatusOK, s.toGroup(row))
+}
+
+func (s *Service) createGroup(c *contextmodel.ReqContext) response.Response {
+	var g Group
+	if err := decode(c, &g); err != nil {
+		return s.errorResponse(err)
+	}
+
+	ctx := c.Req.Context()
+	orgID := c.SignedInUser.GetOrgID()
+	spec, err := s.parseGroup(ctx, orgID, &g)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+
+	var uid string
+	err = s.db.InTransaction(ctx, func(ctx context.Context) error {
+		t, err := s.teamService.CreateTeam(ctx, &team.CreateTeamCommand{
+			Name:          spec.name,
+			OrgID:         orgID,
+			ExternalUID:   spec.externalID,
+			IsProvisioned: true,
+		})
+		if errors.Is(err, team.ErrTeamNameTaken) {
+			return ErrUniqueness.Errorf("a team named %q already exists", spec.name)
+		}
+		if err != nil {
+			return err
+		}
+		uid = t.UID
+		return s.setMembers(ctx, orgID, t.ID, nil, spec.members)
+	})
+	if err != nil {
+		return s.errorResponse(err)
+	}
+
+	row, err := s.getTeamRow(ctx, orgID, uid)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	return scimResponse(http.StatusCreated, s.toGroup(row)).SetHeader("Location", s.location("Groups", uid))
+}
+
+func (s *Service) replaceGroup(c *contextmodel.ReqContext) response.Response {
+	row, err := s.getTeamRow(c.Req.Context(), c.SignedInUser.GetOrgID(), web.Params(c.Req)[":id"])
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	var g Group
+	if err := decode(c, &g); err != nil {
+		return s.errorResponse(err)
+	}
+	return s.updateGroup(c, row, &g)
+}
+
+func (s *Service) patchGroup(c *contextmodel.ReqContext) response.Response {
+	row, err := s.getTeamRow(c.Req.Context(), c.SignedInUser.GetOrgID(), web.Params(c.Req)[":id"])
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	var req PatchRequest
+	if err := decode(c, &req); err != nil {
+		return s.errorResponse(err)
+	}
+
+	resource, err := toMap(s.toGroup(row))
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	if err := applyPatch(resource, req.Operations); err != nil {
+		return s.errorResponse(err)
+	}
+
+	var g Group
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	if err := json.Unmarshal(data, &g); err != nil {
+		return s.errorResponse(ErrInvalidValue.Errorf("invalid group: %w", err))
+	}
+	return s.updateGroup(c, row, &g)
+}
+
+// updateGroup saves the name and the members of a replaced or patched group. Teams that were created
+// before SCIM was enabled are marked as provisioned.
+func (s *Service) updateGroup(c *contextmodel.ReqContext, row *teamRow, g *Group) response.Response {
+	ctx := c.Req.Context()
+	orgID := c.SignedInUser.GetOrgID()
+	spec, err := s.parseGroup(ctx, orgID, g)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	// Not all identity providers send the external ID of groups, an omitted ID is kept.
+	if spec.externalID == "" {
+		spec.externalID = row.ExternalUID
+	}
+
+	err = s.db.InTransaction(ctx, func(ctx context.Context) error {
+		if spec.name != row.Name {
+			err := s.teamService.UpdateTeam(ctx, &team.UpdateTeamCommand{ID: row.ID, Name: spec.name, OrgID: orgID})
+			if errors.Is(err, team.ErrTeamNameTaken) {
+				return ErrUniqueness.Errorf("a team named %q already exists", spec.name)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if !row.IsProvisioned || spec.externalID != row.ExternalUID {
+			if err := s.store.updateTeamProvisioning(ctx, row.ID, spec.externalID); err != nil {
+				return err
+			}
+		}
+		return s.setMembers(ctx, orgID, row.ID, row.Members, spec.members)
+	})
+	if err != nil {
+		return s.errorResponse(err)
+	}
+
+	updated, err := s.getTeamRow(ctx, orgID, row.UID)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	return scimResponse(http.StatusOK, s.toGroup(updated))
+}
+
+// setMembers adds and removes the members of a team so that they match the wanted members.
+func (s *Service) setMembers(ctx context.Context, orgID, teamID int64, current []*memberRow, wanted map[int64]bool) error {
+	resourceID := strconv.FormatInt(teamID, 10)
+	isMember := make(map[int64]bool, len(current))
+	for _, m := range current {
+		isMember[m.UserID] = true
+		if wanted[m.UserID] {
+			continue
+		}
+		if _, err := s.teamPermissionsService.SetUserPermission(ctx, orgID, ac.User{ID: m.UserID, IsExternal: true}, resourceID, ""); err != nil {
+			return err
+		}
+	}
+	for userID := range wanted {
+		if isMember[userID] {
+			continue
+		}
+		if _, err := s.teamPermissionsService.SetUserPermission(ctx, orgID, ac.User{ID: userID, IsExternal: true}, resourceID, team.PermissionTypeMember.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) deleteGroup(c *contextmodel.ReqContext) response.Response {
+	ctx := c.Req.Context()
+	orgID := c.SignedInUser.GetOrgID()
+	row, err := s.getTeamRow(ctx, orgID, web.Params(c.Req)[":id"])
+	if err != nil {
+		return s.errorResponse(err)
+	}
+
+	if err := s.teamService.DeleteTeam(ctx, &team.DeleteTeamCommand{OrgID: orgID, ID: row.ID}); err != nil {
+		return s.errorResponse(err)
+	}
+	// Clear the role assignments and the permissions of the team, like when a team is deleted in Grafana.
+	if err := s.accesscontrolService.DeleteTeamPermissions(ctx, orgID, row.ID); err != nil {
+		return s.errorResponse(err)
+	}
+	return response.Empty(http.StatusNoContent)
+}
@@ -0,0 +1,141 @@
+package scim
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/org"
+)
+
+const (
+	SchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	SchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+	// ContentType is the media type of SCIM requests and responses.
+	ContentType = "application/scim+json"
+)
+
+// The errors are returned in the format of RFC 7644, Section 3.12. Their message ID is mapped to the
+// scimType of the response.
+var (
+	ErrInvalidFilter = errutil.BadRequest("scim.invalid-filter", errutil.WithPublicMessage("The filter is invalid"))
+	ErrInvalidPath   = errutil.BadRequest("scim.invalid-path", errutil.WithPublicMessage("The path of the operation is invalid"))
+	ErrInvalidSyntax = errutil.BadRequest("scim.invalid-syntax", errutil.WithPublicMessage("The request is invalid"))
+	ErrInvalidValue  = errutil.BadRequest("scim.invalid-value", errutil.WithPublicMessage("A value is missing or invalid"))
+	ErrMutability    = errutil.BadRequest("scim.mutability", errutil.WithPublicMessage("The attribute cannot be changed"))
+	ErrUniqueness    = errutil.Conflict("scim.uniqueness", errutil.WithPublicMessage("The resource already exists"))
+	ErrNotFound      = errutil.NotFound("scim.not-found", errutil.WithPublicMessage("Resource not found"))
+	ErrNotManaged    = errutil.Forbidden("scim.not-managed", errutil.WithPublicMessage("The resource is not managed by SCIM"))
+)
+
+var scimTypes = map[string]string{
+	"scim.invalid-filter": "invalidFilter",
+	"scim.invalid-path":   "invalidPath",
+	"scim.invalid-syntax": "invalidSyntax",
+	"scim.invalid-value":  "invalidValue",
+	"scim.mutability":     "mutability",
+	"scim.uniqueness":     "uniqueness",
+}
+
+type Meta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location"`
+}
+
+type Name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type Email struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// User is a Grafana user. The ID is the UID of the user and the user name is its login.
+type User struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id,omitempty"`
+	ExternalID  string   `json:"externalId,omitempty"`
+	UserName    string   `json:"userName"`
+	Name        *Name    `json:"name,omitempty"`
+	DisplayName string   `json:"displayName,omitempty"`
+	Emails      []Email  `json:"emails,omitempty"`
+	Active      *bool    `json:"active,omitempty"`
+	Meta        *Meta    `json:"meta,omitempty"`
+}
+
+type Member struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+// Group is a Grafana team. The ID is the UID of the team and the members are users.
+type Group struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id,omitempty"`
+	ExternalID  string   `json:"externalId,omitempty"`
+	DisplayName string   `json:"displayName"`
+	Members     []Member `json:"members"`
+	Meta        *Meta    `json:"meta,omitempty"`
+}
+
+type ListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []any    `json:"Resources"`
+}
+
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+type ErrorResponse struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+const (
+	// ActionProvision allows to provision the users and teams of an organization with SCIM.
+	ActionProvision = "scim:provision"
+)
+
+var provisionerRole = ac.RoleDTO{
+	Name:        "fixed:scim:provisioner",
+	DisplayName: "Provisioner",
+	Description: "Provision the users and teams of the organization with SCIM",
+	Group:       "SCIM",
+	Permissions: []ac.Permission{
+		{Action: ActionProvision},
+	},
+}
+
+func declareFixedRoles(service ac.Service) error {
+	return service.DeclareFixedRoles(ac.RoleRegistration{
+		Role:   provisionerRole,
+		Grants: []string{string(org.RoleAdmin)},
+	})
+}
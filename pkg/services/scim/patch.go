@@ -0,0 +1,292 @@
+package scim
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// This file implements the PATCH operations of RFC 7644, Section 3.5.2. Operations are applied to the
+// JSON representation of a resource, which is then saved like a replaced resource.
+
+type patchPath struct {
+	attribute    string
+	filter       filter
+	subAttribute string
+}
+
+// parsePatchPath parses the path of an operation, for example members[value eq "2819c223"] or name.givenName.
+func parsePatchPath(s string) (*patchPath, error) {
+	head, rest := s, ""
+	if i := strings.Index(s, "["); i >= 0 {
+		head, rest = s[:i], s[i:]
+	}
+
+	parts := attributePath(head)
+	p := &patchPath{attribute: parts[0]}
+	switch {
+	case p.attribute == "":
+		return nil, ErrInvalidPath.Errorf("invalid path %q", s)
+	case len(parts) == 2 && rest == "":
+		p.subAttribute = parts[1]
+	case len(parts) > 1:
+		return nil, ErrInvalidPath.Errorf("invalid path %q", s)
+	}
+
+	if rest != "" {
+		end := strings.LastIndex(rest, "]")
+		if end < 0 {
+			return nil, ErrInvalidPath.Errorf("invalid path %q", s)
+		}
+		f, err := parseValueFilter(p.attribute, rest[1:end])
+		if err != nil {
+			return nil, ErrInvalidPath.Errorf("invalid filter in path %q: %w", s, err)
+		}
+		p.filter = f
+		if sub := rest[end+1:]; sub != "" {
+			if !strings.HasPrefix(sub, ".") || len(sub) == 1 {
+				return nil, ErrInvalidPath.Errorf("invalid path %q", s)
+			}
+			p.subAttribute = sub[1:]
+		}
+	}
+	return p, nil
+}
+
+// applyPatch applies the operations of a PATCH request to a resource.
+func applyPatch(resource map[string]any, operations []PatchOperation) error {
+	for _, op := range operations {
+		var value any
+		if len(op.Value) > 0 {
+			if err := json.Unmarshal(op.Value, &value); err != nil {
+				return ErrInvalidSyntax.Errorf("invalid value: %w", err)
+			}
+		}
+
+		switch opName := strings.ToLower(op.Op); opName {
+		case "add", "replace":
+			replace := opName == "replace"
+			if op.Path == "" {
+				// Without a path, the value holds the attributes to set.
+				attributes, ok := value.(map[string]any)
+				if !ok {
+					return ErrInvalidValue.Errorf("the value of an operation without path must be an object")
+				}
+				for path, v := range attributes {
+					if err := setPath(resource, path, v, replace); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if err := setPath(resource, op.Path, value, replace); err != nil {
+				return err
+			}
+		case "remove":
+			if op.Path == "" {
+				return ErrInvalidPath.Errorf("remove operations require a path")
+			}
+			if err := removePath(resource, op.Path, value); err != nil {
+				return err
+			}
+		default:
+			return ErrInvalidSyntax.Errorf("unsupported operation %q", op.Op)
+		}
+	}
+	return nil
+}
+
+// setPath sets the value of an attribute. Values are added to multi-valued attributes, unless replace
+// is true, in which case they replace the existing values.
+func setPath(resource map[string]any, path string, value any, replace bool) error {
+	p, err := parsePatchPath(path)
+	if err != nil {
+		return err
+	}
+	key, ok := findKey(resource, p.attribute)
+	if !ok {
+		key = p.attribute
+	}
+
+	if p.filter == nil {
+		if p.subAttribute == "" {
+			if _, isMultiValued := value.([]any); isMultiValued && replace {
+				resource[key] = value
+			} else {
+				resource[key] = merge(resource[key], value)
+			}
+			return nil
+		}
+		switch existing := resource[key].(type) {
+		case map[string]any:
+			setAttribute(existing, p.subAttribute, value)
+		case []any:
+			for _, item := range existing {
+				if m, ok := item.(map[string]any); ok {
+					setAttribute(m, p.subAttribute, value)
+				}
+			}
+		default:
+			resource[key] = map[string]any{p.subAttribute: value}
+		}
+		return nil
+	}
+
+	values, _ := resource[key].([]any)
+	matched := false
+	for i, item := range values {
+		m, ok := item.(map[string]any)
+		if !ok || !p.filter.matches(m) {
+			continue
+		}
+		matched = true
+		if p.subAttribute != "" {
+			setAttribute(m, p.subAttribute, value)
+		} else {
+			values[i] = merge(m, value)
+		}
+	}
+	if matched {
+		return nil
+	}
+
+	// A value that does not exist yet is added when the filter identifies it, so that for example
+	// emails[type eq "work"].value sets the work email of a user who has none.
+	f, ok := p.filter.(*attributeFilter)
+	if !ok || f.op != "eq" || len(f.path) != 1 {
+		return ErrInvalidPath.Errorf("no value matches the path %q", path)
+	}
+	item := map[string]any{f.path[0]: f.value}
+	if p.subAttribute != "" {
+		item[p.subAttribute] = value
+	} else if m, ok := value.(map[string]any); ok {
+		item = merge(item, m).(map[string]any)
+	}
+	resource[key] = append(values, item)
+	return nil
+}
+
+func setAttribute(m map[string]any, name string, value any) {
+	key, ok := findKey(m, name)
+	if !ok {
+		key = name
+	}
+	m[key] = merge(m[key], value)
+}
+
+// merge returns the result of setting value on existing: the values of multi-valued attributes are
+// added to the existing values, and the sub-attributes of complex attributes replace the existing ones.
+func merge(existing, value any) any {
+	switch v := value.(type) {
+	case []any:
+		values, ok := existing.([]any)
+		if !ok {
+			return v
+		}
+		for _, item := range v {
+			if !containsValue(values, item) {
+				values = append(values, item)
+			}
+		}
+		return values
+	case map[string]any:
+		m, ok := existing.(map[string]any)
+		if !ok {
+			return v
+		}
+		for name, sub := range v {
+			setAttribute(m, name, sub)
+		}
+		return m
+	}
+	return value
+}
+
+func removePath(resource map[string]any, path string, value any) error {
+	p, err := parsePatchPath(path)
+	if err != nil {
+		return err
+	}
+	key, ok := findKey(resource, p.attribute)
+	if !ok {
+		return nil
+	}
+
+	values, isMultiValued := resource[key].([]any)
+	switch {
+	case p.filter == nil && p.subAttribute == "":
+		removed, ok := value.([]any)
+		if !isMultiValued || !ok {
+			delete(resource, key)
+			return nil
+		}
+		// Some identity providers remove values with their value instead of a filter.
+		kept := make([]any, 0, len(values))
+		for _, item := range values {
+			if !containsValue(removed, item) {
+				kept = append(kept, item)
+			}
+		}
+		resource[key] = kept
+	case p.filter == nil:
+		if m, ok := resource[key].(map[string]any); ok {
+			removeAttribute(m, p.subAttribute)
+		}
+		for _, item := range values {
+			if m, ok := item.(map[string]any); ok {
+				removeAttribute(m, p.subAttribute)
+			}
+		}
+	default:
+		kept := make([]any, 0, len(values))
+		for _, item := range values {
+			m, ok := item.(map[string]any)
+			if !ok || !p.filter.matches(m) {
+				kept = append(kept, item)
+				continue
+			}
+			if p.subAttribute != "" {
+				removeAttribute(m, p.subAttribute)
+				kept = append(kept, m)
+			}
+		}
+		resource[key] = kept
+	}
+	return nil
+}
+
+func removeAttribute(m map[string]any, name string) {
+	if key, ok := findKey(m, name); ok {
+		delete(m, key)
+	}
+}
+
+// containsValue returns true if values contains item. Complex values are identified by their value sub-attribute.
+func containsValue(values []any, item any) bool {
+	id, hasID := valueOf(item)
+	for _, v := range values {
+		if hasID {
+			if other, ok := valueOf(v); ok && other == id {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(v, item) {
+			return true
+		}
+	}
+	return false
+}
+
+func valueOf(item any) (string, bool) {
+	m, ok := item.(map[string]any)
+	if !ok {
+		return "", false
+	}
+	key, ok := findKey(m, "value")
+	if !ok {
+		return "", false
+	}
+	value, ok := m[key].(string)
+	return value, ok
+}
@@ -0,0 +1,142 @@
+package scim
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyPatch(t *testing.T) {
+	group := func() map[string]any {
+		return map[string]any{
+			"displayName": "Engineering",
+			"members": []any{
+				map[string]any{"value": "u1", "display": "alice"},
+				map[string]any{"value": "u2", "display": "bob"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		operations string
+		expected   map[string]any
+	}{
+		{
+			name:       "add members",
+			operations: `[{"op": "add", "path": "members", "value": [{"value": "u2"}, {"value": "u3"}]}]`,
+			expected: map[string]any{
+				"displayName": "Engineering",
+				"members": []any{
+					map[string]any{"value": "u1", "display": "alice"},
+					map[string]any{"value": "u2", "display": "bob"},
+					map[string]any{"value": "u3"},
+				},
+			},
+		},
+		{
+			name:       "replace members",
+			operations: `[{"op": "Replace", "path": "members", "value": [{"value": "u3"}]}]`,
+			expected: map[string]any{
+				"displayName": "Engineering",
+				"members":     []any{map[string]any{"value": "u3"}},
+			},
+		},
+		{
+			name:       "remove member with a filter",
+			operations: `[{"op": "remove", "path": "members[value eq \"u1\"]"}]`,
+			expected: map[string]any{
+				"displayName": "Engineering",
+				"members":     []any{map[string]any{"value": "u2", "display": "bob"}},
+			},
+		},
+		{
+			name:       "remove member with a value",
+			operations: `[{"op": "Remove", "path": "members", "value": [{"value": "u2"}]}]`,
+			expected: map[string]any{
+				"displayName": "Engineering",
+				"members":     []any{map[string]any{"value": "u1", "display": "alice"}},
+			},
+		},
+		{
+			name:       "remove all members",
+			operations: `[{"op": "remove", "path": "members"}]`,
+			expected:   map[string]any{"displayName": "Engineering"},
+		},
+		{
+			name:       "replace without path",
+			operations: `[{"op": "replace", "value": {"displayName": "Platform", "externalId": "grp-1"}}]`,
+			expected: map[string]any{
+				"displayName": "Platform",
+				"externalId":  "grp-1",
+				"members": []any{
+					map[string]any{"value": "u1", "display": "alice"},
+					map[string]any{"value": "u2", "display": "bob"},
+				},
+			},
+		},
+		{
+			name:       "replace sub-attribute of a filtered value",
+			operations: `[{"op": "replace", "path": "members[value eq \"u2\"].display", "value": "robert"}]`,
+			expected: map[string]any{
+				"displayName": "Engineering",
+				"members": []any{
+					map[string]any{"value": "u1", "display": "alice"},
+					map[string]any{"value": "u2", "display": "robert"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ops []PatchOperation
+			require.NoError(t, json.Unmarshal([]byte(tt.operations), &ops))
+
+			resource := group()
+			require.NoError(t, applyPatch(resource, ops))
+			assert.Equal(t, tt.expected, resource)
+		})
+	}
+}
+
+func TestApplyPatchUser(t *testing.T) {
+	user := map[string]any{
+		"userName": "bjensen",
+		"active":   true,
+		"emails":   []any{map[string]any{"value": "bjensen@example.com", "type": "work", "primary": true}},
+	}
+
+	// The operations sent by Microsoft Entra ID when a user is changed.
+	var ops []PatchOperation
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"op": "Replace", "path": "emails[type eq \"work\"].value", "value": "barbara@example.com"},
+		{"op": "Add", "path": "name.givenName", "value": "Barbara"},
+		{"op": "Replace", "path": "active", "value": "False"}
+	]`), &ops))
+	require.NoError(t, applyPatch(user, ops))
+
+	assert.Equal(t, map[string]any{
+		"userName": "bjensen",
+		"active":   "False",
+		"name":     map[string]any{"givenName": "Barbara"},
+		"emails":   []any{map[string]any{"value": "barbara@example.com", "type": "work", "primary": true}},
+	}, user)
+}
+
+func TestApplyPatchErrors(t *testing.T) {
+	for _, operations := range []string{
+		`[{"op": "move", "path": "displayName", "value": "x"}]`,
+		`[{"op": "remove"}]`,
+		`[{"op": "add", "value": "x"}]`,
+		`[{"op": "replace", "path": "members[value eq]", "value": "x"}]`,
+		`[{"op": "replace", "path": "members[display co \"a\"].value", "value": "x"}]`,
+	} {
+		t.Run(operations, func(t *testing.T) {
+			var ops []PatchOperation
+			require.NoError(t, json.Unmarshal([]byte(operations), &ops))
+			require.Error(t, applyPatch(map[string]any{"members": []any{}}, ops))
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	claims "github.com/grafana/authlib/types"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/middleware"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/auth"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/team"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	basePath = "/api/scim/v2"
+
+	defaultCount = 100
+	maxCount     = 1000
+)
+
+// Service is a SCIM 2.0 server (RFC 7643 and RFC 7644), which lets an identity provider create, update
+// and remove the users and teams of an organization as soon as they change in the identity provider.
+// The identity provider authenticates with the token of a service account of the organization.
+type Service struct {
+	cfg                    *setting.Cfg
+	store                  *store
+	db                     db.DB
+	userService            user.Service
+	orgService             org.Service
+	teamService            team.Service
+	teamPermissionsService ac.TeamPermissionsService
+	accesscontrolService   ac.Service
+	authInfoService        login.AuthInfoService
+	authTokenService       auth.UserTokenService
+	log                    log.Logger
+}
+
+func ProvideService(
+	cfg *setting.Cfg,
+	features featuremgmt.FeatureToggles,
+	sqlStore db.DB,
+	routeRegister routing.RouteRegister,
+	accessControl ac.AccessControl,
+	accesscontrolService ac.Service,
+	userService user.Service,
+	orgService org.Service,
+	teamService team.Service,
+	teamPermissionsService ac.TeamPermissionsService,
+	authInfoService login.AuthInfoService,
+	authTokenService auth.UserTokenService,
+) (*Service, error) {
+	s := &Service{
+		cfg:                    cfg,
+		store:                  &store{db: sqlStore},
+		db:                     sqlStore,
+		userService:            userService,
+		orgService:             orgService,
+		teamService:            teamService,
+		teamPermissionsService: teamPermissionsService,
+		accesscontrolService:   accesscontrolService,
+		authInfoService:        authInfoService,
+		authTokenService:       authTokenService,
+		log:                    log.New("scim"),
+	}
+	if !features.IsEnabledGlobally(featuremgmt.FlagEnableSCIM) || (!cfg.SCIM.UserSyncEnabled && !cfg.SCIM.GroupSyncEnabled) {
+		return s, nil
+	}
+
+	if err := declareFixedRoles(accesscontrolService); err != nil {
+		return nil, err
+	}
+	s.registerAPIEndpoints(routeRegister, accessControl)
+
+	return s, nil
+}
+
+func (s *Service) registerAPIEndpoints(router routing.RouteRegister, accessControl ac.AccessControl) {
+	authorize := ac.Middleware(accessControl)
+
+	router.Group(basePath, func(r routing.RouteRegister) {
+		r.Get("/ServiceProviderConfig", routing.Wrap(s.getServiceProviderConfig))
+		r.Get("/ResourceTypes", routing.Wrap(s.getResourceTypes))
+		if s.cfg.SCIM.UserSyncEnabled {
+			r.Get("/Users", routing.Wrap(s.getUsers))
+			r.Post("/Users", routing.Wrap(s.createUser))
+			r.Get("/Users/:id", routing.Wrap(s.getUser))
+			r.Put("/Users/:id", routing.Wrap(s.replaceUser))
+			r.Patch("/Users/:id", routing.Wrap(s.patchUser))
+			r.Delete("/Users/:id", routing.Wrap(s.deleteUser))
+		}
+		if s.cfg.SCIM.GroupSyncEnabled {
+			r.Get("/Groups", routing.Wrap(s.getGroups))
+			r.Post("/Groups", routing.Wrap(s.createGroup))
+			r.Get("/Groups/:id", routing.Wrap(s.getGroup))
+			r.Put("/Groups/:id", routing.Wrap(s.replaceGroup))
+			r.Patch("/Groups/:id", routing.Wrap(s.patchGroup))
+			r.Delete("/Groups/:id", routing.Wrap(s.deleteGroup))
+		}
+	}, middleware.ReqSignedIn, reqServiceAccount, authorize(ac.EvalPermission(ActionProvision)))
+}
+
+// reqServiceAccount rejects the requests that are not authenticated with the token of a service account.
+func reqServiceAccount(c *contextmodel.ReqContext) {
+	if !c.SignedInUser.IsIdentityType(claims.TypeServiceAccount) {
+		c.JsonApiErr(http.StatusForbidden, "SCIM requests must use the token of a service account", nil)
+	}
+}
+
+func scimResponse(status int, body any) *response.NormalResponse {
+	return response.JSON(status, body).SetHeader("Content-Type", ContentType)
+}
+
+// errorResponse returns an error in the format of RFC 7644, Section 3.12.
+func (s *Service) errorResponse(err error) response.Response {
+	body := ErrorResponse{Schemas: []string{SchemaError}}
+	status := http.StatusInternalServerError
+
+	var gfErr errutil.Error
+	if errors.As(err, &gfErr) {
+		status = gfErr.Reason.Status().HTTPStatus()
+		body.ScimType = scimTypes[gfErr.MessageID]
+		body.Detail = gfErr.LogMessage
+	}
+	if status >= http.StatusInternalServerError {
+		s.log.Error("SCIM request failed", "error", err)
+		body.Detail = "Internal server error"
+	}
+
+	body.Status = strconv.Itoa(status)
+	return scimResponse(status, body)
+}
+
+func decode(c *contextmodel.ReqContext, v any) error {
+	if err := json.NewDecoder(c.Req.Body).Decode(v); err != nil {
+		return ErrInvalidSyntax.Errorf("invalid JSON: %w", err)
+	}
+	return nil
+}
+
+// listResponse filters the resources and returns the requested page. startIndex is 1-based.
+func listResponse(c *contextmodel.ReqContext, resources []any) (*ListResponse, error) {
+	query := c.Req.URL.Query()
+	startIndex := 1
+	if v := query.Get("startIndex"); v != "" {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, ErrInvalidValue.Errorf("invalid startIndex %q", v)
+		}
+		startIndex = max(i, 1)
+	}
+	count := defaultCount
+	if v := query.Get("count"); v != "" {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, ErrInvalidValue.Errorf("invalid count %q", v)
+		}
+		count = min(max(i, 0), maxCount)
+	}
+
+	if expr := query.Get("filter"); expr != "" {
+		f, err := parseFilter(expr)
+		if err != nil {
+			return nil, err
+		}
+		filtered := make([]any, 0, len(resources))
+		for _, r := range resources {
+			m, err := toMap(r)
+			if err != nil {
+				return nil, err
+			}
+			if f.matches(m) {
+				filtered = append(filtered, r)
+			}
+		}
+		resources = filtered
+	}
+
+	page := []any{}
+	if start := startIndex - 1; start < len(resources) {
+		page = resources[start:min(start+count, len(resources))]
+	}
+	return &ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: len(resources),
+		StartIndex:   startIndex,
+		ItemsPerPage: len(page),
+		Resources:    page,
+	}, nil
+}
+
+func (s *Service) location(resourceType, id string) string {
+	return s.cfg.AppURL + basePath[1:] + "/" + resourceType + "/" + id
+}
+
+func (s *Service) getServiceProviderConfig(c *contextmodel.ReqContext) response.Response {
+	return scimResponse(http.StatusOK, map[string]any{
+		"schemas":        []string{SchemaServiceProviderConfig},
+		"patch":          map[string]any{"supported": true},
+		"bulk":           map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]any{"supported": true, "maxResults": maxCount},
+		"changePassword": map[string]any{"supported": false},
+		"sort":           map[string]any{"supported": false},
+		"etag":           map[string]any{"supported": false},
+		"authenticationSchemes": []map[string]any{{
+			"type":        "oauthbearertoken",
+			"name":        "Service account token",
+			"description": "Authentication with the token of a Grafana service account",
+		}},
+	})
+}
+
+func (s *Service) getResourceTypes(c *contextmodel.ReqContext) response.Response {
+	resources := []any{}
+	if s.cfg.SCIM.UserSyncEnabled {
+		resources = append(resources, map[string]any{
+			"schemas":  []string{SchemaResourceType},
+			"id":       "User",
+			"name":     "User",
+			"endpoint": "/Users",
+			"schema":   SchemaUser,
+		})
+	}
+	if s.cfg.SCIM.GroupSyncEnabled {
+		resources = append(resources, map[string]any{
+			"schemas":  []string{SchemaResourceType},
+			"id":       "Group",
+			"name":     "Group",
+			"endpoint": "/Groups",
+			"schema":   SchemaGroup,
+		})
+	}
+	return scimResponse(http.StatusOK, &ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: len(resources),
+		StartIndex:   1,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
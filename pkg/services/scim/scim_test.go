@@ -0,0 +1,320 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/actest"
+	"github.com/grafana/grafana/pkg/services/auth/authtest"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/login/authinfoimpl"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/org/orgimpl"
+	"github.com/grafana/grafana/pkg/services/quota/quotaimpl"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	"github.com/grafana/grafana/pkg/services/supportbundles/supportbundlestest"
+	"github.com/grafana/grafana/pkg/services/team"
+	"github.com/grafana/grafana/pkg/services/team/teamimpl"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/userimpl"
+	"github.com/grafana/grafana/pkg/tests/testsuite"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func TestMain(m *testing.M) {
+	testsuite.Run(m)
+}
+
+// fakeTeamPermissionsService manages team memberships like the team resource permissions service.
+type fakeTeamPermissionsService struct {
+	ac.TeamPermissionsService
+	db db.DB
+}
+
+func (f *fakeTeamPermissionsService) SetUserPermission(ctx context.Context, orgID int64, u ac.User, resourceID, permission string) (*ac.ResourcePermission, error) {
+	teamID, err := strconv.ParseInt(resourceID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return nil, f.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		if permission == "" {
+			return teamimpl.RemoveTeamMemberHook(sess, &team.RemoveTeamMemberCommand{OrgID: orgID, UserID: u.ID, TeamID: teamID})
+		}
+		return teamimpl.AddOrUpdateTeamMemberHook(sess, u.ID, orgID, teamID, u.IsExternal, team.PermissionTypeMember)
+	})
+}
+
+type testService struct {
+	*Service
+	revoked []int64
+}
+
+func setupTestService(t *testing.T) *testService {
+	t.Helper()
+
+	sqlStore, cfg := db.InitTestDBWithCfg(t)
+	cfg.AppURL = "http://localhost:3000/"
+	cfg.SCIM.AuthModule = "auth.saml"
+
+	quotaService := quotaimpl.ProvideService(sqlStore, cfg)
+	orgService, err := orgimpl.ProvideService(sqlStore, cfg, quotaService)
+	require.NoError(t, err)
+	userService, err := userimpl.ProvideService(sqlStore, orgService, cfg, nil, nil, tracing.InitializeTracerForTest(), quotaService, supportbundlestest.NewFakeBundleService())
+	require.NoError(t, err)
+	teamService, err := teamimpl.ProvideService(sqlStore, cfg, tracing.InitializeTracerForTest())
+	require.NoError(t, err)
+	secretsService := fakes.NewFakeSecretsService()
+
+	// An organization always keeps an admin, users can only be removed when another admin remains.
+	admin, err := userService.Create(context.Background(), &user.CreateUserCommand{Login: "admin", SkipOrgSetup: true})
+	require.NoError(t, err)
+	_, err = orgService.InsertOrgUser(context.Background(), &org.OrgUser{OrgID: 1, UserID: admin.ID, Role: org.RoleAdmin, Created: time.Now(), Updated: time.Now()})
+	require.NoError(t, err)
+
+	ts := &testService{}
+	tokenService := authtest.NewFakeUserAuthTokenService()
+	tokenService.RevokeAllUserTokensProvider = func(ctx context.Context, userID int64) error {
+		ts.revoked = append(ts.revoked, userID)
+		return nil
+	}
+	ts.Service = &Service{
+		cfg:                    cfg,
+		store:                  &store{db: sqlStore},
+		db:                     sqlStore,
+		userService:            userService,
+		orgService:             orgService,
+		teamService:            teamService,
+		teamPermissionsService: &fakeTeamPermissionsService{db: sqlStore},
+		accesscontrolService:   actest.FakeService{},
+		authInfoService:        authinfoimpl.ProvideService(authinfoimpl.ProvideStore(sqlStore, secretsService), remotecache.NewFakeCacheStorage(), secretsService),
+		authTokenService:       tokenService,
+		log:                    log.NewNopLogger(),
+	}
+	return ts
+}
+
+func (ts *testService) do(t *testing.T, handler func(*contextmodel.ReqContext) response.Response, id, body string) (int, map[string]any) {
+	t.Helper()
+
+	serviceAccount := &user.SignedInUser{UserID: 1000, OrgID: 1, Login: "sa-scim", IsServiceAccount: true}
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req = req.WithContext(identity.WithRequester(req.Context(), serviceAccount))
+	req = web.SetURLParams(req, map[string]string{":id": id})
+
+	resp := handler(&contextmodel.ReqContext{Context: &web.Context{Req: req}, SignedInUser: serviceAccount}).(*response.NormalResponse)
+	var result map[string]any
+	if len(resp.Body()) > 0 {
+		require.NoError(t, json.Unmarshal(resp.Body(), &result))
+	}
+	return resp.Status(), result
+}
+
+func (ts *testService) list(t *testing.T, handler func(*contextmodel.ReqContext) response.Response, filter string) []any {
+	t.Helper()
+
+	serviceAccount := &user.SignedInUser{UserID: 1000, OrgID: 1, Login: "sa-scim", IsServiceAccount: true}
+	req := httptest.NewRequest(http.MethodGet, "/?filter="+strings.ReplaceAll(filter, " ", "+"), nil)
+	resp := handler(&contextmodel.ReqContext{Context: &web.Context{Req: req}, SignedInUser: serviceAccount}).(*response.NormalResponse)
+	require.Equal(t, http.StatusOK, resp.Status())
+
+	var result ListResponse
+	require.NoError(t, json.Unmarshal(resp.Body(), &result))
+	return result.Resources
+}
+
+func TestIntegrationUsers(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ts := setupTestService(t)
+
+	status, created := ts.do(t, ts.createUser, "", `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+		"externalId": "00u1",
+		"userName": "bjensen",
+		"name": {"givenName": "Barbara", "familyName": "Jensen"},
+		"emails": [{"value": "bjensen@example.com", "type": "work", "primary": true}],
+		"active": true
+	}`)
+	require.Equal(t, http.StatusCreated, status)
+	id := created["id"].(string)
+	assert.Equal(t, "00u1", created["externalId"])
+	assert.Equal(t, "Barbara Jensen", created["displayName"])
+	assert.Equal(t, "http://localhost:3000/api/scim/v2/Users/"+id, created["meta"].(map[string]any)["location"])
+
+	t.Run("the user is provisioned in the organization of the service account", func(t *testing.T) {
+		usr, err := ts.userService.GetByLogin(context.Background(), &user.GetUserByLoginQuery{LoginOrEmail: "bjensen"})
+		require.NoError(t, err)
+		assert.True(t, usr.IsProvisioned)
+
+		users := ts.list(t, ts.getUsers, `userName eq "bjensen"`)
+		require.Len(t, users, 1)
+		assert.Equal(t, id, users[0].(map[string]any)["id"])
+		assert.Empty(t, ts.list(t, ts.getUsers, `externalId eq "00u2"`))
+	})
+
+	t.Run("duplicate users are rejected", func(t *testing.T) {
+		status, body := ts.do(t, ts.createUser, "", `{"externalId": "00u2", "userName": "BJensen"}`)
+		assert.Equal(t, http.StatusConflict, status)
+		assert.Equal(t, "uniqueness", body["scimType"])
+	})
+
+	t.Run("users without external ID are rejected", func(t *testing.T) {
+		status, body := ts.do(t, ts.createUser, "", `{"userName": "jdoe"}`)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "invalidValue", body["scimType"])
+	})
+
+	t.Run("disabling a user revokes its sessions", func(t *testing.T) {
+		status, body := ts.do(t, ts.patchUser, id, `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [
+				{"op": "Replace", "path": "active", "value": "False"},
+				{"op": "Replace", "path": "emails[type eq \"work\"].value", "value": "barbara@example.com"}
+			]
+		}`)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, false, body["active"])
+		assert.Equal(t, "barbara@example.com", body["emails"].([]any)[0].(map[string]any)["value"])
+		assert.Len(t, ts.revoked, 1)
+	})
+
+	t.Run("replace a user", func(t *testing.T) {
+		status, body := ts.do(t, ts.replaceUser, id, `{"externalId": "00u1-new", "userName": "barbara", "displayName": "Barbara J.", "active": true}`)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "00u1-new", body["externalId"])
+		assert.Equal(t, "barbara", body["userName"])
+		assert.Equal(t, true, body["active"])
+		assert.Len(t, ts.revoked, 1)
+	})
+
+	t.Run("users that were not provisioned for the organization cannot be changed", func(t *testing.T) {
+		local, err := ts.userService.Create(context.Background(), &user.CreateUserCommand{Login: "local", SkipOrgSetup: true})
+		require.NoError(t, err)
+		_, err = ts.orgService.InsertOrgUser(context.Background(), &org.OrgUser{OrgID: 1, UserID: local.ID, Role: org.RoleViewer, Created: time.Now(), Updated: time.Now()})
+		require.NoError(t, err)
+
+		status, created := ts.do(t, ts.createUser, "", `{"externalId": "00u3", "userName": "jdoe"}`)
+		require.Equal(t, http.StatusCreated, status)
+		multiOrg := created["id"].(string)
+		jdoe, err := ts.userService.GetByLogin(context.Background(), &user.GetUserByLoginQuery{LoginOrEmail: "jdoe"})
+		require.NoError(t, err)
+		_, err = ts.orgService.InsertOrgUser(context.Background(), &org.OrgUser{OrgID: 2, UserID: jdoe.ID, Role: org.RoleViewer, Created: time.Now(), Updated: time.Now()})
+		require.NoError(t, err)
+
+		status, created = ts.do(t, ts.createUser, "", `{"externalId": "00u4", "userName": "root"}`)
+		require.Equal(t, http.StatusCreated, status)
+		serverAdmin := created["id"].(string)
+		root, err := ts.userService.GetByLogin(context.Background(), &user.GetUserByLoginQuery{LoginOrEmail: "root"})
+		require.NoError(t, err)
+		isAdmin := true
+		require.NoError(t, ts.userService.Update(context.Background(), &user.UpdateUserCommand{UserID: root.ID, IsGrafanaAdmin: &isAdmin}))
+
+		for _, uid := range []string{local.UID, multiOrg, serverAdmin} {
+			status, _ := ts.do(t, ts.replaceUser, uid, `{"externalId": "00u5", "userName": "taken-over", "active": false}`)
+			assert.Equal(t, http.StatusForbidden, status)
+			status, _ = ts.do(t, ts.deleteUser, uid, "")
+			assert.Equal(t, http.StatusForbidden, status)
+		}
+		assert.Len(t, ts.revoked, 1)
+
+		usr, err := ts.userService.GetByID(context.Background(), &user.GetUserByIDQuery{ID: local.ID})
+		require.NoError(t, err)
+		assert.False(t, usr.IsProvisioned)
+		assert.Equal(t, "local", usr.Login)
+	})
+
+	t.Run("delete a user", func(t *testing.T) {
+		status, _ := ts.do(t, ts.deleteUser, id, "")
+		require.Equal(t, http.StatusNoContent, status)
+
+		status, _ = ts.do(t, ts.getUser, id, "")
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.Len(t, ts.revoked, 2)
+	})
+}
+
+func TestIntegrationGroups(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ts := setupTestService(t)
+
+	userIDs := make([]string, 0, 2)
+	for _, login := range []string{"alice", "bob"} {
+		status, body := ts.do(t, ts.createUser, "", `{"externalId": "ext-`+login+`", "userName": "`+login+`"}`)
+		require.Equal(t, http.StatusCreated, status)
+		userIDs = append(userIDs, body["id"].(string))
+	}
+
+	status, created := ts.do(t, ts.createGroup, "", `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:Group"],
+		"externalId": "grp-1",
+		"displayName": "Engineering",
+		"members": [{"value": "`+userIDs[0]+`"}]
+	}`)
+	require.Equal(t, http.StatusCreated, status)
+	id := created["id"].(string)
+	require.Len(t, created["members"], 1)
+	assert.Equal(t, "alice", created["members"].([]any)[0].(map[string]any)["display"])
+
+	t.Run("the team is provisioned", func(t *testing.T) {
+		groups := ts.list(t, ts.getGroups, `displayName eq "Engineering"`)
+		require.Len(t, groups, 1)
+
+		teams, err := ts.store.findTeams(context.Background(), 1, id)
+		require.NoError(t, err)
+		require.Len(t, teams, 1)
+		assert.True(t, teams[0].IsProvisioned)
+		assert.Equal(t, "grp-1", teams[0].ExternalUID)
+	})
+
+	t.Run("unknown members are rejected", func(t *testing.T) {
+		status, body := ts.do(t, ts.createGroup, "", `{"displayName": "Unknown", "members": [{"value": "nope"}]}`)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "invalidValue", body["scimType"])
+	})
+
+	t.Run("patch the members", func(t *testing.T) {
+		status, body := ts.do(t, ts.patchGroup, id, `{"Operations": [
+			{"op": "add", "path": "members", "value": [{"value": "`+userIDs[1]+`"}]},
+			{"op": "remove", "path": "members[value eq \"`+userIDs[0]+`\"]"}
+		]}`)
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, body["members"], 1)
+		assert.Equal(t, userIDs[1], body["members"].([]any)[0].(map[string]any)["value"])
+	})
+
+	t.Run("replace a group", func(t *testing.T) {
+		status, body := ts.do(t, ts.replaceGroup, id, `{"displayName": "Platform", "members": []}`)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "Platform", body["displayName"])
+		assert.Equal(t, "grp-1", body["externalId"])
+		assert.Empty(t, body["members"])
+	})
+
+	t.Run("delete a group", func(t *testing.T) {
+		status, _ := ts.do(t, ts.deleteGroup, id, "")
+		require.Equal(t, http.StatusNoContent, status)
+
+		status, body := ts.do(t, ts.getGroup, id, "")
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.Equal(t, "404", body["status"])
+	})
+}
@@ -0,0 +1,124 @@
+package scim
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+)
+
+type userRow struct {
+	ID            int64     `xorm:"id"`
+	UID           string    `xorm:"uid"`
+	Login         string    `xorm:"login"`
+	Email         string    `xorm:"email"`
+	Name          string    `xorm:"name"`
+	IsDisabled    bool      `xorm:"is_disabled"`
+	IsProvisioned bool      `xorm:"is_provisioned"`
+	IsAdmin       bool      `xorm:"is_admin"`
+	Created       time.Time `xorm:"created"`
+	Updated       time.Time `xorm:"updated"`
+	// OrgCount is the number of organizations of the user.
+	OrgCount int64 `xorm:"org_count"`
+	// AuthInfoID is the ID of the connection of the user to the login method of provisioned users, or 0.
+	AuthInfoID  int64  `xorm:"auth_info_id"`
+	ExternalUID string `xorm:"external_uid"`
+}
+
+type teamRow struct {
+	ID            int64     `xorm:"id"`
+	UID           string    `xorm:"uid"`
+	Name          string    `xorm:"name"`
+	ExternalUID   string    `xorm:"external_uid"`
+	IsProvisioned bool      `xorm:"is_provisioned"`
+	Created       time.Time `xorm:"created"`
+	Updated       time.Time `xorm:"updated"`
+	Members       []*memberRow
+}
+
+type memberRow struct {
+	TeamID  int64  `xorm:"team_id"`
+	UserID  int64  `xorm:"user_id"`
+	UserUID string `xorm:"user_uid"`
+	Login   string `xorm:"login"`
+}
+
+type store struct {
+	db db.DB
+}
+
+// findUsers returns the users of an organization, with their external ID for the login method of
+// provisioned users. Service accounts are never returned.
+func (s *store) findUsers(ctx context.Context, orgID int64, authModule, uid string) ([]*userRow, error) {
+	dialect := s.db.GetDialect()
+	sql := `SELECT u.id, u.uid, u.login, u.email, u.name, u.is_disabled, u.is_provisioned, u.is_admin, u.created, u.updated,
+		(SELECT COUNT(*) FROM org_user AS other WHERE other.user_id = u.id) AS org_count,
+		COALESCE(ua.id, 0) AS auth_info_id, COALESCE(ua.external_uid, '') AS external_uid
+		FROM ` + dialect.Quote("user") + ` AS u
+		INNER JOIN org_user AS ou ON ou.user_id = u.id
+		LEFT JOIN user_auth AS ua ON ua.user_id = u.id AND ua.auth_module = ?
+		WHERE ou.org_id = ? AND u.is_service_account = ?`
+	args := []any{authModule, orgID, dialect.BooleanValue(false)}
+	if uid != "" {
+		sql += " AND u.uid = ?"
+		args = append(args, uid)
+	}
+	sql += " ORDER BY u.id"
+
+	rows := []*userRow{}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.SQL(sql, args...).Find(&rows)
+	})
+	return rows, err
+}
+
+// findTeams returns the teams of an organization with their members.
+func (s *store) findTeams(ctx context.Context, orgID int64, uid string) ([]*teamRow, error) {
+	teamSQL := "SELECT id, uid, name, external_uid, is_provisioned, created, updated FROM team WHERE org_id = ?"
+	memberSQL := `SELECT tm.team_id, tm.user_id, u.uid AS user_uid, u.login
+		FROM team_member AS tm
+		INNER JOIN ` + s.db.GetDialect().Quote("user") + ` AS u ON u.id = tm.user_id
+		INNER JOIN team AS t ON t.id = tm.team_id
+		WHERE tm.org_id = ?`
+	args := []any{orgID}
+	if uid != "" {
+		teamSQL += " AND uid = ?"
+		memberSQL += " AND t.uid = ?"
+		args = append(args, uid)
+	}
+	teamSQL += " ORDER BY id"
+	memberSQL += " ORDER BY u.id"
+
+	teams := []*teamRow{}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		if err := sess.SQL(teamSQL, args...).Find(&teams); err != nil {
+			return err
+		}
+		var members []*memberRow
+		if err := sess.SQL(memberSQL, args...).Find(&members); err != nil {
+			return err
+		}
+
+		byID := make(map[int64]*teamRow, len(teams))
+		for _, t := range teams {
+			t.Members = []*memberRow{}
+			byID[t.ID] = t
+		}
+		for _, m := range members {
+			if t, ok := byID[m.TeamID]; ok {
+				t.Members = append(t.Members, m)
+			}
+		}
+		return nil
+	})
+	return teams, err
+}
+
+// updateTeamProvisioning sets the external ID of a team and marks it as provisioned, which prevents
+// changes to the team outside of SCIM.
+func (s *store) updateTeamProvisioning(ctx context.Context, teamID int64, externalUID string) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Exec("UPDATE team SET external_uid = ?, is_provisioned = ? WHERE id = ?", externalUID, s.db.GetDialect().BooleanValue(true), teamID)
+		return err
+	})
+}
@@ -0,0 +1,344 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// userSpec holds the attributes of a SCIM user that are stored by Grafana.
+type userSpec struct {
+	login      string
+	email      string
+	name       string
+	externalID string
+	active     bool
+}
+
+func parseUser(u *User) (*userSpec, error) {
+	spec := &userSpec{
+		login:      strings.TrimSpace(u.UserName),
+		externalID: u.ExternalID,
+		name:       u.DisplayName,
+		active:     u.Active == nil || *u.Active,
+	}
+	if spec.login == "" {
+		return nil, ErrInvalidValue.Errorf("userName is required")
+	}
+	if spec.externalID == "" {
+		return nil, ErrInvalidValue.Errorf("externalId is required")
+	}
+
+	if spec.name == "" && u.Name != nil {
+		spec.name = u.Name.Formatted
+		if spec.name == "" {
+			spec.name = strings.TrimSpace(u.Name.GivenName + " " + u.Name.FamilyName)
+		}
+	}
+
+	for _, email := range u.Emails {
+		if email.Primary || spec.email == "" {
+			spec.email = strings.TrimSpace(email.Value)
+		}
+		if email.Primary {
+			break
+		}
+	}
+	return spec, nil
+}
+
+func (s *Service) toUser(row *userRow) *User {
+	active := !row.IsDisabled
+	u := &User{
+		Schemas:     []string{SchemaUser},
+		ID:          row.UID,
+		ExternalID:  row.ExternalUID,
+		UserName:    row.Login,
+		DisplayName: row.Name,
+		Active:      &active,
+		Meta: &Meta{
+			ResourceType: "User",
+			Created:      row.Created,
+			LastModified: row.Updated,
+			Location:     s.location("Users", row.UID),
+		},
+	}
+	if row.Name != "" {
+		u.Name = &Name{Formatted: row.Name}
+	}
+	if row.Email != "" {
+		u.Emails = []Email{{Value: row.Email, Type: "work", Primary: true}}
+	}
+	return u
+}
+
+func (s *Service) getUserRow(ctx context.Context, orgID int64, uid string) (*userRow, error) {
+	rows, err := s.store.findUsers(ctx, orgID, s.cfg.SCIM.AuthModule, uid)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrNotFound.Errorf("user %q not found", uid)
+	}
+	return rows[0], nil
+}
+
+// getManagedUserRow returns a user that SCIM can change: a user that was provisioned for the organization by
+// SCIM, and that neither is a server admin nor belongs to another organization.
+func (s *Service) getManagedUserRow(ctx context.Context, orgID int64, uid string) (*userRow, error) {
+	row, err := s.getUserRow(ctx, orgID, uid)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case !row.IsProvisioned || row.AuthInfoID == 0:
+		return nil, ErrNotManaged.Errorf("user %q was not provisioned by SCIM", uid)
+	case row.IsAdmin:
+		return nil, ErrNotManaged.Errorf("user %q is a server admin", uid)
+	case row.OrgCount > 1:
+		return nil, ErrNotManaged.Errorf("user %q belongs to other organizations", uid)
+	}
+	return row, nil
+}
+
+func (s *Service) getUsers(c *contextmodel.ReqContext) response.Response {
+	rows, err := s.store.findUsers(c.Req.Context(), c.SignedInUser.GetOrgID(), s.cfg.SCIM.AuthModule, "")
+	if err != nil {
+		return s.errorResponse(err)
+	}
+
+	resources := make([]any, 0, len(rows))
+	for _, row := range rows {
+		resources = append(resources, s.toUser(row))
+	}
+	list, err := listResponse(c, resources)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	return scimResponse(http.StatusOK, list)
+}
+
+func (s *Service) getUser(c *contextmodel.ReqContext) response.Response {
+	row, err := s.getUserRow(c.Req.Context(), c.SignedInUser.GetOrgID(), web.Params(c.Req)[":id"])
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	return scimResponse(http.StatusOK, s.toUser(row))
+}
+
+func (s *Service) createUser(c *contextmodel.ReqContext) response.Response {
+	var u User
+	if err := decode(c, &u); err != nil {
+		return s.errorResponse(err)
+	}
+	spec, err := parseUser(&u)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+
+	ctx := c.Req.Context()
+	orgID := c.SignedInUser.GetOrgID()
+	var uid string
+	err = s.db.InTransaction(ctx, func(ctx context.Context) error {
+		usr, err := s.userService.Create(ctx, &user.CreateUserCommand{
+			Login:         spec.login,
+			Email:         spec.email,
+			Name:          spec.name,
+			IsDisabled:    !spec.active,
+			IsProvisioned: true,
+			EmailVerified: spec.email != "",
+		})
+		if errors.Is(err, user.ErrUserAlreadyExists) {
+			return ErrUniqueness.Errorf("a user with the login %q or the email %q already exists", spec.login, spec.email)
+		}
+		if err != nil {
+			return err
+		}
+		uid = usr.UID
+
+		// Provisioned users are created without organization, they join the organization of the service account.
+		now := time.Now()
+		if _, err := s.orgService.InsertOrgUser(ctx, &org.OrgUser{
+			OrgID:   orgID,
+			UserID:  usr.ID,
+			Role:    org.RoleType(s.cfg.AutoAssignOrgRole),
+			Created: now,
+			Updated: now,
+		}); err != nil {
+			return err
+		}
+
+		return s.authInfoService.SetAuthInfo(ctx, &login.SetAuthInfoCommand{
+			AuthModule:  s.cfg.SCIM.AuthModule,
+			AuthId:      spec.externalID,
+			UserId:      usr.ID,
+			ExternalUID: spec.externalID,
+		})
+	})
+	if err != nil {
+		return s.errorResponse(err)
+	}
+
+	row, err := s.getUserRow(ctx, orgID, uid)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	return scimResponse(http.StatusCreated, s.toUser(row)).SetHeader("Location", s.location("Users", uid))
+}
+
+func (s *Service) replaceUser(c *contextmodel.ReqContext) response.Response {
+	row, err := s.getManagedUserRow(c.Req.Context(), c.SignedInUser.GetOrgID(), web.Params(c.Req)[":id"])
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	var u User
+	if err := decode(c, &u); err != nil {
+		return s.errorResponse(err)
+	}
+	return s.updateUser(c, row, &u)
+}
+
+func (s *Service) patchUser(c *contextmodel.ReqContext) response.Response {
+	row, err := s.getManagedUserRow(c.Req.Context(), c.SignedInUser.GetOrgID(), web.Params(c.Req)[":id"])
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	var req PatchRequest
+	if err := decode(c, &req); err != nil {
+		return s.errorResponse(err)
+	}
+
+	resource, err := toMap(s.toUser(row))
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	if err := applyPatch(resource, req.Operations); err != nil {
+		return s.errorResponse(err)
+	}
+	// Some identity providers send booleans as strings, for example "False".
+	if key, ok := findKey(resource, "active"); ok {
+		if v, ok := resource[key].(string); ok {
+			active, err := strconv.ParseBool(v)
+			if err != nil {
+				return s.errorResponse(ErrInvalidValue.Errorf("invalid value %q for active", v))
+			}
+			resource[key] = active
+		}
+	}
+
+	var u User
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	if err := json.Unmarshal(data, &u); err != nil {
+		return s.errorResponse(ErrInvalidValue.Errorf("invalid user: %w", err))
+	}
+	return s.updateUser(c, row, &u)
+}
+
+// updateUser saves the attributes of a replaced or patched user. Disabled users are signed out.
+func (s *Service) updateUser(c *contextmodel.ReqContext, row *userRow, u *User) response.Response {
+	spec, err := parseUser(u)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+
+	ctx := c.Req.Context()
+	disable := row.IsDisabled == spec.active && !spec.active
+	err = s.db.InTransaction(ctx, func(ctx context.Context) error {
+		if err := s.checkUserConflict(ctx, row, spec); err != nil {
+			return err
+		}
+
+		cmd := &user.UpdateUserCommand{UserID: row.ID, Login: spec.login, Email: spec.email, Name: spec.name}
+		if row.IsDisabled == spec.active {
+			disabled := !spec.active
+			cmd.IsDisabled = &disabled
+		}
+		if err := s.userService.Update(ctx, cmd); err != nil {
+			return err
+		}
+
+		if row.ExternalUID != spec.externalID {
+			return s.authInfoService.UpdateAuthInfo(ctx, &login.UpdateAuthInfoCommand{
+				AuthModule:  s.cfg.SCIM.AuthModule,
+				UserId:      row.ID,
+				ExternalUID: spec.externalID,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return s.errorResponse(err)
+	}
+
+	if disable {
+		if err := s.authTokenService.RevokeAllUserTokens(ctx, row.ID); err != nil {
+			s.log.Error("Failed to revoke the sessions of a disabled user", "userID", row.ID, "error", err)
+		}
+	}
+
+	updated, err := s.getUserRow(ctx, c.SignedInUser.GetOrgID(), row.UID)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	return scimResponse(http.StatusOK, s.toUser(updated))
+}
+
+// checkUserConflict returns an error if the new login or email of a user belongs to another user.
+func (s *Service) checkUserConflict(ctx context.Context, row *userRow, spec *userSpec) error {
+	for _, loginOrEmail := range []string{spec.login, spec.email} {
+		if loginOrEmail == "" {
+			continue
+		}
+		other, err := s.userService.GetByLogin(ctx, &user.GetUserByLoginQuery{LoginOrEmail: loginOrEmail})
+		if errors.Is(err, user.ErrUserNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if other.ID != row.ID {
+			return ErrUniqueness.Errorf("another user has the login or email %q", loginOrEmail)
+		}
+	}
+	return nil
+}
+
+// deleteUser removes a user from the organization, which deletes the user since managed users do not belong to any
+// other organization. The sessions of the user are revoked once it is deleted.
+func (s *Service) deleteUser(c *contextmodel.ReqContext) response.Response {
+	ctx := c.Req.Context()
+	orgID := c.SignedInUser.GetOrgID()
+	row, err := s.getManagedUserRow(ctx, orgID, web.Params(c.Req)[":id"])
+	if err != nil {
+		return s.errorResponse(err)
+	}
+
+	cmd := &org.RemoveOrgUserCommand{
+		UserID:                   row.ID,
+		OrgID:                    orgID,
+		ShouldDeleteOrphanedUser: true,
+	}
+	if err := s.orgService.RemoveOrgUser(ctx, cmd); err != nil {
+		return s.errorResponse(err)
+	}
+	if cmd.UserWasDeleted {
+		if err := s.authTokenService.RevokeAllUserTokens(ctx, row.ID); err != nil {
+			s.log.Error("Failed to revoke the sessions of a deleted user", "userID", row.ID, "error", err)
+		}
+	}
+	return response.Empty(http.StatusNoContent)
+}
@@ -8,6 +8,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
 
@@ -230,3 +231,64 @@ func buildExtraConnectionString(sep rune, urlQueryParams map[string][]string) st
 	}
 	return sb.String()
 }
+
+// ReplicaConfig is the configuration of the read replicas of the database.
+type ReplicaConfig struct {
+	// Replicas are the database configurations of the replicas.
+	Replicas []*DatabaseConfig
+	// HealthCheckInterval is how often the replicas are checked to be reachable and up to date.
+	HealthCheckInterval time.Duration
+	// MaxReplicationLag is the replication lag above which a replica is not used, and reads go to other replicas or
+	// the primary.
+	MaxReplicationLag time.Duration
+}
+
+// NewReplicaConfig reads the read replicas of the [database_replicas] section. The replicas use the settings of the
+// [database] section, except for their host and the settings of [database_replicas]. Read replicas are only supported
+// for MySQL and Postgres.
+func NewReplicaConfig(cfg *setting.Cfg, features featuremgmt.FeatureToggles) (*ReplicaConfig, error) {
+	if cfg == nil {
+		return nil, errors.New("cfg cannot be nil")
+	}
+
+	sec := cfg.Raw.Section("database_replicas")
+	replicaCfg := &ReplicaConfig{
+		HealthCheckInterval: sec.Key("health_check_interval").MustDuration(10 * time.Second),
+		MaxReplicationLag:   sec.Key("max_replication_lag").MustDuration(30 * time.Second),
+	}
+	if replicaCfg.HealthCheckInterval <= 0 {
+		return nil, fmt.Errorf("database_replicas health_check_interval must be positive")
+	}
+
+	hosts := util.SplitString(sec.Key("hosts").String())
+	if len(hosts) == 0 {
+		return replicaCfg, nil
+	}
+
+	for _, host := range hosts {
+		dbCfg := &DatabaseConfig{}
+		if err := dbCfg.readConfig(cfg); err != nil {
+			return nil, err
+		}
+		if dbCfg.Type != migrator.MySQL && dbCfg.Type != migrator.Postgres {
+			return nil, fmt.Errorf("read replicas are not supported for database type %q", dbCfg.Type)
+		}
+
+		// The connection string of the primary can't be used for the replicas.
+		dbCfg.ConnectionString = ""
+		dbCfg.Host = host
+		dbCfg.Name = sec.Key("name").MustString(dbCfg.Name)
+		dbCfg.User = sec.Key("user").MustString(dbCfg.User)
+		dbCfg.Pwd = sec.Key("password").MustString(dbCfg.Pwd)
+		dbCfg.MaxOpenConn = sec.Key("max_open_conn").MustInt(dbCfg.MaxOpenConn)
+		dbCfg.MaxIdleConn = sec.Key("max_idle_conn").MustInt(dbCfg.MaxIdleConn)
+		dbCfg.ConnMaxLifetime = sec.Key("conn_max_lifetime").MustInt(dbCfg.ConnMaxLifetime)
+
+		if err := dbCfg.buildConnectionString(cfg, features); err != nil {
+			return nil, fmt.Errorf("invalid read replica %s: %w", host, err)
+		}
+		replicaCfg.Replicas = append(replicaCfg.Replicas, dbCfg)
+	}
+
+	return replicaCfg, nil
+}
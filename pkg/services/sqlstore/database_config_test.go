@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -221,3 +222,45 @@ func TestBuildConnectionStringPostgres(t *testing.T) {
 		})
 	}
 }
+
+func TestNewReplicaConfig(t *testing.T) {
+	t.Run("without replicas", func(t *testing.T) {
+		cfg := makeDatabaseTestConfig(t, databaseConfigTest{dbType: "postgres", dbHost: "primary:5432"})
+
+		replicaCfg, err := NewReplicaConfig(cfg, nil)
+		require.NoError(t, err)
+		assert.Empty(t, replicaCfg.Replicas)
+		assert.Equal(t, 10*time.Second, replicaCfg.HealthCheckInterval)
+		assert.Equal(t, 30*time.Second, replicaCfg.MaxReplicationLag)
+	})
+
+	t.Run("replicas use the database settings", func(t *testing.T) {
+		cfg := makeDatabaseTestConfig(t, databaseConfigTest{dbType: "postgres", dbHost: "primary:5432", dbUser: "grafana", dbPwd: "secret"})
+		sec, err := cfg.Raw.NewSection("database_replicas")
+		require.NoError(t, err)
+		_, err = sec.NewKey("hosts", "replica1:5432, replica2:5433")
+		require.NoError(t, err)
+		_, err = sec.NewKey("user", "reader")
+		require.NoError(t, err)
+		_, err = sec.NewKey("max_replication_lag", "5s")
+		require.NoError(t, err)
+
+		replicaCfg, err := NewReplicaConfig(cfg, nil)
+		require.NoError(t, err)
+		require.Len(t, replicaCfg.Replicas, 2)
+		assert.Equal(t, 5*time.Second, replicaCfg.MaxReplicationLag)
+		assert.Equal(t, "user=reader host=replica1 port=5432 dbname=test_db sslmode='' sslcert='' sslkey='' sslrootcert='' password=secret", replicaCfg.Replicas[0].ConnectionString)
+		assert.Equal(t, "user=reader host=replica2 port=5433 dbname=test_db sslmode='' sslcert='' sslkey='' sslrootcert='' password=secret", replicaCfg.Replicas[1].ConnectionString)
+	})
+
+	t.Run("replicas are not supported for sqlite", func(t *testing.T) {
+		cfg := makeDatabaseTestConfig(t, databaseConfigTest{dbType: "sqlite3"})
+		sec, err := cfg.Raw.NewSection("database_replicas")
+		require.NoError(t, err)
+		_, err = sec.NewKey("hosts", "replica1")
+		require.NoError(t, err)
+
+		_, err = NewReplicaConfig(cfg, nil)
+		require.Error(t, err)
+	})
+}
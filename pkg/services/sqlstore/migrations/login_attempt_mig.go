@@ -39,4 +39,32 @@ func addLoginAttemptMigrations(mg *Migrator) {
 		"username":   "username",
 		"ip_address": "ip_address",
 	})
+
+	// The subnet of the IP address is used to detect password spraying across many usernames.
+	mg.AddMigration("add column subnet to login_attempt", NewAddColumnMigration(loginAttemptV2, &Column{
+		Name: "subnet", Type: DB_NVarchar, Length: 50, Nullable: true,
+	}))
+	mg.AddMigration("add index login_attempt.subnet_created", NewAddIndexMigration(loginAttemptV2, &Index{
+		Cols: []string{"subnet", "created"},
+	}))
+
+	loginLockoutV1 := Table{
+		Name: "login_lockout",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "kind", Type: DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "lock_key", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "level", Type: DB_Int, Nullable: false},
+			{Name: "locked_until", Type: DB_BigInt, Nullable: false},
+			{Name: "created", Type: DB_BigInt, Nullable: false},
+			{Name: "updated", Type: DB_BigInt, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"kind", "lock_key"}, Type: UniqueIndex},
+			{Cols: []string{"locked_until"}},
+		},
+	}
+
+	mg.AddMigration("create login_lockout table v1", NewAddTableMigration(loginLockoutV1))
+	addTableIndicesMigrations(mg, "v1", loginLockoutV1)
 }
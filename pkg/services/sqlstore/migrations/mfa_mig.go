@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addMFAMigrations(mg *Migrator) {
+	factorV1 := Table{
+		Name: "user_mfa_factor",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "type", Type: DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "name", Type: DB_NVarchar, Length: 190, Nullable: false},
+			// secret is the encrypted key of an authenticator app.
+			{Name: "secret", Type: DB_Text, Nullable: true},
+			// credential_id and public_key identify a security key.
+			{Name: "credential_id", Type: DB_NVarchar, Length: 255, Nullable: true},
+			{Name: "public_key", Type: DB_Text, Nullable: true},
+			// counter is the last time step of an authenticator app or the signature counter of a
+			// security key, used to reject codes and signatures that are replayed.
+			{Name: "counter", Type: DB_BigInt, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "last_used", Type: DB_DateTime, Nullable: true},
+		},
+		Indices: []*Index{
+			{Cols: []string{"user_id"}},
+		},
+	}
+
+	mg.AddMigration("create user_mfa_factor table v1", NewAddTableMigration(factorV1))
+	addTableIndicesMigrations(mg, "v1", factorV1)
+
+	recoveryCodeV1 := Table{
+		Name: "user_mfa_recovery_code",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "code_hash", Type: DB_NVarchar, Length: 64, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"user_id", "code_hash"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create user_mfa_recovery_code table v1", NewAddTableMigration(recoveryCodeV1))
+	addTableIndicesMigrations(mg, "v1", recoveryCodeV1)
+}
@@ -153,4 +153,14 @@ func (oss *OSSMigrations) AddMigration(mg *Migrator) {
 	accesscontrol.AddDatasourceDrilldownRemovalMigration(mg)
 
 	ualert.DropTitleUniqueIndexMigration(mg)
+
+	ualert.AddAlertRuleBacktestTable(mg)
+
+	ualert.AddAlertStateHistoryTables(mg)
+
+	addAuditLogMigrations(mg)
+
+	addReportMigrations(mg)
+
+	addMFAMigrations(mg)
 }
@@ -0,0 +1,45 @@
+package ualert
+
+import "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+// AddAlertRuleBacktestTable creates the table that holds the state and results of backtesting runs.
+func AddAlertRuleBacktestTable(mg *migrator.Migrator) {
+	backtestTable := migrator.Table{
+		Name: "alert_rule_backtest",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "uid", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: false},
+			{Name: "rule_uid", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: false},
+			{Name: "rule_version", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "title", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "status", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "progress", Type: migrator.DB_Int, Nullable: false},
+			{Name: "evaluations", Type: migrator.DB_Int, Nullable: false},
+			{Name: "range_from", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "range_to", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "config", Type: migrator.DB_LongText, Nullable: false},
+			{Name: "result", Type: migrator.DB_LongText, Nullable: true},
+			{Name: "notifications", Type: migrator.DB_LongText, Nullable: true},
+			{Name: "error", Type: migrator.DB_Text, Nullable: true},
+			{Name: "created_by", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: false},
+			{Name: "created", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "updated", Type: migrator.DB_DateTime, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "uid"}, Type: migrator.UniqueIndex},
+			{Cols: []string{"org_id", "rule_uid"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("add alert_rule_backtest table", migrator.NewAddTableMigration(backtestTable))
+	mg.AddMigration("add unique index on org_id and uid to alert_rule_backtest table", migrator.NewAddIndexMigration(backtestTable, backtestTable.Indices[0]))
+	mg.AddMigration("add index on org_id and rule_uid to alert_rule_backtest table", migrator.NewAddIndexMigration(backtestTable, backtestTable.Indices[1]))
+
+	mg.AddMigration("add namespace_uid column to alert_rule_backtest table", migrator.NewAddColumnMigration(backtestTable, &migrator.Column{
+		Name: "namespace_uid", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: false, Default: "''",
+	}))
+	mg.AddMigration("add suppressed column to alert_rule_backtest table", migrator.NewAddColumnMigration(backtestTable, &migrator.Column{
+		Name: "suppressed", Type: migrator.DB_LongText, Nullable: true,
+	}))
+}
@@ -0,0 +1,63 @@
+package ualert
+
+import "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+// AddAlertStateHistoryTables creates the tables of the "sql" state history backend. The labels of the alert
+// instances are kept in a separate table, once per instance fingerprint, so that label matchers can use an index.
+func AddAlertStateHistoryTables(mg *migrator.Migrator) {
+	historyTable := migrator.Table{
+		Name: "alert_state_history",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "rule_uid", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: false},
+			{Name: "rule_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "rule_title", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "rule_group", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "folder_uid", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: false},
+			{Name: "dashboard_uid", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: true},
+			{Name: "panel_id", Type: migrator.DB_BigInt, Nullable: true},
+			{Name: "fingerprint", Type: migrator.DB_NVarchar, Length: 16, Nullable: false},
+			{Name: "previous_state", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "current_state", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "error", Type: migrator.DB_Text, Nullable: true},
+			{Name: "state_values", Type: migrator.DB_Text, Nullable: true},
+			{Name: "rule_condition", Type: migrator.DB_NVarchar, Length: 190, Nullable: true},
+			{Name: "labels", Type: migrator.DB_Text, Nullable: false},
+			{Name: "evaluated_at", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "evaluated_at"}, Type: migrator.IndexType},
+			{Cols: []string{"org_id", "rule_uid", "evaluated_at"}, Type: migrator.IndexType},
+			{Cols: []string{"org_id", "dashboard_uid", "panel_id"}, Type: migrator.IndexType},
+			{Cols: []string{"org_id", "fingerprint"}, Type: migrator.IndexType},
+			{Cols: []string{"evaluated_at"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("add alert_state_history table", migrator.NewAddTableMigration(historyTable))
+	mg.AddMigration("add index on org_id and evaluated_at to alert_state_history table", migrator.NewAddIndexMigration(historyTable, historyTable.Indices[0]))
+	mg.AddMigration("add index on org_id, rule_uid and evaluated_at to alert_state_history table", migrator.NewAddIndexMigration(historyTable, historyTable.Indices[1]))
+	mg.AddMigration("add index on org_id, dashboard_uid and panel_id to alert_state_history table", migrator.NewAddIndexMigration(historyTable, historyTable.Indices[2]))
+	mg.AddMigration("add index on org_id and fingerprint to alert_state_history table", migrator.NewAddIndexMigration(historyTable, historyTable.Indices[3]))
+	mg.AddMigration("add index on evaluated_at to alert_state_history table", migrator.NewAddIndexMigration(historyTable, historyTable.Indices[4]))
+
+	labelTable := migrator.Table{
+		Name: "alert_state_history_label",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "fingerprint", Type: migrator.DB_NVarchar, Length: 16, Nullable: false},
+			{Name: "label_key", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "label_value", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "fingerprint", "label_key"}, Type: migrator.UniqueIndex},
+			{Cols: []string{"org_id", "label_key", "label_value"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("add alert_state_history_label table", migrator.NewAddTableMigration(labelTable))
+	mg.AddMigration("add unique index on org_id, fingerprint and label_key to alert_state_history_label table", migrator.NewAddIndexMigration(labelTable, labelTable.Indices[0]))
+	mg.AddMigration("add index on org_id, label_key and label_value to alert_state_history_label table", migrator.NewAddIndexMigration(labelTable, labelTable.Indices[1]))
+}
@@ -0,0 +1,337 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/services/sqlstore/session"
+	"github.com/grafana/grafana/pkg/util/xorm"
+)
+
+var (
+	replicaHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "grafana",
+		Name:      "database_replica_healthy",
+		Help:      "Whether the read replica is reachable and its replication lag is below the maximum.",
+	}, []string{"replica"})
+	replicaLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "grafana",
+		Name:      "database_replica_lag_seconds",
+		Help:      "Replication lag of the read replica at its last health check.",
+	}, []string{"replica"})
+	replicaReads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "database_replica_reads_total",
+		Help:      "Reads that opted into the read replicas, by the database they were routed to.",
+	}, []string{"target"})
+)
+
+func init() {
+	prometheus.MustRegister(replicaHealthy, replicaLag, replicaReads)
+}
+
+// replica is a read replica of the database.
+type replica struct {
+	name        string
+	engine      *xorm.Engine
+	sqlxsession *session.SessionDB
+
+	mu      sync.RWMutex
+	healthy bool
+	lag     time.Duration
+}
+
+func (r *replica) state() (bool, time.Duration) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.healthy, r.lag
+}
+
+func (r *replica) setState(healthy bool, lag time.Duration) {
+	r.mu.Lock()
+	r.healthy = healthy
+	r.lag = lag
+	r.mu.Unlock()
+
+	replicaLag.WithLabelValues(r.name).Set(lag.Seconds())
+}
+
+// replicaSet routes reads to the healthy read replicas in turn, and to the primary when no replica is healthy. A replica
+// is healthy when it is reachable and its replication lag is below the maximum, as of its last health check.
+type replicaSet struct {
+	replicas []*replica
+	dbType   string
+	maxLag   time.Duration
+	interval time.Duration
+	log      log.Logger
+	next     atomic.Uint64
+}
+
+func newReplicaSet(replicas []*replica, dbType string, replicaCfg *ReplicaConfig, logger log.Logger) *replicaSet {
+	return &replicaSet{
+		replicas: replicas,
+		dbType:   dbType,
+		maxLag:   replicaCfg.MaxReplicationLag,
+		interval: replicaCfg.HealthCheckInterval,
+		log:      logger,
+	}
+}
+
+// pick returns the next healthy replica, or nil if there is none.
+func (rs *replicaSet) pick() *replica {
+	if rs == nil || len(rs.replicas) == 0 {
+		return nil
+	}
+	start := rs.next.Add(1)
+	for i := range rs.replicas {
+		r := rs.replicas[(start+uint64(i))%uint64(len(rs.replicas))]
+		if healthy, lag := r.state(); healthy && lag <= rs.maxLag {
+			return r
+		}
+	}
+	return nil
+}
+
+// run checks the health of the replicas at the health check interval until the context is done.
+func (rs *replicaSet) run(ctx context.Context) {
+	ticker := time.NewTicker(rs.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			rs.checkHealth(ctx)
+		}
+	}
+}
+
+// close closes the connections to the replicas.
+func (rs *replicaSet) close() {
+	for _, r := range rs.replicas {
+		if err := r.engine.Close(); err != nil {
+			rs.log.Warn("Failed to close read replica connection", "replica", r.name, "error", err)
+		}
+	}
+}
+
+// checkHealth checks that the replicas are reachable and measures their replication lag.
+func (rs *replicaSet) checkHealth(ctx context.Context) {
+	for _, r := range rs.replicas {
+		ctx, cancel := context.WithTimeout(ctx, rs.interval)
+		lag, err := rs.replicationLag(ctx, r.engine)
+		cancel()
+
+		wasHealthy, _ := r.state()
+		healthy := err == nil && lag <= rs.maxLag
+		switch {
+		case err != nil:
+			if wasHealthy {
+				rs.log.Warn("Read replica is unreachable, reading from the other replicas or the primary", "replica", r.name, "error", err)
+			}
+		case !healthy:
+			if wasHealthy {
+				rs.log.Warn("Read replica is lagging behind, reading from the other replicas or the primary", "replica", r.name, "lag", lag, "maxLag", rs.maxLag)
+			}
+		case !wasHealthy:
+			rs.log.Info("Read replica is healthy", "replica", r.name, "lag", lag)
+		}
+
+		r.setState(err == nil, lag)
+		if healthy {
+			replicaHealthy.WithLabelValues(r.name).Set(1)
+		} else {
+			replicaHealthy.WithLabelValues(r.name).Set(0)
+		}
+	}
+}
+
+// replicationLag returns how far behind the primary the replica is. The replication lag of replicas of databases that
+// don't report it is 0.
+func (rs *replicaSet) replicationLag(ctx context.Context, engine *xorm.Engine) (time.Duration, error) {
+	if err := engine.DB().PingContext(ctx); err != nil {
+		return 0, err
+	}
+
+	switch rs.dbType {
+	case migrator.Postgres:
+		// The time since the last replayed transaction is only the lag while there are transactions to replay, as it
+		// keeps growing when the primary is idle.
+		var lag float64
+		err := engine.DB().QueryRowContext(ctx, `SELECT CASE
+			WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+		END`).Scan(&lag)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read replication lag: %w", err)
+		}
+		return time.Duration(lag * float64(time.Second)), nil
+	case migrator.MySQL:
+		return mysqlReplicationLag(ctx, engine)
+	default:
+		return 0, nil
+	}
+}
+
+// mysqlReplicationLag reads the replication lag from the replica status, with the statement of MySQL 8.0.22 and above
+// or the one of older versions and MariaDB.
+func mysqlReplicationLag(ctx context.Context, engine *xorm.Engine) (time.Duration, error) {
+	db := sqlx.NewDb(engine.DB().DB, migrator.MySQL)
+	var status []map[string]any
+	var err error
+	for _, statement := range []string{"SHOW REPLICA STATUS", "SHOW SLAVE STATUS"} {
+		status, err = queryMaps(ctx, db, statement)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read replica status: %w", err)
+	}
+	if len(status) == 0 {
+		// Not a replica, so there is no lag.
+		return 0, nil
+	}
+
+	for _, column := range []string{"Seconds_Behind_Source", "Seconds_Behind_Master"} {
+		value, ok := status[0][column]
+		if !ok {
+			continue
+		}
+		if value == nil {
+			return 0, fmt.Errorf("replication is not running")
+		}
+		seconds, err := strconv.ParseInt(fmt.Sprintf("%s", value), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid replication lag %v: %w", value, err)
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return 0, fmt.Errorf("replica status does not contain the replication lag")
+}
+
+func queryMaps(ctx context.Context, db *sqlx.DB, query string) ([]map[string]any, error) {
+	rows, err := db.QueryxContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var result []map[string]any
+	for rows.Next() {
+		row := map[string]any{}
+		if err := rows.MapScan(row); err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// initReplicas connects to the read replicas of the configuration, and checks their health once. The health checks
+// then run in Run. Reads fall back to the primary until a replica passes a health check.
+func (ss *SQLStore) initReplicas() error {
+	replicaCfg, err := NewReplicaConfig(ss.cfg, ss.features)
+	if err != nil {
+		return err
+	}
+	if len(replicaCfg.Replicas) == 0 {
+		return nil
+	}
+
+	replicas := make([]*replica, 0, len(replicaCfg.Replicas))
+	for _, dbCfg := range replicaCfg.Replicas {
+		// The replicas use the driver of the primary, which has the instrumentation hooks if they are enabled.
+		dbCfg.Type = ss.dbCfg.Type
+		engine, err := ss.newEngine(dbCfg)
+		if err != nil {
+			for _, r := range replicas {
+				_ = r.engine.Close()
+			}
+			return fmt.Errorf("failed to connect to read replica %s: %w", dbCfg.Host, err)
+		}
+		replicas = append(replicas, &replica{
+			name:        dbCfg.Host,
+			engine:      engine,
+			sqlxsession: session.GetSession(sqlx.NewDb(engine.DB().DB, ss.dialect.DriverName())),
+		})
+	}
+
+	ss.replicas = newReplicaSet(replicas, ss.dialect.DriverName(), replicaCfg, log.New("sqlstore.replicas"))
+	ss.replicas.checkHealth(context.Background())
+
+	ss.log.Info("Reading from read replicas", "replicas", len(replicas), "maxReplicationLag", replicaCfg.MaxReplicationLag)
+	return nil
+}
+
+// IsDisabled returns true if no read replica is configured, in which case there is nothing to run.
+func (ss *SQLStore) IsDisabled() bool {
+	return ss.replicas == nil
+}
+
+// Run checks the health of the read replicas until the context is done, and then closes their connections.
+func (ss *SQLStore) Run(ctx context.Context) error {
+	if ss.replicas == nil {
+		return nil
+	}
+	defer ss.replicas.close()
+	ss.replicas.run(ctx)
+	return nil
+}
+
+// readEngine returns the engine of a healthy read replica, or the one of the primary if there is none.
+func (ss *SQLStore) readEngine() (*xorm.Engine, *replica) {
+	if r := ss.replicas.pick(); r != nil {
+		replicaReads.WithLabelValues("replica").Inc()
+		return r.engine, r
+	}
+	if ss.replicas != nil {
+		replicaReads.WithLabelValues("primary").Inc()
+	}
+	return ss.engine, nil
+}
+
+// WithReadReplica calls the callback with a session of a healthy read replica, for read-only database operations that
+// tolerate data up to the maximum replication lag old. Reads fall back to the primary when no read replica is
+// configured or healthy, and when the replica fails to be reached. Like WithDbSession, the session in the context is
+// used if there is one, so reads within transactions see their writes.
+func (ss *SQLStore) WithReadReplica(ctx context.Context, callback DBTransactionFunc) error {
+	engine, r := ss.readEngine()
+	err := ss.withDbSession(ctx, engine, callback)
+	if err == nil || r == nil || ctx.Err() != nil {
+		return err
+	}
+
+	// The error may be caused by the replica becoming unreachable since its last health check.
+	if pingErr := r.engine.DB().PingContext(ctx); pingErr != nil {
+		ss.replicas.log.Warn("Read replica is unreachable, reading from the primary", "replica", r.name, "error", pingErr)
+		_, lag := r.state()
+		r.setState(false, lag)
+		replicaHealthy.WithLabelValues(r.name).Set(0)
+		replicaReads.WithLabelValues("primary").Inc()
+		return ss.withDbSession(ctx, ss.engine, callback)
+	}
+	return err
+}
+
+// ReadSession returns a sqlx session of a healthy read replica, or of the primary if no read replica is configured or
+// healthy. See WithReadReplica.
+func (ss *SQLStore) ReadSession() *session.SessionDB {
+	if r := ss.replicas.pick(); r != nil {
+		replicaReads.WithLabelValues("replica").Inc()
+		return r.sqlxsession
+	}
+	if ss.replicas != nil {
+		replicaReads.WithLabelValues("primary").Inc()
+	}
+	return ss.GetSqlxSession()
+}
@@ -0,0 +1,180 @@
+package sqlstore
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/services/sqlstore/session"
+	"github.com/grafana/grafana/pkg/util/xorm"
+)
+
+// newNamedEngine returns an engine of a new SQLite database whose single row is the name of the database.
+func newNamedEngine(t *testing.T, name string) *xorm.Engine {
+	t.Helper()
+
+	engine, err := xorm.NewEngine(migrator.SQLite, filepath.Join(t.TempDir(), name+".db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = engine.Close() })
+
+	_, err = engine.Exec("CREATE TABLE name (name TEXT)")
+	require.NoError(t, err)
+	_, err = engine.Exec("INSERT INTO name (name) VALUES (?)", name)
+	require.NoError(t, err)
+	return engine
+}
+
+func newReplicaTestStore(t *testing.T, replicaNames ...string) *SQLStore {
+	t.Helper()
+
+	replicas := make([]*replica, 0, len(replicaNames))
+	for _, name := range replicaNames {
+		engine := newNamedEngine(t, name)
+		replicas = append(replicas, &replica{
+			name:        name,
+			engine:      engine,
+			sqlxsession: session.GetSession(sqlx.NewDb(engine.DB().DB, migrator.SQLite)),
+		})
+	}
+
+	ss := &SQLStore{
+		engine:  newNamedEngine(t, "primary"),
+		dialect: migrator.NewSQLite3Dialect(),
+		dbCfg:   &DatabaseConfig{},
+		log:     log.New("sqlstore"),
+		tracer:  tracing.InitializeTracerForTest(),
+	}
+	if len(replicas) > 0 {
+		ss.replicas = newReplicaSet(replicas, migrator.SQLite, &ReplicaConfig{
+			HealthCheckInterval: time.Second,
+			MaxReplicationLag:   time.Second,
+		}, log.New("sqlstore.replicas"))
+		ss.replicas.checkHealth(context.Background())
+	}
+	return ss
+}
+
+func readName(t *testing.T, ss *SQLStore) string {
+	t.Helper()
+
+	var name string
+	err := ss.WithReadReplica(context.Background(), func(sess *DBSession) error {
+		_, err := sess.SQL("SELECT name FROM name").Get(&name)
+		return err
+	})
+	require.NoError(t, err)
+	return name
+}
+
+func TestWithReadReplica(t *testing.T) {
+	t.Run("reads from the primary without replicas", func(t *testing.T) {
+		ss := newReplicaTestStore(t)
+
+		assert.Equal(t, "primary", readName(t, ss))
+	})
+
+	t.Run("reads from the replicas in turn", func(t *testing.T) {
+		ss := newReplicaTestStore(t, "replica1", "replica2")
+
+		names := []string{readName(t, ss), readName(t, ss), readName(t, ss), readName(t, ss)}
+		assert.ElementsMatch(t, []string{"replica1", "replica1", "replica2", "replica2"}, names)
+		assert.NotEqual(t, names[0], names[1])
+	})
+
+	t.Run("skips unhealthy and lagging replicas", func(t *testing.T) {
+		ss := newReplicaTestStore(t, "replica1", "replica2", "replica3")
+		ss.replicas.replicas[0].setState(false, 0)
+		ss.replicas.replicas[1].setState(true, time.Minute)
+
+		for range 3 {
+			assert.Equal(t, "replica3", readName(t, ss))
+		}
+	})
+
+	t.Run("reads from the primary when no replica is healthy", func(t *testing.T) {
+		ss := newReplicaTestStore(t, "replica1")
+		ss.replicas.replicas[0].setState(true, time.Minute)
+
+		assert.Equal(t, "primary", readName(t, ss))
+	})
+
+	t.Run("falls back to the primary when the replica is unreachable", func(t *testing.T) {
+		ss := newReplicaTestStore(t, "replica1")
+		require.NoError(t, ss.replicas.replicas[0].engine.Close())
+
+		assert.Equal(t, "primary", readName(t, ss))
+		healthy, _ := ss.replicas.replicas[0].state()
+		assert.False(t, healthy)
+	})
+
+	t.Run("returns the errors of reachable replicas", func(t *testing.T) {
+		ss := newReplicaTestStore(t, "replica1")
+
+		err := ss.WithReadReplica(context.Background(), func(sess *DBSession) error {
+			_, err := sess.Exec("SELECT missing FROM name")
+			return err
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("health checks mark unreachable replicas unhealthy", func(t *testing.T) {
+		ss := newReplicaTestStore(t, "replica1")
+		healthy, _ := ss.replicas.replicas[0].state()
+		require.True(t, healthy)
+
+		require.NoError(t, ss.replicas.replicas[0].engine.Close())
+		ss.replicas.checkHealth(context.Background())
+
+		healthy, _ = ss.replicas.replicas[0].state()
+		assert.False(t, healthy)
+		assert.Nil(t, ss.replicas.pick())
+	})
+}
+
+func TestReadSession(t *testing.T) {
+	readSessionName := func(t *testing.T, ss *SQLStore) string {
+		var name string
+		require.NoError(t, ss.ReadSession().Get(context.Background(), &name, "SELECT name FROM name"))
+		return name
+	}
+
+	t.Run("reads from a healthy replica", func(t *testing.T) {
+		ss := newReplicaTestStore(t, "replica1")
+
+		assert.Equal(t, "replica1", readSessionName(t, ss))
+	})
+
+	t.Run("reads from the primary when no replica is healthy", func(t *testing.T) {
+		ss := newReplicaTestStore(t, "replica1")
+		ss.replicas.replicas[0].setState(false, 0)
+
+		assert.Equal(t, "primary", readSessionName(t, ss))
+	})
+}
+
+func TestRunReplicas(t *testing.T) {
+	t.Run("is disabled without replicas", func(t *testing.T) {
+		ss := newReplicaTestStore(t)
+
+		assert.True(t, ss.IsDisabled())
+	})
+
+	t.Run("closes the replicas when the context is done", func(t *testing.T) {
+		ss := newReplicaTestStore(t, "replica1")
+		require.False(t, ss.IsDisabled())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.NoError(t, ss.Run(ctx))
+
+		assert.Error(t, ss.replicas.replicas[0].engine.DB().PingContext(context.Background()))
+	})
+}
@@ -52,6 +52,8 @@ type SQLStore struct {
 	tracer                       tracing.Tracer
 	recursiveQueriesAreSupported *bool
 	recursiveQueriesMu           sync.Mutex
+	// replicas are the read replicas, or nil if none are configured.
+	replicas *replicaSet
 }
 
 func ProvideService(cfg *setting.Cfg,
@@ -110,6 +112,10 @@ func newStore(cfg *setting.Cfg, engine *xorm.Engine, features featuremgmt.Featur
 
 	ss.dialect = migrator.NewDialect(ss.engine.DriverName())
 
+	if err := ss.initReplicas(); err != nil {
+		return nil, fmt.Errorf("%v: %w", "failed to connect to read replicas", err)
+	}
+
 	// if err := ss.Reset(); err != nil {
 	// 	return nil, err
 	// }
@@ -280,44 +286,13 @@ func (ss *SQLStore) initEngine(engine *xorm.Engine) error {
 		}
 	}
 	if engine == nil {
-		// Ensure that parseTime is enabled for MySQL
-		if strings.Contains(ss.dbCfg.Type, migrator.MySQL) && !strings.Contains(ss.dbCfg.ConnectionString, "parseTime=") {
-			if strings.Contains(ss.dbCfg.ConnectionString, "?") {
-				ss.dbCfg.ConnectionString += "&parseTime=true"
-			} else {
-				ss.dbCfg.ConnectionString += "?parseTime=true"
-			}
-		}
-
 		var err error
-		engine, err = xorm.NewEngine(ss.dbCfg.Type, ss.dbCfg.ConnectionString)
+		engine, err = ss.openEngine(ss.dbCfg)
 		if err != nil {
 			return err
 		}
-		// Only for MySQL or MariaDB, verify we can connect with the current connection string's system var for transaction isolation.
-		// If not, create a new engine with a compatible connection string.
-		if ss.dbCfg.Type == migrator.MySQL {
-			engine, err = ss.ensureTransactionIsolationCompatibility(engine, ss.dbCfg.ConnectionString)
-			if err != nil {
-				return err
-			}
-		}
-	}
-
-	engine.SetMaxOpenConns(ss.dbCfg.MaxOpenConn)
-	engine.SetMaxIdleConns(ss.dbCfg.MaxIdleConn)
-	engine.SetConnMaxLifetime(time.Second * time.Duration(ss.dbCfg.ConnMaxLifetime))
-
-	// configure sql logging
-	debugSQL := ss.cfg.Raw.Section("database").Key("log_queries").MustBool(false)
-	if !debugSQL {
-		engine.SetLogger(&xorm.DiscardLogger{})
-	} else {
-		// add stack to database calls to be able to see what repository initiated queries. Top 7 items from the stack as they are likely in the xorm library.
-		engine.SetLogger(NewXormLogger(log.LvlInfo, log.WithSuffix(log.New("sqlstore.xorm"), log.CallerContextKey, log.StackCaller(log.DefaultCallerDepth))))
-		engine.ShowSQL(true)
-		engine.ShowExecTime(true)
 	}
+	ss.configureEngine(engine, ss.dbCfg)
 
 	// initialize and register metrics wrapper around the *sql.DB
 	db := engine.DB().DB
@@ -336,6 +311,58 @@ func (ss *SQLStore) initEngine(engine *xorm.Engine) error {
 	return nil
 }
 
+// newEngine connects to the database of the configuration.
+func (ss *SQLStore) newEngine(dbCfg *DatabaseConfig) (*xorm.Engine, error) {
+	engine, err := ss.openEngine(dbCfg)
+	if err != nil {
+		return nil, err
+	}
+	ss.configureEngine(engine, dbCfg)
+	return engine, nil
+}
+
+func (ss *SQLStore) openEngine(dbCfg *DatabaseConfig) (*xorm.Engine, error) {
+	// Ensure that parseTime is enabled for MySQL
+	if strings.Contains(dbCfg.Type, migrator.MySQL) && !strings.Contains(dbCfg.ConnectionString, "parseTime=") {
+		if strings.Contains(dbCfg.ConnectionString, "?") {
+			dbCfg.ConnectionString += "&parseTime=true"
+		} else {
+			dbCfg.ConnectionString += "?parseTime=true"
+		}
+	}
+
+	engine, err := xorm.NewEngine(dbCfg.Type, dbCfg.ConnectionString)
+	if err != nil {
+		return nil, err
+	}
+	// Only for MySQL or MariaDB, verify we can connect with the current connection string's system var for transaction isolation.
+	// If not, create a new engine with a compatible connection string.
+	if dbCfg.Type == migrator.MySQL {
+		engine, err = ss.ensureTransactionIsolationCompatibility(engine, dbCfg.ConnectionString)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return engine, nil
+}
+
+func (ss *SQLStore) configureEngine(engine *xorm.Engine, dbCfg *DatabaseConfig) {
+	engine.SetMaxOpenConns(dbCfg.MaxOpenConn)
+	engine.SetMaxIdleConns(dbCfg.MaxIdleConn)
+	engine.SetConnMaxLifetime(time.Second * time.Duration(dbCfg.ConnMaxLifetime))
+
+	// configure sql logging
+	debugSQL := ss.cfg.Raw.Section("database").Key("log_queries").MustBool(false)
+	if !debugSQL {
+		engine.SetLogger(&xorm.DiscardLogger{})
+	} else {
+		// add stack to database calls to be able to see what repository initiated queries. Top 7 items from the stack as they are likely in the xorm library.
+		engine.SetLogger(NewXormLogger(log.LvlInfo, log.WithSuffix(log.New("sqlstore.xorm"), log.CallerContextKey, log.StackCaller(log.DefaultCallerDepth))))
+		engine.ShowSQL(true)
+		engine.ShowExecTime(true)
+	}
+}
+
 // The transaction_isolation system variable isn't compatible with MySQL < 5.7.20 or MariaDB. If we get an error saying this
 // system variable is unknown, then replace it with it's older version tx_isolation which is compatible with MySQL < 5.7.20 and MariaDB.
 func (ss *SQLStore) ensureTransactionIsolationCompatibility(engine *xorm.Engine, connectionString string) (*xorm.Engine, error) {
@@ -137,6 +137,9 @@ type Cfg struct {
 	PluginsPath                string
 	EnterpriseLicensePath      string
 
+	// ProvisioningAllowLocalGitRemotes allows git repositories with file:// remotes in development.
+	ProvisioningAllowLocalGitRemotes bool
+
 	// SMTP email settings
 	Smtp SmtpSettings
 
@@ -158,6 +161,7 @@ type Cfg struct {
 	DisableBruteForceLoginProtection     bool
 	BruteForceLoginProtectionMaxAttempts int64
 	DisableIPAddressLoginProtection      bool
+	LoginLockout                         LoginLockoutSettings
 	CookieSecure                         bool
 	CookieSameSiteDisabled               bool
 	CookieSameSiteMode                   http.SameSite
@@ -193,6 +197,7 @@ type Cfg struct {
 	PluginsAllowUnsigned             []string
 	PluginCatalogURL                 string
 	PluginCatalogHiddenPlugins       []string
+	PluginRepositoryURL              string
 	PluginAdminEnabled               bool
 	PluginAdminExternalManageEnabled bool
 	PluginForcePublicKeyDownload     bool
@@ -277,6 +282,8 @@ type Cfg struct {
 	ExtJWTAuth ExtJWTSettings
 
 	PasswordlessMagicLinkAuth AuthPasswordlessMagicLinkSettings
+	MFA                       AuthMFASettings
+	SCIM                      SCIMSettings
 
 	// SSO Settings Auth
 	SSOSettingsReloadInterval        time.Duration
@@ -300,6 +307,18 @@ type Cfg struct {
 	// DistributedCache
 	RemoteCacheOptions *RemoteCacheSettings
 
+	// Query and resource caching
+	QueryCaching QueryCachingSettings
+
+	// Concurrency and rate limits of data source requests
+	QueryLimits QueryLimitsSettings
+
+	// Audit log of configuration changes
+	AuditLog AuditLogSettings
+
+	// Scheduled dashboard reports
+	Reports ReportsSettings
+
 	// Deprecated: no longer used
 	ViewersCanEdit bool
 
@@ -1302,6 +1321,11 @@ func (cfg *Cfg) parseINIFile(iniFile *ini.File) error {
 	cfg.readAuthProxySettings()
 	cfg.readSessionConfig()
 	cfg.readPasswordlessMagicLinkSettings()
+	cfg.readMFASettings()
+	cfg.readSCIMSettings()
+	if err := cfg.readLoginLockoutSettings(); err != nil {
+		return err
+	}
 	if err := cfg.readSmtpSettings(); err != nil {
 		return err
 	}
@@ -1365,6 +1389,10 @@ func (cfg *Cfg) parseINIFile(iniFile *ini.File) error {
 	cfg.GeomapEnableCustomBaseLayers = geomapSection.Key("enable_custom_baselayers").MustBool(true)
 
 	cfg.readRemoteCacheSettings()
+	cfg.readQueryCachingSettings()
+	cfg.readQueryLimitsSettings()
+	cfg.readAuditLogSettings()
+	cfg.readReportsSettings()
 	cfg.readDateFormats()
 	cfg.readGrafanaJavascriptAgentConfig()
 
@@ -2049,6 +2077,9 @@ func (cfg *Cfg) readProvisioningSettings(iniFile *ini.File) error {
 			cfg.PermittedProvisioningPaths[i] = makeAbsolute(s, cfg.HomePath)
 		}
 	}
+
+	// Local git remotes read repositories from the filesystem of the server, so they are only for development.
+	cfg.ProvisioningAllowLocalGitRemotes = cfg.Env == Dev && iniFile.Section("provisioning").Key("allow_local_git_remotes").MustBool(false)
 	return nil
 }
 
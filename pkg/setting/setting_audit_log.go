@@ -0,0 +1,79 @@
+package setting
+
+import (
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	AuditLogSinkDatabase = "database"
+	AuditLogSinkFile     = "file"
+	AuditLogSinkLoki     = "loki"
+	AuditLogSinkWebhook  = "webhook"
+)
+
+type AuditLogSettings struct {
+	Enabled bool
+	// Sinks are the destinations the events are written to.
+	Sinks []string
+	// Retention is how long events are kept in the database. 0 keeps them forever.
+	Retention time.Duration
+	// MaxPayloadSize is the maximum size in bytes of a request body that is included in an event.
+	MaxPayloadSize int64
+	// QueueSize is the number of events buffered before new events are dropped.
+	QueueSize int
+	// SinkTimeout is the timeout of a single write to a sink.
+	SinkTimeout time.Duration
+
+	// FilePath is the file the events are appended to as JSON lines.
+	FilePath string
+
+	LokiURL      string
+	LokiTenantID string
+	LokiUsername string
+	LokiPassword string
+
+	WebhookURL string
+	// WebhookAuthorization is sent as the value of the Authorization header of the webhook requests.
+	WebhookAuthorization string
+}
+
+func (cfg *Cfg) readAuditLogSettings() {
+	section := cfg.Raw.Section("audit_log")
+
+	s := AuditLogSettings{
+		Enabled:              section.Key("enabled").MustBool(false),
+		MaxPayloadSize:       section.Key("max_payload_size").MustInt64(64 * 1024),
+		QueueSize:            section.Key("queue_size").MustInt(1000),
+		SinkTimeout:          section.Key("sink_timeout").MustDuration(10 * time.Second),
+		FilePath:             section.Key("file_path").MustString(""),
+		LokiURL:              strings.TrimSuffix(section.Key("loki_url").MustString(""), "/"),
+		LokiTenantID:         section.Key("loki_tenant_id").MustString(""),
+		LokiUsername:         section.Key("loki_username").MustString(""),
+		LokiPassword:         section.Key("loki_password").MustString(""),
+		WebhookURL:           section.Key("webhook_url").MustString(""),
+		WebhookAuthorization: section.Key("webhook_authorization").MustString(""),
+	}
+
+	for _, sink := range util.SplitString(section.Key("sinks").MustString(AuditLogSinkDatabase)) {
+		switch sink {
+		case AuditLogSinkDatabase, AuditLogSinkFile, AuditLogSinkLoki, AuditLogSinkWebhook:
+			s.Sinks = append(s.Sinks, sink)
+		default:
+			cfg.Logger.Warn("Unknown audit log sink", "sink", sink)
+		}
+	}
+
+	retention, err := gtime.ParseDuration(section.Key("retention").MustString("90d"))
+	if err != nil {
+		cfg.Logger.Warn("Invalid audit log retention, using the default", "error", err)
+		retention = 90 * 24 * time.Hour
+	}
+	s.Retention = retention
+
+	cfg.AuditLog = s
+}
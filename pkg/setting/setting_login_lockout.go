@@ -0,0 +1,93 @@
+package setting
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// LoginLockoutSettings configures how failed login attempts lock accounts, IP addresses and subnets,
+// on top of the brute force login protection settings of the [security] section.
+type LoginLockoutSettings struct {
+	// BackoffEnabled doubles the lockout duration every time the same account or IP address is locked again.
+	BackoffEnabled bool
+	// InitialDuration is the duration of the first lockout.
+	InitialDuration time.Duration
+	// MaxDuration caps the duration of lockouts with backoff.
+	MaxDuration time.Duration
+	// ResetAfter is the time without lockouts after which the backoff starts over.
+	ResetAfter time.Duration
+
+	// AllowedNetworks are never locked nor throttled, for example the addresses of a corporate proxy.
+	AllowedNetworks []*net.IPNet
+	// DeniedNetworks can never log in with a password.
+	DeniedNetworks []*net.IPNet
+
+	// NotifyUser emails users when their account is locked.
+	NotifyUser bool
+
+	// SprayDetectionEnabled throttles subnets from which many usernames fail to log in.
+	SprayDetectionEnabled bool
+	SprayMaxUsernames     int64
+	SprayIPv4PrefixLength int
+	SprayIPv6PrefixLength int
+}
+
+func (cfg *Cfg) readLoginLockoutSettings() error {
+	section := cfg.SectionWithEnvOverrides("security.login_lockout")
+
+	s := LoginLockoutSettings{
+		BackoffEnabled:        section.Key("backoff_enabled").MustBool(false),
+		InitialDuration:       section.Key("initial_duration").MustDuration(5 * time.Minute),
+		MaxDuration:           section.Key("max_duration").MustDuration(24 * time.Hour),
+		ResetAfter:            section.Key("reset_after").MustDuration(24 * time.Hour),
+		NotifyUser:            section.Key("notify_user").MustBool(false),
+		SprayDetectionEnabled: section.Key("spray_detection_enabled").MustBool(false),
+		SprayMaxUsernames:     max(section.Key("spray_max_usernames").MustInt64(20), 1),
+		SprayIPv4PrefixLength: section.Key("spray_ipv4_prefix_length").MustInt(24),
+		SprayIPv6PrefixLength: section.Key("spray_ipv6_prefix_length").MustInt(64),
+	}
+	if s.InitialDuration <= 0 {
+		s.InitialDuration = 5 * time.Minute
+	}
+	s.MaxDuration = max(s.MaxDuration, s.InitialDuration)
+	if s.SprayIPv4PrefixLength < 0 || s.SprayIPv4PrefixLength > 32 {
+		return fmt.Errorf("invalid spray_ipv4_prefix_length %d in [security.login_lockout]", s.SprayIPv4PrefixLength)
+	}
+	if s.SprayIPv6PrefixLength < 0 || s.SprayIPv6PrefixLength > 128 {
+		return fmt.Errorf("invalid spray_ipv6_prefix_length %d in [security.login_lockout]", s.SprayIPv6PrefixLength)
+	}
+
+	var err error
+	if s.AllowedNetworks, err = parseNetworks(section.Key("allowed_networks").String()); err != nil {
+		return fmt.Errorf("invalid allowed_networks in [security.login_lockout]: %w", err)
+	}
+	if s.DeniedNetworks, err = parseNetworks(section.Key("denied_networks").String()); err != nil {
+		return fmt.Errorf("invalid denied_networks in [security.login_lockout]: %w", err)
+	}
+
+	cfg.LoginLockout = s
+	return nil
+}
+
+// parseNetworks parses a list of CIDRs. Single IP addresses are accepted as well.
+func parseNetworks(s string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, cidr := range util.SplitString(s) {
+		if ip := net.ParseIP(cidr); ip != nil {
+			bits := 8 * len(ip.To4())
+			if bits == 0 {
+				bits = 8 * net.IPv6len
+			}
+			cidr = fmt.Sprintf("%s/%d", cidr, bits)
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
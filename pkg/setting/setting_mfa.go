@@ -0,0 +1,47 @@
+package setting
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+type AuthMFASettings struct {
+	Enabled bool
+	// EnforcedRoles are the organization roles that require a second factor. A role also enforces
+	// a second factor for the roles that include it, so Editor enforces it for Admin too.
+	EnforcedRoles []string
+	// Issuer is the name of the account shown in authenticator apps.
+	Issuer string
+	// WebAuthnRPID is the relying party ID of security keys, the host of the root URL by default.
+	WebAuthnRPID string
+	// WebAuthnOrigins are the origins allowed in WebAuthn responses, the origin of the root URL by default.
+	WebAuthnOrigins []string
+	// ChallengeTTL is how long a user has to complete the second step of a login.
+	ChallengeTTL time.Duration
+}
+
+func (cfg *Cfg) readMFASettings() {
+	section := cfg.SectionWithEnvOverrides("auth.mfa")
+
+	settings := AuthMFASettings{
+		Enabled:         section.Key("enabled").MustBool(false),
+		EnforcedRoles:   util.SplitString(section.Key("enforced_roles").MustString("")),
+		Issuer:          section.Key("issuer").MustString("Grafana"),
+		WebAuthnRPID:    section.Key("webauthn_rp_id").MustString(""),
+		WebAuthnOrigins: util.SplitString(section.Key("webauthn_origins").MustString("")),
+		ChallengeTTL:    section.Key("challenge_ttl").MustDuration(5 * time.Minute),
+	}
+
+	if appURL, err := url.Parse(cfg.AppURL); err == nil {
+		if settings.WebAuthnRPID == "" {
+			settings.WebAuthnRPID = appURL.Hostname()
+		}
+		if len(settings.WebAuthnOrigins) == 0 {
+			settings.WebAuthnOrigins = []string{appURL.Scheme + "://" + appURL.Host}
+		}
+	}
+
+	cfg.MFA = settings
+}
@@ -0,0 +1,50 @@
+package setting
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+type QueryCachingSettings struct {
+	Enabled bool
+	// TTL is the default time to live for cached query results.
+	TTL time.Duration
+	// ResourcesTTL is the time to live for cached resource responses.
+	ResourcesTTL time.Duration
+	// MaxValueSize is the maximum size in bytes of an encoded response that will be cached. 0 means no limit.
+	MaxValueSize int
+	// DataSourceTTLs overrides TTL per data source UID.
+	DataSourceTTLs map[string]time.Duration
+	// SharedResourceDataSources are the UIDs of the data sources whose resource responses are shared by the users of
+	// an organization. Resource responses of other data sources are cached per user.
+	SharedResourceDataSources map[string]bool
+}
+
+func (cfg *Cfg) readQueryCachingSettings() {
+	section := cfg.Raw.Section("caching")
+
+	s := QueryCachingSettings{
+		Enabled:        section.Key("enabled").MustBool(false),
+		TTL:            section.Key("ttl").MustDuration(time.Minute),
+		ResourcesTTL:   section.Key("resources_ttl").MustDuration(5 * time.Minute),
+		MaxValueSize:   section.Key("max_value_size").MustInt(10 * 1024 * 1024),
+		DataSourceTTLs: map[string]time.Duration{},
+
+		SharedResourceDataSources: map[string]bool{},
+	}
+	for _, uid := range util.SplitString(section.Key("shared_resources_datasources").String()) {
+		s.SharedResourceDataSources[uid] = true
+	}
+
+	for _, key := range cfg.Raw.Section("caching.datasource_ttl").Keys() {
+		ttl, err := key.Duration()
+		if err != nil {
+			cfg.Logger.Warn("Invalid query caching TTL for data source", "uid", key.Name(), "value", key.Value(), "error", err)
+			continue
+		}
+		s.DataSourceTTLs[key.Name()] = ttl
+	}
+
+	cfg.QueryCaching = s
+}
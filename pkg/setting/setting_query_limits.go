@@ -0,0 +1,66 @@
+package setting
+
+import (
+	"time"
+)
+
+// QueryLimitsSettings configures the concurrency and rate limits of data source queries and resource calls.
+// A limit of 0 means no limit.
+type QueryLimitsSettings struct {
+	Enabled bool
+
+	// DataSourceMaxConcurrentRequests caps the requests to a data source that are running at the same time.
+	DataSourceMaxConcurrentRequests int
+	// DataSourceRequestsPerSecond and DataSourceBurst configure the token bucket of every data source.
+	DataSourceRequestsPerSecond float64
+	DataSourceBurst             int
+
+	// UserMaxConcurrentRequests caps the requests of a user to all data sources that are running at the same time.
+	UserMaxConcurrentRequests int
+	// UserRequestsPerSecond and UserBurst configure the token bucket of every user.
+	UserRequestsPerSecond float64
+	UserBurst             int
+
+	// QueueTimeout is how long a request waits for the limits before it is rejected. 0 rejects requests immediately.
+	QueueTimeout time.Duration
+
+	// DataSourceMaxConcurrentRequestsByUID and DataSourceRequestsPerSecondByUID override the limits per data source UID.
+	DataSourceMaxConcurrentRequestsByUID map[string]int
+	DataSourceRequestsPerSecondByUID     map[string]float64
+}
+
+func (cfg *Cfg) readQueryLimitsSettings() {
+	section := cfg.Raw.Section("query_limits")
+
+	s := QueryLimitsSettings{
+		Enabled:                              section.Key("enabled").MustBool(false),
+		DataSourceMaxConcurrentRequests:      max(section.Key("datasource_max_concurrent_requests").MustInt(0), 0),
+		DataSourceRequestsPerSecond:          max(section.Key("datasource_requests_per_second").MustFloat64(0), 0),
+		DataSourceBurst:                      max(section.Key("datasource_burst").MustInt(0), 0),
+		UserMaxConcurrentRequests:            max(section.Key("user_max_concurrent_requests").MustInt(0), 0),
+		UserRequestsPerSecond:                max(section.Key("user_requests_per_second").MustFloat64(0), 0),
+		UserBurst:                            max(section.Key("user_burst").MustInt(0), 0),
+		QueueTimeout:                         max(section.Key("queue_timeout").MustDuration(5*time.Second), 0),
+		DataSourceMaxConcurrentRequestsByUID: map[string]int{},
+		DataSourceRequestsPerSecondByUID:     map[string]float64{},
+	}
+
+	for _, key := range cfg.Raw.Section("query_limits.datasource_max_concurrent_requests").Keys() {
+		limit, err := key.Int()
+		if err != nil || limit < 0 {
+			cfg.Logger.Warn("Invalid concurrent requests limit for data source", "uid", key.Name(), "value", key.Value(), "error", err)
+			continue
+		}
+		s.DataSourceMaxConcurrentRequestsByUID[key.Name()] = limit
+	}
+	for _, key := range cfg.Raw.Section("query_limits.datasource_requests_per_second").Keys() {
+		limit, err := key.Float64()
+		if err != nil || limit < 0 {
+			cfg.Logger.Warn("Invalid requests per second limit for data source", "uid", key.Name(), "value", key.Value(), "error", err)
+			continue
+		}
+		s.DataSourceRequestsPerSecondByUID[key.Name()] = limit
+	}
+
+	cfg.QueryLimits = s
+}
@@ -0,0 +1,29 @@
+package setting
+
+import (
+	"time"
+)
+
+type ReportsSettings struct {
+	Enabled bool
+	// MaxRetries is the number of times the delivery of a report is retried after it fails.
+	MaxRetries int
+	// RetryInterval is the time waited before the first retry, which doubles with every retry.
+	RetryInterval time.Duration
+	// RenderTimeout is the timeout of rendering a single file of a report.
+	RenderTimeout time.Duration
+	// HistoryLimit is the number of runs kept in the send history of every report.
+	HistoryLimit int
+}
+
+func (cfg *Cfg) readReportsSettings() {
+	section := cfg.Raw.Section("reports")
+
+	cfg.Reports = ReportsSettings{
+		Enabled:       section.Key("enabled").MustBool(false),
+		MaxRetries:    max(section.Key("max_retries").MustInt(3), 0),
+		RetryInterval: section.Key("retry_interval").MustDuration(time.Minute),
+		RenderTimeout: section.Key("render_timeout").MustDuration(time.Minute),
+		HistoryLimit:  max(section.Key("history_limit").MustInt(100), 1),
+	}
+}
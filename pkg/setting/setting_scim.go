@@ -0,0 +1,24 @@
+package setting
+
+type SCIMSettings struct {
+	// UserSyncEnabled enables the provisioning of users with SCIM, and requires users to be provisioned to log in
+	// unless AllowNonProvisionedUsers is set.
+	UserSyncEnabled bool
+	// GroupSyncEnabled enables the provisioning of teams with SCIM.
+	GroupSyncEnabled         bool
+	AllowNonProvisionedUsers bool
+	// AuthModule is the login method of provisioned users. The external ID of a provisioned user is
+	// checked against the identity returned by this login method.
+	AuthModule string
+}
+
+func (cfg *Cfg) readSCIMSettings() {
+	section := cfg.SectionWithEnvOverrides("auth.scim")
+
+	cfg.SCIM = SCIMSettings{
+		UserSyncEnabled:          section.Key("user_sync_enabled").MustBool(false),
+		GroupSyncEnabled:         section.Key("group_sync_enabled").MustBool(false),
+		AllowNonProvisionedUsers: section.Key("allow_non_provisioned_users").MustBool(false),
+		AuthModule:               section.Key("auth_module").MustString("auth.saml"),
+	}
+}
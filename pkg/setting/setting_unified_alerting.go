@@ -67,6 +67,9 @@ const (
 	lokiDefaultMaxQueryLength      = 721 * time.Hour // 30d1h, matches the default value in Loki
 	defaultRecordingRequestTimeout = 10 * time.Second
 	lokiDefaultMaxQuerySize        = 65536 // 64kb
+
+	stateHistorySQLDefaultBatchSize     = 100
+	stateHistorySQLDefaultFlushInterval = 5 * time.Second
 )
 
 type UnifiedAlertingSettings struct {
@@ -83,6 +86,10 @@ type UnifiedAlertingSettings struct {
 	HAPushPullInterval              time.Duration
 	HALabel                         string
 	HARedisClusterModeEnabled       bool
+	HARedisSentinelModeEnabled      bool
+	HARedisSentinelMasterName       string
+	HARedisSentinelUsername         string
+	HARedisSentinelPassword         string
 	HARedisAddr                     string
 	HARedisPeerName                 string
 	HARedisPrefix                   string
@@ -134,6 +141,9 @@ type UnifiedAlertingSettings struct {
 
 	// DeletedRuleRetention defines the maximum duration to retain deleted alerting rules before permanent removal.
 	DeletedRuleRetention time.Duration
+
+	// BacktestRetention defines how long finished backtests are retained. 0 value means that they are never deleted.
+	BacktestRetention time.Duration
 }
 
 type RecordingRuleSettings struct {
@@ -189,6 +199,11 @@ type UnifiedAlertingStateHistorySettings struct {
 	MultiPrimary          string
 	MultiSecondaries      []string
 	ExternalLabels        map[string]string
+	// SQLMaxAge is how long the "sql" backend keeps state history. 0 keeps it forever.
+	SQLMaxAge time.Duration
+	// SQLBatchSize and SQLFlushInterval control how the "sql" backend batches its writes.
+	SQLBatchSize     int
+	SQLFlushInterval time.Duration
 }
 
 // IsEnabled returns true if UnifiedAlertingSettings.Enabled is either nil or true.
@@ -279,6 +294,18 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 	uaCfg.HAAdvertiseAddr = ua.Key("ha_advertise_address").MustString("")
 	uaCfg.HALabel = ua.Key("ha_label").MustString("")
 	uaCfg.HARedisClusterModeEnabled = ua.Key("ha_redis_cluster_mode_enabled").MustBool(false)
+	uaCfg.HARedisSentinelModeEnabled = ua.Key("ha_redis_sentinel_mode_enabled").MustBool(false)
+	uaCfg.HARedisSentinelMasterName = ua.Key("ha_redis_sentinel_master_name").MustString("")
+	uaCfg.HARedisSentinelUsername = ua.Key("ha_redis_sentinel_username").MustString("")
+	uaCfg.HARedisSentinelPassword = ua.Key("ha_redis_sentinel_password").MustString("")
+	if uaCfg.HARedisSentinelModeEnabled {
+		if uaCfg.HARedisClusterModeEnabled {
+			return fmt.Errorf("setting 'ha_redis_sentinel_mode_enabled' cannot be used together with 'ha_redis_cluster_mode_enabled'")
+		}
+		if uaCfg.HARedisSentinelMasterName == "" {
+			return fmt.Errorf("setting 'ha_redis_sentinel_master_name' is required when 'ha_redis_sentinel_mode_enabled' is true")
+		}
+	}
 	uaCfg.HARedisAddr = ua.Key("ha_redis_address").MustString("")
 	uaCfg.HARedisPeerName = ua.Key("ha_redis_peer_name").MustString("")
 	uaCfg.HARedisPrefix = ua.Key("ha_redis_prefix").MustString("")
@@ -431,6 +458,10 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 
 	stateHistory := iniFile.Section("unified_alerting.state_history")
 	stateHistoryLabels := iniFile.Section("unified_alerting.state_history.external_labels")
+	stateHistorySQLMaxAge, err := gtime.ParseDuration(valueAsString(stateHistory, "sql_max_age", "0s"))
+	if err != nil {
+		return err
+	}
 	uaCfgStateHistory := UnifiedAlertingStateHistorySettings{
 		Enabled:               stateHistory.Key("enabled").MustBool(stateHistoryDefaultEnabled),
 		Backend:               stateHistory.Key("backend").MustString("annotations"),
@@ -445,6 +476,9 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 		MultiPrimary:          stateHistory.Key("primary").MustString(""),
 		MultiSecondaries:      splitTrim(stateHistory.Key("secondaries").MustString(""), ","),
 		ExternalLabels:        stateHistoryLabels.KeysHash(),
+		SQLMaxAge:             stateHistorySQLMaxAge,
+		SQLBatchSize:          stateHistory.Key("sql_batch_size").MustInt(stateHistorySQLDefaultBatchSize),
+		SQLFlushInterval:      stateHistory.Key("sql_flush_interval").MustDuration(stateHistorySQLDefaultFlushInterval),
 	}
 	uaCfg.StateHistory = uaCfgStateHistory
 
@@ -501,6 +535,11 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 		return fmt.Errorf("setting 'deleted_rule_retention' is invalid, only 0 or a positive duration are allowed")
 	}
 
+	uaCfg.BacktestRetention = ua.Key("backtest_retention").MustDuration(7 * 24 * time.Hour)
+	if uaCfg.BacktestRetention < 0 {
+		return fmt.Errorf("setting 'backtest_retention' is invalid, only 0 or a positive duration are allowed")
+	}
+
 	cfg.UnifiedAlerting = uaCfg
 	return nil
 }
@@ -350,3 +350,50 @@ func TestHARedisTLSSettings(t *testing.T) {
 	require.Equal(t, cipherSuites, cfg.UnifiedAlerting.HARedisTLSConfig.CipherSuites)
 	require.Equal(t, minVersion, cfg.UnifiedAlerting.HARedisTLSConfig.MinVersion)
 }
+
+func TestHARedisSentinelSettings(t *testing.T) {
+	newFile := func(t *testing.T, keys map[string]string) *ini.File {
+		t.Helper()
+		f := ini.Empty()
+		section, err := f.NewSection("unified_alerting")
+		require.NoError(t, err)
+		for k, v := range keys {
+			_, err = section.NewKey(k, v)
+			require.NoError(t, err)
+		}
+		return f
+	}
+
+	t.Run("should read sentinel settings", func(t *testing.T) {
+		cfg := NewCfg()
+		err := cfg.ReadUnifiedAlertingSettings(newFile(t, map[string]string{
+			"ha_redis_sentinel_mode_enabled": "true",
+			"ha_redis_sentinel_master_name":  "mymaster",
+			"ha_redis_sentinel_username":     "user",
+			"ha_redis_sentinel_password":     "pass",
+		}))
+		require.NoError(t, err)
+		require.True(t, cfg.UnifiedAlerting.HARedisSentinelModeEnabled)
+		require.Equal(t, "mymaster", cfg.UnifiedAlerting.HARedisSentinelMasterName)
+		require.Equal(t, "user", cfg.UnifiedAlerting.HARedisSentinelUsername)
+		require.Equal(t, "pass", cfg.UnifiedAlerting.HARedisSentinelPassword)
+	})
+
+	t.Run("should require the master name", func(t *testing.T) {
+		cfg := NewCfg()
+		err := cfg.ReadUnifiedAlertingSettings(newFile(t, map[string]string{
+			"ha_redis_sentinel_mode_enabled": "true",
+		}))
+		require.ErrorContains(t, err, "ha_redis_sentinel_master_name")
+	})
+
+	t.Run("should not allow sentinel and cluster mode together", func(t *testing.T) {
+		cfg := NewCfg()
+		err := cfg.ReadUnifiedAlertingSettings(newFile(t, map[string]string{
+			"ha_redis_sentinel_mode_enabled": "true",
+			"ha_redis_sentinel_master_name":  "mymaster",
+			"ha_redis_cluster_mode_enabled":  "true",
+		}))
+		require.ErrorContains(t, err, "ha_redis_cluster_mode_enabled")
+	})
+}
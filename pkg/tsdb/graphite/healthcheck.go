@@ -0,0 +1,59 @@
+package graphite
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// CheckHealth renders a constant series, like the test of the data source configuration page does, to check that
+// Graphite is reachable and can evaluate queries.
+func (s *Service) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	logger := logger.FromContext(ctx)
+
+	dsInfo, err := s.getDSInfo(ctx, req.PluginContext)
+	if err != nil {
+		logger.Error("Failed to get data source info", "error", err)
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusUnknown,
+			Message: "Health check failed: Failed to get data source info",
+		}, nil
+	}
+
+	formData := url.Values{
+		"from":   []string{"-1h"},
+		"until":  []string{"now"},
+		"format": []string{"json"},
+		"target": []string{"constantLine(100)"},
+	}
+	graphiteReq, err := s.createRequest(ctx, logger, dsInfo, formData)
+	if err != nil {
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusUnknown,
+			Message: "Health check failed: Failed to create request",
+		}, nil
+	}
+
+	res, err := dsInfo.HTTPClient.Do(graphiteReq)
+	if err != nil {
+		logger.Error("Failed to connect to Graphite", "error", err)
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: "Health check failed: Failed to connect to Graphite",
+		}, nil
+	}
+
+	if _, err := s.parseResponse(logger, res); err != nil {
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: fmt.Sprintf("Health check failed: %s", err),
+		}, nil
+	}
+
+	return &backend.CheckHealthResult{
+		Status:  backend.HealthStatusOk,
+		Message: "Successfully queried the Graphite data source.",
+	}, nil
+}
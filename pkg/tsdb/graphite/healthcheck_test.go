@@ -0,0 +1,56 @@
+package graphite
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+func TestCheckHealth(t *testing.T) {
+	checkHealth := func(t *testing.T, handler http.HandlerFunc) *backend.CheckHealthResult {
+		t.Helper()
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+
+		service := ProvideService(httpclient.NewProvider(), tracing.NewNoopTracerService())
+		res, err := service.CheckHealth(context.Background(), &backend.CheckHealthRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{URL: server.URL},
+			},
+		})
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("should render a constant series", func(t *testing.T) {
+		res := checkHealth(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/render", r.URL.Path)
+			assert.Equal(t, "constantLine(100)", r.FormValue("target"))
+			_, _ = w.Write([]byte(`[{"target": "100", "datapoints": [[100, 1], [100, 2]]}]`))
+		})
+		assert.Equal(t, backend.HealthStatusOk, res.Status)
+	})
+
+	t.Run("should fail when Graphite returns an error", func(t *testing.T) {
+		res := checkHealth(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+		assert.Equal(t, backend.HealthStatusError, res.Status)
+		assert.Contains(t, res.Message, "500")
+	})
+
+	t.Run("should fail when the response is not a render response", func(t *testing.T) {
+		res := checkHealth(t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`<html></html>`))
+		})
+		assert.Equal(t, backend.HealthStatusError, res.Status)
+	})
+}
@@ -0,0 +1,105 @@
+package graphite
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// CallResource serves the Graphite APIs the query editor, template variables and annotations use, so they work where
+// there is no frontend proxy, like in alerting, public dashboards and server-side expressions.
+func (s *Service) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	handler := httpadapter.New(s.registerResourceRoutes())
+	return handler.CallResource(ctx, req, sender)
+}
+
+func (s *Service) registerResourceRoutes() *http.ServeMux {
+	router := http.NewServeMux()
+	router.HandleFunc("GET /metrics/find", s.proxyHandler("metrics/find"))
+	router.HandleFunc("POST /metrics/find", s.proxyHandler("metrics/find"))
+	router.HandleFunc("GET /tags/autoComplete/tags", s.proxyHandler("tags/autoComplete/tags"))
+	router.HandleFunc("GET /tags/autoComplete/values", s.proxyHandler("tags/autoComplete/values"))
+	router.HandleFunc("GET /functions", s.proxyHandler("functions"))
+	router.HandleFunc("GET /events", s.proxyHandler("events/get_data"))
+	return router
+}
+
+// proxyHandler forwards the query parameters and the form body of the request to the Graphite API at the path, and
+// writes back its response.
+func (s *Service) proxyHandler(graphitePath string) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		logger := logger.FromContext(ctx)
+
+		dsInfo, err := s.getDSInfo(ctx, backend.PluginConfigFromContext(ctx))
+		if err != nil {
+			logger.Error("Failed to get data source info", "error", err)
+			http.Error(rw, "Failed to get data source info", http.StatusInternalServerError)
+			return
+		}
+
+		graphiteReq, err := createResourceRequest(ctx, dsInfo, graphitePath, r)
+		if err != nil {
+			logger.Error("Failed to create request", "error", err, "path", graphitePath)
+			http.Error(rw, "Failed to create request", http.StatusBadRequest)
+			return
+		}
+
+		ctx, span := s.tracer.Start(ctx, "graphite resource")
+		defer span.End()
+		span.SetAttributes(
+			attribute.String("path", graphitePath),
+			attribute.Int64("datasource_id", dsInfo.Id),
+		)
+		s.tracer.Inject(ctx, graphiteReq.Header, span)
+
+		res, err := dsInfo.HTTPClient.Do(graphiteReq)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			logger.Error("Graphite resource request failed", "error", err, "path", graphitePath)
+			http.Error(rw, "Failed to connect to Graphite", http.StatusBadGateway)
+			return
+		}
+		defer func() {
+			if err := res.Body.Close(); err != nil {
+				logger.Warn("Failed to close response body", "error", err)
+			}
+		}()
+		span.SetAttributes(attribute.Int("graphite.response.code", res.StatusCode))
+
+		if contentType := res.Header.Get("Content-Type"); contentType != "" {
+			rw.Header().Set("Content-Type", contentType)
+		}
+		rw.WriteHeader(res.StatusCode)
+		if _, err := io.Copy(rw, res.Body); err != nil {
+			logger.Warn("Failed to write Graphite response", "error", err, "path", graphitePath)
+		}
+	}
+}
+
+func createResourceRequest(ctx context.Context, dsInfo *datasourceInfo, graphitePath string, r *http.Request) (*http.Request, error) {
+	u, err := url.Parse(dsInfo.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse data source URL: %w", err)
+	}
+	u.Path = path.Join(u.Path, graphitePath)
+	u.RawQuery = r.URL.RawQuery
+
+	req, err := http.NewRequestWithContext(ctx, r.Method, u.String(), r.Body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req, nil
+}
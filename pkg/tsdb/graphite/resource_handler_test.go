@@ -0,0 +1,107 @@
+package graphite
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+func TestCallResource(t *testing.T) {
+	var received *http.Request
+	var receivedBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		require.NoError(t, r.ParseForm())
+		receivedBody = r.PostForm.Encode()
+		if r.URL.Path == "/graphite/tags/autoComplete/values" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`tag is required`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`["result"]`))
+	}))
+	t.Cleanup(server.Close)
+
+	service := ProvideService(httpclient.NewProvider(), tracing.NewNoopTracerService())
+	pluginCtx := backend.PluginContext{
+		DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{URL: server.URL + "/graphite"},
+	}
+	callResource := func(t *testing.T, req *backend.CallResourceRequest) *backend.CallResourceResponse {
+		t.Helper()
+		req.PluginContext = pluginCtx
+		var res *backend.CallResourceResponse
+		err := service.CallResource(context.Background(), req, backend.CallResourceResponseSenderFunc(func(r *backend.CallResourceResponse) error {
+			res = r
+			return nil
+		}))
+		require.NoError(t, err)
+		require.NotNil(t, res)
+		return res
+	}
+
+	testCases := []struct {
+		path         string
+		graphitePath string
+	}{
+		{path: "metrics/find", graphitePath: "/graphite/metrics/find"},
+		{path: "tags/autoComplete/tags", graphitePath: "/graphite/tags/autoComplete/tags"},
+		{path: "functions", graphitePath: "/graphite/functions"},
+		{path: "events", graphitePath: "/graphite/events/get_data"},
+	}
+	for _, tc := range testCases {
+		t.Run("should proxy "+tc.path, func(t *testing.T) {
+			res := callResource(t, &backend.CallResourceRequest{
+				Method: http.MethodGet,
+				Path:   tc.path,
+				URL:    tc.path + "?query=a.*&from=-1h",
+			})
+			assert.Equal(t, http.StatusOK, res.Status)
+			assert.Equal(t, `["result"]`, string(res.Body))
+			assert.Equal(t, []string{"application/json"}, res.Headers["Content-Type"])
+			assert.Equal(t, tc.graphitePath, received.URL.Path)
+			assert.Equal(t, "a.*", received.URL.Query().Get("query"))
+			assert.Equal(t, "-1h", received.URL.Query().Get("from"))
+		})
+	}
+
+	t.Run("should forward the form of metric find requests", func(t *testing.T) {
+		res := callResource(t, &backend.CallResourceRequest{
+			Method:  http.MethodPost,
+			Path:    "metrics/find",
+			URL:     "metrics/find",
+			Headers: map[string][]string{"Content-Type": {"application/x-www-form-urlencoded"}},
+			Body:    []byte("query=a.*"),
+		})
+		assert.Equal(t, http.StatusOK, res.Status)
+		assert.Equal(t, http.MethodPost, received.Method)
+		assert.Equal(t, "query=a.%2A", receivedBody)
+	})
+
+	t.Run("should return the errors of Graphite", func(t *testing.T) {
+		res := callResource(t, &backend.CallResourceRequest{
+			Method: http.MethodGet,
+			Path:   "tags/autoComplete/values",
+			URL:    "tags/autoComplete/values",
+		})
+		assert.Equal(t, http.StatusBadRequest, res.Status)
+		assert.Equal(t, "tag is required", string(res.Body))
+	})
+
+	t.Run("should not proxy other paths", func(t *testing.T) {
+		res := callResource(t, &backend.CallResourceRequest{
+			Method: http.MethodGet,
+			Path:   "render",
+			URL:    "render",
+		})
+		assert.Equal(t, http.StatusNotFound, res.Status)
+	})
+}
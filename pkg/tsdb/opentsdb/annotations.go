@@ -0,0 +1,120 @@
+package opentsdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// queryAnnotations returns the annotations of the metric of an annotation query as an annotation frame. OpenTSDB
+// returns the annotations of the series of a query, and the global annotations when they are requested, so the metric
+// is queried with the sum aggregator like the annotation editor does.
+func (s *Service) queryAnnotations(ctx context.Context, logger log.Logger, dsInfo *datasourceInfo, query backend.DataQuery, model *simplejson.Json) backend.DataResponse {
+	metric := model.Get("target").MustString()
+	if metric == "" {
+		return backend.DataResponse{Frames: data.Frames{newAnnotationFrame(query.RefID, nil)}}
+	}
+
+	tsdbQuery := OpenTsdbQuery{
+		Start:             query.TimeRange.From.UnixNano() / int64(time.Millisecond),
+		End:               query.TimeRange.To.UnixNano() / int64(time.Millisecond),
+		Queries:           []map[string]any{{"aggregator": "sum", "metric": metric}},
+		GlobalAnnotations: true,
+	}
+
+	request, err := s.createRequest(ctx, logger, dsInfo, tsdbQuery)
+	if err != nil {
+		return backend.ErrorResponseWithErrorSource(err)
+	}
+
+	res, err := dsInfo.HTTPClient.Do(request)
+	if err != nil {
+		return backend.ErrorResponseWithErrorSource(backend.DownstreamError(err))
+	}
+
+	annotations, err := s.parseAnnotationResponse(logger, res, model.Get("isGlobal").MustBool())
+	if err != nil {
+		return backend.ErrorResponseWithErrorSource(err)
+	}
+
+	return backend.DataResponse{Frames: data.Frames{newAnnotationFrame(query.RefID, annotations)}}
+}
+
+// parseAnnotationResponse returns the annotations of the series of the response, or the global annotations if
+// isGlobal is set.
+func (s *Service) parseAnnotationResponse(logger log.Logger, res *http.Response, isGlobal bool) ([]OpenTsdbAnnotation, error) {
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			logger.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	if res.StatusCode/100 != 2 {
+		logger.Info("Request failed", "status", res.Status, "body", string(body))
+		return nil, backend.DownstreamError(fmt.Errorf("request failed, status: %s", res.Status))
+	}
+
+	var responseData []OpenTsdbAnnotationResponse
+	if err := json.Unmarshal(body, &responseData); err != nil {
+		logger.Info("Failed to unmarshal opentsdb annotation response", "error", err, "status", res.Status, "body", string(body))
+		return nil, err
+	}
+
+	if len(responseData) == 0 {
+		return nil, nil
+	}
+	if isGlobal {
+		// The global annotations are the same for every series.
+		return responseData[0].GlobalAnnotations, nil
+	}
+
+	var annotations []OpenTsdbAnnotation
+	for _, series := range responseData {
+		annotations = append(annotations, series.Annotations...)
+	}
+	return annotations, nil
+}
+
+// newAnnotationFrame returns the annotations in the fields dashboards read annotation events from.
+func newAnnotationFrame(refID string, annotations []OpenTsdbAnnotation) *data.Frame {
+	times := make([]time.Time, 0, len(annotations))
+	timeEnds := make([]*time.Time, 0, len(annotations))
+	texts := make([]string, 0, len(annotations))
+	for _, a := range annotations {
+		times = append(times, annotationTime(a.StartTime))
+		var timeEnd *time.Time
+		if a.EndTime > 0 {
+			t := annotationTime(a.EndTime)
+			timeEnd = &t
+		}
+		timeEnds = append(timeEnds, timeEnd)
+		texts = append(texts, a.Description)
+	}
+
+	frame := data.NewFrame("annotations",
+		data.NewField("time", nil, times),
+		data.NewField("timeEnd", nil, timeEnds),
+		data.NewField("text", nil, texts),
+	)
+	frame.RefID = refID
+	return frame
+}
+
+// annotationTime converts the time of an annotation, in seconds, to a time.
+func annotationTime(seconds float64) time.Time {
+	return time.Unix(int64(math.Floor(seconds)), 0).UTC()
+}
@@ -0,0 +1,38 @@
+package opentsdb
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// CheckHealth looks up metric suggestions, like the test of the data source configuration page does, which checks
+// that OpenTSDB is reachable and that its UID tables can be read.
+func (s *Service) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	logger := logger.FromContext(ctx)
+
+	dsInfo, err := s.getDSInfo(ctx, req.PluginContext)
+	if err != nil {
+		logger.Error("Failed to get data source info", "error", err)
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusUnknown,
+			Message: "Health check failed: Failed to get data source info",
+		}, nil
+	}
+
+	_, err = s.get(ctx, dsInfo, "api/suggest", url.Values{"type": {"metrics"}, "q": {"cpu"}, "max": {"1"}})
+	if err != nil {
+		logger.Error("OpenTSDB health check failed", "error", err)
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: fmt.Sprintf("Health check failed: %s", err),
+		}, nil
+	}
+
+	return &backend.CheckHealthResult{
+		Status:  backend.HealthStatusOk,
+		Message: "Data source is working",
+	}, nil
+}
@@ -83,20 +83,44 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 }
 
 func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
-	var tsdbQuery OpenTsdbQuery
-
 	logger := logger.FromContext(ctx)
 
-	q := req.Queries[0]
+	dsInfo, err := s.getDSInfo(ctx, req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
 
-	refID := q.RefID
+	result := backend.NewQueryDataResponse()
+	for _, query := range req.Queries {
+		model, err := simplejson.NewJson(query.JSON)
+		if err != nil {
+			result.Responses[query.RefID] = backend.ErrDataResponseWithSource(backend.StatusBadRequest, backend.ErrorSourceDownstream, fmt.Sprintf("invalid query: %s", err))
+			continue
+		}
 
-	tsdbQuery.Start = q.TimeRange.From.UnixNano() / int64(time.Millisecond)
-	tsdbQuery.End = q.TimeRange.To.UnixNano() / int64(time.Millisecond)
+		if model.Get("fromAnnotations").MustBool() {
+			result.Responses[query.RefID] = s.queryAnnotations(ctx, logger, dsInfo, query, model)
+			continue
+		}
 
-	for _, query := range req.Queries {
-		metric := s.buildMetric(query)
-		tsdbQuery.Queries = append(tsdbQuery.Queries, metric)
+		result.Responses[query.RefID] = s.queryMetric(ctx, logger, dsInfo, query)
+	}
+
+	return result, nil
+}
+
+// queryMetric executes the query with its own request, so the series of every query are returned for its ref ID.
+func (s *Service) queryMetric(ctx context.Context, logger log.Logger, dsInfo *datasourceInfo, query backend.DataQuery) backend.DataResponse {
+	metric := s.buildMetric(query)
+	if metric == nil || metric["metric"] == "" {
+		// Queries without a metric are skipped, like in the query editor.
+		return backend.DataResponse{}
+	}
+
+	tsdbQuery := OpenTsdbQuery{
+		Start:   query.TimeRange.From.UnixNano() / int64(time.Millisecond),
+		End:     query.TimeRange.To.UnixNano() / int64(time.Millisecond),
+		Queries: []map[string]any{metric},
 	}
 
 	// TODO: Don't use global variable
@@ -104,34 +128,22 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 		logger.Debug("OpenTsdb request", "params", tsdbQuery)
 	}
 
-	dsInfo, err := s.getDSInfo(ctx, req.PluginContext)
-	if err != nil {
-		return nil, err
-	}
-
 	request, err := s.createRequest(ctx, logger, dsInfo, tsdbQuery)
 	if err != nil {
-		return &backend.QueryDataResponse{}, err
+		return backend.ErrorResponseWithErrorSource(err)
 	}
 
 	res, err := dsInfo.HTTPClient.Do(request)
 	if err != nil {
-		return &backend.QueryDataResponse{}, err
+		return backend.ErrorResponseWithErrorSource(backend.DownstreamError(err))
 	}
 
-	defer func() {
-		err := res.Body.Close()
-		if err != nil {
-			logger.Warn("failed to close response body", "error", err)
-		}
-	}()
-
-	result, err := s.parseResponse(logger, res, refID, dsInfo.TSDBVersion)
+	result, err := s.parseResponse(logger, res, query.RefID, dsInfo.TSDBVersion)
 	if err != nil {
-		return &backend.QueryDataResponse{}, err
+		return backend.ErrorResponseWithErrorSource(err)
 	}
 
-	return result, nil
+	return result.Responses[query.RefID]
 }
 
 func (s *Service) createRequest(ctx context.Context, logger log.Logger, dsInfo *datasourceInfo, data OpenTsdbQuery) (*http.Request, error) {
@@ -168,7 +180,7 @@ func createInitialFrame(val OpenTsdbCommon, length int, refID string) *data.Fram
 		labels[label] = value
 	}
 
-	frame := data.NewFrameOfFieldTypes(val.Metric, length, data.FieldTypeTime, data.FieldTypeFloat64)
+	frame := data.NewFrameOfFieldTypes(val.Metric, length, data.FieldTypeTime, data.FieldTypeNullableFloat64)
 	frame.Meta = &data.FrameMeta{Type: data.FrameTypeTimeSeriesMulti, TypeVersion: data.FrameTypeVersion{0, 1}}
 	frame.RefID = refID
 	timeField := frame.Fields[0]
@@ -186,7 +198,7 @@ func parseResponse24(responseData []OpenTsdbResponse24, refID string, frames dat
 		frame := createInitialFrame(val.OpenTsdbCommon, len(val.DataPoints), refID)
 
 		for i, point := range val.DataPoints {
-			frame.SetRow(i, time.Unix(int64(point[0]), 0).UTC(), point[1])
+			frame.SetRow(i, time.Unix(int64(point[0].Float64), 0).UTC(), point[1].Ptr())
 		}
 
 		frames = append(frames, frame)
@@ -213,7 +225,7 @@ func parseResponseLT24(responseData []OpenTsdbResponse, refID string, frames dat
 				logger.Info("Failed to unmarshal opentsdb timestamp", "timestamp", timeString)
 				return frames, err
 			}
-			frame.SetRow(i, time.Unix(timestamp, 0).UTC(), val.DataPoints[timeString])
+			frame.SetRow(i, time.Unix(timestamp, 0).UTC(), val.DataPoints[timeString].Ptr())
 		}
 
 		frames = append(frames, frame)
@@ -237,7 +249,7 @@ func (s *Service) parseResponse(logger log.Logger, res *http.Response, refID str
 
 	if res.StatusCode/100 != 2 {
 		logger.Info("Request failed", "status", res.Status, "body", string(body))
-		return nil, fmt.Errorf("request failed, status: %s", res.Status)
+		return nil, backend.DownstreamError(fmt.Errorf("request failed, status: %s", res.Status))
 	}
 
 	frames := data.Frames{}
@@ -281,21 +293,16 @@ func (s *Service) buildMetric(query backend.DataQuery) map[string]any {
 
 	// Setting metric and aggregator
 	metric["metric"] = model.Get("metric").MustString()
-	metric["aggregator"] = model.Get("aggregator").MustString()
+	aggregator := model.Get("aggregator").MustString()
+	if aggregator == "" {
+		aggregator = "avg" // default value of the query editor
+	}
+	metric["aggregator"] = aggregator
 
 	// Setting downsampling options
 	disableDownsampling := model.Get("disableDownsampling").MustBool()
 	if !disableDownsampling {
-		downsampleInterval := model.Get("downsampleInterval").MustString()
-		if downsampleInterval == "" {
-			downsampleInterval = "1m" // default value for blank
-		}
-		downsample := downsampleInterval + "-" + model.Get("downsampleAggregator").MustString()
-		if model.Get("downsampleFillPolicy").MustString() != "none" {
-			metric["downsample"] = downsample + "-" + model.Get("downsampleFillPolicy").MustString()
-		} else {
-			metric["downsample"] = downsample
-		}
+		metric["downsample"] = buildDownsample(model, query.Interval)
 	}
 
 	// Setting rate options
@@ -304,38 +311,128 @@ func (s *Service) buildMetric(query backend.DataQuery) map[string]any {
 		rateOptions := make(map[string]any)
 		rateOptions["counter"] = model.Get("isCounter").MustBool()
 
-		counterMax, counterMaxCheck := model.CheckGet("counterMax")
+		counterMax, counterMaxCheck := modelFloat(model, "counterMax")
 		if counterMaxCheck {
-			rateOptions["counterMax"] = counterMax.MustFloat64()
+			rateOptions["counterMax"] = counterMax
 		}
 
-		resetValue, resetValueCheck := model.CheckGet("counterResetValue")
+		resetValue, resetValueCheck := modelFloat(model, "counterResetValue")
 		if resetValueCheck {
-			rateOptions["resetValue"] = resetValue.MustFloat64()
+			rateOptions["resetValue"] = resetValue
 		}
 
-		if !counterMaxCheck && (!resetValueCheck || resetValue.MustFloat64() == 0) {
+		if !counterMaxCheck && (!resetValueCheck || resetValue == 0) {
 			rateOptions["dropResets"] = true
 		}
 
 		metric["rateOptions"] = rateOptions
 	}
 
-	// Setting tags
-	tags, tagsCheck := model.CheckGet("tags")
-	if tagsCheck && len(tags.MustMap()) > 0 {
-		metric["tags"] = tags.MustMap()
+	// Setting filters, which OpenTSDB 2.2 and above use instead of the tags
+	filters := buildFilters(model)
+	if len(filters) > 0 {
+		metric["filters"] = filters
+	} else {
+		// Setting tags
+		tags, tagsCheck := model.CheckGet("tags")
+		if tagsCheck && len(tags.MustMap()) > 0 {
+			metric["tags"] = tags.MustMap()
+		}
 	}
 
-	// Setting filters
-	filters, filtersCheck := model.CheckGet("filters")
-	if filtersCheck && len(filters.MustArray()) > 0 {
-		metric["filters"] = filters.MustArray()
+	// Setting explicit tags, so only the series with exactly the tags of the filters are returned (OpenTSDB 2.3 and above)
+	if model.Get("explicitTags").MustBool() {
+		metric["explicitTags"] = true
 	}
 
 	return metric
 }
 
+// downsampleFillPolicies are the fill policies OpenTSDB 2.2 and above support for downsampling. The "none" policy is the
+// default and is not added to the downsample specification.
+var downsampleFillPolicies = map[string]bool{"nan": true, "null": true, "zero": true}
+
+// buildDownsample returns the downsample specification of the query, in the format `interval-aggregator[-fill policy]`.
+func buildDownsample(model *simplejson.Json, queryInterval time.Duration) string {
+	downsampleInterval := model.Get("downsampleInterval").MustString()
+	if downsampleInterval == "" {
+		downsampleInterval = "1m" // default value for blank
+		if queryInterval > 0 {
+			downsampleInterval = formatInterval(queryInterval)
+		}
+	}
+
+	downsampleAggregator := model.Get("downsampleAggregator").MustString()
+	if downsampleAggregator == "" {
+		downsampleAggregator = "avg"
+	}
+
+	downsample := downsampleInterval + "-" + downsampleAggregator
+	if fillPolicy := model.Get("downsampleFillPolicy").MustString(); downsampleFillPolicies[fillPolicy] {
+		downsample += "-" + fillPolicy
+	}
+	return downsample
+}
+
+// formatInterval formats the interval of the query in the largest OpenTSDB unit that it is a multiple of.
+func formatInterval(interval time.Duration) string {
+	switch {
+	case interval%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", interval/(24*time.Hour))
+	case interval%time.Hour == 0:
+		return fmt.Sprintf("%dh", interval/time.Hour)
+	case interval%time.Minute == 0:
+		return fmt.Sprintf("%dm", interval/time.Minute)
+	case interval%time.Second == 0:
+		return fmt.Sprintf("%ds", interval/time.Second)
+	default:
+		return fmt.Sprintf("%dms", interval/time.Millisecond)
+	}
+}
+
+// buildFilters returns the filters of the query. Filters without a tag key are skipped, as OpenTSDB rejects them.
+func buildFilters(model *simplejson.Json) []OpenTsdbFilter {
+	var filters []OpenTsdbFilter
+	for i := range model.Get("filters").MustArray() {
+		f := model.Get("filters").GetIndex(i)
+		filter := OpenTsdbFilter{
+			Type:    f.Get("type").MustString(),
+			TagK:    f.Get("tagk").MustString(),
+			Filter:  f.Get("filter").MustString(),
+			GroupBy: f.Get("groupBy").MustBool(),
+		}
+		if filter.TagK == "" || filter.Type == "" {
+			continue
+		}
+		filters = append(filters, filter)
+	}
+	return filters
+}
+
+// modelFloat returns the number of the query model at the key. The query editor stores numbers as strings, so
+// numeric strings are parsed, and empty strings are treated as unset.
+func modelFloat(model *simplejson.Json, key string) (float64, bool) {
+	value, ok := model.CheckGet(key)
+	if !ok {
+		return 0, false
+	}
+	if str, err := value.String(); err == nil {
+		if strings.TrimSpace(str) == "" {
+			return 0, false
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+		if err != nil {
+			return 0, false
+		}
+		return f, true
+	}
+	f, err := value.Float64()
+	if err != nil {
+		return 0, false
+	}
+	return f, true
+}
+
 func (s *Service) getDSInfo(ctx context.Context, pluginCtx backend.PluginContext) (*datasourceInfo, error) {
 	i, err := s.im.Get(ctx, pluginCtx)
 	if err != nil {
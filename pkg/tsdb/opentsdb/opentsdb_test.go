@@ -2,8 +2,13 @@ package opentsdb
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -11,8 +16,12 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/experimental"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/grafana/grafana/pkg/util"
 )
 
 func TestOpenTsdbExecutor(t *testing.T) {
@@ -58,8 +67,8 @@ func TestOpenTsdbExecutor(t *testing.T) {
 			data.NewField("Time", nil, []time.Time{
 				time.Date(2014, 7, 16, 20, 55, 46, 0, time.UTC),
 			}),
-			data.NewField("test", map[string]string{"env": "prod", "app": "grafana"}, []float64{
-				50}),
+			data.NewField("test", map[string]string{"env": "prod", "app": "grafana"}, []*float64{
+				util.Pointer(50.0)}),
 		)
 		testFrame.Meta = &data.FrameMeta{
 			Type:        data.FrameTypeTimeSeriesMulti,
@@ -99,8 +108,8 @@ func TestOpenTsdbExecutor(t *testing.T) {
 			data.NewField("Time", nil, []time.Time{
 				time.Date(2014, 7, 16, 20, 55, 46, 0, time.UTC),
 			}),
-			data.NewField("test", map[string]string{"env": "prod", "app": "grafana"}, []float64{
-				50}),
+			data.NewField("test", map[string]string{"env": "prod", "app": "grafana"}, []*float64{
+				util.Pointer(50.0)}),
 		)
 		testFrame.Meta = &data.FrameMeta{
 			Type:        data.FrameTypeTimeSeriesMulti,
@@ -160,18 +169,18 @@ func TestOpenTsdbExecutor(t *testing.T) {
 				time.Date(2014, 9, 3, 2, 48, 52, 0, time.UTC),
 				time.Date(2017, 4, 7, 0, 10, 11, 0, time.UTC),
 			}),
-			data.NewField("test", map[string]string{"env": "prod", "app": "grafana"}, []float64{
-				215,
-				50,
-				55,
-				124,
-				1284,
-				9035,
-				258,
-				153,
-				812,
-				356,
-				8953,
+			data.NewField("test", map[string]string{"env": "prod", "app": "grafana"}, []*float64{
+				util.Pointer(215.0),
+				util.Pointer(50.0),
+				util.Pointer(55.0),
+				util.Pointer(124.0),
+				util.Pointer(1284.0),
+				util.Pointer(9035.0),
+				util.Pointer(258.0),
+				util.Pointer(153.0),
+				util.Pointer(812.0),
+				util.Pointer(356.0),
+				util.Pointer(8953.0),
 			}),
 		)
 		testFrame.Meta = &data.FrameMeta{
@@ -214,8 +223,8 @@ func TestOpenTsdbExecutor(t *testing.T) {
 			data.NewField("Time", nil, []time.Time{
 				time.Date(2014, 7, 16, 20, 55, 46, 0, time.UTC),
 			}),
-			data.NewField("test", map[string]string{"env": "prod", "app": "grafana"}, []float64{
-				50}),
+			data.NewField("test", map[string]string{"env": "prod", "app": "grafana"}, []*float64{
+				util.Pointer(50.0)}),
 		)
 		testFrame.Meta = &data.FrameMeta{
 			Type:        data.FrameTypeTimeSeriesMulti,
@@ -403,4 +412,253 @@ func TestOpenTsdbExecutor(t *testing.T) {
 		require.Equal(t, float64(45), metricRateOptions["counterMax"])
 		require.Equal(t, float64(60), metricRateOptions["resetValue"])
 	})
+
+	t.Run("Build metric with downsampling fill policies", func(t *testing.T) {
+		for fillPolicy, downsample := range map[string]string{
+			"":     "5m-sum",
+			"none": "5m-sum",
+			"nan":  "5m-sum-nan",
+			"null": "5m-sum-null",
+			"zero": "5m-sum-zero",
+		} {
+			query := backend.DataQuery{
+				JSON: []byte(`{
+					"metric": "cpu.average.percent",
+					"downsampleInterval": "5m",
+					"downsampleAggregator": "sum",
+					"downsampleFillPolicy": "` + fillPolicy + `"
+				}`),
+			}
+			require.Equal(t, downsample, service.buildMetric(query)["downsample"], fillPolicy)
+		}
+	})
+
+	t.Run("Build metric with the interval of the query when the downsample interval is blank", func(t *testing.T) {
+		query := backend.DataQuery{
+			Interval: 90 * time.Second,
+			JSON:     []byte(`{"metric": "cpu.average.percent", "downsampleAggregator": "max"}`),
+		}
+
+		metric := service.buildMetric(query)
+
+		require.Equal(t, "avg", metric["aggregator"])
+		require.Equal(t, "90s-max", metric["downsample"])
+	})
+
+	t.Run("Build metric with filters and explicit tags", func(t *testing.T) {
+		query := backend.DataQuery{
+			JSON: []byte(`
+					{
+						"metric": "cpu.average.percent",
+						"aggregator": "avg",
+						"disableDownsampling": true,
+						"explicitTags": true,
+						"tags": {
+							"env": "prod"
+						},
+						"filters": [
+							{ "type": "wildcard", "tagk": "host", "filter": "web*", "groupBy": true },
+							{ "type": "literal_or", "tagk": "env", "filter": "prod|staging", "groupBy": false },
+							{ "type": "literal_or", "tagk": "", "filter": "incomplete", "groupBy": false }
+						]
+					}`,
+			),
+		}
+
+		metric := service.buildMetric(query)
+
+		require.Len(t, metric, 4)
+		require.Nil(t, metric["tags"])
+		require.True(t, metric["explicitTags"].(bool))
+		require.Equal(t, []OpenTsdbFilter{
+			{Type: "wildcard", TagK: "host", Filter: "web*", GroupBy: true},
+			{Type: "literal_or", TagK: "env", Filter: "prod|staging", GroupBy: false},
+		}, metric["filters"])
+	})
+
+	t.Run("Build metric with rate options set by the query editor", func(t *testing.T) {
+		query := backend.DataQuery{
+			JSON: []byte(`
+					{
+						"metric": "cpu.average.percent",
+						"disableDownsampling": true,
+						"shouldComputeRate": true,
+						"isCounter": true,
+						"counterMax": "45",
+						"counterResetValue": ""
+					}`,
+			),
+		}
+
+		metric := service.buildMetric(query)
+
+		require.Equal(t, map[string]any{"counter": true, "counterMax": float64(45)}, metric["rateOptions"])
+	})
+
+	t.Run("Parse response should handle the null and nan fill policies", func(t *testing.T) {
+		response := `[{"metric": "test", "dps": [[1405544146, null], [1405544206, "NaN"], [1405544266, 50.0]]}]`
+
+		resp := http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(response))}
+		result, err := service.parseResponse(logger, &resp, "A", 4)
+		require.NoError(t, err)
+
+		values := result.Responses["A"].Frames[0].Fields[1]
+		require.Nil(t, values.At(0))
+		require.True(t, math.IsNaN(*values.At(1).(*float64)))
+		require.Equal(t, 50.0, *values.At(2).(*float64))
+	})
+}
+
+// fakeOpenTSDB is an OpenTSDB server that responds to the API paths with the fixtures of testdata, and records the
+// requests it receives.
+type fakeOpenTSDB struct {
+	*httptest.Server
+	// requests are the paths and queries of the requests.
+	requests []string
+	// bodies are the bodies of the requests.
+	bodies []string
+}
+
+func newFakeOpenTSDB(t *testing.T, fixtures map[string]string) *fakeOpenTSDB {
+	t.Helper()
+	f := &fakeOpenTSDB{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		f.requests = append(f.requests, r.URL.RequestURI())
+		f.bodies = append(f.bodies, string(body))
+
+		fixture, ok := fixtures[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error": {"code": 400, "message": "No such name for 'metrics'"}}`))
+			return
+		}
+		b, err := os.ReadFile(filepath.Join("testdata", fixture))
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(b)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func newTestPluginContext(url string, tsdbVersion int) backend.PluginContext {
+	return backend.PluginContext{
+		DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+			URL:      url,
+			JSONData: []byte(fmt.Sprintf(`{"tsdbVersion": %d, "lookupLimit": 100}`, tsdbVersion)),
+		},
+	}
+}
+
+func TestQueryData(t *testing.T) {
+	timeRange := backend.TimeRange{
+		From: time.Date(2014, 7, 16, 20, 55, 0, 0, time.UTC),
+		To:   time.Date(2014, 7, 16, 21, 0, 0, 0, time.UTC),
+	}
+
+	testCases := []struct {
+		name        string
+		tsdbVersion int
+		fixture     string
+		query       string
+		request     string
+	}{
+		{
+			name:        "metric_v24",
+			tsdbVersion: 4,
+			fixture:     "query_v24.json",
+			query: `{
+				"metric": "cpu.average.percent",
+				"aggregator": "sum",
+				"downsampleInterval": "1m",
+				"downsampleAggregator": "avg",
+				"downsampleFillPolicy": "null",
+				"explicitTags": true,
+				"filters": [{ "type": "wildcard", "tagk": "host", "filter": "web*", "groupBy": true }]
+			}`,
+			request: `{"start":1405544100000,"end":1405544400000,"queries":[{"aggregator":"sum","downsample":"1m-avg-null","explicitTags":true,"filters":[{"type":"wildcard","tagk":"host","filter":"web*","groupBy":true}],"metric":"cpu.average.percent"}]}`,
+		},
+		{
+			name:        "metric_v23",
+			tsdbVersion: 3,
+			fixture:     "query_v23.json",
+			query: `{
+				"metric": "cpu.average.percent",
+				"aggregator": "avg",
+				"disableDownsampling": true,
+				"tags": { "env": "prod" }
+			}`,
+			request: `{"start":1405544100000,"end":1405544400000,"queries":[{"aggregator":"avg","metric":"cpu.average.percent","tags":{"env":"prod"}}]}`,
+		},
+		{
+			name:        "annotations",
+			tsdbVersion: 3,
+			fixture:     "annotations.json",
+			query:       `{"fromAnnotations": true, "target": "deploys"}`,
+			request:     `{"start":1405544100000,"end":1405544400000,"queries":[{"aggregator":"sum","metric":"deploys"}],"globalAnnotations":true}`,
+		},
+		{
+			name:        "global_annotations",
+			tsdbVersion: 3,
+			fixture:     "annotations.json",
+			query:       `{"fromAnnotations": true, "target": "deploys", "isGlobal": true}`,
+			request:     `{"start":1405544100000,"end":1405544400000,"queries":[{"aggregator":"sum","metric":"deploys"}],"globalAnnotations":true}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newFakeOpenTSDB(t, map[string]string{"/api/query": tc.fixture})
+			service := ProvideService(httpclient.NewProvider())
+
+			resp, err := service.QueryData(context.Background(), &backend.QueryDataRequest{
+				PluginContext: newTestPluginContext(server.URL, tc.tsdbVersion),
+				Queries:       []backend.DataQuery{{RefID: "A", TimeRange: timeRange, JSON: []byte(tc.query)}},
+			})
+			require.NoError(t, err)
+
+			require.Equal(t, []string{tc.request}, server.bodies)
+			dr := resp.Responses["A"]
+			experimental.CheckGoldenJSONResponse(t, "testdata", tc.name+".golden", &dr, false)
+		})
+	}
+
+	t.Run("should execute every query for its ref ID", func(t *testing.T) {
+		server := newFakeOpenTSDB(t, map[string]string{"/api/query": "query_v24.json"})
+		service := ProvideService(httpclient.NewProvider())
+
+		resp, err := service.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: newTestPluginContext(server.URL, 4),
+			Queries: []backend.DataQuery{
+				{RefID: "A", TimeRange: timeRange, JSON: []byte(`{"metric": "cpu.average.percent"}`)},
+				{RefID: "B", TimeRange: timeRange, JSON: []byte(`{"metric": "cpu.idle"}`)},
+				{RefID: "C", TimeRange: timeRange, JSON: []byte(`{"metric": ""}`)},
+			},
+		})
+		require.NoError(t, err)
+
+		require.Len(t, server.bodies, 2)
+		require.Len(t, resp.Responses["A"].Frames, 2)
+		require.Equal(t, "A", resp.Responses["A"].Frames[0].RefID)
+		require.Len(t, resp.Responses["B"].Frames, 2)
+		require.Equal(t, "B", resp.Responses["B"].Frames[0].RefID)
+		require.Empty(t, resp.Responses["C"].Frames)
+		require.NoError(t, resp.Responses["C"].Error)
+	})
+
+	t.Run("should return the errors of OpenTSDB as downstream errors of the query", func(t *testing.T) {
+		server := newFakeOpenTSDB(t, map[string]string{})
+		service := ProvideService(httpclient.NewProvider())
+
+		resp, err := service.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: newTestPluginContext(server.URL, 4),
+			Queries:       []backend.DataQuery{{RefID: "A", TimeRange: timeRange, JSON: []byte(`{"metric": "unknown"}`)}},
+		})
+		require.NoError(t, err)
+
+		require.Error(t, resp.Responses["A"].Error)
+		require.Equal(t, backend.ErrorSourceDownstream, resp.Responses["A"].ErrorSource)
+	})
 }
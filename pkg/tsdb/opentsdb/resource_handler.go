@@ -0,0 +1,229 @@
+package opentsdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
+)
+
+// defaultLookupLimit is the number of results of suggestions and lookups when the data source doesn't set one, like in
+// the query editor.
+const defaultLookupLimit = 1000
+
+// errUpstream is the error of a request that OpenTSDB responded to with an error status.
+type errUpstream struct {
+	status int
+	body   string
+}
+
+func (e *errUpstream) Error() string {
+	return fmt.Sprintf("request failed, status: %d %s", e.status, http.StatusText(e.status))
+}
+
+func (s *Service) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	handler := httpadapter.New(s.registerResourceRoutes())
+	return handler.CallResource(ctx, req, sender)
+}
+
+func (s *Service) registerResourceRoutes() *http.ServeMux {
+	router := http.NewServeMux()
+	router.HandleFunc("GET /api/suggest", s.withDatasourceHandlerFunc(s.suggestHandler))
+	router.HandleFunc("GET /tag-keys", s.withDatasourceHandlerFunc(s.tagKeysHandler))
+	router.HandleFunc("GET /tag-values", s.withDatasourceHandlerFunc(s.tagValuesHandler))
+	return router
+}
+
+func (s *Service) withDatasourceHandlerFunc(getHandler func(dsInfo *datasourceInfo) http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		dsInfo, err := s.getDSInfo(r.Context(), backend.PluginConfigFromContext(r.Context()))
+		if err != nil {
+			writeResponse(r.Context(), nil, fmt.Errorf("failed to get data source info: %w", err), rw)
+			return
+		}
+		getHandler(dsInfo).ServeHTTP(rw, r)
+	}
+}
+
+// suggestHandler returns the metrics, tag keys or tag values that start with the query, with the `type` and `q`
+// parameters of the OpenTSDB suggest API.
+func (s *Service) suggestHandler(dsInfo *datasourceInfo) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		params := url.Values{
+			"type": {r.URL.Query().Get("type")},
+			"q":    {r.URL.Query().Get("q")},
+			"max":  {strconv.Itoa(lookupLimit(dsInfo))},
+		}
+		if params.Get("type") == "" {
+			http.Error(rw, "type is required", http.StatusBadRequest)
+			return
+		}
+
+		body, err := s.get(r.Context(), dsInfo, "api/suggest", params)
+		if err != nil {
+			writeResponse(r.Context(), nil, err, rw)
+			return
+		}
+
+		var suggestions []string
+		if err := json.Unmarshal(body, &suggestions); err != nil {
+			writeResponse(r.Context(), nil, fmt.Errorf("failed to unmarshal suggest response: %w", err), rw)
+			return
+		}
+		writeResponse(r.Context(), suggestions, nil, rw)
+	}
+}
+
+// tagKeysHandler returns the tag keys of the series of the `metric` parameter.
+func (s *Service) tagKeysHandler(dsInfo *datasourceInfo) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		metric := strings.TrimSpace(r.URL.Query().Get("metric"))
+		if metric == "" {
+			writeResponse(r.Context(), []string{}, nil, rw)
+			return
+		}
+
+		lookup, err := s.lookup(r.Context(), dsInfo, metric)
+		if err != nil {
+			writeResponse(r.Context(), nil, err, rw)
+			return
+		}
+
+		keys := []string{}
+		for _, result := range lookup.Results {
+			for key := range result.Tags {
+				if !slices.Contains(keys, key) {
+					keys = append(keys, key)
+				}
+			}
+		}
+		slices.Sort(keys)
+		writeResponse(r.Context(), keys, nil, rw)
+	}
+}
+
+// tagValuesHandler returns the values of a tag key of the series of the `metric` parameter. The `keys` parameter is
+// the tag key, optionally followed by comma-separated `key=value` conditions on the other tags of the series, like the
+// `tag_values(metric, keys)` template variable query.
+func (s *Service) tagValuesHandler(dsInfo *datasourceInfo) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		metric := strings.TrimSpace(r.URL.Query().Get("metric"))
+		keys := strings.Split(r.URL.Query().Get("keys"), ",")
+		for i := range keys {
+			keys[i] = strings.TrimSpace(keys[i])
+		}
+		key := keys[0]
+		if metric == "" || key == "" {
+			writeResponse(r.Context(), []string{}, nil, rw)
+			return
+		}
+
+		conditions := append([]string{key + "=*"}, keys[1:]...)
+		lookup, err := s.lookup(r.Context(), dsInfo, metric+"{"+strings.Join(conditions, ",")+"}")
+		if err != nil {
+			writeResponse(r.Context(), nil, err, rw)
+			return
+		}
+
+		values := []string{}
+		for _, result := range lookup.Results {
+			if value, ok := result.Tags[key]; ok && !slices.Contains(values, value) {
+				values = append(values, value)
+			}
+		}
+		slices.Sort(values)
+		writeResponse(r.Context(), values, nil, rw)
+	}
+}
+
+// lookup returns the series that match the query, in the `metric{key=value,...}` format of the OpenTSDB lookup API.
+func (s *Service) lookup(ctx context.Context, dsInfo *datasourceInfo, query string) (*OpenTsdbLookupResponse, error) {
+	body, err := s.get(ctx, dsInfo, "api/search/lookup", url.Values{
+		"m":     {query},
+		"limit": {strconv.Itoa(lookupLimit(dsInfo))},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var lookup OpenTsdbLookupResponse
+	if err := json.Unmarshal(body, &lookup); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal lookup response: %w", err)
+	}
+	return &lookup, nil
+}
+
+// get sends a GET request to the OpenTSDB API at the path, and returns the body of the response.
+func (s *Service) get(ctx context.Context, dsInfo *datasourceInfo, apiPath string, params url.Values) ([]byte, error) {
+	logger := logger.FromContext(ctx)
+
+	u, err := url.Parse(dsInfo.URL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, apiPath)
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	res, err := dsInfo.HTTPClient.Do(req)
+	if err != nil {
+		return nil, backend.DownstreamError(err)
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			logger.Warn("Failed to close response body", "error", err)
+		}
+	}()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode/100 != 2 {
+		logger.Info("Request failed", "status", res.Status, "path", apiPath, "body", string(body))
+		return nil, backend.DownstreamError(&errUpstream{status: res.StatusCode, body: string(body)})
+	}
+	return body, nil
+}
+
+func lookupLimit(dsInfo *datasourceInfo) int {
+	if dsInfo.LookupLimit > 0 {
+		return int(dsInfo.LookupLimit)
+	}
+	return defaultLookupLimit
+}
+
+func writeResponse(ctx context.Context, res any, err error, rw http.ResponseWriter) {
+	if err != nil {
+		logger.FromContext(ctx).Warn("An error occurred while doing a resource call", "error", err)
+		var upstream *errUpstream
+		if errors.As(err, &upstream) && upstream.status/100 == 4 {
+			http.Error(rw, upstream.body, upstream.status)
+			return
+		}
+		http.Error(rw, "An error occurred within the plugin", http.StatusInternalServerError)
+		return
+	}
+	b, err := json.Marshal(res)
+	if err != nil {
+		logger.FromContext(ctx).Warn("An error occurred while processing response from resource call", "error", err)
+		http.Error(rw, "An error occurred within the plugin", http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	_, _ = rw.Write(b)
+}
@@ -0,0 +1,104 @@
+package opentsdb
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/httpclient"
+)
+
+func TestCallResource(t *testing.T) {
+	server := newFakeOpenTSDB(t, map[string]string{
+		"/api/suggest":       "suggest.json",
+		"/api/search/lookup": "lookup.json",
+	})
+	callResource := func(t *testing.T, server *fakeOpenTSDB, url string) *backend.CallResourceResponse {
+		t.Helper()
+		server.requests = nil
+		service := ProvideService(httpclient.NewProvider())
+		var res *backend.CallResourceResponse
+		path, _, _ := strings.Cut(url, "?")
+		err := service.CallResource(context.Background(), &backend.CallResourceRequest{
+			PluginContext: newTestPluginContext(server.URL, 4),
+			Method:        http.MethodGet,
+			Path:          path,
+			URL:           url,
+		}, backend.CallResourceResponseSenderFunc(func(r *backend.CallResourceResponse) error {
+			res = r
+			return nil
+		}))
+		require.NoError(t, err)
+		require.NotNil(t, res)
+		return res
+	}
+
+	t.Run("should return suggestions", func(t *testing.T) {
+		res := callResource(t, server, "api/suggest?type=metrics&q=cpu")
+		assert.Equal(t, http.StatusOK, res.Status)
+		assert.JSONEq(t, `["cpu.average.percent", "cpu.idle", "cpu.user"]`, string(res.Body))
+		assert.Equal(t, []string{"/api/suggest?max=100&q=cpu&type=metrics"}, server.requests)
+	})
+
+	t.Run("should require the type of suggestions", func(t *testing.T) {
+		res := callResource(t, server, "api/suggest?q=cpu")
+		assert.Equal(t, http.StatusBadRequest, res.Status)
+	})
+
+	t.Run("should return the tag keys of a metric", func(t *testing.T) {
+		res := callResource(t, server, "tag-keys?metric=cpu.average.percent")
+		assert.Equal(t, http.StatusOK, res.Status)
+		assert.JSONEq(t, `["dc", "env", "host"]`, string(res.Body))
+		assert.Equal(t, []string{"/api/search/lookup?limit=100&m=cpu.average.percent"}, server.requests)
+	})
+
+	t.Run("should return the values of a tag key of a metric", func(t *testing.T) {
+		res := callResource(t, server, "tag-values?metric=cpu.average.percent&keys=host,%20env%3Dprod")
+		assert.Equal(t, http.StatusOK, res.Status)
+		assert.JSONEq(t, `["web01", "web02"]`, string(res.Body))
+		assert.Equal(t, []string{"/api/search/lookup?limit=100&m=cpu.average.percent%7Bhost%3D%2A%2Cenv%3Dprod%7D"}, server.requests)
+	})
+
+	t.Run("should not look up tags without a metric", func(t *testing.T) {
+		res := callResource(t, server, "tag-values?keys=host")
+		assert.Equal(t, http.StatusOK, res.Status)
+		assert.JSONEq(t, `[]`, string(res.Body))
+		assert.Empty(t, server.requests)
+	})
+
+	t.Run("should return the client errors of OpenTSDB", func(t *testing.T) {
+		server := newFakeOpenTSDB(t, map[string]string{})
+		res := callResource(t, server, "tag-keys?metric=unknown")
+		assert.Equal(t, http.StatusBadRequest, res.Status)
+		assert.Contains(t, string(res.Body), "No such name")
+	})
+}
+
+func TestCheckHealth(t *testing.T) {
+	checkHealth := func(t *testing.T, fixtures map[string]string) *backend.CheckHealthResult {
+		t.Helper()
+		server := newFakeOpenTSDB(t, fixtures)
+		service := ProvideService(httpclient.NewProvider())
+		res, err := service.CheckHealth(context.Background(), &backend.CheckHealthRequest{
+			PluginContext: newTestPluginContext(server.URL, 4),
+		})
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("should look up metric suggestions", func(t *testing.T) {
+		res := checkHealth(t, map[string]string{"/api/suggest": "suggest.json"})
+		assert.Equal(t, backend.HealthStatusOk, res.Status)
+	})
+
+	t.Run("should fail when OpenTSDB returns an error", func(t *testing.T) {
+		res := checkHealth(t, map[string]string{})
+		assert.Equal(t, backend.HealthStatusError, res.Status)
+		assert.Contains(t, res.Message, "400")
+	})
+}
@@ -1,9 +1,23 @@
 package opentsdb
 
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
 type OpenTsdbQuery struct {
-	Start   int64            `json:"start"`
-	End     int64            `json:"end"`
-	Queries []map[string]any `json:"queries"`
+	Start             int64            `json:"start"`
+	End               int64            `json:"end"`
+	Queries           []map[string]any `json:"queries"`
+	GlobalAnnotations bool             `json:"globalAnnotations,omitempty"`
+}
+
+type OpenTsdbFilter struct {
+	Type    string `json:"type"`
+	TagK    string `json:"tagk"`
+	Filter  string `json:"filter"`
+	GroupBy bool   `json:"groupBy"`
 }
 
 type OpenTsdbCommon struct {
@@ -13,10 +27,70 @@ type OpenTsdbCommon struct {
 
 type OpenTsdbResponse struct {
 	OpenTsdbCommon
-	DataPoints map[string]float64 `json:"dps"`
+	DataPoints map[string]OpenTsdbValue `json:"dps"`
 }
 
 type OpenTsdbResponse24 struct {
 	OpenTsdbCommon
-	DataPoints [][]float64 `json:"dps"`
+	DataPoints [][2]OpenTsdbValue `json:"dps"`
+}
+
+// OpenTsdbValue is a number of a response. Values are null for the intervals the `null` downsampling fill policy
+// fills, and "NaN" for the intervals the `nan` fill policy fills.
+type OpenTsdbValue struct {
+	Float64 float64
+	Valid   bool
+}
+
+func (v *OpenTsdbValue) UnmarshalJSON(b []byte) error {
+	var raw any
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	switch raw := raw.(type) {
+	case nil:
+		*v = OpenTsdbValue{}
+	case float64:
+		*v = OpenTsdbValue{Float64: raw, Valid: true}
+	case string:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value %q: %w", raw, err)
+		}
+		*v = OpenTsdbValue{Float64: f, Valid: true}
+	default:
+		return fmt.Errorf("invalid value %s", string(b))
+	}
+	return nil
+}
+
+// Ptr returns a pointer to the number, or nil if the value is null.
+func (v OpenTsdbValue) Ptr() *float64 {
+	if !v.Valid {
+		return nil
+	}
+	f := v.Float64
+	return &f
+}
+
+type OpenTsdbAnnotation struct {
+	TSUID       string            `json:"tsuid"`
+	Description string            `json:"description"`
+	Notes       string            `json:"notes"`
+	Custom      map[string]string `json:"custom"`
+	StartTime   float64           `json:"startTime"`
+	EndTime     float64           `json:"endTime"`
+}
+
+type OpenTsdbAnnotationResponse struct {
+	OpenTsdbCommon
+	Annotations       []OpenTsdbAnnotation `json:"annotations"`
+	GlobalAnnotations []OpenTsdbAnnotation `json:"globalAnnotations"`
+}
+
+type OpenTsdbLookupResponse struct {
+	Results []struct {
+		Metric string            `json:"metric"`
+		Tags   map[string]string `json:"tags"`
+	} `json:"results"`
 }
@@ -83,6 +83,18 @@ export const reducerTypes: Array<SelectableValue<string>> = [
   { value: ReducerID.sum, label: 'Sum', description: 'Get the sum of all values' },
   { value: ReducerID.count, label: 'Count', description: 'Get the number of values' },
   { value: ReducerID.last, label: 'Last', description: 'Get the last value' },
+  { value: ReducerID.first, label: 'First', description: 'Get the first value' },
+  { value: 'stddev', label: 'Standard deviation', description: 'Get the standard deviation of all values' },
+  { value: ReducerID.variance, label: 'Variance', description: 'Get the variance of all values' },
+  { value: ReducerID.range, label: 'Range', description: 'Get the difference between maximum and minimum values' },
+  { value: 'count_non_null', label: 'Count non-null', description: 'Get the number of non-null values' },
+  { value: ReducerID.diff, label: 'Difference', description: 'Get the difference between last and first values' },
+  { value: ReducerID.delta, label: 'Delta', description: 'Get the total increase, treating decreases as counter resets' },
+  { value: 'rate', label: 'Rate', description: 'Get the per-second rate of change between first and last values' },
+  { value: 'p50', label: '50th percentile', description: 'Get the 50th percentile' },
+  { value: 'p90', label: '90th percentile', description: 'Get the 90th percentile' },
+  { value: 'p95', label: '95th percentile', description: 'Get the 95th percentile' },
+  { value: 'p99', label: '99th percentile', description: 'Get the 99th percentile' },
 ];
 
 export enum ReducerMode {
@@ -115,6 +127,12 @@ export const downsamplingTypes: Array<SelectableValue<string>> = [
   { value: ReducerID.max, label: 'Max', description: 'Fill with the maximum value' },
   { value: ReducerID.mean, label: 'Mean', description: 'Fill with the average value' },
   { value: ReducerID.sum, label: 'Sum', description: 'Fill with the sum of all values' },
+  { value: ReducerID.median, label: 'Median', description: 'Fill with the median value' },
+  { value: ReducerID.first, label: 'First', description: 'Fill with the first value' },
+  { value: ReducerID.count, label: 'Count', description: 'Fill with the number of values' },
+  { value: 'stddev', label: 'Standard deviation', description: 'Fill with the standard deviation of all values' },
+  { value: 'p95', label: '95th percentile', description: 'Fill with the 95th percentile' },
+  { value: 'p99', label: '99th percentile', description: 'Fill with the 99th percentile' },
 ];
 
 export const upsamplingTypes: Array<SelectableValue<string>> = [
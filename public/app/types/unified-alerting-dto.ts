@@ -273,6 +273,7 @@ export interface PostableGrafanaRuleDefinition {
     metric: string;
     from: string;
     target_datasource_uid?: string;
+    target_table?: string;
   };
   intervalSeconds?: number;
   missing_series_evals_to_resolve?: number;